@@ -0,0 +1,88 @@
+package activity
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bkidd1/wash-cli/internal/services/journal"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/spf13/cobra"
+)
+
+// Command creates the activity command
+func Command() *cobra.Command {
+	var since, projectName, action, search string
+	var limit int
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "activity",
+		Short: "Review what wash has done",
+		Long: `Show the activity journal: every analysis run, saved note, deletion, restore,
+archive and config change, with its time, project and the command that did it.
+
+The journal is append-only and kept per month in ~/.wash/journal. Monitor notes
+are too frequent to journal one by one, but their compaction and pruning are.
+
+Examples:
+  # The latest activity
+  wash activity
+
+  # Where did that note go?
+  wash activity --action deleted --search 2024-05-01
+
+  # A project's analyses of the last week
+  wash activity --project my-project --action analysis --since 7d`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			filter := journal.Filter{Project: projectName, Action: action, Search: search}
+			if action != "" && !slices.Contains(journal.Actions, action) {
+				return fmt.Errorf("unknown action %q (expected %s)", action, strings.Join(journal.Actions, ", "))
+			}
+			if since != "" {
+				t, err := config.ParseTime(since)
+				if err != nil {
+					return err
+				}
+				filter.Since = t
+			}
+
+			entries, err := journal.Load(filter)
+			if err != nil {
+				return fmt.Errorf("failed to load activity journal: %w", err)
+			}
+			if limit > 0 && len(entries) > limit {
+				entries = entries[len(entries)-limit:]
+			}
+
+			if asJSON {
+				data, err := json.MarshalIndent(entries, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to encode activity: %w", err)
+				}
+				fmt.Println(string(data))
+				return nil
+			}
+
+			if len(entries) == 0 {
+				fmt.Println("No activity found")
+				return nil
+			}
+			for _, entry := range entries {
+				fmt.Println(entry)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&since, "since", "", "Only activity from this time on (date, RFC 3339 or age such as 7d)")
+	cmd.Flags().StringVarP(&projectName, "project", "p", "", "Only activity of this project")
+	cmd.Flags().StringVar(&action, "action", "", "Only this action: "+strings.Join(journal.Actions, ", "))
+	cmd.Flags().StringVar(&search, "search", "", "Only activity whose subject, detail or command contains this text")
+	cmd.Flags().IntVarP(&limit, "limit", "n", 50, "Show at most this many of the latest entries (0 shows all)")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print entries as JSON")
+
+	return cmd
+}
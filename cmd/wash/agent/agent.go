@@ -0,0 +1,114 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/agent"
+	"github.com/bkidd1/wash-cli/internal/services/api"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Flags
+	projectName string
+	connectAddr string
+	token       string
+)
+
+// Command creates the agent command
+func Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "agent",
+		Short: "Run the remote side of wash on a headless machine",
+		Long: `Run wash on a remote development machine and send activity to the monitor on your laptop.
+
+The agent watches the project for file changes and pushes them as monitor
+notes to the local API of 'wash monitor --listen' on your laptop, where they
+feed the same progress notes and summaries as screenshot analysis. Files
+changed within a couple of seconds of each other, such as a multi-file edit
+by an AI agent, are sent as one change once the files settle. In a Go module,
+each change also lists the files that import the changed packages, directly
+or through other packages, as likely affected. Notes are
+queued on disk and retried while the laptop is unreachable, so notes queued
+before a sleep, reboot or crash are delivered when the agent runs again.
+
+The API listens on localhost, so forward it over SSH:
+
+  # On the laptop
+  wash monitor --listen
+  ssh -R 7420:localhost:7420 devbox
+
+  # On the devbox, inside the project
+  wash agent --connect localhost:7420
+
+Use the same project name on both sides, or pass --project. The monitor only
+accepts notes carrying its token: the one given to it with --token or
+WASH_AGENT_TOKEN, or else the one it generates in agent_token in its data
+directory. Give the agent the same token with --token or WASH_AGENT_TOKEN.
+
+The agent does not call the AI itself. It only reports file activity; the
+laptop turns it into progress notes and summaries along with the rest of the
+monitor's notes.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get current directory: %w", err)
+			}
+			if projectName == "" {
+				projectName = filepath.Base(cwd)
+			}
+			if token == "" {
+				token = os.Getenv(api.TokenEnv)
+			}
+			if token == "" {
+				return fmt.Errorf("no API token: pass the monitor's token with --token or $%s", api.TokenEnv)
+			}
+
+			client := api.NewClient(connectAddr, token)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			health, err := client.Health(ctx)
+			cancel()
+			if err != nil {
+				return fmt.Errorf("failed to connect to wash monitor at %s: %w", connectAddr, err)
+			}
+
+			a, err := agent.NewAgent(client, projectName, cwd)
+			if err != nil {
+				return fmt.Errorf("failed to create agent: %w", err)
+			}
+
+			// Read before Start, which hands the queue to the delivery loop
+			queued := a.Queued()
+			if err := a.Start(); err != nil {
+				return fmt.Errorf("failed to start agent: %w", err)
+			}
+
+			fmt.Printf("Connected to wash %s at %s. Watching %s as project %s. Press Ctrl+C to stop.\n",
+				health.Version, connectAddr, cwd, projectName)
+			if queued > 0 {
+				fmt.Printf("Resuming delivery of %d queued notes from the last run\n", queued)
+			}
+
+			interrupt := make(chan os.Signal, 1)
+			signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+			<-interrupt
+
+			fmt.Println("\nStopping agent...")
+			a.Stop()
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&projectName, "project", "p", "", "Project name (defaults to current directory name)")
+	cmd.Flags().StringVarP(&connectAddr, "connect", "c", api.DefaultAddr, "Address of the wash monitor local API")
+	cmd.Flags().StringVar(&token, "token", "", "Shared API token (defaults to $"+api.TokenEnv+")")
+
+	return cmd
+}
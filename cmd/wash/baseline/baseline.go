@@ -0,0 +1,81 @@
+package baseline
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bkidd1/wash-cli/internal/services/baseline"
+	"github.com/bkidd1/wash-cli/internal/services/findings"
+	"github.com/bkidd1/wash-cli/internal/services/journal"
+	"github.com/spf13/cobra"
+)
+
+// Command creates the baseline command
+func Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "baseline",
+		Short: "Accept the current findings so analyses report only new ones",
+		Long: `Manages .wash-baseline.json, the findings the project has accepted. wash file
+leaves known findings out of its analyses, including when the model words them
+differently, and reports only new issues. Commit the file so CI runs with
+--fail-on gate only on new findings.
+
+A finding can also be suppressed where it occurs, with a wash:ignore comment on
+the line it concerns or on the line above it.
+
+Examples:
+  # Accept the latest findings of every analyzed file
+  wash baseline create
+
+  # Suppress findings about a line
+  defer f.Close() // wash:ignore`,
+	}
+
+	cmd.AddCommand(createCmd())
+	return cmd
+}
+
+func createCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "create",
+		Short: "Snapshot the current findings into .wash-baseline.json",
+		Long: `Adds the latest findings of every file analyzed with wash file to
+.wash-baseline.json in the current directory, creating it if needed. Findings
+already in the baseline stay there; delete the file to start over.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get current directory: %w", err)
+			}
+			projectName := filepath.Base(cwd)
+
+			findingsManager, err := findings.NewFindingsManager()
+			if err != nil {
+				return fmt.Errorf("failed to create findings manager: %w", err)
+			}
+			state, err := findingsManager.Load(projectName)
+			if err != nil {
+				return err
+			}
+			if len(state.Files) == 0 {
+				return fmt.Errorf("no findings for %s yet; analyze files with wash file first", projectName)
+			}
+
+			existing, err := baseline.Load(cwd)
+			if err != nil {
+				return err
+			}
+			updated, added := baseline.Update(existing, state)
+			if err := updated.Save(cwd); err != nil {
+				return err
+			}
+			journal.Record(journal.ActionSaved, projectName, baseline.FileName, fmt.Sprintf("baseline: %d findings added", added))
+
+			fmt.Printf("%s accepts %d findings in %d files (%d new)\n",
+				baseline.FileName, updated.Count(), len(updated.Files), added)
+			return nil
+		},
+	}
+}
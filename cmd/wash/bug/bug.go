@@ -3,21 +3,36 @@ package bug
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/bkidd1/wash-cli/cmd/wash/cmdutil"
 	"github.com/bkidd1/wash-cli/internal/services/analyzer"
+	"github.com/bkidd1/wash-cli/internal/services/attachments"
+	"github.com/bkidd1/wash-cli/internal/services/bugs"
+	"github.com/bkidd1/wash-cli/internal/services/hooks"
+	"github.com/bkidd1/wash-cli/internal/services/journal"
+	"github.com/bkidd1/wash-cli/internal/services/relevance"
+	"github.com/bkidd1/wash-cli/internal/utils/analyzable"
 	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/git"
+	"github.com/bkidd1/wash-cli/internal/utils/templates"
 	"github.com/spf13/cobra"
 )
 
 var (
 	// Flags
-	projectName string
-	priority    string
+	projectName    string
+	priority       string
+	attachPaths    []string
+	explain        bool
+	allowDuplicate bool
+	noGit          bool
+	contextFiles   int
 )
 
 // loadingAnimation shows a simple loading animation
@@ -37,6 +52,26 @@ func loadingAnimation(done chan bool) {
 	}
 }
 
+// newBugAnalyzer creates an analyzer with the context of the project in the current directory.
+// Context that fails to load is returned as warnings, since the analysis works without it.
+func newBugAnalyzer(cfg *config.Config, projectName string) (*analyzer.TerminalAnalyzer, []error) {
+	cwd, _ := os.Getwd()
+	a, conventions, warnings := cmdutil.NewAnalyzer(cfg, "", projectName, cwd)
+	if conventions != nil {
+		a.SetFormattingConventions(conventions.Describe(""))
+	}
+	return a, warnings
+}
+
+// sourceAttachments turns excerpts of related project files into analysis attachments
+func sourceAttachments(snippets []relevance.Snippet) []analyzer.BugAttachment {
+	sources := make([]analyzer.BugAttachment, 0, len(snippets))
+	for _, snippet := range snippets {
+		sources = append(sources, analyzer.BugAttachment{Name: snippet.Name(), Content: snippet.Content})
+	}
+	return sources
+}
+
 // Command creates the bug command
 func Command() *cobra.Command {
 	cmd := &cobra.Command{
@@ -46,10 +81,17 @@ func Command() *cobra.Command {
 
 This command will:
 1. Prompt you for a description of the bug
-2. Analyze your project context and recent changes
+2. Analyze your project context and recent changes; inside a git repository,
+   the branch, HEAD commit, status and latest diff are included (--no-git skips them).
+   Excerpts of up to --context-files project files that the description names,
+   whose symbols it mentions, or that changed recently are sent too
 3. Suggest potential causes and solutions
 4. Save the bug report with analysis for future reference
 
+Before the analysis, open bugs of the project are compared with the description.
+When one looks like the same bug, you are offered to link the new report to it
+instead; use --allow-duplicate to skip the check.
+
 The analysis includes:
 - Root cause analysis
 - Impact assessment
@@ -68,7 +110,21 @@ Examples:
   wash bug --priority high "Critical security vulnerability"
 
   # Report a bug for specific project
-  wash bug --project my-project "Database connection issues"`,
+  wash bug --project my-project "Database connection issues"
+
+  # Attach a log excerpt and a screenshot
+  wash bug --attach server.log --attach error.png "Login page crashes"
+
+  # Review open bugs and record a fix
+  wash bug list --status open
+  wash bug show 2024-05-01-10-30-00
+  wash bug resolve 2024-05-01-10-30-00 --note "Cleared stale sessions on upgrade"
+
+  # Go through open bugs one by one
+  wash bug triage
+
+  # Push a bug into your team's Jira backlog
+  wash bug publish 2024-05-01-10-30-00 --tracker jira`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			var description string
@@ -105,67 +161,161 @@ Examples:
 				return fmt.Errorf("failed to load config: %w", err)
 			}
 
+			// Store attachments before analysis so a bad path fails fast
+			var stored []attachments.Attachment
+			var attachedText []analyzer.BugAttachment
+			if len(attachPaths) > 0 {
+				attachmentManager, err := attachments.NewAttachmentManager(int64(cfg.AttachmentQuotaMB) << 20)
+				if err != nil {
+					return fmt.Errorf("failed to create attachment manager: %w", err)
+				}
+				for _, path := range attachPaths {
+					attachment, err := attachmentManager.AddFile(path)
+					if err != nil {
+						return fmt.Errorf("failed to attach %s: %w", path, err)
+					}
+					stored = append(stored, *attachment)
+
+					// Text files, such as logs and sources, are sent with the analysis; binary files are only stored
+					data, err := os.ReadFile(path)
+					if err != nil {
+						return fmt.Errorf("failed to read %s: %w", path, err)
+					}
+					var skip *analyzable.SkipError
+					if err := analyzable.CheckContent(path, data); errors.As(err, &skip) && skip.Reason == analyzable.ReasonBinary {
+						continue
+					}
+					attachedText = append(attachedText, analyzer.BugAttachment{Name: filepath.Base(path), Content: string(data)})
+				}
+			}
+
+			// Offer to link the report to an open bug that looks the same, instead of saving a duplicate
+			if !allowDuplicate {
+				linked, err := linkDuplicate(cfg.OpenAIKey, projectName, description, stored)
+				if err != nil {
+					fmt.Printf("Warning: Could not check for duplicate bugs: %v\n", err)
+				} else if linked {
+					return nil
+				}
+			}
+
 			// Create analyzer with project context
-			analyzer := analyzer.NewTerminalAnalyzer(cfg.OpenAIKey, cfg.ProjectGoal, cfg.RememberNotes)
+			analyzer, warnings := newBugAnalyzer(cfg, projectName)
+			for _, warning := range warnings {
+				fmt.Printf("Warning: %v\n", warning)
+			}
+			analyzer.SetBugAttachments(attachedText)
+
+			// Send the project files the description points to, so solutions can refer to the code
+			var sourceFiles []string
+			if cwd, err := os.Getwd(); err == nil && contextFiles > 0 {
+				snippets, err := relevance.Find(cwd, description, contextFiles)
+				if err != nil {
+					fmt.Printf("Warning: Could not search for related source files: %v\n", err)
+				}
+				analyzer.SetRelevantSources(sourceAttachments(snippets))
+				for _, snippet := range snippets {
+					sourceFiles = append(sourceFiles, snippet.Name())
+				}
+			}
+
+			// Capture the repository state so causes can be tied to recent changes
+			var gitContext *git.Context
+			if cwd, err := os.Getwd(); err == nil && !noGit {
+				if gitContext, err = git.CaptureContext(cwd); err != nil {
+					fmt.Printf("Warning: Could not capture git context: %v\n", err)
+				} else if gitContext != nil {
+					analyzer.SetGitContext(gitContext.String())
+				}
+			}
 
 			// Create a channel to signal when analysis is done
 			done := make(chan bool)
 			go loadingAnimation(done)
 
-			// Analyze the bug
+			// Analyze the bug, printing the causes and solutions as they are generated
+			analyzer.SetStream(os.Stdout, func() {
+				done <- true
+				fmt.Println("\nBug Analysis Results:")
+				fmt.Println("-------------------")
+			})
 			analysis, err := analyzer.AnalyzeBug(context.Background(), description)
-			if err != nil {
+			analyzer.SetStream(nil, nil)
+			if !analyzer.Streamed() {
+				// Signal that analysis is complete
 				done <- true
+			}
+			if err != nil {
 				return fmt.Errorf("failed to analyze bug: %w", err)
 			}
 
-			// Signal that analysis is complete
-			done <- true
-
-			// Create project-specific bug directory
-			bugDir := filepath.Join(os.Getenv("HOME"), ".wash", "projects", projectName, "bugs")
-			if err := os.MkdirAll(bugDir, 0755); err != nil {
-				return fmt.Errorf("failed to create bugs directory: %w", err)
+			if p := analyzer.LastProvenance(); p != nil && p.Substituted() {
+				fmt.Printf("Note: analyzed with %s\n", p)
 			}
 
-			// Generate bug report filename with timestamp
-			timestamp := time.Now().Format("2006-01-02-15-04-05")
-			bugFile := filepath.Join(bugDir, fmt.Sprintf("bug_%s.md", timestamp))
-
-			// Create bug report with analysis
-			report := fmt.Sprintf(`# Bug Report
-*Reported on %s*
-
-## Description
-%s
-
-## Suggested Solutions
-%s
+			// Show which notes went into the prompt and why
+			if explain {
+				if selection := analyzer.LastContext(); selection != nil {
+					fmt.Println("\nContext:")
+					fmt.Print(selection.Explain())
+				}
+			}
 
-## Priority
-%s
+			bugManager, err := bugs.NewBugManager()
+			if err != nil {
+				return fmt.Errorf("failed to initialize bug manager: %w", err)
+			}
 
-## Status
-Open
+			// Bug reports are named by the time they were reported
+			timestamp := time.Now().Format("2006-01-02-15-04-05")
 
-## Notes
-`,
-				time.Now().Format("2006-01-02 15:04:05"),
-				description,
-				analysis.SuggestedSolutions,
-				priority,
-			)
+			// Render the bug report with the user's template, if any
+			var fallback string
+			if p := analyzer.LastProvenance(); p != nil && p.Substituted() {
+				fallback = p.String()
+			}
+			report, err := templates.Render(templates.BugReport, templates.BugReportData{
+				ReportedAt:         time.Now(),
+				Project:            projectName,
+				Description:        description,
+				PotentialCauses:    analysis.PotentialCauses,
+				SuggestedSolutions: analysis.SuggestedSolutions,
+				Priority:           priority,
+				Status:             "Open",
+				Attachments:        stored,
+				Git:                gitContext,
+				Fallback:           fallback,
+				SourceFiles:        sourceFiles,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to render bug report: %w", err)
+			}
 
 			// Save bug report
-			if err := os.WriteFile(bugFile, []byte(report), 0644); err != nil {
+			bugFile, err := bugManager.Create(projectName, timestamp, report)
+			if err != nil {
 				return fmt.Errorf("failed to save bug report: %w", err)
 			}
 
-			// Print analysis to console
-			fmt.Println("\nBug Analysis Results:")
-			fmt.Println("-------------------")
-			fmt.Printf("\nSuggested Solutions:\n%s\n", analysis.SuggestedSolutions)
+			// Print analysis to console, unless it was streamed
+			if !analyzer.Streamed() {
+				fmt.Println("\nBug Analysis Results:")
+				fmt.Println("-------------------")
+				fmt.Printf("\nSuggested Solutions:\n%s\n", analysis.SuggestedSolutions)
+			}
 			fmt.Printf("\nBug report saved to: %s\n", bugFile)
+			fmt.Printf("Mark it fixed with: wash bug resolve %s --note \"...\"\n", timestamp)
+
+			// The journal is not encrypted, so it names the report but never quotes its description
+			journal.Record(journal.ActionAnalysis, projectName, "bug "+timestamp, "bug report, "+priority+" priority")
+			hooks.Notify(cfg, hooks.BugCreated, projectName, hooks.Bug{
+				ID:                 timestamp,
+				Description:        description,
+				Priority:           priority,
+				Path:               bugFile,
+				PotentialCauses:    analysis.PotentialCauses,
+				SuggestedSolutions: analysis.SuggestedSolutions,
+			})
 
 			return nil
 		},
@@ -174,6 +324,20 @@ Open
 	// Add flags
 	cmd.Flags().StringVarP(&projectName, "project", "p", "", "Project name (defaults to current directory name)")
 	cmd.Flags().StringVar(&priority, "priority", "medium", "Bug priority (low, medium, high)")
+	cmd.Flags().StringArrayVar(&attachPaths, "attach", nil, "File to attach to the bug report, such as a log or screenshot (repeatable); text files are also sent with the analysis")
+	cmd.Flags().BoolVar(&explain, "explain", false, "Show which notes were included in the prompt and why")
+	cmd.Flags().BoolVar(&noGit, "no-git", false, "Do not capture the git branch, status and recent diff")
+	cmd.Flags().IntVar(&contextFiles, "context-files", relevance.DefaultLimit, "Send up to this many project files related to the bug with the analysis (0 disables)")
+	cmd.Flags().BoolVar(&allowDuplicate, "allow-duplicate", false, "Save the report without checking for an open bug that matches it")
+
+	cmd.AddCommand(listCmd())
+	cmd.AddCommand(showCmd())
+	cmd.AddCommand(resolveCmd())
+	cmd.AddCommand(reopenCmd())
+	cmd.AddCommand(lessonsCmd())
+	cmd.AddCommand(reportCmd())
+	cmd.AddCommand(triageCmd())
+	cmd.AddCommand(publishCmd())
 
 	return cmd
 }
@@ -0,0 +1,54 @@
+package bug
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bkidd1/wash-cli/internal/services/attachments"
+	"github.com/bkidd1/wash-cli/internal/services/bugs"
+	"github.com/bkidd1/wash-cli/internal/services/retrieval"
+)
+
+// linkDuplicate looks for an open bug of the project that matches the description and offers to
+// record the new report in it instead. It reports whether the report was linked.
+func linkDuplicate(apiKey, project, description string, stored []attachments.Attachment) (bool, error) {
+	bugManager, err := bugs.NewBugManager()
+	if err != nil {
+		return false, err
+	}
+
+	// Without embeddings, only reports with the same fingerprint are found
+	var ranker bugs.Ranker
+	if retriever, err := retrieval.NewRetriever(apiKey); err == nil {
+		ranker = retriever
+	}
+	duplicate, err := bugManager.FindDuplicate(context.Background(), project, description, ranker)
+	if err != nil || duplicate == nil {
+		return false, err
+	}
+
+	match := "the same description"
+	if !duplicate.Exact {
+		match = fmt.Sprintf("%.0f%% similar", duplicate.Score*100)
+	}
+	fmt.Printf("This looks like bug %s (%s): %s\n", duplicate.Report.ID, match, duplicate.Report.Title())
+	fmt.Print("Link this report to it instead of saving a new one? (Y/n): ")
+	input, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && input == "" {
+		// Without an answer, save the report as usual
+		fmt.Println()
+		return false, nil
+	}
+	if answer := strings.ToLower(strings.TrimSpace(input)); answer != "" && answer != "y" && answer != "yes" {
+		return false, nil
+	}
+
+	if err := bugManager.Link(duplicate.Report, description, stored); err != nil {
+		return false, err
+	}
+	fmt.Printf("Linked to bug %s: %s\n", duplicate.Report.ID, duplicate.Report.Path)
+	return true, nil
+}
@@ -0,0 +1,240 @@
+package bug
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bkidd1/wash-cli/cmd/wash/cmdutil"
+	"github.com/bkidd1/wash-cli/internal/services/bugs"
+	"github.com/bkidd1/wash-cli/internal/services/notes"
+	"github.com/spf13/cobra"
+)
+
+func listCmd() *cobra.Command {
+	var project, status string
+	var archived bool
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List a project's bug reports",
+		Long: `List a project's bug reports, newest first, with the ID that 'wash bug show',
+'wash bug resolve' and 'wash bug reopen' take. Any unique prefix of an ID works.
+
+Examples:
+  wash bug list
+  wash bug list --status open
+  wash bug list --project my-app --archived`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			project, err := cmdutil.ProjectName(project)
+			if err != nil {
+				return err
+			}
+			switch strings.ToLower(status) {
+			case "", "all", "open", "resolved":
+			default:
+				return fmt.Errorf("invalid status %q (use open, resolved or all)", status)
+			}
+
+			bugManager, err := bugs.NewBugManager()
+			if err != nil {
+				return fmt.Errorf("failed to create bug manager: %w", err)
+			}
+			reports, err := bugManager.List(project, archived)
+			if err != nil {
+				return fmt.Errorf("failed to list bug reports: %w", err)
+			}
+
+			shown := 0
+			for _, report := range reports {
+				if (status == "open" && report.IsResolved()) || (status == "resolved" && !report.IsResolved()) {
+					continue
+				}
+				reportStatus := report.Status()
+				if report.Archived {
+					reportStatus += " (archived)"
+				}
+				fmt.Printf("%s  %-19s %-7s %s\n", report.ID, reportStatus, report.Priority(), truncate(report.Title(), 60))
+				shown++
+			}
+			if shown == 0 {
+				fmt.Printf("No bug reports found for project %s\n", project)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&project, "project", "p", "", "Project name (defaults to current directory name)")
+	cmd.Flags().StringVar(&status, "status", "all", "Only list bugs with this status: open, resolved or all")
+	cmd.Flags().BoolVar(&archived, "archived", false, "Include archived bug reports")
+
+	return cmd
+}
+
+func showCmd() *cobra.Command {
+	var project string
+
+	cmd := &cobra.Command{
+		Use:   "show <id>",
+		Short: "Print a bug report",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			project, err := cmdutil.ProjectName(project)
+			if err != nil {
+				return err
+			}
+			bugManager, err := bugs.NewBugManager()
+			if err != nil {
+				return fmt.Errorf("failed to create bug manager: %w", err)
+			}
+			report, err := bugManager.Get(project, args[0])
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("ID: %s\nFile: %s\n\n", report.ID, report.Path)
+			fmt.Print(report.String())
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&project, "project", "p", "", "Project name (defaults to current directory name)")
+
+	return cmd
+}
+
+func resolveCmd() *cobra.Command {
+	var project, note string
+
+	cmd := &cobra.Command{
+		Use:   "resolve <id>",
+		Short: "Mark a bug resolved and record the fix",
+		Long: `Set a bug report's status to Resolved and record when, by whom and, with
+--note, how it was fixed in a Resolution section.
+
+A short lesson from the bug and its fix is saved with the project and
+considered in later 'wash file' and 'wash bug' analyses, so the same mistake
+is flagged earlier next time. Reopening the bug removes its lesson.
+
+Examples:
+  wash bug resolve 2024-05-01-10-30-00 --note "Cleared stale sessions on upgrade"
+  wash bug resolve 2024-05-01 --note "See commit abc123"`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			project, err := cmdutil.ProjectName(project)
+			if err != nil {
+				return err
+			}
+			bugManager, err := bugs.NewBugManager()
+			if err != nil {
+				return fmt.Errorf("failed to create bug manager: %w", err)
+			}
+			report, err := bugManager.Get(project, args[0])
+			if err != nil {
+				return err
+			}
+			if err := bugManager.Resolve(report, note, notes.CurrentUser()); err != nil {
+				return err
+			}
+			if err := bugManager.RecordLesson(report, note); err != nil {
+				fmt.Printf("Warning: Could not save the lesson of bug %s: %v\n", report.ID, err)
+			}
+			fmt.Printf("Resolved bug %s: %s\n", report.ID, report.Title())
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&project, "project", "p", "", "Project name (defaults to current directory name)")
+	cmd.Flags().StringVarP(&note, "note", "n", "", "How the bug was fixed")
+
+	return cmd
+}
+
+func reopenCmd() *cobra.Command {
+	var project string
+
+	cmd := &cobra.Command{
+		Use:   "reopen <id>",
+		Short: "Mark a resolved bug open again",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			project, err := cmdutil.ProjectName(project)
+			if err != nil {
+				return err
+			}
+			bugManager, err := bugs.NewBugManager()
+			if err != nil {
+				return fmt.Errorf("failed to create bug manager: %w", err)
+			}
+			report, err := bugManager.Get(project, args[0])
+			if err != nil {
+				return err
+			}
+			if err := bugManager.Reopen(report); err != nil {
+				return err
+			}
+			if err := bugManager.ForgetLesson(report); err != nil {
+				fmt.Printf("Warning: Could not remove the lesson of bug %s: %v\n", report.ID, err)
+			}
+			fmt.Printf("Reopened bug %s: %s\n", report.ID, report.Title())
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&project, "project", "p", "", "Project name (defaults to current directory name)")
+
+	return cmd
+}
+
+func lessonsCmd() *cobra.Command {
+	var project string
+
+	cmd := &cobra.Command{
+		Use:   "lessons",
+		Short: "List the lessons learned from resolved bugs",
+		Long: `List the lessons saved when the project's bugs were resolved, newest first.
+The most relevant ones are considered in 'wash file' and 'wash bug' analyses.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			project, err := cmdutil.ProjectName(project)
+			if err != nil {
+				return err
+			}
+			bugManager, err := bugs.NewBugManager()
+			if err != nil {
+				return fmt.Errorf("failed to create bug manager: %w", err)
+			}
+			lessons, err := bugManager.Lessons(project)
+			if err != nil {
+				return err
+			}
+			if len(lessons) == 0 {
+				fmt.Printf("No lessons from resolved bugs for project %s\n", project)
+				return nil
+			}
+			for _, lesson := range lessons {
+				fmt.Printf("%s  %s\n", lesson.BugID, lesson.Title)
+				if lesson.Cause != "" {
+					fmt.Printf("  Cause: %s\n", lesson.Cause)
+				}
+				if lesson.Fix != "" {
+					fmt.Printf("  Fix: %s\n", lesson.Fix)
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&project, "project", "p", "", "Project name (defaults to current directory name)")
+
+	return cmd
+}
+
+// truncate shortens s to width characters, marking the cut with an ellipsis
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	return string(runes[:width-1]) + "…"
+}
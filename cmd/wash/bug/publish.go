@@ -0,0 +1,83 @@
+package bug
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bkidd1/wash-cli/cmd/wash/cmdutil"
+	"github.com/bkidd1/wash-cli/internal/services/bugs"
+	"github.com/bkidd1/wash-cli/internal/services/tracker"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/spf13/cobra"
+)
+
+func publishCmd() *cobra.Command {
+	var project, trackerName string
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "publish <id>",
+		Short: "Publish a bug report to an issue tracker",
+		Long: `Create an issue from an analyzed bug report in your team's tracker and record
+the issue in the report, so it is not published twice.
+
+Jira is configured in wash.yaml; Jira Cloud needs the account email and an API
+token, Jira Server and Data Center a personal access token:
+
+  jira:
+    base_url: https://example.atlassian.net
+    project_key: APP
+    email: you@example.com
+    token: your-api-token    # or set WASH_JIRA_TOKEN
+    issue_type: Bug          # optional
+
+Examples:
+  wash bug publish 2024-05-01-10-30-00 --tracker jira
+  wash bug publish 2024-05-01 --tracker jira --force`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			project, err := cmdutil.ProjectName(project)
+			if err != nil {
+				return err
+			}
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			t, err := tracker.New(trackerName, cfg)
+			if err != nil {
+				return err
+			}
+
+			bugManager, err := bugs.NewBugManager()
+			if err != nil {
+				return fmt.Errorf("failed to create bug manager: %w", err)
+			}
+			report, err := bugManager.Get(project, args[0])
+			if err != nil {
+				return err
+			}
+			if issue := report.Published(t.Name()); issue != "" && !force {
+				return fmt.Errorf("bug %s was already published to %s as %s (use --force to publish it again)", report.ID, t.Name(), issue)
+			}
+
+			issue, err := t.Publish(context.Background(), report)
+			if err != nil {
+				return fmt.Errorf("failed to publish bug %s: %w", report.ID, err)
+			}
+			if err := bugManager.RecordPublished(report, t.Name(), issue.Key+" "+issue.URL); err != nil {
+				fmt.Printf("Warning: published as %s but could not record it in the report: %v\n", issue.Key, err)
+			}
+			fmt.Printf("Published bug %s to %s: %s\n", report.ID, t.Name(), issue.URL)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&project, "project", "p", "", "Project name (defaults to current directory name)")
+	cmd.Flags().StringVarP(&trackerName, "tracker", "t", "", "Issue tracker to publish to ("+strings.Join(tracker.Trackers, ", ")+")")
+	cmd.Flags().BoolVar(&force, "force", false, "Publish again even if the bug was already published")
+	cmd.MarkFlagRequired("tracker")
+
+	return cmd
+}
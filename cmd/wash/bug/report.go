@@ -0,0 +1,71 @@
+package bug
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bkidd1/wash-cli/cmd/wash/cmdutil"
+	"github.com/bkidd1/wash-cli/internal/services/bugs"
+	"github.com/spf13/cobra"
+)
+
+func reportCmd() *cobra.Command {
+	var project, format, outPath string
+	var archived bool
+
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Summarize a project's bugs by priority and status",
+		Long: `Print an overview of all of a project's bug reports, grouped by priority and
+status, with counts and how many days each bug has been open. Resolved bugs
+show how long they were open before they were resolved.
+
+Examples:
+  wash bug report
+  wash bug report --format markdown --out BUGS.md
+  wash bug report --project my-app --archived`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			project, err := cmdutil.ProjectName(project)
+			if err != nil {
+				return err
+			}
+			if format != "table" && format != "markdown" {
+				return fmt.Errorf("invalid format %q (use table or markdown)", format)
+			}
+
+			bugManager, err := bugs.NewBugManager()
+			if err != nil {
+				return fmt.Errorf("failed to create bug manager: %w", err)
+			}
+			reports, err := bugManager.List(project, archived)
+			if err != nil {
+				return fmt.Errorf("failed to list bug reports: %w", err)
+			}
+
+			dashboard := bugs.NewDashboard(project, reports, time.Now())
+			output := dashboard.Table()
+			if format == "markdown" {
+				output = dashboard.Markdown()
+			}
+
+			if outPath == "" {
+				fmt.Print(output)
+				return nil
+			}
+			if err := os.WriteFile(outPath, []byte(output), 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", outPath, err)
+			}
+			fmt.Printf("Wrote the bug report overview for %s to %s\n", project, outPath)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&project, "project", "p", "", "Project name (defaults to current directory name)")
+	cmd.Flags().StringVar(&format, "format", "table", "Output format: table or markdown")
+	cmd.Flags().StringVarP(&outPath, "out", "o", "", "Write the overview to this file instead of printing it")
+	cmd.Flags().BoolVar(&archived, "archived", false, "Include archived bug reports")
+
+	return cmd
+}
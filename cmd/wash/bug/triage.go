@@ -0,0 +1,435 @@
+package bug
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/cmd/wash/cmdutil"
+	"github.com/bkidd1/wash-cli/internal/services/analyzer"
+	"github.com/bkidd1/wash-cli/internal/services/bugs"
+	"github.com/bkidd1/wash-cli/internal/services/notes"
+	"github.com/bkidd1/wash-cli/internal/services/relevance"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/git"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+)
+
+var (
+	headerStyle = lipgloss.NewStyle().Bold(true)
+	dimStyle    = lipgloss.NewStyle().Faint(true)
+	paneStyle   = lipgloss.NewStyle().Border(lipgloss.NormalBorder()).BorderForeground(lipgloss.Color("8"))
+)
+
+const triageHelp = "←/→ bug · 1/2/3 high/medium/low · r resolve · o reopen · n note · a re-analyze · pgup/pgdn scroll · q quit"
+
+// triagePriorities are the priorities set by the number keys
+var triagePriorities = map[string]string{"1": "high", "2": "medium", "3": "low"}
+
+func triageCmd() *cobra.Command {
+	var project string
+
+	cmd := &cobra.Command{
+		Use:   "triage",
+		Short: "Walk through open bugs and triage them interactively",
+		Long: `Walk through a project's open bugs one by one, highest priority and oldest
+first, reading each report with its analysis and deciding what to do with it.
+
+Keys:
+  ←/→ or h/l      Previous or next bug; space also moves to the next one
+  1, 2, 3         Set the priority to high, medium or low
+  r               Resolve the bug, asking how it was fixed
+  o               Reopen a bug resolved during triage
+  n               Add a note to the report
+  a               Analyze the bug again with the current remember notes, pinned
+                  notes, lessons, git state and related source files, replacing the suggested solutions
+  pgup/pgdn       Scroll the report
+  q               Quit
+
+Every change is saved to the report right away.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			project, err := cmdutil.ProjectName(project)
+			if err != nil {
+				return err
+			}
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			bugManager, err := bugs.NewBugManager()
+			if err != nil {
+				return fmt.Errorf("failed to create bug manager: %w", err)
+			}
+			reports, err := bugManager.List(project, false)
+			if err != nil {
+				return fmt.Errorf("failed to list bug reports: %w", err)
+			}
+
+			// Triage in dashboard order: most urgent priority first, oldest first within it
+			var open []*bugs.Report
+			for _, group := range bugs.NewDashboard(project, reports, time.Now()).Groups {
+				for _, report := range group.Reports {
+					if !report.IsResolved() {
+						open = append(open, report)
+					}
+				}
+			}
+			if len(open) == 0 {
+				fmt.Printf("No open bugs to triage for project %s\n", project)
+				return nil
+			}
+
+			model := newTriageModel(cfg, bugManager, project, open)
+			program := tea.NewProgram(model, tea.WithAltScreen(), tea.WithOutput(os.Stdout))
+			restore, err := silenceOutput()
+			if err != nil {
+				return err
+			}
+			_, err = program.Run()
+			// Warnings and hook output held back while the TUI had the screen
+			fmt.Fprint(os.Stderr, restore())
+			if err != nil {
+				return fmt.Errorf("failed to run triage: %w", err)
+			}
+			fmt.Print(model.summary())
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&project, "project", "p", "", "Project name (defaults to current directory name)")
+
+	return cmd
+}
+
+// silenceOutput points os.Stdout and os.Stderr at a temporary file while the TUI draws on the
+// terminal, so that warnings and the output of hooks run by the services cannot draw over it.
+// restore puts them back and returns what was written meanwhile.
+func silenceOutput() (restore func() string, err error) {
+	held, err := os.CreateTemp("", "wash-triage-*.log")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output file: %w", err)
+	}
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = held, held
+	return func() string {
+		os.Stdout, os.Stderr = stdout, stderr
+		held.Close()
+		defer os.Remove(held.Name())
+		data, _ := os.ReadFile(held.Name())
+		return string(data)
+	}, nil
+}
+
+// triageInput is what the text input is collecting, if anything
+type triageInput int
+
+const (
+	inputNone triageInput = iota
+	inputResolution
+	inputNote
+)
+
+// analysisMsg carries the result of a re-analysis back to the model
+type analysisMsg struct {
+	report   *bugs.Report
+	analysis *analyzer.BugAnalysis
+	warnings []error
+	err      error
+}
+
+// triageModel is the bubbletea model behind wash bug triage
+type triageModel struct {
+	cfg        *config.Config
+	bugManager *bugs.BugManager
+	project    string
+	reports    []*bugs.Report
+	cursor     int
+
+	input     textinput.Model
+	inputKind triageInput
+	analyzing bool
+	status    string
+
+	// changed counts the triage decisions, for the summary printed afterwards
+	changed map[string]int
+
+	report viewport.Model
+	width  int
+	height int
+}
+
+func newTriageModel(cfg *config.Config, bugManager *bugs.BugManager, project string, reports []*bugs.Report) *triageModel {
+	m := &triageModel{
+		cfg:        cfg,
+		bugManager: bugManager,
+		project:    project,
+		reports:    reports,
+		input:      textinput.New(),
+		changed:    make(map[string]int),
+		report:     viewport.New(0, 0),
+	}
+	m.loadReport()
+	return m
+}
+
+func (m *triageModel) Init() tea.Cmd {
+	return nil
+}
+
+// current returns the bug being triaged
+func (m *triageModel) current() *bugs.Report {
+	return m.reports[m.cursor]
+}
+
+// loadReport shows the current bug in the report pane
+func (m *triageModel) loadReport() {
+	m.report.SetContent(lipgloss.NewStyle().Width(m.report.Width).Render(m.current().String()))
+	m.report.GotoTop()
+}
+
+func (m *triageModel) move(delta int) {
+	m.cursor = min(max(m.cursor+delta, 0), len(m.reports)-1)
+	m.loadReport()
+}
+
+func (m *triageModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.report.Width = max(m.width-2, 10)
+		m.report.Height = max(m.height-4, 1)
+		m.loadReport()
+		return m, nil
+
+	case analysisMsg:
+		m.analyzing = false
+		m.applyAnalysis(msg)
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.inputKind != inputNone {
+			return m.updateInput(msg)
+		}
+
+		m.status = ""
+		switch msg.String() {
+		case "q", "ctrl+c":
+			return m, tea.Quit
+		case "right", "l", " ":
+			m.move(1)
+		case "left", "h", "p":
+			m.move(-1)
+		case "1", "2", "3":
+			m.setPriority(triagePriorities[msg.String()])
+		case "r":
+			if m.current().IsResolved() {
+				m.status = "Already resolved; o reopens it"
+				break
+			}
+			return m, m.prompt(inputResolution, "How was it fixed? ")
+		case "o":
+			m.reopen()
+		case "n":
+			return m, m.prompt(inputNote, "Note: ")
+		case "a":
+			if m.analyzing {
+				m.status = "Already analyzing"
+				break
+			}
+			m.analyzing = true
+			m.status = "Analyzing " + m.current().ID + " again..."
+			return m, m.reanalyze(m.current())
+		case "pgdown", "ctrl+d", "down", "j":
+			m.report.HalfPageDown()
+		case "pgup", "ctrl+u", "up", "k":
+			m.report.HalfPageUp()
+		}
+		return m, nil
+	}
+	return m, nil
+}
+
+// prompt focuses the text input to collect a resolution note or a note
+func (m *triageModel) prompt(kind triageInput, label string) tea.Cmd {
+	m.inputKind = kind
+	m.input.Prompt = label
+	m.input.SetValue("")
+	return m.input.Focus()
+}
+
+// updateInput sends keys to the text input; enter saves it and esc cancels
+func (m *triageModel) updateInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		kind, value := m.inputKind, m.input.Value()
+		m.inputKind = inputNone
+		m.input.Blur()
+		if kind == inputResolution {
+			m.resolve(value)
+		} else {
+			m.addNote(value)
+		}
+		return m, nil
+	case "esc":
+		m.inputKind = inputNone
+		m.input.Blur()
+		m.status = "Cancelled"
+		return m, nil
+	case "ctrl+c":
+		return m, tea.Quit
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m *triageModel) setPriority(priority string) {
+	report := m.current()
+	if err := m.bugManager.SetPriority(report, priority); err != nil {
+		m.status = err.Error()
+		return
+	}
+	m.changed["reprioritized"]++
+	m.status = fmt.Sprintf("Priority of %s set to %s", report.ID, priority)
+	m.loadReport()
+}
+
+func (m *triageModel) resolve(note string) {
+	report := m.current()
+	if err := m.bugManager.Resolve(report, note, notes.CurrentUser()); err != nil {
+		m.status = err.Error()
+		return
+	}
+	m.status = "Resolved " + report.ID
+	if err := m.bugManager.RecordLesson(report, note); err != nil {
+		m.status += fmt.Sprintf(" (could not save its lesson: %v)", err)
+	}
+	m.changed["resolved"]++
+	m.loadReport()
+}
+
+func (m *triageModel) reopen() {
+	report := m.current()
+	if err := m.bugManager.Reopen(report); err != nil {
+		m.status = err.Error()
+		return
+	}
+	m.status = "Reopened " + report.ID
+	if err := m.bugManager.ForgetLesson(report); err != nil {
+		m.status += fmt.Sprintf(" (could not remove its lesson: %v)", err)
+	}
+	m.changed["resolved"]--
+	m.loadReport()
+}
+
+func (m *triageModel) addNote(note string) {
+	report := m.current()
+	if err := m.bugManager.AddNote(report, note, notes.CurrentUser()); err != nil {
+		m.status = err.Error()
+		return
+	}
+	m.changed["noted"]++
+	m.status = "Added a note to " + report.ID
+	m.loadReport()
+}
+
+// reanalyze analyzes a bug again in the background with the project's current context. The
+// report is only read here, while the model may still change it, and is updated once the
+// analysis is back in applyAnalysis.
+func (m *triageModel) reanalyze(report *bugs.Report) tea.Cmd {
+	cfg := *m.cfg
+	project := m.project
+	description := report.Section("Description")
+	return func() tea.Msg {
+		a, warnings := newBugAnalyzer(&cfg, project)
+		if cwd, err := os.Getwd(); err == nil {
+			if gitContext, err := git.CaptureContext(cwd); err != nil {
+				warnings = append(warnings, fmt.Errorf("could not capture git context: %w", err))
+			} else if gitContext != nil {
+				a.SetGitContext(gitContext.String())
+			}
+		}
+		if cwd, err := os.Getwd(); err == nil {
+			snippets, err := relevance.Find(cwd, description, relevance.DefaultLimit)
+			if err != nil {
+				warnings = append(warnings, fmt.Errorf("could not search for related source files: %w", err))
+			}
+			a.SetRelevantSources(sourceAttachments(snippets))
+		}
+		analysis, err := a.AnalyzeBug(context.Background(), description)
+		return analysisMsg{report: report, analysis: analysis, warnings: warnings, err: err}
+	}
+}
+
+// applyAnalysis saves a finished re-analysis into its report
+func (m *triageModel) applyAnalysis(msg analysisMsg) {
+	if msg.err != nil {
+		m.status = fmt.Sprintf("Could not analyze %s: %v", msg.report.ID, msg.err)
+		return
+	}
+	if err := m.bugManager.UpdateAnalysis(msg.report, msg.analysis.PotentialCauses, msg.analysis.SuggestedSolutions); err != nil {
+		m.status = err.Error()
+		return
+	}
+	if err := m.bugManager.AddNote(msg.report, "Analyzed again during triage", notes.CurrentUser()); err != nil {
+		m.status = err.Error()
+		return
+	}
+	m.changed["re-analyzed"]++
+	m.status = "Updated the analysis of " + msg.report.ID
+	if len(msg.warnings) > 0 {
+		m.status += fmt.Sprintf(" (warning: %v)", msg.warnings[0])
+	}
+	if msg.report == m.current() {
+		m.loadReport()
+	}
+}
+
+func (m *triageModel) View() string {
+	if m.width == 0 {
+		return ""
+	}
+
+	report := m.current()
+	priority := report.Priority()
+	if priority == "" {
+		priority = "no priority"
+	}
+	header := headerStyle.Render(fmt.Sprintf("%s  bug %d of %d", m.project, m.cursor+1, len(m.reports))) +
+		dimStyle.Render(fmt.Sprintf("  %s · %s · %s · open %d days", report.ID, priority, report.Status(), report.DaysOpen(time.Now())))
+	body := paneStyle.Width(m.report.Width).Height(m.report.Height).Render(m.report.View())
+
+	footer := dimStyle.Render(triageHelp)
+	switch {
+	case m.inputKind != inputNone:
+		footer = m.input.View()
+	case m.status != "":
+		footer = m.status
+	case m.analyzing:
+		footer = "Analyzing again..."
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, header, body, footer)
+}
+
+// summary describes the triage decisions once the TUI has closed
+func (m *triageModel) summary() string {
+	var parts []string
+	for _, kind := range []string{"reprioritized", "resolved", "noted", "re-analyzed"} {
+		if n := m.changed[kind]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, kind))
+		}
+	}
+	if len(parts) == 0 {
+		return "No bugs were changed\n"
+	}
+	return "Triaged: " + strings.Join(parts, ", ") + "\n"
+}
@@ -0,0 +1,135 @@
+package clean
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/analyzer"
+	"github.com/bkidd1/wash-cli/internal/services/screenshot"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/spf13/cobra"
+)
+
+// Command creates the clean command
+func Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "clean",
+		Short: "Delete files wash leaves on disk",
+		Long: `Delete files wash keeps outside its notes.
+
+Examples:
+  wash clean screenshots
+  wash clean screenshots --older-than 7d --dry-run
+  wash clean cache --older-than 7d`,
+	}
+
+	cmd.AddCommand(screenshotsCmd())
+	cmd.AddCommand(cacheCmd())
+
+	return cmd
+}
+
+func screenshotsCmd() *cobra.Command {
+	var olderThan string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "screenshots",
+		Short: "Delete the screenshots wash monitor kept",
+		Long: `Delete the screenshots wash monitor kept in the screenshots directory of the
+data directory, ~/.wash or --data-dir.
+
+The monitor deletes each screenshot once it is analyzed, unless
+retention.screenshots keeps them for an age such as 7d, after which a running
+monitor deletes them once a day. This deletes every screenshot, or with
+--older-than those older than an age.
+
+Examples:
+  wash clean screenshots
+  wash clean screenshots --older-than 7d --dry-run`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cutoff := time.Now()
+			if olderThan != "" {
+				age, err := config.ParseAge(olderThan)
+				if err != nil {
+					return fmt.Errorf("invalid --older-than: %w", err)
+				}
+				cutoff = cutoff.Add(-age)
+			}
+
+			deleted, size, err := screenshot.Clean(cutoff, dryRun)
+			if err != nil {
+				return err
+			}
+			dir, err := screenshot.Dir()
+			if err != nil {
+				return err
+			}
+
+			verb := "Deleted"
+			if dryRun {
+				verb = "Would delete"
+			}
+			fmt.Printf("%s %d screenshots (%.1f MB) from %s.\n", verb, deleted, float64(size)/(1<<20), dir)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&olderThan, "older-than", "", "Delete only screenshots older than this age, e.g. 7d")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Report what would be deleted without deleting")
+
+	return cmd
+}
+
+func cacheCmd() *cobra.Command {
+	var olderThan string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Delete the cached analysis answers",
+		Long: `Delete the answers 'wash file' cached in the analyze directory of the data
+directory, ~/.wash or --data-dir.
+
+Cached answers are kept for retention.analysis_cache, 30d by default, and the
+cache deletes expired ones once a day as it is used. This deletes every cached
+answer, or with --older-than those older than an age.
+
+Examples:
+  wash clean cache
+  wash clean cache --older-than 7d --dry-run`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cutoff := time.Now()
+			if olderThan != "" {
+				age, err := config.ParseAge(olderThan)
+				if err != nil {
+					return fmt.Errorf("invalid --older-than: %w", err)
+				}
+				cutoff = cutoff.Add(-age)
+			}
+
+			deleted, size, err := analyzer.CleanCache(cutoff, dryRun)
+			if err != nil {
+				return err
+			}
+			dir, err := analyzer.CacheDir()
+			if err != nil {
+				return err
+			}
+
+			verb := "Deleted"
+			if dryRun {
+				verb = "Would delete"
+			}
+			fmt.Printf("%s %d cached answers (%.1f MB) from %s.\n", verb, deleted, float64(size)/(1<<20), dir)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&olderThan, "older-than", "", "Delete only answers older than this age, e.g. 7d")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Report what would be deleted without deleting")
+
+	return cmd
+}
@@ -0,0 +1,105 @@
+// Package cmdutil holds the helpers wash commands share
+package cmdutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bkidd1/wash-cli/internal/services/analyzer"
+	"github.com/bkidd1/wash-cli/internal/services/bugs"
+	"github.com/bkidd1/wash-cli/internal/services/goals"
+	"github.com/bkidd1/wash-cli/internal/services/notes"
+	"github.com/bkidd1/wash-cli/internal/services/retrieval"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/language"
+	"github.com/bkidd1/wash-cli/internal/utils/style"
+)
+
+// ProjectName returns the --project flag when set, or else the current directory name
+func ProjectName(flag string) (string, error) {
+	if flag != "" {
+		return flag, nil
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current directory: %w", err)
+	}
+	return filepath.Base(cwd), nil
+}
+
+// NewAnalyzer creates an analyzer with the context of the project in dir: its goal, or goal when
+// set, remember and pinned notes, lessons from resolved bugs, and languages. The project's
+// formatting conventions are returned for the caller to describe per file, and context that fails
+// to load is returned as warnings, since the analysis works without it.
+func NewAnalyzer(cfg *config.Config, goal, projectName, dir string) (*analyzer.TerminalAnalyzer, *style.Conventions, []error) {
+	var warnings []error
+	if goal != "" {
+		cfg.ProjectGoal = goal
+	} else {
+		cfg.ProjectGoal = goals.Resolve(projectName, cfg)
+	}
+	a := analyzer.NewTerminalAnalyzer(cfg.OpenAIKey, cfg.ProjectGoal, cfg.RememberNotes)
+	a.SetModel(cfg.Model)
+	a.SetFallbackModels(cfg.FallbackModels)
+
+	// Select remember notes by relevance instead of sending all of them
+	if retriever, err := retrieval.NewRetriever(cfg.OpenAIKey); err == nil {
+		a.SetRetriever(retriever)
+	}
+
+	// Include the notes saved with wash remember alongside the config list
+	if store, err := notes.NewRememberStore(cfg); err == nil {
+		if err := a.UseRememberNotes(store, projectName); err != nil {
+			warnings = append(warnings, err)
+		}
+	}
+
+	// Include the notes pinned with wash notes pin
+	if notesManager, err := notes.NewNotesManager(); err == nil {
+		if err := a.UsePinnedNotes(notesManager, projectName); err != nil {
+			warnings = append(warnings, err)
+		}
+	}
+
+	// Include what resolved bugs taught, so repeated mistakes are flagged
+	if bugManager, err := bugs.NewBugManager(); err == nil {
+		if err := a.UseLessons(bugManager, projectName); err != nil {
+			warnings = append(warnings, err)
+		}
+	}
+
+	// Detect project languages so suggestions match the codebase
+	if profile, err := language.Load(projectName, dir); err == nil {
+		a.SetLanguageProfile(profile.String())
+	}
+	conventions, err := style.Detect(dir)
+	if err != nil {
+		return a, nil, warnings
+	}
+	return a, conventions, warnings
+}
+
+// AnalyzeStreaming runs an analysis behind animate, a loading animation that stops once done
+// receives, printing the analysis under heading as the model generates it. An analysis that was
+// not streamed is printed under heading once it is done.
+func AnalyzeStreaming(a *analyzer.TerminalAnalyzer, animate func(done chan bool), heading string, analyze func() (string, error)) (string, error) {
+	done := make(chan bool)
+	go animate(done)
+	a.SetStream(os.Stdout, func() {
+		done <- true
+		fmt.Printf("\n%s:\n%s\n", heading, strings.Repeat("-", len(heading)))
+	})
+	defer a.SetStream(nil, nil)
+
+	result, err := analyze()
+	if !a.Streamed() {
+		done <- true
+		if err == nil {
+			fmt.Printf("\n%s:\n%s\n", heading, strings.Repeat("-", len(heading)))
+			fmt.Println(result)
+		}
+	}
+	return result, err
+}
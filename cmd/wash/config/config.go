@@ -2,11 +2,17 @@ package config
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
+	"github.com/bkidd1/wash-cli/internal/services/journal"
+	"github.com/bkidd1/wash-cli/internal/services/usage"
 	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/editor"
 	"github.com/spf13/cobra"
 )
 
@@ -21,6 +27,10 @@ func Command() *cobra.Command {
 	// Add subcommands
 	cmd.AddCommand(setKeyCommand())
 	cmd.AddCommand(showConfigCommand())
+	cmd.AddCommand(editConfigCommand())
+	cmd.AddCommand(validateConfigCommand())
+	cmd.AddCommand(upgradeCommand())
+	cmd.AddCommand(pricingCommand())
 
 	return cmd
 }
@@ -32,12 +42,6 @@ func setKeyCommand() *cobra.Command {
 		Short: "Set or reset your OpenAI API key",
 		Long:  `Set or reset your OpenAI API key. This will update the key in your configuration file.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// Load current config
-			cfg, err := config.LoadConfig()
-			if err != nil {
-				return fmt.Errorf("failed to load config: %w", err)
-			}
-
 			// Get new API key from user
 			fmt.Print("Enter your OpenAI API key: ")
 			reader := bufio.NewReader(os.Stdin)
@@ -52,12 +56,16 @@ func setKeyCommand() *cobra.Command {
 				return fmt.Errorf("API key cannot be empty")
 			}
 
-			// Update config with new key
-			cfg.OpenAIKey = apiKey
-			if err := config.SaveConfig(cfg); err != nil {
+			// Update only the key so other settings, and an OPENAI_API_KEY from the environment, are not written
+			err = config.UpdateConfig(func(cfg *config.Config) error {
+				cfg.OpenAIKey = apiKey
+				return nil
+			})
+			if err != nil {
 				return fmt.Errorf("failed to save config: %w", err)
 			}
 
+			journal.Record(journal.ActionConfigChanged, "", "openai_key", "API key set")
 			fmt.Println("API key updated successfully!")
 			return nil
 		},
@@ -82,13 +90,233 @@ func showConfigCommand() *cobra.Command {
 			fmt.Println("---------------------")
 			fmt.Printf("OpenAI API Key: %s\n", maskAPIKey(cfg.OpenAIKey))
 			fmt.Printf("Project Goal: %s\n", cfg.ProjectGoal)
-			fmt.Printf("Remember Notes: %d notes\n", len(cfg.RememberNotes))
+			fmt.Printf("Provider: %s\n", valueOr(cfg.Provider, "openai"))
+			fmt.Printf("Model: %s\n", valueOr(cfg.Model, "default"))
+			if len(cfg.FallbackModels) > 0 {
+				fmt.Printf("Fallback Models: %s\n", strings.Join(cfg.FallbackModels, " → "))
+			}
+			fmt.Printf("Profile: %s\n", valueOr(cfg.Profile, config.ProfileStandard))
+			if cfg.MonthlySpendCap > 0 {
+				if spent, err := usage.MonthSpend(); err == nil {
+					fmt.Printf("Monthly Spend Cap: $%.2f ($%.2f spent this month)\n", cfg.MonthlySpendCap, spent)
+				} else {
+					fmt.Printf("Monthly Spend Cap: $%.2f\n", cfg.MonthlySpendCap)
+				}
+			}
+			fmt.Printf("Remember Notes: %d in config (run 'wash remember list' for saved notes)\n", len(cfg.RememberNotes))
 
 			return nil
 		},
 	}
 }
 
+// editConfigCommand returns the command to edit the config file in $EDITOR
+func editConfigCommand() *cobra.Command {
+	var project bool
+
+	cmd := &cobra.Command{
+		Use:   "edit",
+		Short: "Edit the configuration in your editor",
+		Long: `Open the configuration file in $VISUAL or $EDITOR (vi by default).
+
+The file is edited as a copy and only saved once it passes validation, so a typo
+never leaves wash with a broken config. Unknown keys, values of the wrong type
+and unsupported values (such as an unknown provider) are reported with their
+line numbers, and you can go back to the editor to fix them.
+
+With --project, the nearest project .wash.yaml is edited instead, and created in
+the current directory if there is none.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := configFilePath(project)
+			if err != nil {
+				return err
+			}
+
+			original, err := os.ReadFile(path)
+			if err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to read config: %w", err)
+			}
+
+			tmp, err := os.CreateTemp("", "wash-config-*.yaml")
+			if err != nil {
+				return fmt.Errorf("failed to create temporary file: %w", err)
+			}
+			defer os.Remove(tmp.Name())
+			_, err = tmp.Write(original)
+			if closeErr := tmp.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return fmt.Errorf("failed to write temporary file: %w", err)
+			}
+
+			reader := bufio.NewReader(os.Stdin)
+			for {
+				if err := editor.Edit(tmp.Name()); err != nil {
+					return err
+				}
+
+				data, err := os.ReadFile(tmp.Name())
+				if err != nil {
+					return fmt.Errorf("failed to read edited config: %w", err)
+				}
+				if bytes.Equal(data, original) {
+					fmt.Println("No changes made")
+					return nil
+				}
+
+				err = config.WriteConfigFile(path, data)
+				var problems config.ValidationErrors
+				if !errors.As(err, &problems) {
+					if err != nil {
+						return err
+					}
+					journal.Record(journal.ActionConfigChanged, "", path, "edited")
+					fmt.Printf("Saved %s\n", path)
+					return nil
+				}
+
+				printProblems(path, problems)
+				fmt.Print("Edit again? [Y/n]: ")
+				answer, _ := reader.ReadString('\n')
+				if answer = strings.ToLower(strings.TrimSpace(answer)); answer == "n" || answer == "no" {
+					return fmt.Errorf("config not saved; %s is unchanged", path)
+				}
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&project, "project", false, "Edit the project .wash.yaml instead of the global config")
+
+	return cmd
+}
+
+// upgradeCommand returns the command to leave the safe profile
+func upgradeCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "upgrade",
+		Short: "Leave safe mode",
+		Long: `New configurations start in safe mode, which keeps first runs cheap: analyses
+use ` + config.SafeModel + `, wash monitor is off and API calls stop once $` + fmt.Sprintf("%.0f", config.SafeSpendCapUSD) + ` has
+been spent in a month.
+
+Upgrading switches to the standard profile: the default or configured models,
+wash monitor, and no spend cap unless monthly_spend_cap is set.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			if !cfg.Safe() {
+				fmt.Println("Safe mode is already off")
+				return nil
+			}
+
+			err = config.UpdateConfig(func(cfg *config.Config) error {
+				cfg.Profile = config.ProfileStandard
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("failed to save config: %w", err)
+			}
+			journal.Record(journal.ActionConfigChanged, "", "profile", config.ProfileStandard)
+
+			// A project config or WASH_PROFILE can still select the safe profile
+			if cfg, err := config.LoadConfig(); err == nil && cfg.Safe() {
+				fmt.Println("Updated the global config, but safe mode is still set by WASH_PROFILE or the project .wash.yaml")
+				return nil
+			}
+			fmt.Println("Safe mode is off: wash now uses the configured models, wash monitor is available and there is no spend cap unless monthly_spend_cap is set")
+			return nil
+		},
+	}
+}
+
+// validateConfigCommand returns the command to check config files against the schema
+func validateConfigCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate [file]",
+		Short: "Check configuration files for mistakes",
+		Long:  `Check the global config and the nearest project .wash.yaml, or the given file, for unknown keys, values of the wrong type and unsupported values.`,
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var paths []string
+			if len(args) == 1 {
+				paths = args
+			} else {
+				global, err := config.Path()
+				if err != nil {
+					return err
+				}
+				paths = append(paths, global)
+				if project := config.ProjectPath(); project != "" {
+					paths = append(paths, project)
+				}
+			}
+
+			failed := 0
+			for _, path := range paths {
+				data, err := os.ReadFile(path)
+				if os.IsNotExist(err) && len(args) == 0 {
+					continue
+				}
+				if err != nil {
+					return fmt.Errorf("failed to read config: %w", err)
+				}
+
+				validate := config.Validate
+				if filepath.Base(path) == config.DefaultConfigName+"."+config.DefaultConfigType {
+					validate = config.ValidateProject
+				}
+				var problems config.ValidationErrors
+				if err := validate(data); errors.As(err, &problems) {
+					printProblems(path, problems)
+					failed++
+					continue
+				}
+				fmt.Printf("%s is valid\n", path)
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d config file(s) have problems", failed)
+			}
+			return nil
+		},
+	}
+}
+
+// configFilePath returns the global config path, or with project set the nearest project config path
+func configFilePath(project bool) (string, error) {
+	if !project {
+		return config.Path()
+	}
+	if path := config.ProjectPath(); path != "" {
+		return path, nil
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current directory: %w", err)
+	}
+	return filepath.Join(cwd, config.DefaultConfigName+"."+config.DefaultConfigType), nil
+}
+
+// printProblems lists validation problems found in a config file
+func printProblems(path string, problems config.ValidationErrors) {
+	fmt.Printf("%s has %d problem(s):\n", path, len(problems))
+	for _, problem := range problems {
+		fmt.Printf("  - %s\n", problem.Error())
+	}
+}
+
+// valueOr returns value, or fallback when value is empty
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
 // maskAPIKey masks the API key for display
 func maskAPIKey(key string) string {
 	if key == "" {
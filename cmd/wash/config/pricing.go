@@ -0,0 +1,83 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/pricing"
+	"github.com/bkidd1/wash-cli/internal/utils/tokens"
+	"github.com/spf13/cobra"
+)
+
+// pricingCommand returns the command to show and update the model prices
+func pricingCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pricing",
+		Short: "Show the model prices used for cost estimates",
+		Long: `Show the model prices used for cost estimates and the monthly spend cap.
+
+Wash ships with a pricing table. Builds with a pricing table URL check weekly,
+in the background, for a signed update, so estimates follow provider price
+changes; 'wash config pricing update --url' checks any table. Downloaded tables are only used when
+their signature verifies and they are newer than the prices already in use.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			table, err := pricing.Apply()
+			if err != nil {
+				fmt.Printf("Warning: ignoring the downloaded pricing table: %v\n", err)
+			}
+			switch {
+			case table != nil:
+				fmt.Printf("Prices: downloaded table from %s\n", table.UpdatedAt.Format("2006-01-02"))
+			case pricing.PublicKey == "" || pricing.URL == "":
+				fmt.Printf("Prices: bundled table from %s (updates are disabled in this build)\n", tokens.BundledPricesDate)
+			default:
+				fmt.Printf("Prices: bundled table from %s\n", tokens.BundledPricesDate)
+			}
+
+			names := make([]string, 0, len(tokens.Models))
+			for name := range tokens.Models {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			fmt.Printf("\n%-16s %12s %12s\n", "Model", "Input/1K", "Output/1K")
+			for _, name := range names {
+				info := tokens.Models[name]
+				fmt.Printf("%-16s %12s %12s\n", name, fmt.Sprintf("$%.5f", info.InputPer1K), fmt.Sprintf("$%.5f", info.OutputPer1K))
+			}
+			return nil
+		},
+	}
+
+	cmd.AddCommand(pricingUpdateCommand())
+
+	return cmd
+}
+
+// pricingUpdateCommand returns the command to download the latest pricing table now
+func pricingUpdateCommand() *cobra.Command {
+	var url string
+
+	cmd := &cobra.Command{
+		Use:   "update",
+		Short: "Download the latest signed pricing table",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			table, err := pricing.Update(ctx, url)
+			if err != nil {
+				return fmt.Errorf("failed to update prices: %w", err)
+			}
+			fmt.Printf("Updated prices to the table from %s (%d models)\n", table.UpdatedAt.Format("2006-01-02"), len(table.Models))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&url, "url", pricing.URL, "Pricing table to download; its signature must be at the same URL plus .sig")
+
+	return cmd
+}
@@ -0,0 +1,122 @@
+package context
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/bugs"
+	"github.com/bkidd1/wash-cli/internal/services/goals"
+	"github.com/bkidd1/wash-cli/internal/services/handoff"
+	"github.com/bkidd1/wash-cli/internal/services/notes"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// recentWindow and recentNotes limit the progress notes shown
+	recentWindow = 7 * 24 * time.Hour
+	recentNotes  = 5
+)
+
+// Command creates the context command
+func Command() *cobra.Command {
+	var projectName string
+
+	cmd := &cobra.Command{
+		Use:   "context",
+		Short: "Show where the project stands, to resume work",
+		Long: `Prints what you need to pick a project back up: its goal, the handoff written
+at the end of the last session, the latest progress notes and the open bugs.
+Paste it into a new chat to brief an AI coding assistant.
+
+Examples:
+  # Resume the project in the current directory
+  wash context
+
+  # Copy the context of another project
+  wash context --project api | pbcopy`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if projectName == "" {
+				cwd, err := os.Getwd()
+				if err != nil {
+					return fmt.Errorf("failed to get current directory: %w", err)
+				}
+				projectName = filepath.Base(cwd)
+			}
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
+			fmt.Printf("# Context for %s\n", projectName)
+			if goal := goals.Resolve(projectName, cfg); goal != "" {
+				fmt.Printf("\nGoal: %s\n", goal)
+			}
+
+			hm, err := handoff.NewHandoffManager()
+			if err != nil {
+				return fmt.Errorf("failed to create handoff manager: %w", err)
+			}
+			latest, err := hm.Latest(projectName)
+			if err != nil {
+				fmt.Printf("Warning: %v\n", err)
+			}
+			if latest != nil {
+				fmt.Printf("\n%s", latest)
+			}
+
+			notesManager, err := notes.NewNotesManager()
+			if err != nil {
+				return fmt.Errorf("failed to initialize notes manager: %w", err)
+			}
+			progress, err := notesManager.GetProgressNotesBetween(projectName, time.Now().Add(-recentWindow), time.Now())
+			if err != nil {
+				return fmt.Errorf("failed to load progress notes: %w", err)
+			}
+			sort.Slice(progress, func(i, j int) bool { return progress[i].Timestamp.Before(progress[j].Timestamp) })
+			if len(progress) > recentNotes {
+				progress = progress[len(progress)-recentNotes:]
+			}
+			if len(progress) > 0 {
+				fmt.Println("\n## Recent progress")
+				for _, note := range progress {
+					fmt.Printf("- %s: %s\n", note.Timestamp.Local().Format("2006-01-02 15:04"), note.Title)
+				}
+			}
+
+			bugManager, err := bugs.NewBugManager()
+			if err != nil {
+				return fmt.Errorf("failed to create bug manager: %w", err)
+			}
+			reports, err := bugManager.List(projectName, false)
+			if err != nil {
+				return err
+			}
+			var open []*bugs.Report
+			for _, report := range reports {
+				if !report.IsResolved() {
+					open = append(open, report)
+				}
+			}
+			if len(open) > 0 {
+				fmt.Println("\n## Open bugs")
+				for _, report := range open {
+					fmt.Printf("- %s (%s, %s)\n", report.Title(), report.Status(), report.Priority())
+				}
+			}
+
+			if latest == nil && len(progress) == 0 && len(open) == 0 {
+				fmt.Println("\nNo handoff, recent progress or open bugs yet")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&projectName, "project", "p", "", "Project name (defaults to current directory name)")
+
+	return cmd
+}
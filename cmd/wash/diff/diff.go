@@ -0,0 +1,130 @@
+package diff
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bkidd1/wash-cli/cmd/wash/file"
+	"github.com/bkidd1/wash-cli/internal/utils/analyzable"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/git"
+	"github.com/spf13/cobra"
+)
+
+// loadingAnimation shows a simple loading animation
+func loadingAnimation(done chan bool) {
+	spinner := []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+	i := 0
+	for {
+		select {
+		case <-done:
+			fmt.Printf("\r") // Clear the line
+			return
+		default:
+			fmt.Printf("\rWashing diff... %s", spinner[i])
+			i = (i + 1) % len(spinner)
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+}
+
+// Command creates the diff analysis command
+func Command() *cobra.Command {
+	var staged bool
+	var ref, goal string
+	var contextLines int
+
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Analyze your uncommitted changes",
+		Long: `Reviews the changes of the git diff instead of whole files. The changed hunks
+are sent with the unchanged lines around them for context, and only issues on
+added or changed lines are reported.
+
+Without flags, all uncommitted changes (staged and unstaged) are analyzed.
+
+Examples:
+  # Review everything not yet committed
+  wash diff
+
+  # Review what is about to be committed
+  wash diff --staged
+
+  # Review a branch before opening a pull request
+  wash diff --ref main`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if staged && ref != "" {
+				return fmt.Errorf("--staged and --ref cannot be used together")
+			}
+
+			cwd, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get current directory: %w", err)
+			}
+			if !git.IsRepo(cwd) {
+				return fmt.Errorf("not a git repository: %s", cwd)
+			}
+
+			changes, err := git.Diff(cwd, git.DiffOptions{Staged: staged, Ref: ref, Context: contextLines})
+			if err != nil {
+				return fmt.Errorf("failed to get diff: %w", err)
+			}
+
+			// Binary, generated and minified files are left out as in wash file
+			var files []git.FileDiff
+			for _, change := range changes {
+				if change.Deleted || change.Binary || len(change.Hunks) == 0 {
+					continue
+				}
+				if err := analyzable.CheckContent(change.Path, []byte(change.Text())); err != nil {
+					fmt.Printf("Warning: %v\n", err)
+					continue
+				}
+				files = append(files, change)
+			}
+			if len(files) == 0 {
+				fmt.Println("No changes to analyze")
+				return nil
+			}
+
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			analyzer, _ := file.NewAnalyzer(cfg, goal)
+
+			done := make(chan bool)
+			go loadingAnimation(done)
+
+			result, analysis, err := analyzer.AnalyzeDiff(context.Background(), files)
+			done <- true
+			if err != nil {
+				return fmt.Errorf("failed to analyze diff: %w", err)
+			}
+
+			fmt.Println("\nAnalysis Results:")
+			fmt.Println("----------------")
+			fmt.Println(result)
+
+			subject := "uncommitted changes"
+			switch {
+			case staged:
+				subject = "staged changes"
+			case ref != "":
+				subject = "changes since " + ref
+			}
+			file.FinishAnalysis(cfg, "diff", subject, &analysis.Analysis)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&staged, "staged", false, "Analyze only the staged changes")
+	cmd.Flags().StringVar(&ref, "ref", "", "Analyze the changes since the current branch forked from this ref, such as main")
+	cmd.Flags().IntVarP(&contextLines, "context", "U", 10, "Unchanged lines to include around each change")
+	cmd.Flags().StringVar(&goal, "goal", "", "Specific goal for the diff analysis")
+
+	return cmd
+}
@@ -0,0 +1,107 @@
+package doctor
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bkidd1/wash-cli/internal/services/screenshot"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/platform"
+	"github.com/spf13/cobra"
+)
+
+// check is one thing wash needs, with what was found and how to fix it
+type check struct {
+	name   string
+	status string
+	// fix is set when the check failed
+	fix string
+	// optional checks report missing features rather than problems
+	optional bool
+}
+
+// Command creates the doctor command
+func Command() *cobra.Command {
+	return &cobra.Command{
+		Use:   "doctor",
+		Short: "Check that wash can run on this machine",
+		Long: `Check the API key, and what wash monitor needs to capture the screen: the
+Screen Recording permission on macOS, finding the focused window, the
+connected displays and tesseract for reading screenshots locally.
+
+Each problem is printed with the steps to fix it.
+
+Examples:
+  wash doctor`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			problems := 0
+			for _, c := range checks() {
+				mark := "ok"
+				if c.fix != "" {
+					mark = "missing"
+					if !c.optional {
+						mark = "problem"
+						problems++
+					}
+				}
+				fmt.Printf("%-8s %s: %s\n", "["+mark+"]", c.name, c.status)
+				if c.fix != "" {
+					fmt.Println(indent(c.fix))
+				}
+			}
+			if problems > 0 {
+				cmd.SilenceUsage, cmd.SilenceErrors = true, true
+				return fmt.Errorf("%d problem(s) found", problems)
+			}
+			return nil
+		},
+	}
+}
+
+// checks runs the checks
+func checks() []check {
+	var results []check
+
+	if hasKey, err := config.ValidateAPIKey(); err != nil || !hasKey {
+		results = append(results, check{name: "API key", status: "not set", fix: "Run 'wash config set-key', or set WASH_OPENAI_KEY."})
+	} else {
+		results = append(results, check{name: "API key", status: "set"})
+	}
+
+	if !platform.IsSupported() {
+		return append(results, check{name: "Screen capture", status: "not supported on " + platform.GetOSName(),
+			fix: "Use 'wash monitor --source' with tmux, screen, claude or aider instead."})
+	}
+
+	if platform.CurrentOS() == platform.Darwin {
+		if err := screenshot.CheckScreenRecording(); errors.Is(err, screenshot.ErrNoScreenRecording) {
+			results = append(results, check{name: "Screen Recording", status: "not granted", fix: screenshot.ScreenRecordingSteps})
+		} else {
+			results = append(results, check{name: "Screen Recording", status: "granted"})
+		}
+	}
+
+	if window, err := screenshot.ActiveWindow(); err != nil {
+		results = append(results, check{name: "Focused window", status: err.Error(), optional: true,
+			fix: "Without it wash monitor captures the screen whatever is focused."})
+	} else {
+		results = append(results, check{name: "Focused window", status: window.Name()})
+	}
+
+	results = append(results, check{name: "Displays", status: screenshot.DescribeDisplays()})
+
+	if screenshot.OCRAvailable() {
+		results = append(results, check{name: "tesseract", status: "installed"})
+	} else {
+		results = append(results, check{name: "tesseract", status: "not installed", optional: true,
+			fix: "Install it with your package manager: 'wash monitor' only sends screenshots once secrets are blacked out with it, unless redact_secrets is off, and 'wash monitor --ocr' needs it."})
+	}
+	return results
+}
+
+// indent indents the lines of a fix under its check
+func indent(text string) string {
+	return "         " + strings.ReplaceAll(text, "\n", "\n         ")
+}
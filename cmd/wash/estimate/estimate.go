@@ -0,0 +1,231 @@
+package estimate
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/cmd/wash/cmdutil"
+	"github.com/bkidd1/wash-cli/internal/services/estimates"
+	"github.com/bkidd1/wash-cli/internal/services/notes"
+	"github.com/bkidd1/wash-cli/internal/services/plans"
+	"github.com/bkidd1/wash-cli/internal/services/retrieval"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Flags
+	projectName string
+)
+
+// loadingAnimation shows a simple loading animation
+func loadingAnimation(done chan bool) {
+	spinner := []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+	i := 0
+	for {
+		select {
+		case <-done:
+			fmt.Printf("\r") // Clear the line
+			return
+		default:
+			fmt.Printf("\rEstimating... %s", spinner[i])
+			i = (i + 1) % len(spinner)
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+}
+
+// Command creates the estimate command
+func Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "estimate [task]",
+		Short: "Estimate effort for a task",
+		Long: `Estimate the effort for a described task using the project's history.
+
+The estimate draws on how long past estimated tasks actually took, plan
+completion rates and recent progress note activity. Estimates are stored in
+~/.wash/projects/[project-name]/estimates/ so their accuracy can be scored
+once the task is done.
+
+Examples:
+  # Estimate a task
+  wash estimate "add OAuth login to the API"
+
+  # List estimates and their accuracy
+  wash estimate list
+
+  # Record the actual hours a task took
+  wash estimate done 1a2b3c4d 6.5`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			task := strings.TrimSpace(strings.Join(args, " "))
+			if task == "" {
+				return fmt.Errorf("task description cannot be empty")
+			}
+
+			cwd, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get current directory: %w", err)
+			}
+			if projectName == "" {
+				projectName = filepath.Base(cwd)
+			}
+
+			// Load config
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
+			estimateManager, err := estimates.NewEstimateManager()
+			if err != nil {
+				return fmt.Errorf("failed to create estimate manager: %w", err)
+			}
+
+			planManager, err := plans.NewPlanManager()
+			if err != nil {
+				return fmt.Errorf("failed to create plan manager: %w", err)
+			}
+
+			notesManager, err := notes.NewNotesManager()
+			if err != nil {
+				return fmt.Errorf("failed to create notes manager: %w", err)
+			}
+
+			// Send the past tasks most like this one; without embeddings, the most recent
+			var ranker estimates.Ranker
+			if retriever, err := retrieval.NewRetriever(cfg.OpenAIKey); err == nil {
+				ranker = retriever
+			}
+			history := estimates.BuildHistory(context.Background(), estimateManager, planManager, notesManager, projectName, task, ranker)
+
+			// Create analyzer with project context
+			analyzer, _, warnings := cmdutil.NewAnalyzer(cfg, "", projectName, cwd)
+			for _, warning := range warnings {
+				fmt.Printf("Warning: %v\n", warning)
+			}
+
+			done := make(chan bool)
+			go loadingAnimation(done)
+
+			estimate, err := analyzer.EstimateEffort(context.Background(), projectName, task, history)
+			done <- true
+			if err != nil {
+				return fmt.Errorf("failed to estimate task: %w", err)
+			}
+			if p := analyzer.LastProvenance(); p != nil && p.Substituted() {
+				fmt.Printf("Note: analyzed with %s\n", p)
+			}
+
+			if err := estimateManager.SaveEstimate(estimate); err != nil {
+				return fmt.Errorf("failed to save estimate: %w", err)
+			}
+
+			fmt.Printf("\nEstimate %s: %s\n", estimate.ID, estimate.Task)
+			fmt.Println("------------------------")
+			fmt.Printf("Most likely: %.1f hours (range %.1f-%.1f, %s confidence)\n", estimate.Hours, estimate.LowHours, estimate.HighHours, estimate.Confidence)
+			fmt.Printf("%s\n", estimate.Rationale)
+			fmt.Printf("\nWhen the task is finished, record the actual time with: wash estimate done %s [hours]\n", estimate.ID)
+			return nil
+		},
+	}
+
+	cmd.PersistentFlags().StringVarP(&projectName, "project", "p", "", "Project name (defaults to current directory name)")
+
+	cmd.AddCommand(listCmd())
+	cmd.AddCommand(doneCmd())
+
+	return cmd
+}
+
+func listCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List estimates and their accuracy",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			project, err := cmdutil.ProjectName(projectName)
+			if err != nil {
+				return err
+			}
+
+			estimateManager, err := estimates.NewEstimateManager()
+			if err != nil {
+				return fmt.Errorf("failed to create estimate manager: %w", err)
+			}
+
+			all, err := estimateManager.ListEstimates(project)
+			if err != nil {
+				return fmt.Errorf("failed to list estimates: %w", err)
+			}
+
+			if len(all) == 0 {
+				fmt.Printf("No estimates found for project %s\n", project)
+				return nil
+			}
+
+			for _, estimate := range all {
+				actual := "open"
+				if accuracy, ok := estimate.Accuracy(); ok {
+					actual = fmt.Sprintf("actual %.1fh, %.0f%% accurate", *estimate.ActualHours, accuracy*100)
+				}
+				fmt.Printf("%s  %s  est %.1fh  %s  %s\n",
+					estimate.ID,
+					estimate.CreatedAt.Format("2006-01-02"),
+					estimate.Hours,
+					actual,
+					estimate.Task)
+			}
+
+			if mean, count := estimates.MeanAccuracy(all); count > 0 {
+				fmt.Printf("\nMean accuracy: %.0f%% over %d completed tasks\n", mean*100, count)
+			}
+			return nil
+		},
+	}
+}
+
+func doneCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "done [id] [hours]",
+		Short: "Record the actual hours a task took",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			project, err := cmdutil.ProjectName(projectName)
+			if err != nil {
+				return err
+			}
+
+			hours, err := strconv.ParseFloat(args[1], 64)
+			if err != nil || hours <= 0 {
+				return fmt.Errorf("invalid number of hours: %s", args[1])
+			}
+
+			estimateManager, err := estimates.NewEstimateManager()
+			if err != nil {
+				return fmt.Errorf("failed to create estimate manager: %w", err)
+			}
+
+			estimate, err := estimateManager.LoadEstimate(project, args[0])
+			if err != nil {
+				return err
+			}
+
+			now := time.Now()
+			estimate.ActualHours = &hours
+			estimate.CompletedAt = &now
+
+			if err := estimateManager.SaveEstimate(estimate); err != nil {
+				return fmt.Errorf("failed to save estimate: %w", err)
+			}
+
+			accuracy, _ := estimate.Accuracy()
+			fmt.Printf("Recorded %.1f hours for %s (estimated %.1f, %.0f%% accurate)\n", hours, estimate.Task, estimate.Hours, accuracy*100)
+			return nil
+		},
+	}
+}
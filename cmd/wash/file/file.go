@@ -7,16 +7,44 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/bkidd1/wash-cli/cmd/wash/cmdutil"
 	"github.com/bkidd1/wash-cli/internal/services/analyzer"
+	"github.com/bkidd1/wash-cli/internal/services/findings"
+	"github.com/bkidd1/wash-cli/internal/services/hooks"
+	"github.com/bkidd1/wash-cli/internal/services/journal"
+	"github.com/bkidd1/wash-cli/internal/utils/analyzable"
 	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/fsutil"
+	"github.com/bkidd1/wash-cli/internal/utils/report"
+	"github.com/bkidd1/wash-cli/internal/utils/style"
+	"github.com/bkidd1/wash-cli/internal/utils/tokens"
 	"github.com/spf13/cobra"
 )
 
 var (
 	// Flags
-	goal string
+	goal      string
+	force     bool
+	explain   bool
+	outPath   string
+	lineRange string
+	funcName  string
+	fix       bool
+	dryRun    bool
+	noCache   bool
+	output    string
+	failOn    string
+	workers   int
+
+	// found counts the findings of the run's analyses for --fail-on; files analyzed in parallel
+	// add to it at once
+	found struct {
+		sync.Mutex
+		critical, shouldFix, couldFix int
+	}
 )
 
 // loadingAnimation shows a simple loading animation
@@ -36,12 +64,36 @@ func loadingAnimation(done chan bool) {
 	}
 }
 
+// analyzeStreaming runs an analysis behind the loading animation, printing it under heading as the
+// model generates it, and says when the answer was cached or findings were suppressed
+func analyzeStreaming(a *analyzer.TerminalAnalyzer, heading string, analyze func() (string, error)) (string, error) {
+	result, err := cmdutil.AnalyzeStreaming(a, loadingAnimation, heading, analyze)
+	if err == nil && a.Cached() {
+		fmt.Println("File unchanged since this analysis; run with --no-cache to analyze it again.")
+	}
+	if note := suppressedNote(a); err == nil && note != "" {
+		fmt.Println(note)
+	}
+	return result, err
+}
+
+// useCache makes the analyzer reuse the answer to an unchanged file, or refresh it with --no-cache
+func useCache(a *analyzer.TerminalAnalyzer) {
+	cache, err := analyzer.NewCache()
+	if err != nil {
+		progressf("Warning: %v\n", err)
+		return
+	}
+	cache.Refresh = noCache
+	a.SetCache(cache)
+}
+
 // Command creates the file analysis command
 func Command() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "file [path]",
-		Short: "Analyze and optimize a single file",
-		Long: `Analyzes the specified file and suggests improvements for:
+		Use:   "file [path|pattern...]",
+		Short: "Analyze and optimize files",
+		Long: `Analyzes the specified files and suggests improvements for:
 - Code structure
 - Performance
 - Maintainability
@@ -63,9 +115,46 @@ Examples:
   wash file main.go
 
   # Analyze with specific goal
-  wash file --goal "Improve error handling and logging" main.go`,
-		Args: cobra.MaximumNArgs(1),
+  wash file --goal "Improve error handling and logging" main.go
+
+  # Analyze only the part of a file you are working on
+  wash file main.go --lines 120-240
+  wash file server.go --func HandleRequest
+
+  # Preview and apply patches for the Critical and Should Fix findings
+  wash file main.go --fix
+  wash file main.go --fix --dry-run
+
+  # Analyze several files or a glob (** matches any number of directories),
+  # four files at a time unless --workers or the workers setting say otherwise
+  wash file main.go cmd/root.go
+  wash file 'internal/**/*.go' --out analysis.md --workers 8
+
+  # Write the findings with their lines as JSON, SARIF or markdown for editors and CI
+  wash file main.go --output json
+  wash file 'internal/**/*.go' --output sarif --out wash.sarif
+
+  # Exit with status 2 when there are Should Fix findings or worse, to gate CI
+  wash file 'internal/**/*.go' --fail-on should`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkOutput(); err != nil {
+				return err
+			}
+			if err := report.CheckFailOn(failOn); err != nil {
+				return err
+			}
+
+			// Several files or a pattern get one combined report
+			if len(args) > 1 || (len(args) == 1 && fsutil.HasGlob(args[0])) {
+				if lineRange != "" || funcName != "" {
+					return fmt.Errorf("--lines and --func analyze part of a single file")
+				}
+				if fix || dryRun {
+					return fmt.Errorf("--fix patches a single file")
+				}
+				return analyzeFiles(args)
+			}
+
 			// Get the path to analyze
 			path := "."
 			if len(args) > 0 {
@@ -135,89 +224,178 @@ Examples:
 				return fmt.Errorf("failed to load config: %w", err)
 			}
 
-			// Override project goal if specified
-			if goal != "" {
-				cfg.ProjectGoal = goal
+			// Skip binary, generated, minified and oversized files unless forced
+			if !force {
+				if err := analyzable.Check(absPath, int64(cfg.MaxFileSizeKB)<<10); err != nil {
+					return fmt.Errorf("%w; use --force to analyze it anyway", err)
+				}
+			}
+
+			analyzer, conventions := NewAnalyzer(cfg, goal)
+			useCache(analyzer)
+			suppressKnown(analyzer, loadBaseline(), absPath)
+			if conventions != nil {
+				analyzer.SetFormattingConventions(conventions.Describe(absPath))
 			}
 
-			// Create analyzer with project context
-			analyzer := analyzer.NewTerminalAnalyzer(cfg.OpenAIKey, cfg.ProjectGoal, cfg.RememberNotes)
+			if reporting() {
+				return reportFile(cfg, analyzer, path, absPath)
+			}
+			if lineRange != "" || funcName != "" {
+				return analyzeTarget(cfg, analyzer, path, absPath)
+			}
 
-			// Create a channel to signal when analysis is done
-			done := make(chan bool)
-			go loadingAnimation(done)
+			// Show the expected size and cost of large requests before sending
+			if estimate, err := analyzer.EstimateFile(absPath); err == nil && estimate.PromptTokens > tokens.LargeRequestTokens {
+				fmt.Printf("Estimated request: %s\n", estimate)
+			}
 
-			// Analyze file
-			result, err := analyzer.AnalyzeFile(context.Background(), absPath)
+			// Analyze file, printing the results as they are generated
+			result, err := analyzeStreaming(analyzer, "Analysis Results", func() (string, error) {
+				return analyzer.AnalyzeFile(context.Background(), absPath)
+			})
 			if err != nil {
-				done <- true
 				return fmt.Errorf("failed to analyze file: %w", err)
 			}
+			FinishAnalysis(cfg, "file", path, analyzer.LastAnalysis())
+
+			// Show which notes went into the prompt and why
+			if explain {
+				if selection := analyzer.LastContext(); selection != nil {
+					fmt.Println("\nContext:")
+					fmt.Println("--------")
+					fmt.Print(selection.Explain())
+				}
+			}
 
-			// Signal that analysis is complete
-			done <- true
-
-			// Print results
-			fmt.Println("\nAnalysis Results:")
-			fmt.Println("----------------")
-			fmt.Println(result)
+			if fix || dryRun {
+				if err := fixFile(analyzer, path, absPath, 0, -1); err != nil {
+					return err
+				}
+			}
 
-			// Check if this is a partial analysis
+			// Offer to analyze the rest of a partial analysis, one chunk at a time
 			if strings.Contains(result, "Would you like to analyze the remaining lines?") {
-				fmt.Print("\nYour choice (y/n): ")
-				reader := bufio.NewReader(os.Stdin)
-				input, err := reader.ReadString('\n')
+				content, err := os.ReadFile(absPath)
 				if err != nil {
-					return fmt.Errorf("failed to read input: %w", err)
+					return fmt.Errorf("error reading file: %w", err)
 				}
+				analyzedLines := analyzer.ChunkEnd(absPath, content, 0)
 
-				input = strings.TrimSpace(strings.ToLower(input))
-				if input == "y" || input == "yes" {
-					// Create a new channel for the second analysis
-					done = make(chan bool)
-					go loadingAnimation(done)
-
-					// Get the remaining content
-					content, err := os.ReadFile(absPath)
+				reader := bufio.NewReader(os.Stdin)
+				for strings.Contains(result, "Would you like to analyze the remaining lines?") {
+					fmt.Print("\nYour choice (y/n): ")
+					input, err := reader.ReadString('\n')
 					if err != nil {
-						done <- true
-						return fmt.Errorf("error reading file: %w", err)
+						return fmt.Errorf("failed to read input: %w", err)
 					}
-
-					lines := strings.Split(string(content), "\n")
-					// Assuming average of 6 tokens per line and reserving 4000 tokens for system prompt and overhead
-					approxLines := (8192 - 4000) / 6 // GPT-4's context window is 8192 tokens
-
-					// Further reduce by 30% to be safe
-					approxLines = (approxLines * 7) / 10
-
-					// Ensure we don't exceed the number of lines
-					if approxLines > len(lines) {
-						approxLines = len(lines)
+					input = strings.TrimSpace(strings.ToLower(input))
+					if input != "y" && input != "yes" {
+						break
 					}
 
-					remainingContent := strings.Join(lines[approxLines:], "\n")
-
-					// Analyze the remaining content
-					remainingResult, err := analyzer.AnalyzeContent(context.Background(), remainingContent)
+					// Analyze the next chunk with the file's imports and earlier declarations as context
+					result, err = analyzeStreaming(analyzer, "Remaining Analysis", func() (string, error) {
+						result, end, err := analyzer.AnalyzeRemaining(context.Background(), absPath, analyzedLines)
+						if err == nil {
+							analyzedLines = end
+						}
+						return result, err
+					})
 					if err != nil {
-						done <- true
 						return fmt.Errorf("failed to analyze remaining content: %w", err)
 					}
-
-					done <- true
-					fmt.Println("\nRemaining Analysis:")
-					fmt.Println("------------------")
-					fmt.Println(remainingResult)
+					FinishAnalysis(cfg, "file", path, analyzer.LastAnalysis())
 				}
 			}
 
 			return nil
 		},
+		// Runs only when the analyses succeeded; the findings were shown, so only the summary is printed
+		PostRunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage, cmd.SilenceErrors = true, true
+			return report.FailOn(failOn, found.critical, found.shouldFix, found.couldFix)
+		},
 	}
 
 	// Add flags
 	cmd.Flags().StringVar(&goal, "goal", "", "Specific goal for the file analysis")
+	cmd.Flags().BoolVar(&force, "force", false, "Analyze binary, generated, minified or oversized files anyway")
+	cmd.Flags().BoolVar(&explain, "explain", false, "Show which notes were included in the prompt and why")
+	cmd.Flags().StringVar(&lineRange, "lines", "", "Analyze only these lines, such as 120-240")
+	cmd.Flags().StringVar(&funcName, "func", "", "Analyze only this function, method (Type.Method) or class")
+	cmd.MarkFlagsMutuallyExclusive("lines", "func")
+	cmd.Flags().BoolVar(&fix, "fix", false, "Propose patches for the Critical and Should Fix findings and apply them after confirmation")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "With --fix, preview the patches and check that they apply without changing the file")
+	cmd.Flags().BoolVar(&noCache, "no-cache", false, "Analyze again even if the file is unchanged since its last analysis")
+	cmd.Flags().StringVarP(&outPath, "out", "o", "", "Write the combined report of several files, or the --output report, to this file instead of printing it")
+	cmd.Flags().StringVar(&output, "output", "text", "Output format: text, json, sarif or markdown")
+	cmd.Flags().IntVar(&workers, "workers", 0, "Analyze this many files at once (default: the workers setting, or 4)")
+	cmd.Flags().StringVar(&failOn, "fail-on", "", "Exit with status 2 when there are findings at or above this level: critical, should or could")
 
 	return cmd
 }
+
+// NewAnalyzer creates an analyzer with the current project's context, using goal instead of the
+// project's when set, and prints the context that failed to load as warnings. The formatting
+// conventions are returned for the caller to describe per file.
+func NewAnalyzer(cfg *config.Config, goal string) (*analyzer.TerminalAnalyzer, *style.Conventions) {
+	cwd, _ := os.Getwd()
+	a, conventions, warnings := cmdutil.NewAnalyzer(cfg, goal, filepath.Base(cwd), cwd)
+	for _, warning := range warnings {
+		progressf("Warning: %v\n", warning)
+	}
+	return a, conventions
+}
+
+// recordFindings replaces the stored findings of a file, keyed by its path from the project directory
+func recordFindings(project, path string, analysis *analyzer.Analysis) {
+	findingsManager, err := findings.NewFindingsManager()
+	if err != nil {
+		return
+	}
+	err = findingsManager.RecordFile(project, &findings.File{
+		Path:           projectPath(path),
+		AnalyzedAt:     time.Now(),
+		CriticalIssues: analysis.CriticalIssues,
+		ShouldFix:      analysis.ShouldFix,
+		CouldFix:       analysis.CouldFix,
+	})
+	if err != nil {
+		progressf("Warning: %v\n", err)
+	}
+}
+
+// FinishAnalysis records an analysis of kind, such as file or diff, in the activity journal and
+// runs the on-critical-finding hook when it found critical issues
+func FinishAnalysis(cfg *config.Config, kind, path string, analysis *analyzer.Analysis) {
+	if analysis == nil {
+		return
+	}
+	project := ""
+	if cwd, err := os.Getwd(); err == nil {
+		project = filepath.Base(cwd)
+	}
+	journal.Record(journal.ActionAnalysis, project, path, fmt.Sprintf("%s analysis: %d critical, %d should fix, %d could fix",
+		kind, len(analysis.CriticalIssues), len(analysis.ShouldFix), len(analysis.CouldFix)))
+	found.Lock()
+	found.critical += len(analysis.CriticalIssues)
+	found.shouldFix += len(analysis.ShouldFix)
+	found.couldFix += len(analysis.CouldFix)
+	found.Unlock()
+
+	// Keep the latest findings of each file for wash snapshot
+	if kind == "file" {
+		recordFindings(project, path, analysis)
+	}
+
+	if len(analysis.CriticalIssues) == 0 {
+		return
+	}
+	hooks.Notify(cfg, hooks.CriticalFinding, project, hooks.Finding{
+		File:           path,
+		CriticalIssues: analysis.CriticalIssues,
+		ShouldFix:      analysis.ShouldFix,
+		CouldFix:       analysis.CouldFix,
+	})
+}
@@ -0,0 +1,138 @@
+package file
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bkidd1/wash-cli/internal/services/analyzer"
+	"github.com/bkidd1/wash-cli/internal/services/notes"
+	"github.com/bkidd1/wash-cli/internal/utils/fsutil"
+	"github.com/bkidd1/wash-cli/internal/utils/patch"
+)
+
+// fixFile asks for patches fixing the Critical and Should Fix findings of the last analysis of a
+// file, previews each and applies the confirmed ones. The analyzed part runs from line start to
+// end (zero-based); a negative end is the end of the first chunk of a whole-file analysis.
+func fixFile(a *analyzer.TerminalAnalyzer, path, absPath string, start, end int) error {
+	analysis := a.LastAnalysis()
+	if analysis == nil || len(analysis.CriticalIssues)+len(analysis.ShouldFix) == 0 {
+		fmt.Println("\nNo Critical or Should Fix findings to fix")
+		return nil
+	}
+
+	content, err := os.ReadFile(absPath)
+	if err != nil {
+		return fmt.Errorf("error reading file: %w", err)
+	}
+	lines := strings.Split(string(content), "\n")
+	if end < 0 {
+		end = a.ChunkEnd(absPath, content, 0)
+	}
+
+	done := make(chan bool)
+	go loadingAnimation(done)
+	fixes, err := a.ProposeFixes(context.Background(), absPath, strings.Join(lines[start:end], "\n"), analysis)
+	done <- true
+	if err != nil {
+		return fmt.Errorf("failed to propose fixes: %w", err)
+	}
+	if len(fixes) == 0 {
+		fmt.Println("\nNo fixes proposed")
+		return nil
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	current := string(content)
+	var applied []analyzer.Fix
+	for i, fix := range fixes {
+		fmt.Printf("\nFix %d/%d: %s\n", i+1, len(fixes), fix.Finding)
+		fmt.Println(strings.TrimRight(fix.Diff, "\n"))
+
+		// Each fix is checked against the file as changed by the fixes applied before it. The diff
+		// numbers lines from the start of the analyzed part, and may only change that part.
+		hunks, err := patch.Parse(fix.Diff)
+		var updated string
+		if err == nil {
+			updated, err = patch.ApplyWithin(current, hunks, start, end)
+		}
+		if err != nil {
+			fmt.Printf("Warning: fix %d cannot be applied: %v\n", i+1, err)
+			continue
+		}
+		if dryRun {
+			fmt.Println("Applies cleanly")
+			continue
+		}
+
+		fmt.Print("Apply this fix? (y/n): ")
+		input, err := reader.ReadString('\n')
+		if err != nil && input == "" {
+			return fmt.Errorf("failed to read input: %w", err)
+		}
+		if input = strings.TrimSpace(strings.ToLower(input)); input == "y" || input == "yes" {
+			end += strings.Count(updated, "\n") - strings.Count(current, "\n")
+			current = updated
+			applied = append(applied, fix)
+		}
+	}
+
+	if dryRun {
+		fmt.Println("\nDry run: no changes were written")
+		return nil
+	}
+	if len(applied) == 0 {
+		fmt.Println("\nNo fixes applied")
+		return nil
+	}
+
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return fmt.Errorf("error reading file: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(absPath, []byte(current), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("error writing fixes: %w", err)
+	}
+	fmt.Printf("\nApplied %d of %d fixes to %s\n", len(applied), len(fixes), path)
+
+	// Record the applied fixes as project progress
+	notesManager, err := notes.NewNotesManager()
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+		return nil
+	}
+	if err := notesManager.SaveProjectProgress(fixNote(path, applied)); err != nil {
+		fmt.Printf("Warning: could not save fixes as a progress note: %v\n", err)
+	}
+	return nil
+}
+
+// fixNote describes applied fixes as a progress note
+func fixNote(path string, applied []analyzer.Fix) *notes.ProjectProgressNote {
+	var description strings.Builder
+	for _, fix := range applied {
+		fmt.Fprintf(&description, "Fixed: %s\n\n```diff\n%s\n```\n\n", fix.Finding, strings.TrimRight(fix.Diff, "\n"))
+	}
+
+	project := ""
+	if cwd, err := os.Getwd(); err == nil {
+		project = filepath.Base(cwd)
+	}
+	note := &notes.ProjectProgressNote{
+		ProjectName: project,
+		Type:        "fix",
+		Title:       fmt.Sprintf("Applied %d fix(es) to %s", len(applied), path),
+		Description: strings.TrimSpace(description.String()),
+	}
+	note.Changes.FilesModified = []string{path}
+	note.Impact.Scope = "local"
+	note.Impact.AffectedAreas = []string{path}
+	note.Impact.RiskLevel = "low"
+	note.Metadata.Priority = notes.PriorityMedium
+	note.Metadata.Status = notes.StatusResolved
+	note.Metadata.Tags = []string{"fix"}
+	return note
+}
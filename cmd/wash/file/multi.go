@@ -0,0 +1,217 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/utils/analyzable"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/fsutil"
+	"github.com/bkidd1/wash-cli/internal/utils/ignore"
+	"github.com/bkidd1/wash-cli/internal/utils/pool"
+	"github.com/bkidd1/wash-cli/internal/utils/report"
+	"github.com/bkidd1/wash-cli/internal/utils/tokens"
+)
+
+// fileResult is the analysis of one file in a multi-file run
+type fileResult struct {
+	path   string
+	result string
+	err    error
+}
+
+// expandPaths resolves paths and glob patterns to the files they name, in order and without
+// duplicates. Globs skip hidden directories and those ignored by the project's .gitignore or .washignore.
+func expandPaths(args []string) ([]string, error) {
+	patterns, err := ignore.LoadGitignorePatterns(".")
+	if err != nil {
+		patterns = ignore.DefaultIgnorePatterns
+	}
+	skip := func(dir string) bool {
+		name := filepath.Base(dir)
+		if strings.HasPrefix(name, ".") {
+			return true
+		}
+		// Patterns are relative to the project root; directories outside it are matched by name
+		if rel := projectPath(dir); !filepath.IsAbs(rel) && !strings.HasPrefix(rel, "..") {
+			return ignore.ShouldIgnore(rel, patterns)
+		}
+		return ignore.ShouldIgnore(name, patterns)
+	}
+
+	var files []string
+	seen := make(map[string]bool)
+	for _, arg := range args {
+		matches := []string{arg}
+		if fsutil.HasGlob(arg) {
+			matches, err = fsutil.Glob(arg, skip)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %s: %w", arg, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no files match %s", arg)
+			}
+		} else if info, err := os.Stat(arg); err != nil {
+			return nil, fmt.Errorf("file does not exist: %s", arg)
+		} else if info.IsDir() {
+			return nil, fmt.Errorf("%s is a directory; use a pattern such as %s", arg, filepath.Join(arg, "**", "*"))
+		}
+
+		for _, match := range matches {
+			absPath, err := filepath.Abs(match)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get absolute path: %w", err)
+			}
+			if !seen[absPath] {
+				seen[absPath] = true
+				files = append(files, match)
+			}
+		}
+	}
+	return files, nil
+}
+
+// analyzeFiles analyzes every file named by args, several at once, with copies of one analyzer so
+// the project context is built once, and prints a combined report with a section per file
+func analyzeFiles(args []string) error {
+	paths, err := expandPaths(args)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Skip binary, generated, minified and oversized files unless forced
+	var files []string
+	for _, path := range paths {
+		if !force {
+			if err := analyzable.Check(path, int64(cfg.MaxFileSizeKB)<<10); err != nil {
+				progressf("Warning: %v\n", err)
+				continue
+			}
+		}
+		files = append(files, path)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no files to analyze; use --force to analyze skipped files anyway")
+	}
+
+	analyzer, conventions := NewAnalyzer(cfg, goal)
+	useCache(analyzer)
+	known := loadBaseline()
+
+	// Show the expected size and cost of the whole run before sending
+	var promptTokens, completionTokens int
+	for _, path := range files {
+		if estimate, err := analyzer.EstimateFile(path); err == nil {
+			promptTokens += estimate.PromptTokens
+			completionTokens += estimate.CompletionTokens
+		}
+	}
+	progressf("Analyzing %d files, estimated request: %s\n", len(files), tokens.NewEstimate(cfg.Model, promptTokens, completionTokens))
+
+	// Files are analyzed in parallel, each by its own copy of the analyzer, and reported in order
+	if workers <= 0 {
+		workers = cfg.Workers
+	}
+	results := make([]fileResult, len(files))
+	reports := make([]*report.File, len(files))
+	var mu sync.Mutex
+	done, failed := 0, 0
+	pool.Run(pool.Workers(workers), len(files), func(i int) {
+		path := files[i]
+		worker := analyzer.Clone()
+		if conventions != nil {
+			absPath, _ := filepath.Abs(path)
+			worker.SetFormattingConventions(conventions.Describe(absPath))
+		}
+		suppressKnown(worker, known, path)
+
+		var result string
+		var err error
+		if reporting() {
+			reports[i], err = analyzeForReport(cfg, worker, path, path)
+		} else if result, err = worker.AnalyzeFile(context.Background(), path); err == nil {
+			FinishAnalysis(cfg, "file", path, worker.LastAnalysis())
+			if note := suppressedNote(worker); note != "" {
+				result += "\n\n" + note
+			}
+			if selection := worker.LastContext(); explain && selection != nil {
+				result += "\n\nContext:\n" + selection.Explain()
+			}
+		}
+		results[i] = fileResult{path: path, result: result, err: err}
+
+		mu.Lock()
+		defer mu.Unlock()
+		done++
+		if err != nil {
+			failed++
+			progressf("Warning: failed to analyze %s: %v\n", path, err)
+		}
+		progressf("[%d/%d] %s\n", done, len(files), path)
+	})
+	if failed == len(files) {
+		return fmt.Errorf("failed to analyze all %d files", failed)
+	}
+
+	// The report covers the files that were analyzed, but --fail-on cannot pass a run that left
+	// files unchecked
+	var incomplete error
+	if failed > 0 && failOn != "" {
+		incomplete = fmt.Errorf("failed to analyze %d of %d files, so --fail-on %s cannot pass", failed, len(files), failOn)
+	}
+	if reporting() {
+		if err := writeReport(reports...); err != nil {
+			return err
+		}
+		return incomplete
+	}
+
+	report := combinedReport(results, time.Now())
+	if outPath != "" {
+		if err := os.WriteFile(outPath, []byte(report), 0644); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+		fmt.Printf("Report written to %s\n", outPath)
+		return incomplete
+	}
+
+	fmt.Println("\nAnalysis Results:")
+	fmt.Println("----------------")
+	fmt.Println(report)
+	return incomplete
+}
+
+// combinedReport renders the analyses as one markdown report with a section per file
+func combinedReport(results []fileResult, now time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Code Analysis: %d Files\n*Generated on %s*\n", len(results), now.Format("2006-01-02 15:04:05"))
+	for _, r := range results {
+		if r.err != nil {
+			fmt.Fprintf(&b, "\n## %s\n\nAnalysis failed: %v\n", r.path, r.err)
+			continue
+		}
+
+		// Each analysis starts with its own title and date, which the section heading replaces
+		title, body, _ := strings.Cut(r.result, "\n")
+		if _, rest, ok := strings.Cut(body, "\n"); ok && strings.HasPrefix(body, "*Generated on") {
+			body = rest
+		}
+		heading := r.path
+		if strings.Contains(title, "(Partial)") {
+			heading += " (Partial)"
+			body = strings.Replace(body, "Would you like to analyze the remaining lines? (y/n)", fmt.Sprintf("Run `wash file %s` to analyze the remaining lines.", r.path), 1)
+		}
+		fmt.Fprintf(&b, "\n## %s\n%s\n", heading, strings.TrimRight(body, "\n"))
+	}
+	return b.String()
+}
@@ -0,0 +1,114 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/bkidd1/wash-cli/internal/services/analyzer"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/report"
+	"github.com/bkidd1/wash-cli/pkg/version"
+)
+
+// reporting reports whether --output asks for a report instead of the terminal output
+func reporting() bool {
+	return output != "text"
+}
+
+// checkOutput rejects unknown formats and the interactive flags a report cannot be combined with
+func checkOutput() error {
+	if !slices.Contains(report.Formats, output) {
+		return fmt.Errorf("unknown output format %q: use one of %s", output, strings.Join(report.Formats, ", "))
+	}
+	if reporting() && (fix || dryRun) {
+		return fmt.Errorf("--fix cannot be combined with --output %s", output)
+	}
+	return nil
+}
+
+// progressf prints progress to stderr when a report goes to stdout, so the report stays parseable
+func progressf(format string, args ...interface{}) {
+	if reporting() {
+		fmt.Fprintf(os.Stderr, format, args...)
+		return
+	}
+	fmt.Printf(format, args...)
+}
+
+// fileReport attributes the findings of an analysis of lines start to end (zero-based, end
+// exclusive) of a file to their lines
+func fileReport(path string, content []byte, start, end int, analysis *analyzer.Analysis) *report.File {
+	lines := strings.Split(string(content), "\n")
+	if analysis == nil {
+		analysis = &analyzer.Analysis{}
+	}
+	return report.NewFile(path, lines, start, end, analysis.CriticalIssues, analysis.ShouldFix, analysis.CouldFix)
+}
+
+// reportFile analyzes a file, or the part selected with --lines or --func, and writes the report in
+// the --output format
+func reportFile(cfg *config.Config, a *analyzer.TerminalAnalyzer, path, absPath string) error {
+	if lineRange != "" || funcName != "" {
+		content, err := os.ReadFile(absPath)
+		if err != nil {
+			return fmt.Errorf("error reading file: %w", err)
+		}
+		start, end, label, err := targetRange(path, content)
+		if err != nil {
+			return err
+		}
+		if _, err := a.AnalyzeRange(context.Background(), absPath, start, end); err != nil {
+			return fmt.Errorf("failed to analyze file: %w", err)
+		}
+		FinishAnalysis(cfg, "range", label, a.LastAnalysis())
+		file := fileReport(path, content, start, end, a.LastAnalysis())
+		file.Suppressed = a.Suppressed()
+		return writeReport(file)
+	}
+
+	file, err := analyzeForReport(cfg, a, path, absPath)
+	if err != nil {
+		return fmt.Errorf("failed to analyze file: %w", err)
+	}
+	return writeReport(file)
+}
+
+// analyzeForReport analyzes a whole file for a report. A file too large for one request is
+// reported for the lines analyzed, and a failed analysis is reported with its error.
+func analyzeForReport(cfg *config.Config, a *analyzer.TerminalAnalyzer, path, absPath string) (*report.File, error) {
+	content, err := os.ReadFile(absPath)
+	if err != nil {
+		return report.Failed(path, err), fmt.Errorf("error reading file: %w", err)
+	}
+	end := a.ChunkEnd(absPath, content, 0)
+	if _, err := a.AnalyzeFile(context.Background(), absPath); err != nil {
+		return report.Failed(path, err), err
+	}
+	FinishAnalysis(cfg, "file", path, a.LastAnalysis())
+	file := fileReport(path, content, 0, end, a.LastAnalysis())
+	file.Suppressed = a.Suppressed()
+	return file, nil
+}
+
+// writeReport renders the analyses in the --output format to --out, or to stdout
+func writeReport(files ...*report.File) error {
+	r := report.New(version.Version)
+	r.Files = append(r.Files, files...)
+	text, err := r.Render(output)
+	if err != nil {
+		return err
+	}
+
+	if outPath == "" {
+		fmt.Print(text)
+		return nil
+	}
+	if err := os.WriteFile(outPath, []byte(text), 0644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Report written to %s\n", outPath)
+	return nil
+}
@@ -0,0 +1,54 @@
+package file
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bkidd1/wash-cli/internal/services/analyzer"
+	"github.com/bkidd1/wash-cli/internal/services/baseline"
+)
+
+// projectPath returns path from the project directory with forward slashes, as findings and the
+// baseline name files, or path unchanged when it is outside the project
+func projectPath(path string) string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		if rel, err := filepath.Rel(cwd, abs); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return path
+}
+
+// loadBaseline returns the project's baseline, or nil if it has none
+func loadBaseline() *baseline.Baseline {
+	known, err := baseline.Load(".")
+	if err != nil {
+		progressf("Warning: %v\n", err)
+	}
+	return known
+}
+
+// suppressKnown makes the analyzer leave out the findings of the file at path that are in the
+// baseline or about lines marked wash:ignore
+func suppressKnown(a *analyzer.TerminalAnalyzer, known *baseline.Baseline, path string) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		a.SetSuppress(nil)
+		return
+	}
+	a.SetSuppress(baseline.Matcher(known, projectPath(path), content))
+}
+
+// suppressedNote tells how many known findings the last analysis left out, or is empty
+func suppressedNote(a *analyzer.TerminalAnalyzer) string {
+	if a.Suppressed() == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d known findings left out by %s or %s comments", a.Suppressed(), baseline.FileName, baseline.IgnoreMarker)
+}
@@ -0,0 +1,92 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/bkidd1/wash-cli/internal/services/analyzer"
+	"github.com/bkidd1/wash-cli/internal/utils/chunk"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+)
+
+// parseLineRange reads a 1-based, inclusive range such as 120-240 into zero-based start and
+// exclusive end. A single line such as 120 is a range of one line.
+func parseLineRange(s string) (start, end int, err error) {
+	first, last, found := strings.Cut(s, "-")
+	start, err = strconv.Atoi(strings.TrimSpace(first))
+	if err != nil || start < 1 {
+		return 0, 0, fmt.Errorf("invalid line range %q: expected a range such as 120-240", s)
+	}
+	end = start
+	if found {
+		end, err = strconv.Atoi(strings.TrimSpace(last))
+		if err != nil || end < start {
+			return 0, 0, fmt.Errorf("invalid line range %q: expected a range such as 120-240", s)
+		}
+	}
+	return start - 1, end, nil
+}
+
+// targetRange resolves --lines or --func to the zero-based lines of a file they select, and a
+// label such as main.go:120-240 for the activity journal
+func targetRange(path string, content []byte) (start, end int, label string, err error) {
+	lineCount := len(strings.Split(string(content), "\n"))
+
+	if lineRange != "" {
+		start, end, err = parseLineRange(lineRange)
+		if err != nil {
+			return 0, 0, "", err
+		}
+		if start >= lineCount {
+			return 0, 0, "", fmt.Errorf("%s has only %d lines", path, lineCount)
+		}
+		end = min(end, lineCount)
+		return start, end, fmt.Sprintf("%s:%d-%d", path, start+1, end), nil
+	}
+
+	decls := chunk.Declarations(path, content)
+	if decls == nil {
+		return 0, 0, "", fmt.Errorf("--func supports Go, JavaScript and TypeScript files; use --lines for %s", path)
+	}
+	found := chunk.Find(decls, funcName)
+	switch len(found) {
+	case 0:
+		return 0, 0, "", fmt.Errorf("no top-level function, method, class or type named %s in %s", funcName, path)
+	case 1:
+	default:
+		var names []string
+		for _, decl := range found {
+			names = append(names, fmt.Sprintf("%s (line %d)", decl.Name, decl.Start+1))
+		}
+		return 0, 0, "", fmt.Errorf("%s is ambiguous in %s: %s; use Type.Method or --lines", funcName, path, strings.Join(names, ", "))
+	}
+	return found[0].Start, found[0].End, fmt.Sprintf("%s:%s", path, funcName), nil
+}
+
+// analyzeTarget analyzes the part of a file selected with --lines or --func
+func analyzeTarget(cfg *config.Config, a *analyzer.TerminalAnalyzer, path, absPath string) error {
+	content, err := os.ReadFile(absPath)
+	if err != nil {
+		return fmt.Errorf("error reading file: %w", err)
+	}
+	start, end, label, err := targetRange(path, content)
+	if err != nil {
+		return err
+	}
+
+	_, err = analyzeStreaming(a, "Analysis Results", func() (string, error) {
+		return a.AnalyzeRange(context.Background(), absPath, start, end)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to analyze file: %w", err)
+	}
+	// Findings of part of a file do not replace those of the whole file
+	FinishAnalysis(cfg, "range", label, a.LastAnalysis())
+	if fix || dryRun {
+		return fixFile(a, path, absPath, start, end)
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package goal
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bkidd1/wash-cli/cmd/wash/cmdutil"
+	"github.com/bkidd1/wash-cli/internal/services/goals"
+	"github.com/bkidd1/wash-cli/internal/services/notes"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Flags
+	projectName string
+)
+
+// Command creates the goal command
+func Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "goal",
+		Short: "Set and review the project goal",
+		Long: `Manage the goal of a project. Analyzers include it in every prompt so
+suggestions stay focused on what you are trying to achieve.
+
+Each project has its own goal, and every change is kept with a timestamp in
+~/.wash/projects/[project-name]/goal.json. The goal analyzers use is, in order:
+$WASH_PROJECT_GOAL, the goal set with 'wash goal set', then project_goal from
+the project .wash.yaml or the global config.
+
+Examples:
+  # Set the goal for the current project
+  wash goal set "Ship the v2 API with zero downtime"
+
+  # Show the current goal and where it comes from
+  wash goal show
+
+  # See how the goal changed over time
+  wash goal history`,
+	}
+
+	cmd.PersistentFlags().StringVarP(&projectName, "project", "p", "", "Project name (defaults to current directory name)")
+
+	cmd.AddCommand(setCmd())
+	cmd.AddCommand(showCmd())
+	cmd.AddCommand(historyCmd())
+	cmd.AddCommand(clearCmd())
+
+	return cmd
+}
+
+func setCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set [goal]",
+		Short: "Set the goal for the project",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			text := strings.TrimSpace(strings.Join(args, " "))
+			if text == "" {
+				return fmt.Errorf("goal cannot be empty; use 'wash goal clear' to remove it")
+			}
+			return setGoal(text)
+		},
+	}
+}
+
+func clearCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "clear",
+		Short: "Clear the project goal so the config file goal applies again",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return setGoal("")
+		},
+	}
+}
+
+// setGoal records a new goal for the project
+func setGoal(text string) error {
+	project, err := cmdutil.ProjectName(projectName)
+	if err != nil {
+		return err
+	}
+
+	goalManager, err := goals.NewGoalManager()
+	if err != nil {
+		return fmt.Errorf("failed to create goal manager: %w", err)
+	}
+	if _, err := goalManager.Set(project, text, notes.CurrentUser()); err != nil {
+		return fmt.Errorf("failed to save goal: %w", err)
+	}
+
+	if text == "" {
+		fmt.Printf("Goal cleared for %s\n", project)
+	} else {
+		fmt.Printf("Goal for %s: %s\n", project, text)
+	}
+	if os.Getenv(goals.GoalEnv) != "" {
+		fmt.Printf("Warning: $%s is set and overrides this goal\n", goals.GoalEnv)
+	}
+	return nil
+}
+
+func showCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show",
+		Short: "Show the goal analyzers use for the project",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			project, err := cmdutil.ProjectName(projectName)
+			if err != nil {
+				return err
+			}
+
+			goalManager, err := goals.NewGoalManager()
+			if err != nil {
+				return fmt.Errorf("failed to create goal manager: %w", err)
+			}
+			stored, err := goalManager.Load(project)
+			if err != nil {
+				return err
+			}
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
+			fmt.Printf("Project: %s\n", project)
+			switch {
+			case os.Getenv(goals.GoalEnv) != "":
+				fmt.Printf("Goal: %s\n", os.Getenv(goals.GoalEnv))
+				fmt.Printf("Source: $%s\n", goals.GoalEnv)
+			case stored.Current() != "":
+				last := stored.History[len(stored.History)-1]
+				fmt.Printf("Goal: %s\n", last.Goal)
+				fmt.Printf("Source: set %s by %s\n", last.SetAt.Local().Format("2006-01-02 15:04"), last.SetBy)
+			case cfg.ProjectGoal != "":
+				fmt.Printf("Goal: %s\n", cfg.ProjectGoal)
+				fmt.Println("Source: config file")
+			default:
+				fmt.Println("Goal: not set (use 'wash goal set')")
+			}
+			return nil
+		},
+	}
+}
+
+func historyCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "history",
+		Short: "Show how the project goal changed over time",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			project, err := cmdutil.ProjectName(projectName)
+			if err != nil {
+				return err
+			}
+
+			goalManager, err := goals.NewGoalManager()
+			if err != nil {
+				return fmt.Errorf("failed to create goal manager: %w", err)
+			}
+			stored, err := goalManager.Load(project)
+			if err != nil {
+				return err
+			}
+			if len(stored.History) == 0 {
+				fmt.Printf("No goal has been set for %s\n", project)
+				return nil
+			}
+
+			for i := len(stored.History) - 1; i >= 0; i-- {
+				change := stored.History[i]
+				text := change.Goal
+				if text == "" {
+					text = "(cleared)"
+				}
+				fmt.Printf("%s  %-10s  %s\n", change.SetAt.Local().Format("2006-01-02 15:04"), change.SetBy, text)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,122 @@
+package handoff
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/handoff"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/spf13/cobra"
+)
+
+// defaultWindow is how far back a handoff looks when the project has none yet
+const defaultWindow = 24 * time.Hour
+
+// Command creates the handoff command
+func Command() *cobra.Command {
+	var since time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "handoff",
+		Short: "Write a handoff briefing for whoever resumes the project",
+		Long: `Writes a compact briefing of where the current project was left: what was in
+progress, current blockers, next steps and the relevant files. It is generated
+from the progress notes, wash analyses and open bugs since the last handoff (or
+the last 24 hours), stored in ~/.wash/projects/[project-name]/handoffs and
+shown by wash context until the next one is written.
+
+wash monitor writes a handoff automatically when it stops.
+
+Examples:
+  # Hand off the work since the last handoff
+  wash handoff
+
+  # Hand off the last four hours
+  wash handoff --since 4h
+
+  # Show the latest handoff
+  wash handoff show`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			projectName, err := currentProject()
+			if err != nil {
+				return err
+			}
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
+			start := time.Now().Add(-since)
+			if since == 0 {
+				start = time.Now().Add(-defaultWindow)
+				hm, err := handoff.NewHandoffManager()
+				if err != nil {
+					return fmt.Errorf("failed to create handoff manager: %w", err)
+				}
+				if latest, err := hm.Latest(projectName); err != nil {
+					fmt.Printf("Warning: %v\n", err)
+				} else if latest != nil {
+					start = latest.CreatedAt
+				}
+			}
+
+			fmt.Printf("Writing handoff for %s since %s...\n", projectName, start.Local().Format("2006-01-02 15:04"))
+			written, err := handoff.Write(cfg, projectName, start)
+			if err != nil {
+				return fmt.Errorf("failed to write handoff: %w", err)
+			}
+			if written == nil {
+				fmt.Println("No progress notes or analyses since then; no handoff was written")
+				return nil
+			}
+			fmt.Printf("\n%s", written)
+			return nil
+		},
+	}
+
+	cmd.Flags().DurationVar(&since, "since", 0, "Cover this much time, such as 4h (default since the last handoff, or 24h)")
+	cmd.AddCommand(showCmd())
+
+	return cmd
+}
+
+func showCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show",
+		Short: "Show the latest handoff of the project",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			projectName, err := currentProject()
+			if err != nil {
+				return err
+			}
+			hm, err := handoff.NewHandoffManager()
+			if err != nil {
+				return fmt.Errorf("failed to create handoff manager: %w", err)
+			}
+
+			latest, err := hm.Latest(projectName)
+			if err != nil {
+				return err
+			}
+			if latest == nil {
+				fmt.Printf("No handoff for %s yet; run wash handoff to write one\n", projectName)
+				return nil
+			}
+			fmt.Print(latest)
+			return nil
+		},
+	}
+}
+
+// currentProject returns the name of the project in the current directory
+func currentProject() (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current directory: %w", err)
+	}
+	return filepath.Base(cwd), nil
+}
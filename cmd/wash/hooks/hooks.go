@@ -0,0 +1,156 @@
+package hooks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bkidd1/wash-cli/internal/services/hooks"
+	"github.com/bkidd1/wash-cli/internal/services/journal"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/spf13/cobra"
+)
+
+// Command creates the hooks command
+func Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "hooks",
+		Short: "List, trust and test hook commands",
+		Long: `Hooks run your own commands when wash events happen, with a JSON payload on
+stdin holding the event, project, time and event data. Configure them in the
+global wash.yaml or a project .wash.yaml:
+
+  hooks:
+    on-critical-finding: ./scripts/notify.sh   # wash file found critical issues
+    on-summary-generated: ./scripts/post.sh    # wash summary generated a summary
+    on-bug-created: jq -r .data.id >> bugs.txt # wash bug saved a report
+
+Hooks run with sh -c (cmd /C on Windows) in the current directory, with
+WASH_HOOK_EVENT and WASH_HOOK_PROJECT set, and are stopped after 30 seconds.
+A failing hook prints a warning and never fails the command that fired it.
+
+Hooks from a project .wash.yaml come with the repository, so they only run
+after you review them and run 'wash hooks trust'. Changing them requires
+trusting them again.
+
+Examples:
+  # Show the configured hooks and whether project hooks are trusted
+  wash hooks
+
+  # Allow the hooks of the current project's .wash.yaml to run
+  wash hooks trust
+
+  # Run a hook with a sample payload
+  wash hooks test on-bug-created`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			runner := hooks.NewRunner(cfg)
+			projectHooks, projectFile := runner.ProjectHooks()
+
+			if len(cfg.Hooks) == 0 {
+				fmt.Println("No hooks configured")
+				return nil
+			}
+			for _, event := range config.HookEvents {
+				command := runner.Command(event)
+				if command == "" {
+					continue
+				}
+				source := "global config or environment"
+				if _, ok := projectHooks[event]; ok {
+					source = projectFile
+					if !runner.Trusted() {
+						source += ", not trusted"
+					}
+				}
+				fmt.Printf("%-22s %s (%s)\n", event, command, source)
+			}
+			return nil
+		},
+	}
+
+	cmd.AddCommand(trustCmd())
+	cmd.AddCommand(testCmd())
+
+	return cmd
+}
+
+func trustCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "trust",
+		Short: "Allow the hooks of the project .wash.yaml to run",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			runner := hooks.NewRunner(cfg)
+			if err := runner.Trust(); err != nil {
+				return err
+			}
+
+			projectHooks, projectFile := runner.ProjectHooks()
+			journal.Record(journal.ActionConfigChanged, "", projectFile, "hooks trusted")
+			fmt.Printf("Trusted the hooks of %s:\n", projectFile)
+			for _, event := range config.HookEvents {
+				if command, ok := projectHooks[event]; ok {
+					fmt.Printf("  %s: %s\n", event, command)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func testCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:       "test <event>",
+		Short:     "Run the hook for an event with a sample payload",
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: config.HookEvents,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			event := args[0]
+			if !slices.Contains(config.HookEvents, event) {
+				return fmt.Errorf("unknown event %q (expected %s)", event, strings.Join(config.HookEvents, ", "))
+			}
+
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			runner := hooks.NewRunner(cfg)
+			if runner.Command(event) == "" {
+				return fmt.Errorf("no hook configured for %s", event)
+			}
+
+			cwd, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get current directory: %w", err)
+			}
+			if err := runner.Run(event, filepath.Base(cwd), sampleData(event)); err != nil {
+				return err
+			}
+			fmt.Printf("%s hook ran successfully\n", event)
+			return nil
+		},
+	}
+}
+
+// sampleData returns example event data for testing a hook
+func sampleData(event string) any {
+	switch event {
+	case hooks.CriticalFinding:
+		return hooks.Finding{File: "main.go", CriticalIssues: []string{"Is the error from db.Close ignored on purpose?"}}
+	case hooks.SummaryGenerated:
+		return hooks.Summary{Period: "2024-05-01", Summary: "Sample summary of the day's progress.", Notes: 3}
+	default:
+		return hooks.Bug{ID: "2024-05-01-10-30-00", Description: "Sample bug", Priority: "medium"}
+	}
+}
@@ -1,18 +1,44 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 
+	"github.com/bkidd1/wash-cli/cmd/wash/activity"
+	"github.com/bkidd1/wash-cli/cmd/wash/agent"
+	baselinecmd "github.com/bkidd1/wash-cli/cmd/wash/baseline"
 	"github.com/bkidd1/wash-cli/cmd/wash/bug"
+	"github.com/bkidd1/wash-cli/cmd/wash/clean"
 	configcmd "github.com/bkidd1/wash-cli/cmd/wash/config"
+	contextcmd "github.com/bkidd1/wash-cli/cmd/wash/context"
+	"github.com/bkidd1/wash-cli/cmd/wash/diff"
+	"github.com/bkidd1/wash-cli/cmd/wash/doctor"
+	"github.com/bkidd1/wash-cli/cmd/wash/estimate"
 	"github.com/bkidd1/wash-cli/cmd/wash/file"
+	"github.com/bkidd1/wash-cli/cmd/wash/goal"
+	"github.com/bkidd1/wash-cli/cmd/wash/handoff"
+	hookscmd "github.com/bkidd1/wash-cli/cmd/wash/hooks"
+	"github.com/bkidd1/wash-cli/cmd/wash/migrate"
 	"github.com/bkidd1/wash-cli/cmd/wash/monitor"
+	notescmd "github.com/bkidd1/wash-cli/cmd/wash/notes"
 	"github.com/bkidd1/wash-cli/cmd/wash/project"
+	"github.com/bkidd1/wash-cli/cmd/wash/refactorplan"
 	"github.com/bkidd1/wash-cli/cmd/wash/remember"
+	"github.com/bkidd1/wash-cli/cmd/wash/retro"
+	"github.com/bkidd1/wash-cli/cmd/wash/snapshot"
 	"github.com/bkidd1/wash-cli/cmd/wash/summary"
+	"github.com/bkidd1/wash-cli/cmd/wash/tags"
+	templatescmd "github.com/bkidd1/wash-cli/cmd/wash/templates"
 	versioncmd "github.com/bkidd1/wash-cli/cmd/wash/version"
+	workspacecmd "github.com/bkidd1/wash-cli/cmd/wash/workspace"
+	"github.com/bkidd1/wash-cli/internal/services/journal"
+	"github.com/bkidd1/wash-cli/internal/services/notes"
+	"github.com/bkidd1/wash-cli/internal/services/pricing"
+	"github.com/bkidd1/wash-cli/internal/services/usage"
 	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/pkg/version"
 	"github.com/spf13/cobra"
 )
 
@@ -28,8 +54,12 @@ var rootCmd = &cobra.Command{
 }
 
 func init() {
+	// Global flags override environment variables and config files
+	config.BindFlags(rootCmd.PersistentFlags())
+
 	// Add commands
 	rootCmd.AddCommand(file.Command())
+	rootCmd.AddCommand(diff.Command())
 	rootCmd.AddCommand(bug.Command())
 	rootCmd.AddCommand(versioncmd.Command())
 	rootCmd.AddCommand(configcmd.Command())
@@ -40,6 +70,24 @@ func init() {
 	rootCmd.AddCommand(monitorCmd)
 
 	rootCmd.AddCommand(project.Command())
+	rootCmd.AddCommand(refactorplan.Command())
+	rootCmd.AddCommand(estimate.Command())
+	rootCmd.AddCommand(workspacecmd.Command())
+	rootCmd.AddCommand(agent.Command())
+	rootCmd.AddCommand(migrate.Command())
+	rootCmd.AddCommand(templatescmd.Command())
+	rootCmd.AddCommand(notescmd.Command())
+	rootCmd.AddCommand(goal.Command())
+	rootCmd.AddCommand(tags.Command())
+	rootCmd.AddCommand(hookscmd.Command())
+	rootCmd.AddCommand(activity.Command())
+	rootCmd.AddCommand(snapshot.Command())
+	rootCmd.AddCommand(retro.Command())
+	rootCmd.AddCommand(handoff.Command())
+	rootCmd.AddCommand(contextcmd.Command())
+	rootCmd.AddCommand(baselinecmd.Command())
+	rootCmd.AddCommand(clean.Command())
+	rootCmd.AddCommand(doctor.Command())
 
 	// Add hidden commands
 	rememberCmd := remember.Command()
@@ -87,9 +135,16 @@ Use "{{.CommandPath}} [command] --help" for more information about a command.{{e
 
 	// Add pre-run function to check for API key
 	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
-		// Skip API key check for config and version commands
-		if cmd.Use == "config" || cmd.Use == "version" {
-			return nil
+		// Attribute API calls in the usage ledger to the running command
+		usage.SetCommand(strings.TrimPrefix(cmd.CommandPath(), rootCmd.Name()+" "))
+		journal.SetCommand(strings.TrimPrefix(cmd.CommandPath(), rootCmd.Name()+" "))
+
+		// Skip API key check for config, version and doctor commands, including config
+		// subcommands, so a missing key or a broken config file can still be found and fixed
+		for c := cmd; c != nil; c = c.Parent() {
+			if c.Name() == "config" || c.Name() == "version" || c.Name() == "doctor" {
+				return nil
+			}
 		}
 
 		// Check if API key is set
@@ -104,13 +159,50 @@ Use "{{.CommandPath}} [command] --help" for more information about a command.{{e
 			return fmt.Errorf("API key not set")
 		}
 
+		// Keep estimates and the spend cap on current prices; a due check runs in the background, and
+		// offline the last known prices are used
+		_ = pricing.Refresh("")
+
+		// Stop API calls once this month's spend reaches the cap, and keep parallel analyses within
+		// the rate limit
+		if cfg, err := config.LoadConfig(); err == nil {
+			usage.SetSpendCap(cfg.MonthlySpendCap)
+			usage.SetRateLimit(cfg.RequestsPerMinute)
+		}
+
+		// 'wash migrate' reports conflicts itself
+		if cmd.Name() != "migrate" {
+			warnDataConflicts()
+		}
+
 		return nil
 	}
 }
 
+// warnDataConflicts warns when other wash versions write notes to the data directory in another format
+func warnDataConflicts() {
+	notesManager, err := notes.NewNotesManager()
+	if err != nil {
+		return
+	}
+	report, err := notesManager.CheckWriters(version.Version)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not check for other wash versions: %v\n", err)
+		return
+	}
+	if report.HasConflicts() {
+		fmt.Fprintf(os.Stderr, "WARNING: other versions of wash are writing notes in a different format:\n%s\n", report)
+	}
+}
+
 func main() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		// Errors such as a failed --fail-on gate exit with their own status
+		var exit interface{ ExitCode() int }
+		if errors.As(err, &exit) {
+			os.Exit(exit.ExitCode())
+		}
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,92 @@
+package migrate
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/bkidd1/wash-cli/internal/services/notes"
+	"github.com/bkidd1/wash-cli/pkg/version"
+	"github.com/spf13/cobra"
+)
+
+// Command creates the migrate command
+func Command() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Upgrade stored notes to the current schema",
+		Long: fmt.Sprintf(`Upgrade interaction, monitor, progress and remember notes in ~/.wash to
+schema version %d in place.
+
+Notes written by a newer version of wash are left untouched. wash warns on
+startup when another version, or an old copy that predates schema versions,
+writes to the same data directory; migrate reports those conflicts too, and
+once every note is upgraded it stops warning about the older versions.
+
+Examples:
+  # Show what would be migrated
+  wash migrate --dry-run
+
+  # Migrate all notes
+  wash migrate`, notes.CurrentSchemaVersion),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			notesManager, err := notes.NewNotesManager()
+			if err != nil {
+				return fmt.Errorf("failed to create notes manager: %w", err)
+			}
+
+			conflicts, err := notesManager.CheckWriters(version.Version)
+			if err != nil {
+				return fmt.Errorf("failed to check for other wash versions: %w", err)
+			}
+			if conflicts.HasConflicts() {
+				fmt.Printf("Other versions of wash write to this data directory:\n%s\n", conflicts)
+			}
+
+			report, err := notesManager.Migrate(dryRun)
+			if err != nil {
+				return fmt.Errorf("failed to migrate notes: %w", err)
+			}
+
+			verb := "Migrated"
+			if dryRun {
+				verb = "Would migrate"
+			}
+			fmt.Printf("Scanned %d notes. %s %d to schema version %d.\n", report.Scanned, verb, report.Migrated, notes.CurrentSchemaVersion)
+
+			if len(report.Skipped) > 0 {
+				fmt.Printf("\nSkipped %d notes written by a newer version of wash:\n", len(report.Skipped))
+				for _, path := range report.Skipped {
+					fmt.Printf("  %s\n", path)
+				}
+			}
+
+			if len(report.Failed) > 0 {
+				paths := make([]string, 0, len(report.Failed))
+				for path := range report.Failed {
+					paths = append(paths, path)
+				}
+				sort.Strings(paths)
+
+				fmt.Printf("\nFailed to migrate %d notes:\n", len(report.Failed))
+				for _, path := range paths {
+					fmt.Printf("  %s: %v\n", path, report.Failed[path])
+				}
+				return fmt.Errorf("%d notes could not be migrated", len(report.Failed))
+			}
+
+			if !dryRun && len(conflicts.Older) > 0 {
+				if err := notesManager.ForgetOlderWriters(); err != nil {
+					return fmt.Errorf("failed to update writer registry: %w", err)
+				}
+				fmt.Println("\nNotes from older versions are upgraded. Remove those copies of wash so they stop writing the old format.")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Report what would change without writing")
+
+	return cmd
+}
@@ -1,6 +1,9 @@
 package monitor
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -10,17 +13,40 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/bkidd1/wash-cli/internal/pid"
+	"github.com/bkidd1/wash-cli/internal/services/api"
+	"github.com/bkidd1/wash-cli/internal/services/attachments"
 	"github.com/bkidd1/wash-cli/internal/services/monitor/chatmonitor"
+	"github.com/bkidd1/wash-cli/internal/services/notes"
+	"github.com/bkidd1/wash-cli/internal/services/screenshot"
+	"github.com/bkidd1/wash-cli/internal/services/terminal"
+	"github.com/bkidd1/wash-cli/internal/services/transcript"
+	"github.com/bkidd1/wash-cli/internal/services/workspace"
 	"github.com/bkidd1/wash-cli/internal/utils/config"
 	"github.com/spf13/cobra"
 )
 
 var (
 	// Global flags
-	projectName string
-	pidFile     = filepath.Join(os.TempDir(), "wash-monitor.pid")
+	projectName   string
+	workspaceName string
+	listenAddr    string
+	apiToken      string
+	source        string
+	target        string
+	keepShots     bool
+	ocr           bool
+	display       int
+	assumeYes     bool
+	pidFile       = filepath.Join(os.TempDir(), "wash-monitor.pid")
 )
 
+// errSafeMode is returned when the monitor is started in the safe profile
+var errSafeMode = errors.New("wash monitor is off in safe mode, since it analyzes a screenshot every 30 seconds; run 'wash config upgrade' to turn it on")
+
+// stopTimeout is how long wash monitor stop waits for the final summary to be written
+const stopTimeout = 2 * time.Minute
+
 // Command creates the monitor command with start and stop subcommands
 func Command() *cobra.Command {
 	cmd := &cobra.Command{
@@ -43,39 +69,65 @@ Examples:
   # Start monitoring specific project
   wash monitor --project my-project
 
+  # Monitor every repository in a workspace
+  wash monitor --workspace my-workspace
+
+  # Accept notes from 'wash agent' on a remote machine
+  wash monitor --listen
+
+  # Read a tmux pane instead of taking screenshots (e.g. vim + aider over SSH)
+  wash monitor --source tmux --target dev:0.1
+
+  # Follow this project's Claude Code sessions or aider chat history
+  wash monitor --source claude
+  wash monitor --source aider
+
   # Stop monitoring
   wash monitor stop`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Check if monitor is already running
-			if _, err := os.Stat(pidFile); err == nil {
-				// Read PID from file
-				pidBytes, err := os.ReadFile(pidFile)
+			if status, err := chatmonitor.QueryStatus(); err == nil {
+				return fmt.Errorf("monitor is already running for %s (pid %d). Use 'wash monitor stop' to stop it first", strings.Join(status.Projects, ", "), status.PID)
+			}
+
+			cwd, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get current directory: %w", err)
+			}
+
+			// Validate the capture source before doing any work
+			if err := validateSource(); err != nil {
+				return err
+			}
+			if err := checkScreenRecording(); err != nil {
+				return err
+			}
+
+			// Load the workspace, defaulting the project to the repository we are in
+			var ws *workspace.Workspace
+			if workspaceName != "" {
+				workspaceManager, err := workspace.NewWorkspaceManager()
 				if err != nil {
-					// Clean up invalid PID file
-					os.Remove(pidFile)
-				} else {
-					pid, err := strconv.Atoi(strings.TrimSpace(string(pidBytes)))
-					if err == nil {
-						// Check if process exists and is running
-						process, err := os.FindProcess(pid)
-						if err == nil {
-							// On Unix systems, FindProcess always succeeds, so we need to check if the process is actually running
-							if err := process.Signal(syscall.Signal(0)); err == nil {
-								return fmt.Errorf("monitor is already running. Use 'wash monitor stop' to stop it first")
-							}
-						}
+					return fmt.Errorf("failed to create workspace manager: %w", err)
+				}
+				ws, err = workspaceManager.LoadWorkspace(workspaceName)
+				if err != nil {
+					return err
+				}
+				if len(ws.Repos) == 0 {
+					return fmt.Errorf("workspace %s has no repositories", workspaceName)
+				}
+				if projectName == "" {
+					if repo, ok := ws.RepoForPath(cwd); ok {
+						projectName = repo.Name
+					} else {
+						projectName = ws.Repos[0].Name
 					}
-					// Clean up invalid or stale PID file
-					os.Remove(pidFile)
 				}
 			}
 
 			// If project name not provided, use current directory name
 			if projectName == "" {
-				cwd, err := os.Getwd()
-				if err != nil {
-					return fmt.Errorf("failed to get current directory: %w", err)
-				}
 				projectName = filepath.Base(cwd)
 			}
 
@@ -85,62 +137,206 @@ Examples:
 				return fmt.Errorf("failed to load config: %w", err)
 			}
 
+			// The monitor analyzes a screenshot every 30 seconds, which safe mode does not allow
+			if cfg.Safe() {
+				return errSafeMode
+			}
+
+			if err := checkRedaction(cfg); err != nil {
+				return err
+			}
+
+			if err := confirmCost(cfg); err != nil {
+				return err
+			}
+
 			// Create monitor
 			m, err := chatmonitor.NewMonitor(cfg, projectName)
 			if err != nil {
 				return fmt.Errorf("failed to create monitor: %w", err)
 			}
+			if ws != nil {
+				m.SetWorkspace(ws)
+			}
+			if format, err := transcript.ParseFormat(source); err == nil {
+				tailer, err := transcript.NewTailer(format, target, cwd)
+				if err != nil {
+					return err
+				}
+				m.SetTranscript(tailer)
+			} else if terminalSource, err := terminal.ParseSource(source); err == nil {
+				m.SetTerminalSource(terminalSource, target)
+			} else {
+				if ocr {
+					if err := m.SetOCR(); err != nil {
+						return err
+					}
+				}
+				if display != 0 {
+					if err := m.SetDisplay(display); err != nil {
+						return err
+					}
+				}
+			}
+			if keepShots {
+				attachmentManager, err := attachments.NewAttachmentManager(int64(cfg.AttachmentQuotaMB) << 20)
+				if err != nil {
+					return fmt.Errorf("failed to create attachment manager: %w", err)
+				}
+				m.SetKeepScreenshots(attachmentManager)
+			}
 
 			// Start monitoring
 			if err := m.Start(); err != nil {
 				return fmt.Errorf("failed to start monitor: %w", err)
 			}
 
+			// Serve the local API for remote agents
+			var server *api.Server
+			if listenAddr != "" {
+				notesManager, err := notes.NewNotesManager()
+				if err != nil {
+					return fmt.Errorf("failed to create notes manager: %w", err)
+				}
+				if apiToken == "" {
+					apiToken = os.Getenv(api.TokenEnv)
+				}
+				tokenPath := ""
+				if apiToken == "" {
+					if apiToken, tokenPath, err = api.LoadToken(); err != nil {
+						m.Stop()
+						return fmt.Errorf("failed to load the local API token: %w", err)
+					}
+				}
+				server, err = api.NewServer(listenAddr, apiToken, notesManager)
+				if err == nil {
+					err = server.Start()
+				}
+				if err != nil {
+					m.Stop()
+					return fmt.Errorf("failed to start local API: %w", err)
+				}
+				fmt.Printf("Accepting remote agent notes on %s\n", listenAddr)
+				if tokenPath != "" {
+					fmt.Printf("Agents need the token in %s: pass it to 'wash agent' with --token or $%s\n", tokenPath, api.TokenEnv)
+				}
+			}
+
 			// Write PID to file
 			if err := os.WriteFile(pidFile, []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
 				return fmt.Errorf("failed to write PID file: %w", err)
 			}
 
-			// Start time for elapsed time calculation
-			startTime := time.Now()
-
-			// Create a ticker for updating the timer display
-			ticker := time.NewTicker(time.Second)
-			defer ticker.Stop()
-
-			// Create a channel for handling interrupts
-			interrupt := make(chan os.Signal, 1)
-			signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
-
-			// Display timer in foreground
-			fmt.Println("Monitoring started. Press Ctrl+C to stop.")
-			for {
-				select {
-				case <-ticker.C:
-					elapsed := time.Since(startTime)
-					fmt.Printf("\rMonitoring for: %02d:%02d:%02d",
-						int(elapsed.Hours()),
-						int(elapsed.Minutes())%60,
-						int(elapsed.Seconds())%60)
-				case <-interrupt:
-					fmt.Println("\nStopping monitor...")
-					m.Stop()
-					os.Remove(pidFile)
-					return nil
-				}
-			}
+			return runForeground(m, server)
 		},
 	}
 
 	// Add global flags
 	cmd.PersistentFlags().StringVarP(&projectName, "project", "p", "", "Project name (defaults to current directory name)")
-
-	// Add stop command
+	cmd.Flags().StringVarP(&workspaceName, "workspace", "w", "", "Monitor every repository in a workspace")
+	cmd.Flags().StringVar(&listenAddr, "listen", "", "Serve the local API for remote agents (default address "+api.DefaultAddr+")")
+	cmd.Flags().Lookup("listen").NoOptDefVal = api.DefaultAddr
+	cmd.Flags().StringVar(&source, "source", "screenshot", sourceUsage)
+	cmd.Flags().StringVar(&target, "target", "", targetUsage)
+	cmd.Flags().BoolVar(&keepShots, "keep-screenshots", false, "Keep analyzed screenshots as attachments on monitor notes")
+	cmd.Flags().BoolVar(&ocr, "ocr", false, ocrUsage)
+	cmd.Flags().IntVar(&display, "display", 0, displayUsage)
+	cmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Start without confirming the cost estimate")
+	cmd.Flags().StringVar(&apiToken, "token", "", "Shared API token for remote agents (defaults to $"+api.TokenEnv+", then a token generated in the data directory)")
+
+	// Add stop, status and service commands
 	cmd.AddCommand(stopCmd())
+	cmd.AddCommand(statusCmd())
+	cmd.AddCommand(pauseCmd())
+	cmd.AddCommand(resumeCmd())
+	cmd.AddCommand(installCmd())
+	cmd.AddCommand(uninstallCmd())
 
 	return cmd
 }
 
+const (
+	sourceUsage  = "Capture source: screenshot, tmux, screen, or the transcript of claude or aider"
+	targetUsage  = "tmux target pane, screen session or transcript file or directory to follow (defaults to the current one)"
+	ocrUsage     = "Read screenshots locally with tesseract and send only their text"
+	displayUsage = "Capture this display, numbered from 1, instead of the editor's window"
+)
+
+// checkRedaction refuses to send screenshots that secrets cannot be blacked out of, unless
+// redact_secrets is off
+func checkRedaction(cfg *config.Config) error {
+	if source != "screenshot" || ocr || cfg.RedactSecrets == "off" || screenshot.OCRAvailable() {
+		return nil
+	}
+	return fmt.Errorf("screenshots are only sent once secrets are blacked out of them, which needs tesseract; install it with your package manager, or set redact_secrets: off to send them unredacted")
+}
+
+// validateSource checks --source names a capture source: screenshots, a terminal multiplexer or an
+// agent's transcript
+func validateSource() error {
+	if source == "screenshot" {
+		if ocr && !screenshot.OCRAvailable() {
+			return fmt.Errorf("--ocr needs tesseract, which is not installed; install it with your package manager")
+		}
+		if display != 0 {
+			return screenshot.CheckDisplay(display)
+		}
+		return nil
+	}
+	if ocr || display != 0 {
+		return fmt.Errorf("--ocr and --display apply to screenshots, so they cannot be used with --source %s", source)
+	}
+	if _, err := transcript.ParseFormat(source); err == nil {
+		return nil
+	}
+	if _, err := terminal.ParseSource(source); err == nil {
+		return nil
+	}
+	return fmt.Errorf("unknown source %q (expected screenshot, tmux, screen, claude or aider)", source)
+}
+
+// checkScreenRecording stops a screenshot monitor that macOS would let capture only a blank
+// desktop, after printing how to grant the permission
+func checkScreenRecording() error {
+	if source != "screenshot" {
+		return nil
+	}
+	if err := screenshot.CheckScreenRecording(); err != nil {
+		fmt.Println(screenshot.ScreenRecordingSteps)
+		return err
+	}
+	return nil
+}
+
+// confirmCost shows what monitoring will cost, and asks unless this estimate was accepted before
+// or --yes was given
+func confirmCost(cfg *config.Config) error {
+	interval, err := chatmonitor.Interval(cfg)
+	if err != nil {
+		return err
+	}
+	costSource := source
+	if ocr {
+		costSource = "ocr"
+	}
+	estimate := chatmonitor.EstimateCost(interval, costSource)
+	fmt.Println(estimate)
+	if cfg.MonthlySpendCap > 0 {
+		fmt.Printf("  API calls stop once $%.2f has been spent this month (monthly_spend_cap)\n", cfg.MonthlySpendCap)
+	}
+	if !assumeYes && !estimate.Confirmed() {
+		fmt.Print("Start monitoring? (y/N): ")
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+			return fmt.Errorf("monitoring not started; use --yes to start without confirming")
+		}
+	}
+	if err := estimate.Confirm(); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	return nil
+}
+
 func runMonitorCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:    "run-monitor",
@@ -153,6 +349,11 @@ func runMonitorCmd() *cobra.Command {
 				return fmt.Errorf("failed to load config: %w", err)
 			}
 
+			// The monitor analyzes a screenshot every 30 seconds, which safe mode does not allow
+			if cfg.Safe() {
+				return errSafeMode
+			}
+
 			// Create monitor
 			m, err := chatmonitor.NewMonitor(cfg, projectName)
 			if err != nil {
@@ -164,40 +365,73 @@ func runMonitorCmd() *cobra.Command {
 				return fmt.Errorf("failed to start monitor: %w", err)
 			}
 
-			// Start time for elapsed time calculation
-			startTime := time.Now()
-
-			// Create a ticker for updating the timer display
-			ticker := time.NewTicker(time.Second)
-			defer ticker.Stop()
-
-			// Create a channel for handling interrupts
-			interrupt := make(chan os.Signal, 1)
-			signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
-
-			// Display timer in foreground
-			fmt.Println("Monitoring started. Press Ctrl+C to stop.")
-			for {
-				select {
-				case <-ticker.C:
-					elapsed := time.Since(startTime)
-					fmt.Printf("\rMonitoring for: %02d:%02d:%02d",
-						int(elapsed.Hours()),
-						int(elapsed.Minutes())%60,
-						int(elapsed.Seconds())%60)
-				case <-interrupt:
-					fmt.Println("\nStopping monitor...")
-					m.Stop()
-					os.Remove(pidFile)
-					return nil
-				}
-			}
+			return runForeground(m, nil)
 		},
 	}
 
 	return cmd
 }
 
+// runForeground shows the elapsed time until the monitor is stopped by Ctrl+C, a signal or
+// wash monitor stop, then prints the session recap
+func runForeground(m *chatmonitor.Monitor, server *api.Server) error {
+	// Write PID to file for monitors stopped by older versions of wash monitor stop
+	if err := os.WriteFile(pidFile, []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+		return fmt.Errorf("failed to write PID file: %w", err)
+	}
+	defer os.Remove(pidFile)
+
+	// Start time for elapsed time calculation
+	startTime := time.Now()
+
+	// Create a ticker for updating the timer display
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	// Create a channel for handling interrupts
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+
+	// Display timer in foreground; a service's output goes to a log, which it would fill
+	fmt.Println("Monitoring started. Press Ctrl+C to stop.")
+	interactive := false
+	if info, err := os.Stdout.Stat(); err == nil {
+		interactive = info.Mode()&os.ModeCharDevice != 0
+	}
+	for {
+		select {
+		case <-ticker.C:
+			if !interactive {
+				continue
+			}
+			elapsed := time.Since(startTime)
+			fmt.Printf("\rMonitoring for: %02d:%02d:%02d",
+				int(elapsed.Hours()),
+				int(elapsed.Minutes())%60,
+				int(elapsed.Seconds())%60)
+			continue
+		case <-interrupt:
+			fmt.Println("\nStopping monitor...")
+			fmt.Println("Writing final summary...")
+		case <-m.Stopped():
+			fmt.Println("\nStopped by wash monitor stop")
+		}
+		break
+	}
+
+	if server != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		server.Stop(ctx)
+		cancel()
+	}
+	m.Stop()
+	m.CloseControl()
+	if summary := m.Summary(); summary != nil {
+		fmt.Print(summary)
+	}
+	return nil
+}
+
 func stopCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "stop",
@@ -206,68 +440,255 @@ func stopCmd() *cobra.Command {
 This will:
 1. Stop tracking new changes
 2. Save current progress
-3. Generate final report`,
+3. Write a final progress note for the time since the last 5-minute summary
+4. Write a handoff briefing for the next session, shown by wash context
+5. Report what was stopped, how long it ran and a session recap
+
+The request goes to the running monitor over its control socket in the data
+directory, and the command waits for the final summary to be written.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// Check if PID file exists
-			if _, err := os.Stat(pidFile); os.IsNotExist(err) {
-				fmt.Println("No monitor process is running")
-				return nil
+			fmt.Println("Stopping monitor and writing its final summary...")
+			status, summary, err := chatmonitor.RequestStop(stopTimeout)
+			if errors.Is(err, chatmonitor.ErrNotRunning) {
+				return stopByPID()
 			}
-
-			// Read PID from file
-			pidBytes, err := os.ReadFile(pidFile)
 			if err != nil {
-				// Clean up invalid PID file
-				os.Remove(pidFile)
-				fmt.Println("No monitor process is running")
-				return nil
+				return fmt.Errorf("failed to stop monitor: %w", err)
 			}
 
-			pid, err := strconv.Atoi(strings.TrimSpace(string(pidBytes)))
-			if err != nil {
-				// Clean up invalid PID file
-				os.Remove(pidFile)
-				fmt.Println("No monitor process is running")
-				return nil
+			fmt.Printf("Stopped monitor for %s (pid %d, %s capture), started %s\n",
+				strings.Join(status.Projects, ", "), status.PID, status.Source, status.Started.Local().Format("2006-01-02 15:04:05"))
+			if summary != nil {
+				fmt.Print(summary)
 			}
+			return nil
+		},
+	}
+
+	return cmd
+}
 
-			// Check if process exists and is running
-			process, err := os.FindProcess(pid)
+func statusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show whether a monitor is running and what it is doing",
+		Long: `Reports whether a monitor is running and, if so, for which projects, for how
+long, when it last captured the screen or terminal, the last analysis
+request that failed, and the health of its loop: its last heartbeat and the
+times it died and was restarted. It also counts the notes written today, for the monitored
+projects or, when none is running, for --project or the current directory.
+
+A monitor that does not answer on its control socket, such as one started by an
+older wash, is found through its PID file.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			status, err := chatmonitor.QueryStatus()
+			if errors.Is(err, chatmonitor.ErrNotRunning) {
+				if pid := runningPID(); pid != 0 {
+					fmt.Printf("Monitor process %d is running but does not answer on its control socket\n", pid)
+				} else {
+					fmt.Println("No monitor is running")
+				}
+				project := projectName
+				if project == "" {
+					cwd, err := os.Getwd()
+					if err != nil {
+						return fmt.Errorf("failed to get current directory: %w", err)
+					}
+					project = filepath.Base(cwd)
+				}
+				return printNotesToday([]string{project})
+			}
 			if err != nil {
-				// Clean up PID file for non-existent process
-				os.Remove(pidFile)
-				fmt.Println("No monitor process is running")
-				return nil
+				return err
 			}
 
-			// On Unix systems, FindProcess always succeeds, so we need to check if the process is actually running
-			if err := process.Signal(syscall.Signal(0)); err != nil {
-				// Process not running, clean up PID file
-				os.Remove(pidFile)
-				fmt.Println("No monitor process is running")
-				return nil
+			fmt.Printf("Monitoring %s (pid %d)\n", strings.Join(status.Projects, ", "), status.PID)
+			fmt.Printf("Capture: %s\n", status.Source)
+			if paused := pauseDescription(status); paused != "" {
+				fmt.Println(paused)
+			}
+			fmt.Printf("Running for %s, since %s\n", time.Since(status.Started).Round(time.Second), status.Started.Local().Format("2006-01-02 15:04:05"))
+			if status.LastCapture.IsZero() {
+				fmt.Println("Last capture: none yet")
+			} else {
+				fmt.Printf("Last capture: %s\n", ago(status.LastCapture))
+			}
+			if status.LastError != "" {
+				fmt.Printf("Last API error: %s, %s\n", ago(status.LastErrorAt), status.LastError)
+			}
+			if !status.Heartbeat.IsZero() {
+				fmt.Printf("Heartbeat: %s\n", ago(status.Heartbeat))
+			}
+			if status.Stalled {
+				fmt.Println("Warning: the monitor loop has missed its last heartbeats; if it stays stuck, stop it with 'wash monitor stop' and start it again")
+			}
+			if status.Restarts > 0 {
+				fmt.Printf("Loop restarts: %d, the last %s: %s\n", status.Restarts, ago(status.RestartedAt), status.RestartReason)
 			}
+			if !status.RestartAt.IsZero() {
+				fmt.Printf("Loop restarting at %s\n", status.RestartAt.Local().Format("15:04:05"))
+			}
+			fmt.Printf("Notes this session: %d monitor, %d progress\n", status.MonitorNotes, status.ProgressNotes)
+			if status.IdleSkips > 0 {
+				fmt.Printf("Unchanged screenshots skipped: %d\n", status.IdleSkips)
+			}
+			if status.Redactions > 0 {
+				fmt.Printf("Secrets redacted before upload: %d\n", status.Redactions)
+			}
+			return printNotesToday(status.Projects)
+		},
+	}
+}
+
+func pauseCmd() *cobra.Command {
+	var pauseFor time.Duration
+	cmd := &cobra.Command{
+		Use:   "pause",
+		Short: "Stop capturing the screen during sensitive work, without stopping the monitor",
+		Long: `Pauses the running monitor's screenshots and terminal captures, until
+'wash monitor resume' or, with --for, until the time is up. The monitor keeps
+running, so the session, its progress notes and its recap go on as before.
+
+Examples:
+  # Pause until resumed
+  wash monitor pause
 
-			// Send termination signal to the process group
-			pgid, err := syscall.Getpgid(pid)
+  # Pause for half an hour
+  wash monitor pause --for 30m`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if pauseFor < 0 {
+				return fmt.Errorf("--for must be positive")
+			}
+			status, err := chatmonitor.RequestPause(pauseFor)
+			if errors.Is(err, chatmonitor.ErrNotRunning) {
+				return fmt.Errorf("no monitor is running")
+			}
 			if err != nil {
-				// Clean up PID file if we can't get the process group
-				os.Remove(pidFile)
-				fmt.Println("No monitor process is running")
-				return nil
+				return fmt.Errorf("failed to pause monitor: %w", err)
 			}
-
-			if err := syscall.Kill(-pgid, syscall.SIGTERM); err != nil {
-				return fmt.Errorf("failed to stop monitor: %w", err)
+			fmt.Printf("Paused monitor for %s (pid %d)\n", strings.Join(status.Projects, ", "), status.PID)
+			if status.PausedUntil.IsZero() {
+				fmt.Println("Run 'wash monitor resume' to capture again.")
+			} else {
+				fmt.Printf("Captures resume at %s\n", status.PausedUntil.Local().Format("15:04:05"))
 			}
+			return nil
+		},
+	}
+	cmd.Flags().DurationVar(&pauseFor, "for", 0, "Resume on its own after this long, such as 30m or 2h")
+	return cmd
+}
 
-			// Remove PID file
-			os.Remove(pidFile)
-
-			fmt.Println("Monitoring stopped")
+func resumeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "resume",
+		Short: "Capture the screen again after wash monitor pause",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			status, err := chatmonitor.RequestResume()
+			if errors.Is(err, chatmonitor.ErrNotRunning) {
+				return fmt.Errorf("no monitor is running")
+			}
+			if err != nil {
+				return fmt.Errorf("failed to resume monitor: %w", err)
+			}
+			fmt.Printf("Monitoring %s again (pid %d)\n", strings.Join(status.Projects, ", "), status.PID)
 			return nil
 		},
 	}
+}
+
+// pauseDescription describes a paused monitor's pause, or returns "" when it is capturing
+func pauseDescription(status *chatmonitor.Status) string {
+	if status.PausedAt.IsZero() {
+		return ""
+	}
+	description := "Paused since " + status.PausedAt.Local().Format("15:04:05")
+	if !status.PausedUntil.IsZero() {
+		description += ", until " + status.PausedUntil.Local().Format("15:04:05")
+	}
+	return description
+}
 
-	return cmd
+// runningPID returns the process ID of a running monitor from its PID files, or 0
+func runningPID() int {
+	if running := chatmonitor.RunningPID(); running != 0 {
+		return running
+	}
+	running, _ := pid.NewPIDManager(pidFile).CheckRunning()
+	return running
+}
+
+// printNotesToday reports how many monitor and progress notes each project has had written today
+func printNotesToday(projects []string) error {
+	notesManager, err := notes.NewNotesManager()
+	if err != nil {
+		return fmt.Errorf("failed to create notes manager: %w", err)
+	}
+	now := time.Now()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	for _, project := range projects {
+		monitorNotes, err := notesManager.MonitorNoteTimes(project, midnight, now.Add(time.Second))
+		if err != nil {
+			return err
+		}
+		entries, err := notesManager.ListProgressIndex(project)
+		if err != nil {
+			return err
+		}
+		progressNotes := 0
+		for _, entry := range entries {
+			if !entry.Timestamp.Before(midnight) {
+				progressNotes++
+			}
+		}
+		fmt.Printf("Notes today for %s: %d monitor, %d progress\n", project, len(monitorNotes), progressNotes)
+	}
+	return nil
+}
+
+// ago formats a time of today as its clock time and how long ago it was
+func ago(t time.Time) string {
+	return fmt.Sprintf("%s (%s ago)", t.Local().Format("15:04:05"), time.Since(t).Round(time.Second))
+}
+
+// stopByPID signals a monitor that has no control socket, such as one started by an older wash
+func stopByPID() error {
+	pidBytes, err := os.ReadFile(pidFile)
+	if err != nil {
+		fmt.Println("No monitor process is running")
+		return nil
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(pidBytes)))
+	if err != nil {
+		// Clean up invalid PID file
+		os.Remove(pidFile)
+		fmt.Println("No monitor process is running")
+		return nil
+	}
+
+	// On Unix systems, FindProcess always succeeds, so we need to check if the process is actually running
+	process, err := os.FindProcess(pid)
+	if err != nil || process.Signal(syscall.Signal(0)) != nil {
+		os.Remove(pidFile)
+		fmt.Println("No monitor process is running")
+		return nil
+	}
+
+	if err := process.Signal(syscall.SIGTERM); err != nil {
+		return fmt.Errorf("failed to stop monitor: %w", err)
+	}
+
+	// Give the monitor time to write its final summary before reporting it stopped
+	deadline := time.Now().Add(stopTimeout)
+	for process.Signal(syscall.Signal(0)) == nil && time.Now().Before(deadline) {
+		time.Sleep(200 * time.Millisecond)
+	}
+	os.Remove(pidFile)
+
+	fmt.Printf("Stopped monitor process %d; it had no control socket, so its recap is in its own terminal\n", pid)
+	return nil
 }
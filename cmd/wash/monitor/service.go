@@ -0,0 +1,189 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+
+	"github.com/bkidd1/wash-cli/internal/services/monitor/chatmonitor"
+	"github.com/bkidd1/wash-cli/internal/services/service"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/spf13/cobra"
+)
+
+// serviceEnv are the variables the monitor needs from the installing session to capture the
+// screen or a terminal
+var serviceEnv = []string{"PATH", "DISPLAY", "WAYLAND_DISPLAY", "XAUTHORITY", "TMUX_TMPDIR"}
+
+func installCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "install",
+		Short: "Run the monitor as a background service that starts at login",
+		Long: `Registers the monitor as a launchd agent on macOS, or a systemd user unit on
+Linux, so it runs detached from any terminal, starts at login and restarts if
+it crashes. It monitors the current directory's project, or --project or
+--workspace, with the capture flags given here. Installing again replaces the
+service.
+
+The service reads the global config, so the API key must be set there rather
+than only in the shell. On Linux, its output is in the user journal
+(journalctl --user -u wash-monitor); run 'loginctl enable-linger' to keep it
+running after logout. On macOS, its output goes to monitor.log in the data
+directory.
+
+Use 'wash monitor status' to check on it, 'wash monitor stop' to stop it until
+the next login and 'wash monitor uninstall' to remove it.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get current directory: %w", err)
+			}
+			if projectName == "" && workspaceName == "" {
+				projectName = filepath.Base(cwd)
+			}
+			if err := validateSource(); err != nil {
+				return err
+			}
+
+			// A monitor started in a terminal would keep the service's from starting
+			definition, err := service.Path()
+			if err != nil {
+				return err
+			}
+			if status, err := chatmonitor.QueryStatus(); err == nil {
+				if _, err := os.Stat(definition); os.IsNotExist(err) {
+					return fmt.Errorf("monitor is already running for %v (pid %d). Use 'wash monitor stop' to stop it first", status.Projects, status.PID)
+				}
+			}
+
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			if cfg.Safe() {
+				return errSafeMode
+			}
+			// The service would otherwise fail on every capture, out of sight in its log
+			if err := checkRedaction(cfg); err != nil {
+				return err
+			}
+			if err := confirmCost(cfg); err != nil {
+				return err
+			}
+
+			executable, err := os.Executable()
+			if err != nil {
+				return fmt.Errorf("failed to find the wash executable: %w", err)
+			}
+			if resolved, err := filepath.EvalSymlinks(executable); err == nil {
+				executable = resolved
+			}
+			dataDir, err := config.DataDir()
+			if err != nil {
+				return err
+			}
+			logPath, err := service.LogPath()
+			if err != nil {
+				return err
+			}
+
+			s := service.Service{
+				Executable: executable,
+				Args:       monitorArgs(),
+				WorkDir:    cwd,
+				Env:        map[string]string{config.DataDirEnv: dataDir},
+				LogPath:    logPath,
+			}
+			for _, name := range serviceEnv {
+				if value := os.Getenv(name); value != "" {
+					s.Env[name] = value
+				}
+			}
+
+			path, err := service.Install(s)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Installed %s\n", path)
+			if runtime.GOOS == "linux" {
+				fmt.Println("The monitor is running; its output is in 'journalctl --user -u wash-monitor'.")
+				fmt.Println("Run 'loginctl enable-linger' to keep it running after you log out.")
+			} else {
+				fmt.Printf("The monitor is running; its output is in %s.\n", logPath)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&workspaceName, "workspace", "w", "", "Monitor every repository in a workspace")
+	cmd.Flags().StringVar(&source, "source", "screenshot", sourceUsage)
+	cmd.Flags().StringVar(&target, "target", "", targetUsage)
+	cmd.Flags().BoolVar(&keepShots, "keep-screenshots", false, "Keep analyzed screenshots as attachments on monitor notes")
+	cmd.Flags().BoolVar(&ocr, "ocr", false, ocrUsage)
+	cmd.Flags().IntVar(&display, "display", 0, displayUsage)
+	cmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Install without confirming the cost estimate")
+	return cmd
+}
+
+// monitorArgs returns the wash arguments the service runs the monitor with
+func monitorArgs() []string {
+	args := []string{"monitor", "--yes"}
+	if projectName != "" {
+		args = append(args, "--project", projectName)
+	}
+	if workspaceName != "" {
+		args = append(args, "--workspace", workspaceName)
+	}
+	if source != "screenshot" {
+		args = append(args, "--source", source)
+		if target != "" {
+			args = append(args, "--target", target)
+		}
+	}
+	if keepShots {
+		args = append(args, "--keep-screenshots")
+	}
+	if ocr {
+		args = append(args, "--ocr")
+	}
+	if display != 0 {
+		args = append(args, "--display", strconv.Itoa(display))
+	}
+	return args
+}
+
+func uninstallCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "uninstall",
+		Short: "Stop the background monitor service and remove it",
+		Long: `Stops the monitor installed with 'wash monitor install', which writes its final
+summary as on 'wash monitor stop', and removes the launchd agent or systemd
+user unit.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			definition, err := service.Path()
+			if err != nil {
+				return err
+			}
+			if _, err := os.Stat(definition); os.IsNotExist(err) {
+				fmt.Println("No monitor service is installed")
+				return nil
+			}
+
+			// Stop over the control socket first, so the session is summarized
+			if _, _, err := chatmonitor.RequestStop(stopTimeout); err != nil && !errors.Is(err, chatmonitor.ErrNotRunning) {
+				fmt.Printf("Warning: %v\n", err)
+			}
+			path, err := service.Uninstall()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Removed %s\n", path)
+			return nil
+		},
+	}
+}
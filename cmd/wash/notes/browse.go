@@ -0,0 +1,378 @@
+package notes
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bkidd1/wash-cli/internal/services/notes"
+	"github.com/bkidd1/wash-cli/internal/utils/templates"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+)
+
+var (
+	tabStyle       = lipgloss.NewStyle().Padding(0, 1)
+	activeTabStyle = tabStyle.Bold(true).Reverse(true)
+	selectedStyle  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12"))
+	dimStyle       = lipgloss.NewStyle().Faint(true)
+	paneStyle      = lipgloss.NewStyle().Border(lipgloss.NormalBorder()).BorderForeground(lipgloss.Color("8"))
+)
+
+const browseHelp = "↑/↓ move · tab kind · / filter · a archive · A show archived · d delete · pgup/pgdn scroll · q quit"
+
+func browseCommand() *cobra.Command {
+	var projectName string
+
+	cmd := &cobra.Command{
+		Use:   "browse",
+		Short: "Browse a project's notes interactively",
+		Long: `Browse a project's interactions, monitor notes, progress notes and bug reports
+in a terminal UI with a preview pane. Progress notes are previewed with the
+progress_note template, which 'wash templates init progress_note' lets you customize.
+
+Keys:
+  ↑/↓ or k/j     Move through the list
+  tab/shift+tab  Switch between note kinds
+  /              Filter by title; enter keeps the filter, esc clears it
+  a              Archive the selected note, or restore it when showing archived notes
+  A              Toggle between live and archived notes
+  d              Move the selected note to the trash (asks for confirmation)
+  pgup/pgdn      Scroll the preview
+  q              Quit
+
+Archived notes are moved into an archive directory beside the live ones, so
+they no longer feed analysis context but are still kept, encrypted and pruned.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if projectName == "" {
+				cwd, err := os.Getwd()
+				if err != nil {
+					return fmt.Errorf("failed to get current directory: %w", err)
+				}
+				projectName = filepath.Base(cwd)
+			}
+
+			notesManager, err := notes.NewNotesManager()
+			if err != nil {
+				return fmt.Errorf("failed to create notes manager: %w", err)
+			}
+
+			model := newBrowseModel(notesManager, projectName)
+			if _, err := tea.NewProgram(model, tea.WithAltScreen()).Run(); err != nil {
+				return fmt.Errorf("failed to run browser: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&projectName, "project", "p", "", "Project name (defaults to current directory name)")
+
+	return cmd
+}
+
+// browseModel is the bubbletea model behind wash notes browse
+type browseModel struct {
+	notesManager *notes.NotesManager
+	project      string
+
+	kind     int
+	archived bool
+	entries  []notes.Entry
+	visible  []notes.Entry
+	cursor   int
+	offset   int
+
+	filter        textinput.Model
+	filtering     bool
+	confirmDelete bool
+	status        string
+
+	preview viewport.Model
+	width   int
+	height  int
+}
+
+func newBrowseModel(notesManager *notes.NotesManager, project string) *browseModel {
+	filter := textinput.New()
+	filter.Prompt = "/"
+	filter.Placeholder = "filter"
+
+	m := &browseModel{
+		notesManager: notesManager,
+		project:      project,
+		filter:       filter,
+		preview:      viewport.New(0, 0),
+	}
+	m.reload()
+	return m
+}
+
+func (m *browseModel) Init() tea.Cmd {
+	return nil
+}
+
+// reload lists the current kind's entries again and reapplies the filter
+func (m *browseModel) reload() {
+	entries, err := m.notesManager.ListEntries(m.project, notes.BrowseKinds[m.kind], m.archived)
+	if err != nil {
+		m.status = err.Error()
+	}
+	m.entries = entries
+	m.applyFilter()
+}
+
+// applyFilter narrows the list to entries whose title contains the filter text
+func (m *browseModel) applyFilter() {
+	query := strings.ToLower(strings.TrimSpace(m.filter.Value()))
+	m.visible = m.visible[:0]
+	for _, entry := range m.entries {
+		if query == "" || strings.Contains(strings.ToLower(entry.Title), query) {
+			m.visible = append(m.visible, entry)
+		}
+	}
+	if m.cursor >= len(m.visible) {
+		m.cursor = len(m.visible) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+	m.loadPreview()
+}
+
+// loadPreview shows the selected entry in the preview pane
+func (m *browseModel) loadPreview() {
+	if len(m.visible) == 0 {
+		m.preview.SetContent(dimStyle.Render("No notes"))
+		return
+	}
+	content, err := previewEntry(m.notesManager, m.visible[m.cursor])
+	if err != nil {
+		content = err.Error()
+	}
+	m.preview.SetContent(lipgloss.NewStyle().Width(m.preview.Width).Render(content))
+	m.preview.GotoTop()
+}
+
+// previewEntry renders progress notes with the progress_note template, which users can customize
+// with wash templates, and shows other entries as stored
+func previewEntry(nm *notes.NotesManager, entry notes.Entry) (string, error) {
+	if entry.Kind != notes.KindProgress {
+		return nm.Preview(entry)
+	}
+	note, err := nm.ProgressNote(entry)
+	if err != nil {
+		return "", err
+	}
+	return templates.Render(templates.ProgressNote, note)
+}
+
+// listHeight is the number of list rows that fit between the header and footer
+func (m *browseModel) listHeight() int {
+	return max(m.height-6, 1)
+}
+
+func (m *browseModel) move(delta int) {
+	if len(m.visible) == 0 {
+		return
+	}
+	m.cursor = min(max(m.cursor+delta, 0), len(m.visible)-1)
+	m.loadPreview()
+}
+
+func (m *browseModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.preview.Width = max(m.width-m.width*2/5-4, 10)
+		m.preview.Height = m.listHeight()
+		m.loadPreview()
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.filtering {
+			return m.updateFilter(msg)
+		}
+		if m.confirmDelete {
+			m.confirmDelete = false
+			if msg.String() == "y" || msg.String() == "Y" {
+				m.deleteSelected()
+			} else {
+				m.status = "Delete cancelled"
+			}
+			return m, nil
+		}
+
+		m.status = ""
+		switch msg.String() {
+		case "q", "ctrl+c":
+			return m, tea.Quit
+		case "up", "k":
+			m.move(-1)
+		case "down", "j":
+			m.move(1)
+		case "home", "g":
+			m.move(-len(m.visible))
+		case "end", "G":
+			m.move(len(m.visible))
+		case "tab":
+			m.kind = (m.kind + 1) % len(notes.BrowseKinds)
+			m.cursor = 0
+			m.reload()
+		case "shift+tab":
+			m.kind = (m.kind + len(notes.BrowseKinds) - 1) % len(notes.BrowseKinds)
+			m.cursor = 0
+			m.reload()
+		case "A":
+			m.archived = !m.archived
+			m.cursor = 0
+			m.reload()
+		case "/":
+			m.filtering = true
+			return m, m.filter.Focus()
+		case "esc":
+			m.filter.SetValue("")
+			m.applyFilter()
+		case "a":
+			m.archiveSelected()
+		case "d":
+			if len(m.visible) > 0 {
+				m.confirmDelete = true
+			}
+		case "pgdown", "ctrl+d":
+			m.preview.HalfPageDown()
+		case "pgup", "ctrl+u":
+			m.preview.HalfPageUp()
+		}
+		return m, nil
+	}
+	return m, nil
+}
+
+// updateFilter sends keys to the filter input while it has focus
+func (m *browseModel) updateFilter(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		m.filtering = false
+		m.filter.Blur()
+		return m, nil
+	case "esc":
+		m.filtering = false
+		m.filter.Blur()
+		m.filter.SetValue("")
+		m.applyFilter()
+		return m, nil
+	case "ctrl+c":
+		return m, tea.Quit
+	}
+
+	var cmd tea.Cmd
+	m.filter, cmd = m.filter.Update(msg)
+	m.cursor = 0
+	m.applyFilter()
+	return m, cmd
+}
+
+func (m *browseModel) archiveSelected() {
+	if len(m.visible) == 0 {
+		return
+	}
+	entry := m.visible[m.cursor]
+	if err := m.notesManager.ArchiveEntry(entry); err != nil {
+		m.status = err.Error()
+		return
+	}
+	if entry.Archived {
+		m.status = "Restored " + filepath.Base(entry.Path)
+	} else {
+		m.status = "Archived " + filepath.Base(entry.Path)
+	}
+	m.reload()
+}
+
+func (m *browseModel) deleteSelected() {
+	entry := m.visible[m.cursor]
+	trashed, err := m.notesManager.DeleteEntry(entry)
+	if err != nil {
+		m.status = err.Error()
+		return
+	}
+	m.status = fmt.Sprintf("Moved %s to the trash (wash notes restore %s)", filepath.Base(entry.Path), trashed.ID)
+	m.reload()
+}
+
+func (m *browseModel) View() string {
+	if m.width == 0 {
+		return ""
+	}
+
+	var tabs []string
+	for i, kind := range notes.BrowseKinds {
+		label := string(kind)
+		if i == m.kind {
+			tabs = append(tabs, activeTabStyle.Render(label))
+		} else {
+			tabs = append(tabs, tabStyle.Render(label))
+		}
+	}
+	header := m.project + "  " + strings.Join(tabs, " ")
+	if m.archived {
+		header += dimStyle.Render("  (archived)")
+	}
+
+	listWidth := m.width * 2 / 5
+	height := m.listHeight()
+
+	// Keep the cursor on screen
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+height {
+		m.offset = m.cursor - height + 1
+	}
+
+	var rows []string
+	for i := m.offset; i < len(m.visible) && i < m.offset+height; i++ {
+		entry := m.visible[i]
+		row := truncate(entry.Timestamp.Local().Format("2006-01-02 15:04")+"  "+entry.Title, listWidth-2)
+		if i == m.cursor {
+			row = selectedStyle.Render("> " + row)
+		} else {
+			row = "  " + row
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		rows = append(rows, dimStyle.Render("  No notes"))
+	}
+
+	list := paneStyle.Width(listWidth).Height(height).Render(strings.Join(rows, "\n"))
+	preview := paneStyle.Width(m.preview.Width).Height(height).Render(m.preview.View())
+	body := lipgloss.JoinHorizontal(lipgloss.Top, list, preview)
+
+	footer := dimStyle.Render(browseHelp)
+	switch {
+	case m.filtering:
+		footer = m.filter.View()
+	case m.confirmDelete:
+		footer = fmt.Sprintf("Move %s to the trash? [y/N]", filepath.Base(m.visible[m.cursor].Path))
+	case m.status != "":
+		footer = m.status
+	case m.filter.Value() != "":
+		footer = fmt.Sprintf("Filter: %s (%d of %d) · esc clears", m.filter.Value(), len(m.visible), len(m.entries))
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, header, body, footer)
+}
+
+// truncate shortens s to width runes, marking the cut with an ellipsis
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if width <= 1 || len(runes) <= width {
+		return s
+	}
+	return string(runes[:width-1]) + "…"
+}
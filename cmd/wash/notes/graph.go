@@ -0,0 +1,96 @@
+package notes
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bkidd1/wash-cli/internal/services/notes"
+	"github.com/spf13/cobra"
+)
+
+func graphCommand() *cobra.Command {
+	var projectName, format, outPath string
+
+	cmd := &cobra.Command{
+		Use:   "graph",
+		Short: "Export a project's knowledge graph",
+		Long: `Export the entities in a project's notes and how they connect, for viewing in
+a graph tool:
+
+  Project   the project itself
+  File      source files changed by progress notes or mentioned in notes and bugs
+  Bug       bug reports saved by 'wash bug'
+  Decision  progress notes, with the files they added, modified or deleted
+  Person    authors of remember notes about the project
+  Error     error messages seen in notes and bug reports, grouped when they
+            differ only in numbers
+
+--format graphml writes one GraphML file, which Gephi, yEd and Cytoscape open.
+--format neo4j writes nodes.csv and relationships.csv to a directory for
+'neo4j-admin database import full --nodes=nodes.csv --relationships=relationships.csv'.
+
+Examples:
+  wash notes graph
+  wash notes graph --project my-app --out my-app.graphml
+  wash notes graph --format neo4j --out my-app-graph`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if projectName == "" {
+				cwd, err := os.Getwd()
+				if err != nil {
+					return fmt.Errorf("failed to get current directory: %w", err)
+				}
+				projectName = filepath.Base(cwd)
+			}
+
+			notesManager, err := notes.NewNotesManager()
+			if err != nil {
+				return fmt.Errorf("failed to create notes manager: %w", err)
+			}
+			graph, err := notesManager.BuildGraph(projectName)
+			if err != nil {
+				return fmt.Errorf("failed to build graph: %w", err)
+			}
+
+			switch format {
+			case "graphml":
+				if outPath == "" {
+					outPath = projectName + ".graphml"
+				}
+				out, err := os.Create(outPath)
+				if err != nil {
+					return fmt.Errorf("failed to create %s: %w", outPath, err)
+				}
+				err = graph.WriteGraphML(out)
+				if closeErr := out.Close(); err == nil {
+					err = closeErr
+				}
+				if err != nil {
+					return fmt.Errorf("failed to write graph: %w", err)
+				}
+			case "neo4j":
+				if outPath == "" {
+					outPath = projectName + "-graph"
+				}
+				if err := graph.WriteNeo4jCSV(outPath); err != nil {
+					return fmt.Errorf("failed to write graph: %w", err)
+				}
+			default:
+				return fmt.Errorf("invalid format %q (use graphml or neo4j)", format)
+			}
+
+			if len(graph.Nodes) <= 1 {
+				fmt.Printf("Warning: no notes found for project %s\n", projectName)
+			}
+			fmt.Printf("Exported %d nodes and %d relationships for project %s to %s\n", len(graph.Nodes), len(graph.Edges), projectName, outPath)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&projectName, "project", "p", "", "Project name (defaults to current directory name)")
+	cmd.Flags().StringVar(&format, "format", "graphml", "Output format: graphml or neo4j")
+	cmd.Flags().StringVarP(&outPath, "out", "o", "", "Output file for graphml, or directory for neo4j (defaults to <project>.graphml or <project>-graph)")
+
+	return cmd
+}
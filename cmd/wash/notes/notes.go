@@ -0,0 +1,382 @@
+package notes
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bkidd1/wash-cli/internal/services/archive"
+	"github.com/bkidd1/wash-cli/internal/services/notes"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/crypt"
+	"github.com/spf13/cobra"
+)
+
+// Command creates the notes command
+func Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "notes",
+		Aliases: []string{"note"},
+		Short:   "Manage stored notes",
+		Long:    "Browse, search, pin, export, import, compact, prune, encrypt and otherwise manage the notes wash keeps in ~/.wash.",
+	}
+
+	cmd.AddCommand(exportCommand())
+	cmd.AddCommand(importCommand())
+	cmd.AddCommand(pruneCommand())
+	cmd.AddCommand(compactCommand())
+	cmd.AddCommand(encryptCommand())
+	cmd.AddCommand(browseCommand())
+	cmd.AddCommand(searchCommand())
+	cmd.AddCommand(statsCommand())
+	cmd.AddCommand(trashCommand())
+	cmd.AddCommand(restoreCommand())
+	cmd.AddCommand(graphCommand())
+	cmd.AddCommand(pinCommand())
+	cmd.AddCommand(unpinCommand())
+	cmd.AddCommand(pinnedCommand())
+
+	return cmd
+}
+
+func exportCommand() *cobra.Command {
+	var projectName, outPath string
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export a project's notes to an archive",
+		Long: `Export a project's interactions, bug reports, plans, estimates, progress notes,
+monitor notes and remember notes to a gzipped tar archive.
+
+The archive includes a manifest with a SHA-256 checksum for every file, which
+'wash notes import' verifies before writing anything.
+
+Examples:
+  wash notes export
+  wash notes export --project my-app --out my-app-notes.tar.gz`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if projectName == "" {
+				cwd, err := os.Getwd()
+				if err != nil {
+					return fmt.Errorf("failed to get current directory: %w", err)
+				}
+				projectName = filepath.Base(cwd)
+			}
+			if outPath == "" {
+				outPath = projectName + "-notes.tar.gz"
+			}
+
+			notesManager, err := notes.NewNotesManager()
+			if err != nil {
+				return fmt.Errorf("failed to create notes manager: %w", err)
+			}
+
+			out, err := os.Create(outPath)
+			if err != nil {
+				return fmt.Errorf("failed to create archive: %w", err)
+			}
+
+			manifest, err := archive.Export(notesManager.BaseDir(), projectName, notes.CurrentUser(), notesManager.Cipher(), out)
+			if closeErr := out.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(outPath)
+				return fmt.Errorf("failed to export notes: %w", err)
+			}
+
+			if len(manifest.Files) == 0 {
+				fmt.Printf("Warning: no notes found for project %s\n", projectName)
+			}
+			fmt.Printf("Exported %d files for project %s to %s\n", len(manifest.Files), projectName, outPath)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&projectName, "project", "p", "", "Project name (defaults to current directory name)")
+	cmd.Flags().StringVarP(&outPath, "out", "o", "", "Archive path (defaults to <project>-notes.tar.gz)")
+
+	return cmd
+}
+
+func importCommand() *cobra.Command {
+	var overwrite bool
+
+	cmd := &cobra.Command{
+		Use:   "import [archive]",
+		Short: "Import notes from an archive",
+		Long: `Import notes from an archive created by 'wash notes export'.
+
+Every file is checked against the archive manifest before anything is written.
+Files that already exist with different content are left alone unless
+--overwrite is set.
+
+Examples:
+  wash notes import my-app-notes.tar.gz
+  wash notes import my-app-notes.tar.gz --overwrite`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			notesManager, err := notes.NewNotesManager()
+			if err != nil {
+				return fmt.Errorf("failed to create notes manager: %w", err)
+			}
+
+			in, err := os.Open(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to open archive: %w", err)
+			}
+			defer in.Close()
+
+			result, err := archive.Import(notesManager.BaseDir(), in, notes.CurrentUser(), overwrite)
+			if err != nil {
+				return fmt.Errorf("failed to import notes: %w", err)
+			}
+
+			// Imported progress notes are not in the index yet
+			if err := notesManager.RebuildProgressIndex(result.Manifest.Project); err != nil {
+				fmt.Printf("Warning: failed to rebuild progress index: %v\n", err)
+			}
+
+			fmt.Printf("Imported project %s (exported %s): %d written, %d unchanged\n",
+				result.Manifest.Project, result.Manifest.CreatedAt.Format("2006-01-02 15:04"),
+				len(result.Written), len(result.Unchanged))
+
+			if len(result.Conflicts) > 0 {
+				fmt.Printf("\nSkipped %d files that differ from existing notes (use --overwrite to replace them):\n", len(result.Conflicts))
+				for _, path := range result.Conflicts {
+					fmt.Printf("  %s\n", path)
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&overwrite, "overwrite", false, "Replace existing files that differ from the archive")
+
+	return cmd
+}
+
+func pruneCommand() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete notes older than the retention policy",
+		Long: `Delete monitor notes, progress notes and interactions older than the retention
+policy in ~/.wash/wash.yaml, empty the trash of notes deleted long enough ago and
+delete old context logs, then remove attachments no remaining note references.
+
+  retention:
+    monitor_notes: 30d
+    progress_notes: 52w
+    interactions: 90d
+    trash: 30d
+    context_logs: 90d
+
+Ages accept d (days), w (weeks) and Go durations such as 12h. Kinds without a
+setting are kept forever. Deleted notes are emptied from the trash after 30
+days, and context logs deleted after 90, unless retention.trash and
+retention.context_logs say otherwise ("forever" keeps them).
+'wash monitor' also prunes once a day while running.
+
+Examples:
+  wash notes prune --dry-run
+  wash notes prune`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
+			policy, err := notes.ParseRetentionPolicy(cfg.Retention)
+			if err != nil {
+				return err
+			}
+			if policy.IsZero() {
+				fmt.Println("No retention policy configured; nothing to prune. Set retention.monitor_notes (e.g. 30d) in ~/.wash/wash.yaml.")
+				return nil
+			}
+
+			notesManager, err := notes.NewNotesManager()
+			if err != nil {
+				return fmt.Errorf("failed to create notes manager: %w", err)
+			}
+
+			report, err := notesManager.Prune(policy, dryRun)
+			if err != nil {
+				return fmt.Errorf("failed to prune notes: %w", err)
+			}
+
+			verb := "Deleted"
+			if dryRun {
+				verb = "Would delete"
+			}
+			fmt.Printf("%s %d monitor notes, %d progress notes and %d interactions.\n", verb,
+				report.Deleted[notes.KindMonitor], report.Deleted[notes.KindProgress], report.Deleted[notes.KindInteraction])
+			if report.TrashEmptied > 0 {
+				fmt.Printf("%s %d notes from the trash.\n", verb, report.TrashEmptied)
+			}
+			if report.ContextLogs > 0 {
+				fmt.Printf("%s %d monthly context logs.\n", verb, report.ContextLogs)
+			}
+			if report.AttachmentsFreed > 0 {
+				fmt.Printf("Freed %.1f MB of unreferenced attachments.\n", float64(report.AttachmentsFreed)/(1<<20))
+			}
+
+			if len(report.Failed) > 0 {
+				paths := make([]string, 0, len(report.Failed))
+				for path := range report.Failed {
+					paths = append(paths, path)
+				}
+				sort.Strings(paths)
+
+				fmt.Printf("\nFailed to prune %d notes:\n", len(report.Failed))
+				for _, path := range paths {
+					fmt.Printf("  %s: %v\n", path, report.Failed[path])
+				}
+				return fmt.Errorf("%d notes could not be pruned", len(report.Failed))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Report what would be deleted without deleting")
+
+	return cmd
+}
+
+func encryptCommand() *cobra.Command {
+	var decrypt bool
+
+	cmd := &cobra.Command{
+		Use:   "encrypt",
+		Short: "Encrypt existing notes and attachments at rest",
+		Long: `Encrypt notes and attachments written before encryption was enabled.
+
+Enable encryption in ~/.wash/wash.yaml with a key source first:
+
+  encryption: passphrase   # key derived from $` + crypt.PassphraseEnv + `
+  encryption: keychain     # random key kept in the macOS keychain or Linux Secret Service
+
+New notes, bug reports and lessons, goals, plans, estimates, findings,
+snapshots, handoffs, manifests, attachments, kept screenshots, cached analyses
+and embeddings are then encrypted with AES-256-GCM as they are written, and
+every command reads both encrypted and plaintext files.
+
+To turn encryption off, run 'wash notes encrypt --decrypt' while the key is
+still available, then remove the setting.
+
+Examples:
+  wash notes encrypt
+  wash notes encrypt --decrypt`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			notesManager, err := notes.NewNotesManager()
+			if err != nil {
+				return fmt.Errorf("failed to create notes manager: %w", err)
+			}
+
+			converted, err := notesManager.ConvertEncryption(!decrypt)
+			if err != nil {
+				return fmt.Errorf("failed to convert notes: %w", err)
+			}
+
+			verb := "Encrypted"
+			if decrypt {
+				verb = "Decrypted"
+			}
+			fmt.Printf("%s %d files.\n", verb, converted)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&decrypt, "decrypt", false, "Decrypt every note back to plaintext instead")
+
+	return cmd
+}
+
+func compactCommand() *cobra.Command {
+	var projectName, olderThan string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "compact",
+		Short: "Roll old monitor notes into daily digests",
+		Long: `Roll each full day of monitor notes older than the given age into a single
+AI-generated digest progress note, then delete the raw notes.
+
+Digests keep the day's progress, decisions and open issues, so summaries and
+estimates still see the history while ~/.wash stays small. Set
+compact_monitor_notes_after (e.g. 7d) in ~/.wash/wash.yaml to have
+'wash monitor' compact once a day.
+
+Examples:
+  wash notes compact --dry-run
+  wash notes compact --older-than 14d --project my-app`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if projectName == "" {
+				cwd, err := os.Getwd()
+				if err != nil {
+					return fmt.Errorf("failed to get current directory: %w", err)
+				}
+				projectName = filepath.Base(cwd)
+			}
+
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
+			setting := olderThan
+			if setting == "" {
+				setting = cfg.CompactAfter
+			}
+			if setting == "" {
+				setting = notes.DefaultCompactAfter
+			}
+			age, err := notes.ParseRetention(setting)
+			if err != nil {
+				return err
+			}
+			if age == 0 {
+				return fmt.Errorf("compaction age must be greater than zero")
+			}
+
+			notesManager, err := notes.NewNotesManager()
+			if err != nil {
+				return fmt.Errorf("failed to create notes manager: %w", err)
+			}
+
+			report, err := notesManager.CompactMonitorNotes(projectName, age, dryRun)
+			if err != nil {
+				if report != nil && len(report.Days) > 0 {
+					fmt.Printf("Compacted %d days before the error.\n", len(report.Days))
+				}
+				return fmt.Errorf("failed to compact notes: %w", err)
+			}
+
+			if len(report.Days) == 0 {
+				fmt.Printf("No full days of monitor notes older than %s for %s.\n", setting, projectName)
+				return nil
+			}
+
+			if dryRun {
+				fmt.Printf("Would compact %d monitor notes from %d days: %s\n", report.Removed, len(report.Days), strings.Join(report.Days, ", "))
+				return nil
+			}
+
+			fmt.Printf("Compacted %d monitor notes into %d digests:\n", report.Removed, len(report.Digests))
+			for _, digest := range report.Digests {
+				fmt.Printf("  %s\n", digest.Title)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&projectName, "project", "p", "", "Project name (defaults to current directory name)")
+	cmd.Flags().StringVar(&olderThan, "older-than", "", "Compact days older than this age, e.g. 7d (defaults to compact_monitor_notes_after, or 7d)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Report which days would be compacted without calling the model")
+
+	return cmd
+}
@@ -0,0 +1,90 @@
+package notes
+
+import (
+	"fmt"
+
+	"github.com/bkidd1/wash-cli/internal/services/notes"
+	"github.com/spf13/cobra"
+)
+
+func pinCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "pin <id>",
+		Short: "Include a note in every analysis of its project",
+		Long: `Pin a note so its gist is added to the system prompt of every later
+'wash file' and 'wash bug' run for its project. Pinned global remember notes
+apply to every project.
+
+Remember and progress notes are identified by their ID, or any unique prefix
+of it; monitor notes and interactions by <project>/<file name>, as listed by
+'wash tags list <tag>'; bug reports by <project>/bug_<time>.
+
+Examples:
+  wash notes pin 3f9c2a1b
+  wash notes pin my-app/bug_2024-05-01-10-30-00
+  wash notes pinned
+  wash notes unpin 3f9c2a1b`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			notesManager, err := notes.NewNotesManager()
+			if err != nil {
+				return fmt.Errorf("failed to create notes manager: %w", err)
+			}
+
+			pin, err := notesManager.Pin(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Pinned %s note %s for project %s\n", pin.Kind, pin.ID, pin.Project)
+			return nil
+		},
+	}
+}
+
+func unpinCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "unpin <id>",
+		Short: "Stop including a pinned note in analyses",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			notesManager, err := notes.NewNotesManager()
+			if err != nil {
+				return fmt.Errorf("failed to create notes manager: %w", err)
+			}
+
+			pin, err := notesManager.Unpin(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Unpinned %s note %s\n", pin.Kind, pin.ID)
+			return nil
+		},
+	}
+}
+
+func pinnedCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "pinned",
+		Short: "List pinned notes",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			notesManager, err := notes.NewNotesManager()
+			if err != nil {
+				return fmt.Errorf("failed to create notes manager: %w", err)
+			}
+
+			pins, err := notesManager.ListPins()
+			if err != nil {
+				return fmt.Errorf("failed to list pins: %w", err)
+			}
+			if len(pins) == 0 {
+				fmt.Println("No notes are pinned")
+				return nil
+			}
+			for _, pin := range pins {
+				fmt.Printf("%-10s %-40s %-20s pinned %s\n", pin.Kind, pin.ID, pin.Project, pin.PinnedAt.Local().Format("2006-01-02"))
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,217 @@
+package notes
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/notes"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/spf13/cobra"
+)
+
+// searchResult is one note matched by wash notes search
+type searchResult struct {
+	Kind      notes.NoteKind `json:"kind"`
+	Timestamp time.Time      `json:"timestamp"`
+	Type      string         `json:"type,omitempty"`
+	Status    notes.Status   `json:"status,omitempty"`
+	Priority  notes.Priority `json:"priority,omitempty"`
+	Tags      []string       `json:"tags,omitempty"`
+	Title     string         `json:"title"`
+	Note      interface{}    `json:"note"`
+}
+
+func searchCommand() *cobra.Command {
+	var projectName, kind, noteType, status, priority, tag, since, until string
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "search",
+		Short: "Search interactions and progress notes with filters",
+		Long: `Search a project's interactions and progress notes by type, status, priority,
+tag and time, newest first.
+
+--type matches progress note types (milestone, feature, refactor, summary, digest...),
+so it limits the search to progress notes. --since and --until take a date, an
+RFC 3339 time or an age such as 7d.
+
+Examples:
+  wash notes search --type milestone --status open --tag refactor --since 2024-01-01
+  wash notes search --kind interaction --priority high --since 7d
+  wash notes search --tag auth --json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if projectName == "" {
+				cwd, err := os.Getwd()
+				if err != nil {
+					return fmt.Errorf("failed to get current directory: %w", err)
+				}
+				projectName = filepath.Base(cwd)
+			}
+
+			criteria := make(map[string]interface{})
+			if status != "" {
+				if !isOneOf(status, notes.StatusOpen, notes.StatusResolved, notes.StatusArchived) {
+					return fmt.Errorf("invalid status %q (use open, resolved or archived)", status)
+				}
+				criteria["status"] = notes.Status(status)
+			}
+			if priority != "" {
+				if !isOneOf(priority, notes.PriorityLow, notes.PriorityMedium, notes.PriorityHigh) {
+					return fmt.Errorf("invalid priority %q (use low, medium or high)", priority)
+				}
+				criteria["priority"] = notes.Priority(priority)
+			}
+			if tag != "" {
+				criteria["tag"] = tag
+			}
+			if since != "" {
+				t, err := config.ParseTime(since)
+				if err != nil {
+					return err
+				}
+				criteria["since"] = t
+			}
+			if until != "" {
+				t, err := config.ParseTime(until)
+				if err != nil {
+					return err
+				}
+				criteria["until"] = t
+			}
+
+			searchInteractions, searchProgress := true, true
+			switch kind {
+			case "", "all":
+			case string(notes.KindInteraction):
+				searchProgress = false
+			case string(notes.KindProgress):
+				searchInteractions = false
+			default:
+				return fmt.Errorf("invalid kind %q (use interaction, progress or all)", kind)
+			}
+			if noteType != "" {
+				if !searchProgress {
+					return fmt.Errorf("--type only applies to progress notes")
+				}
+				searchInteractions = false
+			}
+
+			notesManager, err := notes.NewNotesManager()
+			if err != nil {
+				return fmt.Errorf("failed to create notes manager: %w", err)
+			}
+
+			var results []searchResult
+			if searchInteractions {
+				interactions, err := notesManager.QueryInteractions(projectName, criteria)
+				if err != nil {
+					return fmt.Errorf("failed to search interactions: %w", err)
+				}
+				for _, interaction := range interactions {
+					results = append(results, searchResult{
+						Kind:      notes.KindInteraction,
+						Timestamp: interaction.Timestamp,
+						Status:    interaction.Metadata.Status,
+						Priority:  interaction.Metadata.Priority,
+						Tags:      interaction.Metadata.Tags,
+						Title:     interaction.Context.CurrentState,
+						Note:      interaction,
+					})
+				}
+			}
+			if searchProgress {
+				if noteType != "" {
+					criteria["type"] = noteType
+				}
+				progress, err := notesManager.QueryProjectProgress(projectName, criteria)
+				if err != nil {
+					return fmt.Errorf("failed to search progress notes: %w", err)
+				}
+				for _, note := range progress {
+					results = append(results, searchResult{
+						Kind:      notes.KindProgress,
+						Timestamp: note.Timestamp,
+						Type:      note.Type,
+						Status:    note.Metadata.Status,
+						Priority:  note.Metadata.Priority,
+						Tags:      note.Metadata.Tags,
+						Title:     note.Title,
+						Note:      note,
+					})
+				}
+			}
+
+			sort.SliceStable(results, func(i, j int) bool { return results[i].Timestamp.After(results[j].Timestamp) })
+
+			if asJSON {
+				if results == nil {
+					results = []searchResult{}
+				}
+				data, err := json.MarshalIndent(results, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to encode results: %w", err)
+				}
+				fmt.Println(string(data))
+				return nil
+			}
+
+			if len(results) == 0 {
+				fmt.Printf("No notes found for project %s\n", projectName)
+				return nil
+			}
+
+			fmt.Printf("%-16s  %-11s  %-12s  %-8s  %-6s  %s\n", "TIME", "KIND", "TYPE", "STATUS", "PRIO", "TITLE")
+			for _, result := range results {
+				title := strings.SplitN(strings.TrimSpace(result.Title), "\n", 2)[0]
+				if len(result.Tags) > 0 {
+					title += " [" + strings.Join(result.Tags, ", ") + "]"
+				}
+				fmt.Printf("%-16s  %-11s  %-12s  %-8s  %-6s  %s\n",
+					result.Timestamp.Local().Format("2006-01-02 15:04"),
+					result.Kind,
+					valueOr(result.Type, "-"),
+					valueOr(string(result.Status), "-"),
+					valueOr(string(result.Priority), "-"),
+					title)
+			}
+			fmt.Printf("\n%d notes\n", len(results))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&projectName, "project", "p", "", "Project name (defaults to current directory name)")
+	cmd.Flags().StringVar(&kind, "kind", "all", "Notes to search: interaction, progress or all")
+	cmd.Flags().StringVar(&noteType, "type", "", "Progress note type, such as milestone or refactor")
+	cmd.Flags().StringVar(&status, "status", "", "Status: open, resolved or archived")
+	cmd.Flags().StringVar(&priority, "priority", "", "Priority: low, medium or high")
+	cmd.Flags().StringVar(&tag, "tag", "", "Only notes with this tag")
+	cmd.Flags().StringVar(&since, "since", "", "Only notes from this time on (date, RFC 3339 or age such as 7d)")
+	cmd.Flags().StringVar(&until, "until", "", "Only notes up to this time (date, RFC 3339 or age such as 7d)")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print results as JSON")
+
+	return cmd
+}
+
+// isOneOf reports whether value is one of the allowed string constants
+func isOneOf[T ~string](value string, allowed ...T) bool {
+	for _, a := range allowed {
+		if value == string(a) {
+			return true
+		}
+	}
+	return false
+}
+
+// valueOr returns value, or fallback when it is empty
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,173 @@
+package notes
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/notes"
+	"github.com/bkidd1/wash-cli/internal/services/usage"
+	"github.com/spf13/cobra"
+)
+
+// statsKinds is the order note kinds are listed in
+var statsKinds = []notes.NoteKind{notes.KindInteraction, notes.KindMonitor, notes.KindProgress, notes.KindRemember, notes.KindBug}
+
+// commandUsage totals the API calls made by one command
+type commandUsage struct {
+	Calls  int `json:"calls"`
+	Tokens int `json:"tokens"`
+}
+
+func statsCommand() *cobra.Command {
+	var projectName string
+	var days int
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show what wash has recorded",
+		Long: `Show how many notes wash has stored per kind, per project and per day, how
+much disk space its data directory uses, and how many API analyses it has run.
+
+API calls are counted from the usage ledger in ~/.wash/usage, which records
+every OpenAI request with the command that made it and the tokens it used.
+
+Examples:
+  wash notes stats
+  wash notes stats --project my-app --days 30
+  wash notes stats --json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			notesManager, err := notes.NewNotesManager()
+			if err != nil {
+				return fmt.Errorf("failed to create notes manager: %w", err)
+			}
+
+			stats, err := notesManager.Stats(projectName)
+			if err != nil {
+				return fmt.Errorf("failed to count notes: %w", err)
+			}
+
+			records, err := usage.Load(time.Time{})
+			if err != nil {
+				return fmt.Errorf("failed to read usage ledger: %w", err)
+			}
+			byCommand := make(map[string]commandUsage)
+			for _, record := range records {
+				name := record.Command
+				if name == "" {
+					name = "(unknown)"
+				}
+				u := byCommand[name]
+				u.Calls++
+				u.Tokens += record.TotalTokens
+				byCommand[name] = u
+			}
+
+			if asJSON {
+				data, err := json.MarshalIndent(map[string]interface{}{
+					"notes":      stats.ByKind,
+					"projects":   stats.ByProject,
+					"days":       stats.ByDay,
+					"storage":    stats.Storage,
+					"unreadable": stats.Unreadable,
+					"api_calls":  byCommand,
+				}, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to encode stats: %w", err)
+				}
+				fmt.Println(string(data))
+				return nil
+			}
+
+			fmt.Printf("Notes: %d", stats.Total())
+			if stats.Unreadable > 0 {
+				fmt.Printf(" (%d unreadable)", stats.Unreadable)
+			}
+			fmt.Println()
+			for _, kind := range statsKinds {
+				fmt.Printf("  %-12s %6d\n", kind, stats.ByKind[kind])
+			}
+
+			if len(stats.ByProject) > 0 {
+				fmt.Println("\nBy project:")
+				for _, project := range sortedKeys(stats.ByProject) {
+					var parts []string
+					total := 0
+					for _, kind := range statsKinds {
+						if count := stats.ByProject[project][kind]; count > 0 {
+							parts = append(parts, fmt.Sprintf("%d %s", count, kind))
+							total += count
+						}
+					}
+					fmt.Printf("  %-20s %6d  (%s)\n", project, total, strings.Join(parts, ", "))
+				}
+			}
+
+			if days > 0 {
+				fmt.Printf("\nNotes per day (last %d days):\n", days)
+				peak := 0
+				for _, count := range stats.ByDay {
+					peak = max(peak, count)
+				}
+				today := time.Now()
+				for i := days - 1; i >= 0; i-- {
+					day := today.AddDate(0, 0, -i).Format("2006-01-02")
+					count := stats.ByDay[day]
+					bar := ""
+					if peak > 0 {
+						bar = strings.Repeat("█", (count*40+peak-1)/peak)
+					}
+					fmt.Printf("  %s %6d  %s\n", day, count, bar)
+				}
+			}
+
+			var totalBytes int64
+			for _, size := range stats.Storage {
+				totalBytes += size
+			}
+			fmt.Printf("\nStorage: %.1f MB\n", float64(totalBytes)/(1<<20))
+			dirs := sortedKeys(stats.Storage)
+			sort.SliceStable(dirs, func(i, j int) bool { return stats.Storage[dirs[i]] > stats.Storage[dirs[j]] })
+			for _, dir := range dirs {
+				if stats.Storage[dir] == 0 {
+					continue
+				}
+				fmt.Printf("  %-20s %8.1f KB\n", dir, float64(stats.Storage[dir])/(1<<10))
+			}
+
+			if len(records) == 0 {
+				fmt.Println("\nAPI analyses: none recorded yet")
+				return nil
+			}
+			totalTokens := 0
+			for _, u := range byCommand {
+				totalTokens += u.Tokens
+			}
+			fmt.Printf("\nAPI analyses: %d calls, %d tokens since %s\n", len(records), totalTokens, records[0].Timestamp.Local().Format("2006-01-02"))
+			for _, name := range sortedKeys(byCommand) {
+				fmt.Printf("  %-20s %6d calls  %10d tokens\n", name, byCommand[name].Calls, byCommand[name].Tokens)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&projectName, "project", "p", "", "Only count this project's notes")
+	cmd.Flags().IntVar(&days, "days", 14, "Number of days to show notes per day for (0 hides them)")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print stats as JSON")
+
+	return cmd
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,138 @@
+package notes
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/notes"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/spf13/cobra"
+)
+
+func trashCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trash",
+		Short: "List deleted notes",
+		Long: `List the notes deleted with 'wash notes browse' or 'wash remember delete'.
+
+Deleted notes are moved to ~/.wash/trash and can be put back with
+'wash notes restore <id>'. They are emptied for good after retention.trash
+(30 days by default) by 'wash notes prune' and by a running 'wash monitor'.
+
+Examples:
+  wash notes trash
+  wash notes restore 3f9c2a1b
+  wash notes trash empty`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			notesManager, err := notes.NewNotesManager()
+			if err != nil {
+				return fmt.Errorf("failed to create notes manager: %w", err)
+			}
+
+			entries, err := notesManager.ListTrash()
+			if err != nil {
+				return fmt.Errorf("failed to list trash: %w", err)
+			}
+			if len(entries) == 0 {
+				fmt.Println("The trash is empty")
+				return nil
+			}
+
+			for _, entry := range entries {
+				fmt.Printf("%s  %s  %-11s %s\n", entry.ID, entry.DeletedAt.Local().Format("2006-01-02 15:04"), entry.Kind, entry.Path)
+			}
+			return nil
+		},
+	}
+
+	cmd.AddCommand(emptyTrashCommand())
+
+	return cmd
+}
+
+func emptyTrashCommand() *cobra.Command {
+	var all bool
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "empty",
+		Short: "Permanently delete notes from the trash",
+		Long: `Permanently delete the notes that have been in the trash longer than
+retention.trash, or with --all every note in the trash.
+
+Examples:
+  wash notes trash empty --dry-run
+  wash notes trash empty --all`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cutoff := time.Now()
+			if !all {
+				cfg, err := config.LoadConfig()
+				if err != nil {
+					return fmt.Errorf("failed to load config: %w", err)
+				}
+				policy, err := notes.ParseRetentionPolicy(cfg.Retention)
+				if err != nil {
+					return err
+				}
+				if policy.Trash == 0 {
+					fmt.Println("retention.trash keeps deleted notes forever; use --all to empty the trash anyway.")
+					return nil
+				}
+				cutoff = cutoff.Add(-policy.Trash)
+			}
+
+			notesManager, err := notes.NewNotesManager()
+			if err != nil {
+				return fmt.Errorf("failed to create notes manager: %w", err)
+			}
+
+			emptied, err := notesManager.EmptyTrash(cutoff, dryRun)
+			if err != nil {
+				return fmt.Errorf("failed to empty trash: %w", err)
+			}
+
+			verb := "Deleted"
+			if dryRun {
+				verb = "Would delete"
+			}
+			fmt.Printf("%s %d notes from the trash.\n", verb, emptied)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&all, "all", false, "Delete every note in the trash, however recently it was deleted")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Report what would be deleted without deleting")
+
+	return cmd
+}
+
+func restoreCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore <id>",
+		Short: "Restore a deleted note from the trash",
+		Long: `Move a deleted note out of the trash and back where it was. The ID is the one
+shown when the note was deleted and by 'wash notes trash'; any unique prefix
+of it works.
+
+Examples:
+  wash notes restore 3f9c2a1b`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			notesManager, err := notes.NewNotesManager()
+			if err != nil {
+				return fmt.Errorf("failed to create notes manager: %w", err)
+			}
+
+			entry, err := notesManager.RestoreTrash(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Restored %s note %s\n", entry.Kind, entry.Path)
+			return nil
+		},
+	}
+
+	return cmd
+}
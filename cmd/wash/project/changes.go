@@ -0,0 +1,79 @@
+package project
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bkidd1/wash-cli/cmd/wash/cmdutil"
+	"github.com/bkidd1/wash-cli/internal/services/analyzer"
+	"github.com/bkidd1/wash-cli/internal/services/journal"
+	"github.com/bkidd1/wash-cli/internal/services/manifest"
+)
+
+// currentHashes hashes the files a project analysis covers, for the manifest
+func currentHashes(absPath string) (map[string]string, error) {
+	files, err := analyzer.ProjectFiles(absPath)
+	if err != nil {
+		return nil, err
+	}
+	return manifest.Hash(absPath, files), nil
+}
+
+// saveManifest records the files and findings of this run, for the next --changed-only run
+func saveManifest(projectName string, hashes map[string]string, analysis *analyzer.Analysis) {
+	if hashes == nil || analysis == nil {
+		return
+	}
+	manifests, err := manifest.NewManifestManager()
+	if err == nil {
+		err = manifests.Save(projectName, &manifest.Manifest{
+			AnalyzedAt:     time.Now(),
+			Files:          hashes,
+			CriticalIssues: analysis.CriticalIssues,
+			ShouldFix:      analysis.ShouldFix,
+			CouldFix:       analysis.CouldFix,
+		})
+	}
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+}
+
+// analyzeChanges updates the report of the last run for the files changed since, and returns the
+// current findings. It returns nil without analyzing when there is no previous run, so the whole
+// project is analyzed instead.
+func analyzeChanges(a *analyzer.TerminalAnalyzer, projectName, absPath string, hashes map[string]string) (*analyzer.Analysis, error) {
+	manifests, err := manifest.NewManifestManager()
+	if err != nil {
+		return nil, err
+	}
+	last, err := manifests.Load(projectName)
+	if err != nil {
+		return nil, err
+	}
+	if last == nil {
+		fmt.Printf("No previous analysis of %s; analyzing the whole project.\n", projectName)
+		return nil, nil
+	}
+
+	previous := &analyzer.Analysis{CriticalIssues: last.CriticalIssues, ShouldFix: last.ShouldFix, CouldFix: last.CouldFix}
+	changed, removed := last.Changes(hashes)
+	if len(changed) == 0 && len(removed) == 0 {
+		fmt.Printf("No files changed since the analysis of %s:\n\n", last.AnalyzedAt.Format("2006-01-02 15:04:05"))
+		fmt.Println(previous.Format())
+		return previous, nil
+	}
+
+	fmt.Printf("%d files changed and %d removed since the analysis of %s\n",
+		len(changed), len(removed), last.AnalyzedAt.Format("2006-01-02 15:04:05"))
+	_, err = cmdutil.AnalyzeStreaming(a, loadingAnimation, "Analysis Results", func() (string, error) {
+		return a.AnalyzeProjectChanges(context.Background(), absPath, previous, changed, removed)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze project: %w", err)
+	}
+	journal.Record(journal.ActionAnalysis, projectName, absPath, fmt.Sprintf("project changes analysis: %d changed, %d removed", len(changed), len(removed)))
+	saveManifest(projectName, hashes, a.LastAnalysis())
+	return a.LastAnalysis(), nil
+}
@@ -0,0 +1,58 @@
+package project
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/analyzer"
+	"github.com/bkidd1/wash-cli/internal/services/journal"
+)
+
+const (
+	// diagramPath is where the architecture diagram is written, relative to the project
+	diagramPath = "docs/architecture.mmd"
+	// renderTimeout bounds rendering with mmdc, which starts a headless browser
+	renderTimeout = 2 * time.Minute
+)
+
+// writeDiagram generates the architecture diagram of the project at absPath, writes it to
+// docs/architecture.mmd and renders it to SVG next to it when the Mermaid CLI is installed
+func writeDiagram(a *analyzer.TerminalAnalyzer, projectName, absPath string) error {
+	done := make(chan bool)
+	go loadingAnimation(done)
+	source, err := a.GenerateDiagram(context.Background(), absPath)
+	done <- true
+	if err != nil {
+		return fmt.Errorf("failed to generate diagram: %w", err)
+	}
+
+	path := filepath.Join(absPath, filepath.FromSlash(diagramPath))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create docs directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+		return fmt.Errorf("failed to write diagram: %w", err)
+	}
+	journal.Record(journal.ActionSaved, projectName, diagramPath, "architecture diagram")
+	fmt.Printf("Diagram written to %s\n", path)
+
+	mmdc, err := exec.LookPath("mmdc")
+	if err != nil {
+		fmt.Println("Install the Mermaid CLI (npm install -g @mermaid-js/mermaid-cli) to render it to SVG as well.")
+		return nil
+	}
+	svgPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".svg"
+	ctx, cancel := context.WithTimeout(context.Background(), renderTimeout)
+	defer cancel()
+	if out, err := exec.CommandContext(ctx, mmdc, "-i", path, "-o", svgPath).CombinedOutput(); err != nil {
+		fmt.Printf("Warning: failed to render the diagram with mmdc: %v\n%s", err, out)
+		return nil
+	}
+	fmt.Printf("Rendered to %s\n", svgPath)
+	return nil
+}
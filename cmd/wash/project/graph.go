@@ -0,0 +1,39 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bkidd1/wash-cli/internal/services/journal"
+	"github.com/bkidd1/wash-cli/internal/utils/impact"
+)
+
+// graphPath is where the package graph is written, relative to the project, as .dot and .mmd
+const graphPath = "docs/dependencies"
+
+// writeGraph writes the package import graph of the Go module at absPath to docs/dependencies.dot
+// and docs/dependencies.mmd
+func writeGraph(projectName, absPath string) error {
+	graph, err := impact.Load(absPath)
+	if err != nil {
+		return fmt.Errorf("failed to load package graph: %w", err)
+	}
+	if graph == nil {
+		fmt.Println("Warning: no go.mod found; the package graph is drawn for Go modules only")
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Join(absPath, "docs"), 0755); err != nil {
+		return fmt.Errorf("failed to create docs directory: %w", err)
+	}
+	for _, out := range []struct{ ext, content string }{{".dot", graph.DOT()}, {".mmd", graph.Mermaid()}} {
+		rel := graphPath + out.ext
+		if err := os.WriteFile(filepath.Join(absPath, filepath.FromSlash(rel)), []byte(out.content), 0644); err != nil {
+			return fmt.Errorf("failed to write package graph: %w", err)
+		}
+		journal.Record(journal.ActionSaved, projectName, rel, "package graph")
+	}
+	fmt.Printf("Package graph written to %s.dot and .mmd\n", filepath.Join(absPath, filepath.FromSlash(graphPath)))
+	return nil
+}
@@ -8,14 +8,35 @@ import (
 	"strings"
 	"time"
 
+	"github.com/bkidd1/wash-cli/cmd/wash/cmdutil"
 	"github.com/bkidd1/wash-cli/internal/services/analyzer"
+	"github.com/bkidd1/wash-cli/internal/services/goals"
+	"github.com/bkidd1/wash-cli/internal/services/journal"
 	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/language"
+	"github.com/bkidd1/wash-cli/internal/utils/report"
+	"github.com/bkidd1/wash-cli/internal/utils/style"
+	"github.com/bkidd1/wash-cli/internal/utils/tokens"
 	"github.com/spf13/cobra"
 )
 
+// Analysis modes
+const (
+	modeStructure = "structure"
+	modeHygiene   = "hygiene"
+)
+
 var (
 	// Flags
-	goal string
+	goal        string
+	failOn      string
+	changedOnly bool
+	diagram     bool
+	graph       bool
+	mode        string
+
+	// found is the analysis of the run, for --fail-on
+	found *analyzer.Analysis
 )
 
 // loadingAnimation shows a simple loading animation
@@ -56,6 +77,32 @@ The command will:
 3. Identify potential improvements
 4. Generate actionable recommendations
 
+Along with the file list, the analysis reads excerpts of the code: key
+configuration files such as go.mod or package.json, and the package docs and
+top-level declarations of source files, within a fixed token budget.
+
+The report starts with metrics measured locally, which the analysis is also
+given: lines of code and files per language, the largest files, and the
+cyclomatic complexity of Go functions and JavaScript and TypeScript
+declarations. In Go modules, the import graph between packages is parsed as
+well, for import cycles, god packages importing much of the module, and
+layering violations such as utils importing services or anything importing
+cmd. --graph writes the graph to docs/dependencies.dot and .mmd.
+
+With --mode hygiene, the analysis looks for dead and duplicated code instead:
+exports no other package uses, Go packages nothing imports or that share a
+name with another, which are often leftover copies, and blocks of code copied
+between files. These are found locally and the model explains them and what
+to do.
+
+Projects too large for one request are analyzed in parts, grouped by directory,
+and the findings of the parts are combined into one report.
+
+Each run records the files it covered. With --changed-only, only the files
+added, modified or removed since the last run are analyzed, and the last
+report is updated for them: findings the changes resolved are dropped and new
+ones added. Without a previous run, the whole project is analyzed.
+
 Examples:
   # Analyze current directory
   wash project
@@ -64,9 +111,38 @@ Examples:
   wash project ./src
 
   # Analyze with specific goal
-  wash project --goal "Improve code organization and reduce technical debt"`,
+  wash project --goal "Improve code organization and reduce technical debt"
+
+  # Update the last report for the files changed since
+  wash project --changed-only
+
+  # Draw the architecture as a Mermaid diagram in docs/architecture.mmd,
+  # rendered to docs/architecture.svg when mmdc is installed
+  wash project --diagram
+
+  # Look for dead code, leftover packages and copy-pasted blocks
+  wash project --mode hygiene
+
+  # Also write the Go package graph as DOT and Mermaid
+  wash project --graph
+
+  # Exit with status 2 when there are Critical findings, to gate CI
+  wash project --fail-on critical`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := report.CheckFailOn(failOn); err != nil {
+				return err
+			}
+			switch mode {
+			case modeStructure:
+			case modeHygiene:
+				if changedOnly || diagram {
+					return fmt.Errorf("--changed-only and --diagram apply to the structure analysis, not --mode %s", mode)
+				}
+			default:
+				return fmt.Errorf("unknown mode %q: use %s or %s", mode, modeStructure, modeHygiene)
+			}
+
 			// Get the path to analyze
 			path := "."
 			if len(args) > 0 {
@@ -90,24 +166,82 @@ Examples:
 				return fmt.Errorf("failed to load config: %w", err)
 			}
 
-			// Override project goal if specified
+			// Use the project's goal unless one is specified
 			if goal != "" {
 				cfg.ProjectGoal = goal
+			} else {
+				cfg.ProjectGoal = goals.Resolve(filepath.Base(absPath), cfg)
 			}
 
 			// Create analyzer with project context
 			analyzer := analyzer.NewTerminalAnalyzer(cfg.OpenAIKey, cfg.ProjectGoal, nil)
+			analyzer.SetModel(cfg.Model)
+			analyzer.SetFallbackModels(cfg.FallbackModels)
 
-			// Create a channel to signal when washing is done
-			done := make(chan bool)
-			go loadingAnimation(done)
+			// Large projects are analyzed in parts, several at once; report each as it is done
+			analyzer.SetWorkers(cfg.Workers)
+			analyzer.SetProgress(func(done, total int, label string) {
+				fmt.Printf("\rAnalyzed part %d/%d: %s\n", done, total, label)
+			})
 
-			// Wash project structure
-			result, err := analyzer.AnalyzeProjectStructure(context.Background(), absPath)
+			// Detect project languages so suggestions match the codebase
+			if profile, err := language.Load(filepath.Base(absPath), absPath); err == nil {
+				analyzer.SetLanguageProfile(profile.String())
+			}
+			if conventions, err := style.Detect(absPath); err == nil {
+				analyzer.SetFormattingConventions(conventions.Describe(""))
+			}
+
+			projectName := filepath.Base(absPath)
+			if graph {
+				if err := writeGraph(projectName, absPath); err != nil {
+					return err
+				}
+			}
+			if diagram {
+				return writeDiagram(analyzer, projectName, absPath)
+			}
+
+			if mode == modeHygiene {
+				_, err := cmdutil.AnalyzeStreaming(analyzer, loadingAnimation, "Hygiene Results", func() (string, error) {
+					return analyzer.AnalyzeProjectHygiene(context.Background(), absPath)
+				})
+				if err != nil {
+					return fmt.Errorf("failed to analyze project hygiene: %w", err)
+				}
+				journal.Record(journal.ActionAnalysis, projectName, absPath, "project hygiene analysis")
+				found = analyzer.LastAnalysis()
+				return nil
+			}
+
+			// Update the last run's report for the files changed since, when there is one
+			hashes, err := currentHashes(absPath)
+			if err != nil {
+				fmt.Printf("Warning: %v\n", err)
+			}
+			if changedOnly && hashes != nil {
+				analysis, err := analyzeChanges(analyzer, projectName, absPath, hashes)
+				if err != nil {
+					return err
+				}
+				if analysis != nil {
+					found = analysis
+					return nil
+				}
+			}
+
+			// Show the expected size and cost of large requests before sending
+			if estimate, err := analyzer.EstimateProjectStructure(absPath); err == nil && estimate.PromptTokens > tokens.LargeRequestTokens {
+				fmt.Printf("Estimated request: %s\n", estimate)
+			}
+
+			// Wash project structure, printing the results as they are generated
+			_, err = cmdutil.AnalyzeStreaming(analyzer, loadingAnimation, "Analysis Results", func() (string, error) {
+				return analyzer.AnalyzeProjectStructure(context.Background(), absPath)
+			})
 			if err != nil {
 				// Check if error is token limit related
 				if strings.Contains(err.Error(), "maximum context length") || strings.Contains(err.Error(), "resulted in") {
-					done <- true
 					fmt.Println("\n⚠️  Project is too large for complete analysis.")
 					fmt.Println("Please specify a subdirectory to analyze (e.g., 'cmd', 'internal', 'pkg'):")
 
@@ -120,41 +254,48 @@ Examples:
 						return fmt.Errorf("subdirectory does not exist: %s", subdir)
 					}
 
-					// Create a new channel for the subdirectory analysis
-					done = make(chan bool)
-					go loadingAnimation(done)
-
 					// Analyze the subdirectory
-					result, err = analyzer.AnalyzeProjectStructure(context.Background(), subdirPath)
+					_, err = cmdutil.AnalyzeStreaming(analyzer, loadingAnimation, fmt.Sprintf("Analysis Results for %s directory", subdir), func() (string, error) {
+						return analyzer.AnalyzeProjectStructure(context.Background(), subdirPath)
+					})
 					if err != nil {
-						done <- true
 						return fmt.Errorf("failed to analyze subdirectory: %w", err)
 					}
-
-					done <- true
-					fmt.Printf("\nAnalysis Results for %s directory:\n", subdir)
-					fmt.Println("-------------------------------")
-					fmt.Println(result)
+					journal.Record(journal.ActionAnalysis, filepath.Base(absPath), subdir, "project structure analysis")
+					found = analyzer.LastAnalysis()
 					return nil
 				}
 
-				done <- true
 				return fmt.Errorf("failed to analyze project: %w", err)
 			}
-
-			// Signal that washing is complete
-			done <- true
-
-			// Print results
-			fmt.Println("\nAnalysis Results:")
-			fmt.Println("----------------")
-			fmt.Println(result)
+			journal.Record(journal.ActionAnalysis, projectName, absPath, "project structure analysis")
+			found = analyzer.LastAnalysis()
+			saveManifest(projectName, hashes, found)
 			return nil
 		},
+		// Runs only when the analysis succeeded; the findings were shown, so only the summary is printed
+		PostRunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage, cmd.SilenceErrors = true, true
+			if found == nil {
+				// The analysis gave no findings to check, so the gate cannot pass
+				if failOn != "" {
+					return fmt.Errorf("the analysis reported no findings to check --fail-on %s against", failOn)
+				}
+				return nil
+			}
+			return report.FailOn(failOn, len(found.CriticalIssues), len(found.ShouldFix), len(found.CouldFix))
+		},
 	}
 
 	// Add flags
 	cmd.Flags().StringVar(&goal, "goal", "", "Specific goal for the project analysis")
+	cmd.Flags().BoolVar(&changedOnly, "changed-only", false, "Update the last report for the files changed since, instead of analyzing the whole project")
+	cmd.Flags().BoolVar(&diagram, "diagram", false, "Generate a Mermaid diagram of the architecture in docs/architecture.mmd instead of analyzing the project")
+	cmd.Flags().StringVar(&mode, "mode", modeStructure, "What to analyze: structure, or hygiene for dead code, leftover packages and copied blocks")
+	cmd.Flags().BoolVar(&graph, "graph", false, "Write the Go package import graph to docs/dependencies.dot and docs/dependencies.mmd")
+	cmd.Flags().StringVar(&failOn, "fail-on", "", "Exit with status 2 when there are findings at or above this level: critical, should or could")
+	cmd.MarkFlagsMutuallyExclusive("diagram", "changed-only")
+	cmd.MarkFlagsMutuallyExclusive("diagram", "fail-on")
 
 	return cmd
 }
@@ -0,0 +1,287 @@
+package refactorplan
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/cmd/wash/cmdutil"
+	"github.com/bkidd1/wash-cli/internal/services/notes"
+	"github.com/bkidd1/wash-cli/internal/services/plans"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Flags
+	projectName string
+)
+
+// loadingAnimation shows a simple loading animation
+func loadingAnimation(done chan bool) {
+	spinner := []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+	i := 0
+	for {
+		select {
+		case <-done:
+			fmt.Printf("\r") // Clear the line
+			return
+		default:
+			fmt.Printf("\rPlanning refactor... %s", spinner[i])
+			i = (i + 1) % len(spinner)
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+}
+
+// Command creates the refactor-plan command
+func Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "refactor-plan",
+		Short: "Plan a multi-step refactor",
+		Long: `Generate a concrete, ordered plan of file-level steps for a refactoring goal.
+
+Each step lists the files it touches and notes what could break. Plans are
+stored in ~/.wash/projects/[project-name]/plans/ and completed steps are
+recorded as progress notes. The goal is given to 'new', so one starting with
+list, show or done is not taken for those commands.
+
+Examples:
+  # Plan a refactor
+  wash refactor-plan new "split the monolith notes package"
+
+  # List plans for the current project
+  wash refactor-plan list
+
+  # Show a plan
+  wash refactor-plan show 1a2b3c4d
+
+  # Mark step 2 of a plan as done
+  wash refactor-plan done 1a2b3c4d 2`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return cmd.Help()
+			}
+			return fmt.Errorf("unknown command %q for \"wash refactor-plan\"; to plan a refactor, run: wash refactor-plan new %q", args[0], strings.Join(args, " "))
+		},
+	}
+
+	cmd.PersistentFlags().StringVarP(&projectName, "project", "p", "", "Project name (defaults to current directory name)")
+
+	cmd.AddCommand(newCmd())
+	cmd.AddCommand(listCmd())
+	cmd.AddCommand(showCmd())
+	cmd.AddCommand(doneCmd())
+
+	return cmd
+}
+
+func newCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "new [goal]",
+		Short: "Plan a refactor for a goal",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			goal := strings.TrimSpace(strings.Join(args, " "))
+			if goal == "" {
+				return fmt.Errorf("refactoring goal cannot be empty")
+			}
+
+			cwd, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get current directory: %w", err)
+			}
+			if projectName == "" {
+				projectName = filepath.Base(cwd)
+			}
+
+			// Load config
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
+			// Create analyzer with project context
+			analyzer, _, warnings := cmdutil.NewAnalyzer(cfg, "", projectName, cwd)
+			for _, warning := range warnings {
+				fmt.Printf("Warning: %v\n", warning)
+			}
+
+			done := make(chan bool)
+			go loadingAnimation(done)
+
+			plan, err := analyzer.GenerateRefactorPlan(context.Background(), cwd, projectName, goal)
+			done <- true
+			if err != nil {
+				return fmt.Errorf("failed to generate plan: %w", err)
+			}
+			if p := analyzer.LastProvenance(); p != nil && p.Substituted() {
+				fmt.Printf("Note: analyzed with %s\n", p)
+			}
+
+			planManager, err := plans.NewPlanManager()
+			if err != nil {
+				return fmt.Errorf("failed to create plan manager: %w", err)
+			}
+
+			if err := planManager.SavePlan(plan); err != nil {
+				return fmt.Errorf("failed to save plan: %w", err)
+			}
+
+			printPlan(plan)
+			fmt.Printf("\nPlan saved with ID %s. Mark steps done with: wash refactor-plan done %s [step]\n", plan.ID, plan.ID)
+			return nil
+		},
+	}
+}
+
+func listCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List plans for the project",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			project, err := cmdutil.ProjectName(projectName)
+			if err != nil {
+				return err
+			}
+
+			planManager, err := plans.NewPlanManager()
+			if err != nil {
+				return fmt.Errorf("failed to create plan manager: %w", err)
+			}
+
+			allPlans, err := planManager.ListPlans(project)
+			if err != nil {
+				return fmt.Errorf("failed to list plans: %w", err)
+			}
+
+			if len(allPlans) == 0 {
+				fmt.Printf("No plans found for project %s\n", project)
+				return nil
+			}
+
+			for _, plan := range allPlans {
+				fmt.Printf("%s  [%s] %d/%d steps  %s  %s\n",
+					plan.ID,
+					plan.Status,
+					plan.CompletedSteps(),
+					len(plan.Steps),
+					plan.CreatedAt.Format("2006-01-02"),
+					plan.Goal)
+			}
+			return nil
+		},
+	}
+}
+
+func showCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show [id]",
+		Short: "Show a plan and its step status",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			project, err := cmdutil.ProjectName(projectName)
+			if err != nil {
+				return err
+			}
+
+			planManager, err := plans.NewPlanManager()
+			if err != nil {
+				return fmt.Errorf("failed to create plan manager: %w", err)
+			}
+
+			plan, err := planManager.LoadPlan(project, args[0])
+			if err != nil {
+				return err
+			}
+
+			printPlan(plan)
+			return nil
+		},
+	}
+}
+
+func doneCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "done [id] [step]",
+		Short: "Mark a plan step as done",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			project, err := cmdutil.ProjectName(projectName)
+			if err != nil {
+				return err
+			}
+
+			stepNumber, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid step number: %s", args[1])
+			}
+
+			planManager, err := plans.NewPlanManager()
+			if err != nil {
+				return fmt.Errorf("failed to create plan manager: %w", err)
+			}
+
+			plan, err := planManager.LoadPlan(project, args[0])
+			if err != nil {
+				return err
+			}
+
+			step, err := plan.CompleteStep(stepNumber)
+			if err != nil {
+				return err
+			}
+
+			if err := planManager.SavePlan(plan); err != nil {
+				return fmt.Errorf("failed to save plan: %w", err)
+			}
+
+			// Record the completed step as a progress note
+			notesManager, err := notes.NewNotesManager()
+			if err != nil {
+				return fmt.Errorf("failed to create notes manager: %w", err)
+			}
+
+			if err := notesManager.SaveProjectProgress(plans.ProgressNoteForStep(plan, step)); err != nil {
+				return fmt.Errorf("failed to save progress note: %w", err)
+			}
+
+			fmt.Printf("Step %d marked done: %s\n", step.Number, step.Title)
+			if plan.Status == plans.StatusDone {
+				fmt.Println("All steps are complete. Plan done!")
+			} else if next := plan.NextStep(); next != nil {
+				fmt.Printf("Next: %d. %s\n", next.Number, next.Title)
+			}
+			return nil
+		},
+	}
+}
+
+// printPlan prints a plan with its steps
+func printPlan(plan *plans.Plan) {
+	fmt.Printf("\nRefactor Plan %s: %s\n", plan.ID, plan.Goal)
+	fmt.Println("------------------------")
+	if plan.Summary != "" {
+		fmt.Printf("%s\n\n", plan.Summary)
+	}
+
+	for _, step := range plan.Steps {
+		marker := "[ ]"
+		if step.Status == plans.StatusDone {
+			marker = "[x]"
+		}
+		fmt.Printf("%s %d. %s (risk: %s)\n", marker, step.Number, step.Title, step.Risk)
+		fmt.Printf("    %s\n", step.Description)
+		if len(step.Files) > 0 {
+			fmt.Printf("    Files: %s\n", strings.Join(step.Files, ", "))
+		}
+		if step.RiskNotes != "" {
+			fmt.Printf("    Risk notes: %s\n", step.RiskNotes)
+		}
+	}
+}
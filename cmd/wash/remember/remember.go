@@ -9,19 +9,16 @@ import (
 	"time"
 
 	"github.com/bkidd1/wash-cli/internal/services/notes"
+	"github.com/bkidd1/wash-cli/internal/services/retrieval"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/editor"
 	"github.com/spf13/cobra"
 )
 
-var (
-	// Flags
-	projectName string
-	tags        []string
-)
-
 // Command returns the remember command
 func Command() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "remember [content]",
+		Use:   "remember",
 		Short: "Save important information to remember",
 		Long: `Save important information, notes, or reminders for your project.
 The remember command helps you keep track of:
@@ -31,20 +28,59 @@ The remember command helps you keep track of:
 - Project-specific knowledge
 - Development patterns
 
-Notes are stored in ~/.wash/remember/[project-name]/
+Notes are stored in ~/.wash/remember/[user]/. A note applies to the project it
+was saved for, or with --global to every project. Analyzers include the project's
+notes first, then global notes and the remember_notes list from the config file.
+A note is saved with 'add', so one starting with list, edit or delete is not
+taken for those commands.
 
 Examples:
   # Save a note interactively
-  wash remember
+  wash remember add
 
   # Save a note directly
-  wash remember "Implement caching for better performance"
+  wash remember add "Implement caching for better performance"
 
   # Save a note with tags
-  wash remember "Add error handling" --tags "error,security"
+  wash remember add "Add error handling" --tags "error,security"
 
   # Save a note for specific project
-  wash remember "Update documentation" --project my-project`,
+  wash remember add "Update documentation" --project my-project
+
+  # Save a note that applies to every project
+  wash remember add --global "Prefer table-driven tests"
+
+  # List, edit and delete notes
+  wash remember list --tag security --since 7d
+  wash remember edit 3f2a9c1e
+  wash remember delete 3f2a9c1e`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return cmd.Help()
+			}
+			return fmt.Errorf("unknown command %q for \"wash remember\"; to save a note, run: wash remember add %q", args[0], strings.Join(args, " "))
+		},
+	}
+
+	// Add subcommands
+	cmd.AddCommand(addCommand())
+	cmd.AddCommand(listCommand())
+	cmd.AddCommand(editCommand())
+	cmd.AddCommand(deleteCommand())
+	cmd.AddCommand(importConfigCommand())
+
+	return cmd
+}
+
+func addCommand() *cobra.Command {
+	var projectName string
+	var tags []string
+	var global bool
+
+	cmd := &cobra.Command{
+		Use:   "add [content]",
+		Short: "Save a remember note",
+		Long:  `Save a remember note. Without content, the note is read from the terminal.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			var content string
 			if len(args) == 0 {
@@ -67,45 +103,43 @@ Examples:
 			}
 
 			// Get project name
-			if projectName == "" {
-				cwd, err := os.Getwd()
-				if err != nil {
-					return fmt.Errorf("failed to get current directory: %w", err)
-				}
-				projectName = filepath.Base(cwd)
+			projectName, err := currentProject(projectName)
+			if err != nil {
+				return err
+			}
+			if global && cmd.Flags().Changed("project") {
+				return fmt.Errorf("--global and --project cannot be used together")
 			}
 
-			// Create notes manager
-			notesManager, err := notes.NewNotesManager()
+			// Load config
+			cfg, err := config.LoadConfig()
 			if err != nil {
-				return fmt.Errorf("failed to create notes manager: %w", err)
+				return fmt.Errorf("failed to load config: %w", err)
 			}
 
-			// Create new note
-			note := &notes.RememberNote{
-				Timestamp: time.Now(),
-				Content:   content,
-				Metadata: map[string]interface{}{
-					"project": projectName,
-					"type":    "remember",
-					"tags":    tags,
-				},
+			store, err := notes.NewRememberStore(cfg)
+			if err != nil {
+				return fmt.Errorf("failed to create notes manager: %w", err)
 			}
 
-			// Get current user
-			username := os.Getenv("USER")
-			if username == "" {
-				username = "default"
+			// Save note; an empty project makes it global
+			scope := projectName
+			if global {
+				scope = ""
 			}
-
-			// Save note
-			if err := notesManager.SaveUserNote(username, note); err != nil {
+			note, err := store.Add(scope, content, tags)
+			if err != nil {
 				return fmt.Errorf("failed to save note: %w", err)
 			}
 
 			fmt.Printf("\nNote saved successfully!\n")
+			fmt.Printf("ID: %s\n", shortID(note.ID))
 			fmt.Printf("Time: %s\n", note.Timestamp.Format(time.RFC3339))
-			fmt.Printf("Project: %s\n", projectName)
+			if global {
+				fmt.Println("Scope: global")
+			} else {
+				fmt.Printf("Project: %s\n", projectName)
+			}
 			if len(tags) > 0 {
 				fmt.Printf("Tags: %s\n", strings.Join(tags, ", "))
 			}
@@ -113,9 +147,275 @@ Examples:
 		},
 	}
 
-	// Add flags
 	cmd.Flags().StringVarP(&projectName, "project", "p", "", "Project name (defaults to current directory name)")
 	cmd.Flags().StringSliceVarP(&tags, "tags", "t", []string{}, "Tags for the note (comma-separated)")
+	cmd.Flags().BoolVarP(&global, "global", "g", false, "Apply the note to every project")
+
+	return cmd
+}
+
+// shortIDLength is how many characters of a note ID are shown; any unique prefix is accepted
+const shortIDLength = 8
+
+// currentProject returns the project named by flag, defaulting to the current directory name
+func currentProject(flag string) (string, error) {
+	if flag != "" {
+		return flag, nil
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current directory: %w", err)
+	}
+	return filepath.Base(cwd), nil
+}
+
+// shortID abbreviates a note ID for display
+func shortID(id string) string {
+	if len(id) > shortIDLength {
+		return id[:shortIDLength]
+	}
+	return id
+}
+
+func listCommand() *cobra.Command {
+	var project, since, until string
+	var tagFilter []string
+	var all, globalOnly bool
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List remember notes",
+		Long: `List your remember notes for the current project and your global notes, oldest first.
+
+--since and --until take a date (2025-01-31), an RFC 3339 time or an age such as 7d.
+With several --tag flags, only notes carrying every tag are listed.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			filter := notes.RememberFilter{Tags: tagFilter}
+			var err error
+			switch {
+			case globalOnly:
+				filter.Scope = notes.ScopeGlobal
+			case !all:
+				if filter.Project, err = currentProject(project); err != nil {
+					return err
+				}
+				filter.IncludeGlobal = true
+			}
+			if filter.Since, err = config.ParseTime(since); err != nil {
+				return err
+			}
+			if filter.Until, err = config.ParseTime(until); err != nil {
+				return err
+			}
+
+			notesManager, err := notes.NewNotesManager()
+			if err != nil {
+				return fmt.Errorf("failed to create notes manager: %w", err)
+			}
+			list, err := notesManager.ListUserNotes(notes.CurrentUser(), filter)
+			if err != nil {
+				return fmt.Errorf("failed to list notes: %w", err)
+			}
+			if len(list) == 0 {
+				fmt.Println("No remember notes found")
+				return nil
+			}
+
+			for _, note := range list {
+				line := fmt.Sprintf("%-*s  %s", shortIDLength, shortID(note.ID), note.Timestamp.Local().Format("2006-01-02 15:04"))
+				if note.Scope() == notes.ScopeGlobal {
+					line += "  [global]"
+				} else if all {
+					line += "  [" + note.Project() + "]"
+				}
+				line += "  " + strings.ReplaceAll(note.Content, "\n", " ")
+				if noteTags := note.Tags(); len(noteTags) > 0 {
+					line += "  (" + strings.Join(noteTags, ", ") + ")"
+				}
+				fmt.Println(line)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&project, "project", "p", "", "Project name (defaults to current directory name)")
+	cmd.Flags().BoolVarP(&all, "all", "a", false, "List notes from every project")
+	cmd.Flags().BoolVarP(&globalOnly, "global", "g", false, "Only list global notes")
+	cmd.Flags().StringSliceVarP(&tagFilter, "tag", "t", nil, "Only list notes with this tag (repeatable)")
+	cmd.Flags().StringVar(&since, "since", "", "Only list notes saved at or after this time")
+	cmd.Flags().StringVar(&until, "until", "", "Only list notes saved at or before this time")
+
+	return cmd
+}
+
+func editCommand() *cobra.Command {
+	var content string
+	var newTags []string
+
+	cmd := &cobra.Command{
+		Use:   "edit <id>",
+		Short: "Edit a remember note",
+		Long: `Edit a remember note. Without --content, the note opens in $VISUAL or $EDITOR.
+Any unique prefix of a note ID shown by 'wash remember list' is accepted.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			notesManager, err := notes.NewNotesManager()
+			if err != nil {
+				return fmt.Errorf("failed to create notes manager: %w", err)
+			}
+			username := notes.CurrentUser()
+			note, err := notesManager.GetUserNote(username, args[0])
+			if err != nil {
+				return err
+			}
+
+			edited := note.Content
+			if cmd.Flags().Changed("content") {
+				edited = content
+			} else if !cmd.Flags().Changed("tags") {
+				if edited, err = editInEditor(note.Content); err != nil {
+					return err
+				}
+			}
+			edited = strings.TrimSpace(edited)
+			if edited == "" {
+				return fmt.Errorf("content cannot be empty; use 'wash remember delete' to remove a note")
+			}
+
+			previous := note.Content
+			changed := edited != note.Content
+			note.Content = edited
+			if cmd.Flags().Changed("tags") {
+				note.SetTags(newTags)
+				changed = true
+			}
+			if !changed {
+				fmt.Println("No changes made")
+				return nil
+			}
+
+			if err := notesManager.UpdateUserNote(username, note); err != nil {
+				return fmt.Errorf("failed to update note: %w", err)
+			}
+			if note.Content != previous {
+				forgetEmbedding(previous)
+			}
+			fmt.Printf("Note %s updated\n", shortID(note.ID))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&content, "content", "c", "", "Replace the note content without opening an editor")
+	cmd.Flags().StringSliceVarP(&newTags, "tags", "t", nil, "Replace the note tags (comma-separated)")
+
+	return cmd
+}
+
+// editInEditor opens content in the user's editor and returns the saved text
+func editInEditor(content string) (string, error) {
+	tmp, err := os.CreateTemp("", "wash-remember-*.txt")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.WriteString(content + "\n")
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write temporary file: %w", err)
+	}
+
+	if err := editor.Edit(tmp.Name()); err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(tmp.Name())
+	if err != nil {
+		return "", fmt.Errorf("failed to read edited note: %w", err)
+	}
+	return string(data), nil
+}
+
+func deleteCommand() *cobra.Command {
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:     "delete <id>",
+		Aliases: []string{"rm"},
+		Short:   "Delete a remember note",
+		Long:    `Delete a remember note. Any unique prefix of a note ID shown by 'wash remember list' is accepted.`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			notesManager, err := notes.NewNotesManager()
+			if err != nil {
+				return fmt.Errorf("failed to create notes manager: %w", err)
+			}
+			username := notes.CurrentUser()
+			note, err := notesManager.GetUserNote(username, args[0])
+			if err != nil {
+				return err
+			}
+
+			if !yes {
+				fmt.Printf("Delete note %s: %q? [y/N]: ", shortID(note.ID), note.Content)
+				answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+				if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+					fmt.Println("Note kept")
+					return nil
+				}
+			}
+
+			trashed, err := notesManager.DeleteUserNote(username, note.ID)
+			if err != nil {
+				return fmt.Errorf("failed to delete note: %w", err)
+			}
+			forgetEmbedding(note.Content)
+			fmt.Printf("Note %s moved to the trash; restore it with 'wash notes restore %s'\n", shortID(note.ID), trashed.ID)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Delete without asking for confirmation")
 
 	return cmd
 }
+
+// forgetEmbedding drops a note's text from the retrieval store. The note is already changed,
+// so a failure is only a warning.
+func forgetEmbedding(content string) {
+	retriever, err := retrieval.NewRetriever("")
+	if err == nil {
+		err = retriever.Forget(strings.TrimSpace(content))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not remove the note from the retrieval store: %v\n", err)
+	}
+}
+
+func importConfigCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "import-config",
+		Short: "Move remember_notes from the config file into global remember notes",
+		Long: `Move the remember_notes list from the global config file into your remember notes as global
+notes tagged "config", so they can be listed, edited and deleted like any other note.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			store, err := notes.NewRememberStore(cfg)
+			if err != nil {
+				return fmt.Errorf("failed to create notes manager: %w", err)
+			}
+
+			imported, err := store.ImportConfigNotes()
+			if err != nil {
+				return fmt.Errorf("failed to import notes: %w", err)
+			}
+			fmt.Printf("Imported %d note(s) from the config file\n", imported)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,180 @@
+package retro
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/bugs"
+	"github.com/bkidd1/wash-cli/internal/services/findings"
+	"github.com/bkidd1/wash-cli/internal/services/notes"
+	"github.com/bkidd1/wash-cli/internal/services/retro"
+	"github.com/bkidd1/wash-cli/internal/services/usage"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/sashabaranov/go-openai"
+	"github.com/spf13/cobra"
+)
+
+// observationsPrompt asks the model to read the week before the user reflects on it
+const observationsPrompt = `You are a thoughtful engineering coach preparing a developer's weekly retrospective. From the week's data below, write 3-5 short, specific observations: patterns in when and how they worked, recurring problems in the findings and bugs, and whether they followed through on last retro's stop/start items. Be direct and concrete; do not repeat the numbers back without interpreting them. End with one question for them to reflect on.`
+
+// Command creates the retro command
+func Command() *cobra.Command {
+	var dateStr string
+	var noAI bool
+
+	cmd := &cobra.Command{
+		Use:   "retro",
+		Short: "Run a guided weekly retrospective",
+		Long: `Walks through the past week of the current project: time spent per day
+(estimated from monitor notes and wash activity), analysis findings and bugs,
+daily digests, progress notes and the stop/start items of the last retro. The
+model adds a few observations, then you are asked what to keep, stop and start.
+
+The retro is saved as a progress note of type retro, so weekly summaries and
+the next retro build on it.
+
+Examples:
+  # Reflect on the last seven days
+  wash retro
+
+  # Reflect on the week ending on a date, without the model's observations
+  wash retro --date 2024-05-03 --no-ai`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get current directory: %w", err)
+			}
+			projectName := filepath.Base(cwd)
+
+			day := time.Now()
+			if dateStr != "" {
+				day, err = time.ParseInLocation("2006-01-02", dateStr, time.Local)
+				if err != nil {
+					return fmt.Errorf("invalid date format: %w", err)
+				}
+			}
+			end := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.Local).AddDate(0, 0, 1)
+
+			notesManager, err := notes.NewNotesManager()
+			if err != nil {
+				return fmt.Errorf("failed to initialize notes manager: %w", err)
+			}
+			bugManager, err := bugs.NewBugManager()
+			if err != nil {
+				return fmt.Errorf("failed to create bug manager: %w", err)
+			}
+			findingsManager, err := findings.NewFindingsManager()
+			if err != nil {
+				return fmt.Errorf("failed to create findings manager: %w", err)
+			}
+
+			week, err := retro.Gather(notesManager, bugManager, findingsManager, projectName, end)
+			if err != nil {
+				return fmt.Errorf("failed to gather the week: %w", err)
+			}
+			overview := week.String()
+			fmt.Println(overview)
+
+			entry := &retro.Entry{}
+			if !noAI {
+				fmt.Println("Reading the week...")
+				if entry.Observations, err = observations(overview); err != nil {
+					fmt.Printf("Warning: could not generate observations: %v\n", err)
+				} else {
+					fmt.Printf("\n## Observations\n%s\n", entry.Observations)
+				}
+			}
+
+			reader := bufio.NewReader(os.Stdin)
+			fmt.Println("\nOne item per line; an empty line moves on.")
+			if entry.Keep, err = readItems(reader, "What went well that you want to keep doing?"); err != nil {
+				return err
+			}
+			if entry.Stop, err = readItems(reader, "What do you want to stop doing?"); err != nil {
+				return err
+			}
+			if entry.Start, err = readItems(reader, "What do you want to start doing?"); err != nil {
+				return err
+			}
+			if entry.Empty() {
+				fmt.Println("No keep, stop or start items given; the retro was not saved")
+				return nil
+			}
+
+			note := retro.NewNote(week, entry)
+			if err := notesManager.SaveProjectProgress(note); err != nil {
+				return fmt.Errorf("failed to save retro: %w", err)
+			}
+			fmt.Printf("\nSaved %s\n", note.Title)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&dateStr, "date", "", "Last day of the week to reflect on (YYYY-MM-DD, default today)")
+	cmd.Flags().BoolVar(&noAI, "no-ai", false, "Skip the model's observations")
+
+	return cmd
+}
+
+// readItems asks a question and reads one item per line until an empty line or the end of input
+func readItems(reader *bufio.Reader, question string) ([]string, error) {
+	fmt.Printf("\n%s\n", question)
+	var items []string
+	for {
+		fmt.Print("> ")
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to read input: %w", err)
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if err == io.EOF {
+				fmt.Println()
+			}
+			return items, nil
+		}
+		items = append(items, line)
+		if err == io.EOF {
+			fmt.Println()
+			return items, nil
+		}
+	}
+}
+
+// observations asks the model for its reading of the week
+func observations(overview string) (string, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return "", fmt.Errorf("failed to load config: %w", err)
+	}
+	client := usage.NewClient(cfg.OpenAIKey)
+	model := cfg.Model
+	if model == "" {
+		model = openai.GPT4o
+	}
+
+	resp, err := client.CreateChatCompletion(
+		context.Background(),
+		openai.ChatCompletionRequest{
+			Model: model,
+			Messages: []openai.ChatCompletionMessage{
+				{Role: openai.ChatMessageRoleSystem, Content: observationsPrompt},
+				{Role: openai.ChatMessageRoleUser, Content: overview},
+			},
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no response from model")
+	}
+	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+}
@@ -0,0 +1,235 @@
+package snapshot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/findings"
+	"github.com/bkidd1/wash-cli/internal/services/snapshots"
+	"github.com/spf13/cobra"
+)
+
+// currentName compares a snapshot with the current findings
+const currentName = "current"
+
+// Command creates the snapshot command
+func Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Freeze and compare the state of the codebase",
+		Long: `Snapshots freeze a project's current findings (the latest wash file analysis
+of every file), its health score and its latest wash summary under a name, so
+audits can compare the codebase at two labeled points.
+
+Snapshots are immutable: a name can only be used once, and snapshots are never
+changed after they are created.
+
+The health score rates each analyzed file from 0 to 100, losing 10 points for
+every critical issue, 3 for every should-fix and 1 for every could-fix
+finding, and averages the files.
+
+Examples:
+  # Freeze the state before a release
+  wash snapshot create pre-release-1.4
+
+  # What changed since then?
+  wash snapshot compare pre-release-1.4 current`,
+	}
+
+	cmd.AddCommand(createCmd())
+	cmd.AddCommand(listCmd())
+	cmd.AddCommand(showCmd())
+	cmd.AddCommand(compareCmd())
+
+	return cmd
+}
+
+// projectName returns the project of the current directory
+func projectName() (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current directory: %w", err)
+	}
+	return filepath.Base(cwd), nil
+}
+
+func createCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "create <name>",
+		Short: "Freeze the current findings, health score and summary",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if args[0] == currentName {
+				return fmt.Errorf("%q is reserved for the current findings", currentName)
+			}
+			project, err := projectName()
+			if err != nil {
+				return err
+			}
+			findingsManager, err := findings.NewFindingsManager()
+			if err != nil {
+				return fmt.Errorf("failed to create findings manager: %w", err)
+			}
+			state, err := findingsManager.Load(project)
+			if err != nil {
+				return err
+			}
+			snapshotManager, err := snapshots.NewSnapshotManager()
+			if err != nil {
+				return fmt.Errorf("failed to create snapshot manager: %w", err)
+			}
+
+			snapshot, err := snapshotManager.Create(args[0], state)
+			if err != nil {
+				return err
+			}
+			critical, shouldFix, couldFix := state.Counts()
+			fmt.Printf("Created snapshot %s of %s: health score %d, %d files, %d critical, %d should fix, %d could fix\n",
+				snapshot.Name, project, snapshot.HealthScore, len(state.Files), critical, shouldFix, couldFix)
+			if len(state.Files) == 0 {
+				fmt.Println("No files have been analyzed yet; run wash file to record findings.")
+			}
+			return nil
+		},
+	}
+}
+
+func listCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the project's snapshots",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			project, err := projectName()
+			if err != nil {
+				return err
+			}
+			snapshotManager, err := snapshots.NewSnapshotManager()
+			if err != nil {
+				return fmt.Errorf("failed to create snapshot manager: %w", err)
+			}
+			list, err := snapshotManager.List(project)
+			if err != nil {
+				return err
+			}
+
+			if len(list) == 0 {
+				fmt.Println("No snapshots found")
+				return nil
+			}
+			for _, s := range list {
+				critical, shouldFix, couldFix := s.State.Counts()
+				fmt.Printf("%-24s %s  score %3d  %d files, %d critical, %d should fix, %d could fix\n",
+					s.Name, s.CreatedAt.Local().Format("2006-01-02 15:04"), s.HealthScore, len(s.State.Files), critical, shouldFix, couldFix)
+			}
+			return nil
+		},
+	}
+}
+
+func showCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <name>",
+		Short: "Show the findings and summary of a snapshot",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			project, err := projectName()
+			if err != nil {
+				return err
+			}
+			snapshot, err := load(project, args[0])
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("# Snapshot %s\n*Created on %s*\n\n", snapshot.Name, snapshot.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+			fmt.Printf("Health score: %d\n", snapshot.HealthScore)
+			for _, path := range snapshot.State.Paths() {
+				file := snapshot.State.Files[path]
+				fmt.Printf("\n## %s (score %d, analyzed %s)\n", path, file.Score(), file.AnalyzedAt.Local().Format("2006-01-02"))
+				printIssues("Critical", file.CriticalIssues)
+				printIssues("Should fix", file.ShouldFix)
+				printIssues("Could fix", file.CouldFix)
+			}
+			if summary := snapshot.State.Summary; summary != nil {
+				fmt.Printf("\n## Summary (%s)\n%s\n", summary.Period, summary.Text)
+			}
+			return nil
+		},
+	}
+}
+
+func compareCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "compare <from> <to>",
+		Short: "Compare two snapshots, or a snapshot with the current findings",
+		Long: `Lists the health score change and the findings added and resolved between two
+snapshots. Use "current" for the project's findings now.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			project, err := projectName()
+			if err != nil {
+				return err
+			}
+			from, err := load(project, args[0])
+			if err != nil {
+				return err
+			}
+			to, err := load(project, args[1])
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Health score: %d -> %d (%+d)\n", from.HealthScore, to.HealthScore, to.HealthScore-from.HealthScore)
+			changes := snapshots.Compare(from.State, to.State)
+			if len(changes) == 0 {
+				fmt.Println("No findings changed")
+				return nil
+			}
+			added, resolved := 0, 0
+			for _, change := range changes {
+				fmt.Printf("\n%s\n", change.Path)
+				for _, issue := range change.Added {
+					fmt.Printf("  + %s\n", issue)
+				}
+				for _, issue := range change.Resolved {
+					fmt.Printf("  - %s\n", issue)
+				}
+				added += len(change.Added)
+				resolved += len(change.Resolved)
+			}
+			fmt.Printf("\n%d findings added, %d resolved\n", added, resolved)
+			return nil
+		},
+	}
+}
+
+// load returns a snapshot by name, or the current findings as an unsaved snapshot for "current"
+func load(project, name string) (*snapshots.Snapshot, error) {
+	if name == currentName {
+		findingsManager, err := findings.NewFindingsManager()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create findings manager: %w", err)
+		}
+		state, err := findingsManager.Load(project)
+		if err != nil {
+			return nil, err
+		}
+		return &snapshots.Snapshot{Name: currentName, Project: project, CreatedAt: time.Now(), HealthScore: state.HealthScore(), State: state}, nil
+	}
+
+	snapshotManager, err := snapshots.NewSnapshotManager()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create snapshot manager: %w", err)
+	}
+	return snapshotManager.Get(project, name)
+}
+
+// printIssues prints one severity of a file's findings
+func printIssues(label string, issues []string) {
+	for _, issue := range issues {
+		fmt.Printf("- %s: %s\n", label, issue)
+	}
+}
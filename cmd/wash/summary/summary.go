@@ -9,8 +9,15 @@ import (
 	"strings"
 	"time"
 
+	"github.com/bkidd1/wash-cli/internal/services/findings"
+	"github.com/bkidd1/wash-cli/internal/services/hooks"
+	"github.com/bkidd1/wash-cli/internal/services/journal"
 	"github.com/bkidd1/wash-cli/internal/services/notes"
+	"github.com/bkidd1/wash-cli/internal/services/plans"
+	"github.com/bkidd1/wash-cli/internal/services/usage"
+	"github.com/bkidd1/wash-cli/internal/services/workspace"
 	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/language"
 	"github.com/sashabaranov/go-openai"
 
 	"github.com/spf13/cobra"
@@ -55,19 +62,21 @@ func Command() *cobra.Command {
 	cmd.Flags().IntVar(&cfg.RetryDelay, "retry-delay", defaultRetryDelay, "Delay between retries in milliseconds")
 	cmd.Flags().StringP("date", "d", "", "Date to show summary for (YYYY-MM-DD)")
 	cmd.Flags().StringP("project", "p", "", "Project name to show summary for")
+	cmd.Flags().StringP("workspace", "w", "", "Summarize every repository in a workspace")
+	cmd.Flags().Bool("weekly", false, "Summarize the seven days ending on the date instead of a single day")
 
 	return cmd
 }
 
 // generateSummaryWithRetry generates a summary for all notes with retry logic
-func generateSummaryWithRetry(client *openai.Client, notes []*notes.ProjectProgressNote, cfg Config) (string, error) {
+func generateSummaryWithRetry(client *openai.Client, notes []*notes.ProjectProgressNote, languages string, cfg Config) (string, error) {
 	var lastErr error
 	for attempt := 0; attempt < cfg.MaxRetries; attempt++ {
 		if attempt > 0 {
 			time.Sleep(time.Duration(cfg.RetryDelay) * time.Millisecond)
 		}
 
-		summary, err := generateSummary(client, notes)
+		summary, err := generateSummary(client, notes, languages)
 		if err == nil {
 			return summary, nil
 		}
@@ -86,7 +95,22 @@ func generateSummaryWithRetry(client *openai.Client, notes []*notes.ProjectProgr
 }
 
 // generateSummary generates a summary for all notes
-func generateSummary(client *openai.Client, notes []*notes.ProjectProgressNote) (string, error) {
+func generateSummary(client *openai.Client, notes []*notes.ProjectProgressNote, languages string) (string, error) {
+	systemPrompt := summaryPrompt
+	if languages != "" {
+		systemPrompt += "\n\nThe project uses:\n" + languages
+	}
+
+	// Notes from several workspace repositories are attributed to their repository
+	repos := make(map[string]bool)
+	for _, note := range notes {
+		repos[note.ProjectName] = true
+	}
+	multiRepo := len(repos) > 1
+	if multiRepo {
+		systemPrompt += "\n\nThese notes span several repositories in one workspace. Attribute each point to its repository by name."
+	}
+
 	var prompt strings.Builder
 	prompt.WriteString("Summarize these progress notes concisely:\n\n")
 
@@ -96,6 +120,9 @@ func generateSummary(client *openai.Client, notes []*notes.ProjectProgressNote)
 	})
 
 	for _, note := range notes {
+		if multiRepo {
+			prompt.WriteString(fmt.Sprintf("[%s] ", note.ProjectName))
+		}
 		prompt.WriteString(fmt.Sprintf("%s: %s\n", note.Timestamp.Format("15:04"), note.Title))
 		prompt.WriteString(fmt.Sprintf("%s\n", note.Description))
 		if len(note.Changes.FilesModified) > 0 {
@@ -111,7 +138,7 @@ func generateSummary(client *openai.Client, notes []*notes.ProjectProgressNote)
 			Messages: []openai.ChatCompletionMessage{
 				{
 					Role:    openai.ChatMessageRoleSystem,
-					Content: summaryPrompt,
+					Content: systemPrompt,
 				},
 				{
 					Role:    openai.ChatMessageRoleUser,
@@ -160,18 +187,36 @@ func runSummary(cmd *cobra.Command, args []string) error {
 
 	dateStr, _ := cmd.Flags().GetString("date")
 	projectName, _ := cmd.Flags().GetString("project")
+	weekly, _ := cmd.Flags().GetBool("weekly")
+	workspaceName, _ := cmd.Flags().GetString("workspace")
 
-	// If no project name provided, use current directory name
-	if projectName == "" {
-		cwd, err := os.Getwd()
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+
+	// Summarize either one project or every repository in a workspace
+	var repos []workspace.Repo
+	if workspaceName != "" {
+		workspaceManager, err := workspace.NewWorkspaceManager()
+		if err != nil {
+			return fmt.Errorf("failed to create workspace manager: %w", err)
+		}
+		ws, err := workspaceManager.LoadWorkspace(workspaceName)
 		if err != nil {
-			return fmt.Errorf("failed to get current directory: %w", err)
+			return err
+		}
+		repos = ws.Repos
+		projectName = "workspace " + ws.Name
+	} else {
+		// If no project name provided, use current directory name
+		if projectName == "" {
+			projectName = filepath.Base(cwd)
 		}
-		projectName = filepath.Base(cwd)
+		repos = []workspace.Repo{{Name: projectName, Path: cwd}}
 	}
 
 	var targetDate time.Time
-	var err error
 	if dateStr != "" {
 		targetDate, err = time.Parse("2006-01-02", dateStr)
 		if err != nil {
@@ -181,28 +226,45 @@ func runSummary(cmd *cobra.Command, args []string) error {
 		targetDate = time.Now()
 	}
 
+	// Summarize the target date, or the week ending on it
+	periodEnd := time.Date(targetDate.Year(), targetDate.Month(), targetDate.Day(), 0, 0, 0, 0, targetDate.Location()).AddDate(0, 0, 1)
+	periodStart := periodEnd.AddDate(0, 0, -1)
+	periodLabel := targetDate.Format("2006-01-02")
+	if weekly {
+		periodStart = periodEnd.AddDate(0, 0, -7)
+		periodLabel = fmt.Sprintf("week of %s to %s", periodStart.Format("2006-01-02"), targetDate.Format("2006-01-02"))
+	}
+
 	// Get progress notes
 	notesManager, err := notes.NewNotesManager()
 	if err != nil {
 		return fmt.Errorf("failed to initialize notes manager: %w", err)
 	}
-	progressNotes, err := notesManager.GetProgressNotes(projectName)
+	planManager, err := plans.NewPlanManager()
 	if err != nil {
-		return fmt.Errorf("failed to get progress notes: %w", err)
+		return fmt.Errorf("failed to create plan manager: %w", err)
 	}
 
-	// Filter notes for target date
 	var targetNotes []*notes.ProjectProgressNote
-	for _, note := range progressNotes {
-		if note.Timestamp.Year() == targetDate.Year() &&
-			note.Timestamp.Month() == targetDate.Month() &&
-			note.Timestamp.Day() == targetDate.Day() {
-			targetNotes = append(targetNotes, note)
+	var planUpdates []plans.StepUpdate
+	for _, repo := range repos {
+		// Update plan progress before summarizing so completed steps show up in the notes
+		updates, err := planManager.SyncProgress(notesManager, repo.Name, repo.Path)
+		if err != nil {
+			fmt.Printf("Warning: could not update plan progress for %s: %v\n", repo.Name, err)
 		}
+		planUpdates = append(planUpdates, updates...)
+
+		repoNotes, err := notesManager.GetProgressNotesBetween(repo.Name, periodStart, periodEnd)
+		if err != nil {
+			return fmt.Errorf("failed to get progress notes: %w", err)
+		}
+		targetNotes = append(targetNotes, repoNotes...)
 	}
 
 	if len(targetNotes) == 0 {
-		fmt.Printf("No progress notes found for project %s on %s\n", projectName, targetDate.Format("2006-01-02"))
+		fmt.Printf("No progress notes found for project %s on %s\n", projectName, periodLabel)
+		printPlanStatus(planManager, repos, planUpdates, weekly)
 		return nil
 	}
 
@@ -213,19 +275,87 @@ func runSummary(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create OpenAI client with config key
-	client := openai.NewClient(config.OpenAIKey)
+	client := usage.NewClient(config.OpenAIKey)
+
+	// Detect project languages for the summary prompt
+	var languages string
+	var repoLanguages []string
+	for _, repo := range repos {
+		if profile, err := language.Load(repo.Name, repo.Path); err == nil {
+			repoLanguages = append(repoLanguages, fmt.Sprintf("%s: %s", repo.Name, strings.ReplaceAll(profile.String(), "\n", "; ")))
+			languages = profile.String()
+		}
+	}
+	if len(repos) > 1 {
+		languages = strings.Join(repoLanguages, "\n")
+	}
 
 	// Generate summary
 	fmt.Println("Generating summary...")
-	summary, err := generateSummaryWithRetry(client, targetNotes, cfg)
+	summary, err := generateSummaryWithRetry(client, targetNotes, languages, cfg)
 	if err != nil {
 		return fmt.Errorf("failed to generate summary: %w", err)
 	}
 
 	// Print the summary
-	fmt.Printf("\nProgress Summary for %s - %s\n", projectName, targetDate.Format("2006-01-02"))
+	fmt.Printf("\nProgress Summary for %s - %s\n", projectName, periodLabel)
 	fmt.Println("------------------------")
 	fmt.Println(summary)
 
+	journal.Record(journal.ActionAnalysis, projectName, "summary", periodLabel)
+
+	// Keep the latest summary of a project for wash snapshot
+	if workspaceName == "" {
+		if findingsManager, err := findings.NewFindingsManager(); err == nil {
+			if err := findingsManager.RecordSummary(projectName, &findings.Summary{Period: periodLabel, Text: summary, GeneratedAt: time.Now()}); err != nil {
+				fmt.Printf("Warning: %v\n", err)
+			}
+		}
+	}
+	hooks.Notify(config, hooks.SummaryGenerated, projectName, hooks.Summary{Period: periodLabel, Summary: summary, Notes: len(targetNotes)})
+
+	printPlanStatus(planManager, repos, planUpdates, weekly)
+
 	return nil
 }
+
+// printPlanStatus prints plan steps completed automatically and, in the weekly digest, reminders
+// for stalled plans
+func printPlanStatus(planManager *plans.PlanManager, repos []workspace.Repo, updates []plans.StepUpdate, weekly bool) {
+	if len(updates) > 0 {
+		fmt.Println("\nPlan Progress")
+		fmt.Println("------------------------")
+		for _, update := range updates {
+			fmt.Printf("Plan %s (%s): step %d done (%s)\n", update.Plan.ID, update.Plan.ProjectName, update.Step.Number, update.Step.Title)
+			if update.Plan.Status == plans.StatusDone {
+				fmt.Printf("Plan %s is complete: %s\n", update.Plan.ID, update.Plan.Goal)
+			}
+		}
+	}
+
+	if !weekly {
+		return
+	}
+	var stalled []*plans.Plan
+	for _, repo := range repos {
+		repoStalled, err := planManager.StalledPlans(repo.Name, plans.StallThreshold)
+		if err != nil {
+			continue
+		}
+		stalled = append(stalled, repoStalled...)
+	}
+	if len(stalled) == 0 {
+		return
+	}
+
+	fmt.Println("\nStalled Plans")
+	fmt.Println("------------------------")
+	for _, plan := range stalled {
+		days := int(time.Since(plan.UpdatedAt).Hours() / 24)
+		fmt.Printf("Plan %s (%s) has had no progress for %d days (%d/%d steps): %s\n",
+			plan.ID, plan.ProjectName, days, plan.CompletedSteps(), len(plan.Steps), plan.Goal)
+		if next := plan.NextStep(); next != nil {
+			fmt.Printf("  Next step: %d. %s\n", next.Number, next.Title)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package tags
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bkidd1/wash-cli/internal/services/notes"
+	"github.com/spf13/cobra"
+)
+
+// Command creates the tags command
+func Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tags",
+		Short: "List, rename and apply note tags",
+		Long: `Manage the tags on remember notes, progress notes, monitor notes and
+interactions. Tags are matched without regard to case.
+
+Remember and progress notes are identified by their ID, or any unique prefix
+of it; monitor notes and interactions by <project>/<file name>, as listed by
+'wash tags list <tag>'.
+
+Examples:
+  # Show every tag and how many notes carry it
+  wash tags list
+
+  # Show the notes tagged auth
+  wash tags list auth
+
+  # Merge the "authn" tag into "auth"
+  wash tags rename authn auth
+
+  # Tag a note
+  wash tags apply security 3f9c2a1b`,
+	}
+
+	cmd.AddCommand(listCmd())
+	cmd.AddCommand(renameCmd())
+	cmd.AddCommand(applyCmd())
+
+	return cmd
+}
+
+func listCmd() *cobra.Command {
+	var projectName string
+
+	cmd := &cobra.Command{
+		Use:   "list [tag]",
+		Short: "List tags, or the notes carrying one",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			notesManager, err := notes.NewNotesManager()
+			if err != nil {
+				return fmt.Errorf("failed to create notes manager: %w", err)
+			}
+
+			if len(args) == 1 {
+				tagged, err := notesManager.NotesWithTag(projectName, args[0])
+				if err != nil {
+					return fmt.Errorf("failed to list notes: %w", err)
+				}
+				if len(tagged) == 0 {
+					fmt.Printf("No notes are tagged %s\n", args[0])
+					return nil
+				}
+				for _, note := range tagged {
+					fmt.Printf("%-10s %-40s %s\n", note.Kind, note.ID, strings.Join(note.Tags, ", "))
+				}
+				return nil
+			}
+
+			counts, err := notesManager.ListTags(projectName)
+			if err != nil {
+				return fmt.Errorf("failed to list tags: %w", err)
+			}
+			if len(counts) == 0 {
+				fmt.Println("No tags found")
+				return nil
+			}
+			for _, count := range counts {
+				var parts []string
+				for _, kind := range notes.TagKinds {
+					if n := count.ByKind[kind]; n > 0 {
+						parts = append(parts, fmt.Sprintf("%d %s", n, kind))
+					}
+				}
+				fmt.Printf("%-24s %5d  (%s)\n", count.Tag, count.Total, strings.Join(parts, ", "))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&projectName, "project", "p", "", "Only count this project's notes")
+
+	return cmd
+}
+
+func renameCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rename <old> <new>",
+		Short: "Rename a tag on every note",
+		Long: `Rename a tag on every note carrying it. Renaming onto an existing tag merges
+the two, leaving one copy on notes that had both.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			notesManager, err := notes.NewNotesManager()
+			if err != nil {
+				return fmt.Errorf("failed to create notes manager: %w", err)
+			}
+
+			renamed, err := notesManager.RenameTag(args[0], args[1])
+			if err != nil {
+				return fmt.Errorf("failed to rename tag: %w", err)
+			}
+			if renamed == 0 {
+				fmt.Printf("No notes are tagged %s\n", args[0])
+				return nil
+			}
+			fmt.Printf("Renamed %s to %s on %d notes\n", args[0], args[1], renamed)
+			return nil
+		},
+	}
+}
+
+func applyCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "apply <tag> <note-id>",
+		Short: "Add a tag to a note",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			notesManager, err := notes.NewNotesManager()
+			if err != nil {
+				return fmt.Errorf("failed to create notes manager: %w", err)
+			}
+
+			note, err := notesManager.ApplyTag(args[0], args[1])
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Tagged %s note %s: %s\n", note.Kind, note.ID, strings.Join(note.Tags, ", "))
+			return nil
+		},
+	}
+}
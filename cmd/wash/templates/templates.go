@@ -0,0 +1,134 @@
+package templates
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bkidd1/wash-cli/internal/utils/templates"
+	"github.com/spf13/cobra"
+)
+
+// Command creates the templates command
+func Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "templates",
+		Short: "Customize the markdown templates for bug reports and notes",
+		Long: `Customize how wash renders bug reports, as wash bug saves them, and progress
+notes, as wash notes browse shows them.
+
+Templates use Go text/template syntax and live in ~/.wash/templates as
+[name].md.tmpl. A template there replaces the built-in one, so you can match
+your team's ticket format or add extra fields. Available functions: join,
+upper, lower, date, datetime and env.
+
+Examples:
+  # List templates and whether they are customized
+  wash templates list
+
+  # Copy the built-in bug report template to ~/.wash/templates for editing
+  wash templates init bug_report
+
+  # Print the template currently in use
+  wash templates show bug_report`,
+	}
+
+	cmd.AddCommand(listCmd())
+	cmd.AddCommand(initCmd())
+	cmd.AddCommand(showCmd())
+
+	return cmd
+}
+
+func listCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List templates",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, name := range templates.Names() {
+				path, err := templates.Path(name)
+				if err != nil {
+					return err
+				}
+
+				source := "built-in"
+				if _, err := os.Stat(path); err == nil {
+					source = path
+				}
+				fmt.Printf("%-15s %s\n", name, source)
+			}
+			return nil
+		},
+	}
+}
+
+func initCmd() *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "init [name]",
+		Short: "Copy built-in templates to ~/.wash/templates for editing",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			names := templates.Names()
+			if len(args) == 1 {
+				names = args
+			}
+
+			for _, name := range names {
+				text, err := templates.Default(name)
+				if err != nil {
+					return err
+				}
+
+				path, err := templates.Path(name)
+				if err != nil {
+					return err
+				}
+
+				if _, err := os.Stat(path); err == nil && !force {
+					fmt.Printf("Skipping %s: %s already exists (use --force to overwrite)\n", name, path)
+					continue
+				}
+
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					return fmt.Errorf("failed to create templates directory: %w", err)
+				}
+				if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+					return fmt.Errorf("failed to write template: %w", err)
+				}
+				fmt.Printf("Wrote %s\n", path)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&force, "force", false, "Overwrite existing templates")
+
+	return cmd
+}
+
+func showCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show [name]",
+		Short: "Print the template in use",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			text, err := templates.Default(args[0])
+			if err != nil {
+				return err
+			}
+
+			path, err := templates.Path(args[0])
+			if err != nil {
+				return err
+			}
+			if data, err := os.ReadFile(path); err == nil {
+				text = string(data)
+			}
+
+			fmt.Print(text)
+			return nil
+		},
+	}
+}
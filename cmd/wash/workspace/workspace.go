@@ -0,0 +1,220 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/bkidd1/wash-cli/internal/services/workspace"
+	"github.com/spf13/cobra"
+)
+
+// Command creates the workspace command
+func Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "workspace",
+		Short: "Group related repositories into a workspace",
+		Long: `Define a workspace of related repositories that are developed together.
+
+Monitoring and summaries can span every repository in a workspace, with each
+note and report item attributed to its repository. Workspaces are stored in
+~/.wash/workspaces/.
+
+Examples:
+  # Create a workspace from the current directory and a sibling repo
+  wash workspace create shop . ../shop-api
+
+  # Add another repository
+  wash workspace add shop ../shop-web
+
+  # Monitor or summarize the whole workspace
+  wash monitor --workspace shop
+  wash summary --workspace shop`,
+	}
+
+	cmd.AddCommand(createCmd())
+	cmd.AddCommand(addCmd())
+	cmd.AddCommand(removeCmd())
+	cmd.AddCommand(listCmd())
+	cmd.AddCommand(showCmd())
+	cmd.AddCommand(deleteCmd())
+
+	return cmd
+}
+
+func createCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "create [name] [paths...]",
+		Short: "Create a workspace (defaults to the current directory)",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceManager, err := workspace.NewWorkspaceManager()
+			if err != nil {
+				return fmt.Errorf("failed to create workspace manager: %w", err)
+			}
+
+			if _, err := workspaceManager.LoadWorkspace(args[0]); err == nil {
+				return fmt.Errorf("workspace %s already exists", args[0])
+			}
+
+			paths := args[1:]
+			if len(paths) == 0 {
+				cwd, err := os.Getwd()
+				if err != nil {
+					return fmt.Errorf("failed to get current directory: %w", err)
+				}
+				paths = []string{cwd}
+			}
+
+			ws := &workspace.Workspace{Name: args[0]}
+			for _, path := range paths {
+				if _, err := ws.AddRepo(path); err != nil {
+					return err
+				}
+			}
+
+			if err := workspaceManager.SaveWorkspace(ws); err != nil {
+				return fmt.Errorf("failed to save workspace: %w", err)
+			}
+
+			fmt.Printf("Created workspace %s with %d repositories\n", ws.Name, len(ws.Repos))
+			return nil
+		},
+	}
+}
+
+func addCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "add [name] [paths...]",
+		Short: "Add repositories to a workspace",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceManager, err := workspace.NewWorkspaceManager()
+			if err != nil {
+				return fmt.Errorf("failed to create workspace manager: %w", err)
+			}
+
+			ws, err := workspaceManager.LoadWorkspace(args[0])
+			if err != nil {
+				return err
+			}
+
+			for _, path := range args[1:] {
+				repo, err := ws.AddRepo(path)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("Added %s (%s)\n", repo.Name, repo.Path)
+			}
+
+			if err := workspaceManager.SaveWorkspace(ws); err != nil {
+				return fmt.Errorf("failed to save workspace: %w", err)
+			}
+			return nil
+		},
+	}
+}
+
+func removeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove [name] [repo]",
+		Short: "Remove a repository from a workspace",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceManager, err := workspace.NewWorkspaceManager()
+			if err != nil {
+				return fmt.Errorf("failed to create workspace manager: %w", err)
+			}
+
+			ws, err := workspaceManager.LoadWorkspace(args[0])
+			if err != nil {
+				return err
+			}
+
+			if err := ws.RemoveRepo(args[1]); err != nil {
+				return err
+			}
+
+			if err := workspaceManager.SaveWorkspace(ws); err != nil {
+				return fmt.Errorf("failed to save workspace: %w", err)
+			}
+
+			fmt.Printf("Removed %s from workspace %s\n", args[1], ws.Name)
+			return nil
+		},
+	}
+}
+
+func listCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List workspaces",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceManager, err := workspace.NewWorkspaceManager()
+			if err != nil {
+				return fmt.Errorf("failed to create workspace manager: %w", err)
+			}
+
+			workspaces, err := workspaceManager.ListWorkspaces()
+			if err != nil {
+				return fmt.Errorf("failed to list workspaces: %w", err)
+			}
+
+			if len(workspaces) == 0 {
+				fmt.Println("No workspaces defined")
+				return nil
+			}
+
+			for _, ws := range workspaces {
+				fmt.Printf("%s  %d repositories\n", ws.Name, len(ws.Repos))
+			}
+			return nil
+		},
+	}
+}
+
+func showCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show [name]",
+		Short: "Show the repositories in a workspace",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceManager, err := workspace.NewWorkspaceManager()
+			if err != nil {
+				return fmt.Errorf("failed to create workspace manager: %w", err)
+			}
+
+			ws, err := workspaceManager.LoadWorkspace(args[0])
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Workspace %s\n", ws.Name)
+			fmt.Println("------------------------")
+			for _, repo := range ws.Repos {
+				fmt.Printf("%s  %s\n", repo.Name, repo.Path)
+			}
+			return nil
+		},
+	}
+}
+
+func deleteCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete [name]",
+		Short: "Delete a workspace (repository notes are kept)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceManager, err := workspace.NewWorkspaceManager()
+			if err != nil {
+				return fmt.Errorf("failed to create workspace manager: %w", err)
+			}
+
+			if err := workspaceManager.DeleteWorkspace(args[0]); err != nil {
+				return err
+			}
+
+			fmt.Printf("Deleted workspace %s\n", args[0])
+			return nil
+		},
+	}
+}
@@ -0,0 +1,273 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/api"
+	"github.com/bkidd1/wash-cli/internal/services/monitor"
+	"github.com/bkidd1/wash-cli/internal/services/notes"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/crypt"
+	"github.com/bkidd1/wash-cli/internal/utils/ignore"
+	"github.com/bkidd1/wash-cli/internal/utils/impact"
+)
+
+const (
+	// DefaultFlushInterval is how often queued notes are retried, and the longest a change is
+	// collected before it is sent even though files keep changing
+	DefaultFlushInterval = 30 * time.Second
+	// DefaultChangeWindow is how long files must stay unchanged for the changes before to count as
+	// one change, such as a multi-file edit applied by an AI agent
+	DefaultChangeWindow = 2 * time.Second
+	// maxQueuedNotes caps the notes kept while the laptop is unreachable
+	maxQueuedNotes = 500
+)
+
+// Agent watches a project on a remote machine and pushes file activity to a wash monitor's local API
+type Agent struct {
+	client        *api.Client
+	projectName   string
+	projectPath   string
+	hostname      string
+	flushInterval time.Duration
+	changeWindow  time.Duration
+	watcher       *monitor.Monitor
+	changed       map[string]bool
+	changeStarted time.Time
+	renames       []notes.FileRename
+	queue         []*notes.MonitorNote
+	queuePath     string
+	cipher        *crypt.Cipher // nil when encryption at rest is off
+	stopChan      chan struct{}
+	doneChan      chan struct{}
+}
+
+// NewAgent creates an agent for the project at projectPath
+func NewAgent(client *api.Client, projectName string, projectPath string) (*Agent, error) {
+	absPath, err := filepath.Abs(projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve project path: %w", err)
+	}
+
+	watcher, err := monitor.NewMonitor([]string{absPath})
+	if err != nil {
+		return nil, err
+	}
+
+	patterns, err := ignore.LoadGitignorePatterns(absPath)
+	if err != nil {
+		patterns = ignore.DefaultIgnorePatterns
+	}
+	watcher.SetIgnorePatterns(patterns)
+	watcher.SetIgnoreLoader(func() ([]string, error) { return ignore.LoadGitignorePatterns(absPath) })
+
+	// Notes queued before a reboot or crash are delivered first
+	path, err := queuePath(projectName)
+	if err != nil {
+		return nil, err
+	}
+	// Queued notes are encrypted at rest like the notes they become
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	cipher, err := crypt.Load(cfg.Encryption, dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unlock the agent queue: %w", err)
+	}
+	queue, err := loadQueue(path, cipher)
+	if err != nil {
+		return nil, err
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "remote"
+	}
+
+	return &Agent{
+		client:        client,
+		projectName:   projectName,
+		projectPath:   absPath,
+		hostname:      hostname,
+		flushInterval: DefaultFlushInterval,
+		changeWindow:  DefaultChangeWindow,
+		watcher:       watcher,
+		changed:       make(map[string]bool),
+		queue:         queue,
+		queuePath:     path,
+		cipher:        cipher,
+		stopChan:      make(chan struct{}),
+		doneChan:      make(chan struct{}),
+	}, nil
+}
+
+// Queued returns the number of notes waiting to be pushed
+func (a *Agent) Queued() int {
+	return len(a.queue)
+}
+
+// Start begins watching the project and pushing notes
+func (a *Agent) Start() error {
+	if err := a.watcher.Start(); err != nil {
+		return fmt.Errorf("failed to watch project: %w", err)
+	}
+
+	go a.loop()
+	return nil
+}
+
+// Stop pushes any pending changes and stops the agent
+func (a *Agent) Stop() {
+	close(a.stopChan)
+	<-a.doneChan
+	a.watcher.Stop()
+}
+
+func (a *Agent) loop() {
+	defer close(a.doneChan)
+
+	ticker := time.NewTicker(a.flushInterval)
+	defer ticker.Stop()
+
+	// settle fires once files have stopped changing for the change window
+	settle := time.NewTimer(a.changeWindow)
+	settle.Stop()
+	defer settle.Stop()
+
+	// Deliver notes left over from a previous run without waiting for the first tick
+	if len(a.queue) > 0 {
+		a.flush()
+	}
+
+	for {
+		select {
+		case <-a.stopChan:
+			a.flush()
+			return
+		case event := <-a.watcher.Events():
+			if len(a.changed) == 0 {
+				a.changeStarted = time.Now()
+			}
+			a.record(event)
+			settle.Reset(a.changeWindow)
+		case <-settle.C:
+			a.flush()
+		case <-ticker.C:
+			// A change still in progress is only cut when files have kept changing for a whole interval
+			if len(a.changed) > 0 && time.Since(a.changeStarted) < a.flushInterval {
+				a.deliver()
+			} else {
+				a.flush()
+			}
+		}
+	}
+}
+
+// flush turns the collected changes into one monitor note and delivers queued notes in order. The
+// queue is saved before and after delivery, so a note pushed just before a crash may be delivered
+// twice but none is lost.
+func (a *Agent) flush() {
+	if len(a.changed) > 0 {
+		a.queue = append(a.queue, a.noteForChanges())
+		a.changed = make(map[string]bool)
+		a.renames = nil
+		if len(a.queue) > maxQueuedNotes {
+			a.queue = a.queue[len(a.queue)-maxQueuedNotes:]
+		}
+		if err := a.saveQueue(); err != nil {
+			fmt.Printf("\nWarning: %v\n", err)
+		}
+	}
+	a.deliver()
+}
+
+// deliver pushes queued notes in order until one fails
+func (a *Agent) deliver() {
+	dirty := false
+	for len(a.queue) > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		err := a.client.PushMonitorNote(ctx, a.queue[0])
+		cancel()
+		if err != nil {
+			fmt.Printf("\nWarning: could not push note, will retry: %v\n", err)
+			break
+		}
+		a.queue = a.queue[1:]
+		dirty = true
+	}
+
+	if dirty {
+		if err := a.saveQueue(); err != nil {
+			fmt.Printf("\nWarning: %v\n", err)
+		}
+	}
+}
+
+// record adds a file event to the batched changes; a rename replaces the old path with the new one
+// and is kept, so history can follow the file
+func (a *Agent) record(event monitor.Event) {
+	rel, err := filepath.Rel(a.projectPath, event.Path)
+	if err != nil {
+		return
+	}
+	rel = filepath.ToSlash(rel)
+	a.changed[rel] = true
+
+	if event.OldPath == "" {
+		return
+	}
+	if oldRel, err := filepath.Rel(a.projectPath, event.OldPath); err == nil {
+		oldRel = filepath.ToSlash(oldRel)
+		delete(a.changed, oldRel)
+		a.renames = append(a.renames, notes.FileRename{From: oldRel, To: rel, At: event.Timestamp})
+	}
+}
+
+// affectedFiles returns the Go files that import the changed Go files, directly or through other
+// packages, so the note says what else a change may break
+func (a *Agent) affectedFiles(changed []string) []string {
+	if !slices.ContainsFunc(changed, func(file string) bool { return strings.HasSuffix(file, ".go") }) {
+		return nil
+	}
+	graph, err := impact.Load(a.projectPath)
+	if err != nil {
+		fmt.Printf("\nWarning: could not read Go imports: %v\n", err)
+		return nil
+	}
+	return graph.Affected(changed, impact.DefaultLimit)
+}
+
+// noteForChanges builds a monitor note describing the batched file changes
+func (a *Agent) noteForChanges() *notes.MonitorNote {
+	files := make([]string, 0, len(a.changed))
+	for file := range a.changed {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	note := &notes.MonitorNote{
+		Timestamp:   time.Now(),
+		ProjectName: a.projectName,
+	}
+	note.Interaction.AIAction = fmt.Sprintf("Edited %d files on %s", len(files), a.hostname)
+	if len(a.renames) > 0 {
+		note.Interaction.AIAction = fmt.Sprintf("Edited %d files, renaming %d, on %s", len(files), len(a.renames), a.hostname)
+	}
+	note.Interaction.Context = fmt.Sprintf("Remote agent on %s watching %s", a.hostname, a.projectPath)
+	note.Interaction.CodeChanges = files
+	note.Interaction.AffectedFiles = a.affectedFiles(files)
+	note.Renames = a.renames
+	return note
+}
@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/api"
+	"github.com/bkidd1/wash-cli/internal/services/notes"
+)
+
+func TestMultiFileEditIsOneChange(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	pushed := make(chan *notes.MonitorNote, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var note notes.MonitorNote
+		json.NewDecoder(r.Body).Decode(&note)
+		pushed <- &note
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	a, err := NewAgent(api.NewClient(server.URL, ""), "demo", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.changeWindow = 300 * time.Millisecond
+	if err := a.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer a.Stop()
+
+	// An edit spread over several files, with short pauses between them
+	for _, name := range []string{"a.go", "b.go", "c.go"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("package main\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	select {
+	case note := <-pushed:
+		if got := strings.Join(note.Interaction.CodeChanges, " "); got != "a.go b.go c.go" {
+			t.Errorf("Expected one change with all files, got %q", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the change")
+	}
+	select {
+	case note := <-pushed:
+		t.Errorf("Expected a single note, got another with %v", note.Interaction.CodeChanges)
+	case <-time.After(500 * time.Millisecond):
+	}
+}
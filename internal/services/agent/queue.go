@@ -0,0 +1,70 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bkidd1/wash-cli/internal/services/notes"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/crypt"
+	"github.com/bkidd1/wash-cli/internal/utils/fsutil"
+)
+
+// queueFileName holds the notes an agent has not delivered yet, so they survive a reboot
+const queueFileName = "agent_queue.json"
+
+// queuePath returns where the undelivered notes of a project are kept
+func queuePath(projectName string) (string, error) {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "projects", projectName, queueFileName), nil
+}
+
+// loadQueue reads the notes left undelivered by a previous run
+func loadQueue(path string, cipher *crypt.Cipher) ([]*notes.MonitorNote, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading agent queue: %w", err)
+	}
+	if data, err = cipher.Decrypt(data); err != nil {
+		return nil, fmt.Errorf("error decrypting agent queue: %w", err)
+	}
+
+	var queue []*notes.MonitorNote
+	if err := json.Unmarshal(data, &queue); err != nil {
+		return nil, fmt.Errorf("error parsing agent queue %s: %w", path, err)
+	}
+	return queue, nil
+}
+
+// saveQueue replaces the saved queue with the notes still to deliver; an empty queue removes the file
+func (a *Agent) saveQueue() error {
+	if len(a.queue) == 0 {
+		if err := os.Remove(a.queuePath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error clearing agent queue: %w", err)
+		}
+		return nil
+	}
+
+	data, err := json.Marshal(a.queue)
+	if err != nil {
+		return fmt.Errorf("error encoding agent queue: %w", err)
+	}
+	if data, err = a.cipher.Encrypt(data); err != nil {
+		return fmt.Errorf("error encrypting agent queue: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(a.queuePath), 0755); err != nil {
+		return fmt.Errorf("error creating project directory: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(a.queuePath, data, 0600); err != nil {
+		return fmt.Errorf("error saving agent queue: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bkidd1/wash-cli/internal/services/api"
+	"github.com/bkidd1/wash-cli/internal/utils/crypt"
+)
+
+func TestQueueSurvivesRestart(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	var reachable atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !reachable.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+	client := api.NewClient(server.URL, "")
+
+	first, err := NewAgent(client, "demo", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.changed["main.go"] = true
+	first.flush()
+	if first.Queued() != 1 {
+		t.Fatalf("Expected the undelivered note to stay queued, got %d", first.Queued())
+	}
+
+	// A new agent, as after a reboot, picks up the saved queue and delivers it
+	second, err := NewAgent(client, "demo", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Queued() != 1 || second.queue[0].Interaction.CodeChanges[0] != "main.go" {
+		t.Fatalf("Expected the queued note to be restored, got %d notes", second.Queued())
+	}
+
+	reachable.Store(true)
+	second.flush()
+	if second.Queued() != 0 {
+		t.Errorf("Expected the queue to be delivered, got %d notes", second.Queued())
+	}
+	if _, err := os.Stat(second.queuePath); !os.IsNotExist(err) {
+		t.Errorf("Expected the saved queue to be removed once delivered, got %v", err)
+	}
+}
+
+func TestQueueIsEncrypted(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("WASH_ENCRYPTION", crypt.SourcePassphrase)
+	t.Setenv(crypt.PassphraseEnv, "correct horse")
+
+	client := api.NewClient("http://127.0.0.1:1", "")
+	first, err := NewAgent(client, "demo", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.changed["main.go"] = true
+	first.flush()
+
+	data, err := os.ReadFile(first.queuePath)
+	if err != nil {
+		t.Fatalf("Expected a saved queue: %v", err)
+	}
+	if !crypt.IsEncrypted(data) {
+		t.Error("Expected the saved queue to be encrypted")
+	}
+
+	second, err := NewAgent(client, "demo", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Queued() != 1 {
+		t.Errorf("Expected the encrypted queue to be restored, got %d notes", second.Queued())
+	}
+}
@@ -3,16 +3,33 @@ package analyzer
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
-	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
-	"github.com/bkidd1/wash-cli/internal/utils/ignore"
+	"github.com/bkidd1/wash-cli/internal/services/retrieval"
+	"github.com/bkidd1/wash-cli/internal/services/usage"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/tokens"
 	"github.com/sashabaranov/go-openai"
 )
 
 const (
+	// analysisModel is the default model for terminal analyses
+	analysisModel = openai.GPT4
+	// projectStructureMaxTokens is the completion limit for project structure analysis
+	projectStructureMaxTokens = 4000
+	// maxContextNotes is how many remember notes are included in a prompt
+	maxContextNotes = 5
+	// maxContextLessons is how many lessons from resolved bugs are included in a prompt
+	maxContextLessons = 3
+	// rememberNotesTokenReserve is the prompt space kept free for remember notes
+	rememberNotesTokenReserve = 500
+	// maxAttachmentTokens caps how much of each attached file is sent with a bug analysis
+	maxAttachmentTokens = 2000
+
 	terminalSystemPrompt = "You are an expert software architect and project manager serving as an intermediary between a human developer and their AI coding agent. Your role is to:\n\n" +
 		"1. Analyze code and interactions with an expert developer's perspective\n" +
 		"2. Identify potential issues and improvements objectively\n" +
@@ -41,246 +58,576 @@ const (
 		"   Documentation improvements\n" +
 		"   Minor refactoring opportunities\n\n" +
 		"Limit yourself to one \"Could Fix\" per response.\n\n" +
-		"Start each response with 'You can copy this analysis into your chat window!'\n\n" +
 		"For each issue identified, provide a concise and clear description of the problem. Phrase responses in the form of a question. Structure each issue as a 1-2 sentence paragraph.\n\n" +
-		"Sometimes the code will already be optimal. Remember that changing things always risks being unneeded and potentially harmful/overly complex. You must decide which issues are actually issues and which are not. If no issues are found at a particular priority level, say \"No issues found\". Don't print any response for subcriteria if you find no issue.\n\n" +
-		"DO NOT include any introductory text, summaries, or conclusions. Start directly with the priority levels and their issues."
+		"Sometimes the code will already be optimal. Remember that changing things always risks being unneeded and potentially harmful/overly complex. You must decide which issues are actually issues and which are not. If no issues are found at a particular priority level, leave that level empty.\n\n" +
+		"DO NOT include any introductory text, summaries, or conclusions. Report your findings only through the report_code_analysis function."
 )
 
 // TerminalAnalyzer represents a code analyzer that returns formatted terminal output
 type TerminalAnalyzer struct {
 	client        *openai.Client
+	model         string
 	projectGoal   string
 	rememberNotes []string
+	pinnedNotes   []string
+	lessons       []string
+	languages     string
+	formatting    string
+	gitContext    string
+	attachments   []BugAttachment
+	sources       []BugAttachment
+	retriever     *retrieval.Retriever
+
+	fallbackModels []string
+	lastProvenance *Provenance
+	lastAnalysis   *Analysis
+
+	// stream receives analyses as they are generated when set; see SetStream
+	stream      io.Writer
+	streamBegin func()
+	streamed    bool
+
+	// cache answers identical requests without the model when set; see SetCache
+	cache    *Cache
+	cachedAt time.Time
+
+	// suppress leaves known findings out of analyses when set; see SetSuppress
+	suppress   func(severity, finding string) bool
+	suppressed int
+
+	// progress reports the requests of multi-request analyses; see SetProgress
+	progress func(done, total int, label string)
+	// workers is how many requests of a multi-request analysis are sent at once; see SetWorkers
+	workers int
+
+	// projectName, noteScopes and noteTimes describe the notes for context explanations
+	projectName string
+	noteScopes  map[string]string
+	noteTimes   map[string]time.Time
+	lastContext *ContextSelection
 }
 
 // NewTerminalAnalyzer creates a new terminal analyzer
 func NewTerminalAnalyzer(apiKey string, projectGoal string, rememberNotes []string) *TerminalAnalyzer {
-	client := openai.NewClient(apiKey)
+	client := usage.NewClient(apiKey)
 
 	// Create wash directory if it doesn't exist
-	washDir := filepath.Join(os.Getenv("HOME"), ".wash")
-	if err := os.MkdirAll(washDir, 0755); err != nil {
-		fmt.Printf("Warning: Could not create wash directory: %v\n", err)
+	if washDir, err := config.DataDir(); err == nil {
+		if err := os.MkdirAll(washDir, 0755); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Could not create wash directory: %v\n", err)
+		}
 	}
 
 	return &TerminalAnalyzer{
 		client:        client,
+		model:         analysisModel,
 		projectGoal:   projectGoal,
 		rememberNotes: rememberNotes,
 	}
 }
 
+// Clone returns an analyzer with the same model, client, project context and cache, for running
+// analyses in parallel: the clone's analyses do not change this analyzer's last analysis, stream,
+// suppressions or progress
+func (a *TerminalAnalyzer) Clone() *TerminalAnalyzer {
+	clone := *a
+	clone.lastProvenance, clone.lastAnalysis, clone.lastContext = nil, nil, nil
+	clone.stream, clone.streamBegin, clone.streamed = nil, nil, false
+	clone.cachedAt = time.Time{}
+	clone.suppress, clone.suppressed = nil, 0
+	clone.progress = nil
+	return &clone
+}
+
+// SetModel overrides the analysis model; an empty model keeps the default
+func (a *TerminalAnalyzer) SetModel(model string) {
+	if model != "" {
+		a.model = model
+	}
+}
+
 // UpdateProjectContext updates the project goal
 func (a *TerminalAnalyzer) UpdateProjectContext(projectGoal string) {
 	a.projectGoal = projectGoal
 }
 
-// getContextualPrompt returns the system prompt with project context
-func (a *TerminalAnalyzer) getContextualPrompt() string {
-	var context strings.Builder
+// SetLanguageProfile sets the detected project languages included in every prompt
+func (a *TerminalAnalyzer) SetLanguageProfile(languages string) {
+	a.languages = languages
+}
 
-	// Add the system prompt
-	context.WriteString(terminalSystemPrompt)
-	context.WriteString("\n\n")
+// SetFormattingConventions sets the project formatting rules that suggested code must follow
+func (a *TerminalAnalyzer) SetFormattingConventions(formatting string) {
+	a.formatting = formatting
+}
 
-	// Add project goal
-	context.WriteString(fmt.Sprintf("PROJECT GOAL:\n%s\n\n", a.projectGoal))
+// SetGitContext sets the repository state included in bug analyses, so causes can be tied to recent changes
+func (a *TerminalAnalyzer) SetGitContext(gitContext string) {
+	a.gitContext = gitContext
+}
 
-	return context.String()
+// BugAttachment is a text file attached to a bug report, sent with its analysis
+type BugAttachment struct {
+	Name    string
+	Content string
 }
 
-// AnalyzeFile analyzes a single file and returns formatted terminal output
-func (a *TerminalAnalyzer) AnalyzeFile(ctx context.Context, filePath string) (string, error) {
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return "", fmt.Errorf("error reading file: %w", err)
-	}
+// SetBugAttachments sets the files whose contents are sent with bug analyses
+func (a *TerminalAnalyzer) SetBugAttachments(attached []BugAttachment) {
+	a.attachments = attached
+}
 
-	// Split content into lines for tracking
-	lines := strings.Split(string(content), "\n")
-	totalLines := len(lines)
+// SetRelevantSources sets excerpts of project files that look related to the bug, sent with its
+// analysis so suggested solutions can refer to the actual code
+func (a *TerminalAnalyzer) SetRelevantSources(sources []BugAttachment) {
+	a.sources = sources
+}
 
-	// Try to analyze the entire file first
-	resp, err := a.client.CreateChatCompletion(
-		ctx,
-		openai.ChatCompletionRequest{
-			Model: openai.GPT4,
-			Messages: []openai.ChatCompletionMessage{
-				{
-					Role:    openai.ChatMessageRoleSystem,
-					Content: a.getContextualPrompt(),
-				},
-				{
-					Role:    openai.ChatMessageRoleUser,
-					Content: string(content),
-				},
-			},
-		},
-	)
-	if err != nil {
-		// Check if error is token limit related
-		if strings.Contains(err.Error(), "maximum context length") || strings.Contains(err.Error(), "resulted in") {
-			// Calculate approximate lines that fit within token limit
-			// Assuming average of 6 tokens per line and reserving 4000 tokens for system prompt and overhead
-			approxLines := (8192 - 4000) / 6 // GPT-4's context window is 8192 tokens
-
-			// Further reduce by 30% to be safe
-			approxLines = (approxLines * 7) / 10
-
-			// Ensure we don't exceed the number of lines
-			if approxLines > totalLines {
-				approxLines = totalLines
-			}
+// fileTokenShare is the prompt space each attached file and source excerpt gets: an equal share of
+// the space left, up to maxAttachmentTokens
+func (a *TerminalAnalyzer) fileTokenShare() int {
+	return min(maxAttachmentTokens, a.contentTokenBudget()/max(len(a.attachments)+len(a.sources), 1))
+}
 
-			// Get partial content
-			partialContent := strings.Join(lines[:approxLines], "\n")
-
-			// Try to analyze partial content
-			resp, err = a.client.CreateChatCompletion(
-				ctx,
-				openai.ChatCompletionRequest{
-					Model: openai.GPT4,
-					Messages: []openai.ChatCompletionMessage{
-						{
-							Role:    openai.ChatMessageRoleSystem,
-							Content: a.getContextualPrompt(),
-						},
-						{
-							Role:    openai.ChatMessageRoleUser,
-							Content: partialContent,
-						},
-					},
-				},
-			)
-			if err != nil {
-				return "", fmt.Errorf("error getting partial analysis: %w", err)
-			}
+// sourcesPrompt formats the relevant source excerpts for a bug analysis, keeping the start of each
+func (a *TerminalAnalyzer) sourcesPrompt() string {
+	if len(a.sources) == 0 {
+		return ""
+	}
+	budget := a.fileTokenShare()
+
+	var prompt strings.Builder
+	prompt.WriteString("\n\nRelevant source files from the project (refer to this code in the causes and solutions when it is involved):\n")
+	for _, source := range a.sources {
+		lines := strings.Split(strings.TrimRight(source.Content, "\n"), "\n")
+		kept := tokens.SplitLines(a.model, lines, budget)
+		prompt.WriteString(fmt.Sprintf("\n--- %s ---\n", source.Name))
+		prompt.WriteString(strings.Join(lines[:kept], "\n"))
+		prompt.WriteString("\n")
+	}
+	return prompt.String()
+}
 
-			// Format the response with partial analysis warning
-			analysis := fmt.Sprintf(`# Code Analysis (Partial)
-*Generated on %s*
+// attachmentsPrompt formats the attached files for a bug analysis. Each file gets an equal share of
+// the space left in the prompt, and long files keep their end, where logs report the failure.
+func (a *TerminalAnalyzer) attachmentsPrompt() string {
+	if len(a.attachments) == 0 {
+		return ""
+	}
+	budget := a.fileTokenShare()
+
+	var prompt strings.Builder
+	prompt.WriteString("\n\nAttached files (use them to find the root cause):\n")
+	for _, attached := range a.attachments {
+		lines := strings.Split(strings.TrimRight(attached.Content, "\n"), "\n")
+		reversed := make([]string, len(lines))
+		for i, line := range lines {
+			reversed[len(lines)-1-i] = line
+		}
+		kept := tokens.SplitLines(a.model, reversed, budget)
 
-⚠️  File is too large for complete analysis. Analyzed lines 1-%d of %d.
+		prompt.WriteString(fmt.Sprintf("\n--- %s", attached.Name))
+		if kept < len(lines) {
+			prompt.WriteString(fmt.Sprintf(" (last %d of %d lines)", kept, len(lines)))
+		}
+		prompt.WriteString(" ---\n")
+		prompt.WriteString(strings.Join(lines[len(lines)-kept:], "\n"))
+		prompt.WriteString("\n")
+	}
+	return prompt.String()
+}
 
-%s
+// SetRetriever enables semantic selection of the remember notes included in prompts
+func (a *TerminalAnalyzer) SetRetriever(retriever *retrieval.Retriever) {
+	a.retriever = retriever
+}
 
-Would you like to analyze the remaining lines? (y/n)`,
-				time.Now().Format(time.RFC3339),
-				approxLines,
-				totalLines,
-				resp.Choices[0].Message.Content)
+// RememberSource supplies the global and project scoped remember notes for a project
+type RememberSource interface {
+	ForProject(projectName string) (global, project []string, err error)
+}
 
-			return analysis, nil
+// UseRememberNotes replaces the remember notes with those the source holds for a project
+func (a *TerminalAnalyzer) UseRememberNotes(source RememberSource, projectName string) error {
+	global, project, err := source.ForProject(projectName)
+	if err != nil {
+		return fmt.Errorf("error loading remember notes: %w", err)
+	}
+	a.SetRememberNotes(global, project)
+	a.projectName = projectName
+
+	// Record when notes were saved, so context explanations can show their age
+	if timed, ok := source.(NoteTimeSource); ok {
+		if times, err := timed.SavedTimes(projectName); err == nil {
+			a.noteTimes = times
 		}
-		return "", fmt.Errorf("error getting analysis: %w", err)
 	}
+	return nil
+}
 
-	// Format the response with priority levels
-	analysis := fmt.Sprintf(`# Code Analysis
-*Generated on %s*
+// PinSource supplies the notes pinned into every analysis of a project
+type PinSource interface {
+	PinnedNotes(projectName string) ([]string, error)
+}
 
-%s`, time.Now().Format(time.RFC3339), resp.Choices[0].Message.Content)
+// UsePinnedNotes includes the notes the source has pinned for a project in every prompt
+func (a *TerminalAnalyzer) UsePinnedNotes(source PinSource, projectName string) error {
+	pinned, err := source.PinnedNotes(projectName)
+	if err != nil {
+		return fmt.Errorf("error loading pinned notes: %w", err)
+	}
+	a.pinnedNotes = pinned
+	a.projectName = projectName
+	return nil
+}
 
-	return analysis, nil
+// LessonSource supplies the lessons learned from a project's resolved bugs, newest first
+type LessonSource interface {
+	LessonTexts(projectName string) ([]string, error)
 }
 
-// AnalyzeProjectStructure analyzes the project structure and returns formatted terminal output
-func (a *TerminalAnalyzer) AnalyzeProjectStructure(ctx context.Context, projectPath string) (string, error) {
-	// Load ignore patterns from .gitignore and default patterns
-	ignorePatterns, err := ignore.LoadGitignorePatterns(projectPath)
+// UseLessons considers the lessons of a project's resolved bugs alongside the remember notes
+func (a *TerminalAnalyzer) UseLessons(source LessonSource, projectName string) error {
+	lessons, err := source.LessonTexts(projectName)
 	if err != nil {
-		return "", fmt.Errorf("error loading ignore patterns: %w", err)
+		return fmt.Errorf("error loading lessons from resolved bugs: %w", err)
 	}
+	a.lessons = lessons
+	a.projectName = projectName
+	return nil
+}
 
-	// Get list of files in the project
-	fileList := &strings.Builder{}
-	fileCount := 0
-	maxFiles := 100 // Limit the number of files to prevent token limit issues
+// SetRememberNotes replaces the remember notes with the merge of global and project scoped notes
+func (a *TerminalAnalyzer) SetRememberNotes(global, project []string) {
+	a.rememberNotes = MergeRememberNotes(global, project)
+	// Project notes are set last, so a note saved in both scopes counts as a project note
+	a.noteScopes = make(map[string]string)
+	for _, note := range global {
+		a.noteScopes[strings.TrimSpace(note)] = "global"
+	}
+	for _, note := range project {
+		a.noteScopes[strings.TrimSpace(note)] = "project"
+	}
+}
 
-	err = filepath.Walk(projectPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
+// MergeRememberNotes combines global and project scoped notes: project notes first, since they are
+// the most specific, then global notes, each in the order given and without duplicates
+func MergeRememberNotes(global, project []string) []string {
+	seen := make(map[string]bool)
+	merged := make([]string, 0, len(global)+len(project))
+	for _, scope := range [][]string{project, global} {
+		for _, note := range scope {
+			note = strings.TrimSpace(note)
+			key := strings.ToLower(note)
+			if note == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			merged = append(merged, note)
 		}
+	}
+	return merged
+}
 
-		// Get relative path for ignore pattern matching
-		relPath, err := filepath.Rel(projectPath, path)
-		if err != nil {
-			return err
+// relevantNotes returns the remember notes most relevant to the query, in their merged order,
+// followed by the most relevant lessons from resolved bugs. Without a retriever, or with only
+// a few notes, all notes are returned. The selection and the reasons for it are kept for
+// LastContext and appended to the context log.
+func (a *TerminalAnalyzer) relevantNotes(ctx context.Context, query string) []string {
+	selection := &ContextSelection{Timestamp: time.Now(), Project: a.projectName, Query: query}
+	for _, note := range a.pinnedNotes {
+		selection.Notes = append(selection.Notes, ContextNote{Text: note, Source: SourcePinned, Included: true, Reason: "included in every prompt"})
+	}
+	defer func() {
+		a.lastContext = selection
+		// The log is for debugging only and must never fail an analysis
+		_ = LogContextSelection(selection)
+	}()
+
+	lessons := a.relevantLessons(ctx, query, selection)
+
+	reason := fmt.Sprintf("all %d remember notes fit in the prompt", len(a.rememberNotes))
+	if a.retriever != nil && len(a.rememberNotes) > maxContextNotes {
+		// Rank every note so the ones left out can be explained too
+		results, err := a.retriever.TopK(ctx, query, a.rememberNotes, 0)
+		if err == nil {
+			return append(a.rankedNotes(selection, results), lessons...)
 		}
+		fmt.Fprintf(os.Stderr, "Warning: Could not rank remember notes, including all of them: %v\n", err)
+		reason = "included because ranking failed"
+	}
 
-		// Skip ignored paths
-		if ignore.ShouldIgnore(relPath, ignorePatterns) {
-			if info.IsDir() {
-				return filepath.SkipDir
+	for _, note := range a.rememberNotes {
+		selection.Notes = append(selection.Notes, a.contextNote(note, true, reason))
+	}
+	return append(slices.Clip(a.rememberNotes), lessons...)
+}
+
+// relevantLessons returns the lessons from resolved bugs most similar to the query, or the
+// newest ones without a retriever, and records the choice in the selection
+func (a *TerminalAnalyzer) relevantLessons(ctx context.Context, query string, selection *ContextSelection) []string {
+	if len(a.lessons) == 0 {
+		return nil
+	}
+
+	ranks := make(map[string]int)
+	scores := make(map[string]float64)
+	reason := fmt.Sprintf("among the %d newest lessons", maxContextLessons)
+	if a.retriever != nil && len(a.lessons) > maxContextLessons {
+		if results, err := a.retriever.TopK(ctx, query, a.lessons, maxContextLessons); err == nil {
+			for i, result := range results {
+				ranks[result.Text] = i + 1
+				scores[result.Text] = result.Score
 			}
-			return nil
+			reason = fmt.Sprintf("among the %d most similar lessons", maxContextLessons)
+		} else {
+			fmt.Fprintf(os.Stderr, "Warning: Could not rank lessons from resolved bugs, including the newest: %v\n", err)
 		}
+	}
 
-		if !info.IsDir() {
-			// Skip binary files and other non-text files
-			if strings.HasSuffix(path, ".exe") || strings.HasSuffix(path, ".dll") ||
-				strings.HasSuffix(path, ".so") || strings.HasSuffix(path, ".dylib") ||
-				strings.HasSuffix(path, ".bin") || strings.HasSuffix(path, ".dat") {
-				return nil
-			}
+	var included []string
+	for i, lesson := range a.lessons {
+		rank, ranked := ranks[lesson]
+		include := ranked || (len(ranks) == 0 && i < maxContextLessons)
+		noteReason := reason
+		if !include {
+			noteReason = fmt.Sprintf("only %d lessons are included", maxContextLessons)
+		}
+		selection.Notes = append(selection.Notes, ContextNote{Text: lesson, Source: SourceLesson, Rank: rank, Score: scores[lesson], Included: include, Reason: noteReason})
+		if include {
+			included = append(included, lesson)
+		}
+	}
+	return included
+}
+
+// rankedNotes records the ranking of the remember notes and returns the top ones, in their merged order
+func (a *TerminalAnalyzer) rankedNotes(selection *ContextSelection, results []retrieval.Result) []string {
+	ranks := make(map[string]int, len(results))
+	scores := make(map[string]float64, len(results))
+	for i, result := range results {
+		ranks[result.Text] = i + 1
+		scores[result.Text] = result.Score
+	}
+
+	// Keep the merged order so the prompt does not change with ranking ties
+	notes := make([]string, 0, maxContextNotes)
+	for _, note := range a.rememberNotes {
+		rank, ok := ranks[note]
+		included := ok && rank <= maxContextNotes
+		reason := fmt.Sprintf("among the %d most similar to the request", maxContextNotes)
+		if !ok {
+			reason = "could not be ranked"
+		} else if !included {
+			reason = fmt.Sprintf("only the %d most similar notes are included", maxContextNotes)
+		}
+		contextNote := a.contextNote(note, included, reason)
+		contextNote.Rank, contextNote.Score = rank, scores[note]
+		selection.Notes = append(selection.Notes, contextNote)
+		if included {
+			notes = append(notes, note)
+		}
+	}
+	return notes
+}
+
+// contextNote describes a remember note for a context selection
+func (a *TerminalAnalyzer) contextNote(note string, included bool, reason string) ContextNote {
+	contextNote := ContextNote{Text: note, Source: SourceRemember, Scope: a.noteScopes[note], Included: included, Reason: reason}
+	if savedAt, ok := a.noteTimes[note]; ok {
+		contextNote.SavedAt = &savedAt
+	}
+	return contextNote
+}
 
-			fileList.WriteString(relPath + "\n")
-			fileCount++
+// LastAnalysis returns the findings of the most recent file, content, project or chat analysis, or nil before one
+func (a *TerminalAnalyzer) LastAnalysis() *Analysis {
+	return a.lastAnalysis
+}
 
-			// Stop after reaching max files
-			if fileCount >= maxFiles {
-				return filepath.SkipAll
+// SetSuppress makes file and project analyses leave out the findings for which known returns true,
+// such as those accepted into a baseline; nil reports every finding. known is given the JSON name
+// of the finding's list, "critical_issues", "should_fix" or "could_fix", as its severity.
+func (a *TerminalAnalyzer) SetSuppress(known func(severity, finding string) bool) {
+	a.suppress = known
+}
+
+// Suppressed returns how many findings the last analysis left out as known
+func (a *TerminalAnalyzer) Suppressed() int {
+	return a.suppressed
+}
+
+// remove drops the findings for which known returns true and returns how many it dropped
+func (analysis *Analysis) remove(known func(severity, finding string) bool) int {
+	removed := 0
+	keep := func(severity string, findings []string) []string {
+		var kept []string
+		for _, finding := range findings {
+			if known(severity, strings.TrimSpace(finding)) {
+				removed++
+			} else {
+				kept = append(kept, finding)
 			}
 		}
-		return nil
-	})
+		return kept
+	}
+	analysis.CriticalIssues = keep("critical_issues", analysis.CriticalIssues)
+	analysis.ShouldFix = keep("should_fix", analysis.ShouldFix)
+	analysis.CouldFix = keep("could_fix", analysis.CouldFix)
+	return removed
+}
+
+// LastContext returns the notes considered for the most recent file or bug analysis, or nil before one
+func (a *TerminalAnalyzer) LastContext() *ContextSelection {
+	return a.lastContext
+}
+
+// rememberNotesPrompt formats remember notes as a reminders section for the system prompt
+func rememberNotesPrompt(notes []string) string {
+	if len(notes) == 0 {
+		return ""
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString("REMINDERS:\n")
+	for _, note := range notes {
+		prompt.WriteString(fmt.Sprintf("- %s\n", note))
+	}
+	return prompt.String()
+}
+
+// getContextualPrompt returns the system prompt with project context
+func (a *TerminalAnalyzer) getContextualPrompt() string {
+	var context strings.Builder
+
+	// Add the system prompt
+	context.WriteString(terminalSystemPrompt)
+	context.WriteString("\n\n")
+
+	// Add project goal
+	context.WriteString(fmt.Sprintf("PROJECT GOAL:\n%s\n\n", a.projectGoal))
+
+	// Add detected languages so suggestions follow the project's idioms
+	if a.languages != "" {
+		context.WriteString(fmt.Sprintf("PROJECT LANGUAGES:\n%s\nTailor all suggestions to the idioms of these languages and frameworks.\n\n", a.languages))
+	}
+
+	// Add formatting conventions so suggested code passes the project's style checks
+	if a.formatting != "" {
+		context.WriteString(fmt.Sprintf("FORMATTING CONVENTIONS:\n%s\nAny code you suggest must follow these conventions. Do not suggest purely stylistic changes that contradict them.\n\n", a.formatting))
+	}
+
+	// Add the notes the user pinned, such as resolved bugs and decision records
+	if len(a.pinnedNotes) > 0 {
+		context.WriteString("PINNED NOTES (past bugs, decisions and insights the user wants considered in every analysis):\n")
+		for _, note := range a.pinnedNotes {
+			context.WriteString(fmt.Sprintf("- %s\n", note))
+		}
+		context.WriteString("\n")
+	}
+
+	return context.String()
+}
+
+// ContentLineLimit returns how many of the leading lines fit in a single analysis request
+func (a *TerminalAnalyzer) ContentLineLimit(lines []string) int {
+	return tokens.SplitLines(a.model, lines, a.contentTokenBudget())
+}
+
+// contentTokenBudget returns the tokens left for content after the prompt and the reply
+func (a *TerminalAnalyzer) contentTokenBudget() int {
+	budget := tokens.ContextWindow(a.model) -
+		tokens.CountMessages(a.model, a.getContextualPrompt()) -
+		tokens.DefaultCompletionTokens -
+		functionSchemaTokens -
+		rememberNotesTokenReserve
+	if budget < 0 {
+		return 0
+	}
+	return budget
+}
+
+// EstimateFile estimates the size and cost of analyzing a file
+func (a *TerminalAnalyzer) EstimateFile(filePath string) (tokens.Estimate, error) {
+	content, err := os.ReadFile(filePath)
 	if err != nil {
-		return "", fmt.Errorf("error walking project directory: %w", err)
+		return tokens.Estimate{}, fmt.Errorf("error reading file: %w", err)
 	}
 
-	// Add a note if we hit the file limit
-	if fileCount >= maxFiles {
-		fileList.WriteString(fmt.Sprintf("\nNote: Only showing first %d files for analysis.\n", maxFiles))
+	lines := strings.Split(string(content), "\n")
+	analyzed := strings.Join(lines[:a.ChunkEnd(filePath, content, 0)], "\n")
+
+	prompt := tokens.CountMessages(a.model, a.getContextualPrompt(), analyzed) + functionSchemaTokens
+	return tokens.NewEstimate(a.model, prompt, tokens.DefaultCompletionTokens), nil
+}
+
+// AnalyzeFile analyzes a single file and returns formatted terminal output
+func (a *TerminalAnalyzer) AnalyzeFile(ctx context.Context, filePath string) (string, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf("error reading file: %w", err)
 	}
 
-	resp, err := a.client.CreateChatCompletion(
+	// Split content into lines and count how many fit in the context window, ending between declarations
+	lines := strings.Split(string(content), "\n")
+	totalLines := len(lines)
+	analyzedLines := a.ChunkEnd(filePath, content, 0)
+	analyzedContent := strings.Join(lines[:analyzedLines], "\n")
+
+	// Include only the remember notes relevant to this file
+	notesPrompt := rememberNotesPrompt(a.relevantNotes(ctx, filePath+"\n"+analyzedContent))
+
+	header := func() string {
+		if analyzedLines < totalLines {
+			return fmt.Sprintf("# Code Analysis (Partial)\n*Generated on %s*\n\n⚠️  File is too large for complete analysis. Analyzed lines 1-%d of %d.\n\n",
+				a.generated(), analyzedLines, totalLines)
+		}
+		return fmt.Sprintf("# Code Analysis\n*Generated on %s*\n\n", a.generated())
+	}
+	footer := ""
+	if analyzedLines < totalLines {
+		footer = "\n\nWould you like to analyze the remaining lines? (y/n)"
+	}
+
+	var result Analysis
+	err = a.completeStreamed(
 		ctx,
 		openai.ChatCompletionRequest{
-			Model: openai.GPT4,
+			Model: a.model,
 			Messages: []openai.ChatCompletionMessage{
 				{
 					Role:    openai.ChatMessageRoleSystem,
-					Content: a.getContextualPrompt() + "\n\nAs an expert project manager and architect, analyze the project structure, organization, and architecture. Focus on identifying potential issues that could impact project success, maintainability, and scalability. Format your response EXACTLY as follows:\n\n* Critical! Must Fix\n[list critical issues here]\n\n* Should Fix\n[list should fix issues here]\n\n* Could Fix\n[list could fix issues here]\n\nIMPORTANT: Do not include any other sections or text. If no issues are found at a priority level, say \"No issues found\". Never write 'No issues found' or similar messages.",
+					Content: a.getContextualPrompt() + notesPrompt,
 				},
 				{
 					Role:    openai.ChatMessageRoleUser,
-					Content: fmt.Sprintf("Project Structure:\n%s\n\nAnalyze this project structure and identify issues at each priority level.", fileList.String()),
+					Content: analyzedContent,
 				},
 			},
-			MaxTokens: 4000,
+			MaxTokens: tokens.DefaultCompletionTokens,
 		},
+		codeAnalysisFunction,
+		analysisLayout,
+		header,
+		&result,
 	)
 	if err != nil {
-		fmt.Printf("DEBUG: Error from OpenAI API: %v\n", err)
 		return "", fmt.Errorf("error getting analysis: %w", err)
 	}
+	a.lastAnalysis = &result
+	a.finishStream(footer)
 
-	// Format the response
-	analysis := fmt.Sprintf(`# Project Analysis
-*Generated on %s*
-
-%s`, time.Now().Format(time.RFC3339), resp.Choices[0].Message.Content)
-
-	return analysis, nil
+	// Format the response with priority levels, and the partial analysis warning if needed
+	return header() + formatAnalysis(&result) + footer, nil
 }
 
 // AnalyzeChat analyzes chat history and returns formatted terminal output
 func (a *TerminalAnalyzer) AnalyzeChat(ctx context.Context, chatHistory string) (string, error) {
-	resp, err := a.client.CreateChatCompletion(
+	var result Analysis
+	err := a.complete(
 		ctx,
 		openai.ChatCompletionRequest{
-			Model: openai.GPT4,
+			Model: a.model,
 			Messages: []openai.ChatCompletionMessage{
 				{
 					Role:    openai.ChatMessageRoleSystem,
@@ -292,26 +639,30 @@ func (a *TerminalAnalyzer) AnalyzeChat(ctx context.Context, chatHistory string)
 				},
 			},
 		},
+		codeAnalysisFunction,
+		&result,
 	)
 	if err != nil {
 		return "", fmt.Errorf("error getting analysis: %w", err)
 	}
+	a.lastAnalysis = &result
 
 	// Format the response with priority levels
 	analysis := fmt.Sprintf(`# Chat Analysis
 *Generated on %s*
 
-%s`, time.Now().Format(time.RFC3339), resp.Choices[0].Message.Content)
+%s`, a.generated(), formatAnalysis(&result))
 
 	return analysis, nil
 }
 
 // GetErrorFix analyzes chat history for specific error patterns and returns formatted terminal output
 func (a *TerminalAnalyzer) GetErrorFix(ctx context.Context, chatHistory string, errorType string) (string, error) {
-	resp, err := a.client.CreateChatCompletion(
+	var result Analysis
+	err := a.complete(
 		ctx,
 		openai.ChatCompletionRequest{
-			Model: openai.GPT4,
+			Model: a.model,
 			Messages: []openai.ChatCompletionMessage{
 				{
 					Role:    openai.ChatMessageRoleSystem,
@@ -323,6 +674,8 @@ func (a *TerminalAnalyzer) GetErrorFix(ctx context.Context, chatHistory string,
 				},
 			},
 		},
+		codeAnalysisFunction,
+		&result,
 	)
 	if err != nil {
 		return "", fmt.Errorf("error getting error fix: %w", err)
@@ -332,7 +685,7 @@ func (a *TerminalAnalyzer) GetErrorFix(ctx context.Context, chatHistory string,
 	analysis := fmt.Sprintf(`# Error Fix Analysis: %s
 *Generated on %s*
 
-%s`, errorType, time.Now().Format(time.RFC3339), resp.Choices[0].Message.Content)
+%s`, errorType, a.generated(), formatAnalysis(&result))
 
 	return analysis, nil
 }
@@ -350,88 +703,59 @@ func (a *TerminalAnalyzer) AnalyzeBug(ctx context.Context, description string) (
 	// Get project context from remember notes
 	contextPrompt := a.getContextualPrompt()
 
-	// Add remember notes to the context if they exist
-	if len(a.rememberNotes) > 0 {
+	// Add the remember notes most relevant to the bug to the context
+	if notes := a.relevantNotes(ctx, description); len(notes) > 0 {
 		contextPrompt += "\n\nCRITICAL: REMEMBER NOTES (MUST CONSIDER THESE FIRST IN YOUR ANALYSIS):\n"
-		for _, note := range a.rememberNotes {
+		for _, note := range notes {
 			contextPrompt += fmt.Sprintf("- %s\n", note)
 		}
 		contextPrompt += "\nWhen analyzing the bug, you MUST first check if any of these remember notes are relevant to the issue. If they are, they should be your primary consideration for both causes and solutions.\n\n"
 	}
 
-	// Create chat completion request
-	resp, err := a.client.CreateChatCompletion(
+	// Tie the bug to the changes being worked on
+	userPrompt := fmt.Sprintf("Bug description: %s", description)
+	if a.gitContext != "" {
+		userPrompt += "\n\nGit context when the bug was reported (consider whether these recent changes caused it):\n" + a.gitContext
+	}
+	userPrompt += a.attachmentsPrompt()
+	userPrompt += a.sourcesPrompt()
+
+	// Request a structured bug analysis
+	var result bugAnalysisResult
+	err := a.completeStreamed(
 		ctx,
 		openai.ChatCompletionRequest{
-			Model: "gpt-4",
+			Model: a.model,
 			Messages: []openai.ChatCompletionMessage{
 				{
 					Role:    openai.ChatMessageRoleSystem,
-					Content: contextPrompt + "\n\nFor bug analysis, you MUST format your response EXACTLY as follows:\n\n# Potential Causes\n[list potential causes here, prioritizing any relevant remember notes]\n\n# Suggested Solutions\n[list suggested solutions here, prioritizing any relevant remember notes]\n\nDo not include any other sections or text.",
+					Content: contextPrompt + "\n\nFor bug analysis, report the potential causes and suggested solutions through the report_bug_analysis function, prioritizing any relevant remember notes.",
 				},
 				{
 					Role:    openai.ChatMessageRoleUser,
-					Content: fmt.Sprintf("Bug description: %s", description),
+					Content: userPrompt,
 				},
 			},
 			MaxTokens: 1000,
 		},
+		bugAnalysisFunction,
+		bugLayout,
+		func() string { return "" },
+		&result,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to analyze bug: %w", err)
 	}
-
-	// Parse the response into sections
-	content := resp.Choices[0].Message.Content
-	sections := parseSections(content)
+	a.finishStream("")
 
 	return &BugAnalysis{
 		Analysis:           "",
-		PotentialCauses:    sections["Potential Causes"],
-		SuggestedSolutions: sections["Suggested Solutions"],
+		PotentialCauses:    formatList(result.PotentialCauses, "No potential causes identified"),
+		SuggestedSolutions: formatList(result.SuggestedSolutions, "No suggested solutions identified"),
 		RelatedContext:     "",
 	}, nil
 }
 
-// parseSections splits the AI response into sections
-func parseSections(content string) map[string]string {
-	sections := make(map[string]string)
-	lines := strings.Split(content, "\n")
-
-	currentSection := ""
-	var currentContent strings.Builder
-
-	for _, line := range lines {
-		if strings.HasPrefix(line, "#") {
-			// If we were building a previous section, save it
-			if currentSection != "" {
-				sections[strings.TrimSpace(currentSection)] = strings.TrimSpace(currentContent.String())
-				currentContent.Reset()
-			}
-			// Extract new section name (remove # and any leading/trailing spaces)
-			currentSection = strings.TrimSpace(strings.TrimPrefix(line, "#"))
-		} else if currentSection != "" {
-			// Add the line to the current section
-			currentContent.WriteString(line + "\n")
-		}
-	}
-
-	// Save the last section
-	if currentSection != "" {
-		sections[strings.TrimSpace(currentSection)] = strings.TrimSpace(currentContent.String())
-	}
-
-	// Ensure required sections exist with meaningful defaults
-	requiredSections := []string{"Potential Causes", "Suggested Solutions"}
-	for _, section := range requiredSections {
-		if content, exists := sections[section]; !exists || strings.TrimSpace(content) == "" {
-			sections[section] = fmt.Sprintf("No %s information provided by the analysis", strings.ToLower(section))
-		}
-	}
-
-	return sections
-}
-
 // GetProjectGoal returns the project goal
 func (a *TerminalAnalyzer) GetProjectGoal() string {
 	return a.projectGoal
@@ -444,10 +768,11 @@ func (a *TerminalAnalyzer) GetRememberNotes() []string {
 
 // AnalyzeContent analyzes specific content and returns formatted terminal output
 func (a *TerminalAnalyzer) AnalyzeContent(ctx context.Context, content string) (string, error) {
-	resp, err := a.client.CreateChatCompletion(
+	var result Analysis
+	err := a.complete(
 		ctx,
 		openai.ChatCompletionRequest{
-			Model: openai.GPT4,
+			Model: a.model,
 			Messages: []openai.ChatCompletionMessage{
 				{
 					Role:    openai.ChatMessageRoleSystem,
@@ -459,16 +784,19 @@ func (a *TerminalAnalyzer) AnalyzeContent(ctx context.Context, content string) (
 				},
 			},
 		},
+		codeAnalysisFunction,
+		&result,
 	)
 	if err != nil {
 		return "", fmt.Errorf("error getting analysis: %w", err)
 	}
+	a.lastAnalysis = &result
 
 	// Format the response with priority levels
 	analysis := fmt.Sprintf(`# Code Analysis
 *Generated on %s*
 
-%s`, time.Now().Format(time.RFC3339), resp.Choices[0].Message.Content)
+%s`, a.generated(), formatAnalysis(&result))
 
 	return analysis, nil
 }
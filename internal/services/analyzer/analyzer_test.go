@@ -1,7 +1,22 @@
 package analyzer
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/retrieval"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/crypt"
+	"github.com/bkidd1/wash-cli/internal/utils/tokens"
+	"github.com/sashabaranov/go-openai"
 )
 
 func TestNewTerminalAnalyzer(t *testing.T) {
@@ -40,3 +55,419 @@ func TestUpdateProjectContext(t *testing.T) {
 		t.Errorf("Expected remember notes to remain unchanged, got %v", analyzer.rememberNotes)
 	}
 }
+
+func TestFormatAnalysis(t *testing.T) {
+	result := formatAnalysis(&Analysis{
+		CriticalIssues: []string{"Is the API key logged?"},
+	})
+
+	if !strings.Contains(result, "* Critical! Must Fix\n- Is the API key logged?") {
+		t.Errorf("Expected critical issue to be listed, got %q", result)
+	}
+
+	// Empty levels must still render their section
+	if !strings.Contains(result, "* Should Fix\nNo issues found") || !strings.Contains(result, "* Could Fix\nNo issues found") {
+		t.Errorf("Expected empty sections to be reported, got %q", result)
+	}
+}
+
+func TestMergeRememberNotes(t *testing.T) {
+	merged := MergeRememberNotes(
+		[]string{"Prefer table-driven tests", "use gofmt", ""},
+		[]string{"Use gofmt", "API is versioned under /v2"},
+	)
+	want := []string{"Use gofmt", "API is versioned under /v2", "Prefer table-driven tests"}
+	if strings.Join(merged, "|") != strings.Join(want, "|") {
+		t.Errorf("Expected %v, got %v", want, merged)
+	}
+}
+
+func TestRankedNotesExplainSelection(t *testing.T) {
+	a := NewTerminalAnalyzer("test-key", "", nil)
+	notes := []string{"n1", "n2", "n3", "n4", "n5", "n6", "n7"}
+	a.SetRememberNotes(notes[:1], notes[1:])
+
+	var results []retrieval.Result
+	for i := len(notes) - 1; i >= 0; i-- {
+		results = append(results, retrieval.Result{Text: notes[i], Score: float64(i) / 10})
+	}
+	selection := &ContextSelection{}
+	selected := a.rankedNotes(selection, results)
+
+	if want := "n3|n4|n5|n6|n7"; strings.Join(selected, "|") != want {
+		t.Errorf("Expected %s, got %v", want, selected)
+	}
+	if len(selection.Notes) != len(notes) || len(selection.Included()) != maxContextNotes {
+		t.Fatalf("Expected every note recorded and %d included, got %+v", maxContextNotes, selection.Notes)
+	}
+	explanation := selection.Explain()
+	if !strings.Contains(explanation, "n7\n    project note, similarity 0.60 (rank 1)") || !strings.Contains(explanation, "Left out 2 notes") {
+		t.Errorf("Unexpected explanation:\n%s", explanation)
+	}
+}
+
+func TestLogContextSelectionKeepsOnlyIDs(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dataDir := t.TempDir()
+	t.Setenv(config.DataDirEnv, dataDir)
+
+	now := time.Now()
+	selection := &ContextSelection{Timestamp: now, Project: "demo", Query: "password = hunter2", Notes: []ContextNote{
+		{Text: "Deploy key is sk-live-1234", Source: SourceRemember, Score: 0.5, Rank: 1, Included: true, Reason: "most similar"},
+	}}
+	if err := LogContextSelection(selection); err != nil {
+		t.Fatalf("LogContextSelection failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dataDir, "context", now.Format(contextLogTimeLayout)+".jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "hunter2") || strings.Contains(string(data), "sk-live") {
+		t.Errorf("Expected neither the query nor the note text in the log:\n%s", data)
+	}
+	if !strings.Contains(string(data), NoteID("Deploy key is sk-live-1234")) || !strings.Contains(string(data), `"score":0.5`) {
+		t.Errorf("Expected the note's ID and score in the log:\n%s", data)
+	}
+	if selection.Query == "" || selection.Notes[0].Text == "" {
+		t.Error("Expected logging to leave the selection itself alone")
+	}
+}
+
+func TestAttachmentsPromptKeepsEndOfLongFiles(t *testing.T) {
+	a := NewTerminalAnalyzer("test-key", "", nil)
+	var lines []string
+	for i := 0; i < 5000; i++ {
+		lines = append(lines, fmt.Sprintf("request %d ok", i))
+	}
+	lines = append(lines, "panic: nil map write")
+	a.SetBugAttachments([]BugAttachment{
+		{Name: "server.log", Content: strings.Join(lines, "\n") + "\n"},
+		{Name: "config.yaml", Content: "port: 8080\n"},
+	})
+
+	prompt := a.attachmentsPrompt()
+	if !strings.Contains(prompt, "panic: nil map write") || strings.Contains(prompt, "request 0 ok") {
+		t.Errorf("Expected the end of the log to be kept")
+	}
+	if !strings.Contains(prompt, "--- server.log (last ") || !strings.Contains(prompt, "--- config.yaml ---\nport: 8080\n") {
+		t.Errorf("Unexpected attachment headers:\n%s", prompt[:200])
+	}
+}
+
+func TestCompleteFallsBackWhenModelIsOverQuota(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		switch req.Model {
+		case "gpt-4o":
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"error":{"message":"You exceeded your current quota","type":"insufficient_quota","code":"insufficient_quota"}}`)
+		case "gpt-4o-mini":
+			fmt.Fprint(w, `{"model":"gpt-4o-mini","choices":[{"message":{"role":"assistant","tool_calls":[{"type":"function","function":{"name":"report_code_analysis","arguments":"{\"critical_issues\":[],\"should_fix\":[],\"could_fix\":[]}"}}]}}]}`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":{"message":"bad request","type":"invalid_request_error"}}`)
+		}
+	}))
+	defer server.Close()
+
+	cfg := openai.DefaultConfig("test-key")
+	cfg.BaseURL = server.URL
+	analyzer := NewTerminalAnalyzer("test-key", "", nil)
+	analyzer.client = openai.NewClientWithConfig(cfg)
+	analyzer.SetModel("gpt-4o")
+	analyzer.SetFallbackModels([]string{"gpt-4o-mini"})
+
+	var result Analysis
+	if err := analyzer.complete(context.Background(), openai.ChatCompletionRequest{}, codeAnalysisFunction, &result); err != nil {
+		t.Fatalf("Expected the fallback model to answer, got %v", err)
+	}
+	provenance := analyzer.LastProvenance()
+	if provenance == nil || !provenance.Substituted() || provenance.Model != "gpt-4o-mini" {
+		t.Fatalf("Expected the substitution to be recorded, got %+v", provenance)
+	}
+	if !strings.Contains(provenance.String(), "gpt-4o is over quota") {
+		t.Errorf("Expected the reason in the provenance, got %q", provenance.String())
+	}
+
+	// Errors other than quota or availability are not retried with another model
+	analyzer.SetModel("bad-request")
+	if err := analyzer.complete(context.Background(), openai.ChatCompletionRequest{}, codeAnalysisFunction, &result); err == nil {
+		t.Error("Expected a bad request to fail without falling back")
+	}
+}
+
+func TestStreamedAnalysisMatchesFormattedOutput(t *testing.T) {
+	arguments := `{"critical_issues": ["Is the error from Close ignored?", " Does \"retry\" loop forever?\nIt never backs off."], "should_fix": [], "could_fix": ["Could parseé be renamed?"]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		// Send the arguments in small pieces that split escapes and keys
+		for i := 0; i < len(arguments); i += 7 {
+			piece, _ := json.Marshal(arguments[i:min(i+7, len(arguments))])
+			fmt.Fprintf(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"tool_calls\":[{\"index\":0,\"function\":{\"name\":\"report_code_analysis\",\"arguments\":%s}}]}}]}\n\n", piece)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	cfg := openai.DefaultConfig("test-key")
+	cfg.BaseURL = server.URL
+	analyzer := NewTerminalAnalyzer("test-key", "", nil)
+	analyzer.client = openai.NewClientWithConfig(cfg)
+
+	var out strings.Builder
+	began := false
+	analyzer.SetStream(&out, func() { began = true })
+
+	var result Analysis
+	header := func() string { return "# Code Analysis\n\n" }
+	err := analyzer.completeStreamed(context.Background(), openai.ChatCompletionRequest{}, codeAnalysisFunction, analysisLayout, header, &result)
+	if err != nil {
+		t.Fatalf("Streamed completion failed: %v", err)
+	}
+	analyzer.finishStream("")
+
+	if !began || !analyzer.Streamed() {
+		t.Fatal("Expected the analysis to be streamed")
+	}
+	if want := header() + formatAnalysis(&result) + "\n"; out.String() != want {
+		t.Errorf("Streamed output differs from the formatted analysis:\n%q\nwant\n%q", out.String(), want)
+	}
+
+	// Suppressed findings are left out of the stream as well as the result
+	out.Reset()
+	analyzer.SetSuppress(func(severity, finding string) bool { return severity == "could_fix" })
+	result = Analysis{}
+	if err := analyzer.completeStreamed(context.Background(), openai.ChatCompletionRequest{}, codeAnalysisFunction, analysisLayout, header, &result); err != nil {
+		t.Fatalf("Streamed completion failed: %v", err)
+	}
+	analyzer.finishStream("")
+	if analyzer.Suppressed() != 1 || len(result.CouldFix) != 0 {
+		t.Fatalf("Expected the Could Fix finding to be suppressed, got %d: %+v", analyzer.Suppressed(), result)
+	}
+	if want := header() + formatAnalysis(&result) + "\n"; out.String() != want {
+		t.Errorf("Streamed output with suppression differs from the formatted analysis:\n%q\nwant\n%q", out.String(), want)
+	}
+}
+
+func TestCachedAnalysisSkipsTheModel(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"model":"gpt-4o","choices":[{"message":{"role":"assistant","tool_calls":[{"type":"function","function":{"name":"report_code_analysis","arguments":"{\"critical_issues\":[\"Is the error from Close ignored?\"],\"should_fix\":[],\"could_fix\":[]}"}}]}}]}`)
+	}))
+	defer server.Close()
+
+	cfg := openai.DefaultConfig("test-key")
+	cfg.BaseURL = server.URL
+	analyzer := NewTerminalAnalyzer("test-key", "", nil)
+	analyzer.client = openai.NewClientWithConfig(cfg)
+	cache, err := NewCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+	analyzer.SetCache(cache)
+
+	header := func() string { return "" }
+	analyze := func(code string) Analysis {
+		req := openai.ChatCompletionRequest{Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: code}}}
+		var result Analysis
+		if err := analyzer.completeStreamed(context.Background(), req, codeAnalysisFunction, analysisLayout, header, &result); err != nil {
+			t.Fatalf("Analysis failed: %v", err)
+		}
+		return result
+	}
+
+	first := analyze("func main() {}")
+	second := analyze("func main() {}")
+	if calls != 1 || !analyzer.Cached() {
+		t.Fatalf("Expected the second analysis from the cache, got %d calls", calls)
+	}
+	if len(second.CriticalIssues) != 1 || second.CriticalIssues[0] != first.CriticalIssues[0] {
+		t.Errorf("Cached analysis differs: %+v, want %+v", second, first)
+	}
+
+	// Changed content and refreshes go to the model
+	analyze("func main() { os.Exit(1) }")
+	cache.Refresh = true
+	analyze("func main() {}")
+	if calls != 3 || analyzer.Cached() {
+		t.Errorf("Expected changed content and a refresh to call the model, got %d calls", calls)
+	}
+}
+
+func TestCacheIsEncrypted(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("WASH_ENCRYPTION", crypt.SourcePassphrase)
+	t.Setenv(crypt.PassphraseEnv, "correct horse")
+
+	cache, err := NewCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.put("abcdef", &Provenance{Model: "gpt-4o"}, Analysis{CriticalIssues: []string{"password = hunter2"}})
+
+	data, err := os.ReadFile(cache.path("abcdef"))
+	if err != nil {
+		t.Fatalf("Expected a stored answer: %v", err)
+	}
+	if !crypt.IsEncrypted(data) {
+		t.Error("Expected the stored answer to be encrypted")
+	}
+	if entry := cache.get("abcdef"); entry == nil || entry.Provenance.Model != "gpt-4o" {
+		t.Errorf("Expected the encrypted answer to be read back, got %+v", entry)
+	}
+}
+
+func TestCacheDropsExpiredAnswers(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	cache, err := NewCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cache.maxAge != DefaultCacheRetention {
+		t.Fatalf("Expected the default retention, got %v", cache.maxAge)
+	}
+	cache.put("abcdef", &Provenance{Model: "gpt-4o"}, Analysis{})
+	old := time.Now().Add(-DefaultCacheRetention - time.Hour)
+	if err := os.Chtimes(cache.path("abcdef"), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	// The next answer stored cleans the expired one, once a day
+	os.Remove(filepath.Join(cache.dir, cacheCleanMarker))
+	cache.put("123456", &Provenance{Model: "gpt-4o"}, Analysis{})
+	if _, err := os.Stat(cache.path("abcdef")); !os.IsNotExist(err) {
+		t.Errorf("Expected the expired answer deleted, got %v", err)
+	}
+	if entry := cache.get("123456"); entry == nil {
+		t.Error("Expected the new answer kept")
+	}
+
+	// Answers past their age are misses even before a clean
+	cache.maxAge = time.Nanosecond
+	if entry := cache.get("123456"); entry != nil {
+		t.Error("Expected an expired answer to be a miss")
+	}
+}
+
+func TestSplitProjectKeepsDirectoriesWithinBudget(t *testing.T) {
+	var files []string
+	for i := 0; i < 40; i++ {
+		files = append(files, fmt.Sprintf("cmd/tool%d/main.go", i))
+	}
+	for i := 0; i < 900; i++ {
+		files = append(files, fmt.Sprintf("internal/pkg%d/file%d.go", i/30, i))
+	}
+	files = append(files, "README.md", "go.mod")
+
+	if parts := splitProject("gpt-4o", files[:40], projectPartTokens); len(parts) != 1 {
+		t.Fatalf("Expected a small project in one part, got %d", len(parts))
+	}
+
+	parts := splitProject("gpt-4o", files, projectPartTokens)
+	if len(parts) < 3 {
+		t.Fatalf("Expected the project to be split, got %d parts", len(parts))
+	}
+	seen := 0
+	for _, part := range parts {
+		seen += len(part.files)
+		if size := tokens.Count("gpt-4o", strings.Join(part.files, "\n")); size > projectPartTokens {
+			t.Errorf("Part %s has %d tokens, over the budget", part.label, size)
+		}
+	}
+	if seen != len(files) {
+		t.Errorf("Expected every file in one part, got %d of %d", seen, len(files))
+	}
+	if parts[0].label != "cmd/tool0, cmd/tool1, cmd/tool2 and 37 more" {
+		t.Errorf("Unexpected label %q", parts[0].label)
+	}
+	if last := parts[len(parts)-1]; last.label != "(root)" && !strings.HasSuffix(last.label, "(root)") {
+		t.Errorf("Expected the root files in the last part, got %q", last.label)
+	}
+}
+
+func TestPartFindingsKeepsMostSevereWithinBudget(t *testing.T) {
+	parts := []projectPart{{label: "cmd", files: []string{"cmd/main.go"}}, {label: "internal", files: []string{"internal/a.go", "internal/b.go"}}}
+	var many []string
+	for i := 0; i < 50; i++ {
+		many = append(many, fmt.Sprintf("Minor naming issue number %d in the internal packages", i))
+	}
+	results := []Analysis{
+		{CriticalIssues: []string{"The CLI exits without flushing its logs"}},
+		{CriticalIssues: []string{"Two packages import each other"}, CouldFix: many},
+	}
+
+	findings := partFindings("gpt-4o", parts, results, 400)
+	for _, want := range []string{"## cmd (1 files)", "- Critical: The CLI exits without flushing its logs", "## internal (2 files)", "- Critical: Two packages import each other", "less severe findings left out"} {
+		if !strings.Contains(findings, want) {
+			t.Errorf("Expected findings to contain %q, got:\n%s", want, findings)
+		}
+	}
+	// The headings and the note on what was left out come on top of the budget
+	if size := tokens.Count("gpt-4o", findings); size > 400+100 {
+		t.Errorf("Expected findings within the budget, got %d tokens:\n%s", size, findings)
+	}
+
+	if all := partFindings("gpt-4o", parts, results, 100000); strings.Contains(all, "left out") || !strings.Contains(all, "number 49") {
+		t.Errorf("Expected every finding within a large budget, got:\n%s", all)
+	}
+}
+
+func TestSampleProjectShowsDeclarations(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":             "module example.com/app\n\ngo 1.22\n\nrequire github.com/spf13/cobra v1.8.0\n",
+		"store/store.go":     "// Package store persists orders.\npackage store\n\nimport \"os\"\n\n// Store saves orders to disk\ntype Store struct {\n\tdir string\n}\n\n// Save writes an order\nfunc (s *Store) Save(id string) error {\n\treturn os.WriteFile(s.dir+id, nil, 0644)\n}\n",
+		"scripts/deploy.py":  "import sys\n\nclass Deployer:\n    def run(self):\n        pass\n\ndef main():\n    Deployer().run()\n",
+		"docs/notes.txt":     "nothing to declare here\n",
+		"doc.go":             "// Package app wires the services together.\npackage app\n",
+		"config.go":          "package app\n\nconst apiKey = \"sk-live-secret\"\n\nvar timeout time.Duration = 30\n",
+		"docker-compose.yml": "services:\n  db:\n    environment:\n      - POSTGRES_PASSWORD=hunter2secret\n",
+	}
+	var paths []string
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, name)
+	}
+
+	samples := sampleProject("gpt-4o", root, paths, projectSampleTokens)
+	for _, want := range []string{"### go.mod", "const apiKey", "var timeout time.Duration", "POSTGRES_PASSWORD=", "require github.com/spf13/cobra", "// Package store persists orders.", "type Store struct", "func (s *Store) Save(id string) error", "class Deployer", "def main()", "// Package app wires the services together."} {
+		if !strings.Contains(samples, want) {
+			t.Errorf("Expected samples to contain %q, got:\n%s", want, samples)
+		}
+	}
+	for _, unwanted := range []string{"os.WriteFile", "def run", "notes.txt", "import \"os\"", "sk-live-secret", "hunter2secret", "= 30"} {
+		if strings.Contains(samples, unwanted) {
+			t.Errorf("Expected samples to leave out %q, got:\n%s", unwanted, samples)
+		}
+	}
+	if !strings.HasPrefix(samples, "### go.mod") && !strings.HasPrefix(samples, "### docker-compose.yml") {
+		t.Errorf("Expected configuration files first, got:\n%s", samples)
+	}
+
+	if small := sampleProject("gpt-4o", root, paths, 60); tokens.Count("gpt-4o", small) > 60 {
+		t.Errorf("Expected samples within the budget, got %d tokens", tokens.Count("gpt-4o", small))
+	}
+}
+
+func TestCleanMermaid(t *testing.T) {
+	got, err := cleanMermaid("```mermaid\nflowchart TD\n  cmd --> analyzer\n```")
+	if err != nil || got != "flowchart TD\n  cmd --> analyzer\n" {
+		t.Errorf("Expected the fences removed, got %q, %v", got, err)
+	}
+	if _, err := cleanMermaid("Here is the diagram: cmd uses analyzer"); err == nil {
+		t.Error("Expected prose to be rejected")
+	}
+}
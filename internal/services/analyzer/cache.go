@@ -0,0 +1,206 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/crypt"
+	"github.com/bkidd1/wash-cli/internal/utils/fsutil"
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	// cacheVersion is part of every cache key; bump it when prompts or the stored format change in a
+	// way the request itself does not show
+	cacheVersion = 1
+	// DefaultCacheRetention is how long answers are kept when retention.analysis_cache is not set
+	DefaultCacheRetention = 30 * 24 * time.Hour
+	// cacheCleanInterval is how often a cache in use deletes its expired answers
+	cacheCleanInterval = 24 * time.Hour
+	// cacheCleanMarker is rewritten each time the expired answers are deleted
+	cacheCleanMarker = ".cleaned"
+)
+
+// Cache stores analysis answers under ~/.wash/analyze, keyed on a hash of the whole request: the
+// analyzed content, the prompt with its goal and notes, the model and the response schema. Any
+// change to them is a miss. Entries are encrypted at rest along with the notes, and deleted once
+// they are older than retention.analysis_cache.
+type Cache struct {
+	dir    string
+	cipher *crypt.Cipher // nil when encryption at rest is off
+	// maxAge is how long answers are kept; zero keeps them forever
+	maxAge time.Duration
+	// Refresh skips stored answers but still stores new ones
+	Refresh bool
+}
+
+// cacheEntry is one stored answer
+type cacheEntry struct {
+	CreatedAt  time.Time       `json:"created_at"`
+	Provenance Provenance      `json:"provenance"`
+	Arguments  json.RawMessage `json:"arguments"`
+}
+
+// NewCache creates a cache in the data directory
+func NewCache() (*Cache, error) {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+
+	// Answers quote the analyzed code, so they are encrypted like the notes
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	cipher, err := crypt.Load(cfg.Encryption, dataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	maxAge := DefaultCacheRetention
+	if setting, ok := cfg.Retention["analysis_cache"]; ok {
+		if maxAge, err = config.ParseAge(setting); err != nil {
+			return nil, fmt.Errorf("invalid retention.analysis_cache: %w", err)
+		}
+	}
+	return &Cache{dir: filepath.Join(dataDir, "analyze"), cipher: cipher, maxAge: maxAge}, nil
+}
+
+// CacheDir returns the directory holding stored analysis answers
+func CacheDir() (string, error) {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "analyze"), nil
+}
+
+// CleanCache deletes the stored answers last written before cutoff and returns how many there
+// were and their size. With dryRun set, it only counts them.
+func CleanCache(cutoff time.Time, dryRun bool) (int, int64, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return 0, 0, err
+	}
+	return cleanCacheDir(dir, cutoff, dryRun)
+}
+
+// cleanCacheDir deletes the answers in dir written before cutoff, and the subdirectories it empties
+func cleanCacheDir(dir string, cutoff time.Time, dryRun bool) (int, int64, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*", "*.json"))
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list cached answers: %w", err)
+	}
+
+	deleted, size := 0, int64(0)
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if !dryRun {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return deleted, size, fmt.Errorf("failed to delete cached answer: %w", err)
+			}
+			// Fails while other answers remain in the subdirectory, which is fine
+			_ = os.Remove(filepath.Dir(path))
+		}
+		deleted++
+		size += info.Size()
+	}
+	return deleted, size, nil
+}
+
+// cleanDaily deletes expired answers at most once a cacheCleanInterval, so the cache cannot grow
+// without bound
+func (c *Cache) cleanDaily() {
+	if c.maxAge <= 0 {
+		return
+	}
+	marker := filepath.Join(c.dir, cacheCleanMarker)
+	if info, err := os.Stat(marker); err == nil && time.Since(info.ModTime()) < cacheCleanInterval {
+		return
+	}
+	if _, _, err := cleanCacheDir(c.dir, time.Now().Add(-c.maxAge), false); err == nil {
+		_ = os.WriteFile(marker, nil, 0644)
+	}
+}
+
+// SetCache makes file analyses reuse the stored answer to an identical request
+func (a *TerminalAnalyzer) SetCache(cache *Cache) {
+	a.cache = cache
+}
+
+// Cached reports whether the last analysis was answered from the cache
+func (a *TerminalAnalyzer) Cached() bool {
+	return !a.cachedAt.IsZero()
+}
+
+// cacheKey hashes everything that determines the answer to a request
+func cacheKey(model string, req openai.ChatCompletionRequest, fn openai.FunctionDefinition) string {
+	data, _ := json.Marshal(struct {
+		Version   int
+		Model     string
+		Messages  []openai.ChatCompletionMessage
+		MaxTokens int
+		Function  openai.FunctionDefinition
+	}{cacheVersion, model, req.Messages, req.MaxTokens, fn})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// path spreads entries over subdirectories named by the first byte of their key
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key+".json")
+}
+
+// get returns the stored answer for a key, or nil on a miss or when refreshing
+func (c *Cache) get(key string) *cacheEntry {
+	if c.Refresh {
+		return nil
+	}
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil
+	}
+	if data, err = c.cipher.Decrypt(data); err != nil {
+		return nil
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil
+	}
+	// An answer past its retention is a miss even before the cache is cleaned
+	if c.maxAge > 0 && time.Since(entry.CreatedAt) > c.maxAge {
+		return nil
+	}
+	return &entry
+}
+
+// put stores an answer; the cache must never fail the analysis, so errors are ignored
+func (c *Cache) put(key string, provenance *Provenance, out interface{}) {
+	arguments, err := json.Marshal(out)
+	if err != nil {
+		return
+	}
+	data, err := json.MarshalIndent(cacheEntry{CreatedAt: time.Now(), Provenance: *provenance, Arguments: arguments}, "", "  ")
+	if err != nil {
+		return
+	}
+	if data, err = c.cipher.Encrypt(data); err != nil {
+		return
+	}
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	_ = fsutil.WriteFileAtomic(path, data, 0644)
+	c.cleanDaily()
+}
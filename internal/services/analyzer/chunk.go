@@ -0,0 +1,158 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bkidd1/wash-cli/internal/utils/chunk"
+	"github.com/bkidd1/wash-cli/internal/utils/tokens"
+	"github.com/sashabaranov/go-openai"
+)
+
+// ChunkEnd returns where the analysis request for a file starting at line start (zero-based)
+// should end. Requests end between top-level declarations of Go, JavaScript and TypeScript files,
+// so no function or class is cut in half unless it alone exceeds the context window.
+func (a *TerminalAnalyzer) ChunkEnd(filePath string, content []byte, start int) int {
+	lines := strings.Split(string(content), "\n")
+	decls := chunk.Declarations(filePath, content)
+	return a.chunkEnd(lines, decls, start, continuationContext(filePath, lines, decls, start))
+}
+
+// chunkEnd fits the lines from start into the token budget left after the continuation context
+func (a *TerminalAnalyzer) chunkEnd(lines []string, decls []chunk.Declaration, start int, context string) int {
+	budget := a.contentTokenBudget() - tokens.Count(a.model, context)
+	limit := start + tokens.SplitLines(a.model, lines[start:], max(budget, 0))
+	if limit >= len(lines) {
+		return len(lines)
+	}
+	// Always make progress, even when a single line exceeds the budget
+	return max(chunk.Cut(decls, start, limit), start+1)
+}
+
+// continuationContext describes the lines before start for a request that continues a file: the
+// package clause and imports, and the declarations already analyzed. It is empty for the first request.
+func continuationContext(filePath string, lines []string, decls []chunk.Declaration, start int) string {
+	if start == 0 {
+		return ""
+	}
+	return fileContext(fmt.Sprintf("This continues the analysis of %s. Lines 1-%d were analyzed in an earlier request.", filepath.Base(filePath), start),
+		lines, decls, start)
+}
+
+// fileContext introduces a request covering part of a file with the package clause, imports and
+// declarations before start
+func fileContext(intro string, lines []string, decls []chunk.Declaration, start int) string {
+	var b strings.Builder
+	b.WriteString(intro)
+	b.WriteString(" Use the context below to understand the code, but only report issues in the lines to analyze.\n\n")
+	if header := min(chunk.Header(decls), start); header > 0 {
+		fmt.Fprintf(&b, "The file starts with:\n%s\n\n", strings.Join(lines[:header], "\n"))
+	}
+	if names := chunk.Names(decls, 0, start); len(names) > 0 {
+		fmt.Fprintf(&b, "Declared earlier in the file: %s\n\n", strings.Join(names, ", "))
+	}
+	return b.String()
+}
+
+// AnalyzeRange analyzes the lines from start to end (zero-based, end excluded) of a file, with the
+// file's imports and earlier declarations as context, and returns formatted terminal output. A
+// range too large for one request is cut short between declarations, with a note saying so.
+func (a *TerminalAnalyzer) AnalyzeRange(ctx context.Context, filePath string, start, end int) (string, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf("error reading file: %w", err)
+	}
+	lines := strings.Split(string(content), "\n")
+	if start < 0 || start >= end || end > len(lines) {
+		return "", fmt.Errorf("lines %d-%d are outside %s, which has %d lines", start+1, end, filepath.Base(filePath), len(lines))
+	}
+
+	decls := chunk.Declarations(filePath, content)
+	context := fileContext(fmt.Sprintf("This is part of %s: lines %d-%d of %d.", filepath.Base(filePath), start+1, end, len(lines)), lines, decls, start)
+	analyzedEnd := a.chunkEnd(lines[:end], decls, start, context)
+	analyzedContent := strings.Join(lines[start:analyzedEnd], "\n")
+
+	header := func() string {
+		text := fmt.Sprintf("# Code Analysis (Lines %d-%d of %d)\n*Generated on %s*\n\n", start+1, analyzedEnd, len(lines), a.generated())
+		if analyzedEnd < end {
+			text += fmt.Sprintf("⚠️  Range is too large for one request. Run with --lines %d-%d to analyze the rest.\n\n", analyzedEnd+1, end)
+		}
+		return text
+	}
+	result, err := a.analyzeWithContext(ctx, filePath, context, analyzedContent, header)
+	if err != nil {
+		return "", err
+	}
+	a.finishStream("")
+
+	return header() + formatAnalysis(result), nil
+}
+
+// analyzeWithContext analyzes part of a file, sending the context about the rest of it first. A
+// streamed analysis starts with header().
+func (a *TerminalAnalyzer) analyzeWithContext(ctx context.Context, filePath, context, content string, header func() string) (*Analysis, error) {
+	notesPrompt := rememberNotesPrompt(a.relevantNotes(ctx, filePath+"\n"+content))
+
+	var result Analysis
+	err := a.completeStreamed(
+		ctx,
+		openai.ChatCompletionRequest{
+			Model: a.model,
+			Messages: []openai.ChatCompletionMessage{
+				{
+					Role:    openai.ChatMessageRoleSystem,
+					Content: a.getContextualPrompt() + notesPrompt,
+				},
+				{
+					Role:    openai.ChatMessageRoleUser,
+					Content: context + "Lines to analyze:\n" + content,
+				},
+			},
+			MaxTokens: tokens.DefaultCompletionTokens,
+		},
+		codeAnalysisFunction,
+		analysisLayout,
+		header,
+		&result,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting analysis: %w", err)
+	}
+	a.lastAnalysis = &result
+	return &result, nil
+}
+
+// AnalyzeRemaining analyzes a file from line start (zero-based) on, continuing a partial
+// AnalyzeFile, and returns formatted terminal output and the line the analysis stopped at
+func (a *TerminalAnalyzer) AnalyzeRemaining(ctx context.Context, filePath string, start int) (string, int, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", 0, fmt.Errorf("error reading file: %w", err)
+	}
+	lines := strings.Split(string(content), "\n")
+	if start >= len(lines) {
+		return "", start, fmt.Errorf("no lines left to analyze after line %d", start)
+	}
+
+	decls := chunk.Declarations(filePath, content)
+	context := continuationContext(filePath, lines, decls, start)
+	end := a.chunkEnd(lines, decls, start, context)
+	header := func() string {
+		return fmt.Sprintf("# Code Analysis (Lines %d-%d of %d)\n*Generated on %s*\n\n", start+1, end, len(lines), a.generated())
+	}
+	footer := ""
+	if end < len(lines) {
+		footer = "\n\nWould you like to analyze the remaining lines? (y/n)"
+	}
+
+	result, err := a.analyzeWithContext(ctx, filePath, context, strings.Join(lines[start:end], "\n"), header)
+	if err != nil {
+		return "", 0, err
+	}
+	a.finishStream(footer)
+
+	return header() + formatAnalysis(result) + footer, end, nil
+}
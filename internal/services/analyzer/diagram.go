@@ -0,0 +1,97 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+const (
+	// diagramFunctionName is the function the model calls to report a diagram
+	diagramFunctionName = "report_diagram"
+
+	// diagramMaxTokens leaves room for a diagram of a few dozen nodes
+	diagramMaxTokens = 2000
+
+	architectureDiagramPrompt = "You are documenting the architecture of this project. Draw a Mermaid flowchart (flowchart TD) of its components: " +
+		"one node per package or module, grouped in subgraphs by top-level directory, with an arrow from each component to the components it depends on or calls. " +
+		"Name nodes by their role as the code shows it, keep the diagram under 40 nodes by merging minor packages, and leave out tests, fixtures and generated code. " +
+		"Quote labels that contain punctuation. Report the diagram only through the report_diagram function."
+)
+
+// diagramKinds are the Mermaid diagram types a generated diagram may start with
+var diagramKinds = []string{"flowchart", "graph"}
+
+// diagramFunction describes the structured response for diagram generation
+var diagramFunction = openai.FunctionDefinition{
+	Name:        diagramFunctionName,
+	Description: "Report a Mermaid diagram of the project's architecture.",
+	Parameters: jsonschema.Definition{
+		Type: jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{
+			"mermaid": {
+				Type:        jsonschema.String,
+				Description: "The Mermaid source, starting with flowchart TD, without code fences",
+			},
+		},
+		Required: []string{"mermaid"},
+	},
+}
+
+// diagramResult is the decoded argument payload of a diagram call
+type diagramResult struct {
+	Mermaid string `json:"mermaid"`
+}
+
+// GenerateDiagram generates a Mermaid diagram of a project's components and their dependencies
+// from its directory overview and excerpts of its code
+func (a *TerminalAnalyzer) GenerateDiagram(ctx context.Context, projectPath string) (string, error) {
+	files, err := listProjectFiles(projectPath)
+	if err != nil {
+		return "", err
+	}
+
+	var result diagramResult
+	err = a.complete(
+		ctx,
+		openai.ChatCompletionRequest{
+			Model: a.model,
+			Messages: []openai.ChatCompletionMessage{
+				{
+					Role:    openai.ChatMessageRoleSystem,
+					Content: a.getContextualPrompt() + "\n\n" + architectureDiagramPrompt,
+				},
+				{
+					Role: openai.ChatMessageRoleUser,
+					Content: fmt.Sprintf("Project overview:\n%s%s\n\nDraw the architecture diagram of this project.",
+						projectOverview(files), sampledContents(sampleProject(a.model, projectPath, files, projectSampleTokens))),
+				},
+			},
+			MaxTokens: diagramMaxTokens,
+		},
+		diagramFunction,
+		&result,
+	)
+	if err != nil {
+		return "", fmt.Errorf("error generating diagram: %w", err)
+	}
+	return cleanMermaid(result.Mermaid)
+}
+
+// cleanMermaid removes the code fences models add despite the instructions, and rejects source
+// that is not a flowchart
+func cleanMermaid(source string) (string, error) {
+	source = strings.TrimSpace(source)
+	if strings.HasPrefix(source, "```") {
+		source = strings.TrimPrefix(strings.TrimPrefix(source, "```mermaid"), "```")
+		source = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(source), "```"))
+	}
+	if fields := strings.Fields(source); len(fields) > 0 && slices.Contains(diagramKinds, fields[0]) {
+		return source + "\n", nil
+	}
+	return "", fmt.Errorf("generated diagram is not a Mermaid flowchart")
+}
@@ -0,0 +1,147 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bkidd1/wash-cli/internal/utils/git"
+	"github.com/bkidd1/wash-cli/internal/utils/tokens"
+	"github.com/sashabaranov/go-openai"
+)
+
+// diffPrompt narrows the code review to the changed lines of a diff
+const diffPrompt = "You are reviewing a diff, not whole files. Each file starts with \"File: <path>\". Lines are numbered " +
+	"as in the new file and marked + when added or changed, - when removed (no number) and with a space when they are unchanged context.\n\n" +
+	"Only report issues in the added or changed lines (+). Use the context lines to understand them, but never report an issue " +
+	"that only exists in unchanged code. Start every issue with the location of the line it is about, as path:line, for example " +
+	"\"internal/auth/session.go:42: Is the error from Close ignored on purpose?\"\n\n"
+
+// locationPattern reads the path:line an issue starts with
+var locationPattern = regexp.MustCompile("^`?([^\\s:`]+):(\\d+)")
+
+// DiffAnalysis is the findings on a diff, with the findings about unchanged lines removed
+type DiffAnalysis struct {
+	Analysis
+	// Dropped counts the findings about lines the diff does not change
+	Dropped int
+	// AnalyzedLines and TotalLines say how much of a diff too large for one request was sent
+	AnalyzedLines int
+	TotalLines    int
+}
+
+// AnalyzeDiff reviews the changed lines of a diff and returns formatted terminal output
+func (a *TerminalAnalyzer) AnalyzeDiff(ctx context.Context, files []git.FileDiff) (string, *DiffAnalysis, error) {
+	lines := renderDiff(files)
+	if len(lines) == 0 {
+		return "", nil, fmt.Errorf("no changes to analyze")
+	}
+	analyzed := a.ContentLineLimit(lines)
+	content := strings.Join(lines[:analyzed], "\n")
+
+	notesPrompt := rememberNotesPrompt(a.relevantNotes(ctx, content))
+
+	var result DiffAnalysis
+	err := a.complete(
+		ctx,
+		openai.ChatCompletionRequest{
+			Model: a.model,
+			Messages: []openai.ChatCompletionMessage{
+				{
+					Role:    openai.ChatMessageRoleSystem,
+					Content: a.getContextualPrompt() + diffPrompt + notesPrompt,
+				},
+				{
+					Role:    openai.ChatMessageRoleUser,
+					Content: content,
+				},
+			},
+			MaxTokens: tokens.DefaultCompletionTokens,
+		},
+		codeAnalysisFunction,
+		&result.Analysis,
+	)
+	if err != nil {
+		return "", nil, fmt.Errorf("error getting analysis: %w", err)
+	}
+
+	result.AnalyzedLines, result.TotalLines = analyzed, len(lines)
+	result.CriticalIssues = onChangedLines(files, result.CriticalIssues, &result.Dropped)
+	result.ShouldFix = onChangedLines(files, result.ShouldFix, &result.Dropped)
+	result.CouldFix = onChangedLines(files, result.CouldFix, &result.Dropped)
+	a.lastAnalysis = &result.Analysis
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Diff Analysis\n*Generated on %s*\n\n", a.generated())
+	for _, file := range files {
+		added, removed := file.Counts()
+		fmt.Fprintf(&b, "- %s (+%d -%d)\n", file.Path, added, removed)
+	}
+	b.WriteString("\n")
+	if analyzed < len(lines) {
+		fmt.Fprintf(&b, "⚠️  Diff is too large for complete analysis. Analyzed lines 1-%d of %d.\n\n", analyzed, len(lines))
+	}
+	b.WriteString(formatAnalysis(&result.Analysis))
+	if result.Dropped > 0 {
+		fmt.Fprintf(&b, "\n\n%d finding(s) about unchanged lines were left out.", result.Dropped)
+	}
+	return b.String(), &result, nil
+}
+
+// renderDiff numbers the lines of each hunk for the prompt
+func renderDiff(files []git.FileDiff) []string {
+	var lines []string
+	for _, file := range files {
+		if file.Deleted || file.Binary || len(file.Hunks) == 0 {
+			continue
+		}
+		header := "File: " + file.Path
+		if file.OldPath != "" && file.OldPath != file.Path {
+			header += " (renamed from " + file.OldPath + ")"
+		}
+		lines = append(lines, header)
+		for i, hunk := range file.Hunks {
+			if i > 0 {
+				lines = append(lines, "...")
+			}
+			for _, line := range hunk {
+				number := ""
+				if line.Kind != '-' {
+					number = strconv.Itoa(line.Line)
+				}
+				lines = append(lines, fmt.Sprintf("%6s %c %s", number, line.Kind, line.Text))
+			}
+		}
+		lines = append(lines, "")
+	}
+	return lines
+}
+
+// onChangedLines drops the issues located on a line the diff does not add or change, counting them
+// in dropped. Issues without a location are kept, since they cannot be checked.
+func onChangedLines(files []git.FileDiff, issues []string, dropped *int) []string {
+	kept := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		match := locationPattern.FindStringSubmatch(strings.TrimSpace(issue))
+		if match == nil {
+			kept = append(kept, issue)
+			continue
+		}
+		line, _ := strconv.Atoi(match[2])
+		changed := false
+		for _, file := range files {
+			if (file.Path == match[1] || strings.HasSuffix(file.Path, "/"+match[1])) && file.Added(line) {
+				changed = true
+				break
+			}
+		}
+		if !changed {
+			*dropped++
+			continue
+		}
+		kept = append(kept, issue)
+	}
+	return kept
+}
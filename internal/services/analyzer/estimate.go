@@ -0,0 +1,88 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bkidd1/wash-cli/internal/services/estimates"
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+const (
+	// estimateFunctionName is the function the model calls to report an estimate
+	estimateFunctionName = "report_estimate"
+
+	estimatePrompt = "You are estimating the effort for a development task in this project. " +
+		"Ground the estimate in the historical data provided: how long similar past tasks actually took, how quickly plans were completed, and how active the project is. " +
+		"If past estimates were consistently off, correct for that bias. " +
+		"Give a most likely number of focused working hours with a low and high range, rate your confidence, and explain the estimate briefly. " +
+		"Report the estimate only through the report_estimate function."
+)
+
+// estimateFunction describes the structured response for effort estimation
+var estimateFunction = openai.FunctionDefinition{
+	Name:        estimateFunctionName,
+	Description: "Report an effort estimate for a task in focused working hours.",
+	Parameters: jsonschema.Definition{
+		Type: jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{
+			"hours":      {Type: jsonschema.Number, Description: "Most likely effort in hours"},
+			"low_hours":  {Type: jsonschema.Number, Description: "Optimistic effort in hours"},
+			"high_hours": {Type: jsonschema.Number, Description: "Pessimistic effort in hours"},
+			"confidence": {Type: jsonschema.String, Enum: []string{"low", "medium", "high"}},
+			"rationale":  {Type: jsonschema.String, Description: "Short explanation referencing the historical data"},
+		},
+		Required: []string{"hours", "low_hours", "high_hours", "confidence", "rationale"},
+	},
+}
+
+// estimateResponse holds the fields the model fills in through report_estimate
+type estimateResponse struct {
+	Hours      float64 `json:"hours"`
+	LowHours   float64 `json:"low_hours"`
+	HighHours  float64 `json:"high_hours"`
+	Confidence string  `json:"confidence"`
+	Rationale  string  `json:"rationale"`
+}
+
+// EstimateEffort estimates the effort for a task from the project's historical data
+func (a *TerminalAnalyzer) EstimateEffort(ctx context.Context, projectName string, task string, history string) (*estimates.Estimate, error) {
+	var response estimateResponse
+	err := a.complete(
+		ctx,
+		openai.ChatCompletionRequest{
+			Model: a.model,
+			Messages: []openai.ChatCompletionMessage{
+				{
+					Role:    openai.ChatMessageRoleSystem,
+					Content: a.getContextualPrompt() + "\n\n" + estimatePrompt,
+				},
+				{
+					Role:    openai.ChatMessageRoleUser,
+					Content: fmt.Sprintf("Task: %s\n\nHistorical data:\n%s", task, history),
+				},
+			},
+			MaxTokens: 500,
+		},
+		estimateFunction,
+		&response,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error estimating effort: %w", err)
+	}
+
+	if response.Hours <= 0 {
+		return nil, fmt.Errorf("model returned an invalid estimate of %.1f hours", response.Hours)
+	}
+
+	return &estimates.Estimate{
+		ProjectName: projectName,
+		Task:        task,
+		Hours:       response.Hours,
+		LowHours:    response.LowHours,
+		HighHours:   response.HighHours,
+		Confidence:  response.Confidence,
+		Rationale:   response.Rationale,
+	}, nil
+}
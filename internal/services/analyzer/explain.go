@@ -0,0 +1,156 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/fsutil"
+)
+
+const (
+	// contextLogTimeLayout names the monthly context logs in ~/.wash/context
+	contextLogTimeLayout = "2006-01"
+	// noteIDLength is how many hex digits of a note's hash identify it in the context log
+	noteIDLength = 12
+)
+
+// Where a note in a prompt came from
+const (
+	SourcePinned   = "pinned"
+	SourceRemember = "remember"
+	SourceLesson   = "lesson"
+)
+
+// ContextNote is one note considered for a prompt, with why it was or was not included
+type ContextNote struct {
+	// ID is a hash of the note's text, so the context log can name notes without keeping them
+	ID     string `json:"id"`
+	Text   string `json:"text,omitempty"`
+	Source string `json:"source"`
+	// Scope is project or global for remember notes
+	Scope    string     `json:"scope,omitempty"`
+	SavedAt  *time.Time `json:"saved_at,omitempty"`
+	Score    float64    `json:"score,omitempty"`
+	Rank     int        `json:"rank,omitempty"`
+	Included bool       `json:"included"`
+	Reason   string     `json:"reason"`
+}
+
+// ContextSelection records the notes considered for one prompt
+type ContextSelection struct {
+	Timestamp time.Time     `json:"timestamp"`
+	Project   string        `json:"project,omitempty"`
+	Query     string        `json:"query,omitempty"`
+	Notes     []ContextNote `json:"notes"`
+}
+
+// NoteTimeSource is implemented by remember sources that know when each note was saved
+type NoteTimeSource interface {
+	SavedTimes(projectName string) (map[string]time.Time, error)
+}
+
+// Included returns the notes that went into the prompt
+func (s *ContextSelection) Included() []ContextNote {
+	var included []ContextNote
+	for _, note := range s.Notes {
+		if note.Included {
+			included = append(included, note)
+		}
+	}
+	return included
+}
+
+// Explain describes which notes were included in the prompt and why, then the ones left out
+func (s *ContextSelection) Explain() string {
+	if len(s.Notes) == 0 {
+		return "No notes were considered for this prompt\n"
+	}
+
+	var b strings.Builder
+	for _, included := range []bool{true, false} {
+		var notes []ContextNote
+		for _, note := range s.Notes {
+			if note.Included == included {
+				notes = append(notes, note)
+			}
+		}
+		if len(notes) == 0 {
+			continue
+		}
+		if included {
+			b.WriteString(fmt.Sprintf("Included %d notes:\n", len(notes)))
+		} else {
+			b.WriteString(fmt.Sprintf("Left out %d notes:\n", len(notes)))
+		}
+		for _, note := range notes {
+			b.WriteString(fmt.Sprintf("  - %s\n    %s\n", truncateText(note.Text, 80), note.describe()))
+		}
+	}
+	return b.String()
+}
+
+// describe explains a note's selection in one line
+func (n ContextNote) describe() string {
+	var parts []string
+	if n.Source == SourcePinned {
+		parts = append(parts, "pinned")
+	} else if n.Source == SourceLesson {
+		parts = append(parts, "lesson from a resolved bug")
+	} else if n.Scope != "" {
+		parts = append(parts, n.Scope+" note")
+	}
+	if n.SavedAt != nil {
+		parts = append(parts, "saved "+n.SavedAt.Format("2006-01-02"))
+	}
+	if n.Rank > 0 {
+		parts = append(parts, fmt.Sprintf("similarity %.2f (rank %d)", n.Score, n.Rank))
+	}
+	parts = append(parts, n.Reason)
+	return strings.Join(parts, ", ")
+}
+
+// truncateText shortens text to one line of at most width runes
+func truncateText(text string, width int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > width {
+		return string(runes[:width-3]) + "..."
+	}
+	return text
+}
+
+// NoteID returns the ID a note is logged under: the start of the SHA-256 of its trimmed text
+func NoteID(text string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(text)))
+	return hex.EncodeToString(sum[:])[:noteIDLength]
+}
+
+// LogContextSelection appends a selection to the context log of its month. Only the note IDs,
+// sources, scores and reasons are logged; the query and note texts may hold code or secrets.
+func LogContextSelection(selection *ContextSelection) error {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(dataDir, "context")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("error creating context log directory: %w", err)
+	}
+
+	logged := ContextSelection{Timestamp: selection.Timestamp, Project: selection.Project}
+	for _, note := range selection.Notes {
+		note.ID = NoteID(note.Text)
+		note.Text = ""
+		logged.Notes = append(logged.Notes, note)
+	}
+	path := filepath.Join(dir, selection.Timestamp.Format(contextLogTimeLayout)+".jsonl")
+	if err := fsutil.AppendJSONLine(path, logged); err != nil {
+		return fmt.Errorf("error writing context log: %w", err)
+	}
+	return nil
+}
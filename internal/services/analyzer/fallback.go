@@ -0,0 +1,125 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Provenance records which model produced an analysis
+type Provenance struct {
+	// Requested is the configured model
+	Requested string
+	// Model is the model that answered; it differs from Requested after a fallback
+	Model string
+	// Reasons says why each skipped model was not used
+	Reasons []string
+}
+
+// Substituted reports whether a fallback model answered instead of the configured one
+func (p Provenance) Substituted() bool {
+	return p.Model != p.Requested
+}
+
+func (p Provenance) String() string {
+	if !p.Substituted() {
+		return p.Model
+	}
+	s := fmt.Sprintf("%s (fallback from %s:", p.Model, p.Requested)
+	for i, reason := range p.Reasons {
+		if i > 0 {
+			s += ";"
+		}
+		s += " " + reason
+	}
+	return s + ")"
+}
+
+// SetFallbackModels sets the models tried, in order, when the analysis model is over quota or unavailable
+func (a *TerminalAnalyzer) SetFallbackModels(models []string) {
+	a.fallbackModels = models
+}
+
+// LastProvenance returns the model behind the last analysis, or nil before one is made
+func (a *TerminalAnalyzer) LastProvenance() *Provenance {
+	return a.lastProvenance
+}
+
+// complete sends a structured request to the analysis model, falling back along the configured chain
+// when a model is over quota or unavailable, and records which model answered
+func (a *TerminalAnalyzer) complete(ctx context.Context, req openai.ChatCompletionRequest, fn openai.FunctionDefinition, out interface{}) error {
+	return a.withFallback(func(provenance *Provenance) error {
+		req.Model = provenance.Model
+		return createStructuredCompletion(ctx, a.client, req, fn, out)
+	})
+}
+
+// withFallback calls send with the analysis model and then each fallback model in turn, named by
+// provenance.Model, while the model is over quota or unavailable
+func (a *TerminalAnalyzer) withFallback(send func(provenance *Provenance) error) error {
+	provenance := &Provenance{Requested: a.model}
+	var err error
+	for _, model := range append([]string{a.model}, a.fallbackModels...) {
+		provenance.Model = model
+		err = send(provenance)
+		reason, unavailable := unavailableReason(err)
+		if !unavailable {
+			if err == nil {
+				a.lastProvenance = provenance
+			}
+			return err
+		}
+		provenance.Reasons = append(provenance.Reasons, fmt.Sprintf("%s %s", model, reason))
+	}
+	return err
+}
+
+// unavailableReason reports whether err means the model cannot be used right now, as opposed to
+// a problem with the request, and describes why
+func unavailableReason(err error) (string, bool) {
+	var apiErr *openai.APIError
+	var reqErr *openai.RequestError
+	var status int
+	var code interface{}
+	switch {
+	case errors.As(err, &apiErr):
+		status, code = apiErr.HTTPStatusCode, apiErr.Code
+	case errors.As(err, &reqErr):
+		status = reqErr.HTTPStatusCode
+	default:
+		return "", false
+	}
+
+	switch {
+	case code == "insufficient_quota":
+		return "is over quota", true
+	case code == "model_not_found" || status == http.StatusNotFound:
+		return "is not available to this API key", true
+	case status == http.StatusTooManyRequests:
+		return "is rate limited", true
+	case status >= http.StatusInternalServerError:
+		return fmt.Sprintf("is unavailable (status %d)", status), true
+	}
+	return "", false
+}
+
+// generated returns the time of an analysis for its "Generated on" line, naming the fallback model
+// when one answered. A cached analysis keeps the time it was first generated.
+func (a *TerminalAnalyzer) generated() string {
+	at := time.Now()
+	if a.Cached() {
+		at = a.cachedAt
+	}
+	generated := at.Format(time.RFC3339)
+	if a.lastProvenance != nil && a.lastProvenance.Substituted() {
+		generated += " with " + a.lastProvenance.String()
+	}
+	if a.Cached() {
+		generated += " (cached)"
+	}
+	return generated
+}
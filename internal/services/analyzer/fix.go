@@ -0,0 +1,76 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/bkidd1/wash-cli/internal/utils/tokens"
+	"github.com/sashabaranov/go-openai"
+)
+
+// fixPrompt asks for patches instead of findings
+const fixPrompt = "You are now fixing the findings of your analysis. For each Critical or Should Fix finding that can be fixed in this file, " +
+	"write a minimal unified diff that fixes it without unrelated changes. Keep each patch independent of the others, so any subset can be applied. " +
+	"Follow the project's conventions; suggested code must compile.\n\n"
+
+// fixCompletionTokens leaves room for several patches in the reply
+const fixCompletionTokens = 4000
+
+// Fix is a patch proposed for one finding
+type Fix struct {
+	Finding string `json:"finding"`
+	Diff    string `json:"diff"`
+}
+
+// ProposeFixes asks for unified diffs fixing the Critical and Should Fix findings of an analysis
+// of code, which is the analyzed part of the file at filePath
+func (a *TerminalAnalyzer) ProposeFixes(ctx context.Context, filePath, code string, analysis *Analysis) ([]Fix, error) {
+	var findings strings.Builder
+	for _, issue := range analysis.CriticalIssues {
+		fmt.Fprintf(&findings, "- Critical: %s\n", issue)
+	}
+	for _, issue := range analysis.ShouldFix {
+		fmt.Fprintf(&findings, "- Should Fix: %s\n", issue)
+	}
+	if findings.Len() == 0 {
+		return nil, nil
+	}
+
+	system := a.getContextualPrompt() + fixPrompt
+	user := fmt.Sprintf("File: %s\n\n%s\n\nFindings to fix:\n%s", filepath.Base(filePath), code, findings.String())
+
+	// Patches get what the context window has left, up to fixCompletionTokens
+	maxTokens := min(fixCompletionTokens, tokens.ContextWindow(a.model)-tokens.CountMessages(a.model, system, user)-functionSchemaTokens)
+	if maxTokens < tokens.DefaultCompletionTokens/2 {
+		return nil, fmt.Errorf("the analyzed code leaves too little room for patches; analyze a smaller part with --lines or --func")
+	}
+
+	var result struct {
+		Fixes []Fix `json:"fixes"`
+	}
+	err := a.complete(
+		ctx,
+		openai.ChatCompletionRequest{
+			Model: a.model,
+			Messages: []openai.ChatCompletionMessage{
+				{
+					Role:    openai.ChatMessageRoleSystem,
+					Content: system,
+				},
+				{
+					Role:    openai.ChatMessageRoleUser,
+					Content: user,
+				},
+			},
+			MaxTokens: maxTokens,
+		},
+		fixFunction,
+		&result,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting fixes: %w", err)
+	}
+	return result.Fixes, nil
+}
@@ -0,0 +1,169 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+const (
+	// codeAnalysisFunctionName is the function the model calls to report code findings
+	codeAnalysisFunctionName = "report_code_analysis"
+	// bugAnalysisFunctionName is the function the model calls to report bug findings
+	bugAnalysisFunctionName = "report_bug_analysis"
+	// fixFunctionName is the function the model calls to propose patches for findings
+	fixFunctionName = "propose_fixes"
+	// functionSchemaTokens approximates the prompt tokens taken by a function definition
+	functionSchemaTokens = 150
+)
+
+// stringList returns the schema for an array of strings
+func stringList(description string) jsonschema.Definition {
+	return jsonschema.Definition{
+		Type:        jsonschema.Array,
+		Description: description,
+		Items:       &jsonschema.Definition{Type: jsonschema.String},
+	}
+}
+
+// codeAnalysisFunction describes the structured response for code and project analysis
+var codeAnalysisFunction = openai.FunctionDefinition{
+	Name:        codeAnalysisFunctionName,
+	Description: "Report the issues found during analysis, grouped by priority. Use an empty array when a priority level has no issues.",
+	Parameters: jsonschema.Definition{
+		Type: jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{
+			"critical_issues": stringList("Critical! Must Fix issues, each a 1-2 sentence question naming the code it concerns in backticks"),
+			"should_fix":      stringList("Should Fix issues, each a 1-2 sentence question naming the code it concerns in backticks"),
+			"could_fix":       stringList("Could Fix issues, at most one"),
+		},
+		Required: []string{"critical_issues", "should_fix", "could_fix"},
+	},
+}
+
+// bugAnalysisFunction describes the structured response for bug analysis
+var bugAnalysisFunction = openai.FunctionDefinition{
+	Name:        bugAnalysisFunctionName,
+	Description: "Report the potential causes of a bug and the suggested solutions, prioritizing any relevant remember notes.",
+	Parameters: jsonschema.Definition{
+		Type: jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{
+			"potential_causes":    stringList("Potential causes of the bug, most likely first"),
+			"suggested_solutions": stringList("Suggested solutions, most effective first"),
+		},
+		Required: []string{"potential_causes", "suggested_solutions"},
+	},
+}
+
+// fixFunction describes the structured response for patches fixing code findings
+var fixFunction = openai.FunctionDefinition{
+	Name:        fixFunctionName,
+	Description: "Propose one patch per finding that can be fixed in the given code. Leave out findings that cannot be fixed in this file.",
+	Parameters: jsonschema.Definition{
+		Type: jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{
+			"fixes": {
+				Type:        jsonschema.Array,
+				Description: "Patches, one per finding",
+				Items: &jsonschema.Definition{
+					Type: jsonschema.Object,
+					Properties: map[string]jsonschema.Definition{
+						"finding": {Type: jsonschema.String, Description: "The finding this patch fixes, as given"},
+						"diff": {Type: jsonschema.String, Description: "Unified diff of the file with @@ hunk headers and 3 lines of unchanged context around each change, " +
+							"copying context and removed lines exactly from the code"},
+					},
+					Required: []string{"finding", "diff"},
+				},
+			},
+		},
+		Required: []string{"fixes"},
+	},
+}
+
+// bugAnalysisResult is the decoded argument payload of a bug analysis call
+type bugAnalysisResult struct {
+	PotentialCauses    []string `json:"potential_causes"`
+	SuggestedSolutions []string `json:"suggested_solutions"`
+}
+
+// createStructuredCompletion sends a request that forces a call to fn and decodes its arguments into out
+func createStructuredCompletion(ctx context.Context, client *openai.Client, req openai.ChatCompletionRequest, fn openai.FunctionDefinition, out interface{}) error {
+	forceFunction(&req, fn)
+
+	resp, err := client.CreateChatCompletion(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	if len(resp.Choices) == 0 {
+		return fmt.Errorf("no response choices available")
+	}
+
+	calls := resp.Choices[0].Message.ToolCalls
+	if len(calls) == 0 || calls[0].Function.Name != fn.Name {
+		return fmt.Errorf("response did not include a %s call", fn.Name)
+	}
+
+	if err := json.Unmarshal([]byte(calls[0].Function.Arguments), out); err != nil {
+		return fmt.Errorf("error parsing %s arguments: %w", fn.Name, err)
+	}
+
+	return nil
+}
+
+// forceFunction makes the model answer a request by calling fn
+func forceFunction(req *openai.ChatCompletionRequest, fn openai.FunctionDefinition) {
+	req.Tools = []openai.Tool{{Type: openai.ToolTypeFunction, Function: &fn}}
+	req.ToolChoice = openai.ToolChoice{
+		Type:     openai.ToolTypeFunction,
+		Function: openai.ToolFunction{Name: fn.Name},
+	}
+}
+
+// Format renders the findings as the priority sections shown in the terminal
+func (analysis *Analysis) Format() string {
+	return formatAnalysis(analysis)
+}
+
+// formatAnalysis renders structured findings as the priority sections shown in the terminal
+func formatAnalysis(analysis *Analysis) string {
+	var out strings.Builder
+	out.WriteString("You can copy this analysis into your chat window!\n\n")
+
+	sections := []struct {
+		title  string
+		issues []string
+	}{
+		{"Critical! Must Fix", analysis.CriticalIssues},
+		{"Should Fix", analysis.ShouldFix},
+		{"Could Fix", analysis.CouldFix},
+	}
+
+	for i, section := range sections {
+		out.WriteString(fmt.Sprintf("* %s\n", section.title))
+		out.WriteString(formatList(section.issues, "No issues found"))
+		if i < len(sections)-1 {
+			out.WriteString("\n\n")
+		}
+	}
+
+	return out.String()
+}
+
+// formatList renders items as a bulleted list, or the fallback when empty
+func formatList(items []string, fallback string) string {
+	var lines []string
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			lines = append(lines, "- "+item)
+		}
+	}
+	if len(lines) == 0 {
+		return fallback
+	}
+	return strings.Join(lines, "\n")
+}
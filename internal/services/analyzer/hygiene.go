@@ -0,0 +1,90 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bkidd1/wash-cli/internal/utils/hygiene"
+	"github.com/bkidd1/wash-cli/internal/utils/tokens"
+	"github.com/sashabaranov/go-openai"
+)
+
+const projectHygienePrompt = "\n\nYou are reviewing the project's code hygiene: dead code, leftover legacy packages and copy-pasted blocks. " +
+	"The local findings were measured in the code itself; explain each one that matters and what to do about it: delete dead code, unexport what only its package uses, " +
+	"merge or remove a leftover copy of a package, or extract a copied block into a shared function. " +
+	"Exports of packages outside internal/ may be used by other modules, so weigh them as public API. " +
+	"Group related findings, name the files and identifiers, and leave out those that are fine as they are."
+
+// duplicateExcerpts returns the code of the copied blocks, first copy only, that fits in budget tokens
+func duplicateExcerpts(model, projectPath string, duplicates []hygiene.Duplicate, budget int) string {
+	var b strings.Builder
+	used := 0
+	for _, dup := range duplicates {
+		content, err := os.ReadFile(filepath.Join(projectPath, filepath.FromSlash(dup.Path)))
+		if err != nil {
+			continue
+		}
+		lines := strings.Split(string(content), "\n")
+		start := min(dup.Line-1, len(lines))
+		block := strings.Join(lines[start:min(start+dup.Lines, len(lines))], "\n")
+		excerpt := fmt.Sprintf("### %s:%d, also at %s:%d\n%s\n", dup.Path, dup.Line, dup.OtherPath, dup.OtherLine, block)
+		if size := tokens.Count(model, excerpt); used+size <= budget {
+			b.WriteString(excerpt)
+			used += size
+		}
+	}
+	return b.String()
+}
+
+// AnalyzeProjectHygiene looks for unused exports, leftover packages and copied blocks locally, and
+// has the model explain them and what to do, returning formatted terminal output
+func (a *TerminalAnalyzer) AnalyzeProjectHygiene(ctx context.Context, projectPath string) (string, error) {
+	files, err := listProjectFiles(projectPath)
+	if err != nil {
+		return "", err
+	}
+
+	found := hygiene.Find(projectPath, files)
+	userContent := fmt.Sprintf("Project overview:\n%s\nLocal findings:\n%s", projectOverview(files), found)
+	if excerpts := duplicateExcerpts(a.model, projectPath, found.Duplicates, projectSampleTokens); excerpts != "" {
+		userContent += "\nCopied blocks:\n" + excerpts
+	}
+	userContent += "\nExplain the hygiene issues of this project at each priority level, backing them with the local findings."
+
+	header := func() string {
+		return fmt.Sprintf("# Project Hygiene\n*Generated on %s*\n\n* Local Findings\n%s\n", a.generated(), found)
+	}
+
+	var result Analysis
+	err = a.completeStreamed(
+		ctx,
+		openai.ChatCompletionRequest{
+			Model: a.model,
+			Messages: []openai.ChatCompletionMessage{
+				{
+					Role:    openai.ChatMessageRoleSystem,
+					Content: a.getContextualPrompt() + projectHygienePrompt,
+				},
+				{
+					Role:    openai.ChatMessageRoleUser,
+					Content: userContent,
+				},
+			},
+			MaxTokens: projectStructureMaxTokens,
+		},
+		codeAnalysisFunction,
+		analysisLayout,
+		header,
+		&result,
+	)
+	if err != nil {
+		return "", fmt.Errorf("error getting analysis: %w", err)
+	}
+	a.lastAnalysis = &result
+	a.finishStream("")
+
+	return header() + formatAnalysis(&result), nil
+}
@@ -2,13 +2,14 @@ package analyzer
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/bkidd1/wash-cli/internal/services/usage"
 	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/ignore"
 	"github.com/sashabaranov/go-openai"
 )
 
@@ -22,12 +23,7 @@ Focus on three levels:
 
 For each issue identified, provide a concise (1-2 sentences) and clear description of the problem.
 
-Do not write introduction or conclusion paragraphs. Simply return your analysis as a JSON object with the following structure:
-{
-    "critical_issues": ["string"],
-    "should_fix": ["string"],
-    "could_fix": ["string"]
-}
+Do not write introduction or conclusion paragraphs. Report your analysis only through the report_code_analysis function.
 
 If no issues are found at a particular priority level, return an empty array.`
 )
@@ -38,11 +34,12 @@ type NotesAnalyzer struct {
 	cfg           *config.Config
 	projectGoal   string
 	rememberNotes []string
+	languages     string
 }
 
 // NewNotesAnalyzer creates a new notes analyzer
 func NewNotesAnalyzer(apiKey string, projectGoal string, rememberNotes []string) *NotesAnalyzer {
-	client := openai.NewClient(apiKey)
+	client := usage.NewClient(apiKey)
 	return &NotesAnalyzer{
 		Client: client,
 		cfg: &config.Config{
@@ -59,12 +56,20 @@ func (a *NotesAnalyzer) UpdateProjectContext(projectGoal string, rememberNotes [
 	a.rememberNotes = rememberNotes
 }
 
+// SetLanguageProfile sets the detected project languages included in every prompt
+func (a *NotesAnalyzer) SetLanguageProfile(languages string) {
+	a.languages = languages
+}
+
 // getContextualPrompt returns the system prompt with project context
 func (a *NotesAnalyzer) getContextualPrompt() string {
 	context := fmt.Sprintf("The user's end-goal is %s", a.projectGoal)
 	if len(a.rememberNotes) > 0 {
 		context += fmt.Sprintf(", and they want to remind you that:\n%s", strings.Join(a.rememberNotes, "\n"))
 	}
+	if a.languages != "" {
+		context += fmt.Sprintf("\n\nThe project uses:\n%s", a.languages)
+	}
 	return fmt.Sprintf("%s\n\n%s", context, notesSystemPrompt)
 }
 
@@ -82,8 +87,10 @@ func (a *NotesAnalyzer) AnalyzeFile(ctx context.Context, filePath string) (*Anal
 		return nil, fmt.Errorf("error reading file: %w", err)
 	}
 
-	resp, err := a.Client.CreateChatCompletion(
+	var analysis Analysis
+	err = createStructuredCompletion(
 		ctx,
+		a.Client,
 		openai.ChatCompletionRequest{
 			Model: openai.GPT4,
 			Messages: []openai.ChatCompletionMessage{
@@ -97,34 +104,39 @@ func (a *NotesAnalyzer) AnalyzeFile(ctx context.Context, filePath string) (*Anal
 				},
 			},
 		},
+		codeAnalysisFunction,
+		&analysis,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("error getting analysis: %w", err)
 	}
 
-	var analysis Analysis
-	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &analysis); err != nil {
-		return nil, fmt.Errorf("error parsing analysis: %w", err)
-	}
-
 	return &analysis, nil
 }
 
 // AnalyzeProjectStructure analyzes the project structure and returns structured analysis
 func (a *NotesAnalyzer) AnalyzeProjectStructure(ctx context.Context, dirPath string) (*Analysis, error) {
+	patterns, err := ignore.LoadGitignorePatterns(dirPath)
+	if err != nil {
+		patterns = ignore.DefaultIgnorePatterns
+	}
+
 	var fileList strings.Builder
-	err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
+	err = filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
-		if info.IsDir() {
-			// Skip common directories
-			if info.Name() == "node_modules" || info.Name() == ".git" {
+		relPath, _ := filepath.Rel(dirPath, path)
+		// Skip what .gitignore and .washignore exclude, and dependency and build directories
+		if relPath != "." && ignore.ShouldIgnore(relPath, patterns) {
+			if info.IsDir() {
 				return filepath.SkipDir
 			}
+			return nil
+		}
+		if info.IsDir() {
 			fileList.WriteString(fmt.Sprintf("📁 %s\n", path))
 		} else {
-			relPath, _ := filepath.Rel(dirPath, path)
 			fileList.WriteString(fmt.Sprintf("  📄 %s\n", relPath))
 		}
 		return nil
@@ -133,8 +145,10 @@ func (a *NotesAnalyzer) AnalyzeProjectStructure(ctx context.Context, dirPath str
 		return nil, fmt.Errorf("error walking directory: %w", err)
 	}
 
-	resp, err := a.Client.CreateChatCompletion(
+	var analysis Analysis
+	err = createStructuredCompletion(
 		ctx,
+		a.Client,
 		openai.ChatCompletionRequest{
 			Model: openai.GPT4,
 			Messages: []openai.ChatCompletionMessage{
@@ -148,23 +162,22 @@ func (a *NotesAnalyzer) AnalyzeProjectStructure(ctx context.Context, dirPath str
 				},
 			},
 		},
+		codeAnalysisFunction,
+		&analysis,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("error getting analysis: %w", err)
 	}
 
-	var analysis Analysis
-	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &analysis); err != nil {
-		return nil, fmt.Errorf("error parsing analysis: %w", err)
-	}
-
 	return &analysis, nil
 }
 
 // AnalyzeChat analyzes chat history and returns structured analysis
 func (a *NotesAnalyzer) AnalyzeChat(ctx context.Context, chatHistory string) (*Analysis, error) {
-	resp, err := a.Client.CreateChatCompletion(
+	var analysis Analysis
+	err := createStructuredCompletion(
 		ctx,
+		a.Client,
 		openai.ChatCompletionRequest{
 			Model: openai.GPT4,
 			Messages: []openai.ChatCompletionMessage{
@@ -178,23 +191,22 @@ func (a *NotesAnalyzer) AnalyzeChat(ctx context.Context, chatHistory string) (*A
 				},
 			},
 		},
+		codeAnalysisFunction,
+		&analysis,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("error getting analysis: %w", err)
 	}
 
-	var analysis Analysis
-	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &analysis); err != nil {
-		return nil, fmt.Errorf("error parsing analysis: %w", err)
-	}
-
 	return &analysis, nil
 }
 
 // GetErrorFix analyzes chat history for specific error patterns and returns structured analysis
 func (a *NotesAnalyzer) GetErrorFix(ctx context.Context, chatHistory string, errorType string) (*Analysis, error) {
-	resp, err := a.Client.CreateChatCompletion(
+	var analysis Analysis
+	err := createStructuredCompletion(
 		ctx,
+		a.Client,
 		openai.ChatCompletionRequest{
 			Model: openai.GPT4,
 			Messages: []openai.ChatCompletionMessage{
@@ -208,15 +220,12 @@ func (a *NotesAnalyzer) GetErrorFix(ctx context.Context, chatHistory string, err
 				},
 			},
 		},
+		codeAnalysisFunction,
+		&analysis,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("error getting error fix: %w", err)
 	}
 
-	var analysis Analysis
-	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &analysis); err != nil {
-		return nil, fmt.Errorf("error parsing analysis: %w", err)
-	}
-
 	return &analysis, nil
 }
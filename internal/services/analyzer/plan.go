@@ -0,0 +1,97 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bkidd1/wash-cli/internal/services/plans"
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+const (
+	// planFunctionName is the function the model calls to report a plan
+	planFunctionName = "report_plan"
+
+	refactorPlanPrompt = "You are planning a refactor for this project. Break the refactoring goal into a concrete, ordered list of small steps. " +
+		"Each step must be independently committable, name the exact files it touches, and leave the project building and passing tests. " +
+		"Order the steps so that the riskiest changes happen after the groundwork that makes them safe. " +
+		"For each step, rate the risk as low, medium or high and explain what could break. " +
+		"Report the plan only through the report_plan function."
+)
+
+// planFunction describes the structured response for plan generation
+var planFunction = openai.FunctionDefinition{
+	Name:        planFunctionName,
+	Description: "Report an ordered plan of file-level steps toward a goal.",
+	Parameters: jsonschema.Definition{
+		Type: jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{
+			"summary": {
+				Type:        jsonschema.String,
+				Description: "One paragraph describing the overall approach",
+			},
+			"steps": {
+				Type: jsonschema.Array,
+				Items: &jsonschema.Definition{
+					Type: jsonschema.Object,
+					Properties: map[string]jsonschema.Definition{
+						"title":       {Type: jsonschema.String, Description: "Short imperative title"},
+						"description": {Type: jsonschema.String, Description: "What to change and why"},
+						"files":       stringList("Files created, modified or deleted in this step"),
+						"risk":        {Type: jsonschema.String, Enum: []string{"low", "medium", "high"}},
+						"risk_notes":  {Type: jsonschema.String, Description: "What could break and how to verify it did not"},
+					},
+					Required: []string{"title", "description", "files", "risk", "risk_notes"},
+				},
+			},
+		},
+		Required: []string{"summary", "steps"},
+	},
+}
+
+// planResult is the decoded argument payload of a plan call
+type planResult struct {
+	Summary string       `json:"summary"`
+	Steps   []plans.Step `json:"steps"`
+}
+
+// GenerateRefactorPlan generates an ordered refactor plan toward the given goal
+func (a *TerminalAnalyzer) GenerateRefactorPlan(ctx context.Context, projectPath string, projectName string, goal string) (*plans.Plan, error) {
+	fileList, err := buildFileList(projectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var result planResult
+	err = a.complete(
+		ctx,
+		openai.ChatCompletionRequest{
+			Model: a.model,
+			Messages: []openai.ChatCompletionMessage{
+				{
+					Role:    openai.ChatMessageRoleSystem,
+					Content: a.getContextualPrompt() + "\n\n" + refactorPlanPrompt,
+				},
+				{
+					Role:    openai.ChatMessageRoleUser,
+					Content: fmt.Sprintf("Refactoring goal: %s\n\nProject Structure:\n%s", goal, fileList),
+				},
+			},
+			MaxTokens: 2000,
+		},
+		planFunction,
+		&result,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error generating plan: %w", err)
+	}
+
+	if len(result.Steps) == 0 {
+		return nil, fmt.Errorf("generated plan has no steps")
+	}
+
+	plan := plans.NewPlan(projectName, plans.KindRefactor, goal, result.Steps)
+	plan.Summary = result.Summary
+	return plan, nil
+}
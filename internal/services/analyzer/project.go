@@ -0,0 +1,541 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bkidd1/wash-cli/internal/utils/analyzable"
+	"github.com/bkidd1/wash-cli/internal/utils/ignore"
+	"github.com/bkidd1/wash-cli/internal/utils/impact"
+	"github.com/bkidd1/wash-cli/internal/utils/metrics"
+	"github.com/bkidd1/wash-cli/internal/utils/pool"
+	"github.com/bkidd1/wash-cli/internal/utils/tokens"
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	// projectPartTokens caps the file list sent in one project analysis request. Larger projects
+	// are analyzed in parts, whose findings are then combined into one report.
+	projectPartTokens = 3000
+	// projectFindingsTokens caps the findings of the parts sent to combine them into one report,
+	// shared equally by the parts, so that a project of many parts still fits one request
+	projectFindingsTokens = 12000
+	// planMaxFiles caps the file list a refactor plan is generated from
+	planMaxFiles = 100
+	// maxPartLabels is how many directories the label of a part names
+	maxPartLabels = 3
+)
+
+// projectArchitectPrompt sets the role of every project structure request
+const projectArchitectPrompt = "\n\nAs an expert project manager and architect, analyze the project structure, organization, and architecture. Focus on identifying potential issues that could impact project success, maintainability, and scalability. Import cycles, god packages and layering violations listed in the metrics were found in the import graph itself: report each as a finding, naming the packages."
+
+// projectPart is a group of a project's files analyzed in one request
+type projectPart struct {
+	label string
+	files []string
+}
+
+// SetProgress makes analyses that take several requests, such as of large projects, report each
+// request as it completes
+func (a *TerminalAnalyzer) SetProgress(progress func(done, total int, label string)) {
+	a.progress = progress
+}
+
+// SetWorkers sets how many requests of an analysis that takes several, such as of a large project,
+// are sent at once; zero or less uses pool.DefaultWorkers
+func (a *TerminalAnalyzer) SetWorkers(workers int) {
+	a.workers = workers
+}
+
+// listProjectFiles returns the project files sent for structure analysis, relative to the project
+// and in walk order
+func listProjectFiles(projectPath string) ([]string, error) {
+	// Load ignore patterns from .gitignore, .washignore and default patterns
+	ignorePatterns, err := ignore.LoadGitignorePatterns(projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("error loading ignore patterns: %w", err)
+	}
+
+	var files []string
+	err = filepath.Walk(projectPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Get relative path for ignore pattern matching
+		relPath, err := filepath.Rel(projectPath, path)
+		if err != nil {
+			return err
+		}
+
+		// Skip ignored paths, and directories such as node_modules at any depth
+		if relPath != "." && ignore.ShouldIgnore(relPath, ignorePatterns) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// Skip binary, generated and minified files, which say little about the structure
+		if !info.IsDir() && analyzable.Check(path, analyzable.NoLimit) == nil {
+			files = append(files, filepath.ToSlash(relPath))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error walking project directory: %w", err)
+	}
+	return files, nil
+}
+
+// ProjectFiles returns the files a project structure analysis covers, relative to the project with
+// forward slashes
+func ProjectFiles(projectPath string) ([]string, error) {
+	return listProjectFiles(projectPath)
+}
+
+// buildFileList returns the newline-separated list of project files a refactor plan is generated
+// from, limited to the first planMaxFiles
+func buildFileList(projectPath string) (string, error) {
+	files, err := listProjectFiles(projectPath)
+	if err != nil {
+		return "", err
+	}
+	if len(files) <= planMaxFiles {
+		return strings.Join(files, "\n") + "\n", nil
+	}
+	return strings.Join(files[:planMaxFiles], "\n") + fmt.Sprintf("\n\nNote: Only showing first %d files for analysis.\n", planMaxFiles), nil
+}
+
+// splitProject groups a project's files into parts whose file lists fit in budget tokens, keeping
+// top-level directories whole where they fit. A project that fits is a single part.
+func splitProject(model string, files []string, budget int) []projectPart {
+	// Group by top-level directory, in order of first appearance
+	var order []string
+	groups := make(map[string][]string)
+	for _, file := range files {
+		top := "."
+		if dir, _, found := strings.Cut(file, "/"); found {
+			top = dir
+		}
+		if _, ok := groups[top]; !ok {
+			order = append(order, top)
+		}
+		groups[top] = append(groups[top], file)
+	}
+
+	var parts []projectPart
+	var current []string
+	used := 0
+	flush := func() {
+		if len(current) > 0 {
+			parts = append(parts, projectPart{label: partLabel(current), files: current})
+			current, used = nil, 0
+		}
+	}
+	for _, top := range order {
+		group := groups[top]
+		size := tokens.Count(model, strings.Join(group, "\n"))
+		if used+size > budget {
+			flush()
+		}
+		if size <= budget {
+			current = append(current, group...)
+			used += size
+			continue
+		}
+		// Split a directory too large for one part, keeping its subdirectories together as the
+		// walk order does
+		for len(group) > 0 {
+			n := tokens.SplitLines(model, group, budget)
+			current = group[:n]
+			flush()
+			group = group[n:]
+		}
+	}
+	flush()
+	return parts
+}
+
+// partLabel names the directories, two levels deep, that a part covers
+func partLabel(files []string) string {
+	var dirs []string
+	seen := make(map[string]bool)
+	for _, file := range files {
+		dir := path.Dir(file)
+		if segments := strings.Split(dir, "/"); len(segments) > 2 {
+			dir = strings.Join(segments[:2], "/")
+		}
+		if dir == "." {
+			dir = "(root)"
+		}
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	if len(dirs) > maxPartLabels {
+		return fmt.Sprintf("%s and %d more", strings.Join(dirs[:maxPartLabels], ", "), len(dirs)-maxPartLabels)
+	}
+	return strings.Join(dirs, ", ")
+}
+
+// projectOverview counts the files in each directory, two levels deep, so each part of a large
+// project is analyzed knowing the whole
+func projectOverview(files []string) string {
+	counts := make(map[string]int)
+	for _, file := range files {
+		dir := path.Dir(file)
+		if segments := strings.Split(dir, "/"); len(segments) > 2 {
+			dir = strings.Join(segments[:2], "/")
+		}
+		counts[dir]++
+	}
+	dirs := make([]string, 0, len(counts))
+	for dir := range counts {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d files:\n", len(files))
+	for _, dir := range dirs {
+		label := dir + "/"
+		if dir == "." {
+			label = "(root)"
+		}
+		fmt.Fprintf(&b, "- %s %d files\n", label, counts[dir])
+	}
+	return b.String()
+}
+
+// sampledContents introduces the file excerpts of sampleProject, or is empty when there are none
+func sampledContents(samples string) string {
+	if samples == "" {
+		return ""
+	}
+	return "\n\nSampled contents (configuration, package docs and top-level declarations):\n" + samples +
+		"\nGround your findings in these excerpts where they show the code, rather than in file names alone."
+}
+
+// projectMetrics measures a project's code locally, for the report and the prompt, with the
+// dependency findings of its package graph when it is a Go module
+func projectMetrics(projectPath string, files []string) string {
+	stats := metrics.Compute(projectPath, files).String()
+	if graph, err := impact.Load(projectPath); err == nil && graph != nil {
+		stats += graph.Summary()
+	}
+	return stats
+}
+
+// withMetrics adds a project's metrics to the overview of its directories
+func withMetrics(overview, stats string) string {
+	return overview + "\nMetrics:\n" + stats
+}
+
+// projectReportHeader heads a project report with its metrics and a note on how it was made
+func (a *TerminalAnalyzer) projectReportHeader(stats, note string) string {
+	return fmt.Sprintf("# Project Analysis\n*Generated on %s*\n\n* Metrics\n%s\n%s", a.generated(), stats, note)
+}
+
+// projectStructureMessages returns the chat messages for a project structure analysis
+func (a *TerminalAnalyzer) projectStructureMessages(fileList, stats, samples string) []openai.ChatCompletionMessage {
+	return []openai.ChatCompletionMessage{
+		{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: a.getContextualPrompt() + projectArchitectPrompt,
+		},
+		{
+			Role:    openai.ChatMessageRoleUser,
+			Content: fmt.Sprintf("Project Structure:\n%s\n\nMetrics:\n%s%s\n\nAnalyze this project structure and identify issues at each priority level, backing them with the metrics where they apply.", fileList, stats, sampledContents(samples)),
+		},
+	}
+}
+
+// projectPartMessages returns the chat messages for the analysis of one part of a large project
+func (a *TerminalAnalyzer) projectPartMessages(overview string, part projectPart, samples string) []openai.ChatCompletionMessage {
+	return []openai.ChatCompletionMessage{
+		{
+			Role: openai.ChatMessageRoleSystem,
+			Content: a.getContextualPrompt() + projectArchitectPrompt +
+				"\n\nThe project is too large for one review, so you are reviewing one part of it. Report the issues of this part, and of how it fits the overview of the whole project.",
+		},
+		{
+			Role:    openai.ChatMessageRoleUser,
+			Content: fmt.Sprintf("Project overview:\n%s\nPart: %s\n%s%s\n\nAnalyze the structure of this part and identify issues at each priority level.", overview, part.label, strings.Join(part.files, "\n"), sampledContents(samples)),
+		},
+	}
+}
+
+// projectSynthesisMessages returns the chat messages that combine the findings of a large
+// project's parts into one report
+func (a *TerminalAnalyzer) projectSynthesisMessages(overview, findings string) []openai.ChatCompletionMessage {
+	return []openai.ChatCompletionMessage{
+		{
+			Role: openai.ChatMessageRoleSystem,
+			Content: a.getContextualPrompt() + projectArchitectPrompt +
+				"\n\nThe project was too large for one review, so its parts were reviewed separately. Combine their findings into one project-level report: merge duplicates, prefer issues that cut across parts, drop minor ones, and keep each issue at the right priority level.",
+		},
+		{
+			Role:    openai.ChatMessageRoleUser,
+			Content: fmt.Sprintf("Project overview:\n%s\nFindings by part:\n%s", overview, findings),
+		},
+	}
+}
+
+// projectUpdateMessages returns the chat messages that update a project's previous findings for
+// the files changed since
+func (a *TerminalAnalyzer) projectUpdateMessages(overview string, previous *Analysis, changed, removed []string, samples string) []openai.ChatCompletionMessage {
+	var findings strings.Builder
+	writeFindings(&findings, "Critical", previous.CriticalIssues)
+	writeFindings(&findings, "Should Fix", previous.ShouldFix)
+	writeFindings(&findings, "Could Fix", previous.CouldFix)
+	if findings.Len() == 0 {
+		findings.WriteString("None\n")
+	}
+	removedList := "None"
+	if len(removed) > 0 {
+		removedList = strings.Join(removed, "\n")
+	}
+
+	return []openai.ChatCompletionMessage{
+		{
+			Role: openai.ChatMessageRoleSystem,
+			Content: a.getContextualPrompt() + projectArchitectPrompt +
+				"\n\nThe project was reviewed before, and some files have changed since. Update the previous findings into a complete current report: keep the findings the changes do not affect, drop those the changes resolved, and add the issues the changed files introduce, each at the right priority level.",
+		},
+		{
+			Role: openai.ChatMessageRoleUser,
+			Content: fmt.Sprintf("Project overview:\n%s\nPrevious findings:\n%s\nChanged or added files:\n%s\n\nRemoved files:\n%s%s\n\nUpdate the findings for these changes.",
+				overview, findings.String(), strings.Join(changed, "\n"), removedList, sampledContents(samples)),
+		},
+	}
+}
+
+// EstimateProjectStructure estimates the size and cost of analyzing a project structure,
+// including every part and the combined report of a large project
+func (a *TerminalAnalyzer) EstimateProjectStructure(projectPath string) (tokens.Estimate, error) {
+	files, err := listProjectFiles(projectPath)
+	if err != nil {
+		return tokens.Estimate{}, err
+	}
+
+	count := func(messages []openai.ChatCompletionMessage) int {
+		var contents []string
+		for _, msg := range messages {
+			contents = append(contents, msg.Content)
+		}
+		return tokens.CountMessages(a.model, contents...) + functionSchemaTokens
+	}
+
+	stats := projectMetrics(projectPath, files)
+	parts := splitProject(a.model, files, projectPartTokens)
+	if len(parts) <= 1 {
+		samples := sampleProject(a.model, projectPath, files, projectSampleTokens)
+		return tokens.NewEstimate(a.model, count(a.projectStructureMessages(strings.Join(files, "\n"), stats, samples)), projectStructureMaxTokens), nil
+	}
+
+	overview := withMetrics(projectOverview(files), stats)
+	prompt, completion := 0, projectStructureMaxTokens
+	for _, part := range parts {
+		samples := sampleProject(a.model, projectPath, part.files, projectSampleTokens)
+		prompt += count(a.projectPartMessages(overview, part, samples))
+		completion += tokens.DefaultCompletionTokens
+	}
+	// The combined report reads every part's findings, up to their budget
+	prompt += count(a.projectSynthesisMessages(overview, "")) + min(len(parts)*tokens.DefaultCompletionTokens, findingsBudget(a.model))
+	return tokens.NewEstimate(a.model, prompt, completion), nil
+}
+
+// AnalyzeProjectStructure analyzes the project structure, with excerpts of its files, and returns
+// formatted terminal output. A project whose file list does not fit in one request is analyzed in
+// parts, reported through the progress callback, and their findings are combined into one report.
+func (a *TerminalAnalyzer) AnalyzeProjectStructure(ctx context.Context, projectPath string) (string, error) {
+	files, err := listProjectFiles(projectPath)
+	if err != nil {
+		return "", err
+	}
+
+	stats := projectMetrics(projectPath, files)
+	parts := splitProject(a.model, files, projectPartTokens)
+	var messages []openai.ChatCompletionMessage
+	partsNote := ""
+	if len(parts) <= 1 {
+		messages = a.projectStructureMessages(strings.Join(files, "\n"), stats, sampleProject(a.model, projectPath, files, projectSampleTokens))
+	} else {
+		overview := withMetrics(projectOverview(files), stats)
+		findings, err := a.analyzeProjectParts(ctx, projectPath, overview, parts)
+		if err != nil {
+			return "", err
+		}
+		messages = a.projectSynthesisMessages(overview, findings)
+		partsNote = fmt.Sprintf("Analyzed %d files in %d parts.\n\n", len(files), len(parts))
+	}
+
+	header := func() string {
+		return a.projectReportHeader(stats, partsNote)
+	}
+
+	var result Analysis
+	err = a.completeStreamed(
+		ctx,
+		openai.ChatCompletionRequest{
+			Model:     a.model,
+			Messages:  messages,
+			MaxTokens: projectStructureMaxTokens,
+		},
+		codeAnalysisFunction,
+		analysisLayout,
+		header,
+		&result,
+	)
+	if err != nil {
+		return "", fmt.Errorf("error getting analysis: %w", err)
+	}
+	a.lastAnalysis = &result
+	a.finishStream("")
+
+	return header() + formatAnalysis(&result), nil
+}
+
+// AnalyzeProjectChanges updates the previous analysis of a project for the files changed or removed
+// since, and returns the complete report as formatted terminal output. When the changed files are
+// too many for one request, the whole project is analyzed again.
+func (a *TerminalAnalyzer) AnalyzeProjectChanges(ctx context.Context, projectPath string, previous *Analysis, changed, removed []string) (string, error) {
+	if tokens.Count(a.model, strings.Join(slices.Concat(changed, removed), "\n")) > projectPartTokens {
+		return a.AnalyzeProjectStructure(ctx, projectPath)
+	}
+	files, err := listProjectFiles(projectPath)
+	if err != nil {
+		return "", err
+	}
+
+	stats := projectMetrics(projectPath, files)
+	messages := a.projectUpdateMessages(withMetrics(projectOverview(files), stats), previous, changed, removed,
+		sampleProject(a.model, projectPath, changed, projectSampleTokens))
+	changesNote := fmt.Sprintf("Updated for %d changed and %d removed files since the last analysis.\n\n", len(changed), len(removed))
+	header := func() string {
+		return a.projectReportHeader(stats, changesNote)
+	}
+
+	var result Analysis
+	err = a.completeStreamed(
+		ctx,
+		openai.ChatCompletionRequest{
+			Model:     a.model,
+			Messages:  messages,
+			MaxTokens: projectStructureMaxTokens,
+		},
+		codeAnalysisFunction,
+		analysisLayout,
+		header,
+		&result,
+	)
+	if err != nil {
+		return "", fmt.Errorf("error getting analysis: %w", err)
+	}
+	a.lastAnalysis = &result
+	a.finishStream("")
+
+	return header() + formatAnalysis(&result), nil
+}
+
+// findingsBudget returns how many tokens the findings of a large project's parts may take in the
+// request that combines them: projectFindingsTokens, or half the model's context when smaller
+func findingsBudget(model string) int {
+	return min(projectFindingsTokens, tokens.ContextWindow(model)/2)
+}
+
+// analyzeProjectParts analyzes the parts of a large project in parallel and returns their findings,
+// headed by part in project order, for the combined report. The first part that fails cancels
+// the requests of the others.
+func (a *TerminalAnalyzer) analyzeProjectParts(ctx context.Context, projectPath, overview string, parts []projectPart) (string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]Analysis, len(parts))
+	var mu sync.Mutex
+	var firstErr error
+	done := 0
+	pool.Run(pool.Workers(a.workers), len(parts), func(i int) {
+		if ctx.Err() != nil {
+			return
+		}
+		part := parts[i]
+		err := a.Clone().complete(
+			ctx,
+			openai.ChatCompletionRequest{
+				Model:     a.model,
+				Messages:  a.projectPartMessages(overview, part, sampleProject(a.model, projectPath, part.files, projectSampleTokens)),
+				MaxTokens: tokens.DefaultCompletionTokens,
+			},
+			codeAnalysisFunction,
+			&results[i],
+		)
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			// Parts cancelled after the first failure report that failure, not their own
+			if firstErr == nil {
+				firstErr = fmt.Errorf("error analyzing %s: %w", part.label, err)
+				cancel()
+			}
+			return
+		}
+		if a.progress != nil {
+			done++
+			a.progress(done, len(parts), part.label)
+		}
+	})
+	if firstErr != nil {
+		return "", firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return partFindings(a.model, parts, results, findingsBudget(a.model)), nil
+}
+
+// partFindings writes the findings of each part under its heading, in project order. Each part
+// gets an equal share of budget tokens and keeps its most severe findings within it, noting how
+// many it left out.
+func partFindings(model string, parts []projectPart, results []Analysis, budget int) string {
+	share := budget / max(len(parts), 1)
+	var findings strings.Builder
+	for i, part := range parts {
+		fmt.Fprintf(&findings, "\n## %s (%d files)\n", part.label, len(part.files))
+		var lines strings.Builder
+		writeFindings(&lines, "Critical", results[i].CriticalIssues)
+		writeFindings(&lines, "Should Fix", results[i].ShouldFix)
+		writeFindings(&lines, "Could Fix", results[i].CouldFix)
+		all := strings.SplitAfter(lines.String(), "\n")
+		all = all[:len(all)-1] // SplitAfter leaves an empty string after the last newline
+
+		used, kept := 0, 0
+		for _, line := range all {
+			if used += tokens.Count(model, line); used > share {
+				break
+			}
+			findings.WriteString(line)
+			kept++
+		}
+		if left := len(all) - kept; left > 0 {
+			fmt.Fprintf(&findings, "- (%d less severe findings left out)\n", left)
+		}
+	}
+	return findings.String()
+}
+
+// writeFindings writes the findings of one priority level, or nothing if there are none
+func writeFindings(b *strings.Builder, level string, findings []string) {
+	for _, finding := range findings {
+		fmt.Fprintf(b, "- %s: %s\n", level, strings.TrimSpace(finding))
+	}
+}
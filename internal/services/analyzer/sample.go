@@ -0,0 +1,179 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bkidd1/wash-cli/internal/utils/chunk"
+	"github.com/bkidd1/wash-cli/internal/utils/secrets"
+	"github.com/bkidd1/wash-cli/internal/utils/tokens"
+)
+
+const (
+	// projectSampleTokens caps the file contents sampled into each project analysis request
+	projectSampleTokens = 2500
+	// sampleMaxLines caps the lines sampled from one file
+	sampleMaxLines = 40
+	// sampleMaxFileSize skips files too large to be worth reading for a sample
+	sampleMaxFileSize = 512 * 1024
+)
+
+// configFiles describe a project's dependencies, build and tooling, and are sampled first
+var configFiles = map[string]bool{
+	"go.mod": true, "package.json": true, "tsconfig.json": true, "Cargo.toml": true,
+	"pyproject.toml": true, "requirements.txt": true, "setup.py": true, "Gemfile": true,
+	"pom.xml": true, "build.gradle": true, "build.gradle.kts": true, "composer.json": true,
+	"Makefile": true, "Dockerfile": true, "docker-compose.yml": true, "docker-compose.yaml": true,
+}
+
+// declPattern matches the top-level declarations of languages chunk does not parse, such as
+// Python, Ruby, Rust, Java and C#
+var declPattern = regexp.MustCompile(`^(?:(?:pub(?:\([\w:]+\))?|public|private|protected|internal|export|abstract|final|static|sealed|async|unsafe|partial)\s+)*` +
+	`(?:def|class|module|fn|struct|enum|trait|impl|interface|record|object|func|function|type|mod)\b`)
+
+// valueDecl matches a const, var or let declaration, whose value may be a literal secret
+var valueDecl = regexp.MustCompile(`^\s*(?:export\s+)?(?:const|var|let)\b`)
+
+// sampleProject returns excerpts of a project's files that fit in budget tokens, so the structure
+// analysis sees real code: configuration files first, then the package docs and top-level
+// declarations of source files, shallowest first and one file per directory before the rest
+func sampleProject(model, projectPath string, files []string, budget int) string {
+	var b strings.Builder
+	used := 0
+	for _, file := range sampleOrder(files) {
+		if budget-used < 50 {
+			break
+		}
+		fullPath := filepath.Join(projectPath, filepath.FromSlash(file))
+		if info, err := os.Stat(fullPath); err != nil || info.Size() > sampleMaxFileSize {
+			continue
+		}
+		content, err := os.ReadFile(fullPath)
+		if err != nil {
+			continue
+		}
+		sample := sampleFile(file, content)
+		if sample == "" {
+			continue
+		}
+		excerpt := fmt.Sprintf("### %s\n%s\n", file, sample)
+		if size := tokens.Count(model, excerpt); used+size <= budget {
+			b.WriteString(excerpt)
+			used += size
+		}
+	}
+	return b.String()
+}
+
+// sampleOrder orders files for sampling: configuration files, then the first file of each
+// directory, then the others, each shallowest first
+func sampleOrder(files []string) []string {
+	rank := make(map[string]int, len(files))
+	seenDirs := make(map[string]bool)
+	for _, file := range files {
+		dir := path.Dir(file)
+		switch {
+		case configFiles[path.Base(file)]:
+			rank[file] = 0
+		case !seenDirs[dir]:
+			rank[file] = 1
+		default:
+			rank[file] = 2
+		}
+		seenDirs[dir] = true
+	}
+
+	ordered := append([]string(nil), files...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if rank[ordered[i]] != rank[ordered[j]] {
+			return rank[ordered[i]] < rank[ordered[j]]
+		}
+		return strings.Count(ordered[i], "/") < strings.Count(ordered[j], "/")
+	})
+	return ordered
+}
+
+// sampleFile returns the lines of a file that show what it is: the start of a configuration
+// file, or the file comments and top-level declaration lines of source code. It returns "" for
+// files with nothing to show.
+func sampleFile(file string, content []byte) string {
+	lines := strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+	if configFiles[path.Base(file)] {
+		// Indirect Go dependencies say nothing about the project's own choices
+		var kept []string
+		for _, line := range lines {
+			if !strings.HasSuffix(line, "// indirect") {
+				kept = append(kept, line)
+			}
+		}
+		// Configuration such as a docker-compose environment may hold credentials
+		sample, _ := secrets.Redact(strings.Join(kept[:min(len(kept), sampleMaxLines)], "\n"))
+		return strings.TrimRight(sample, "\n")
+	}
+
+	var sampled []string
+	if decls := chunk.Declarations(file, content); decls != nil {
+		// The package doc and clause, or the file comments, precede the first declaration
+		header := len(lines)
+		if len(decls) > 0 {
+			header = min(decls[0].Start, header)
+		}
+		sampled = append(sampled, nonEmpty(lines[:header])...)
+		for _, decl := range decls {
+			if decl.Name == "import" {
+				continue
+			}
+			line := signature(lines, decl.Start, decl.End)
+			if strings.HasSuffix(line, "(") {
+				// A grouped declaration, named by its first spec
+				line = decl.Name + " ..."
+			}
+			if line != "" {
+				sampled = append(sampled, line)
+			}
+		}
+	} else {
+		for _, line := range lines {
+			if declPattern.MatchString(line) {
+				sampled = append(sampled, strings.TrimRight(line, " {:"))
+			}
+		}
+	}
+	return strings.Join(sampled[:min(len(sampled), sampleMaxLines)], "\n")
+}
+
+// signature returns the first line of a declaration after its doc comment and decorators, without
+// the value of a const or var, which may be a literal secret
+func signature(lines []string, start, end int) string {
+	for i := start; i < min(end, len(lines)); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == "" || strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "/*") ||
+			strings.HasPrefix(trimmed, "*") || strings.HasPrefix(trimmed, "@") {
+			continue
+		}
+		line := lines[i]
+		if valueDecl.MatchString(line) {
+			if eq := strings.Index(line, "="); eq >= 0 {
+				line = line[:eq]
+			}
+		}
+		return strings.TrimRight(line, " {")
+	}
+	return ""
+}
+
+// nonEmpty drops blank lines
+func nonEmpty(lines []string) []string {
+	var kept []string
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			kept = append(kept, line)
+		}
+	}
+	return kept
+}
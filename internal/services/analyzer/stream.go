@@ -0,0 +1,368 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// streamSection is how one list argument of a function call is shown while it streams
+type streamSection struct {
+	heading string
+	// empty is shown when the list has no items
+	empty string
+}
+
+// streamLayout is how the arguments of a function call are shown while they stream: each list
+// argument with a section is written as "- " items under its heading, and other arguments are not
+// shown. It matches the formatting of the complete answer.
+type streamLayout struct {
+	intro     string
+	separator string
+	sections  map[string]streamSection
+}
+
+// analysisLayout streams code and project analyses as formatAnalysis renders them
+var analysisLayout = streamLayout{
+	intro:     "You can copy this analysis into your chat window!\n\n",
+	separator: "\n\n",
+	sections: map[string]streamSection{
+		"critical_issues": {heading: "* Critical! Must Fix\n", empty: "No issues found"},
+		"should_fix":      {heading: "* Should Fix\n", empty: "No issues found"},
+		"could_fix":       {heading: "* Could Fix\n", empty: "No issues found"},
+	},
+}
+
+// bugLayout streams bug analyses
+var bugLayout = streamLayout{
+	intro:     "\n",
+	separator: "\n\n",
+	sections: map[string]streamSection{
+		"potential_causes":    {heading: "Potential Causes:\n", empty: "No potential causes identified"},
+		"suggested_solutions": {heading: "Suggested Solutions:\n", empty: "No suggested solutions identified"},
+	},
+}
+
+// SetStream makes file, project and bug analyses write their output to w while the model generates
+// it; a nil w turns streaming off. begin is called before the first output, such as to stop a
+// loading animation.
+func (a *TerminalAnalyzer) SetStream(w io.Writer, begin func()) {
+	a.stream = w
+	a.streamBegin = begin
+}
+
+// Streamed reports whether the output of the last analysis was written to the stream, so it need
+// not be printed again
+func (a *TerminalAnalyzer) Streamed() bool {
+	return a.streamed
+}
+
+// completeStreamed is complete for the analyses shown in the terminal. With a stream set, the answer
+// is streamed: once the model starts answering, header() is written, then the findings in layout as
+// they are generated. The caller ends the output with finishStream. With a cache set, the stored
+// answer to an identical request is used instead, and is not streamed.
+func (a *TerminalAnalyzer) completeStreamed(ctx context.Context, req openai.ChatCompletionRequest, fn openai.FunctionDefinition, layout streamLayout, header func() string, out interface{}) error {
+	a.streamed = false
+	a.cachedAt = time.Time{}
+	a.suppressed = 0
+	if err := a.completeCached(ctx, req, fn, layout, header, out); err != nil {
+		return err
+	}
+	// Cached answers keep every finding, so a changed baseline applies to them too
+	if analysis, ok := out.(*Analysis); ok && a.suppress != nil {
+		a.suppressed = analysis.remove(a.suppress)
+	}
+	return nil
+}
+
+// completeCached answers from the cache when one is set, and otherwise sends the request
+func (a *TerminalAnalyzer) completeCached(ctx context.Context, req openai.ChatCompletionRequest, fn openai.FunctionDefinition, layout streamLayout, header func() string, out interface{}) error {
+	if a.cache == nil {
+		return a.sendStreamed(ctx, req, fn, layout, header, out)
+	}
+
+	key := cacheKey(a.model, req, fn)
+	if entry := a.cache.get(key); entry != nil && json.Unmarshal(entry.Arguments, out) == nil {
+		provenance := entry.Provenance
+		a.lastProvenance = &provenance
+		a.cachedAt = entry.CreatedAt
+		return nil
+	}
+	if err := a.sendStreamed(ctx, req, fn, layout, header, out); err != nil {
+		return err
+	}
+	a.cache.put(key, a.lastProvenance, out)
+	return nil
+}
+
+// sendStreamed sends the request of completeStreamed to the model
+func (a *TerminalAnalyzer) sendStreamed(ctx context.Context, req openai.ChatCompletionRequest, fn openai.FunctionDefinition, layout streamLayout, header func() string, out interface{}) error {
+	if a.stream == nil {
+		return a.complete(ctx, req, fn, out)
+	}
+
+	return a.withFallback(func(provenance *Provenance) error {
+		req.Model = provenance.Model
+		renderer := &streamRenderer{w: a.stream, layout: layout, skip: a.suppress, begin: func() {
+			a.lastProvenance = provenance
+			a.streamed = true
+			if a.streamBegin != nil {
+				a.streamBegin()
+			}
+			io.WriteString(a.stream, header())
+		}}
+		err := createStreamedCompletion(ctx, a.client, req, fn, out, renderer)
+		if err != nil && a.streamed {
+			// Falling back would repeat the output already shown
+			return fmt.Errorf("analysis interrupted: %v", err)
+		}
+		return err
+	})
+}
+
+// finishStream writes the end of a streamed analysis
+func (a *TerminalAnalyzer) finishStream(footer string) {
+	if a.streamed {
+		io.WriteString(a.stream, footer+"\n")
+	}
+}
+
+// createStreamedCompletion is createStructuredCompletion with the function call arguments streamed
+// to renderer as they arrive
+func createStreamedCompletion(ctx context.Context, client *openai.Client, req openai.ChatCompletionRequest, fn openai.FunctionDefinition, out interface{}, renderer *streamRenderer) error {
+	forceFunction(&req, fn)
+	// Streamed responses only report token usage when asked to
+	req.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+
+	stream, err := client.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	var name string
+	var arguments strings.Builder
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		// The final usage chunk has no choices
+		if len(resp.Choices) == 0 {
+			continue
+		}
+		for _, call := range resp.Choices[0].Delta.ToolCalls {
+			if call.Index != nil && *call.Index > 0 {
+				continue
+			}
+			if call.Function.Name != "" {
+				name = call.Function.Name
+			}
+			arguments.WriteString(call.Function.Arguments)
+			renderer.write(call.Function.Arguments)
+		}
+	}
+
+	if name != fn.Name {
+		return fmt.Errorf("response did not include a %s call", fn.Name)
+	}
+	if err := json.Unmarshal([]byte(arguments.String()), out); err != nil {
+		return fmt.Errorf("error parsing %s arguments: %w", fn.Name, err)
+	}
+	return nil
+}
+
+// streamRenderer writes the string lists of function call arguments as the JSON arrives in pieces
+type streamRenderer struct {
+	w      io.Writer
+	layout streamLayout
+	// begin is called before the first output
+	begin   func()
+	started bool
+	// skip leaves out the items for which it returns true; items are then written once complete
+	skip func(severity, item string) bool
+	item strings.Builder
+
+	depth     int
+	inString  bool
+	escaped   bool
+	unicode   []byte // hex digits of a \u escape being read, nil outside one
+	expectKey bool
+	isKey     bool
+	key       strings.Builder
+
+	// section is the section of the list being read, nil when the list is not shown, and
+	// sectionKey its argument name
+	section    *streamSection
+	sectionKey string
+	sections   int
+	items      int
+	// leading is true until an item's first non-space character
+	leading bool
+}
+
+// write renders the next piece of the arguments
+func (r *streamRenderer) write(piece string) {
+	if piece == "" {
+		return
+	}
+	if !r.started {
+		r.started = true
+		r.begin()
+	}
+
+	for i := 0; i < len(piece); i++ {
+		c := piece[i]
+		if r.inString {
+			r.stringByte(c)
+			continue
+		}
+
+		switch c {
+		case '{', '[':
+			r.depth++
+			if c == '{' && r.depth == 1 {
+				r.expectKey = true
+			}
+			if c == '[' && r.depth == 2 {
+				r.startList()
+			}
+		case '}', ']':
+			if c == ']' && r.depth == 2 && r.section != nil {
+				if r.items == 0 {
+					r.out(r.section.empty)
+				}
+				r.section = nil
+			}
+			r.depth--
+		case ',':
+			if r.depth == 1 {
+				r.expectKey = true
+			}
+		case ':':
+			if r.depth == 1 {
+				r.expectKey = false
+			}
+		case '"':
+			r.inString = true
+			r.isKey = r.depth == 1 && r.expectKey
+			if r.isKey {
+				r.key.Reset()
+			} else if r.depth == 2 && r.section != nil {
+				r.leading = true
+				if r.skip != nil {
+					r.item.Reset()
+				} else {
+					if r.items > 0 {
+						r.out("\n")
+					}
+					r.out("- ")
+					r.items++
+				}
+			}
+		}
+	}
+}
+
+// startList starts the section of the list argument that just opened, if it is shown
+func (r *streamRenderer) startList() {
+	section, ok := r.layout.sections[r.key.String()]
+	if !ok {
+		return
+	}
+	if r.sections == 0 {
+		r.out(r.layout.intro)
+	} else {
+		r.out(r.layout.separator)
+	}
+	r.out(section.heading)
+	r.section = &section
+	r.sectionKey = r.key.String()
+	r.sections++
+	r.items = 0
+}
+
+// stringByte handles a byte inside a JSON string, decoding escapes
+func (r *streamRenderer) stringByte(c byte) {
+	switch {
+	case r.unicode != nil:
+		r.unicode = append(r.unicode, c)
+		if len(r.unicode) == 4 {
+			if code, err := strconv.ParseUint(string(r.unicode), 16, 32); err == nil {
+				r.text(string(rune(code)))
+			}
+			r.unicode = nil
+		}
+	case r.escaped:
+		r.escaped = false
+		switch c {
+		case 'n':
+			r.text("\n")
+		case 't':
+			r.text("\t")
+		case 'u':
+			r.unicode = []byte{}
+		case 'r', 'b', 'f':
+		default:
+			r.text(string([]byte{c}))
+		}
+	case c == '\\':
+		r.escaped = true
+	case c == '"':
+		r.inString = false
+		if !r.isKey && r.depth == 2 && r.section != nil && r.skip != nil {
+			r.endItem()
+		}
+	default:
+		r.text(string([]byte{c}))
+	}
+}
+
+// text adds decoded string content to the key being read or the item being shown
+func (r *streamRenderer) text(s string) {
+	if r.isKey {
+		r.key.WriteString(s)
+		return
+	}
+	if r.depth != 2 || r.section == nil {
+		return
+	}
+	if r.leading {
+		if strings.TrimSpace(s) == "" {
+			return
+		}
+		r.leading = false
+	}
+	if r.skip != nil {
+		r.item.WriteString(s)
+		return
+	}
+	r.out(s)
+}
+
+// endItem writes a complete item unless it is skipped
+func (r *streamRenderer) endItem() {
+	item := strings.TrimSpace(r.item.String())
+	if item == "" || r.skip(r.sectionKey, item) {
+		return
+	}
+	if r.items > 0 {
+		r.out("\n")
+	}
+	r.out("- " + item)
+	r.items++
+}
+
+func (r *streamRenderer) out(s string) {
+	io.WriteString(r.w, s)
+}
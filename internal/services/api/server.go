@@ -0,0 +1,211 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/notes"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/fsutil"
+	"github.com/bkidd1/wash-cli/pkg/version"
+)
+
+const (
+	// DefaultAddr is the default local API address; remote agents reach it through an SSH tunnel
+	DefaultAddr = "127.0.0.1:7420"
+	// TokenEnv is the environment variable holding the shared API token
+	TokenEnv = "WASH_AGENT_TOKEN"
+	// TokenFile holds the API token generated when none is given, in the data directory
+	TokenFile = "agent_token"
+	// maxBodyBytes limits the size of a pushed note
+	maxBodyBytes = 1 << 20
+)
+
+// HealthResponse is returned by the health endpoint
+type HealthResponse struct {
+	Status  string `json:"status"`
+	Version string `json:"version"`
+}
+
+// Server is the local API that remote agents push notes to
+type Server struct {
+	notesManager *notes.NotesManager
+	token        string
+	server       *http.Server
+}
+
+// NewServer creates a local API server. Every note push must carry the token, so an empty token
+// is refused rather than leaving the notes writable by anything that can reach the port.
+func NewServer(addr string, token string, notesManager *notes.NotesManager) (*Server, error) {
+	if token == "" {
+		return nil, fmt.Errorf("the local API needs a token")
+	}
+
+	s := &Server{
+		notesManager: notesManager,
+		token:        token,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/health", s.handleHealth)
+	mux.HandleFunc("/v1/monitor-notes", s.authorized(s.handleMonitorNote))
+	mux.HandleFunc("/v1/progress-notes", s.authorized(s.handleProgressNote))
+
+	s.server = &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s, nil
+}
+
+// LoadToken returns the token saved in the data directory, generating and saving one on first use,
+// along with the file's path
+func LoadToken() (string, string, error) {
+	dir, err := config.DataDir()
+	if err != nil {
+		return "", "", err
+	}
+	path := filepath.Join(dir, TokenFile)
+
+	data, err := os.ReadFile(path)
+	if err == nil && strings.TrimSpace(string(data)) != "" {
+		return strings.TrimSpace(string(data)), path, nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return "", "", fmt.Errorf("error reading %s: %w", TokenFile, err)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", fmt.Errorf("error generating token: %w", err)
+	}
+	token := hex.EncodeToString(secret)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", "", fmt.Errorf("error creating data directory: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(path, []byte(token+"\n"), 0600); err != nil {
+		return "", "", fmt.Errorf("error saving %s: %w", TokenFile, err)
+	}
+	return token, path, nil
+}
+
+// Start listens on the server address and serves requests in the background
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.server.Addr, err)
+	}
+
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Printf("Error serving local API: %v\n", err)
+		}
+	}()
+	return nil
+}
+
+// Stop shuts the server down, waiting for in-flight requests
+func (s *Server) Stop(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
+// authorized rejects requests without the shared token
+func (s *Server) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		expected := "Bearer " + s.token
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(expected)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, HealthResponse{Status: "ok", Version: version.Version})
+}
+
+func (s *Server) handleMonitorNote(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var note notes.MonitorNote
+	if err := decodeBody(w, r, &note); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validProjectName(note.ProjectName); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if note.Timestamp.IsZero() {
+		note.Timestamp = time.Now()
+	}
+
+	if err := s.notesManager.SaveMonitorNote(note.ProjectName, &note); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *Server) handleProgressNote(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var note notes.ProjectProgressNote
+	if err := decodeBody(w, r, &note); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validProjectName(note.ProjectName); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.notesManager.SaveProjectProgress(&note); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// decodeBody decodes a size-limited JSON request body
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return fmt.Errorf("invalid note: %w", err)
+	}
+	return nil
+}
+
+// validProjectName rejects names that would escape the notes directories
+func validProjectName(name string) error {
+	if name == "" || name == "." || name == ".." || filepath.Base(name) != name {
+		return fmt.Errorf("invalid project name %q", name)
+	}
+	return nil
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
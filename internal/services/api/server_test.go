@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/notes"
+)
+
+func newTestServer(t *testing.T, token string) (*httptest.Server, string) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	notesManager, err := notes.NewNotesManager()
+	if err != nil {
+		t.Fatalf("Failed to create notes manager: %v", err)
+	}
+
+	server, err := NewServer("", token, notesManager)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	ts := httptest.NewServer(server.server.Handler)
+	t.Cleanup(ts.Close)
+	return ts, home
+}
+
+func TestPushMonitorNote(t *testing.T) {
+	ts, home := newTestServer(t, "secret")
+
+	note := &notes.MonitorNote{Timestamp: time.Now(), ProjectName: "demo"}
+	if err := NewClient(ts.URL, "secret").PushMonitorNote(context.Background(), note); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+
+	files, err := os.ReadDir(filepath.Join(home, ".wash", "monitor_notes", "demo"))
+	if err != nil || len(files) == 0 {
+		t.Errorf("Expected the note to be saved, got %v (%v)", files, err)
+	}
+
+	if err := NewClient(ts.URL, "wrong").PushMonitorNote(context.Background(), note); err == nil {
+		t.Error("Expected a push with the wrong token to be rejected")
+	}
+}
+
+func TestRejectsPathTraversal(t *testing.T) {
+	ts, _ := newTestServer(t, "secret")
+
+	note := &notes.MonitorNote{Timestamp: time.Now(), ProjectName: "../escape"}
+	if err := NewClient(ts.URL, "secret").PushMonitorNote(context.Background(), note); err == nil {
+		t.Error("Expected a project name with path separators to be rejected")
+	}
+}
+
+func TestRequiresToken(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	notesManager, err := notes.NewNotesManager()
+	if err != nil {
+		t.Fatalf("Failed to create notes manager: %v", err)
+	}
+	if _, err := NewServer(DefaultAddr, "", notesManager); err == nil {
+		t.Error("Expected a server without a token to be refused")
+	}
+
+	ts, _ := newTestServer(t, "secret")
+	note := &notes.MonitorNote{Timestamp: time.Now(), ProjectName: "demo"}
+	if err := NewClient(ts.URL, "").PushMonitorNote(context.Background(), note); err == nil {
+		t.Error("Expected a push without a token to be rejected")
+	}
+}
+
+func TestLoadTokenIsGeneratedOnce(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	token, path, err := LoadToken()
+	if err != nil || len(token) != 64 {
+		t.Fatalf("Expected a generated token, got %q, %v", token, err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the token saved readable by the owner only, got %v, %v", info, err)
+	}
+	if again, _, err := LoadToken(); err != nil || again != token {
+		t.Errorf("Expected the saved token to be reused, got %q, %v", again, err)
+	}
+}
@@ -0,0 +1,435 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/attachments"
+	"github.com/bkidd1/wash-cli/internal/utils/crypt"
+	"github.com/bkidd1/wash-cli/internal/utils/fsutil"
+	"github.com/bkidd1/wash-cli/pkg/version"
+)
+
+const (
+	// FormatVersion is the archive layout version written to the manifest
+	FormatVersion = 1
+	// manifestName is the first entry of every archive
+	manifestName = "manifest.json"
+	// maxFileBytes guards against oversized archive entries
+	maxFileBytes = 64 << 20
+)
+
+// projectStores are the directories under projects/<project> that archives carry; the rest of the
+// project directory holds caches and machine state that are not moved between machines
+var projectStores = []string{"notes", "bugs", "plans", "estimates"}
+
+// FileEntry records one archived file and its checksum
+type FileEntry struct {
+	Path   string `json:"path"` // Relative to ~/.wash, slash separated
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Manifest describes the contents of an archive
+type Manifest struct {
+	FormatVersion int         `json:"format_version"`
+	Project       string      `json:"project"`
+	CreatedAt     time.Time   `json:"created_at"`
+	WashVersion   string      `json:"wash_version"`
+	Files         []FileEntry `json:"files"`
+}
+
+// ImportResult summarizes an import
+type ImportResult struct {
+	Manifest  *Manifest
+	Written   []string
+	Unchanged []string
+	Conflicts []string // Existing files with different content that were not overwritten
+}
+
+// ProjectFiles lists the files under baseDir that belong to a project, relative and slash separated
+func ProjectFiles(baseDir string, project string, username string, cipher *crypt.Cipher) ([]string, error) {
+	var files []string
+
+	// Project notes, bugs, plans and estimates, and monitor notes
+	dirs := []string{filepath.Join("monitor_notes", project)}
+	for _, store := range projectStores {
+		dirs = append(dirs, filepath.Join("projects", project, store))
+	}
+	for _, dir := range dirs {
+		root := filepath.Join(baseDir, dir)
+		err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if info.IsDir() || !archivable(info.Name()) {
+				return nil
+			}
+			rel, err := filepath.Rel(baseDir, p)
+			if err != nil {
+				return err
+			}
+			files = append(files, filepath.ToSlash(rel))
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error listing %s: %w", dir, err)
+		}
+	}
+
+	// Progress notes are stored flat as <project>_<id>.json
+	progress, err := filepath.Glob(filepath.Join(baseDir, "progress", project+"_*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing progress notes: %w", err)
+	}
+	for _, p := range progress {
+		if belongsTo(p, cipher, project) {
+			files = append(files, path.Join("progress", filepath.Base(p)))
+		}
+	}
+
+	// Remember notes record their project in metadata
+	remember, err := filepath.Glob(filepath.Join(baseDir, "remember", username, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing remember notes: %w", err)
+	}
+	for _, p := range remember {
+		if rememberBelongsTo(p, cipher, project) {
+			files = append(files, path.Join("remember", username, filepath.Base(p)))
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// archivable skips lock files, temporary files and other hidden files
+func archivable(name string) bool {
+	return !strings.HasPrefix(name, ".")
+}
+
+// readNote reads a note file, decrypting it if needed
+func readNote(p string, cipher *crypt.Cipher) ([]byte, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.Decrypt(data)
+}
+
+// belongsTo reports whether a JSON note's project_name is project
+func belongsTo(p string, cipher *crypt.Cipher, project string) bool {
+	var note struct {
+		ProjectName string `json:"project_name"`
+	}
+	data, err := readNote(p, cipher)
+	if err != nil || json.Unmarshal(data, &note) != nil {
+		return false
+	}
+	return note.ProjectName == project
+}
+
+// rememberBelongsTo reports whether a remember note's metadata names the project
+func rememberBelongsTo(p string, cipher *crypt.Cipher, project string) bool {
+	var note struct {
+		Metadata map[string]interface{} `json:"metadata"`
+	}
+	data, err := readNote(p, cipher)
+	if err != nil || json.Unmarshal(data, &note) != nil {
+		return false
+	}
+	value, _ := note.Metadata["project"].(string)
+	return value == project
+}
+
+// Export writes a gzipped tar of the project's files under baseDir, preceded by a checksummed manifest.
+// Encrypted files are archived as stored; cipher is only used to find which files and attachments belong to the project.
+func Export(baseDir string, project string, username string, cipher *crypt.Cipher, w io.Writer) (*Manifest, error) {
+	files, err := ProjectFiles(baseDir, project, username, cipher)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{
+		FormatVersion: FormatVersion,
+		Project:       project,
+		CreatedAt:     time.Now(),
+		WashVersion:   version.Version,
+	}
+
+	contents := make(map[string][]byte, len(files))
+	for _, rel := range files {
+		data, err := os.ReadFile(filepath.Join(baseDir, filepath.FromSlash(rel)))
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", rel, err)
+		}
+		contents[rel] = data
+	}
+
+	// Bring along the attachments the notes and bug reports reference
+	for _, rel := range files {
+		plain, err := cipher.Decrypt(contents[rel])
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", rel, err)
+		}
+		for _, hash := range attachments.Referenced(plain) {
+			objectRel := path.Join("attachments", "objects", hash[:2], hash)
+			if _, ok := contents[objectRel]; ok {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(baseDir, filepath.FromSlash(objectRel)))
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return nil, fmt.Errorf("error reading attachment %s: %w", hash, err)
+			}
+			contents[objectRel] = data
+			files = append(files, objectRel)
+		}
+	}
+
+	for _, rel := range files {
+		data := contents[rel]
+		manifest.Files = append(manifest.Files, FileEntry{Path: rel, Size: int64(len(data)), SHA256: checksum(data)})
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling manifest: %w", err)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	if err := writeEntry(tw, manifestName, manifestData, manifest.CreatedAt); err != nil {
+		return nil, err
+	}
+	for _, entry := range manifest.Files {
+		if err := writeEntry(tw, entry.Path, contents[entry.Path], manifest.CreatedAt); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("error finishing archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("error compressing archive: %w", err)
+	}
+	return manifest, nil
+}
+
+// writeEntry adds a file to the tar stream
+func writeEntry(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: modTime,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("error writing %s to archive: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("error writing %s to archive: %w", name, err)
+	}
+	return nil
+}
+
+// Import verifies every file in the archive against its manifest checksum and then writes them under baseDir.
+// Nothing is written if any check fails, or if the archive holds anything but the manifest project's notes,
+// bugs, plans, estimates and attachments. Remember notes are imported for username. Each file is written
+// under its directory's lock, as the stores write them.
+func Import(baseDir string, r io.Reader, username string, overwrite bool) (*ImportResult, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("error opening archive: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+
+	manifest, err := readManifest(tr)
+	if err != nil {
+		return nil, err
+	}
+
+	expected := make(map[string]FileEntry, len(manifest.Files))
+	for _, entry := range manifest.Files {
+		if err := validPath(entry.Path); err != nil {
+			return nil, err
+		}
+		if !projectPath(entry.Path, manifest.Project) {
+			return nil, fmt.Errorf("archive contains %s, which is not a note, bug, plan, estimate or attachment of project %s", entry.Path, manifest.Project)
+		}
+		expected[entry.Path] = entry
+	}
+
+	// Read and verify everything before touching ~/.wash
+	contents := make(map[string][]byte, len(expected))
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading archive: %w", err)
+		}
+
+		entry, ok := expected[header.Name]
+		if !ok {
+			return nil, fmt.Errorf("archive contains %s, which is not in the manifest", header.Name)
+		}
+
+		data, err := io.ReadAll(io.LimitReader(tr, maxFileBytes+1))
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", header.Name, err)
+		}
+		if int64(len(data)) != entry.Size || checksum(data) != entry.SHA256 {
+			return nil, fmt.Errorf("integrity check failed for %s", header.Name)
+		}
+		contents[header.Name] = data
+	}
+
+	for rel := range expected {
+		if _, ok := contents[rel]; !ok {
+			return nil, fmt.Errorf("archive is missing %s listed in the manifest", rel)
+		}
+	}
+
+	result := &ImportResult{Manifest: manifest}
+	for _, entry := range manifest.Files {
+		target := filepath.Join(baseDir, filepath.FromSlash(importPath(entry.Path, username)))
+		if err := importFile(result, entry.Path, target, contents[entry.Path], overwrite); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// importFile writes one archived file unless an existing one differs and overwrite is unset,
+// recording the outcome. The directory lock is held from the comparison to the write.
+func importFile(result *ImportResult, rel string, target string, data []byte, overwrite bool) error {
+	unlock, err := fsutil.LockDir(filepath.Dir(target))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if existing, err := os.ReadFile(target); err == nil {
+		if bytes.Equal(existing, data) {
+			result.Unchanged = append(result.Unchanged, rel)
+			return nil
+		}
+		if !overwrite {
+			result.Conflicts = append(result.Conflicts, rel)
+			return nil
+		}
+	}
+
+	if err := fsutil.WriteFileAtomic(target, data, 0644); err != nil {
+		return fmt.Errorf("error writing %s: %w", rel, err)
+	}
+	result.Written = append(result.Written, rel)
+	return nil
+}
+
+// readManifest reads and validates the manifest, which must be the first archive entry
+func readManifest(tr *tar.Reader) (*Manifest, error) {
+	header, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("error reading archive: %w", err)
+	}
+	if header.Name != manifestName {
+		return nil, fmt.Errorf("not a wash notes archive: missing %s", manifestName)
+	}
+
+	var manifest Manifest
+	if err := json.NewDecoder(io.LimitReader(tr, maxFileBytes)).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("error parsing manifest: %w", err)
+	}
+	if manifest.Project == "" || manifest.Project != filepath.Base(manifest.Project) || manifest.Project == ".." {
+		return nil, fmt.Errorf("archive has invalid project name %q", manifest.Project)
+	}
+	if manifest.FormatVersion > FormatVersion {
+		return nil, fmt.Errorf("archive format %d is newer than supported format %d", manifest.FormatVersion, FormatVersion)
+	}
+	return &manifest, nil
+}
+
+// validPath rejects archive paths that would escape ~/.wash
+func validPath(rel string) error {
+	clean := path.Clean(rel)
+	if clean != rel || path.IsAbs(rel) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return fmt.Errorf("archive contains unsafe path %s", rel)
+	}
+	return nil
+}
+
+// projectPath reports whether an archive path is one Export writes for project: a file in one of
+// its stores or monitor notes, one of its progress notes, a remember note or an attachment object.
+// Anything else, such as the global config or trusted hooks, could change how wash runs.
+func projectPath(rel string, project string) bool {
+	parts := strings.Split(rel, "/")
+	for _, part := range parts {
+		if !archivable(part) {
+			return false
+		}
+	}
+
+	switch {
+	case len(parts) >= 4 && parts[0] == "projects" && parts[1] == project:
+		return slices.Contains(projectStores, parts[2])
+	case len(parts) >= 3 && parts[0] == "monitor_notes" && parts[1] == project:
+		return true
+	case len(parts) == 2 && parts[0] == "progress":
+		return strings.HasPrefix(parts[1], project+"_") && path.Ext(parts[1]) == ".json"
+	case len(parts) == 3 && parts[0] == "remember":
+		return path.Ext(parts[2]) == ".json"
+	case len(parts) == 4 && parts[0] == "attachments" && parts[1] == "objects":
+		return attachmentHash(parts[3]) && strings.HasPrefix(parts[3], parts[2]) && len(parts[2]) == 2
+	}
+	return false
+}
+
+// attachmentHash reports whether name is a hex SHA-256, the name of an attachment object
+func attachmentHash(name string) bool {
+	if len(name) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(name)
+	return err == nil && strings.ToLower(name) == name
+}
+
+// importPath maps remember notes to the importing user's directory
+func importPath(rel string, username string) string {
+	parts := strings.SplitN(rel, "/", 3)
+	if len(parts) == 3 && parts[0] == "remember" {
+		return path.Join("remember", username, parts[2])
+	}
+	return rel
+}
+
+// checksum returns the hex SHA-256 of data
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
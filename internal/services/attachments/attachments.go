@@ -0,0 +1,279 @@
+package attachments
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/crypt"
+	"github.com/bkidd1/wash-cli/internal/utils/fsutil"
+)
+
+// DefaultQuotaBytes caps the total size of stored attachments when no quota is configured
+const DefaultQuotaBytes int64 = 1 << 30
+
+// ErrQuotaExceeded is returned when adding an attachment would exceed the storage quota
+var ErrQuotaExceeded = errors.New("attachment storage quota exceeded")
+
+// refPattern matches attachment references in note and bug report text
+var refPattern = regexp.MustCompile(`sha256:[0-9a-f]{64}`)
+
+// Attachment references a stored file from a note or bug report
+type Attachment struct {
+	SHA256    string    `json:"sha256"`
+	Name      string    `json:"name"`
+	MediaType string    `json:"media_type"`
+	Size      int64     `json:"size"`
+	AddedAt   time.Time `json:"added_at"`
+}
+
+// Ref returns the attachment's content address, e.g. sha256:ab12...
+func (a Attachment) Ref() string {
+	return "sha256:" + a.SHA256
+}
+
+// AttachmentManager stores attachments by content hash under ~/.wash/attachments
+type AttachmentManager struct {
+	baseDir string
+	quota   int64
+	cipher  *crypt.Cipher // nil when encryption at rest is off
+}
+
+// NewAttachmentManager creates a new attachment manager; a quota of zero or less uses DefaultQuotaBytes
+func NewAttachmentManager(quota int64) (*AttachmentManager, error) {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+
+	am, err := NewAttachmentManagerAt(filepath.Join(dataDir, "attachments"), quota)
+	if err != nil {
+		return nil, err
+	}
+
+	// Attachments are encrypted at rest along with the notes that reference them
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	am.cipher, err = crypt.Load(cfg.Encryption, dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unlock encrypted attachments: %w", err)
+	}
+	return am, nil
+}
+
+// NewAttachmentManagerAt creates an attachment manager rooted at baseDir
+func NewAttachmentManagerAt(baseDir string, quota int64) (*AttachmentManager, error) {
+	if err := os.MkdirAll(filepath.Join(baseDir, "objects"), 0755); err != nil {
+		return nil, fmt.Errorf("error creating attachments directory: %w", err)
+	}
+	if quota <= 0 {
+		quota = DefaultQuotaBytes
+	}
+	return &AttachmentManager{baseDir: baseDir, quota: quota}, nil
+}
+
+// ObjectPath returns where the content with the given hash is stored
+func (am *AttachmentManager) ObjectPath(hash string) string {
+	hash = strings.TrimPrefix(hash, "sha256:")
+	if len(hash) < 2 {
+		return filepath.Join(am.baseDir, "objects", hash)
+	}
+	return filepath.Join(am.baseDir, "objects", hash[:2], hash)
+}
+
+// Add stores data under its content hash. Identical content is stored once.
+func (am *AttachmentManager) Add(name string, data []byte) (*Attachment, error) {
+	sum := sha256.Sum256(data)
+	attachment := &Attachment{
+		SHA256:    hex.EncodeToString(sum[:]),
+		Name:      filepath.Base(name),
+		MediaType: mediaType(name, data),
+		Size:      int64(len(data)),
+		AddedAt:   time.Now(),
+	}
+
+	objectPath := am.ObjectPath(attachment.SHA256)
+	if _, err := os.Stat(objectPath); err == nil {
+		// Refresh the modification time so pruning treats the content as newly added
+		now := time.Now()
+		os.Chtimes(objectPath, now, now)
+		return attachment, nil
+	}
+
+	unlock, err := fsutil.LockDir(filepath.Join(am.baseDir, "objects"))
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	used, err := am.Usage()
+	if err != nil {
+		return nil, err
+	}
+	if used+attachment.Size > am.quota {
+		return nil, fmt.Errorf("%w: %s would bring usage to %d of %d bytes", ErrQuotaExceeded, attachment.Name, used+attachment.Size, am.quota)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(objectPath), 0755); err != nil {
+		return nil, fmt.Errorf("error creating attachment directory: %w", err)
+	}
+	stored, err := am.cipher.Encrypt(data)
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting attachment: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(objectPath, stored, 0644); err != nil {
+		return nil, fmt.Errorf("error writing attachment: %w", err)
+	}
+	return attachment, nil
+}
+
+// AddFile stores the file at path
+func (am *AttachmentManager) AddFile(path string) (*Attachment, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading attachment: %w", err)
+	}
+	return am.Add(path, data)
+}
+
+// Read returns the content stored under hash, verifying it still matches
+func (am *AttachmentManager) Read(hash string) ([]byte, error) {
+	hash = strings.TrimPrefix(hash, "sha256:")
+	data, err := os.ReadFile(am.ObjectPath(hash))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("attachment %s not found", hash)
+		}
+		return nil, fmt.Errorf("error reading attachment: %w", err)
+	}
+
+	data, err = am.cipher.Decrypt(data)
+	if err != nil {
+		return nil, fmt.Errorf("error reading attachment %s: %w", hash, err)
+	}
+
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != hash {
+		return nil, fmt.Errorf("attachment %s is corrupt", hash)
+	}
+	return data, nil
+}
+
+// Usage returns the total size of stored attachments in bytes
+func (am *AttachmentManager) Usage() (int64, error) {
+	var total int64
+	err := filepath.WalkDir(filepath.Join(am.baseDir, "objects"), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		total += info.Size()
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error measuring attachment storage: %w", err)
+	}
+	return total, nil
+}
+
+// Quota returns the storage quota in bytes
+func (am *AttachmentManager) Quota() int64 {
+	return am.quota
+}
+
+// Refs returns the attachment hashes referenced in text, without the sha256: prefix
+func Refs(text string) []string {
+	var hashes []string
+	seen := make(map[string]bool)
+	for _, ref := range refPattern.FindAllString(text, -1) {
+		hash := strings.TrimPrefix(ref, "sha256:")
+		if !seen[hash] {
+			seen[hash] = true
+			hashes = append(hashes, hash)
+		}
+	}
+	return hashes
+}
+
+// Referenced returns the attachment hashes a note or bug report refers to, either as sha256: references
+// in its text or in the attachments list of a JSON note
+func Referenced(data []byte) []string {
+	hashes := Refs(string(data))
+
+	var note struct {
+		Attachments []Attachment `json:"attachments"`
+	}
+	if json.Unmarshal(data, &note) == nil {
+		for _, attachment := range note.Attachments {
+			if !slices.Contains(hashes, attachment.SHA256) {
+				hashes = append(hashes, attachment.SHA256)
+			}
+		}
+	}
+	return hashes
+}
+
+// mediaType guesses a media type from the file extension, falling back to content sniffing
+func mediaType(name string, data []byte) string {
+	if t := mime.TypeByExtension(filepath.Ext(name)); t != "" {
+		return t
+	}
+	return http.DetectContentType(data)
+}
+
+// RemoveUnreferenced deletes stored attachments missing from referenced that were added before cutoff, returning the bytes freed
+func (am *AttachmentManager) RemoveUnreferenced(referenced map[string]bool, cutoff time.Time) (int64, error) {
+	unlock, err := fsutil.LockDir(filepath.Join(am.baseDir, "objects"))
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	var freed int64
+	err = filepath.WalkDir(filepath.Join(am.baseDir, "objects"), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".") || referenced[d.Name()] {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if !info.ModTime().Before(cutoff) {
+			return nil
+		}
+
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		freed += info.Size()
+		return nil
+	})
+	if err != nil {
+		return freed, fmt.Errorf("error removing unreferenced attachments: %w", err)
+	}
+	return freed, nil
+}
@@ -0,0 +1,47 @@
+package attachments
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAddDeduplicatesAndEnforcesQuota(t *testing.T) {
+	am, err := NewAttachmentManagerAt(t.TempDir(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := am.Add("a.log", []byte("12345678"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := am.Add("b.log", []byte("12345678"))
+	if err != nil {
+		t.Fatalf("identical content should not count against the quota: %v", err)
+	}
+	if first.SHA256 != second.SHA256 {
+		t.Errorf("identical content stored under different hashes")
+	}
+	if used, _ := am.Usage(); used != 8 {
+		t.Errorf("usage = %d, want 8", used)
+	}
+
+	if _, err := am.Add("c.log", []byte("abc")); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("expected quota error, got %v", err)
+	}
+
+	data, err := am.Read(first.Ref())
+	if err != nil || string(data) != "12345678" {
+		t.Errorf("Read = %q, %v", data, err)
+	}
+}
+
+func TestRefs(t *testing.T) {
+	hash := strings.Repeat("ab", 32)
+	text := "- log sha256:" + hash + "\n- again sha256:" + hash + "\n- short sha256:abc"
+	refs := Refs(text)
+	if len(refs) != 1 || refs[0] != hash {
+		t.Errorf("Refs = %v", refs)
+	}
+}
@@ -0,0 +1,225 @@
+package baseline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/findings"
+	"github.com/bkidd1/wash-cli/internal/utils/fsutil"
+	"github.com/bkidd1/wash-cli/internal/utils/report"
+)
+
+const (
+	// FileName is the baseline file in the project root
+	FileName = ".wash-baseline.json"
+
+	// IgnoreMarker in a comment on a line, or on the line above it, suppresses the findings about
+	// that line
+	IgnoreMarker = "wash:ignore"
+
+	// similarThreshold is the share of the words of the shorter finding that another must share to
+	// be the same finding reworded
+	similarThreshold = 0.6
+)
+
+var (
+	// wordPattern splits findings into words, keeping code such as os.Open() together
+	wordPattern = regexp.MustCompile(`[a-z0-9_][a-z0-9_.()\-]*`)
+
+	// stopWords carry no meaning of their own in a finding
+	stopWords = map[string]bool{
+		"the": true, "and": true, "for": true, "this": true, "that": true, "from": true, "with": true,
+		"are": true, "was": true, "does": true, "can": true, "could": true, "should": true, "would": true,
+		"when": true, "what": true, "how": true, "why": true, "there": true, "its": true, "into": true,
+		"not": true, "any": true, "been": true, "have": true, "has": true, "than": true, "then": true,
+	}
+)
+
+// Known is the findings of a file accepted into the baseline
+type Known struct {
+	CriticalIssues []string `json:"critical_issues,omitempty"`
+	ShouldFix      []string `json:"should_fix,omitempty"`
+	CouldFix       []string `json:"could_fix,omitempty"`
+}
+
+// all returns the findings of every severity
+func (k *Known) all() []string {
+	return slices.Concat(k.CriticalIssues, k.ShouldFix, k.CouldFix)
+}
+
+// bySeverity returns the findings of each severity under the JSON name of its list
+func (k *Known) bySeverity() map[string][]string {
+	return map[string][]string{
+		"critical_issues": k.CriticalIssues,
+		"should_fix":      k.ShouldFix,
+		"could_fix":       k.CouldFix,
+	}
+}
+
+// Baseline is the findings a project has accepted, which later analyses do not report again
+type Baseline struct {
+	UpdatedAt time.Time         `json:"updated_at"`
+	Files     map[string]*Known `json:"files"`
+}
+
+// Load reads the baseline in dir, or returns nil if there is none
+func Load(dir string) (*Baseline, error) {
+	data, err := os.ReadFile(filepath.Join(dir, FileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading baseline: %w", err)
+	}
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", FileName, err)
+	}
+	if b.Files == nil {
+		b.Files = map[string]*Known{}
+	}
+	return &b, nil
+}
+
+// Save writes the baseline to dir
+func (b *Baseline) Save(dir string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling baseline: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(filepath.Join(dir, FileName), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("error writing baseline: %w", err)
+	}
+	return nil
+}
+
+// Update adds a project's current findings to the baseline, creating it when b is nil, and returns
+// how many findings were added. Findings already known are kept, so accepted findings stay
+// suppressed even though they are no longer reported.
+func Update(b *Baseline, state *findings.State) (*Baseline, int) {
+	if b == nil {
+		b = &Baseline{Files: map[string]*Known{}}
+	}
+	b.UpdatedAt = time.Now()
+
+	added := 0
+	merge := func(known []string, current []string) []string {
+		for _, finding := range current {
+			if finding = strings.TrimSpace(finding); finding != "" && !slices.Contains(known, finding) {
+				known = append(known, finding)
+				added++
+			}
+		}
+		return known
+	}
+	for _, path := range state.Paths() {
+		file := state.Files[path]
+		known := b.Files[path]
+		if known == nil {
+			known = &Known{}
+		}
+		known.CriticalIssues = merge(known.CriticalIssues, file.CriticalIssues)
+		known.ShouldFix = merge(known.ShouldFix, file.ShouldFix)
+		known.CouldFix = merge(known.CouldFix, file.CouldFix)
+		if len(known.all()) > 0 {
+			b.Files[path] = known
+		}
+	}
+	return b, added
+}
+
+// Count returns the number of known findings
+func (b *Baseline) Count() int {
+	count := 0
+	for _, known := range b.Files {
+		count += len(known.all())
+	}
+	return count
+}
+
+// Matcher returns whether a finding of the file at path, relative to the project root, is known:
+// in the baseline for that file under the same severity, including reworded, or about a line
+// marked wash:ignore. severity is the JSON name of the finding's list, such as "should_fix".
+// It returns nil when nothing in the file can be suppressed. b may be nil.
+func Matcher(b *Baseline, path string, content []byte) func(severity, finding string) bool {
+	known := make(map[string][]map[string]bool)
+	if b != nil {
+		if entry := b.Files[filepath.ToSlash(path)]; entry != nil {
+			for severity, findings := range entry.bySeverity() {
+				for _, finding := range findings {
+					known[severity] = append(known[severity], words(finding))
+				}
+			}
+		}
+	}
+	lines := strings.Split(string(content), "\n")
+	ignored := make(map[int]bool)
+	for i, line := range lines {
+		if strings.Contains(line, IgnoreMarker) {
+			// The marker covers its own line and, as a comment above code, the next one
+			ignored[i+1], ignored[i+2] = true, true
+		}
+	}
+	if len(known) == 0 && len(ignored) == 0 {
+		return nil
+	}
+
+	return func(severity, finding string) bool {
+		if len(ignored) > 0 && ignored[report.Locate(finding, lines, 0, len(lines))] {
+			return true
+		}
+		fw := words(finding)
+		for _, kw := range known[severity] {
+			if similar(fw, kw) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// words returns the meaningful words of a finding, lowercased and stemmed so rewordings match
+func words(finding string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range wordPattern.FindAllString(strings.ToLower(finding), -1) {
+		word = strings.TrimRight(word, ".-")
+		if len(word) < 3 || stopWords[word] {
+			continue
+		}
+		set[stem(word)] = true
+	}
+	return set
+}
+
+// stem drops a common suffix, so ignored, ignores and ignore are one word
+func stem(word string) string {
+	if strings.ContainsAny(word, "._()") {
+		return word
+	}
+	for _, suffix := range []string{"ing", "ed", "es", "s", "e"} {
+		if len(word) > len(suffix)+2 && strings.HasSuffix(word, suffix) {
+			return strings.TrimSuffix(word, suffix)
+		}
+	}
+	return word
+}
+
+// similar reports whether two findings share most of the words of the shorter one
+func similar(a, b map[string]bool) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return false
+	}
+	shared := 0
+	for word := range a {
+		if b[word] {
+			shared++
+		}
+	}
+	return float64(shared) >= similarThreshold*float64(min(len(a), len(b)))
+}
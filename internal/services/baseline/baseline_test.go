@@ -0,0 +1,49 @@
+package baseline
+
+import (
+	"testing"
+
+	"github.com/bkidd1/wash-cli/internal/services/findings"
+)
+
+func TestMatcherSuppressesKnownAndIgnoredFindings(t *testing.T) {
+	dir := t.TempDir()
+	state := &findings.State{Files: map[string]*findings.File{
+		"cmd/main.go": {Path: "cmd/main.go", CriticalIssues: []string{"Is the error from `os.Open` ignored?"}},
+	}}
+	b, added := Update(nil, state)
+	if added != 1 {
+		t.Fatalf("Expected 1 finding added, got %d", added)
+	}
+	if err := b.Save(dir); err != nil {
+		t.Fatal(err)
+	}
+	b, err := Load(dir)
+	if err != nil || b == nil || b.Count() != 1 {
+		t.Fatalf("Expected the saved baseline, got %+v, %v", b, err)
+	}
+	if _, added := Update(b, state); added != 0 {
+		t.Errorf("Expected known findings not to be added twice, got %d", added)
+	}
+
+	content := []byte("package main\n\nfunc main() {\n\tf, _ := os.Open(\"data.txt\")\n\t// wash:ignore\n\tdefer f.Close()\n}\n")
+	known := Matcher(b, "cmd/main.go", content)
+	for _, tc := range []struct {
+		severity, finding string
+		want              bool
+	}{
+		{"critical_issues", "Does the code ignore the error returned by os.Open?", true},
+		{"should_fix", "Does the code ignore the error returned by os.Open?", false},
+		{"could_fix", "Is `f.Close()` deferred without checking its error?", true},
+		{"critical_issues", "Is `os.Open` called with a path the user controls?", false},
+		{"could_fix", "Could `main` return an exit code instead of panicking?", false},
+	} {
+		if got := known(tc.severity, tc.finding); got != tc.want {
+			t.Errorf("known(%q, %q) = %v, want %v", tc.severity, tc.finding, got, tc.want)
+		}
+	}
+
+	if Matcher(b, "other.go", []byte("package other\n")) != nil {
+		t.Error("Expected no matcher for a file with nothing to suppress")
+	}
+}
@@ -0,0 +1,401 @@
+package bugs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/journal"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/crypt"
+	"github.com/bkidd1/wash-cli/internal/utils/fsutil"
+)
+
+const (
+	// StatusOpen and StatusResolved are the statuses wash writes into a report's Status section
+	StatusOpen     = "Open"
+	StatusResolved = "Resolved"
+
+	// filePrefix and fileTimeLayout name report files bug_<time>.md
+	filePrefix     = "bug_"
+	fileTimeLayout = "2006-01-02-15-04-05"
+
+	// archiveDir holds reports archived with wash notes browse
+	archiveDir = "archive"
+)
+
+// Section is one "## Heading" section of a report; Body keeps the text exactly as written
+type Section struct {
+	Heading string
+	Body    string
+}
+
+// Report is a bug report saved by wash bug, parsed from its markdown
+type Report struct {
+	// ID is the report's time stamp, taken from its bug_<time>.md file name
+	ID         string
+	Project    string
+	Path       string
+	ReportedAt time.Time
+	Archived   bool
+
+	// Preamble is the text before the first section
+	Preamble string
+	Sections []Section
+}
+
+// Section returns the trimmed body of the first section with this heading, ignoring case
+func (r *Report) Section(heading string) string {
+	for _, s := range r.Sections {
+		if strings.EqualFold(s.Heading, heading) {
+			return strings.TrimSpace(s.Body)
+		}
+	}
+	return ""
+}
+
+// SetSection replaces the body of a section, adding the section after the one named after when it is missing
+func (r *Report) SetSection(heading, body, after string) {
+	body = strings.TrimRight(body, "\n") + "\n\n"
+	for i, s := range r.Sections {
+		if strings.EqualFold(s.Heading, heading) {
+			r.Sections[i].Body = body
+			return
+		}
+	}
+
+	at := len(r.Sections)
+	for i, s := range r.Sections {
+		if strings.EqualFold(s.Heading, after) {
+			at = i + 1
+			break
+		}
+	}
+	// The section it follows needs a blank line before the new heading
+	if at > 0 && !strings.HasSuffix(r.Sections[at-1].Body, "\n\n") {
+		r.Sections[at-1].Body = strings.TrimRight(r.Sections[at-1].Body, "\n") + "\n\n"
+	}
+	r.Sections = append(r.Sections[:at], append([]Section{{Heading: heading, Body: body}}, r.Sections[at:]...)...)
+}
+
+// Title returns the first line of the description
+func (r *Report) Title() string {
+	for _, line := range strings.Split(r.Section("Description"), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return filepath.Base(r.Path)
+}
+
+// Status returns the report's status, Open when it has none
+func (r *Report) Status() string {
+	if status := firstLine(r.Section("Status")); status != "" {
+		return status
+	}
+	return StatusOpen
+}
+
+// Priority returns the report's priority, or an empty string
+func (r *Report) Priority() string {
+	return firstLine(r.Section("Priority"))
+}
+
+// IsResolved reports whether the report has been resolved
+func (r *Report) IsResolved() bool {
+	return strings.EqualFold(r.Status(), StatusResolved)
+}
+
+// String renders the report back to markdown
+func (r *Report) String() string {
+	var b strings.Builder
+	b.WriteString(r.Preamble)
+	for _, s := range r.Sections {
+		b.WriteString("## " + s.Heading + "\n")
+		b.WriteString(s.Body)
+	}
+	return b.String()
+}
+
+// Parse splits a report into its preamble and sections
+func Parse(data []byte) *Report {
+	report := &Report{}
+	current := -1
+	var body strings.Builder
+	flush := func() {
+		if current < 0 {
+			report.Preamble = body.String()
+		} else {
+			report.Sections[current].Body = body.String()
+		}
+		body.Reset()
+	}
+
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		if strings.HasPrefix(line, "## ") {
+			flush()
+			report.Sections = append(report.Sections, Section{Heading: strings.TrimSpace(strings.TrimPrefix(line, "## "))})
+			current = len(report.Sections) - 1
+			continue
+		}
+		body.WriteString(line)
+	}
+	flush()
+	return report
+}
+
+// firstLine returns the first non-empty line of s
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// BugManager reads and updates the bug reports in ~/.wash/projects/<name>/bugs
+type BugManager struct {
+	baseDir string
+	cipher  *crypt.Cipher // nil when encryption at rest is off
+}
+
+// NewBugManager creates a new BugManager instance
+func NewBugManager() (*BugManager, error) {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+	// Reports quote logs and code, so they are encrypted like the notes
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	cipher, err := crypt.Load(cfg.Encryption, dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unlock encrypted bug reports: %w", err)
+	}
+
+	return &BugManager{baseDir: filepath.Join(dataDir, "projects"), cipher: cipher}, nil
+}
+
+// Dir returns the directory holding a project's bug reports
+func (bm *BugManager) Dir(projectName string) string {
+	return filepath.Join(bm.baseDir, projectName, "bugs")
+}
+
+// Load reads one report file
+func (bm *BugManager) Load(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading bug report: %w", err)
+	}
+	if data, err = bm.cipher.Decrypt(data); err != nil {
+		return nil, fmt.Errorf("error decrypting bug report: %w", err)
+	}
+
+	report := Parse(data)
+	report.Path = path
+	report.ID = strings.TrimPrefix(strings.TrimSuffix(filepath.Base(path), ".md"), filePrefix)
+	dir := filepath.Dir(path)
+	if filepath.Base(dir) == archiveDir {
+		report.Archived = true
+		dir = filepath.Dir(dir)
+	}
+	report.Project = filepath.Base(filepath.Dir(dir))
+	if reportedAt, err := time.ParseInLocation(fileTimeLayout, report.ID, time.Local); err == nil {
+		report.ReportedAt = reportedAt
+	} else if info, err := os.Stat(path); err == nil {
+		report.ReportedAt = info.ModTime()
+	}
+	return report, nil
+}
+
+// List returns a project's bug reports, newest first; archived reports are included when archived is set
+func (bm *BugManager) List(projectName string, archived bool) ([]*Report, error) {
+	patterns := []string{filepath.Join(bm.Dir(projectName), "*.md")}
+	if archived {
+		patterns = append(patterns, filepath.Join(bm.Dir(projectName), archiveDir, "*.md"))
+	}
+
+	var reports []*Report
+	for _, pattern := range patterns {
+		paths, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("error listing bug reports: %w", err)
+		}
+		for _, path := range paths {
+			report, err := bm.Load(path)
+			if err != nil {
+				return nil, err
+			}
+			reports = append(reports, report)
+		}
+	}
+
+	sort.Slice(reports, func(i, j int) bool { return reports[i].ReportedAt.After(reports[j].ReportedAt) })
+	return reports, nil
+}
+
+// Get returns the report of a project with this ID, or a unique prefix of it. The ID may also be
+// written as bug_<time> or <project>/bug_<time>, as wash notes pin does.
+func (bm *BugManager) Get(projectName, id string) (*Report, error) {
+	if i := strings.LastIndex(id, "/"); i >= 0 {
+		projectName, id = id[:i], id[i+1:]
+	}
+	id = strings.TrimSuffix(strings.TrimPrefix(id, filePrefix), ".md")
+	if id == "" {
+		return nil, fmt.Errorf("bug ID cannot be empty")
+	}
+
+	reports, err := bm.List(projectName, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var match *Report
+	for _, report := range reports {
+		if report.ID == id {
+			return report, nil
+		}
+		if strings.HasPrefix(report.ID, id) {
+			if match != nil {
+				return nil, fmt.Errorf("ID %s matches more than one bug report; use more characters", id)
+			}
+			match = report
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("no bug report with ID %s in project %s", id, projectName)
+	}
+	return match, nil
+}
+
+// Create writes a new report file for a project, named by its ID, and returns its path
+func (bm *BugManager) Create(projectName, id, content string) (string, error) {
+	dir := bm.Dir(projectName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("error creating bugs directory: %w", err)
+	}
+	path := filepath.Join(dir, filePrefix+id+".md")
+	if err := bm.write(path, content); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Save writes a report back to its file
+func (bm *BugManager) Save(report *Report) error {
+	if err := bm.write(report.Path, report.String()); err != nil {
+		return err
+	}
+	journal.Record(journal.ActionSaved, report.Project, "bug "+report.ID, "bug report, "+report.Status())
+	return nil
+}
+
+// Resolve marks a report resolved and records how, after any resolutions from before it was reopened
+func (bm *BugManager) Resolve(report *Report, note, resolvedBy string) error {
+	if report.IsResolved() {
+		return fmt.Errorf("bug %s is already resolved", report.ID)
+	}
+
+	entry := fmt.Sprintf("*Resolved on %s", time.Now().Format("2006-01-02 15:04:05"))
+	if resolvedBy != "" {
+		entry += " by " + resolvedBy
+	}
+	entry += "*\n"
+	if note = strings.TrimSpace(note); note != "" {
+		entry += note + "\n"
+	}
+	if previous := report.Section("Resolution"); previous != "" {
+		entry = previous + "\n\n" + entry
+	}
+
+	report.SetSection("Status", StatusResolved, "Priority")
+	report.SetSection("Resolution", entry, "Status")
+	return bm.Save(report)
+}
+
+// Published returns the issue a report was published to in a tracker, or an empty string
+func (r *Report) Published(tracker string) string {
+	for _, line := range strings.Split(r.Section("Published"), "\n") {
+		if issue, ok := strings.CutPrefix(strings.TrimPrefix(line, "- "), tracker+": "); ok {
+			return strings.TrimSpace(issue)
+		}
+	}
+	return ""
+}
+
+// RecordPublished notes in a report the issue it was published to, replacing an earlier one in the same tracker
+func (bm *BugManager) RecordPublished(report *Report, tracker, issue string) error {
+	var lines []string
+	for _, line := range strings.Split(report.Section("Published"), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(strings.TrimPrefix(line, "- "), tracker+": ") {
+			lines = append(lines, line)
+		}
+	}
+	lines = append(lines, fmt.Sprintf("- %s: %s", tracker, issue))
+	report.SetSection("Published", strings.Join(lines, "\n"), "Status")
+	return bm.Save(report)
+}
+
+// SetPriority changes a report's priority
+func (bm *BugManager) SetPriority(report *Report, priority string) error {
+	report.SetSection("Priority", priority, "Suggested Solutions")
+	return bm.Save(report)
+}
+
+// AddNote appends a dated note to a report's Notes section
+func (bm *BugManager) AddNote(report *Report, note, author string) error {
+	note = strings.Join(strings.Fields(note), " ")
+	if note == "" {
+		return fmt.Errorf("note cannot be empty")
+	}
+	entry := "- " + time.Now().Format("2006-01-02 15:04")
+	if author != "" {
+		entry += " " + author
+	}
+	entry += ": " + note
+	if previous := report.Section("Notes"); previous != "" {
+		entry = previous + "\n" + entry
+	}
+	report.SetSection("Notes", entry, "")
+	return bm.Save(report)
+}
+
+// UpdateAnalysis replaces a report's suggested solutions, and its potential causes when the report
+// has that section, with a fresh analysis
+func (bm *BugManager) UpdateAnalysis(report *Report, causes, solutions string) error {
+	for _, s := range report.Sections {
+		if strings.EqualFold(s.Heading, "Potential Causes") {
+			report.SetSection("Potential Causes", causes, "")
+			break
+		}
+	}
+	report.SetSection("Suggested Solutions", solutions, "Description")
+	return bm.Save(report)
+}
+
+// Reopen marks a resolved report open again; its resolution history is kept
+func (bm *BugManager) Reopen(report *Report) error {
+	if !report.IsResolved() {
+		return fmt.Errorf("bug %s is not resolved", report.ID)
+	}
+	report.SetSection("Status", StatusOpen, "Priority")
+	return bm.Save(report)
+}
+
+// write encrypts a report, if encryption is on, and writes it atomically
+func (bm *BugManager) write(path, content string) error {
+	data, err := bm.cipher.Encrypt([]byte(content))
+	if err != nil {
+		return fmt.Errorf("error encrypting bug report: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(path, data, 0644); err != nil {
+		return fmt.Errorf("error saving bug report: %w", err)
+	}
+	return nil
+}
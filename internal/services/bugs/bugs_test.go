@@ -0,0 +1,193 @@
+package bugs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/crypt"
+)
+
+const sampleReport = `# Bug Report
+*Reported on 2024-05-01 10:30:00*
+
+## Description
+Login fails after upgrade
+
+## Suggested Solutions
+- Clear the session cache
+
+## Priority
+high
+
+## Status
+Open
+
+## Notes
+`
+
+func TestParseRoundTrip(t *testing.T) {
+	report := Parse([]byte(sampleReport))
+	if report.String() != sampleReport {
+		t.Errorf("Round trip changed the report:\n%s", report.String())
+	}
+	if report.Title() != "Login fails after upgrade" || report.Priority() != "high" || report.IsResolved() {
+		t.Errorf("Unexpected fields: %q %q %q", report.Title(), report.Priority(), report.Status())
+	}
+}
+
+func TestResolveAndReopen(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	bm, err := NewBugManager()
+	if err != nil {
+		t.Fatalf("Failed to create bug manager: %v", err)
+	}
+	if err := os.MkdirAll(bm.Dir("app"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(bm.Dir("app"), "bug_2024-05-01-10-30-00.md"), []byte(sampleReport), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := bm.Get("app", "2024-05")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if err := bm.Resolve(report, "Cleared stale sessions", "alice"); err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+
+	report, err = bm.Get("", "app/bug_2024-05-01-10-30-00")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if !report.IsResolved() || !strings.Contains(report.Section("Resolution"), "by alice*\nCleared stale sessions") {
+		t.Errorf("Resolution not recorded:\n%s", report)
+	}
+	if strings.Index(report.String(), "## Resolution") > strings.Index(report.String(), "## Notes") {
+		t.Errorf("Expected the resolution before the notes:\n%s", report)
+	}
+
+	if err := bm.Reopen(report); err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	if err := bm.Resolve(report, "Really fixed", ""); err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if resolution := report.Section("Resolution"); !strings.Contains(resolution, "Cleared stale sessions") || !strings.Contains(resolution, "Really fixed") {
+		t.Errorf("Expected both resolutions to be kept, got:\n%s", resolution)
+	}
+}
+
+func TestLessons(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	bm, err := NewBugManager()
+	if err != nil {
+		t.Fatalf("Failed to create bug manager: %v", err)
+	}
+	if err := os.MkdirAll(bm.Dir("app"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(bm.Dir("app"), "bug_2024-05-01-10-30-00.md"), []byte(sampleReport), 0644); err != nil {
+		t.Fatal(err)
+	}
+	report, err := bm.Get("app", "2024-05")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+
+	// Without a note, the first suggested solution is the fix
+	if err := bm.RecordLesson(report, ""); err != nil {
+		t.Fatalf("RecordLesson failed: %v", err)
+	}
+	if err := bm.RecordLesson(report, "Cleared stale sessions"); err != nil {
+		t.Fatalf("RecordLesson failed: %v", err)
+	}
+	texts, err := bm.LessonTexts("app")
+	if err != nil {
+		t.Fatalf("LessonTexts failed: %v", err)
+	}
+	if len(texts) != 1 || texts[0] != "Resolved bug: Login fails after upgrade. Fix: Cleared stale sessions" {
+		t.Errorf("Expected the lesson to be replaced, got %q", texts)
+	}
+	if lesson := NewLesson(report, ""); lesson.Fix != "Clear the session cache" {
+		t.Errorf("Expected the suggested solution as fix, got %q", lesson.Fix)
+	}
+
+	if err := bm.ForgetLesson(report); err != nil {
+		t.Fatalf("ForgetLesson failed: %v", err)
+	}
+	if lessons, err := bm.Lessons("app"); err != nil || len(lessons) != 0 {
+		t.Errorf("Expected no lessons after reopening, got %v %v", lessons, err)
+	}
+}
+
+func TestDashboard(t *testing.T) {
+	now := time.Date(2024, 5, 11, 12, 0, 0, 0, time.Local)
+	report := func(id, priority, status, resolution string) *Report {
+		r := Parse([]byte("## Description\nBug " + id + "\n\n## Priority\n" + priority + "\n\n## Status\n" + status + "\n\n## Resolution\n" + resolution + "\n"))
+		r.ID = id
+		r.ReportedAt, _ = time.ParseInLocation(fileTimeLayout, id, time.Local)
+		return r
+	}
+	dashboard := NewDashboard("app", []*Report{
+		report("2024-05-08-12-00-00", "low", "Open", ""),
+		report("2024-05-01-12-00-00", "high", "Resolved", "*Resolved on 2024-05-02 10:00:00*\n\n*Resolved on 2024-05-04 12:00:00*"),
+		report("2024-05-02-12-00-00", "high", "Open", ""),
+	}, now)
+
+	var groups []string
+	for _, group := range dashboard.Groups {
+		groups = append(groups, group.Priority+"/"+group.Status)
+	}
+	if strings.Join(groups, " ") != "high/Open high/Resolved low/Open" {
+		t.Errorf("Unexpected group order: %v", groups)
+	}
+	if days := dashboard.Groups[1].Reports[0].DaysOpen(now); days != 3 {
+		t.Errorf("Expected a resolved bug to age until its last resolution, got %d days", days)
+	}
+	if !strings.Contains(dashboard.Markdown(), "| **Total** | 2 | 1 | 3 | 9d |") {
+		t.Errorf("Unexpected summary:\n%s", dashboard.Markdown())
+	}
+}
+
+func TestReportsAreEncrypted(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(config.DataDirEnv, t.TempDir())
+	t.Setenv("WASH_ENCRYPTION", crypt.SourcePassphrase)
+	t.Setenv(crypt.PassphraseEnv, "correct horse")
+
+	bm, err := NewBugManager()
+	if err != nil {
+		t.Fatalf("Failed to create bug manager: %v", err)
+	}
+	path, err := bm.Create("app", "2024-05-01-10-30-00", sampleReport)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !crypt.IsEncrypted(data) {
+		t.Error("Expected the report to be encrypted")
+	}
+
+	report, err := bm.Get("app", "2024-05-01")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if report.Title() != "Login fails after upgrade" {
+		t.Errorf("Expected the encrypted report to be read back, got %q", report.Title())
+	}
+	if err := bm.SetPriority(report, "low"); err != nil {
+		t.Fatalf("SetPriority failed: %v", err)
+	}
+	if data, err = os.ReadFile(path); err != nil || !crypt.IsEncrypted(data) {
+		t.Errorf("Expected the saved report to stay encrypted: %v", err)
+	}
+}
@@ -0,0 +1,213 @@
+package bugs
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// resolvedOnPattern finds the resolution times recorded by Resolve
+var resolvedOnPattern = regexp.MustCompile(`\*Resolved on (\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2})`)
+
+// priorityOrder lists the priorities wash bug sets, most urgent first; others follow, then none
+var priorityOrder = []string{"critical", "high", "medium", "low"}
+
+// ResolvedAt returns when the report was last resolved, or the zero time when it is open
+func (r *Report) ResolvedAt() time.Time {
+	if !r.IsResolved() {
+		return time.Time{}
+	}
+	matches := resolvedOnPattern.FindAllStringSubmatch(r.Section("Resolution"), -1)
+	if len(matches) == 0 {
+		return time.Time{}
+	}
+	resolvedAt, err := time.ParseInLocation("2006-01-02 15:04:05", matches[len(matches)-1][1], time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	return resolvedAt
+}
+
+// DaysOpen returns how many days the report has been open, or was open until it was resolved
+func (r *Report) DaysOpen(now time.Time) int {
+	end := now
+	if resolvedAt := r.ResolvedAt(); !resolvedAt.IsZero() {
+		end = resolvedAt
+	}
+	if r.ReportedAt.IsZero() || end.Before(r.ReportedAt) {
+		return 0
+	}
+	return int(end.Sub(r.ReportedAt).Hours() / 24)
+}
+
+// DashboardGroup is the reports sharing a priority and status, oldest first
+type DashboardGroup struct {
+	Priority string
+	Status   string
+	Reports  []*Report
+}
+
+// Dashboard summarizes a project's bug reports by priority and status
+type Dashboard struct {
+	Project     string
+	GeneratedAt time.Time
+	Groups      []DashboardGroup
+}
+
+// NewDashboard groups reports by priority, most urgent first, then by status, open first
+func NewDashboard(projectName string, reports []*Report, now time.Time) *Dashboard {
+	d := &Dashboard{Project: projectName, GeneratedAt: now}
+	index := make(map[[2]string]int)
+	for _, report := range reports {
+		key := [2]string{strings.ToLower(report.Priority()), report.Status()}
+		if report.IsResolved() {
+			key[1] = StatusResolved
+		}
+		i, ok := index[key]
+		if !ok {
+			i = len(d.Groups)
+			index[key] = i
+			d.Groups = append(d.Groups, DashboardGroup{Priority: key[0], Status: key[1]})
+		}
+		d.Groups[i].Reports = append(d.Groups[i].Reports, report)
+	}
+
+	slices.SortStableFunc(d.Groups, func(a, b DashboardGroup) int {
+		if c := priorityRank(a.Priority) - priorityRank(b.Priority); c != 0 {
+			return c
+		}
+		if a.Priority != b.Priority {
+			return strings.Compare(a.Priority, b.Priority)
+		}
+		return statusRank(a.Status) - statusRank(b.Status)
+	})
+	for _, group := range d.Groups {
+		slices.SortStableFunc(group.Reports, func(a, b *Report) int { return a.ReportedAt.Compare(b.ReportedAt) })
+	}
+	return d
+}
+
+// priorityRank orders known priorities first, then unknown ones, then reports without a priority
+func priorityRank(priority string) int {
+	if i := slices.Index(priorityOrder, priority); i >= 0 {
+		return i
+	}
+	if priority == "" {
+		return len(priorityOrder) + 1
+	}
+	return len(priorityOrder)
+}
+
+// statusRank orders open reports first and resolved reports last
+func statusRank(status string) int {
+	switch {
+	case strings.EqualFold(status, StatusOpen):
+		return 0
+	case strings.EqualFold(status, StatusResolved):
+		return 2
+	}
+	return 1
+}
+
+// priorityLabel names a priority for display
+func priorityLabel(priority string) string {
+	if priority == "" {
+		return "none"
+	}
+	return priority
+}
+
+// summaryRow counts one priority's reports
+type summaryRow struct {
+	priority       string
+	open, resolved int
+	oldestOpen     int
+}
+
+// summary counts the reports of each priority, in dashboard order, with a total row last
+func (d *Dashboard) summary() []summaryRow {
+	var rows []summaryRow
+	total := summaryRow{priority: "total"}
+	for _, group := range d.Groups {
+		if len(rows) == 0 || rows[len(rows)-1].priority != priorityLabel(group.Priority) {
+			rows = append(rows, summaryRow{priority: priorityLabel(group.Priority)})
+		}
+		row := &rows[len(rows)-1]
+		for _, report := range group.Reports {
+			if report.IsResolved() {
+				row.resolved++
+				total.resolved++
+				continue
+			}
+			row.open++
+			total.open++
+			row.oldestOpen = max(row.oldestOpen, report.DaysOpen(d.GeneratedAt))
+			total.oldestOpen = max(total.oldestOpen, row.oldestOpen)
+		}
+	}
+	return append(rows, total)
+}
+
+// Table renders the dashboard as aligned plain text for the terminal
+func (d *Dashboard) Table() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Bug reports for %s\n\n", d.Project)
+	if len(d.Groups) == 0 {
+		b.WriteString("No bug reports found\n")
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "%-10s %6s %9s %6s %12s\n", "PRIORITY", "OPEN", "RESOLVED", "TOTAL", "OLDEST OPEN")
+	for _, row := range d.summary() {
+		fmt.Fprintf(&b, "%-10s %6d %9d %6d %12s\n", row.priority, row.open, row.resolved, row.open+row.resolved, days(row.oldestOpen, row.open))
+	}
+
+	for _, group := range d.Groups {
+		fmt.Fprintf(&b, "\n%s / %s (%d)\n", priorityLabel(group.Priority), group.Status, len(group.Reports))
+		for _, report := range group.Reports {
+			fmt.Fprintf(&b, "  %s  %5dd  %s\n", report.ID, report.DaysOpen(d.GeneratedAt), report.Title())
+		}
+	}
+	return b.String()
+}
+
+// Markdown renders the dashboard as a markdown overview
+func (d *Dashboard) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Bug Reports: %s\n*Generated on %s*\n\n", d.Project, d.GeneratedAt.Format("2006-01-02 15:04:05"))
+	if len(d.Groups) == 0 {
+		b.WriteString("No bug reports found.\n")
+		return b.String()
+	}
+
+	b.WriteString("| Priority | Open | Resolved | Total | Oldest open |\n")
+	b.WriteString("|---|---:|---:|---:|---:|\n")
+	for _, row := range d.summary() {
+		label := row.priority
+		if label == "total" {
+			label = "**Total**"
+		}
+		fmt.Fprintf(&b, "| %s | %d | %d | %d | %s |\n", label, row.open, row.resolved, row.open+row.resolved, days(row.oldestOpen, row.open))
+	}
+
+	for _, group := range d.Groups {
+		fmt.Fprintf(&b, "\n## %s / %s (%d)\n\n", priorityLabel(group.Priority), group.Status, len(group.Reports))
+		b.WriteString("| ID | Title | Reported | Days open |\n")
+		b.WriteString("|---|---|---|---:|\n")
+		for _, report := range group.Reports {
+			title := strings.ReplaceAll(report.Title(), "|", "\\|")
+			fmt.Fprintf(&b, "| %s | %s | %s | %d |\n", report.ID, title, report.ReportedAt.Format("2006-01-02"), report.DaysOpen(d.GeneratedAt))
+		}
+	}
+	return b.String()
+}
+
+// days formats an age in days, or a dash when no reports are open
+func days(n, open int) string {
+	if open == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%dd", n)
+}
@@ -0,0 +1,97 @@
+package bugs
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/attachments"
+	"github.com/bkidd1/wash-cli/internal/services/retrieval"
+)
+
+// DuplicateSimilarity is the embedding similarity at or above which two descriptions are taken for the same bug
+const DuplicateSimilarity = 0.85
+
+var (
+	// volatilePattern matches the parts of a description that differ between reports of the same bug
+	volatilePattern = regexp.MustCompile(`0x[0-9a-fA-F]+|\d+`)
+
+	// wordPattern matches the words a fingerprint is built from
+	wordPattern = regexp.MustCompile(`[\p{L}\p{N}_]+`)
+)
+
+// Ranker finds the candidates most similar to a query; retrieval.Retriever implements it
+type Ranker interface {
+	TopK(ctx context.Context, query string, candidates []string, k int) ([]retrieval.Result, error)
+}
+
+// Duplicate is an open report that looks like the same bug as a new description
+type Duplicate struct {
+	Report *Report
+	// Score is the similarity of the descriptions; 1 when their fingerprints match
+	Score float64
+	Exact bool
+}
+
+// Fingerprint hashes a description, ignoring case, punctuation, spacing, numbers and addresses
+func Fingerprint(description string) string {
+	normalized := volatilePattern.ReplaceAllString(strings.ToLower(description), "N")
+	sum := sha1.Sum([]byte(strings.Join(wordPattern.FindAllString(normalized, -1), " ")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// FindDuplicate returns the open report of a project most likely to describe the same bug as
+// description, or nil. Fingerprints are compared first; with a ranker, the descriptions are
+// also compared by embedding similarity.
+func (bm *BugManager) FindDuplicate(ctx context.Context, projectName, description string, ranker Ranker) (*Duplicate, error) {
+	reports, err := bm.List(projectName, false)
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprint := Fingerprint(description)
+	open := make(map[string]*Report)
+	var candidates []string
+	for _, report := range reports {
+		existing := report.Section("Description")
+		if report.IsResolved() || existing == "" {
+			continue
+		}
+		if Fingerprint(existing) == fingerprint {
+			return &Duplicate{Report: report, Score: 1, Exact: true}, nil
+		}
+		if _, seen := open[existing]; !seen {
+			open[existing] = report
+			candidates = append(candidates, existing)
+		}
+	}
+	if ranker == nil || len(candidates) == 0 {
+		return nil, nil
+	}
+
+	results, err := ranker.TopK(ctx, description, candidates, 1)
+	if err != nil {
+		return nil, fmt.Errorf("error comparing bug descriptions: %w", err)
+	}
+	if len(results) == 0 || results[0].Score < DuplicateSimilarity {
+		return nil, nil
+	}
+	return &Duplicate{Report: open[results[0].Text], Score: results[0].Score}, nil
+}
+
+// Link records another report of the same bug in an existing report, with its attachments
+func (bm *BugManager) Link(report *Report, description string, attached []attachments.Attachment) error {
+	entry := fmt.Sprintf("*Reported again on %s*\n%s\n", time.Now().Format("2006-01-02 15:04:05"), strings.TrimSpace(description))
+	for _, a := range attached {
+		entry += fmt.Sprintf("- %s %s\n", a.Name, a.Ref())
+	}
+	if previous := report.Section("Duplicate Reports"); previous != "" {
+		entry = previous + "\n\n" + entry
+	}
+	report.SetSection("Duplicate Reports", entry, "Description")
+	return bm.Save(report)
+}
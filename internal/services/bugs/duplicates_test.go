@@ -0,0 +1,74 @@
+package bugs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bkidd1/wash-cli/internal/services/retrieval"
+)
+
+// fixedRanker scores every candidate the same
+type fixedRanker float64
+
+func (r fixedRanker) TopK(ctx context.Context, query string, candidates []string, k int) ([]retrieval.Result, error) {
+	return []retrieval.Result{{Text: candidates[0], Score: float64(r)}}, nil
+}
+
+func TestFingerprintIgnoresVolatileDetails(t *testing.T) {
+	a := Fingerprint("Panic at 0x1f3a: index out of range [5] with length 3")
+	b := Fingerprint("panic at 0x77b0 - index out of range [12] with length 4!")
+	if a != b {
+		t.Errorf("Expected matching fingerprints, got %s and %s", a, b)
+	}
+	if a == Fingerprint("panic at 0x1f3a: nil map write") {
+		t.Error("Expected different bugs to have different fingerprints")
+	}
+}
+
+func TestFindDuplicateAndLink(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	bm, err := NewBugManager()
+	if err != nil {
+		t.Fatalf("Failed to create bug manager: %v", err)
+	}
+	if err := os.MkdirAll(bm.Dir("app"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(bm.Dir("app"), "bug_2024-05-01-10-30-00.md"), []byte(sampleReport), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	duplicate, err := bm.FindDuplicate(ctx, "app", "login FAILS after upgrade.", nil)
+	if err != nil || duplicate == nil || !duplicate.Exact {
+		t.Fatalf("Expected an exact duplicate, got %+v, %v", duplicate, err)
+	}
+	if duplicate, _ := bm.FindDuplicate(ctx, "app", "Sign-in broken since the update", fixedRanker(0.5)); duplicate != nil {
+		t.Errorf("Expected no duplicate below the similarity threshold, got %+v", duplicate)
+	}
+	duplicate, err = bm.FindDuplicate(ctx, "app", "Sign-in broken since the update", fixedRanker(0.9))
+	if err != nil || duplicate == nil || duplicate.Exact {
+		t.Fatalf("Expected a similar duplicate, got %+v, %v", duplicate, err)
+	}
+
+	if err := bm.Link(duplicate.Report, "Sign-in broken since the update", nil); err != nil {
+		t.Fatalf("Link failed: %v", err)
+	}
+	report, err := bm.Get("app", "2024")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(report.Section("Duplicate Reports"), "Sign-in broken since the update") {
+		t.Errorf("Expected the duplicate to be recorded:\n%s", report)
+	}
+
+	if err := bm.Resolve(report, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if duplicate, _ := bm.FindDuplicate(ctx, "app", "Login fails after upgrade", nil); duplicate != nil {
+		t.Error("Expected resolved bugs to be ignored")
+	}
+}
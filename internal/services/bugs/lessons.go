@@ -0,0 +1,150 @@
+package bugs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/utils/fsutil"
+)
+
+const (
+	// lessonsFileName holds a project's lessons next to its bugs directory
+	lessonsFileName = "lessons.json"
+	// maxLessonFieldLength keeps each part of a lesson short enough for a prompt
+	maxLessonFieldLength = 200
+)
+
+// Lesson is what a resolved bug taught: what went wrong and how it was fixed
+type Lesson struct {
+	BugID      string    `json:"bug_id"`
+	Title      string    `json:"title"`
+	Cause      string    `json:"cause,omitempty"`
+	Fix        string    `json:"fix,omitempty"`
+	ResolvedAt time.Time `json:"resolved_at"`
+}
+
+// String formats the lesson as one line for analysis prompts
+func (l Lesson) String() string {
+	text := "Resolved bug: " + l.Title
+	if l.Cause != "" {
+		text += ". Cause: " + l.Cause
+	}
+	if l.Fix != "" {
+		text += ". Fix: " + l.Fix
+	}
+	return text
+}
+
+// NewLesson builds the lesson of a resolved report. The fix is the resolution note, or the first
+// suggested solution without one.
+func NewLesson(report *Report, note string) Lesson {
+	fix := strings.TrimSpace(note)
+	if fix == "" {
+		fix = firstItem(report.Section("Suggested Solutions"))
+	}
+	return Lesson{
+		BugID:      report.ID,
+		Title:      shorten(report.Title()),
+		Cause:      shorten(firstItem(report.Section("Potential Causes"))),
+		Fix:        shorten(fix),
+		ResolvedAt: time.Now(),
+	}
+}
+
+// firstItem returns the first entry of a markdown list, or the first line of plain text
+func firstItem(s string) string {
+	item := firstLine(s)
+	item = strings.TrimLeft(item, "-*0123456789. ")
+	return strings.TrimSpace(item)
+}
+
+// shorten joins s into one line of at most maxLessonFieldLength runes
+func shorten(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if runes := []rune(s); len(runes) > maxLessonFieldLength {
+		return string(runes[:maxLessonFieldLength-3]) + "..."
+	}
+	return s
+}
+
+// lessonsPath returns the file holding a project's lessons
+func (bm *BugManager) lessonsPath(projectName string) string {
+	return filepath.Join(bm.baseDir, projectName, lessonsFileName)
+}
+
+// Lessons returns a project's lessons, newest first
+func (bm *BugManager) Lessons(projectName string) ([]Lesson, error) {
+	data, err := os.ReadFile(bm.lessonsPath(projectName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading lessons: %w", err)
+	}
+	if data, err = bm.cipher.Decrypt(data); err != nil {
+		return nil, fmt.Errorf("error decrypting lessons: %w", err)
+	}
+	var lessons []Lesson
+	if err := json.Unmarshal(data, &lessons); err != nil {
+		return nil, fmt.Errorf("error parsing lessons: %w", err)
+	}
+	sort.SliceStable(lessons, func(i, j int) bool { return lessons[i].ResolvedAt.After(lessons[j].ResolvedAt) })
+	return lessons, nil
+}
+
+// LessonTexts returns a project's lessons formatted for analysis prompts, newest first
+func (bm *BugManager) LessonTexts(projectName string) ([]string, error) {
+	lessons, err := bm.Lessons(projectName)
+	if err != nil {
+		return nil, err
+	}
+	texts := make([]string, len(lessons))
+	for i, lesson := range lessons {
+		texts[i] = lesson.String()
+	}
+	return texts, nil
+}
+
+// RecordLesson saves the lesson of a resolved report, replacing one from an earlier resolution
+func (bm *BugManager) RecordLesson(report *Report, note string) error {
+	return bm.updateLessons(report, func(lessons []Lesson) []Lesson {
+		return append(lessons, NewLesson(report, note))
+	})
+}
+
+// ForgetLesson removes the lesson of a reopened report, since its fix did not hold
+func (bm *BugManager) ForgetLesson(report *Report) error {
+	return bm.updateLessons(report, func(lessons []Lesson) []Lesson { return lessons })
+}
+
+// updateLessons drops the report's lesson from its project's lessons, applies update and saves them
+func (bm *BugManager) updateLessons(report *Report, update func([]Lesson) []Lesson) error {
+	lessons, err := bm.Lessons(report.Project)
+	if err != nil {
+		return err
+	}
+	kept := lessons[:0]
+	for _, lesson := range lessons {
+		if lesson.BugID != report.ID {
+			kept = append(kept, lesson)
+		}
+	}
+	kept = update(kept)
+
+	data, err := json.MarshalIndent(kept, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding lessons: %w", err)
+	}
+	if data, err = bm.cipher.Encrypt(data); err != nil {
+		return fmt.Errorf("error encrypting lessons: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(bm.lessonsPath(report.Project), data, 0644); err != nil {
+		return fmt.Errorf("error saving lessons: %w", err)
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/bkidd1/wash-cli/internal/services/usage"
 	"github.com/sashabaranov/go-openai"
 )
 
@@ -20,7 +21,7 @@ func NewChatManager() (*ChatManager, error) {
 		return nil, fmt.Errorf("OPENAI_API_KEY environment variable not set")
 	}
 
-	client := openai.NewClient(apiKey)
+	client := usage.NewClient(apiKey)
 	return &ChatManager{client: client}, nil
 }
 
@@ -0,0 +1,276 @@
+package estimates
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/notes"
+	"github.com/bkidd1/wash-cli/internal/services/plans"
+	"github.com/bkidd1/wash-cli/internal/services/retrieval"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/crypt"
+	"github.com/bkidd1/wash-cli/internal/utils/fsutil"
+	"github.com/google/uuid"
+)
+
+// historyTasks caps the past tasks included in the estimation prompt
+const historyTasks = 20
+
+// Ranker finds the candidates most similar to a query; retrieval.Retriever implements it
+type Ranker interface {
+	TopK(ctx context.Context, query string, candidates []string, k int) ([]retrieval.Result, error)
+}
+
+// Estimate represents a recorded effort estimate for a task
+type Estimate struct {
+	ID          string     `json:"id"`
+	ProjectName string     `json:"project_name"`
+	Task        string     `json:"task"`
+	CreatedAt   time.Time  `json:"created_at"`
+	Hours       float64    `json:"hours"`
+	LowHours    float64    `json:"low_hours"`
+	HighHours   float64    `json:"high_hours"`
+	Confidence  string     `json:"confidence"` // e.g., "low", "medium", "high"
+	Rationale   string     `json:"rationale"`
+	ActualHours *float64   `json:"actual_hours,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// Accuracy returns the ratio of the smaller to the larger of estimated and actual hours,
+// so 1.0 is a perfect estimate. It returns false if the task has no recorded actual.
+func (e *Estimate) Accuracy() (float64, bool) {
+	if e.ActualHours == nil || *e.ActualHours <= 0 || e.Hours <= 0 {
+		return 0, false
+	}
+	return math.Min(e.Hours, *e.ActualHours) / math.Max(e.Hours, *e.ActualHours), true
+}
+
+// EstimateManager handles storage of estimates
+type EstimateManager struct {
+	baseDir string
+	cipher  *crypt.Cipher // nil when encryption at rest is off
+}
+
+// NewEstimateManager creates a new EstimateManager instance
+func NewEstimateManager() (*EstimateManager, error) {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+
+	// Estimates describe planned work, so they are encrypted like the notes
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	cipher, err := crypt.Load(cfg.Encryption, dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unlock encrypted estimates: %w", err)
+	}
+
+	return &EstimateManager{baseDir: filepath.Join(dataDir, "projects"), cipher: cipher}, nil
+}
+
+// estimatesDir returns the estimates directory for a project
+func (em *EstimateManager) estimatesDir(projectName string) string {
+	return filepath.Join(em.baseDir, projectName, "estimates")
+}
+
+// SaveEstimate saves an estimate, assigning an ID if it has none
+func (em *EstimateManager) SaveEstimate(estimate *Estimate) error {
+	if estimate.ID == "" {
+		estimate.ID = uuid.New().String()[:8]
+	}
+	if estimate.CreatedAt.IsZero() {
+		estimate.CreatedAt = time.Now()
+	}
+
+	dir := em.estimatesDir(estimate.ProjectName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("error creating estimates directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(estimate, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling estimate: %w", err)
+	}
+	if data, err = em.cipher.Encrypt(data); err != nil {
+		return fmt.Errorf("error encrypting estimate: %w", err)
+	}
+
+	if err := fsutil.WriteFileAtomic(filepath.Join(dir, estimate.ID+".json"), data, 0644); err != nil {
+		return fmt.Errorf("error writing estimate file: %w", err)
+	}
+
+	return nil
+}
+
+// ListEstimates returns all estimates for a project, newest first
+func (em *EstimateManager) ListEstimates(projectName string) ([]*Estimate, error) {
+	dir := em.estimatesDir(projectName)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading estimates directory: %w", err)
+	}
+
+	var estimates []*Estimate
+	for _, file := range files {
+		if filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			continue
+		}
+		if data, err = em.cipher.Decrypt(data); err != nil {
+			continue
+		}
+
+		var estimate Estimate
+		if err := json.Unmarshal(data, &estimate); err != nil {
+			continue
+		}
+
+		estimates = append(estimates, &estimate)
+	}
+
+	sort.Slice(estimates, func(i, j int) bool {
+		return estimates[i].CreatedAt.After(estimates[j].CreatedAt)
+	})
+
+	return estimates, nil
+}
+
+// LoadEstimate loads an estimate by ID or unique ID prefix
+func (em *EstimateManager) LoadEstimate(projectName string, id string) (*Estimate, error) {
+	estimates, err := em.ListEstimates(projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	var match *Estimate
+	for _, estimate := range estimates {
+		if strings.HasPrefix(estimate.ID, id) {
+			if match != nil {
+				return nil, fmt.Errorf("estimate ID %s is ambiguous", id)
+			}
+			match = estimate
+		}
+	}
+
+	if match == nil {
+		return nil, fmt.Errorf("no estimate found with ID %s", id)
+	}
+	return match, nil
+}
+
+// MeanAccuracy returns the average accuracy of scored estimates and how many were scored
+func MeanAccuracy(estimates []*Estimate) (float64, int) {
+	total := 0.0
+	count := 0
+	for _, estimate := range estimates {
+		if accuracy, ok := estimate.Accuracy(); ok {
+			total += accuracy
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, 0
+	}
+	return total / float64(count), count
+}
+
+// SimilarTasks returns up to historyTasks completed estimates, the ones whose tasks are most
+// similar to task first when there is a ranker, or else the most recent. Ranking falls back to
+// the most recent when the ranker fails.
+func SimilarTasks(ctx context.Context, past []*Estimate, task string, ranker Ranker) []*Estimate {
+	var scored []*Estimate
+	byTask := make(map[string][]*Estimate)
+	var candidates []string
+	for _, estimate := range past {
+		if estimate.ActualHours == nil {
+			continue
+		}
+		scored = append(scored, estimate)
+		if _, seen := byTask[estimate.Task]; !seen {
+			candidates = append(candidates, estimate.Task)
+		}
+		byTask[estimate.Task] = append(byTask[estimate.Task], estimate)
+	}
+	if len(scored) <= historyTasks || ranker == nil {
+		return scored[:min(len(scored), historyTasks)]
+	}
+
+	results, err := ranker.TopK(ctx, task, candidates, historyTasks)
+	if err != nil {
+		return scored[:historyTasks]
+	}
+	var similar []*Estimate
+	for _, result := range results {
+		similar = append(similar, byTask[result.Text]...)
+	}
+	return similar[:min(len(similar), historyTasks)]
+}
+
+// BuildHistory summarizes past velocity for the estimation prompt: the completed estimates most
+// similar to task with their actuals, plan completion rates, and recent progress note activity.
+// Without a ranker the most recent completed estimates are used.
+func BuildHistory(ctx context.Context, em *EstimateManager, pm *plans.PlanManager, nm *notes.NotesManager, projectName, task string, ranker Ranker) string {
+	var history strings.Builder
+
+	if past, err := em.ListEstimates(projectName); err == nil {
+		for i, estimate := range SimilarTasks(ctx, past, task, ranker) {
+			if i == 0 {
+				history.WriteString("Past tasks (estimated vs actual hours):\n")
+			}
+			history.WriteString(fmt.Sprintf("- %s: estimated %.1fh, actual %.1fh\n", estimate.Task, estimate.Hours, *estimate.ActualHours))
+		}
+		if mean, count := MeanAccuracy(past); count > 0 {
+			history.WriteString(fmt.Sprintf("Mean estimate accuracy: %.0f%% over %d tasks\n\n", mean*100, count))
+		}
+	}
+
+	if allPlans, err := pm.ListPlans(projectName); err == nil && len(allPlans) > 0 {
+		history.WriteString("Plans:\n")
+		for _, plan := range allPlans {
+			line := fmt.Sprintf("- %s: %d/%d steps done", plan.Goal, plan.CompletedSteps(), len(plan.Steps))
+			if plan.CompletedAt != nil {
+				line += fmt.Sprintf(", finished in %.1f days", plan.CompletedAt.Sub(plan.CreatedAt).Hours()/24)
+			} else {
+				line += fmt.Sprintf(", open for %.1f days", time.Since(plan.CreatedAt).Hours()/24)
+			}
+			history.WriteString(line + "\n")
+		}
+		history.WriteString("\n")
+	}
+
+	if entries, err := nm.ListProgressIndex(projectName); err == nil && len(entries) > 0 {
+		activeDays := make(map[string]bool)
+		cutoff := time.Now().AddDate(0, 0, -30)
+		recent := 0
+		for _, entry := range entries {
+			if entry.Timestamp.After(cutoff) {
+				recent++
+				activeDays[entry.Timestamp.Format("2006-01-02")] = true
+			}
+		}
+		history.WriteString(fmt.Sprintf("Activity in the last 30 days: %d progress notes across %d active days\n", recent, len(activeDays)))
+	}
+
+	if history.Len() == 0 {
+		return "No historical data is available for this project yet."
+	}
+	return history.String()
+}
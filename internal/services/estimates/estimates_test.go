@@ -0,0 +1,72 @@
+package estimates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bkidd1/wash-cli/internal/services/retrieval"
+)
+
+// suffixRanker ranks the candidates ending in its suffix first
+type suffixRanker string
+
+func (r suffixRanker) TopK(ctx context.Context, query string, candidates []string, k int) ([]retrieval.Result, error) {
+	var results []retrieval.Result
+	for _, candidate := range candidates {
+		if len(candidate) >= len(r) && candidate[len(candidate)-len(r):] == string(r) {
+			results = append(results, retrieval.Result{Text: candidate, Score: 1})
+		}
+	}
+	return results, nil
+}
+
+// failingRanker always fails, as when embeddings are unavailable
+type failingRanker struct{}
+
+func (failingRanker) TopK(ctx context.Context, query string, candidates []string, k int) ([]retrieval.Result, error) {
+	return nil, errors.New("embeddings unavailable")
+}
+
+// recent returns the first n task names, in the order they were recorded
+func recent(n int) []string {
+	tasks := make([]string, n)
+	for i := range tasks {
+		tasks[i] = fmt.Sprintf("task %d", i)
+	}
+	return tasks
+}
+
+func TestSimilarTasks(t *testing.T) {
+	actual := 2.0
+	var past []*Estimate
+	for i := 0; i < 30; i++ {
+		past = append(past, &Estimate{Task: fmt.Sprintf("task %d", i), Hours: 1, ActualHours: &actual})
+	}
+	// Unscored estimates are never sent, and repeated tasks are all kept
+	past = append(past, &Estimate{Task: "unscored 7"}, &Estimate{Task: "task 27", Hours: 3, ActualHours: &actual})
+
+	tests := []struct {
+		name   string
+		ranker Ranker
+		want   []string
+	}{
+		{"most recent without a ranker", nil, recent(20)},
+		{"most recent when ranking fails", failingRanker{}, recent(20)},
+		{"most similar with a ranker", suffixRanker("7"), []string{"task 7", "task 17", "task 27", "task 27"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			similar := SimilarTasks(context.Background(), past, "fix 7", tt.ranker)
+			if len(similar) != len(tt.want) {
+				t.Fatalf("Expected %d tasks, got %d", len(tt.want), len(similar))
+			}
+			for i, estimate := range similar {
+				if estimate.Task != tt.want[i] {
+					t.Errorf("Task %d = %q, want %q", i, estimate.Task, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package findings
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/crypt"
+	"github.com/bkidd1/wash-cli/internal/utils/fsutil"
+)
+
+// Weights of each finding in the health score
+const (
+	criticalWeight  = 10
+	shouldFixWeight = 3
+	couldFixWeight  = 1
+)
+
+// File is the latest findings of one analyzed file
+type File struct {
+	Path           string    `json:"path"`
+	AnalyzedAt     time.Time `json:"analyzed_at"`
+	CriticalIssues []string  `json:"critical_issues,omitempty"`
+	ShouldFix      []string  `json:"should_fix,omitempty"`
+	CouldFix       []string  `json:"could_fix,omitempty"`
+}
+
+// Score rates the file from 0 to 100, losing points for every finding by severity
+func (f *File) Score() int {
+	return max(0, 100-criticalWeight*len(f.CriticalIssues)-shouldFixWeight*len(f.ShouldFix)-couldFixWeight*len(f.CouldFix))
+}
+
+// Summary is the latest summary generated with wash summary
+type Summary struct {
+	Period      string    `json:"period"`
+	Text        string    `json:"text"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// State is a project's current findings: the latest analysis of every file and the latest summary
+type State struct {
+	Project string           `json:"project"`
+	Files   map[string]*File `json:"files"`
+	Summary *Summary         `json:"summary,omitempty"`
+}
+
+// Paths returns the analyzed files in order
+func (s *State) Paths() []string {
+	paths := make([]string, 0, len(s.Files))
+	for path := range s.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// HealthScore is the average score of the analyzed files, or 100 when none was analyzed
+func (s *State) HealthScore() int {
+	if len(s.Files) == 0 {
+		return 100
+	}
+	total := 0
+	for _, file := range s.Files {
+		total += file.Score()
+	}
+	return total / len(s.Files)
+}
+
+// Counts returns the number of findings of each severity across files
+func (s *State) Counts() (critical, shouldFix, couldFix int) {
+	for _, file := range s.Files {
+		critical += len(file.CriticalIssues)
+		shouldFix += len(file.ShouldFix)
+		couldFix += len(file.CouldFix)
+	}
+	return critical, shouldFix, couldFix
+}
+
+// FindingsManager handles storage of the current findings of each project
+type FindingsManager struct {
+	baseDir string
+	cipher  *crypt.Cipher // nil when encryption at rest is off
+}
+
+// NewFindingsManager creates a new FindingsManager instance
+func NewFindingsManager() (*FindingsManager, error) {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+
+	// Findings quote the analyzed code, so they are encrypted like the notes
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	cipher, err := crypt.Load(cfg.Encryption, dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unlock encrypted findings: %w", err)
+	}
+
+	return &FindingsManager{baseDir: filepath.Join(dataDir, "projects"), cipher: cipher}, nil
+}
+
+// statePath returns the findings file of a project
+func (fm *FindingsManager) statePath(projectName string) string {
+	return filepath.Join(fm.baseDir, projectName, "findings.json")
+}
+
+// Load returns a project's current findings, which are empty if nothing was analyzed yet
+func (fm *FindingsManager) Load(projectName string) (*State, error) {
+	state := &State{Project: projectName, Files: map[string]*File{}}
+
+	data, err := os.ReadFile(fm.statePath(projectName))
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading findings: %w", err)
+	}
+	if data, err = fm.cipher.Decrypt(data); err != nil {
+		return nil, fmt.Errorf("error decrypting findings: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("error parsing findings: %w", err)
+	}
+	if state.Files == nil {
+		state.Files = map[string]*File{}
+	}
+	return state, nil
+}
+
+// RecordFile replaces the findings of a file with those of its latest analysis
+func (fm *FindingsManager) RecordFile(projectName string, file *File) error {
+	return fm.update(projectName, func(state *State) {
+		state.Files[file.Path] = file
+	})
+}
+
+// RecordSummary keeps the latest summary of a project
+func (fm *FindingsManager) RecordSummary(projectName string, summary *Summary) error {
+	return fm.update(projectName, func(state *State) {
+		state.Summary = summary
+	})
+}
+
+// update loads, changes and saves a project's findings, holding the project lock so concurrent
+// analyses do not lose each other's findings
+func (fm *FindingsManager) update(projectName string, fn func(*State)) error {
+	path := fm.statePath(projectName)
+	unlock, err := fsutil.LockDir(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	state, err := fm.Load(projectName)
+	if err != nil {
+		return err
+	}
+	fn(state)
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling findings: %w", err)
+	}
+	if data, err = fm.cipher.Encrypt(data); err != nil {
+		return fmt.Errorf("error encrypting findings: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(path, data, 0644); err != nil {
+		return fmt.Errorf("error writing findings: %w", err)
+	}
+	return nil
+}
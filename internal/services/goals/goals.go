@@ -0,0 +1,155 @@
+package goals
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/journal"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/crypt"
+	"github.com/bkidd1/wash-cli/internal/utils/fsutil"
+)
+
+// GoalEnv overrides every stored project goal
+const GoalEnv = config.EnvPrefix + "_PROJECT_GOAL"
+
+// Change records one goal being set; an empty goal means it was cleared
+type Change struct {
+	Goal  string    `json:"goal"`
+	SetAt time.Time `json:"set_at"`
+	SetBy string    `json:"set_by,omitempty"`
+}
+
+// ProjectGoal holds a project's goal history, oldest first
+type ProjectGoal struct {
+	Project string   `json:"project"`
+	History []Change `json:"history"`
+}
+
+// Current returns the project's current goal, or an empty string if none is set
+func (g *ProjectGoal) Current() string {
+	if len(g.History) == 0 {
+		return ""
+	}
+	return g.History[len(g.History)-1].Goal
+}
+
+// GoalManager handles storage of per-project goals
+type GoalManager struct {
+	baseDir string
+	cipher  *crypt.Cipher // nil when encryption at rest is off
+}
+
+// NewGoalManager creates a new GoalManager instance
+func NewGoalManager() (*GoalManager, error) {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+
+	// Goals describe the project, so they are encrypted like the notes
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	cipher, err := crypt.Load(cfg.Encryption, dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unlock encrypted goals: %w", err)
+	}
+
+	return &GoalManager{baseDir: filepath.Join(dataDir, "projects"), cipher: cipher}, nil
+}
+
+// goalPath returns the goal file for a project
+func (gm *GoalManager) goalPath(projectName string) string {
+	return filepath.Join(gm.baseDir, projectName, "goal.json")
+}
+
+// Load returns a project's goal history, which is empty if no goal was ever set
+func (gm *GoalManager) Load(projectName string) (*ProjectGoal, error) {
+	goal := &ProjectGoal{Project: projectName}
+
+	data, err := os.ReadFile(gm.goalPath(projectName))
+	if os.IsNotExist(err) {
+		return goal, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading goal file: %w", err)
+	}
+	if data, err = gm.cipher.Decrypt(data); err != nil {
+		return nil, fmt.Errorf("error decrypting goal file: %w", err)
+	}
+	if err := json.Unmarshal(data, goal); err != nil {
+		return nil, fmt.Errorf("error parsing goal file: %w", err)
+	}
+	return goal, nil
+}
+
+// Set records a new goal for a project; an empty goal clears it
+func (gm *GoalManager) Set(projectName, goal, setBy string) (*ProjectGoal, error) {
+	dir := filepath.Dir(gm.goalPath(projectName))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating project directory: %w", err)
+	}
+
+	unlock, err := fsutil.LockDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	current, err := gm.Load(projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	goal = strings.TrimSpace(goal)
+	if goal == current.Current() {
+		return current, nil
+	}
+	current.History = append(current.History, Change{Goal: goal, SetAt: time.Now(), SetBy: setBy})
+
+	data, err := json.MarshalIndent(current, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling goal: %w", err)
+	}
+	if data, err = gm.cipher.Encrypt(data); err != nil {
+		return nil, fmt.Errorf("error encrypting goal: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(gm.goalPath(projectName), data, 0644); err != nil {
+		return nil, fmt.Errorf("error writing goal file: %w", err)
+	}
+
+	// The journal is not encrypted, so it records that the goal changed but not what it says
+	detail := "goal set"
+	if goal == "" {
+		detail = "goal cleared"
+	}
+	journal.Record(journal.ActionConfigChanged, projectName, "project goal", detail)
+	return current, nil
+}
+
+// Resolve returns the goal analyzers should use for a project: $WASH_PROJECT_GOAL, then the goal set
+// with wash goal set, then project_goal from the project or global config file. A goal that cannot be
+// loaded is warned about on stderr, keeping stdout to the command's output.
+func Resolve(projectName string, cfg *config.Config) string {
+	if goal := os.Getenv(GoalEnv); goal != "" {
+		return goal
+	}
+
+	goalManager, err := NewGoalManager()
+	if err == nil {
+		var goal *ProjectGoal
+		if goal, err = goalManager.Load(projectName); err == nil && goal.Current() != "" {
+			return goal.Current()
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not load the goal for %s: %v\n", projectName, err)
+	}
+	return cfg.ProjectGoal
+}
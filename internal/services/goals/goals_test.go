@@ -0,0 +1,49 @@
+package goals
+
+import (
+	"testing"
+
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+)
+
+func TestSetAndResolve(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(GoalEnv, "")
+	gm, err := NewGoalManager()
+	if err != nil {
+		t.Fatalf("Failed to create goal manager: %v", err)
+	}
+
+	cfg := &config.Config{ProjectGoal: "config goal"}
+	if got := Resolve("app", cfg); got != "config goal" {
+		t.Errorf("Expected the config goal without a project goal, got %q", got)
+	}
+
+	for _, text := range []string{"first", "second", "second", ""} {
+		if _, err := gm.Set("app", text, "alice"); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+	goal, err := gm.Load("app")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(goal.History) != 3 || goal.Current() != "" {
+		t.Errorf("Expected 3 changes ending in a clear, got %+v", goal.History)
+	}
+
+	if _, err := gm.Set("app", "third", "alice"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if got := Resolve("app", cfg); got != "third" {
+		t.Errorf("Expected the project goal, got %q", got)
+	}
+	if got := Resolve("other", cfg); got != "config goal" {
+		t.Errorf("Expected other projects to keep the config goal, got %q", got)
+	}
+
+	t.Setenv(GoalEnv, "env goal")
+	if got := Resolve("app", cfg); got != "env goal" {
+		t.Errorf("Expected the environment to win, got %q", got)
+	}
+}
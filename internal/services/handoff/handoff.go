@@ -0,0 +1,335 @@
+package handoff
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/bugs"
+	"github.com/bkidd1/wash-cli/internal/services/goals"
+	"github.com/bkidd1/wash-cli/internal/services/journal"
+	"github.com/bkidd1/wash-cli/internal/services/notes"
+	"github.com/bkidd1/wash-cli/internal/services/usage"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/crypt"
+	"github.com/bkidd1/wash-cli/internal/utils/fsutil"
+	"github.com/bkidd1/wash-cli/internal/utils/tokens"
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	// fileTimeLayout names handoff files <time>.json
+	fileTimeLayout = "2006-01-02-15-04-05"
+
+	// progressBudget caps the tokens of progress notes sent to the model; the latest notes are kept
+	progressBudget = 6000
+)
+
+// prompt asks the model for a handoff from a session's notes
+const prompt = `You are writing a handoff briefing at the end of a development session, for the developer (or their AI coding assistant) who resumes the work, possibly days later with no memory of it. From the session below, say what was in progress and how far it got, what is blocking it, the concrete next steps in order, and the files that matter for picking it up. Be specific and brief: name functions, files and errors rather than describing them. Do not list work that was finished unless the next steps depend on it.
+
+Format your response as a JSON object with the following structure:
+{
+    "in_progress": "one or two sentences on the work in progress and its state",
+    "blockers": ["current blockers or open questions"],
+    "next_steps": ["next steps, most important first"],
+    "files": ["relevant files"]
+}`
+
+// Handoff briefs whoever resumes a project on where its last session left off
+type Handoff struct {
+	Project   string    `json:"project"`
+	CreatedAt time.Time `json:"created_at"`
+	// SessionStart is when the session the handoff covers began
+	SessionStart time.Time `json:"session_start"`
+
+	InProgress string   `json:"in_progress"`
+	Blockers   []string `json:"blockers,omitempty"`
+	NextSteps  []string `json:"next_steps,omitempty"`
+	Files      []string `json:"files,omitempty"`
+}
+
+// String formats the handoff as markdown
+func (h *Handoff) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Handoff from %s\n\n", h.CreatedAt.Local().Format("Mon 2006-01-02 15:04"))
+	fmt.Fprintf(&b, "In progress: %s\n", h.InProgress)
+	writeList(&b, "Blockers", h.Blockers)
+	writeList(&b, "Next steps", h.NextSteps)
+	writeList(&b, "Relevant files", h.Files)
+	return b.String()
+}
+
+// Session is the material a handoff is written from
+type Session struct {
+	Project string
+	Start   time.Time
+	End     time.Time
+	Goal    string
+
+	// Progress are the session's progress notes, oldest first
+	Progress []*notes.ProjectProgressNote
+	// Analyzed are the files and diffs analyzed during the session
+	Analyzed []string
+	OpenBugs []*bugs.Report
+	// Previous is the handoff the session started from, if any
+	Previous *Handoff
+}
+
+// Gather collects a project's session from start to end from its progress notes, activity journal
+// and open bugs
+func Gather(nm *notes.NotesManager, bm *bugs.BugManager, hm *HandoffManager, project, goal string, start, end time.Time) (*Session, error) {
+	session := &Session{Project: project, Start: start, End: end, Goal: goal}
+
+	progress, err := nm.GetProgressNotesBetween(project, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("error loading progress notes: %w", err)
+	}
+	sort.Slice(progress, func(i, j int) bool { return progress[i].Timestamp.Before(progress[j].Timestamp) })
+	session.Progress = progress
+
+	entries, err := journal.Load(journal.Filter{Since: start, Project: project, Action: journal.ActionAnalysis})
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.Timestamp.After(end) || entry.Subject == "" || seen[entry.Subject] {
+			continue
+		}
+		seen[entry.Subject] = true
+		session.Analyzed = append(session.Analyzed, entry.Subject)
+	}
+
+	reports, err := bm.List(project, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, report := range reports {
+		if !report.IsResolved() {
+			session.OpenBugs = append(session.OpenBugs, report)
+		}
+	}
+
+	if session.Previous, err = hm.Latest(project); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// Empty reports whether nothing happened in the session to hand off
+func (s *Session) Empty() bool {
+	return len(s.Progress) == 0 && len(s.Analyzed) == 0
+}
+
+// String formats the session for the model, keeping the latest progress notes within budget
+func (s *Session) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Project: %s\nSession: %s to %s\n", s.Project,
+		s.Start.Local().Format("2006-01-02 15:04"), s.End.Local().Format("2006-01-02 15:04"))
+	if s.Goal != "" {
+		fmt.Fprintf(&b, "Project goal: %s\n", s.Goal)
+	}
+	if s.Previous != nil {
+		fmt.Fprintf(&b, "\nThe session started from this handoff:\n%s", s.Previous)
+	}
+
+	var progress []string
+	used := 0
+	for i := len(s.Progress) - 1; i >= 0; i-- {
+		note := s.Progress[i]
+		text := fmt.Sprintf("### %s (%s)\n%s\n", note.Title, note.Timestamp.Local().Format("15:04"), strings.TrimSpace(note.Description))
+		if used += tokens.Count(openai.GPT4o, text); used > progressBudget && len(progress) > 0 {
+			break
+		}
+		progress = append(progress, text)
+	}
+	if len(progress) > 0 {
+		b.WriteString("\n## Progress notes\n")
+		for i := len(progress) - 1; i >= 0; i-- {
+			b.WriteString(progress[i])
+		}
+	}
+
+	writeList(&b, "Analyzed with wash", s.Analyzed)
+	if len(s.OpenBugs) > 0 {
+		b.WriteString("\n## Open bugs\n")
+		for _, report := range s.OpenBugs {
+			fmt.Fprintf(&b, "- %s (%s, %s)\n", report.Title(), report.Status(), report.Priority())
+		}
+	}
+	return b.String()
+}
+
+// Generate asks the model to write the handoff for a session
+func Generate(ctx context.Context, client *openai.Client, model string, session *Session) (*Handoff, error) {
+	resp, err := client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: prompt},
+			{Role: openai.ChatMessageRoleUser, Content: session.String()},
+		},
+		ResponseFormat: &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error generating handoff: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no response from model")
+	}
+
+	handoff, err := parse(resp.Choices[0].Message.Content)
+	if err != nil {
+		return nil, err
+	}
+	handoff.Project = session.Project
+	handoff.CreatedAt = time.Now()
+	handoff.SessionStart = session.Start
+	return handoff, nil
+}
+
+// Write gathers a project's session since start, generates its handoff and saves it. It returns
+// nil without calling the model when nothing happened in the session.
+func Write(cfg *config.Config, project string, start time.Time) (*Handoff, error) {
+	nm, err := notes.NewNotesManager()
+	if err != nil {
+		return nil, err
+	}
+	bm, err := bugs.NewBugManager()
+	if err != nil {
+		return nil, err
+	}
+	hm, err := NewHandoffManager()
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := Gather(nm, bm, hm, project, goals.Resolve(project, cfg), start, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if session.Empty() {
+		return nil, nil
+	}
+
+	model := cfg.Model
+	if model == "" {
+		model = openai.GPT4o
+	}
+	handoff, err := Generate(context.Background(), usage.NewClient(cfg.OpenAIKey), model, session)
+	if err != nil {
+		return nil, err
+	}
+	if err := hm.Save(handoff); err != nil {
+		return nil, err
+	}
+	return handoff, nil
+}
+
+// parse reads the model's JSON answer into a handoff
+func parse(content string) (*Handoff, error) {
+	var handoff Handoff
+	if err := json.Unmarshal([]byte(content), &handoff); err != nil {
+		return nil, fmt.Errorf("error parsing handoff: %w", err)
+	}
+	if strings.TrimSpace(handoff.InProgress) == "" && len(handoff.NextSteps) == 0 {
+		return nil, fmt.Errorf("the model returned an empty handoff")
+	}
+	return &handoff, nil
+}
+
+// HandoffManager handles storage of the handoffs of each project
+type HandoffManager struct {
+	baseDir string
+	cipher  *crypt.Cipher // nil when encryption at rest is off
+}
+
+// NewHandoffManager creates a new HandoffManager instance
+func NewHandoffManager() (*HandoffManager, error) {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+
+	// Handoffs summarize the notes and open bugs, so they are encrypted like them
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	cipher, err := crypt.Load(cfg.Encryption, dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unlock encrypted handoffs: %w", err)
+	}
+
+	return &HandoffManager{baseDir: filepath.Join(dataDir, "projects"), cipher: cipher}, nil
+}
+
+// Dir returns the directory holding a project's handoffs
+func (hm *HandoffManager) Dir(projectName string) string {
+	return filepath.Join(hm.baseDir, projectName, "handoffs")
+}
+
+// Save stores a handoff; earlier handoffs are kept as history
+func (hm *HandoffManager) Save(handoff *Handoff) error {
+	data, err := json.MarshalIndent(handoff, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling handoff: %w", err)
+	}
+	if data, err = hm.cipher.Encrypt(data); err != nil {
+		return fmt.Errorf("error encrypting handoff: %w", err)
+	}
+
+	dir := hm.Dir(handoff.Project)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("error creating handoff directory: %w", err)
+	}
+	path := filepath.Join(dir, handoff.CreatedAt.Format(fileTimeLayout)+".json")
+	if err := fsutil.WriteFileAtomic(path, data, 0644); err != nil {
+		return fmt.Errorf("error writing handoff: %w", err)
+	}
+
+	journal.Record(journal.ActionSaved, handoff.Project, path, "handoff")
+	return nil
+}
+
+// Latest returns a project's most recent handoff, or nil if none was written
+func (hm *HandoffManager) Latest(projectName string) (*Handoff, error) {
+	paths, err := filepath.Glob(filepath.Join(hm.Dir(projectName), "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing handoffs: %w", err)
+	}
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	// File names sort by time
+	sort.Strings(paths)
+	data, err := os.ReadFile(paths[len(paths)-1])
+	if err != nil {
+		return nil, fmt.Errorf("error reading handoff: %w", err)
+	}
+	if data, err = hm.cipher.Decrypt(data); err != nil {
+		return nil, fmt.Errorf("error decrypting handoff: %w", err)
+	}
+	var handoff Handoff
+	if err := json.Unmarshal(data, &handoff); err != nil {
+		return nil, fmt.Errorf("error parsing handoff %s: %w", filepath.Base(paths[len(paths)-1]), err)
+	}
+	return &handoff, nil
+}
+
+// writeList writes a labeled list, or nothing if it is empty
+func writeList(b *strings.Builder, heading string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n%s:\n", heading)
+	for _, item := range items {
+		fmt.Fprintf(b, "- %s\n", item)
+	}
+}
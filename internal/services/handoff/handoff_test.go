@@ -0,0 +1,51 @@
+package handoff
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSaveAndLatest(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	hm, err := NewHandoffManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest, err := hm.Latest("demo"); err != nil || latest != nil {
+		t.Fatalf("Expected no handoff yet, got %v, %v", latest, err)
+	}
+
+	older, err := parse(`{"in_progress": "Retry logic for the upload client", "next_steps": ["Add backoff"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	older.Project, older.CreatedAt = "demo", time.Date(2024, 5, 1, 17, 0, 0, 0, time.Local)
+	newer, err := parse(`{"in_progress": "Wiring backoff into upload.go", "blockers": ["Flaky TestUpload"], "files": ["upload.go"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newer.Project, newer.CreatedAt = "demo", older.CreatedAt.AddDate(0, 0, 3)
+
+	for _, handoff := range []*Handoff{newer, older} {
+		if err := hm.Save(handoff); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+
+	latest, err := hm.Latest("demo")
+	if err != nil {
+		t.Fatalf("Latest failed: %v", err)
+	}
+	if latest == nil || latest.InProgress != newer.InProgress {
+		t.Fatalf("Expected the newest handoff, got %+v", latest)
+	}
+	if text := latest.String(); !strings.Contains(text, "- Flaky TestUpload") || !strings.Contains(text, "- upload.go") {
+		t.Errorf("Expected blockers and files in the handoff, got:\n%s", text)
+	}
+
+	if _, err := parse(`{"in_progress": " "}`); err == nil {
+		t.Error("Expected an empty handoff to be rejected")
+	}
+}
@@ -0,0 +1,314 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/fsutil"
+)
+
+// Events wash runs hooks for
+const (
+	CriticalFinding  = "on-critical-finding"
+	SummaryGenerated = "on-summary-generated"
+	BugCreated       = "on-bug-created"
+)
+
+// Timeout bounds how long a hook may run before it is killed
+const Timeout = 30 * time.Second
+
+// trustFile records the project hooks the user has reviewed, under the data directory
+const trustFile = "trusted_hooks.json"
+
+// Payload is the JSON document a hook reads from stdin
+type Payload struct {
+	Event   string    `json:"event"`
+	Project string    `json:"project"`
+	Time    time.Time `json:"time"`
+	Data    any       `json:"data"`
+}
+
+// Finding is the data of an on-critical-finding event
+type Finding struct {
+	File           string   `json:"file"`
+	CriticalIssues []string `json:"critical_issues"`
+	ShouldFix      []string `json:"should_fix,omitempty"`
+	CouldFix       []string `json:"could_fix,omitempty"`
+}
+
+// Summary is the data of an on-summary-generated event
+type Summary struct {
+	Period  string `json:"period"`
+	Summary string `json:"summary"`
+	Notes   int    `json:"notes"`
+}
+
+// Bug is the data of an on-bug-created event
+type Bug struct {
+	ID                 string `json:"id"`
+	Description        string `json:"description"`
+	Priority           string `json:"priority"`
+	Path               string `json:"path"`
+	PotentialCauses    string `json:"potential_causes"`
+	SuggestedSolutions string `json:"suggested_solutions"`
+}
+
+// UntrustedError reports a hook from a project .wash.yaml that has not been trusted
+type UntrustedError struct {
+	Event string
+	File  string
+}
+
+func (e *UntrustedError) Error() string {
+	return fmt.Sprintf("%s hook from %s is not trusted; review it and run 'wash hooks trust'", e.Event, e.File)
+}
+
+// Runner runs the hook commands configured for wash events
+type Runner struct {
+	hooks        map[string]string
+	projectHooks map[string]string
+	projectFile  string
+	dataDir      string
+}
+
+// NewRunner creates a runner for the hooks in cfg
+func NewRunner(cfg *config.Config) *Runner {
+	dataDir, _ := config.DataDir()
+	return &Runner{
+		hooks:        cfg.Hooks,
+		projectHooks: cfg.ProjectHooks,
+		projectFile:  config.ProjectPath(),
+		dataDir:      dataDir,
+	}
+}
+
+// Command returns the command configured for an event, or an empty string
+func (r *Runner) Command(event string) string {
+	return r.hooks[event]
+}
+
+// Run runs the hook for an event, if one is configured, with the payload on stdin. The hook's
+// output goes to stderr, so it never mixes into a report the command prints; a hook that fails or
+// outlives Timeout returns an error.
+func (r *Runner) Run(event, project string, data any) error {
+	command := r.hooks[event]
+	if command == "" {
+		return nil
+	}
+	if _, ok := r.projectHooks[event]; ok {
+		if !r.Trusted() {
+			return &UntrustedError{Event: event, File: r.projectFile}
+		}
+	} else if path := relativePath(command); path != "" {
+		// Hooks run in the current directory, so a relative path would run whatever the
+		// repository at hand ships under that name
+		return fmt.Errorf("%s hook runs %s by a relative path; use an absolute path in the global config", event, path)
+	}
+
+	payload, err := json.Marshal(Payload{Event: event, Project: project, Time: time.Now(), Data: data})
+	if err != nil {
+		return fmt.Errorf("error encoding %s payload: %w", event, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	cmd := shell(ctx, command)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), "WASH_HOOK_EVENT="+event, "WASH_HOOK_PROJECT="+project)
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("%s hook timed out after %s", event, Timeout)
+		}
+		return fmt.Errorf("%s hook failed: %w", event, err)
+	}
+	return nil
+}
+
+// Notify runs the hook for an event and prints a warning when it fails, so a broken hook never
+// fails the command that fired it
+func Notify(cfg *config.Config, event, project string, data any) {
+	if err := NewRunner(cfg).Run(event, project, data); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// shell runs a command with the platform's shell
+func shell(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+// ProjectHooks returns the hooks set by the project .wash.yaml and the file's path
+func (r *Runner) ProjectHooks() (map[string]string, string) {
+	return r.projectHooks, r.projectFile
+}
+
+// Trusted reports whether the project hooks, exactly as configured now, have been trusted
+func (r *Runner) Trusted() bool {
+	if len(r.projectHooks) == 0 {
+		return true
+	}
+	trusted, err := r.loadTrust()
+	if err != nil {
+		return false
+	}
+	return trusted[r.projectFile] == fingerprint(r.projectHooks)
+}
+
+// Trust records the current project hooks as reviewed. Changing any of them requires trusting
+// them again.
+func (r *Runner) Trust() error {
+	if len(r.projectHooks) == 0 {
+		return fmt.Errorf("no project .wash.yaml defines hooks")
+	}
+	trusted, err := r.loadTrust()
+	if err != nil {
+		return err
+	}
+	trusted[r.projectFile] = fingerprint(r.projectHooks)
+
+	data, err := json.MarshalIndent(trusted, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding trusted hooks: %w", err)
+	}
+	if err := os.MkdirAll(r.dataDir, 0755); err != nil {
+		return fmt.Errorf("error creating data directory: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(filepath.Join(r.dataDir, trustFile), data, 0600); err != nil {
+		return fmt.Errorf("error saving trusted hooks: %w", err)
+	}
+	return nil
+}
+
+// loadTrust reads the fingerprints of trusted project hooks by config path
+func (r *Runner) loadTrust() (map[string]string, error) {
+	trusted := make(map[string]string)
+	data, err := os.ReadFile(filepath.Join(r.dataDir, trustFile))
+	if os.IsNotExist(err) {
+		return trusted, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading trusted hooks: %w", err)
+	}
+	if err := json.Unmarshal(data, &trusted); err != nil {
+		return nil, fmt.Errorf("error decoding trusted hooks: %w", err)
+	}
+	return trusted, nil
+}
+
+// fingerprint hashes hook commands by event, along with the contents of the files they name, so
+// a trusted script rewritten by a pull has to be trusted again
+func fingerprint(hooks map[string]string) string {
+	events := make([]string, 0, len(hooks))
+	for event := range hooks {
+		events = append(events, event)
+	}
+	sort.Strings(events)
+
+	h := sha256.New()
+	for _, event := range events {
+		fmt.Fprintf(h, "%s=%s\n", event, hooks[event])
+		for _, path := range scriptPaths(hooks[event]) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				fmt.Fprintf(h, "%s unreadable\n", path)
+				continue
+			}
+			sum := sha256.Sum256(data)
+			fmt.Fprintf(h, "%s %x\n", path, sum)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// commandSeparators split a command into the simple commands of its pipelines and lists
+var commandSeparators = regexp.MustCompile(`\|\||&&|[|;&\n]`)
+
+// interpreters run the script named by their first argument
+var interpreters = []string{"sh", "bash", "zsh", "python", "python3", "node", "ruby", "perl"}
+
+// program is a file a command runs: the program of a simple command, or the script an
+// interpreter is given
+type program struct {
+	name   string
+	script bool
+}
+
+// programs returns what a command runs, without quotes
+func programs(command string) []program {
+	var programs []program
+	for _, simple := range commandSeparators.Split(command, -1) {
+		words := strings.Fields(simple)
+		for i := range words {
+			words[i] = strings.Trim(words[i], `"'`)
+		}
+		if len(words) == 0 || words[0] == "" {
+			continue
+		}
+		programs = append(programs, program{name: words[0]})
+		if slices.Contains(interpreters, filepath.Base(words[0])) && len(words) > 1 && !strings.HasPrefix(words[1], "-") {
+			programs = append(programs, program{name: words[1], script: true})
+		}
+	}
+	return programs
+}
+
+// local reports whether the shell finds the program by a path rather than on PATH: it has a
+// slash, or it is a script given to an interpreter
+func (p program) local() bool {
+	return p.script || strings.ContainsRune(p.name, '/')
+}
+
+// scriptPaths returns the local files a hook command runs, such as its script, resolved from the
+// current directory as the shell resolves them
+func scriptPaths(command string) []string {
+	var paths []string
+	for _, p := range programs(command) {
+		if !p.local() {
+			continue
+		}
+		program := p.name
+		if strings.HasPrefix(program, "~/") {
+			if home, err := os.UserHomeDir(); err == nil {
+				program = filepath.Join(home, program[2:])
+			}
+		}
+		path, err := filepath.Abs(program)
+		if err != nil {
+			continue
+		}
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// relativePath returns the first program or script a command names by a relative path, or ""
+func relativePath(command string) string {
+	for _, p := range programs(command) {
+		if p.local() && !filepath.IsAbs(p.name) && !strings.HasPrefix(p.name, "~/") {
+			return p.name
+		}
+	}
+	return ""
+}
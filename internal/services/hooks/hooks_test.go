@@ -0,0 +1,155 @@
+package hooks
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestRunProjectHookOnceTrusted(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands use sh")
+	}
+	dir := t.TempDir()
+	out := filepath.Join(dir, "payload.json")
+	command := "cat > " + out
+	r := &Runner{
+		hooks:        map[string]string{BugCreated: command},
+		projectHooks: map[string]string{BugCreated: command},
+		projectFile:  filepath.Join(dir, ".wash.yaml"),
+		dataDir:      dir,
+	}
+
+	var untrusted *UntrustedError
+	if err := r.Run(BugCreated, "demo", Bug{ID: "1"}); !errors.As(err, &untrusted) {
+		t.Fatalf("Expected an untrusted project hook to be refused, got %v", err)
+	}
+	if err := r.Trust(); err != nil {
+		t.Fatalf("Trust failed: %v", err)
+	}
+	if err := r.Run(BugCreated, "demo", Bug{ID: "1"}); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Hook did not run: %v", err)
+	}
+	var payload struct {
+		Event   string `json:"event"`
+		Project string `json:"project"`
+		Data    Bug    `json:"data"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		t.Fatalf("Invalid payload %q: %v", data, err)
+	}
+	if payload.Event != BugCreated || payload.Project != "demo" || payload.Data.ID != "1" {
+		t.Errorf("Unexpected payload %+v", payload)
+	}
+
+	// Changing a trusted hook requires trusting it again
+	r.hooks[BugCreated] = command + " && true"
+	r.projectHooks[BugCreated] = r.hooks[BugCreated]
+	if err := r.Run(BugCreated, "demo", Bug{ID: "2"}); !errors.As(err, &untrusted) {
+		t.Errorf("Expected a changed project hook to be refused, got %v", err)
+	}
+}
+
+func TestRunKeepsStdoutForReports(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands use sh")
+	}
+	r := &Runner{hooks: map[string]string{CriticalFinding: "echo hook output"}}
+
+	read, write, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = write
+	err = r.Run(CriticalFinding, "demo", Finding{File: "main.go"})
+	os.Stdout = stdout
+	write.Close()
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	data, _ := io.ReadAll(read)
+	if len(data) != 0 {
+		t.Errorf("Expected the hook's output off stdout, got %q", data)
+	}
+}
+
+func TestTrustCoversScripts(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands use sh")
+	}
+	dir := t.TempDir()
+	t.Chdir(dir)
+	script := filepath.Join(dir, "scripts", "notify.sh")
+	if err := os.MkdirAll(filepath.Dir(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(script, []byte("#!/bin/sh\ncat > /dev/null\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	command := "./scripts/notify.sh | tee -a log.txt"
+	r := &Runner{
+		hooks:        map[string]string{BugCreated: command},
+		projectHooks: map[string]string{BugCreated: command},
+		projectFile:  filepath.Join(dir, ".wash.yaml"),
+		dataDir:      dir,
+	}
+	if err := r.Trust(); err != nil {
+		t.Fatalf("Trust failed: %v", err)
+	}
+	if err := r.Run(BugCreated, "demo", Bug{ID: "1"}); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	// Files the hook writes are not part of what was trusted
+	if !r.Trusted() {
+		t.Fatal("Expected the hook to stay trusted after it wrote its log")
+	}
+
+	// A pull that rewrites the script requires trusting it again
+	if err := os.WriteFile(script, []byte("#!/bin/sh\ncurl -d @- https://example.com\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if r.Trusted() {
+		t.Error("Expected a rewritten script to need trusting again")
+	}
+}
+
+func TestRelativePath(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"./scripts/notify.sh", "./scripts/notify.sh"},
+		{"scripts/notify.sh --quiet", "scripts/notify.sh"},
+		{"sh notify.sh", "notify.sh"},
+		{`jq -r .data.id >> bugs.txt && "bin/post"`, "bin/post"},
+		{"/usr/local/bin/notify.sh", ""},
+		{"~/bin/notify.sh", ""},
+		{"jq -r .data.id >> bugs.txt", ""},
+		{"curl -d @- https://example.com/hook", ""},
+		{"bash -c 'echo hi'", ""},
+	}
+	for _, tt := range tests {
+		if got := relativePath(tt.command); got != tt.want {
+			t.Errorf("relativePath(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestRunRefusesRelativeGlobalHooks(t *testing.T) {
+	r := &Runner{hooks: map[string]string{BugCreated: "./scripts/notify.sh"}}
+	if err := r.Run(BugCreated, "demo", Bug{ID: "1"}); err == nil || !strings.Contains(err.Error(), "absolute path") {
+		t.Errorf("Expected a relative global hook to be refused, got %v", err)
+	}
+}
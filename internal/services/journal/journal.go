@@ -0,0 +1,185 @@
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/fsutil"
+)
+
+// journalTimeLayout names the monthly journal files in ~/.wash/journal
+const journalTimeLayout = "2006-01"
+
+// Actions recorded in the journal
+const (
+	ActionAnalysis      = "analysis"
+	ActionSaved         = "saved"
+	ActionDeleted       = "deleted"
+	ActionRestored      = "restored"
+	ActionArchived      = "archived"
+	ActionConfigChanged = "config_changed"
+)
+
+// Actions lists every action, for filtering
+var Actions = []string{ActionAnalysis, ActionSaved, ActionDeleted, ActionRestored, ActionArchived, ActionConfigChanged}
+
+// command is the wash command recorded with each entry, set once at startup
+var command string
+
+// Entry is one wash action in the journal
+type Entry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Command   string    `json:"command,omitempty"`
+	Action    string    `json:"action"`
+	Project   string    `json:"project,omitempty"`
+	// Subject is what the action applied to, such as a file, a note path or a config key
+	Subject string `json:"subject,omitempty"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+// String formats the entry as one line for the terminal
+func (e Entry) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s  %-14s", e.Timestamp.Local().Format("2006-01-02 15:04:05"), e.Action)
+	if e.Project != "" {
+		fmt.Fprintf(&b, " [%s]", e.Project)
+	}
+	if e.Subject != "" {
+		fmt.Fprintf(&b, " %s", e.Subject)
+	}
+	if e.Detail != "" {
+		fmt.Fprintf(&b, " (%s)", e.Detail)
+	}
+	if e.Command != "" {
+		fmt.Fprintf(&b, " via wash %s", e.Command)
+	}
+	return b.String()
+}
+
+// SetCommand names the wash command that entries recorded from now on are attributed to
+func SetCommand(name string) {
+	command = name
+}
+
+// Record appends an entry for an action that just happened. The journal must never fail the
+// action it records, so errors are ignored.
+func Record(action, project, subject, detail string) {
+	_ = Append(Entry{
+		Timestamp: time.Now(),
+		Command:   command,
+		Action:    action,
+		Project:   project,
+		Subject:   subject,
+		Detail:    detail,
+	})
+}
+
+// journalDir returns the directory holding the monthly journals
+func journalDir() (string, error) {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "journal"), nil
+}
+
+// Append adds an entry to the journal of its month. Entries are never changed or removed.
+func Append(entry Entry) error {
+	dir, err := journalDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("error creating journal directory: %w", err)
+	}
+
+	path := filepath.Join(dir, entry.Timestamp.Format(journalTimeLayout)+".jsonl")
+	if err := fsutil.AppendJSONLine(path, entry); err != nil {
+		return fmt.Errorf("error writing journal: %w", err)
+	}
+	return nil
+}
+
+// Filter selects journal entries; empty fields match every entry
+type Filter struct {
+	Since   time.Time
+	Project string
+	Action  string
+	// Search matches the subject, detail or command, ignoring case
+	Search string
+}
+
+// Matches reports whether an entry passes the filter
+func (f Filter) Matches(entry Entry) bool {
+	if !f.Since.IsZero() && entry.Timestamp.Before(f.Since) {
+		return false
+	}
+	if f.Project != "" && entry.Project != f.Project {
+		return false
+	}
+	if f.Action != "" && entry.Action != f.Action {
+		return false
+	}
+	if f.Search != "" {
+		search := strings.ToLower(f.Search)
+		if !strings.Contains(strings.ToLower(entry.Subject+"\n"+entry.Detail+"\n"+entry.Command), search) {
+			return false
+		}
+	}
+	return true
+}
+
+// Load returns the journal entries matching the filter, oldest first
+func Load(filter Filter) ([]Entry, error) {
+	dir, err := journalDir()
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing journals: %w", err)
+	}
+	sort.Strings(paths)
+
+	var entries []Entry
+	for _, path := range paths {
+		// Skip whole months before since
+		month, err := time.ParseInLocation(journalTimeLayout, strings.TrimSuffix(filepath.Base(path), ".jsonl"), time.Local)
+		if err == nil && !filter.Since.IsZero() && month.AddDate(0, 1, 0).Before(filter.Since) {
+			continue
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("error opening journal: %w", err)
+		}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			var entry Entry
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				// A line cut short by a crash is skipped
+				continue
+			}
+			if filter.Matches(entry) {
+				entries = append(entries, entry)
+			}
+		}
+		err = scanner.Err()
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error reading journal: %w", err)
+		}
+	}
+
+	// Entries of concurrent processes can land slightly out of order
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Timestamp.Before(entries[j].Timestamp) })
+	return entries, nil
+}
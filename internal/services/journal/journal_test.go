@@ -0,0 +1,43 @@
+package journal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecordAndLoad(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	SetCommand("notes delete")
+	defer SetCommand("")
+
+	Record(ActionSaved, "demo", "progress/demo_1.json", "progress note: Add login")
+	Record(ActionDeleted, "demo", "progress/demo_1.json", "moved to trash as abc123")
+	Record(ActionAnalysis, "other", "main.go", "file analysis")
+
+	entries, err := Load(Filter{})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(entries) != 3 || entries[0].Action != ActionSaved || entries[2].Action != ActionAnalysis {
+		t.Fatalf("Expected the three entries in order, got %+v", entries)
+	}
+	if entries[1].Command != "notes delete" {
+		t.Errorf("Expected the command to be recorded, got %q", entries[1].Command)
+	}
+
+	deleted, err := Load(Filter{Project: "demo", Action: ActionDeleted, Search: "demo_1"})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0].Detail != "moved to trash as abc123" {
+		t.Errorf("Expected the deletion, got %+v", deleted)
+	}
+
+	later, err := Load(Filter{Since: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(later) != 0 {
+		t.Errorf("Expected no entries after now, got %+v", later)
+	}
+}
@@ -0,0 +1,132 @@
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/crypt"
+	"github.com/bkidd1/wash-cli/internal/utils/fsutil"
+)
+
+// Manifest is a project as of its last wash project run: the hash of every analyzed file and the
+// findings of the report
+type Manifest struct {
+	AnalyzedAt time.Time `json:"analyzed_at"`
+	// Files maps each file, relative to the project, to the SHA-256 of its content
+	Files          map[string]string `json:"files"`
+	CriticalIssues []string          `json:"critical_issues,omitempty"`
+	ShouldFix      []string          `json:"should_fix,omitempty"`
+	CouldFix       []string          `json:"could_fix,omitempty"`
+}
+
+// Changes lists the files added or modified, and the files removed, since the manifest was made,
+// given the current hashes of the project's files
+func (m *Manifest) Changes(current map[string]string) (changed, removed []string) {
+	for path, hash := range current {
+		if m.Files[path] != hash {
+			changed = append(changed, path)
+		}
+	}
+	for path := range m.Files {
+		if _, ok := current[path]; !ok {
+			removed = append(removed, path)
+		}
+	}
+	sort.Strings(changed)
+	sort.Strings(removed)
+	return changed, removed
+}
+
+// Hash returns the SHA-256 of each of a project's files, relative to root; unreadable files are
+// left out
+func Hash(root string, files []string) map[string]string {
+	hashes := make(map[string]string, len(files))
+	for _, path := range files {
+		data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(path)))
+		if err != nil {
+			continue
+		}
+		sum := sha256.Sum256(data)
+		hashes[path] = hex.EncodeToString(sum[:])
+	}
+	return hashes
+}
+
+// ManifestManager handles storage of the manifest of each project's last wash project run
+type ManifestManager struct {
+	baseDir string
+	cipher  *crypt.Cipher // nil when encryption at rest is off
+}
+
+// NewManifestManager creates a new ManifestManager instance
+func NewManifestManager() (*ManifestManager, error) {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+
+	// Manifests keep the report's findings and the project's file names, so they are encrypted like the notes
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	cipher, err := crypt.Load(cfg.Encryption, dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unlock encrypted manifests: %w", err)
+	}
+
+	return &ManifestManager{baseDir: filepath.Join(dataDir, "projects"), cipher: cipher}, nil
+}
+
+// manifestPath returns the manifest file of a project
+func (mm *ManifestManager) manifestPath(projectName string) string {
+	return filepath.Join(mm.baseDir, projectName, "manifest.json")
+}
+
+// Load returns a project's manifest, or nil if wash project has not run on it yet
+func (mm *ManifestManager) Load(projectName string) (*Manifest, error) {
+	data, err := os.ReadFile(mm.manifestPath(projectName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading manifest: %w", err)
+	}
+	if data, err = mm.cipher.Decrypt(data); err != nil {
+		return nil, fmt.Errorf("error decrypting manifest: %w", err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("error parsing manifest: %w", err)
+	}
+	if m.Files == nil {
+		m.Files = map[string]string{}
+	}
+	return &m, nil
+}
+
+// Save replaces a project's manifest
+func (mm *ManifestManager) Save(projectName string, m *Manifest) error {
+	path := mm.manifestPath(projectName)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error creating project directory: %w", err)
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling manifest: %w", err)
+	}
+	if data, err = mm.cipher.Encrypt(data); err != nil {
+		return fmt.Errorf("error encrypting manifest: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(path, data, 0644); err != nil {
+		return fmt.Errorf("error writing manifest: %w", err)
+	}
+	return nil
+}
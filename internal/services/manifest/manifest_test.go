@@ -0,0 +1,58 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestManifestTracksChangedFiles(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	root := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("main.go", "package main\n")
+	write("store/store.go", "package store\n")
+	write("store/old.go", "package store\n")
+
+	mm, err := NewManifestManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m, err := mm.Load("demo"); err != nil || m != nil {
+		t.Fatalf("Expected no manifest before the first run, got %v, %v", m, err)
+	}
+	files := []string{"main.go", "store/store.go", "store/old.go"}
+	err = mm.Save("demo", &Manifest{AnalyzedAt: time.Now(), Files: Hash(root, files), ShouldFix: []string{"Split store"}})
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	write("store/store.go", "package store\n\nfunc Save() {}\n")
+	write("api/api.go", "package api\n")
+	os.Remove(filepath.Join(root, "store/old.go"))
+
+	m, err := mm.Load("demo")
+	if err != nil || m == nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(m.ShouldFix) != 1 {
+		t.Errorf("Expected the report findings to be kept, got %+v", m)
+	}
+	changed, removed := m.Changes(Hash(root, []string{"main.go", "store/store.go", "api/api.go"}))
+	if got := strings.Join(changed, " "); got != "api/api.go store/store.go" {
+		t.Errorf("Unexpected changed files: %s", got)
+	}
+	if got := strings.Join(removed, " "); got != "store/old.go" {
+		t.Errorf("Unexpected removed files: %s", got)
+	}
+}
@@ -4,37 +4,155 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/bkidd1/wash-cli/internal/pid"
+	"github.com/bkidd1/wash-cli/internal/services/attachments"
 	"github.com/bkidd1/wash-cli/internal/services/notes"
+	"github.com/bkidd1/wash-cli/internal/services/plans"
 	"github.com/bkidd1/wash-cli/internal/services/screenshot"
+	"github.com/bkidd1/wash-cli/internal/services/terminal"
+	"github.com/bkidd1/wash-cli/internal/services/transcript"
+	"github.com/bkidd1/wash-cli/internal/services/usage"
+	"github.com/bkidd1/wash-cli/internal/services/workspace"
 	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/crypt"
+	"github.com/bkidd1/wash-cli/internal/utils/language"
+	"github.com/bkidd1/wash-cli/internal/utils/secrets"
 	"github.com/sashabaranov/go-openai"
 )
 
+const (
+	// pidFileName is the PID file a running monitor writes in the data directory
+	pidFileName = "chat_monitor.pid"
+	// idleThreshold is the share of the screen that must change since the last analyzed
+	// screenshot for the next one to be analyzed; a blinking cursor or clock stays below it
+	idleThreshold = 0.003
+)
+
+// RunningPID returns the process ID in the monitor's PID file when that process is alive, or 0
+func RunningPID() int {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return 0
+	}
+	running, _ := pid.NewPIDManager(filepath.Join(dataDir, pidFileName)).CheckRunning()
+	return running
+}
+
 type Monitor struct {
 	client       *openai.Client
 	cfg          *config.Config
 	running      bool
 	stopChan     chan struct{}
 	doneChan     chan struct{}
+	stopOnce     sync.Once
+	stopped      chan struct{}
 	notesDir     string
 	startTime    time.Time
 	pidManager   *pid.PIDManager
 	pidFile      string
 	projectName  string
 	notesManager *notes.NotesManager
+	noteBuffer   *notes.MonitorNoteBuffer
+	languages    string
+	workspace    *workspace.Workspace
+	interval     time.Duration
+
+	// Terminal capture replaces screenshots when a source is set
+	terminalSource terminal.Source
+	terminalTarget string
+	lastScrollback string
+
+	// Agent transcripts are followed instead of screenshots when a tailer is set
+	transcript *transcript.Tailer
+
+	// lastFingerprint is the last analyzed screenshot, and idleSkips counts those skipped as unchanged
+	lastFingerprint screenshot.Fingerprint
+	idleSkips       atomic.Int64
+	// Screenshots of a window are cropped to the chat panel at region, or the one detected in each
+	// when detectRegion is set; a zero region keeps the whole window
+	region       screenshot.Region
+	detectRegion bool
+	// encoding scales and compresses screenshots before they are sent and kept
+	encoding screenshot.Encoding
+	// ocr sends the text read from screenshots locally instead of the images
+	ocr bool
+	// display is the display captured instead of the editor's window, numbered from 1; zero
+	// captures the window
+	display int
+	// screenshotRetention is how long analyzed screenshots are kept on disk, as they were sent;
+	// zero keeps none
+	screenshotRetention time.Duration
+	// cipher encrypts the screenshots kept; nil when encryption at rest is off
+	cipher *crypt.Cipher
+	// redact removes secrets from what is sent; redactWarned is set once screenshots were held back
+	// for want of tesseract to redact them, and redactions counts the secrets removed
+	redact       bool
+	redactWarned bool
+	redactions   atomic.Int64
+
+	// windowWarned is set once the focused window could not be found, so the warning is not repeated
+	windowWarned bool
+
+	// Screenshots are stored as attachments only when an attachment manager is set
+	attachmentManager *attachments.AttachmentManager
+
+	// Session totals for the recap printed on stop
+	lastProgress  time.Time
+	monitorNotes  atomic.Int64
+	progressNotes atomic.Int64
+	summary       SessionSummary
+
+	// Control requests from wash monitor stop and status arrive on a Unix socket
+	controlListener net.Listener
+	controlConns    sync.WaitGroup
+
+	// The latest capture and API error, and the loop's heartbeat and restarts, for wash monitor status
+	healthMu      sync.Mutex
+	lastCapture   time.Time
+	lastError     string
+	lastErrorAt   time.Time
+	heartbeat     time.Time
+	restarts      int
+	restartReason string
+	restartedAt   time.Time
+	restartAt     time.Time
+
+	// Captures are skipped while paused, until pausedUntil when it is set
+	pauseMu     sync.Mutex
+	pausedAt    time.Time
+	pausedUntil time.Time
 }
 
 func NewMonitor(cfg *config.Config, projectName string) (*Monitor, error) {
-	client := openai.NewClient(cfg.OpenAIKey)
+	client := usage.NewClient(cfg.OpenAIKey)
+
+	interval, err := Interval(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var region [4]float64
+	var detectRegion bool
+	if cfg.MonitorRegion != "" {
+		if region, detectRegion, err = config.ParseRegion(cfg.MonitorRegion); err != nil {
+			return nil, fmt.Errorf("invalid monitor_region: %w", err)
+		}
+	}
+	screenshotRetention, err := config.ParseAge(cfg.Retention["screenshots"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid retention.screenshots: %w", err)
+	}
 
 	// If project name not provided, use current directory name
 	if projectName == "" {
@@ -45,14 +163,25 @@ func NewMonitor(cfg *config.Config, projectName string) (*Monitor, error) {
 		projectName = filepath.Base(cwd)
 	}
 
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+
 	// Create project-specific notes directory in ~/.wash/projects/
-	notesDir := filepath.Join(os.Getenv("HOME"), ".wash", "projects", projectName, "notes")
+	notesDir := filepath.Join(dataDir, "projects", projectName, "notes")
 	if err := os.MkdirAll(notesDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create notes directory: %v", err)
 	}
 
+	// Kept screenshots are encrypted at rest along with the notes
+	cipher, err := crypt.Load(cfg.Encryption, dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unlock encrypted screenshots: %w", err)
+	}
+
 	// Create PID manager
-	pidFile := filepath.Join(os.Getenv("HOME"), ".wash", "chat_monitor.pid")
+	pidFile := filepath.Join(dataDir, pidFileName)
 	pidManager := pid.NewPIDManager(pidFile)
 
 	// Create notes manager
@@ -61,33 +190,125 @@ func NewMonitor(cfg *config.Config, projectName string) (*Monitor, error) {
 		return nil, fmt.Errorf("failed to create notes manager: %v", err)
 	}
 
+	// Batch monitor note writes; this also saves notes a crashed run left in the log
+	noteBuffer, err := notes.NewMonitorNoteBuffer(notesManager, cfg.MonitorBatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create monitor note buffer: %v", err)
+	}
+
+	// Detect project languages for the analysis prompt
+	var languages string
+	if cwd, err := os.Getwd(); err == nil {
+		if profile, err := language.Load(projectName, cwd); err == nil {
+			languages = profile.String()
+		}
+	}
+
 	return &Monitor{
-		client:       client,
-		cfg:          cfg,
-		running:      false,
-		stopChan:     make(chan struct{}),
-		doneChan:     make(chan struct{}),
-		notesDir:     notesDir,
-		startTime:    time.Now(),
-		pidManager:   pidManager,
-		pidFile:      pidFile,
-		projectName:  projectName,
-		notesManager: notesManager,
+		client:              client,
+		cfg:                 cfg,
+		running:             false,
+		stopChan:            make(chan struct{}),
+		doneChan:            make(chan struct{}),
+		stopped:             make(chan struct{}),
+		notesDir:            notesDir,
+		startTime:           time.Now(),
+		pidManager:          pidManager,
+		pidFile:             pidFile,
+		projectName:         projectName,
+		notesManager:        notesManager,
+		noteBuffer:          noteBuffer,
+		languages:           languages,
+		interval:            interval,
+		region:              screenshot.Region{X: region[0], Y: region[1], Width: region[2], Height: region[3]},
+		detectRegion:        detectRegion,
+		screenshotRetention: screenshotRetention,
+		cipher:              cipher,
+		encoding:            screenshot.Encoding{Format: cfg.ScreenshotFormat, Quality: cfg.ScreenshotQuality, MaxSize: cfg.ScreenshotMaxSize},
+		redact:              cfg.RedactSecrets != "off",
 	}, nil
 }
 
+// SetWorkspace makes the monitor attribute interactions to the repositories of a workspace
+func (m *Monitor) SetWorkspace(ws *workspace.Workspace) {
+	m.workspace = ws
+}
+
+// SetTerminalSource captures terminal scrollback from a tmux pane or screen session instead of screenshots
+func (m *Monitor) SetTerminalSource(source terminal.Source, target string) {
+	m.terminalSource = source
+	m.terminalTarget = target
+}
+
+// SetTranscript follows a terminal agent's transcript instead of taking screenshots
+func (m *Monitor) SetTranscript(t *transcript.Tailer) {
+	m.transcript = t
+}
+
+// SetOCR analyzes the text read from screenshots with tesseract instead of the images. It fails
+// when tesseract is not installed.
+func (m *Monitor) SetOCR() error {
+	if !screenshot.OCRAvailable() {
+		return screenshot.ErrNoOCR
+	}
+	m.ocr = true
+	return nil
+}
+
+// SetDisplay captures a whole display, numbered from 1, instead of the editor's window
+func (m *Monitor) SetDisplay(display int) error {
+	if err := screenshot.CheckDisplay(display); err != nil {
+		return err
+	}
+	m.display = display
+	return nil
+}
+
+// SetKeepScreenshots stores each analyzed screenshot as an attachment on its monitor note
+func (m *Monitor) SetKeepScreenshots(am *attachments.AttachmentManager) {
+	m.attachmentManager = am
+}
+
+// projects returns the project names the monitor writes notes for
+func (m *Monitor) projects() []string {
+	if m.workspace == nil {
+		return []string{m.projectName}
+	}
+	return m.workspace.ProjectNames()
+}
+
+// projectPath returns the repository path for a project, falling back to the current directory
+func (m *Monitor) projectPath(projectName string) string {
+	if m.workspace != nil {
+		if repo, ok := m.workspace.Repo(projectName); ok {
+			return repo.Path
+		}
+	}
+	cwd, _ := os.Getwd()
+	return cwd
+}
+
 func (m *Monitor) Start() error {
 	if m.running {
 		return fmt.Errorf("monitor is already running")
 	}
 
+	m.summary = SessionSummary{Started: time.Now()}
+	m.lastProgress = m.summary.Started
+
+	// Accept stop and status requests; this also refuses to start a second monitor
+	if err := m.listenControl(); err != nil {
+		return err
+	}
+
 	// Write PID file
 	if err := m.pidManager.WritePID(); err != nil {
+		m.CloseControl()
 		return fmt.Errorf("failed to write PID file: %v", err)
 	}
 
 	m.running = true
-	go m.monitorLoop()
+	go m.supervise(m.monitorLoop)
 
 	// Handle signals
 	sigChan := make(chan os.Signal, 1)
@@ -107,53 +328,163 @@ func (m *Monitor) cleanup() {
 	}
 }
 
+// Stop ends the session: it waits for the monitor loop, writes a final progress note for the
+// time since the last tick and records the summary. Later calls wait for the first to finish.
 func (m *Monitor) Stop() error {
 	if !m.running {
 		return fmt.Errorf("monitor is not running")
 	}
 
-	close(m.stopChan)
-	<-m.doneChan
-	m.running = false
+	m.stopOnce.Do(func() {
+		close(m.stopChan)
+		<-m.doneChan
+		m.finishSession()
+		m.running = false
 
-	m.cleanup()
+		m.cleanup()
+		close(m.stopped)
+	})
 	return nil
 }
 
-func (m *Monitor) monitorLoop() {
-	defer close(m.doneChan)
+// monitorLoop captures and analyzes activity until the monitor stops, when it returns nil, or
+// until analyses keep failing, when it returns why
+func (m *Monitor) monitorLoop() error {
+	defer m.flushNotes()
+	m.beat()
 
-	// Ticker for screenshot analysis (every 30 seconds)
-	screenshotTicker := time.NewTicker(30 * time.Second)
+	// Ticker for screenshot analysis (every 30 seconds unless monitor_interval is set)
+	screenshotTicker := time.NewTicker(m.interval)
 	defer screenshotTicker.Stop()
 
 	// Ticker for progress notes (every 5 minutes)
 	progressTicker := time.NewTicker(5 * time.Minute)
 	defer progressTicker.Stop()
 
+	// Compact and prune notes and clean up screenshots at start and once a day
+	m.maintainNotes()
+	m.cleanScreenshots()
+	maintenanceTicker := time.NewTicker(24 * time.Hour)
+	defer maintenanceTicker.Stop()
+
+	failures := 0
 	for {
 		select {
 		case <-m.stopChan:
-			return
+			return nil
 		case <-screenshotTicker.C:
-			// Log screenshot analysis errors
-			if err := m.analyzeScreenshot(); err != nil {
-				fmt.Printf("Error analyzing screenshot: %v\n", err)
+			m.beat()
+			// Nothing is captured during sensitive work
+			if m.paused() {
+				continue
+			}
+			// Log screenshot analysis errors, and have the loop restarted when they keep coming
+			if err := m.analyze(); err != nil {
+				fmt.Printf("Error analyzing activity: %v\n", err)
+				if failures++; failures >= maxConsecutiveFailures {
+					return fmt.Errorf("%d analyses failed in a row, the last with: %v", failures, err)
+				}
+			} else {
+				failures = 0
 			}
 		case <-progressTicker.C:
-			// Generate progress note for the last 5 minutes
-			progressNote, err := m.notesManager.GenerateProgressFromMonitor(m.projectName, 5*time.Minute)
-			if err != nil {
-				fmt.Printf("Error generating progress note: %v\n", err)
-				continue
+			m.beat()
+			// Progress notes are generated from the monitor notes on disk
+			m.flushNotes()
+			m.lastProgress = time.Now()
+			for _, projectName := range m.projects() {
+				// Generate progress note for the last 5 minutes
+				progressNote, err := m.notesManager.GenerateProgressFromMonitor(projectName, 5*time.Minute)
+				if err != nil {
+					fmt.Printf("Error generating progress note for %s: %v\n", projectName, err)
+					continue
+				}
+
+				// Save the progress note
+				if err := m.notesManager.SaveProjectProgress(progressNote); err != nil {
+					fmt.Printf("Error saving progress note: %v\n", err)
+				} else {
+					m.progressNotes.Add(1)
+				}
+
+				// Mark plan steps whose files have now changed as done
+				m.syncPlans(projectName)
 			}
+		case <-maintenanceTicker.C:
+			m.beat()
+			m.maintainNotes()
+			m.cleanScreenshots()
+		}
+	}
+}
+
+// flushNotes writes buffered monitor notes to their files
+func (m *Monitor) flushNotes() {
+	if err := m.noteBuffer.Flush(); err != nil {
+		fmt.Printf("Error saving monitor notes: %v\n", err)
+	}
+}
 
-			// Save the progress note
-			if err := m.notesManager.SaveProjectProgress(progressNote); err != nil {
-				fmt.Printf("Error saving progress note: %v\n", err)
+// maintainNotes compacts old monitor notes into digests and then applies the retention policy,
+// so notes are digested before retention can delete them
+func (m *Monitor) maintainNotes() {
+	m.flushNotes()
+	if m.cfg.CompactAfter != "" {
+		olderThan, err := notes.ParseRetention(m.cfg.CompactAfter)
+		if err != nil {
+			fmt.Printf("Warning: ignoring compact_monitor_notes_after: %v\n", err)
+		} else if olderThan > 0 {
+			for _, projectName := range m.projects() {
+				if _, err := m.notesManager.CompactMonitorNotes(projectName, olderThan, false); err != nil {
+					fmt.Printf("Error compacting monitor notes for %s: %v\n", projectName, err)
+				}
 			}
 		}
 	}
+
+	policy, err := notes.ParseRetentionPolicy(m.cfg.Retention)
+	if err != nil {
+		fmt.Printf("Warning: ignoring retention policy: %v\n", err)
+		return
+	}
+	if policy.IsZero() {
+		return
+	}
+
+	if _, err := m.notesManager.Prune(policy, false); err != nil {
+		fmt.Printf("Error pruning notes: %v\n", err)
+	}
+}
+
+// cleanScreenshots deletes the screenshots older than retention.screenshots or, when they are not
+// kept, those a crashed run left behind
+func (m *Monitor) cleanScreenshots() {
+	cutoff := m.startTime
+	if m.screenshotRetention > 0 {
+		cutoff = time.Now().Add(-m.screenshotRetention)
+	}
+	if _, _, err := screenshot.Clean(cutoff, false); err != nil {
+		fmt.Printf("Error cleaning up screenshots: %v\n", err)
+	}
+}
+
+// syncPlans updates a project's stored plans with the steps completed since they were created
+func (m *Monitor) syncPlans(projectName string) {
+	planManager, err := plans.NewPlanManager()
+	if err != nil {
+		fmt.Printf("Error creating plan manager: %v\n", err)
+		return
+	}
+
+	updates, err := planManager.SyncProgress(m.notesManager, projectName, m.projectPath(projectName))
+	if err != nil {
+		fmt.Printf("Error syncing plan progress: %v\n", err)
+		return
+	}
+
+	for _, update := range updates {
+		fmt.Printf("\nPlan %s: step %d done (%s)\n", update.Plan.ID, update.Step.Number, update.Step.Title)
+	}
 }
 
 // formatContextForAI formats recent records into a context string for the AI
@@ -181,21 +512,106 @@ func formatContextForAI(records []*notes.Interaction) string {
 	return context.String()
 }
 
+// noteResponseFormat asks the model for the monitor note fields as JSON
+const noteResponseFormat = `IMPORTANT: Keep all descriptions brief and to the point. Each field should be 1 sentence maximum.
+Focus on the key points and avoid unnecessary details.
+
+Format your response as a JSON object with the following structure:
+{
+    "user_request": "brief description of the user goal expressed in the chat",
+    "ai_action": "brief description of the AI's main action - or the user's action if they edit the code directly.",
+    "context": "brief context (e.g., debugging, feature implementation)",
+    "code_changes": ["which file(s) were edited, if any"]
+}`
+
+// analyze captures the configured source and saves a monitor note for it
+func (m *Monitor) analyze() error {
+	if m.transcript != nil {
+		return m.analyzeTranscript()
+	}
+	if m.terminalSource != "" {
+		return m.analyzeTerminal()
+	}
+	return m.analyzeScreenshot()
+}
+
+// recentContext formats the interactions from the last 5 minutes for the prompt
+func (m *Monitor) recentContext() (string, error) {
+	// Only files named within the last 5 minutes are read
+	var recentRecords []*notes.Interaction
+	for interaction, err := range m.notesManager.Interactions(m.projectName, notes.TimeRange{Since: time.Now().Add(-5 * time.Minute)}) {
+		if err != nil {
+			fmt.Printf("Warning: Could not load interaction: %v\n", err)
+			continue
+		}
+		recentRecords = append(recentRecords, interaction)
+	}
+
+	return formatContextForAI(recentRecords), nil
+}
+
+// promptExtras returns the project languages and workspace instructions appended to every analysis prompt
+func (m *Monitor) promptExtras() string {
+	var extras string
+	if m.languages != "" {
+		extras += "\n\nProject languages:\n" + m.languages
+	}
+
+	if m.workspace != nil {
+		extras += fmt.Sprintf("\n\nThe user is working across these repositories: %s. "+
+			"Add a \"repo\" field to the JSON object naming the repository the interaction concerns, or \"%s\" if it is unclear.",
+			strings.Join(m.workspace.ProjectNames(), ", "), m.projectName)
+	}
+	return extras
+}
+
 func (m *Monitor) analyzeScreenshot() error {
-	// Create screenshots directory if it doesn't exist
-	dir := filepath.Join(os.Getenv("HOME"), ".wash-screenshots")
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("failed to create screenshots directory: %v", err)
+	// Capture only while one of the monitored applications is focused; when the focused window
+	// cannot be found, the screen is captured whatever is on it
+	application := ""
+	if window, err := screenshot.ActiveWindow(); err != nil {
+		if !m.windowWarned {
+			fmt.Printf("\nWarning: %v; capturing the screen whatever is focused\n", err)
+			m.windowWarned = true
+		}
+	} else if window.Match(m.windows()) == "" {
+		return nil
+	} else {
+		application = window.Name()
 	}
 
-	// Generate filename with timestamp
-	filename := fmt.Sprintf("screenshot-%s.png", time.Now().Format("2006-01-02-15-04-05"))
-	screenshotPath := filepath.Join(dir, filename)
+	// Capture to the screenshots directory. The capture itself is always deleted; with
+	// retention.screenshots, the copy sent is kept, and those skipped as idle are never kept.
+	dir, err := screenshot.Dir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create screenshots directory: %v", err)
+	}
+	tmp, err := os.CreateTemp(dir, "screenshot-*.png")
+	if err != nil {
+		return fmt.Errorf("failed to create screenshot file: %v", err)
+	}
+	screenshotPath := tmp.Name()
+	tmp.Close()
+	defer os.Remove(screenshotPath)
 
-	// Take screenshot of Cursor window
-	if err := screenshot.CaptureWindow("Cursor", screenshotPath); err != nil {
-		return fmt.Errorf("failed to capture Cursor window: %v", err)
+	// Capture the chosen display, the focused editor's window or, when the focused window is
+	// unknown, the display showing one of the editors
+	window := false
+	switch {
+	case m.display > 0:
+		err = screenshot.CaptureDisplay(m.display, screenshotPath)
+	case application == "":
+		err = screenshot.CaptureScreen(m.windows(), screenshotPath)
+	default:
+		err, window = screenshot.CaptureWindow(application, screenshotPath), true
+	}
+	if err != nil {
+		return fmt.Errorf("failed to capture screen: %v", err)
 	}
+	m.recordCapture()
 
 	// Read screenshot file
 	data, err := os.ReadFile(screenshotPath)
@@ -203,47 +619,306 @@ func (m *Monitor) analyzeScreenshot() error {
 		return fmt.Errorf("failed to read screenshot file: %v", err)
 	}
 
-	// Convert screenshot to base64
-	screenshotBase64 := base64.StdEncoding.EncodeToString(data)
+	// Send only the chat panel, so the image holds the conversation and fewer tokens
+	panel := false
+	if window {
+		if region, ok := m.chatPanel(data); ok {
+			if cropped, err := screenshot.Crop(data, region); err != nil {
+				fmt.Printf("\nWarning: screenshot not cropped to the chat panel: %v\n", err)
+			} else {
+				data, panel = cropped, true
+			}
+		}
+	}
 
-	// Get recent interactions for context
-	recentInteractions, err := m.notesManager.LoadInteractions(m.projectName)
-	if err != nil {
-		return fmt.Errorf("failed to load recent interactions: %v", err)
+	// Skip the vision request while the screen has not meaningfully changed
+	fingerprint, err := screenshot.NewFingerprint(data)
+	if err == nil && fingerprint.Difference(m.lastFingerprint) < idleThreshold {
+		m.idleSkips.Add(1)
+		return nil
 	}
 
-	// Filter to last 5 minutes
-	cutoff := time.Now().Add(-5 * time.Minute)
-	var recentRecords []*notes.Interaction
-	for _, interaction := range recentInteractions {
-		if interaction.Timestamp.After(cutoff) {
-			recentRecords = append(recentRecords, interaction)
+	// In OCR mode only the text read on screen is sent
+	if m.ocr {
+		return m.analyzeScreenText(data, application, fingerprint)
+	}
+
+	// Black out secrets read on screen; a screenshot that cannot be redacted, without tesseract or
+	// when it fails, is not sent
+	if m.redact {
+		redacted, n, err := screenshot.Redact(data)
+		switch {
+		case errors.Is(err, screenshot.ErrNoOCR):
+			if !m.redactWarned {
+				fmt.Println("\nWarning: screenshots are not sent, since secrets cannot be blacked out of them without tesseract; install it, or set redact_secrets: off to send them unredacted")
+				m.redactWarned = true
+			}
+			return nil
+		case err != nil:
+			return fmt.Errorf("failed to redact screenshot: %v", err)
+		default:
+			data = redacted
+			m.redactions.Add(int64(n))
 		}
 	}
 
-	contextStr := formatContextForAI(recentRecords)
+	// Scale down and compress what is sent and kept
+	upload, mimeType, err := m.encoding.Encode(data)
+	if err != nil {
+		return err
+	}
+
+	attached := m.keepScreenshot(upload)
+	m.retainScreenshot(upload)
+
+	// Convert screenshot to base64
+	screenshotBase64 := base64.StdEncoding.EncodeToString(upload)
+
+	contextStr, err := m.recentContext()
+	if err != nil {
+		return err
+	}
 
 	// Create the analysis prompt with context
-	prompt := `You are observing a conversation between a user and an AI coding assistant in the Cursor IDE.
+	editor := "their editor"
+	if application != "" {
+		editor = application
+	}
+	shows := ""
+	if panel {
+		shows = "\nThe screenshot shows only the assistant's chat panel, with the user's input at the bottom."
+	}
+	prompt := `You are observing a conversation between a user and an AI coding assistant in ` + editor + `.` + shows + `
 Your task is to analyze the screenshot and provide a concise summary of the interaction.
 
 Based on the screenshot, please analyze:
-1. The user's request or question. Consider what they're trying to accomplish (this will most likely be in the bottom right corner of the screenshot where the user input for the chat is)
-2. The AI assistant's response and actions (the response willusually be above the user input on the right side of the screenshot)
+1. The user's request or question. Consider what they're trying to accomplish (this will most likely be in the chat input of the assistant's panel)
+2. The AI assistant's response and actions (the response will usually be above the chat input in the same panel)
 3. Code changes or modifications that seem to occur
 4. The overall context of the interaction (e.g., debugging, feature implementation)
 
-IMPORTANT: Keep all descriptions brief and to the point. Each field should be 1 sentence maximum.
-Focus on the key points and avoid unnecessary details.
+` + noteResponseFormat + "\n\n" + contextStr + m.promptExtras()
 
-Format your response as a JSON object with the following structure:
-{
-    "user_request": "brief description of the user goal expressed in the chat in the lower right corner of the screenshot",
-    "ai_action": "brief description of the AI's main action - or the user's action if they edit the code directly.",
-    "context": "brief context (e.g., debugging, feature implementation)",
-    "code_changes": ["which file(s) were edited, if any"]
-}` + "\n\n" + contextStr
+	err = m.requestNote("screenshot", application, attached, []openai.ChatMessagePart{
+		{
+			Type: "text",
+			Text: prompt,
+		},
+		{
+			Type: "image_url",
+			ImageURL: &openai.ChatMessageImageURL{
+				URL: fmt.Sprintf("data:%s;base64,%s", mimeType, screenshotBase64),
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	// Only an analyzed screen is compared against, so a change whose request failed is retried
+	m.lastFingerprint = fingerprint
+	return nil
+}
+
+// redactText removes the secrets from text about to be sent, unless redaction is off
+func (m *Monitor) redactText(text string) string {
+	if !m.redact {
+		return text
+	}
+	text, n := secrets.Redact(text)
+	m.redactions.Add(int64(n))
+	return text
+}
+
+// chatPanel returns the region of a screenshot to send: the one set in monitor_region, or the
+// panel detected in it
+func (m *Monitor) chatPanel(data []byte) (screenshot.Region, bool) {
+	if m.detectRegion {
+		return screenshot.DetectChatPanel(data)
+	}
+	return m.region, m.region.Width > 0
+}
+
+// windows returns the applications whose focused windows are captured
+func (m *Monitor) windows() []string {
+	if len(m.cfg.MonitorWindows) > 0 {
+		return m.cfg.MonitorWindows
+	}
+	return screenshot.DefaultWindows
+}
+
+// keepScreenshot stores an encoded screenshot as an attachment when screenshots are kept
+func (m *Monitor) keepScreenshot(data []byte) []attachments.Attachment {
+	if m.attachmentManager == nil {
+		return nil
+	}
+	name := fmt.Sprintf("screenshot-%s%s", time.Now().Format("2006-01-02-15-04-05"), m.encoding.Extension())
+	attachment, err := m.attachmentManager.Add(name, data)
+	if err != nil {
+		fmt.Printf("\nWarning: screenshot not kept: %v\n", err)
+		return nil
+	}
+	return []attachments.Attachment{*attachment}
+}
+
+// retainScreenshot stores an analyzed screenshot, as it was sent, in the screenshots directory
+// when retention.screenshots keeps them
+func (m *Monitor) retainScreenshot(data []byte) {
+	if m.screenshotRetention <= 0 {
+		return
+	}
+	if _, err := screenshot.Keep(data, m.encoding.Extension(), m.cipher); err != nil {
+		fmt.Printf("\nWarning: screenshot not kept: %v\n", err)
+	}
+}
+
+// keptCopy returns a screenshot read with OCR as it is kept: with its secrets blacked out, unless
+// redact_secrets is off, then scaled down and compressed. ok is false when it cannot be redacted.
+func (m *Monitor) keptCopy(data []byte) ([]byte, bool) {
+	if m.redact {
+		redacted, _, err := screenshot.Redact(data)
+		if err != nil {
+			return nil, false
+		}
+		data = redacted
+	}
+	encoded, _, err := m.encoding.Encode(data)
+	return encoded, err == nil
+}
+
+// analyzeScreenText reads a screenshot with local OCR and analyzes the text alone, so no pixels
+// leave the machine
+func (m *Monitor) analyzeScreenText(data []byte, application string, fingerprint screenshot.Fingerprint) error {
+	words, err := screenshot.OCR(data)
+	if err != nil {
+		return fmt.Errorf("failed to read screenshot: %v", err)
+	}
+	text := m.redactText(strings.Join(screenshot.Lines(words), "\n"))
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+
+	// Screenshots kept on the machine have their secrets blacked out too
+	var attached []attachments.Attachment
+	if m.attachmentManager != nil || m.screenshotRetention > 0 {
+		if kept, ok := m.keptCopy(data); ok {
+			attached = m.keepScreenshot(kept)
+			m.retainScreenshot(kept)
+		}
+	}
+
+	contextStr, err := m.recentContext()
+	if err != nil {
+		return err
+	}
+
+	editor := "their editor"
+	if application != "" {
+		editor = application
+	}
+	prompt := `You are observing a conversation between a user and an AI coding assistant in ` + editor + `.
+Your task is to analyze the text read from a screenshot of it with OCR, line by line from the top, and provide a concise summary of the interaction. OCR may misread some characters.
 
+Based on the text, please analyze:
+1. The user's request or question, usually near the chat input at the bottom of the assistant's panel
+2. The AI assistant's response and actions, above the chat input
+3. Code changes or modifications that seem to occur, including the files named
+4. The overall context of the interaction (e.g., debugging, feature implementation)
+
+` + noteResponseFormat + "\n\n" + contextStr + m.promptExtras() + "\n\nScreen text:\n```\n" + text + "\n```"
+
+	err = m.requestNote("screenshot text", application, attached, []openai.ChatMessagePart{
+		{
+			Type: "text",
+			Text: prompt,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	m.lastFingerprint = fingerprint
+	return nil
+}
+
+// analyzeTerminal captures terminal scrollback and analyzes it as text
+func (m *Monitor) analyzeTerminal() error {
+	scrollback, err := terminal.Capture(m.terminalSource, m.terminalTarget, terminal.DefaultScrollbackLines)
+	if err != nil {
+		return err
+	}
+	m.recordCapture()
+
+	// Nothing happened since the last capture
+	if scrollback == m.lastScrollback {
+		return nil
+	}
+	m.lastScrollback = scrollback
+	scrollback = m.redactText(scrollback)
+
+	contextStr, err := m.recentContext()
+	if err != nil {
+		return err
+	}
+
+	prompt := `You are observing a terminal session where a user works with an AI coding assistant (such as aider) and a terminal editor (such as vim).
+Your task is to analyze the terminal scrollback below and provide a concise summary of the most recent interaction.
+
+Based on the scrollback, please analyze:
+1. The user's latest request or command and what they're trying to accomplish
+2. The AI assistant's response and actions, or the user's own edits and commands
+3. Code changes or modifications that seem to occur, including files named in diffs or commit output
+4. The overall context of the interaction (e.g., debugging, feature implementation)
+
+` + noteResponseFormat + "\n\n" + contextStr + m.promptExtras() + "\n\nTerminal scrollback:\n```\n" + scrollback + "\n```"
+
+	return m.requestNote("terminal", string(m.terminalSource), nil, []openai.ChatMessagePart{
+		{
+			Type: "text",
+			Text: prompt,
+		},
+	})
+}
+
+// analyzeTranscript reads the turns added to an agent's transcript and analyzes them as text
+func (m *Monitor) analyzeTranscript() error {
+	turns, err := m.transcript.Next()
+	if err != nil {
+		return err
+	}
+	m.recordCapture()
+
+	// Nothing was said since the last read
+	if len(turns) == 0 {
+		return nil
+	}
+
+	contextStr, err := m.recentContext()
+	if err != nil {
+		return err
+	}
+
+	application := m.transcript.Format().Application()
+	prompt := `You are observing a conversation between a user and ` + application + `, an AI coding assistant that runs in the terminal.
+Your task is to analyze the new turns of its transcript below and provide a concise summary of the interaction.
+
+Based on the transcript, please analyze:
+1. The user's latest request and what they're trying to accomplish
+2. The AI assistant's response and actions, including the tools it ran
+3. Code changes or modifications made, using the files the transcript lists as edited
+4. The overall context of the interaction (e.g., debugging, feature implementation)
+
+` + noteResponseFormat + "\n\n" + contextStr + m.promptExtras() + "\n\nNew transcript turns:\n" + m.redactText(transcript.FormatTurns(turns))
+
+	return m.requestNote("transcript", application, nil, []openai.ChatMessagePart{
+		{
+			Type: "text",
+			Text: prompt,
+		},
+	})
+}
+
+// requestNote sends the analysis request, retrying transient network errors, and saves the resulting
+// monitor note, tagged with the application it was observed in
+func (m *Monitor) requestNote(source, application string, attached []attachments.Attachment, content []openai.ChatMessagePart) error {
 	// Add retry logic for transient network errors
 	maxRetries := 3
 	var lastErr error
@@ -252,63 +927,18 @@ Format your response as a JSON object with the following structure:
 		resp, err := m.client.CreateChatCompletion(
 			context.Background(),
 			openai.ChatCompletionRequest{
-				Model: "gpt-4.1-mini",
+				Model: NoteModel,
 				Messages: []openai.ChatCompletionMessage{
 					{
-						Role: "user",
-						MultiContent: []openai.ChatMessagePart{
-							{
-								Type: "text",
-								Text: prompt,
-							},
-							{
-								Type: "image_url",
-								ImageURL: &openai.ChatMessageImageURL{
-									URL: fmt.Sprintf("data:image/png;base64,%s", screenshotBase64),
-								},
-							},
-						},
+						Role:         "user",
+						MultiContent: content,
 					},
 				},
 				MaxTokens: 1000,
 			},
 		)
 		if err == nil {
-			// Parse the response into an analysis struct
-			var analysis struct {
-				UserRequest string   `json:"user_request"`
-				AIAction    string   `json:"ai_action"`
-				Context     string   `json:"context"`
-				CodeChanges []string `json:"code_changes"`
-			}
-
-			if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &analysis); err != nil {
-				return fmt.Errorf("failed to parse analysis response: %v", err)
-			}
-
-			// Create a new monitor note
-			note := &notes.MonitorNote{
-				Timestamp:   time.Now(),
-				ProjectName: m.projectName,
-				Interaction: struct {
-					UserRequest string   `json:"user_request"`
-					AIAction    string   `json:"ai_action"`
-					Context     string   `json:"context"`
-					CodeChanges []string `json:"code_changes"`
-				}{
-					UserRequest: analysis.UserRequest,
-					AIAction:    analysis.AIAction,
-					Context:     analysis.Context,
-					CodeChanges: analysis.CodeChanges,
-				},
-			}
-
-			// Save note using the notes manager
-			if err := m.notesManager.SaveMonitorNote(m.projectName, note); err != nil {
-				return fmt.Errorf("failed to save monitor note: %v", err)
-			}
-
-			return nil
+			return m.saveNote(resp.Choices[0].Message.Content, application, attached)
 		}
 
 		// Check if this is a retryable error
@@ -322,11 +952,72 @@ Format your response as a JSON object with the following structure:
 		}
 
 		// If it's not a retryable error, return immediately
-		return fmt.Errorf("failed to analyze screenshot: %v", err)
+		m.recordError(err)
+		return fmt.Errorf("failed to analyze %s: %v", source, err)
 	}
 
 	// If we've exhausted all retries, return the last error
-	return fmt.Errorf("failed to analyze screenshot after %d retries: %v", maxRetries, lastErr)
+	m.recordError(lastErr)
+	return fmt.Errorf("failed to analyze %s after %d retries: %v", source, maxRetries, lastErr)
+}
+
+// recordCapture notes the time of a successful screenshot or terminal capture
+func (m *Monitor) recordCapture() {
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+	m.lastCapture = time.Now()
+}
+
+// recordError notes an analysis request that failed
+func (m *Monitor) recordError(err error) {
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+	m.lastError = err.Error()
+	m.lastErrorAt = time.Now()
+}
+
+// saveNote parses the model's JSON response and saves it as a monitor note
+func (m *Monitor) saveNote(response, application string, attached []attachments.Attachment) error {
+	// Parse the response into an analysis struct
+	var analysis struct {
+		UserRequest string   `json:"user_request"`
+		AIAction    string   `json:"ai_action"`
+		Context     string   `json:"context"`
+		CodeChanges []string `json:"code_changes"`
+		Repo        string   `json:"repo"`
+	}
+
+	if err := json.Unmarshal([]byte(response), &analysis); err != nil {
+		return fmt.Errorf("failed to parse analysis response: %v", err)
+	}
+
+	// Attribute the note to a workspace repository when one was named
+	projectName := m.projectName
+	if m.workspace != nil {
+		if repo, ok := m.workspace.Repo(analysis.Repo); ok {
+			projectName = repo.Name
+		}
+	}
+
+	// Create a new monitor note
+	note := &notes.MonitorNote{
+		Timestamp:   time.Now(),
+		ProjectName: projectName,
+		Application: application,
+	}
+	note.Interaction.UserRequest = analysis.UserRequest
+	note.Interaction.AIAction = analysis.AIAction
+	note.Interaction.Context = analysis.Context
+	note.Interaction.CodeChanges = analysis.CodeChanges
+	note.Attachments = attached
+
+	// Buffer the note; it reaches its file with the next batch
+	if err := m.noteBuffer.Add(projectName, note); err != nil {
+		return fmt.Errorf("failed to save monitor note: %v", err)
+	}
+	m.monitorNotes.Add(1)
+
+	return nil
 }
 
 // StartTime returns the time when the monitor was started
@@ -0,0 +1,292 @@
+package chatmonitor
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+)
+
+const (
+	// controlSocketName is the Unix socket a running monitor accepts control requests on, in the data directory
+	controlSocketName = "monitor.sock"
+
+	// controlStatus, controlStop, controlPause and controlResume are the requests the control
+	// channel understands
+	controlStatus = "status"
+	controlStop   = "stop"
+	controlPause  = "pause"
+	controlResume = "resume"
+)
+
+// ErrNotRunning is returned by the control client when no monitor is listening
+var ErrNotRunning = errors.New("no monitor is running")
+
+// Status describes a running monitor
+type Status struct {
+	PID           int       `json:"pid"`
+	Projects      []string  `json:"projects"`
+	Source        string    `json:"source"`
+	Started       time.Time `json:"started"`
+	MonitorNotes  int       `json:"monitor_notes"`
+	ProgressNotes int       `json:"progress_notes"`
+	// IdleSkips counts the screenshots not analyzed because the screen had not changed
+	IdleSkips int `json:"idle_skips"`
+	// Redactions counts the secrets removed from screenshots and text before they were sent
+	Redactions int `json:"redactions,omitempty"`
+	// LastCapture is when the screen or terminal was last captured, and LastError the last
+	// analysis request that failed
+	LastCapture time.Time `json:"last_capture,omitzero"`
+	LastError   string    `json:"last_error,omitempty"`
+	LastErrorAt time.Time `json:"last_error_at,omitzero"`
+	// PausedAt is when captures were paused, zero while they run, and PausedUntil when they
+	// resume on their own, zero until wash monitor resume
+	PausedAt    time.Time `json:"paused_at,omitzero"`
+	PausedUntil time.Time `json:"paused_until,omitzero"`
+	// Heartbeat is when the monitor loop was last awake, and Stalled is set when it has missed
+	// several intervals, such as on a request that never returns
+	Heartbeat time.Time `json:"heartbeat,omitzero"`
+	Stalled   bool      `json:"stalled,omitempty"`
+	// Restarts counts the times the loop died and was restarted, the last for RestartReason;
+	// RestartAt is when a loop waiting to restart starts again
+	Restarts      int       `json:"restarts,omitempty"`
+	RestartReason string    `json:"restart_reason,omitempty"`
+	RestartedAt   time.Time `json:"restarted_at,omitzero"`
+	RestartAt     time.Time `json:"restart_at,omitzero"`
+}
+
+// controlRequest is one request line sent to the control socket
+type controlRequest struct {
+	Command string `json:"command"`
+	// For is how long a pause lasts; zero pauses until resumed
+	For time.Duration `json:"for,omitempty"`
+}
+
+// controlResponse answers a control request; Summary is set once a stop has finished
+type controlResponse struct {
+	Status  *Status         `json:"status,omitempty"`
+	Summary *SessionSummary `json:"summary,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// ControlSocketPath returns the path of the monitor control socket
+func ControlSocketPath() (string, error) {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, controlSocketName), nil
+}
+
+// listenControl opens the control socket, refusing to start when another monitor answers on it
+func (m *Monitor) listenControl() error {
+	path, err := ControlSocketPath()
+	if err != nil {
+		return err
+	}
+
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("monitor is already running. Use 'wash monitor stop' to stop it first")
+	}
+	// Nothing answered, so any socket file is left over from a monitor that crashed
+	os.Remove(path)
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return fmt.Errorf("failed to open control socket: %v", err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to secure control socket: %v", err)
+	}
+
+	m.controlListener = listener
+	go m.serveControl(listener)
+	return nil
+}
+
+// serveControl answers control requests until the listener is closed
+func (m *Monitor) serveControl(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		m.controlConns.Add(1)
+		go func() {
+			defer m.controlConns.Done()
+			defer conn.Close()
+			m.handleControl(conn)
+		}()
+	}
+}
+
+// handleControl answers one control connection
+func (m *Monitor) handleControl(conn net.Conn) {
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var request controlRequest
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err == nil {
+		err = json.Unmarshal(line, &request)
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	var response controlResponse
+	switch {
+	case err != nil:
+		response.Error = fmt.Sprintf("invalid request: %v", err)
+	case request.Command == controlStatus:
+		response.Status = m.Status()
+	case request.Command == controlPause:
+		m.Pause(request.For)
+		fmt.Println("\nMonitoring paused")
+		response.Status = m.Status()
+	case request.Command == controlResume:
+		if m.Resume() {
+			fmt.Println("\nMonitoring resumed")
+		}
+		response.Status = m.Status()
+	case request.Command == controlStop:
+		response.Status = m.Status()
+		m.Stop()
+		response.Summary = m.Summary()
+	default:
+		response.Error = fmt.Sprintf("unknown command %q", request.Command)
+	}
+
+	json.NewEncoder(conn).Encode(response)
+}
+
+// CloseControl closes the control socket once any stop request has been answered
+func (m *Monitor) CloseControl() {
+	if m.controlListener == nil {
+		return
+	}
+	m.controlListener.Close()
+	m.controlConns.Wait()
+}
+
+// Status reports what the monitor is doing
+func (m *Monitor) Status() *Status {
+	source := "screenshot"
+	if m.display > 0 {
+		source = fmt.Sprintf("screenshot of display %d", m.display)
+	}
+	if m.ocr {
+		source += " (OCR)"
+	}
+	if m.transcript != nil {
+		source = string(m.transcript.Format()) + " " + m.transcript.Target()
+	} else if m.terminalSource != "" {
+		source = string(m.terminalSource)
+		if m.terminalTarget != "" {
+			source += " " + m.terminalTarget
+		}
+	}
+	pausedAt, pausedUntil := m.pauseState()
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+	return &Status{
+		PID:           os.Getpid(),
+		Projects:      m.projects(),
+		Source:        source,
+		Started:       m.summary.Started,
+		MonitorNotes:  int(m.monitorNotes.Load()),
+		ProgressNotes: int(m.progressNotes.Load()),
+		IdleSkips:     int(m.idleSkips.Load()),
+		Redactions:    int(m.redactions.Load()),
+		LastCapture:   m.lastCapture,
+		LastError:     m.lastError,
+		LastErrorAt:   m.lastErrorAt,
+		PausedAt:      pausedAt,
+		PausedUntil:   pausedUntil,
+		Heartbeat:     m.heartbeat,
+		Stalled:       m.stalled(),
+		Restarts:      m.restarts,
+		RestartReason: m.restartReason,
+		RestartedAt:   m.restartedAt,
+		RestartAt:     m.restartAt,
+	}
+}
+
+// Stopped is closed once the monitor has stopped, whether by signal, Stop or a control request
+func (m *Monitor) Stopped() <-chan struct{} {
+	return m.stopped
+}
+
+// request sends one control request to the running monitor and waits up to timeout for the answer
+func request(command string, timeout time.Duration) (*controlResponse, error) {
+	return send(controlRequest{Command: command}, timeout)
+}
+
+// send sends a control request to the running monitor and waits up to timeout for the answer
+func send(req controlRequest, timeout time.Duration) (*controlResponse, error) {
+	command := req.Command
+	path, err := ControlSocketPath()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err != nil {
+		return nil, ErrNotRunning
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, fmt.Errorf("failed to send %s request: %w", command, err)
+	}
+	var response controlResponse
+	if err := json.NewDecoder(conn).Decode(&response); err != nil {
+		return nil, fmt.Errorf("no answer to %s request: %w", command, err)
+	}
+	if response.Error != "" {
+		return nil, fmt.Errorf("monitor refused %s request: %s", command, response.Error)
+	}
+	return &response, nil
+}
+
+// QueryStatus asks the running monitor for its status; it returns ErrNotRunning when none answers
+func QueryStatus() (*Status, error) {
+	response, err := request(controlStatus, 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return response.Status, nil
+}
+
+// RequestPause asks the running monitor to stop capturing until resumed, or for d when it is
+// positive, and returns its status
+func RequestPause(d time.Duration) (*Status, error) {
+	response, err := send(controlRequest{Command: controlPause, For: d}, 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return response.Status, nil
+}
+
+// RequestResume asks the running monitor to capture again and returns its status
+func RequestResume() (*Status, error) {
+	response, err := request(controlResume, 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return response.Status, nil
+}
+
+// RequestStop asks the running monitor to stop and waits up to timeout for it to write its final summary.
+// It returns the status the monitor had when asked and the session summary.
+func RequestStop(timeout time.Duration) (*Status, *SessionSummary, error) {
+	response, err := request(controlStop, timeout)
+	if err != nil {
+		return nil, nil, err
+	}
+	return response.Status, response.Summary, nil
+}
@@ -0,0 +1,133 @@
+package chatmonitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/transcript"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/fsutil"
+	"github.com/bkidd1/wash-cli/internal/utils/tokens"
+)
+
+const (
+	// NoteModel analyzes screenshots and terminal scrollback
+	NoteModel = "gpt-4.1-mini"
+	// DefaultInterval is how often activity is analyzed when monitor_interval is not set
+	DefaultInterval = 30 * time.Second
+
+	// Estimated size of one analysis: a screenshot at the model's image token limit, or the
+	// captured scrollback or text read from a screenshot, plus the instructions and recent interactions
+	screenshotPromptTokens = 3300
+	terminalPromptTokens   = 3000
+	noteCompletionTokens   = 200
+
+	// workdayHours is the length of the day cost estimates are given for
+	workdayHours = 8
+
+	// costFileName records the last cost estimate the user confirmed
+	costFileName = "monitor_cost.json"
+)
+
+// Interval returns how often the monitor analyzes activity
+func Interval(cfg *config.Config) (time.Duration, error) {
+	if cfg.MonitorInterval == "" {
+		return DefaultInterval, nil
+	}
+	interval, err := config.ParseInterval(cfg.MonitorInterval)
+	if err != nil {
+		return 0, fmt.Errorf("invalid monitor_interval: %w", err)
+	}
+	return interval, nil
+}
+
+// CostEstimate is the expected API cost of running the monitor
+type CostEstimate struct {
+	Model    string        `json:"model"`
+	Interval time.Duration `json:"interval"`
+	Source   string        `json:"source"`
+	PerCall  float64       `json:"per_call"`
+	Hourly   float64       `json:"hourly"`
+}
+
+// EstimateCost estimates the cost of analyzing a source, screenshot, ocr, a terminal or a transcript, at an interval
+func EstimateCost(interval time.Duration, source string) CostEstimate {
+	promptTokens := screenshotPromptTokens
+	if source != "screenshot" {
+		promptTokens = terminalPromptTokens
+	}
+	perCall := tokens.NewEstimate(NoteModel, promptTokens, noteCompletionTokens).Cost
+	return CostEstimate{
+		Model:    NoteModel,
+		Interval: interval,
+		Source:   source,
+		PerCall:  perCall,
+		Hourly:   perCall * float64(time.Hour) / float64(interval),
+	}
+}
+
+// Daily returns the cost of a working day of monitoring
+func (e CostEstimate) Daily() float64 {
+	return e.Hourly * workdayHours
+}
+
+func (e CostEstimate) String() string {
+	capture := "a screenshot"
+	if e.Source == "ocr" {
+		capture = "the text read from a screenshot"
+	} else if _, err := transcript.ParseFormat(e.Source); err == nil {
+		capture = "the new turns of the " + e.Source + " transcript, when there are any,"
+	} else if e.Source != "screenshot" {
+		// Unchanged scrollback is not sent, so terminal estimates are an upper bound
+		capture = "the " + e.Source + " scrollback, when it changes,"
+	}
+	return fmt.Sprintf("Monitoring analyzes %s every %s with %s (about $%.4f per analysis):\n"+
+		"  up to ~$%.2f per hour, ~$%.2f per %d-hour day",
+		capture, e.Interval, e.Model, e.PerCall, e.Hourly, e.Daily(), workdayHours)
+}
+
+// Confirmed reports whether the user has already accepted this estimate; a change of model,
+// interval, source or prices needs a new confirmation
+func (e CostEstimate) Confirmed() bool {
+	path, err := costFilePath()
+	if err != nil {
+		return false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	var confirmed CostEstimate
+	return json.Unmarshal(data, &confirmed) == nil && confirmed == e
+}
+
+// Confirm records that the user accepted this estimate
+func (e CostEstimate) Confirm() error {
+	path, err := costFilePath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding cost estimate: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error creating data directory: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(path, data, 0644); err != nil {
+		return fmt.Errorf("error saving cost confirmation: %w", err)
+	}
+	return nil
+}
+
+// costFilePath returns where the confirmed estimate is kept
+func costFilePath() (string, error) {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, costFileName), nil
+}
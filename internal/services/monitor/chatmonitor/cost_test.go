@@ -0,0 +1,30 @@
+package chatmonitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCostEstimateConfirmation(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	estimate := EstimateCost(30*time.Second, "screenshot")
+	if estimate.Hourly != estimate.PerCall*120 || estimate.PerCall <= 0 {
+		t.Errorf("Unexpected estimate: %+v", estimate)
+	}
+	if estimate.Confirmed() {
+		t.Fatal("Expected a new estimate to need confirmation")
+	}
+	if err := estimate.Confirm(); err != nil {
+		t.Fatalf("Confirm failed: %v", err)
+	}
+	if !EstimateCost(30*time.Second, "screenshot").Confirmed() {
+		t.Error("Expected the same estimate to stay confirmed")
+	}
+	if EstimateCost(time.Minute, "screenshot").Confirmed() {
+		t.Error("Expected a new interval to need confirmation")
+	}
+	if EstimateCost(30*time.Second, "ocr").PerCall >= estimate.PerCall {
+		t.Error("Expected screenshot text to cost less than the screenshot")
+	}
+}
@@ -0,0 +1,46 @@
+package chatmonitor
+
+import (
+	"fmt"
+	"time"
+)
+
+// Pause stops screen and terminal captures until Resume, or until d has passed when it is
+// positive. The session goes on: progress notes are still written, and the recap covers the
+// whole session.
+func (m *Monitor) Pause(d time.Duration) {
+	m.pauseMu.Lock()
+	defer m.pauseMu.Unlock()
+	m.pausedAt = time.Now()
+	m.pausedUntil = time.Time{}
+	if d > 0 {
+		m.pausedUntil = m.pausedAt.Add(d)
+	}
+}
+
+// Resume restarts captures and reports whether the monitor was paused
+func (m *Monitor) Resume() bool {
+	m.pauseMu.Lock()
+	defer m.pauseMu.Unlock()
+	wasPaused := !m.pausedAt.IsZero()
+	m.pausedAt, m.pausedUntil = time.Time{}, time.Time{}
+	return wasPaused
+}
+
+// pauseState returns when captures were paused and until when, both zero when they are not;
+// a pause that has run out is ended
+func (m *Monitor) pauseState() (time.Time, time.Time) {
+	m.pauseMu.Lock()
+	defer m.pauseMu.Unlock()
+	if !m.pausedUntil.IsZero() && !time.Now().Before(m.pausedUntil) {
+		m.pausedAt, m.pausedUntil = time.Time{}, time.Time{}
+		fmt.Println("\nMonitoring resumed")
+	}
+	return m.pausedAt, m.pausedUntil
+}
+
+// paused reports whether captures are paused
+func (m *Monitor) paused() bool {
+	pausedAt, _ := m.pauseState()
+	return !pausedAt.IsZero()
+}
@@ -0,0 +1,30 @@
+package chatmonitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPause(t *testing.T) {
+	m := &Monitor{projectName: "app"}
+
+	m.Pause(0)
+	if !m.paused() {
+		t.Fatal("Expected the monitor to be paused")
+	}
+	if status := m.Status(); status.PausedAt.IsZero() || !status.PausedUntil.IsZero() {
+		t.Errorf("Expected an open-ended pause, got %+v", status)
+	}
+	if !m.Resume() || m.paused() {
+		t.Error("Expected resume to end the pause")
+	}
+	if m.Resume() {
+		t.Error("Expected resume to report the monitor was not paused")
+	}
+
+	m.Pause(time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if m.paused() {
+		t.Error("Expected the pause to run out")
+	}
+}
@@ -0,0 +1,92 @@
+package chatmonitor
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/handoff"
+	"github.com/bkidd1/wash-cli/internal/services/notes"
+)
+
+// SessionSummary recaps one monitoring session
+type SessionSummary struct {
+	Started       time.Time
+	Stopped       time.Time
+	MonitorNotes  int
+	ProgressNotes int
+	// IdleSkips counts the screenshots not analyzed because the screen had not changed
+	IdleSkips int
+	// Final holds the progress notes written on stop for the time since the last 5-minute tick
+	Final []*notes.ProjectProgressNote
+	// Handoffs names the projects a handoff was written for on stop
+	Handoffs []string
+}
+
+// String formats the summary as a short end-of-session recap
+func (s *SessionSummary) String() string {
+	var b strings.Builder
+	b.WriteString("\nSession recap\n")
+	b.WriteString("-------------\n")
+	fmt.Fprintf(&b, "Duration: %s\n", s.Stopped.Sub(s.Started).Round(time.Second))
+	fmt.Fprintf(&b, "Monitor notes: %d\n", s.MonitorNotes)
+	fmt.Fprintf(&b, "Progress notes: %d\n", s.ProgressNotes)
+	if s.IdleSkips > 0 {
+		fmt.Fprintf(&b, "Unchanged screenshots skipped: %d\n", s.IdleSkips)
+	}
+	for _, note := range s.Final {
+		fmt.Fprintf(&b, "\n%s (%s)\n", note.Title, note.ProjectName)
+		if description := strings.TrimSpace(note.Description); description != "" {
+			b.WriteString(description + "\n")
+		}
+	}
+	if len(s.Handoffs) > 0 {
+		fmt.Fprintf(&b, "\nHandoff written for %s; wash context shows it\n", strings.Join(s.Handoffs, ", "))
+	}
+	return b.String()
+}
+
+// finishSession writes a final progress note per project covering the time since the last
+// progress tick, so the tail of the session is not dropped, and records the session summary
+func (m *Monitor) finishSession() {
+	since := time.Since(m.lastProgress)
+	for _, projectName := range m.projects() {
+		note, err := m.notesManager.GenerateProgressFromMonitor(projectName, since)
+		if err != nil {
+			// Nothing was captured since the last tick
+			continue
+		}
+		note.Title = fmt.Sprintf("Final %s Summary", since.Round(time.Second))
+		if err := m.notesManager.SaveProjectProgress(note); err != nil {
+			fmt.Printf("Error saving final progress note: %v\n", err)
+			continue
+		}
+		m.progressNotes.Add(1)
+		m.summary.Final = append(m.summary.Final, note)
+	}
+
+	// Brief the next session on where this one left off
+	for _, projectName := range m.projects() {
+		written, err := handoff.Write(m.cfg, projectName, m.summary.Started)
+		if err != nil {
+			fmt.Printf("Error writing handoff: %v\n", err)
+			continue
+		}
+		if written != nil {
+			m.summary.Handoffs = append(m.summary.Handoffs, projectName)
+		}
+	}
+
+	m.summary.Stopped = time.Now()
+	m.summary.MonitorNotes = int(m.monitorNotes.Load())
+	m.summary.ProgressNotes = int(m.progressNotes.Load())
+	m.summary.IdleSkips = int(m.idleSkips.Load())
+}
+
+// Summary returns the recap of a stopped session, or nil while the monitor is running
+func (m *Monitor) Summary() *SessionSummary {
+	if m.summary.Stopped.IsZero() {
+		return nil
+	}
+	return &m.summary
+}
@@ -0,0 +1,89 @@
+package chatmonitor
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+const (
+	// maxConsecutiveFailures is how many analyses in a row may fail before the loop is restarted
+	maxConsecutiveFailures = 5
+	// maxRestartBackoff caps the wait before a restart, which doubles after each quick failure
+	maxRestartBackoff = 10 * time.Minute
+	// stableRun is how long a loop must run for the next restart to wait restartBackoff again
+	stableRun = 30 * time.Minute
+	// stalledIntervals is how many intervals without a heartbeat mark the loop as stalled
+	stalledIntervals = 3
+)
+
+// restartBackoff is the wait before the first restart of a failed loop, shortened in tests
+var restartBackoff = 10 * time.Second
+
+// supervise runs the monitor loop until the monitor stops, restarting it with backoff when it
+// panics or gives up after repeated failures, so the monitor never runs on without its loop
+func (m *Monitor) supervise(loop func() error) {
+	defer close(m.doneChan)
+
+	backoff := restartBackoff
+	for {
+		started := time.Now()
+		err := runLoop(loop)
+		if err == nil {
+			return
+		}
+		if time.Since(started) >= stableRun {
+			backoff = restartBackoff
+		}
+
+		fmt.Printf("\nMonitor loop stopped: %v\nRestarting it in %s\n", err, backoff)
+		m.recordRestart(err, backoff)
+		select {
+		case <-m.stopChan:
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, maxRestartBackoff)
+	}
+}
+
+// runLoop runs the loop, turning a panic into an error that carries its stack
+func runLoop(loop func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	return loop()
+}
+
+// beat records that the loop is alive
+func (m *Monitor) beat() {
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+	m.heartbeat = time.Now()
+	m.restartAt = time.Time{}
+}
+
+// recordRestart notes a loop that stopped and when it will be restarted
+func (m *Monitor) recordRestart(err error, backoff time.Duration) {
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+	m.restarts++
+	m.restartReason = firstLine(err.Error())
+	m.restartedAt = time.Now()
+	m.restartAt = m.restartedAt.Add(backoff)
+}
+
+// stalled reports whether the loop has missed its heartbeat for stalledIntervals intervals
+// without a restart being due; healthMu must be held
+func (m *Monitor) stalled() bool {
+	return !m.heartbeat.IsZero() && m.restartAt.IsZero() && time.Since(m.heartbeat) > stalledIntervals*m.interval
+}
+
+// firstLine returns the first line of a message, leaving out a panic's stack
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
@@ -0,0 +1,51 @@
+package chatmonitor
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSupervise(t *testing.T) {
+	restartBackoff = time.Millisecond
+	defer func() { restartBackoff = 10 * time.Second }()
+
+	m := &Monitor{projectName: "app", interval: time.Minute, stopChan: make(chan struct{}), doneChan: make(chan struct{})}
+	runs := 0
+	go m.supervise(func() error {
+		runs++
+		switch runs {
+		case 1:
+			panic("boom")
+		case 2:
+			return errors.New("analyses failed")
+		}
+		m.beat()
+		<-m.stopChan
+		return nil
+	})
+
+	deadline := time.After(time.Second)
+	for m.Status().Heartbeat.IsZero() {
+		select {
+		case <-deadline:
+			t.Fatal("Expected the loop to be restarted")
+		case <-time.After(time.Millisecond):
+		}
+	}
+	close(m.stopChan)
+	<-m.doneChan
+
+	status := m.Status()
+	if runs != 3 || status.Restarts != 2 {
+		t.Errorf("Expected 3 runs and 2 restarts, got %d and %d", runs, status.Restarts)
+	}
+	if status.RestartReason != "analyses failed" || !status.RestartAt.IsZero() || status.Stalled {
+		t.Errorf("Unexpected status after restart: %+v", status)
+	}
+
+	if err := runLoop(func() error { panic("boom") }); err == nil || !strings.HasPrefix(err.Error(), "panic: boom\n") {
+		t.Errorf("Expected the panic as an error, got %v", err)
+	}
+}
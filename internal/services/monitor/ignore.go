@@ -0,0 +1,92 @@
+package monitor
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/bkidd1/wash-cli/internal/utils/ignore"
+)
+
+// SetIgnoreLoader reloads the ignore patterns with load whenever an ignore file in a watched path
+// changes, so newly ignored directories stop producing events without a restart
+func (m *Monitor) SetIgnoreLoader(load func() ([]string, error)) {
+	m.loadIgnorePatterns = load
+}
+
+// isIgnoreFile reports whether path is an ignore file at the top of a watched path
+func (m *Monitor) isIgnoreFile(path string) bool {
+	if !slices.Contains(ignore.PatternFiles, filepath.Base(path)) {
+		return false
+	}
+	return slices.Contains(m.paths, filepath.Dir(path))
+}
+
+// excluded reports whether path or one of its directories below a watched path is ignored
+func (m *Monitor) excluded(path string) bool {
+	for dir := path; ; dir = filepath.Dir(dir) {
+		if slices.Contains(m.paths, dir) || dir == filepath.Dir(dir) {
+			return false
+		}
+		if m.ignored(dir) {
+			return true
+		}
+	}
+}
+
+// reloadIgnorePatterns applies the current ignore files: directories they now ignore are no longer
+// watched, and directories they no longer ignore are watched again
+func (m *Monitor) reloadIgnorePatterns() {
+	patterns, err := m.loadIgnorePatterns()
+	if err != nil {
+		log.Printf("error reloading ignore patterns: %v", err)
+		return
+	}
+	m.ignorePatterns = patterns
+
+	removed := 0
+	watched := make(map[string]bool)
+	for _, dir := range m.watcher.WatchList() {
+		if m.excluded(dir) {
+			if err := m.watcher.Remove(dir); err == nil {
+				removed++
+			}
+			continue
+		}
+		watched[dir] = true
+	}
+	for file := range m.files {
+		if m.excluded(file) {
+			delete(m.files, file)
+		}
+	}
+
+	added := 0
+	for _, root := range m.paths {
+		filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if !info.IsDir() {
+				if _, ok := m.files[path]; !ok {
+					m.files[path] = info
+				}
+				return nil
+			}
+			if m.ignored(path) {
+				return filepath.SkipDir
+			}
+			if !watched[path] {
+				if err := m.watcher.Add(path); err == nil {
+					added++
+				}
+			}
+			return nil
+		})
+	}
+
+	if removed > 0 || added > 0 {
+		log.Printf("Ignore patterns changed: stopped watching %d directories, started watching %d", removed, added)
+	}
+}
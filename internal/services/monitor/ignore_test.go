@@ -0,0 +1,50 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/utils/ignore"
+)
+
+func TestMonitorReloadsIgnorePatterns(t *testing.T) {
+	dir := t.TempDir()
+	generated := filepath.Join(dir, "generated")
+	if err := os.Mkdir(generated, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := NewMonitor([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.SetIgnoreLoader(func() ([]string, error) { return ignore.LoadGitignorePatterns(dir) })
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Stop()
+
+	if err := os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("generated\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// The ignore file is reread before the next events are handled
+	time.Sleep(200 * time.Millisecond)
+	if err := os.WriteFile(filepath.Join(generated, "out.js"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	kept := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(kept, []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case event := <-m.Events():
+		if event.Path != kept {
+			t.Errorf("Expected only %s to be reported, got %+v", kept, event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for events")
+	}
+}
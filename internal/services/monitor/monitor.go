@@ -1,6 +1,7 @@
 package monitor
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -8,15 +9,25 @@ import (
 	"strings"
 	"time"
 
+	"github.com/bkidd1/wash-cli/internal/utils/analyzable"
+	"github.com/bkidd1/wash-cli/internal/utils/ignore"
 	"github.com/fsnotify/fsnotify"
 )
 
 // Monitor represents a file system monitor
 type Monitor struct {
-	watcher *fsnotify.Watcher
-	paths   []string
-	events  chan Event
-	done    chan struct{}
+	watcher        *fsnotify.Watcher
+	paths          []string
+	ignorePatterns []string
+	// loadIgnorePatterns rereads the ignore patterns when an ignore file changes, if set
+	loadIgnorePatterns func() ([]string, error)
+	maxFileSize        int64
+	events             chan Event
+	done               chan struct{}
+
+	// files and pendingRenames are only used by the event loop, to pair renames with their new paths
+	files          map[string]os.FileInfo
+	pendingRenames []pendingRename
 }
 
 // Event represents a file system event
@@ -24,6 +35,8 @@ type Event struct {
 	Path      string
 	Type      string
 	Timestamp time.Time
+	// OldPath is the path a renamed or moved file had before; Path is its new path
+	OldPath string
 }
 
 // NewMonitor creates a new file system monitor
@@ -38,9 +51,42 @@ func NewMonitor(paths []string) (*Monitor, error) {
 		paths:   paths,
 		events:  make(chan Event, 100),
 		done:    make(chan struct{}),
+		files:   make(map[string]os.FileInfo),
 	}, nil
 }
 
+// SetIgnorePatterns skips directories and files matching the patterns, relative to the watched paths
+func (m *Monitor) SetIgnorePatterns(patterns []string) {
+	m.ignorePatterns = patterns
+}
+
+// SetMaxFileSize sets the size above which changed files are skipped; zero uses the default
+func (m *Monitor) SetMaxFileSize(size int64) {
+	m.maxFileSize = size
+}
+
+// ignored reports whether a path matches the ignore patterns by its path relative to a monitored
+// root, or by base name when it is under none of them
+func (m *Monitor) ignored(path string) bool {
+	if len(m.ignorePatterns) == 0 {
+		return false
+	}
+	under := false
+	for _, root := range m.paths {
+		rel, err := filepath.Rel(root, path)
+		if err == nil && rel == "." {
+			return false
+		}
+		if err == nil && !strings.HasPrefix(rel, "..") {
+			if ignore.ShouldIgnore(rel, m.ignorePatterns) {
+				return true
+			}
+			under = true
+		}
+	}
+	return !under && ignore.ShouldIgnore(filepath.Base(path), m.ignorePatterns)
+}
+
 // Start begins monitoring the specified paths
 func (m *Monitor) Start() error {
 	// Add paths to watcher
@@ -52,8 +98,12 @@ func (m *Monitor) Start() error {
 					return err
 				}
 				if info.IsDir() {
+					if m.ignored(path) {
+						return filepath.SkipDir
+					}
 					return m.watcher.Add(path)
 				}
+				m.files[path] = info
 				return nil
 			}); err != nil {
 				return fmt.Errorf("failed to add directory %s to watcher: %w", path, err)
@@ -67,8 +117,12 @@ func (m *Monitor) Start() error {
 
 	// Start watching for events
 	go func() {
+		expire := time.NewTicker(renameWindow)
+		defer expire.Stop()
 		for {
 			select {
+			case <-expire.C:
+				m.expireRenames(time.Now())
 			case event, ok := <-m.watcher.Events:
 				if !ok {
 					return
@@ -101,28 +155,76 @@ func (m *Monitor) Events() <-chan Event {
 
 // handleEvent processes file system events
 func (m *Monitor) handleEvent(event fsnotify.Event) {
+	if m.loadIgnorePatterns != nil && m.isIgnoreFile(event.Name) && event.Op&^fsnotify.Chmod != 0 {
+		m.reloadIgnorePatterns()
+		return
+	}
+
 	// Skip directories and hidden files
-	if strings.HasPrefix(filepath.Base(event.Name), ".") {
+	if strings.HasPrefix(filepath.Base(event.Name), ".") || m.ignored(event.Name) {
 		return
 	}
 
+	// A rename is reported as a Rename of the old path and a Create of the new one; the old path
+	// is held back until the new one shows up, or reported as removed when it does not
+	if event.Op == fsnotify.Rename {
+		m.holdRename(event.Name)
+		return
+	}
+	var info os.FileInfo
+	if event.Op&(fsnotify.Create|fsnotify.Write) != 0 {
+		info, _ = os.Stat(event.Name)
+	}
+	oldPath := ""
+	if event.Op&fsnotify.Create != 0 {
+		oldPath = m.matchRename(event.Name, info)
+	}
+
+	// Watch directories created after monitoring started
+	if info != nil && info.IsDir() {
+		if event.Op&fsnotify.Create != 0 {
+			if err := m.watcher.Add(event.Name); err != nil {
+				log.Printf("error watching %s: %v", event.Name, err)
+			}
+			if oldPath != "" {
+				m.emit(Event{Path: event.Name, Type: "rename", OldPath: oldPath})
+			}
+		}
+		return
+	}
+	if info != nil {
+		m.files[event.Name] = info
+	} else if event.Op&fsnotify.Remove != 0 {
+		delete(m.files, event.Name)
+	}
+
+	// Skip binary, generated, minified and oversized files, which are not worth analyzing
+	if event.Op&(fsnotify.Create|fsnotify.Write) != 0 {
+		var skip *analyzable.SkipError
+		if err := analyzable.Check(event.Name, m.maxFileSize); errors.As(err, &skip) {
+			return
+		}
+	}
+
 	var eventType string
-	switch event.Op {
-	case fsnotify.Create:
+	switch {
+	case oldPath != "":
+		eventType = "rename"
+	case event.Op == fsnotify.Create:
 		eventType = "create"
-	case fsnotify.Write:
+	case event.Op == fsnotify.Write:
 		eventType = "write"
-	case fsnotify.Remove:
+	case event.Op == fsnotify.Remove:
 		eventType = "remove"
-	case fsnotify.Rename:
-		eventType = "rename"
-	case fsnotify.Chmod:
+	case event.Op == fsnotify.Chmod:
 		eventType = "chmod"
 	}
 
-	m.events <- Event{
-		Path:      event.Name,
-		Type:      eventType,
-		Timestamp: time.Now(),
-	}
+	m.emit(Event{Path: event.Name, Type: eventType, OldPath: oldPath})
+}
+
+// emit sends an event to the events channel, stamped with the current time
+func (m *Monitor) emit(event Event) {
+	event.Timestamp = time.Now()
+	m.events <- event
 }
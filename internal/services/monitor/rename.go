@@ -0,0 +1,77 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// renameWindow is how long the old path of a rename waits for its new path before it is
+// reported as removed, for files moved out of the watched paths
+const renameWindow = time.Second
+
+// pendingRename is the old path of a rename whose new path has not been seen yet
+type pendingRename struct {
+	path string
+	// info is the file's last known info, used to recognize it under its new name
+	info os.FileInfo
+	at   time.Time
+}
+
+// holdRename keeps the old path of a rename until matchRename pairs it with the new path
+func (m *Monitor) holdRename(path string) {
+	m.pendingRenames = append(m.pendingRenames, pendingRename{path: path, info: m.files[path], at: time.Now()})
+	delete(m.files, path)
+}
+
+// matchRename returns the old path of the file created at path when it was renamed there, or an
+// empty string. Files are matched by inode; without one, as for directories or files that were
+// never seen, the newest rename within the window is taken.
+func (m *Monitor) matchRename(path string, info os.FileInfo) string {
+	match := -1
+	for i, pending := range m.pendingRenames {
+		if pending.info != nil && info != nil && os.SameFile(pending.info, info) {
+			match = i
+			break
+		}
+	}
+	if match < 0 && len(m.pendingRenames) > 0 {
+		newest := len(m.pendingRenames) - 1
+		pending := m.pendingRenames[newest]
+		if (pending.info == nil || info == nil) && time.Since(pending.at) < renameWindow {
+			match = newest
+		}
+	}
+	if match < 0 {
+		return ""
+	}
+
+	oldPath := m.pendingRenames[match].path
+	m.pendingRenames = append(m.pendingRenames[:match], m.pendingRenames[match+1:]...)
+
+	// The files of a moved directory keep their inodes under the new path
+	if info != nil && info.IsDir() {
+		prefix := oldPath + string(filepath.Separator)
+		for file, fileInfo := range m.files {
+			if strings.HasPrefix(file, prefix) {
+				delete(m.files, file)
+				m.files[filepath.Join(path, strings.TrimPrefix(file, prefix))] = fileInfo
+			}
+		}
+	}
+	return oldPath
+}
+
+// expireRenames reports the old paths that found no new path within the window as removed
+func (m *Monitor) expireRenames(now time.Time) {
+	kept := m.pendingRenames[:0]
+	for _, pending := range m.pendingRenames {
+		if now.Sub(pending.at) < renameWindow {
+			kept = append(kept, pending)
+			continue
+		}
+		m.emit(Event{Path: pending.path, Type: "remove"})
+	}
+	m.pendingRenames = kept
+}
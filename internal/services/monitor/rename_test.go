@@ -0,0 +1,55 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMonitorPairsRenames(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "old.go")
+	if err := os.WriteFile(oldPath, []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := NewMonitor([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Stop()
+
+	newPath := filepath.Join(dir, "sub", "new.go")
+	if err := os.Rename(oldPath, newPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(newPath, filepath.Join(t.TempDir(), "gone.go")); err != nil {
+		t.Fatal(err)
+	}
+
+	var events []Event
+	timeout := time.After(5 * time.Second)
+	for len(events) < 2 {
+		select {
+		case event := <-m.Events():
+			events = append(events, event)
+		case <-timeout:
+			t.Fatalf("Timed out waiting for events, got %+v", events)
+		}
+	}
+
+	if events[0].Type != "rename" || events[0].Path != newPath || events[0].OldPath != oldPath {
+		t.Errorf("Expected a rename from %s to %s, got %+v", oldPath, newPath, events[0])
+	}
+	// A file moved out of the watched paths is removed
+	if events[1].Type != "remove" || events[1].Path != newPath {
+		t.Errorf("Expected %s to be removed, got %+v", newPath, events[1])
+	}
+}
@@ -0,0 +1,254 @@
+package notes
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/journal"
+)
+
+const (
+	// KindBug marks bug reports saved by wash bug; they are markdown rather than JSON notes
+	KindBug NoteKind = "bug"
+
+	// archiveDir is the directory, beside a kind's live notes, that archived notes are moved into
+	archiveDir = "archive"
+)
+
+// BrowseKinds lists the note kinds wash notes browse shows, in tab order
+var BrowseKinds = []NoteKind{KindInteraction, KindMonitor, KindProgress, KindBug}
+
+// Entry is one stored note as listed by wash notes browse
+type Entry struct {
+	Kind      NoteKind
+	Path      string
+	Project   string
+	Timestamp time.Time
+	Title     string
+	Archived  bool
+}
+
+// kindDir returns the directory holding a project's live notes of one kind
+func (nm *NotesManager) kindDir(kind NoteKind, projectName string) (string, error) {
+	switch kind {
+	case KindInteraction:
+		return filepath.Join(nm.baseDir, "projects", projectName, "notes"), nil
+	case KindMonitor:
+		return nm.GetMonitorNotesDir(projectName), nil
+	case KindProgress:
+		return filepath.Join(nm.baseDir, "progress"), nil
+	case KindBug:
+		return filepath.Join(nm.baseDir, "projects", projectName, "bugs"), nil
+	}
+	return "", fmt.Errorf("cannot browse %s notes", kind)
+}
+
+// ListEntries returns a project's notes of one kind, newest first. With archived set, it lists the
+// archived notes instead of the live ones. Files that cannot be read are listed with their name as title.
+func (nm *NotesManager) ListEntries(projectName string, kind NoteKind, archived bool) ([]Entry, error) {
+	dir, err := nm.kindDir(kind, projectName)
+	if err != nil {
+		return nil, err
+	}
+	if archived {
+		dir = filepath.Join(dir, archiveDir)
+	}
+
+	pattern := "*.json"
+	if kind == KindBug {
+		pattern = "*.md"
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, pattern))
+	if err != nil {
+		return nil, fmt.Errorf("error listing %s notes: %w", kind, err)
+	}
+
+	var entries []Entry
+	for _, path := range paths {
+		if kind == KindProgress && !isProgressFile(filepath.Base(path), projectName) {
+			continue
+		}
+		entry := Entry{Kind: kind, Path: path, Project: projectName, Archived: archived}
+		if err := nm.describeEntry(&entry); err != nil {
+			entry.Title = filepath.Base(path)
+			if info, statErr := os.Stat(path); statErr == nil {
+				entry.Timestamp = info.ModTime()
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Timestamp.After(entries[j].Timestamp) })
+	return entries, nil
+}
+
+// isProgressFile reports whether name is a progress note file of the project,
+// <project>_<id>.json, and not one of a project whose name merely shares the
+// prefix, such as "app_v2" for "app"
+func isProgressFile(name, projectName string) bool {
+	rest, ok := strings.CutPrefix(name, projectName+"_")
+	if !ok || !strings.HasSuffix(rest, ".json") {
+		return false
+	}
+	return !strings.Contains(strings.TrimSuffix(rest, ".json"), "_")
+}
+
+// describeEntry fills in an entry's timestamp and title from its file
+func (nm *NotesManager) describeEntry(entry *Entry) error {
+	if entry.Kind == KindBug {
+		data, err := nm.readNoteFile(entry.Path)
+		if err != nil {
+			return err
+		}
+		info, err := os.Stat(entry.Path)
+		if err != nil {
+			return err
+		}
+		entry.Timestamp = info.ModTime()
+		if named, err := time.ParseInLocation("2006-01-02-15-04-05", strings.TrimSuffix(strings.TrimPrefix(filepath.Base(entry.Path), "bug_"), ".md"), time.Local); err == nil {
+			entry.Timestamp = named
+		}
+		entry.Title = bugTitle(data)
+		return nil
+	}
+
+	data, err := nm.readNoteFile(entry.Path)
+	if err != nil {
+		return err
+	}
+
+	switch entry.Kind {
+	case KindInteraction:
+		var note Interaction
+		if err := json.Unmarshal(data, &note); err != nil {
+			return err
+		}
+		entry.Timestamp = note.Timestamp
+		entry.Title = note.Context.CurrentState
+	case KindMonitor:
+		var note MonitorNote
+		if err := json.Unmarshal(data, &note); err != nil {
+			return err
+		}
+		entry.Timestamp = note.Timestamp
+		entry.Title = note.Interaction.UserRequest
+	case KindProgress:
+		var note ProjectProgressNote
+		if err := json.Unmarshal(data, &note); err != nil {
+			return err
+		}
+		entry.Timestamp = note.Timestamp
+		entry.Title = note.Title
+		if note.ProjectName != "" {
+			entry.Project = note.ProjectName
+		}
+	}
+
+	entry.Title = firstLine(entry.Title)
+	if entry.Title == "" {
+		entry.Title = filepath.Base(entry.Path)
+	}
+	return nil
+}
+
+// bugTitle returns the first line of a bug report's description section
+func bugTitle(data []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	inDescription := false
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "## "):
+			inDescription = line == "## Description"
+		case inDescription && line != "":
+			return line
+		}
+	}
+	return ""
+}
+
+// firstLine returns the first non-empty line of s, trimmed
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// Preview returns an entry's contents for display: bug reports as written and JSON notes indented, both decrypted
+func (nm *NotesManager) Preview(entry Entry) (string, error) {
+	if entry.Kind == KindBug {
+		data, err := nm.readNoteFile(entry.Path)
+		if err != nil {
+			return "", fmt.Errorf("error reading bug report: %w", err)
+		}
+		return string(data), nil
+	}
+
+	data, err := nm.readNoteFile(entry.Path)
+	if err != nil {
+		return "", fmt.Errorf("error reading note: %w", err)
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, data, "", "  "); err != nil {
+		return string(data), nil
+	}
+	return out.String(), nil
+}
+
+// ProgressNote reads the progress note of a progress entry, decrypted
+func (nm *NotesManager) ProgressNote(entry Entry) (*ProjectProgressNote, error) {
+	if entry.Kind != KindProgress {
+		return nil, fmt.Errorf("%s is not a progress note", filepath.Base(entry.Path))
+	}
+	return nm.readProgressNote(entry.Path)
+}
+
+// DeleteEntry moves an entry's file to the trash
+func (nm *NotesManager) DeleteEntry(entry Entry) (*TrashEntry, error) {
+	return nm.Trash(entry.Kind, entry.Path, entry.Project)
+}
+
+// ArchiveEntry moves a live entry into its kind's archive directory, or an archived one back.
+// Archived notes are left out of analysis context but still migrated, encrypted and pruned.
+func (nm *NotesManager) ArchiveEntry(entry Entry) error {
+	dir, err := nm.kindDir(entry.Kind, entry.Project)
+	if err != nil {
+		return err
+	}
+	target := filepath.Join(dir, archiveDir)
+	if entry.Archived {
+		target = dir
+	}
+
+	if err := os.MkdirAll(target, 0755); err != nil {
+		return fmt.Errorf("error creating archive directory: %w", err)
+	}
+	if err := os.Rename(entry.Path, filepath.Join(target, filepath.Base(entry.Path))); err != nil {
+		return fmt.Errorf("error archiving note: %w", err)
+	}
+	detail := "moved to " + archiveDir
+	if entry.Archived {
+		detail = "moved out of " + archiveDir
+	}
+	nm.record(journal.ActionArchived, entry.Project, entry.Path, detail)
+	return nm.entryChanged(entry)
+}
+
+// entryChanged keeps the progress index in step after a progress note is deleted or moved
+func (nm *NotesManager) entryChanged(entry Entry) error {
+	if entry.Kind != KindProgress {
+		return nil
+	}
+	return nm.RebuildProgressIndex(entry.Project)
+}
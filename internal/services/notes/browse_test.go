@@ -0,0 +1,84 @@
+package notes
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestArchiveEntryRoundTrip(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	nm, err := NewNotesManager()
+	if err != nil {
+		t.Fatalf("Failed to create notes manager: %v", err)
+	}
+
+	note := &ProjectProgressNote{Timestamp: time.Now(), ID: "p1", ProjectName: "demo", Title: "Ship login"}
+	if err := nm.SaveProjectProgress(note); err != nil {
+		t.Fatalf("Failed to save note: %v", err)
+	}
+	// A project sharing the name prefix must not show up
+	other := &ProjectProgressNote{Timestamp: time.Now(), ID: "p2", ProjectName: "demo_app", Title: "Other"}
+	if err := nm.SaveProjectProgress(other); err != nil {
+		t.Fatalf("Failed to save note: %v", err)
+	}
+
+	entries, err := nm.ListEntries("demo", KindProgress, false)
+	if err != nil || len(entries) != 1 || entries[0].Title != "Ship login" {
+		t.Fatalf("ListEntries = %+v, %v", entries, err)
+	}
+
+	if err := nm.ArchiveEntry(entries[0]); err != nil {
+		t.Fatalf("ArchiveEntry failed: %v", err)
+	}
+	if live, _ := nm.GetProgressNotes("demo"); len(live) != 0 {
+		t.Errorf("Archived note still loaded: %d notes", len(live))
+	}
+	files, err := nm.noteFiles()
+	if err != nil || len(files[KindProgress]) != 2 {
+		t.Errorf("noteFiles should still cover the archived note: %v, %v", files[KindProgress], err)
+	}
+
+	archived, err := nm.ListEntries("demo", KindProgress, true)
+	if err != nil || len(archived) != 1 || !archived[0].Archived {
+		t.Fatalf("Archived entries = %+v, %v", archived, err)
+	}
+	if err := nm.ArchiveEntry(archived[0]); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if live, _ := nm.GetProgressNotes("demo"); len(live) != 1 {
+		t.Errorf("Restored note not loaded: %d notes", len(live))
+	}
+
+	if _, err := nm.DeleteEntry(entries[0]); err != nil {
+		t.Fatalf("DeleteEntry failed: %v", err)
+	}
+	if entries, _ := nm.ListEntries("demo", KindProgress, false); len(entries) != 0 {
+		t.Errorf("Deleted note still listed: %+v", entries)
+	}
+}
+
+func TestListEntriesMatchesProjectExactly(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	nm, err := NewNotesManager()
+	if err != nil {
+		t.Fatalf("Failed to create notes manager: %v", err)
+	}
+
+	for _, project := range []string{"web[1]", "web[1]_v2"} {
+		if err := nm.SaveProjectProgress(&ProjectProgressNote{ProjectName: project, Title: project}); err != nil {
+			t.Fatalf("Failed to save note: %v", err)
+		}
+	}
+	// An unreadable note of the other project is not attributed to this one
+	progressDir := filepath.Join(nm.baseDir, "progress")
+	if err := os.WriteFile(filepath.Join(progressDir, "web[1]_v2_broken.json"), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := nm.ListEntries("web[1]", KindProgress, false)
+	if err != nil || len(entries) != 1 || entries[0].Title != "web[1]" {
+		t.Fatalf("ListEntries = %+v, %v", entries, err)
+	}
+}
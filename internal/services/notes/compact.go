@@ -0,0 +1,293 @@
+package notes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/journal"
+	"github.com/bkidd1/wash-cli/internal/services/usage"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/tokens"
+	"github.com/google/uuid"
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	// DefaultCompactAfter is how old monitor notes get before compaction when no age is configured
+	DefaultCompactAfter = "7d"
+	// digestModel writes daily digests; a full day of monitor notes needs a long context window
+	digestModel = "gpt-4.1-mini"
+	// digestPromptBudget caps the monitor note text sent for one day
+	digestPromptBudget = 200000
+	// digestType is the progress note type of daily digests
+	digestType = "digest"
+)
+
+// CompactionReport summarizes a compaction run
+type CompactionReport struct {
+	Days    []string // Days compacted, or that would be with dryRun
+	Removed int      // Raw monitor notes rolled into digests
+	Digests []*ProjectProgressNote
+}
+
+// monitorNoteFile is a monitor note and the file it was read from
+type monitorNoteFile struct {
+	path string
+	note *MonitorNote
+}
+
+// CompactMonitorNotes rolls each full day of a project's monitor notes older than olderThan into an
+// AI-generated digest progress note and deletes the raw notes. A day too long for one prompt gets one
+// digest per part. With dryRun set, it only reports the days.
+func (nm *NotesManager) CompactMonitorNotes(projectName string, olderThan time.Duration, dryRun bool) (*CompactionReport, error) {
+	days, err := nm.monitorNotesByDay(projectName, time.Now().Add(-olderThan))
+	if err != nil {
+		return nil, err
+	}
+
+	dayNames := make([]string, 0, len(days))
+	for day := range days {
+		dayNames = append(dayNames, day)
+	}
+	sort.Strings(dayNames)
+
+	report := &CompactionReport{}
+	if dryRun {
+		report.Days = dayNames
+		for _, day := range dayNames {
+			report.Removed += len(days[day])
+		}
+		return report, nil
+	}
+
+	var client *openai.Client
+	for _, day := range dayNames {
+		if client == nil {
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				return report, fmt.Errorf("failed to load config: %w", err)
+			}
+			client = usage.NewClient(cfg.OpenAIKey)
+		}
+
+		parts := digestParts(days[day], digestPromptBudget)
+		for i, part := range parts {
+			label := day
+			if len(parts) > 1 {
+				label = fmt.Sprintf("%s (part %d of %d)", day, i+1, len(parts))
+			}
+			digest, err := nm.digestDay(client, projectName, label, part)
+			if err != nil {
+				return report, fmt.Errorf("error compacting %s: %w", label, err)
+			}
+
+			// Only remove raw notes once their digest is safely stored
+			if err := nm.saveProgress(digest); err != nil {
+				return report, fmt.Errorf("error saving digest for %s: %w", label, err)
+			}
+			for _, file := range part {
+				if err := os.Remove(file.path); err != nil && !os.IsNotExist(err) {
+					return report, fmt.Errorf("error removing compacted note: %w", err)
+				}
+			}
+			nm.record(journal.ActionDeleted, projectName, nm.GetMonitorNotesDir(projectName), fmt.Sprintf("compacted %d monitor notes of %s into digest %s", len(part), label, digest.ID))
+
+			report.Removed += len(part)
+			report.Digests = append(report.Digests, digest)
+		}
+		report.Days = append(report.Days, day)
+	}
+
+	return report, nil
+}
+
+// monitorNotesByDay groups a project's monitor notes by local day, keeping only days that ended before cutoff
+func (nm *NotesManager) monitorNotesByDay(projectName string, cutoff time.Time) (map[string][]monitorNoteFile, error) {
+	monitorDir := nm.GetMonitorNotesDir(projectName)
+	entries, err := os.ReadDir(monitorDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading monitor directory: %w", err)
+	}
+
+	days := make(map[string][]monitorNoteFile)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		path := filepath.Join(monitorDir, entry.Name())
+		data, err := nm.readNoteFile(path)
+		if err != nil {
+			continue
+		}
+
+		var note MonitorNote
+		if err := json.Unmarshal(data, &note); err != nil {
+			continue
+		}
+
+		// Compact whole days only, so a digest never covers part of a day
+		local := note.Timestamp.Local()
+		dayStart := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+		if dayStart.AddDate(0, 0, 1).After(cutoff) {
+			continue
+		}
+
+		day := dayStart.Format("2006-01-02")
+		days[day] = append(days[day], monitorNoteFile{path: path, note: &note})
+	}
+
+	for _, files := range days {
+		sort.Slice(files, func(i, j int) bool { return files[i].note.Timestamp.Before(files[j].note.Timestamp) })
+	}
+	return days, nil
+}
+
+// noteLines renders each monitor note as one prompt line, or as "" when it repeats the note before it
+func noteLines(files []monitorNoteFile) []string {
+	lines := make([]string, len(files))
+	var last string
+	for i, file := range files {
+		note := file.note
+		// Screenshots of an idle screen repeat the same note every 30 seconds
+		entry := fmt.Sprintf("User Request: %s | AI Action: %s | Context: %s",
+			note.Interaction.UserRequest, note.Interaction.AIAction, note.Interaction.Context)
+		if entry == last {
+			continue
+		}
+		last = entry
+
+		line := note.Timestamp.Local().Format("15:04") + " " + entry
+		if len(note.Interaction.CodeChanges) > 0 {
+			line += " | Code Changes: " + strings.Join(note.Interaction.CodeChanges, ", ")
+		}
+		if len(note.Interaction.AffectedFiles) > 0 {
+			line += " | Likely Affected: " + strings.Join(note.Interaction.AffectedFiles, ", ")
+		}
+		lines[i] = line
+	}
+	return lines
+}
+
+// digestParts splits a day's notes into runs whose lines fit within budget tokens, so every note
+// that is deleted was in a digest prompt. A single note over the budget gets a part of its own.
+func digestParts(files []monitorNoteFile, budget int) [][]monitorNoteFile {
+	var parts [][]monitorNoteFile
+	start, used := 0, 0
+	for i, line := range noteLines(files) {
+		if line == "" {
+			continue
+		}
+		// Account for the newline joining each line
+		size := tokens.Count(digestModel, line+"\n")
+		if used+size > budget && i > start {
+			parts = append(parts, files[start:i])
+			start, used = i, 0
+		}
+		used += size
+	}
+	return append(parts, files[start:])
+}
+
+// digestDay asks the model to summarize a day, or part of one, of monitor notes as a progress note
+func (nm *NotesManager) digestDay(client *openai.Client, projectName string, day string, files []monitorNoteFile) (*ProjectProgressNote, error) {
+	var lines []string
+	filesChanged := make(map[string]bool)
+	var renamed []FileRename
+	for i, line := range noteLines(files) {
+		for _, change := range files[i].note.Interaction.CodeChanges {
+			filesChanged[change] = true
+		}
+		renamed = append(renamed, files[i].note.Renames...)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	prompt := `You are compacting one day of monitor notes from a developer working with an AI coding assistant into a single digest.
+The digest replaces the raw notes, so keep everything that will matter for future summaries: what was built or changed,
+technical decisions and their reasons, problems hit and whether they were solved, and work left unfinished.
+Drop repetition and moment-to-moment detail.
+
+Monitor notes for ` + day + `:
+` + strings.Join(lines, "\n") + `
+
+Format your response as a JSON object with the following structure:
+{
+    "title": "short title for the day's work",
+    "summary": "one or two paragraphs describing the day's progress",
+    "decisions": ["technical decisions made"],
+    "open_issues": ["problems or work left unfinished"]
+}`
+
+	resp, err := client.CreateChatCompletion(
+		context.Background(),
+		openai.ChatCompletionRequest{
+			Model: digestModel,
+			Messages: []openai.ChatCompletionMessage{
+				{
+					Role:    openai.ChatMessageRoleUser,
+					Content: prompt,
+				},
+			},
+			ResponseFormat: &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject},
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error generating digest: %w", err)
+	}
+
+	var digest struct {
+		Title      string   `json:"title"`
+		Summary    string   `json:"summary"`
+		Decisions  []string `json:"decisions"`
+		OpenIssues []string `json:"open_issues"`
+	}
+	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &digest); err != nil {
+		return nil, fmt.Errorf("error parsing digest: %w", err)
+	}
+
+	description := digest.Summary
+	if len(digest.Decisions) > 0 {
+		description += "\n\nDecisions:\n- " + strings.Join(digest.Decisions, "\n- ")
+	}
+	if len(digest.OpenIssues) > 0 {
+		description += "\n\nOpen Issues:\n- " + strings.Join(digest.OpenIssues, "\n- ")
+	}
+
+	note := &ProjectProgressNote{
+		// Date the digest with the day's last note so range queries still find it on that day
+		Timestamp:   files[len(files)-1].note.Timestamp,
+		ID:          uuid.New().String(),
+		ProjectName: projectName,
+		Type:        digestType,
+		Title:       fmt.Sprintf("Digest for %s: %s", day, digest.Title),
+		Description: description,
+	}
+	for file := range filesChanged {
+		note.Changes.FilesModified = append(note.Changes.FilesModified, file)
+	}
+	sort.Strings(note.Changes.FilesModified)
+	// Digests replace the notes, so they keep the renames for history to follow files across moves
+	note.Changes.FilesRenamed = renamed
+
+	note.Impact.Scope = "project-wide"
+	note.Impact.RiskLevel = "low"
+	if len(digest.OpenIssues) > 0 {
+		note.Impact.RiskLevel = "medium"
+	}
+	note.Metadata.Tags = []string{digestType, fmt.Sprintf("compacted:%d", len(files))}
+	note.Metadata.Priority = PriorityLow
+	note.Metadata.Status = StatusArchived
+
+	return note, nil
+}
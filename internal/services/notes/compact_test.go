@@ -0,0 +1,85 @@
+package notes
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMonitorNotesByDayKeepsWholeDaysBeforeCutoff(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	nm, err := NewNotesManager()
+	if err != nil {
+		t.Fatalf("Failed to create notes manager: %v", err)
+	}
+
+	day := func(d int, hour int) time.Time {
+		return time.Date(2026, time.March, d, hour, 0, 0, 0, time.Local)
+	}
+	for _, ts := range []time.Time{day(1, 9), day(1, 17), day(2, 9), day(3, 9)} {
+		if err := nm.SaveMonitorNote("demo", &MonitorNote{Timestamp: ts}); err != nil {
+			t.Fatalf("Failed to save note: %v", err)
+		}
+	}
+
+	// March 2 is only half over at the cutoff, so only March 1 qualifies
+	days, err := nm.monitorNotesByDay("demo", day(2, 12))
+	if err != nil {
+		t.Fatalf("Grouping failed: %v", err)
+	}
+	if len(days) != 1 || len(days["2026-03-01"]) != 2 {
+		t.Fatalf("Expected two notes on 2026-03-01, got %v", days)
+	}
+	if !days["2026-03-01"][0].note.Timestamp.Before(days["2026-03-01"][1].note.Timestamp) {
+		t.Error("Expected notes sorted by time")
+	}
+}
+
+func TestDigestPartsCoverEveryNote(t *testing.T) {
+	var files []monitorNoteFile
+	for i, request := range []string{"add login", "add login", "fix tests", "write docs", "ship it"} {
+		note := &MonitorNote{Timestamp: time.Date(2026, time.March, 1, 9, i, 0, 0, time.Local)}
+		note.Interaction.UserRequest = request
+		files = append(files, monitorNoteFile{path: request, note: note})
+	}
+
+	// Each line is about 17 tokens, so a 40 token budget takes two at most
+	parts := digestParts(files, 40)
+	if len(parts) < 2 {
+		t.Fatalf("Expected the day to be split, got %d part", len(parts))
+	}
+	covered := 0
+	for _, part := range parts {
+		if len(part) == 0 {
+			t.Fatal("Expected no empty parts")
+		}
+		covered += len(part)
+	}
+	if covered != len(files) {
+		t.Errorf("Expected every note in a part, got %d of %d", covered, len(files))
+	}
+	// The repeated note goes with the one it repeats
+	if len(parts[0]) < 2 || parts[0][1].note.Interaction.UserRequest != "add login" {
+		t.Errorf("Expected the repeat in the first part, got %d notes", len(parts[0]))
+	}
+
+	if parts := digestParts(files, 100000); len(parts) != 1 || len(parts[0]) != len(files) {
+		t.Errorf("Expected one part within a large budget, got %d", len(parts))
+	}
+}
+
+func TestSaveProgressKeepsTimestamp(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	nm, err := NewNotesManager()
+	if err != nil {
+		t.Fatalf("Failed to create notes manager: %v", err)
+	}
+
+	day := time.Date(2026, time.March, 1, 17, 0, 0, 0, time.Local)
+	if err := nm.saveProgress(&ProjectProgressNote{ProjectName: "demo", Timestamp: day, Title: "Digest"}); err != nil {
+		t.Fatalf("Failed to save digest: %v", err)
+	}
+	notes, err := nm.GetProgressNotesBetween("demo", day.Add(-time.Hour), day.Add(time.Hour))
+	if err != nil || len(notes) != 1 || !notes[0].Timestamp.Equal(day) {
+		t.Errorf("Expected the digest on its own day, got %v, %v", notes, err)
+	}
+}
@@ -0,0 +1,227 @@
+package notes
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/utils/fsutil"
+)
+
+const (
+	// writersFileName records which wash versions have written to the data directory
+	writersFileName = "writers.json"
+
+	// conflictWindow is how recently another writer must have run to count as a conflict
+	conflictWindow = 30 * 24 * time.Hour
+
+	// legacyScanInterval is how often notes are checked for writers that predate the registry
+	legacyScanInterval = 24 * time.Hour
+
+	// maxLegacyExamples caps the legacy files kept in a conflict report
+	maxLegacyExamples = 5
+)
+
+// Writer is one wash version seen writing to the data directory
+type Writer struct {
+	Version       string    `json:"version"`
+	SchemaVersion int       `json:"schema_version"`
+	LastSeen      time.Time `json:"last_seen"`
+}
+
+// writerRegistry is the contents of writers.json
+type writerRegistry struct {
+	Writers  []Writer  `json:"writers"`
+	LastScan time.Time `json:"last_scan"`
+}
+
+// ConflictReport lists the other writers whose notes conflict with this version's layout
+type ConflictReport struct {
+	// Newer are versions writing a schema this version cannot read
+	Newer []Writer
+	// Older are versions writing a schema older than this version's
+	Older []Writer
+	// Legacy counts unversioned notes written since the last scan, by versions that predate the registry
+	Legacy int
+	// LegacyExamples are a few of those notes
+	LegacyExamples []string
+}
+
+// HasConflicts reports whether anything else is writing notes in another format
+func (r *ConflictReport) HasConflicts() bool {
+	return len(r.Newer) > 0 || len(r.Older) > 0 || r.Legacy > 0
+}
+
+// String describes the conflicts and how to resolve them
+func (r *ConflictReport) String() string {
+	var b strings.Builder
+	for _, w := range r.Newer {
+		fmt.Fprintf(&b, "  wash %s writes schema version %d (last seen %s); this version only reads up to %d. Upgrade this copy of wash.\n",
+			w.Version, w.SchemaVersion, w.LastSeen.Local().Format("2006-01-02"), CurrentSchemaVersion)
+	}
+	for _, w := range r.Older {
+		fmt.Fprintf(&b, "  wash %s writes schema version %d (last seen %s). Upgrade or remove it, then run 'wash migrate'.\n",
+			w.Version, w.SchemaVersion, w.LastSeen.Local().Format("2006-01-02"))
+	}
+	if r.Legacy > 0 {
+		fmt.Fprintf(&b, "  %d notes were recently written without a schema version by an old wash, e.g. %s. Remove the old copy, then run 'wash migrate'.\n",
+			r.Legacy, strings.Join(r.LegacyExamples, ", "))
+	}
+	return b.String()
+}
+
+// CheckWriters records that version is writing to the data directory and reports other versions
+// writing in a different format. Unversioned notes are looked for at most once a day.
+func (nm *NotesManager) CheckWriters(version string) (*ConflictReport, error) {
+	// Concurrent wash processes must not drop each other's registrations
+	unlock, err := fsutil.LockDir(nm.baseDir)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	registry, err := nm.loadWriters()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	report := &ConflictReport{}
+	seen := false
+	changed := false
+	for i, w := range registry.Writers {
+		if w.Version == version && w.SchemaVersion == CurrentSchemaVersion {
+			// Refreshing last_seen hourly is plenty for a 30-day window, and spares a write per command
+			if now.Sub(w.LastSeen) >= time.Hour {
+				registry.Writers[i].LastSeen = now
+				changed = true
+			}
+			seen = true
+			continue
+		}
+		if now.Sub(w.LastSeen) > conflictWindow {
+			continue
+		}
+		switch {
+		case w.SchemaVersion > CurrentSchemaVersion:
+			report.Newer = append(report.Newer, w)
+		case w.SchemaVersion < CurrentSchemaVersion:
+			report.Older = append(report.Older, w)
+		}
+	}
+	if !seen {
+		registry.Writers = append(registry.Writers, Writer{Version: version, SchemaVersion: CurrentSchemaVersion, LastSeen: now})
+		changed = true
+	}
+
+	if now.Sub(registry.LastScan) >= legacyScanInterval {
+		// The first scan only sets the baseline; notes already on disk are what 'wash migrate' is for
+		if !registry.LastScan.IsZero() {
+			if err := nm.scanLegacyNotes(registry.LastScan, report); err != nil {
+				return nil, err
+			}
+		}
+		registry.LastScan = now
+		changed = true
+	}
+
+	if changed {
+		if err := nm.saveWriters(registry); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// ForgetOlderWriters drops older writers from the registry once 'wash migrate' has upgraded their notes.
+// Newer writers are kept, since migrating cannot make their notes readable.
+func (nm *NotesManager) ForgetOlderWriters() error {
+	unlock, err := fsutil.LockDir(nm.baseDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	registry, err := nm.loadWriters()
+	if err != nil {
+		return err
+	}
+
+	var kept []Writer
+	for _, w := range registry.Writers {
+		if w.SchemaVersion >= CurrentSchemaVersion {
+			kept = append(kept, w)
+		}
+	}
+	registry.Writers = kept
+	registry.LastScan = time.Now()
+	return nm.saveWriters(registry)
+}
+
+// scanLegacyNotes counts notes modified after since that carry no schema version
+func (nm *NotesManager) scanLegacyNotes(since time.Time, report *ConflictReport) error {
+	files, err := nm.noteFiles()
+	if err != nil {
+		return err
+	}
+
+	var legacy []string
+	for _, paths := range files {
+		for _, path := range paths {
+			info, err := os.Stat(path)
+			if err != nil || !info.ModTime().After(since) {
+				continue
+			}
+			data, err := nm.readNoteFile(path)
+			if err != nil {
+				continue
+			}
+			var note map[string]interface{}
+			if json.Unmarshal(data, &note) == nil && schemaVersionOf(note) == 0 {
+				legacy = append(legacy, path)
+			}
+		}
+	}
+
+	sort.Strings(legacy)
+	report.Legacy = len(legacy)
+	for _, path := range legacy[:min(len(legacy), maxLegacyExamples)] {
+		if rel, err := filepath.Rel(nm.baseDir, path); err == nil {
+			path = rel
+		}
+		report.LegacyExamples = append(report.LegacyExamples, path)
+	}
+	return nil
+}
+
+// loadWriters reads writers.json, returning an empty registry when it does not exist yet
+func (nm *NotesManager) loadWriters() (*writerRegistry, error) {
+	registry := &writerRegistry{}
+	data, err := os.ReadFile(filepath.Join(nm.baseDir, writersFileName))
+	if os.IsNotExist(err) {
+		return registry, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading writer registry: %w", err)
+	}
+	if err := json.Unmarshal(data, registry); err != nil {
+		return nil, fmt.Errorf("error parsing writer registry: %w", err)
+	}
+	return registry, nil
+}
+
+// saveWriters writes writers.json
+func (nm *NotesManager) saveWriters(registry *writerRegistry) error {
+	data, err := json.MarshalIndent(registry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding writer registry: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(filepath.Join(nm.baseDir, writersFileName), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("error writing writer registry: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package notes
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCheckWritersReportsOtherVersions(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	nm, err := NewNotesManager()
+	if err != nil {
+		t.Fatalf("Failed to create notes manager: %v", err)
+	}
+
+	report, err := nm.CheckWriters("1.0.0")
+	if err != nil {
+		t.Fatalf("CheckWriters failed: %v", err)
+	}
+	if report.HasConflicts() {
+		t.Fatalf("Expected no conflicts on first run, got %+v", report)
+	}
+
+	// An older wash registered yesterday, and a pre-registry copy wrote an unversioned note since the last scan
+	registry, err := nm.loadWriters()
+	if err != nil {
+		t.Fatalf("loadWriters failed: %v", err)
+	}
+	registry.Writers = append(registry.Writers, Writer{Version: "0.9.0", SchemaVersion: CurrentSchemaVersion - 1, LastSeen: time.Now().Add(-24 * time.Hour)})
+	registry.LastScan = time.Now().Add(-2 * legacyScanInterval)
+	if err := nm.saveWriters(registry); err != nil {
+		t.Fatalf("saveWriters failed: %v", err)
+	}
+	legacy := filepath.Join(nm.baseDir, "monitor_notes", "app", "old.json")
+	if err := os.MkdirAll(filepath.Dir(legacy), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(legacy, []byte(`{"content": "old"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err = nm.CheckWriters("1.0.0")
+	if err != nil {
+		t.Fatalf("CheckWriters failed: %v", err)
+	}
+	if len(report.Older) != 1 || report.Older[0].Version != "0.9.0" {
+		t.Errorf("Expected the older writer to be reported, got %+v", report.Older)
+	}
+	if report.Legacy != 1 {
+		t.Errorf("Expected 1 legacy note, got %d", report.Legacy)
+	}
+
+	if err := nm.ForgetOlderWriters(); err != nil {
+		t.Fatalf("ForgetOlderWriters failed: %v", err)
+	}
+	if report, _ = nm.CheckWriters("1.0.0"); report.HasConflicts() {
+		t.Errorf("Expected no conflicts after migrating, got %+v", report)
+	}
+}
@@ -0,0 +1,113 @@
+package notes
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bkidd1/wash-cli/internal/utils/crypt"
+	"github.com/bkidd1/wash-cli/internal/utils/fsutil"
+)
+
+// ConvertEncryption rewrites existing notes, bug reports and their lessons, goals, plans, estimates,
+// findings, snapshots, handoffs, manifests, progress indexes, attachments, cached analyses, the
+// embedding store and agent queues so they are all encrypted, or with encrypt unset all decrypted,
+// returning how many files changed.
+// Encryption must be configured either way.
+func (nm *NotesManager) ConvertEncryption(encrypt bool) (int, error) {
+	if nm.cipher == nil {
+		return 0, fmt.Errorf("encryption is not configured; set 'encryption' in ~/.wash/wash.yaml")
+	}
+
+	files, err := nm.noteFiles()
+	if err != nil {
+		return 0, err
+	}
+
+	paths, err := nm.trashedFiles()
+	if err != nil {
+		return 0, err
+	}
+	for _, kindFiles := range files {
+		paths = append(paths, kindFiles...)
+	}
+	for _, pattern := range []string{
+		filepath.Join(nm.baseDir, "projects", "*", "bugs", "*.md"),
+		filepath.Join(nm.baseDir, "projects", "*", "bugs", archiveDir, "*.md"),
+		filepath.Join(nm.baseDir, "projects", "*", "lessons.json"),
+		filepath.Join(nm.baseDir, "projects", "*", "goal.json"),
+		filepath.Join(nm.baseDir, "projects", "*", "plans", "*.json"),
+		filepath.Join(nm.baseDir, "projects", "*", "estimates", "*.json"),
+		filepath.Join(nm.baseDir, "projects", "*", "findings.json"),
+		filepath.Join(nm.baseDir, "projects", "*", "snapshots", "*.json"),
+		filepath.Join(nm.baseDir, "projects", "*", "handoffs", "*.json"),
+		filepath.Join(nm.baseDir, "projects", "*", "manifest.json"),
+		filepath.Join(nm.baseDir, "retrieval", "embeddings.json"),
+		filepath.Join(nm.baseDir, "progress", "index", "*.json"),
+		filepath.Join(nm.baseDir, "attachments", "objects", "*", "*"),
+		filepath.Join(nm.baseDir, "screenshots", "screenshot-*"),
+		filepath.Join(nm.baseDir, "analyze", "*", "*.json"),
+		filepath.Join(nm.baseDir, "projects", "*", "agent_queue.json"),
+	} {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return 0, fmt.Errorf("error listing files to convert: %w", err)
+		}
+		paths = append(paths, matches...)
+	}
+
+	converted := 0
+	for _, path := range paths {
+		if filepath.Base(path)[0] == '.' {
+			continue
+		}
+
+		changed, err := nm.convertFile(path, encrypt)
+		if err != nil {
+			return converted, err
+		}
+		if changed {
+			converted++
+		}
+	}
+
+	return converted, nil
+}
+
+// convertFile encrypts or decrypts one file, reporting whether it changed. The file is read and
+// written under its directory lock, so a note saved meanwhile is not overwritten with stale content.
+func (nm *NotesManager) convertFile(path string, encrypt bool) (bool, error) {
+	unlock, err := fsutil.LockDir(filepath.Dir(path))
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, fmt.Errorf("error reading %s: %w", path, err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("error reading %s: %w", path, err)
+	}
+	if crypt.IsEncrypted(data) == encrypt {
+		return false, nil
+	}
+
+	data, err = nm.cipher.Decrypt(data)
+	if err != nil {
+		return false, fmt.Errorf("error decrypting %s: %w", path, err)
+	}
+	if encrypt {
+		if data, err = nm.cipher.Encrypt(data); err != nil {
+			return false, fmt.Errorf("error encrypting %s: %w", path, err)
+		}
+	}
+
+	// Keep the file's mode, so stores only the user may read stay that way
+	if err := fsutil.WriteFileAtomic(path, data, info.Mode().Perm()); err != nil {
+		return false, fmt.Errorf("error writing %s: %w", path, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,67 @@
+package notes
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/crypt"
+)
+
+func TestConvertEncryptionCoversProjectStores(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(config.DataDirEnv, t.TempDir())
+	t.Setenv("WASH_ENCRYPTION", crypt.SourcePassphrase)
+	t.Setenv(crypt.PassphraseEnv, "correct horse")
+
+	nm, err := NewNotesManager()
+	if err != nil {
+		t.Fatalf("Failed to create notes manager: %v", err)
+	}
+
+	// Files written before encryption was turned on
+	stores := []string{
+		filepath.Join("projects", "app", "bugs", "bug_2024-05-01-10-30-00.md"),
+		filepath.Join("projects", "app", "lessons.json"),
+		filepath.Join("projects", "app", "goal.json"),
+		filepath.Join("projects", "app", "plans", "abc123.json"),
+		filepath.Join("projects", "app", "findings.json"),
+		filepath.Join("projects", "app", "snapshots", "v1.json"),
+		filepath.Join("projects", "app", "handoffs", "2024-05-01-10-30-00.json"),
+		filepath.Join("projects", "app", "manifest.json"),
+		filepath.Join("retrieval", "embeddings.json"),
+		filepath.Join("screenshots", "screenshot-123.jpg"),
+	}
+	for _, rel := range stores {
+		path := filepath.Join(nm.baseDir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(`{"text":"password = hunter2"}`), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	converted, err := nm.ConvertEncryption(true)
+	if err != nil {
+		t.Fatalf("ConvertEncryption failed: %v", err)
+	}
+	if converted != len(stores) {
+		t.Errorf("Expected %d files converted, got %d", len(stores), converted)
+	}
+	for _, rel := range stores {
+		path := filepath.Join(nm.baseDir, rel)
+		data, err := os.ReadFile(path)
+		if err != nil || !crypt.IsEncrypted(data) {
+			t.Errorf("Expected %s to be encrypted (%v)", rel, err)
+		}
+		if info, err := os.Stat(path); err == nil && info.Mode().Perm() != 0600 {
+			t.Errorf("Expected %s to keep its mode, got %v", rel, info.Mode().Perm())
+		}
+	}
+
+	if converted, err = nm.ConvertEncryption(false); err != nil || converted != len(stores) {
+		t.Errorf("Expected %d files decrypted, got %d (%v)", len(stores), converted, err)
+	}
+}
@@ -0,0 +1,309 @@
+package notes
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/utils/language"
+)
+
+// Node labels of the knowledge graph
+const (
+	LabelProject  = "Project"
+	LabelFile     = "File"
+	LabelBug      = "Bug"
+	LabelDecision = "Decision"
+	LabelPerson   = "Person"
+	LabelError    = "Error"
+)
+
+// maxErrorLength caps the text of an error node
+const maxErrorLength = 160
+
+var (
+	// pathPattern finds file paths mentioned in free text; matches are kept only when they look like source files
+	pathPattern = regexp.MustCompile(`[\w.-]*(?:/[\w.-]+)*\.[A-Za-z]{1,5}\b`)
+
+	// errorPattern finds lines that report an error
+	errorPattern = regexp.MustCompile(`(?i)\b\w*(?:error|exception)\b|\bpanic:|\bfatal\b|segmentation fault|traceback`)
+
+	// volatilePattern matches the parts of an error message that differ between occurrences of the same error
+	volatilePattern = regexp.MustCompile(`0x[0-9a-fA-F]+|\d+`)
+)
+
+// GraphNode is one entity of the knowledge graph
+type GraphNode struct {
+	ID         string
+	Label      string
+	Name       string
+	Properties map[string]string
+}
+
+// GraphEdge is a relationship between two nodes; Weight counts how many notes record it
+type GraphEdge struct {
+	From   string
+	To     string
+	Type   string
+	Weight int
+}
+
+// Graph is the knowledge graph built from a project's notes
+type Graph struct {
+	Nodes []*GraphNode
+	Edges []*GraphEdge
+
+	nodes map[string]*GraphNode
+	edges map[string]*GraphEdge
+	// renames merge the history of a file across moves into the node of its latest path
+	renames RenameMap
+}
+
+// node adds a node, or returns the existing one with the same ID
+func (g *Graph) node(label, key, name string) *GraphNode {
+	id := strings.ToLower(label) + ":" + key
+	if n, ok := g.nodes[id]; ok {
+		return n
+	}
+	n := &GraphNode{ID: id, Label: label, Name: name, Properties: make(map[string]string)}
+	g.nodes[id] = n
+	g.Nodes = append(g.Nodes, n)
+	return n
+}
+
+// edge adds a relationship, or counts another occurrence of an existing one
+func (g *Graph) edge(from *GraphNode, relation string, to *GraphNode) {
+	key := from.ID + "\x00" + relation + "\x00" + to.ID
+	if e, ok := g.edges[key]; ok {
+		e.Weight++
+		return
+	}
+	e := &GraphEdge{From: from.ID, To: to.ID, Type: relation, Weight: 1}
+	g.edges[key] = e
+	g.Edges = append(g.Edges, e)
+}
+
+// fileNode adds a source file node that belongs to the project, under the file's latest path;
+// the paths it had before are kept in the previous_paths property
+func (g *Graph) fileNode(project *GraphNode, path string) *GraphNode {
+	path = cleanPath(path)
+	latest := g.renames.Resolve(path)
+	n := g.node(LabelFile, latest, latest)
+	if path != latest {
+		if !slices.Contains(strings.Split(n.Properties["previous_paths"], ";"), path) {
+			n.Properties["previous_paths"] = strings.TrimPrefix(n.Properties["previous_paths"]+";"+path, ";")
+		}
+	}
+	g.edge(n, "PART_OF", project)
+	return n
+}
+
+// errorNode adds an error node, grouping messages that differ only in numbers and addresses
+func (g *Graph) errorNode(project *GraphNode, message string) *GraphNode {
+	sum := sha1.Sum([]byte(strings.ToLower(volatilePattern.ReplaceAllString(message, "N"))))
+	n := g.node(LabelError, hex.EncodeToString(sum[:6]), message)
+	g.edge(n, "SEEN_IN", project)
+	return n
+}
+
+// mentionedFiles returns the source file paths mentioned in text
+func mentionedFiles(text string) []string {
+	var files []string
+	seen := make(map[string]bool)
+	for _, match := range pathPattern.FindAllString(text, -1) {
+		match = strings.TrimLeft(match, ".")
+		if match == "" || seen[match] || language.ForFile(match) == "" {
+			continue
+		}
+		seen[match] = true
+		files = append(files, match)
+	}
+	return files
+}
+
+// errorMessages returns the lines of text that report an error
+func errorMessages(text string) []string {
+	var messages []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "-*>#"))
+		if line == "" || !errorPattern.MatchString(line) {
+			continue
+		}
+		if runes := []rune(line); len(runes) > maxErrorLength {
+			line = string(runes[:maxErrorLength]) + "..."
+		}
+		messages = append(messages, line)
+	}
+	return messages
+}
+
+// BuildGraph builds a knowledge graph of a project from its notes: the files that changed, the bugs
+// reported, the decisions recorded in progress notes, the people who left remember notes and the
+// errors seen, with the relationships between them. Archived notes are included.
+func (nm *NotesManager) BuildGraph(projectName string) (*Graph, error) {
+	g := &Graph{nodes: make(map[string]*GraphNode), edges: make(map[string]*GraphEdge), renames: make(RenameMap)}
+	project := g.node(LabelProject, projectName, projectName)
+
+	files, err := nm.noteFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	// Renames are collected first, so every note's files are linked under their latest paths
+	var renames []FileRename
+	var progressNotes []*ProjectProgressNote
+	for _, path := range files[KindProgress] {
+		note := &ProjectProgressNote{}
+		if nm.decodeNote(path, note) && note.ProjectName == projectName {
+			progressNotes = append(progressNotes, note)
+			renames = append(renames, note.Changes.FilesRenamed...)
+		}
+	}
+	var monitorNotes []*MonitorNote
+	for _, path := range files[KindMonitor] {
+		note := &MonitorNote{}
+		if nm.decodeNote(path, note) && note.ProjectName == projectName {
+			monitorNotes = append(monitorNotes, note)
+			renames = append(renames, note.Renames...)
+		}
+	}
+	g.renames.Add(renames...)
+
+	// Progress notes are the decisions, with the files they changed
+	for _, note := range progressNotes {
+		decision := g.node(LabelDecision, note.ID, note.Title)
+		decision.Properties["type"] = note.Type
+		decision.Properties["timestamp"] = note.Timestamp.Format(time.RFC3339)
+		decision.Properties["risk"] = note.Impact.RiskLevel
+		g.edge(decision, "PART_OF", project)
+		for relation, paths := range map[string][]string{
+			"ADDED":    note.Changes.FilesAdded,
+			"MODIFIED": note.Changes.FilesModified,
+			"DELETED":  note.Changes.FilesDeleted,
+		} {
+			for _, path := range paths {
+				g.edge(decision, relation, g.fileNode(project, path))
+			}
+		}
+		for _, message := range errorMessages(note.Description) {
+			g.edge(decision, "ADDRESSES", g.errorNode(project, message))
+		}
+	}
+
+	// Interactions and monitor notes link the errors seen to the files being worked on
+	for _, path := range files[KindInteraction] {
+		var note Interaction
+		if !nm.decodeNote(path, &note) || note.ProjectName != projectName {
+			continue
+		}
+		g.linkErrors(project, note.Context.CurrentState, note.Context.FilesChanged)
+	}
+	for _, note := range monitorNotes {
+		text := strings.Join([]string{note.Interaction.UserRequest, note.Interaction.AIAction, note.Interaction.Context}, "\n")
+		g.linkErrors(project, text, mentionedFiles(strings.Join(append(note.Interaction.CodeChanges, text), "\n")))
+	}
+
+	// Bug reports, with the files and errors their description mentions
+	for _, archived := range []bool{false, true} {
+		entries, err := nm.ListEntries(projectName, KindBug, archived)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			data, err := nm.readNoteFile(entry.Path)
+			if err != nil {
+				continue
+			}
+			bug := g.node(LabelBug, strings.TrimSuffix(filepath.Base(entry.Path), ".md"), entry.Title)
+			bug.Properties["timestamp"] = entry.Timestamp.Format(time.RFC3339)
+			if archived {
+				bug.Properties["archived"] = "true"
+			}
+			g.edge(bug, "PART_OF", project)
+
+			description := bugDescription(data)
+			for _, path := range mentionedFiles(description) {
+				g.edge(bug, "MENTIONS", g.fileNode(project, path))
+			}
+			for _, message := range errorMessages(description) {
+				g.edge(bug, "REPORTS", g.errorNode(project, message))
+			}
+		}
+	}
+
+	// People are the authors of remember notes about the project
+	for _, path := range files[KindRemember] {
+		var note RememberNote
+		if !nm.decodeNote(path, &note) {
+			continue
+		}
+		if scope, _ := note.Metadata["project"].(string); scope != projectName {
+			continue
+		}
+		username := filepath.Base(filepath.Dir(path))
+		if username == archiveDir {
+			username = filepath.Base(filepath.Dir(filepath.Dir(path)))
+		}
+		person := g.node(LabelPerson, username, username)
+		g.edge(person, "CONTRIBUTES_TO", project)
+		for _, file := range mentionedFiles(note.Content) {
+			g.edge(person, "NOTED", g.fileNode(project, file))
+		}
+	}
+
+	sort.SliceStable(g.Nodes, func(i, j int) bool { return g.Nodes[i].ID < g.Nodes[j].ID })
+	sort.SliceStable(g.Edges, func(i, j int) bool {
+		a, b := g.Edges[i], g.Edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.To < b.To
+	})
+	return g, nil
+}
+
+// linkErrors adds the errors reported in text and links each to the files involved
+func (g *Graph) linkErrors(project *GraphNode, text string, paths []string) {
+	var fileNodes []*GraphNode
+	for _, path := range paths {
+		fileNodes = append(fileNodes, g.fileNode(project, path))
+	}
+	for _, message := range errorMessages(text) {
+		errNode := g.errorNode(project, message)
+		for _, file := range fileNodes {
+			g.edge(errNode, "AFFECTS", file)
+		}
+	}
+}
+
+// decodeNote reads a JSON note into v, reporting whether it could be read
+func (nm *NotesManager) decodeNote(path string, v interface{}) bool {
+	data, err := nm.readNoteFile(path)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, v) == nil
+}
+
+// bugDescription returns the description section of a bug report, or the whole report when it has none
+func bugDescription(data []byte) string {
+	text := string(data)
+	start := strings.Index(text, "## Description")
+	if start < 0 {
+		return text
+	}
+	text = text[start+len("## Description"):]
+	if end := strings.Index(text, "\n## "); end >= 0 {
+		text = text[:end]
+	}
+	return text
+}
@@ -0,0 +1,120 @@
+package notes
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// propertyKeys returns every node property name used in the graph, in order
+func (g *Graph) propertyKeys() []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, n := range g.Nodes {
+		for key := range n.Properties {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// WriteGraphML writes the graph as GraphML, which Gephi, yEd and Cytoscape open directly
+func (g *Graph) WriteGraphML(w io.Writer) error {
+	escape := func(s string) string {
+		var b strings.Builder
+		xml.EscapeText(&b, []byte(s))
+		return b.String()
+	}
+
+	keys := g.propertyKeys()
+	fmt.Fprintln(w, `<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintln(w, `<graphml xmlns="http://graphml.graphdrawing.org/xmlns">`)
+	fmt.Fprintln(w, `  <key id="label" for="node" attr.name="label" attr.type="string"/>`)
+	fmt.Fprintln(w, `  <key id="name" for="node" attr.name="name" attr.type="string"/>`)
+	for _, key := range keys {
+		fmt.Fprintf(w, "  <key id=\"p_%s\" for=\"node\" attr.name=\"%s\" attr.type=\"string\"/>\n", key, key)
+	}
+	fmt.Fprintln(w, `  <key id="type" for="edge" attr.name="type" attr.type="string"/>`)
+	fmt.Fprintln(w, `  <key id="weight" for="edge" attr.name="weight" attr.type="int"/>`)
+	fmt.Fprintln(w, `  <graph id="wash" edgedefault="directed">`)
+
+	for _, n := range g.Nodes {
+		fmt.Fprintf(w, "    <node id=\"%s\">\n", escape(n.ID))
+		fmt.Fprintf(w, "      <data key=\"label\">%s</data>\n", n.Label)
+		fmt.Fprintf(w, "      <data key=\"name\">%s</data>\n", escape(n.Name))
+		for _, key := range keys {
+			if value, ok := n.Properties[key]; ok && value != "" {
+				fmt.Fprintf(w, "      <data key=\"p_%s\">%s</data>\n", key, escape(value))
+			}
+		}
+		fmt.Fprintln(w, "    </node>")
+	}
+	for i, e := range g.Edges {
+		fmt.Fprintf(w, "    <edge id=\"e%d\" source=\"%s\" target=\"%s\">\n", i, escape(e.From), escape(e.To))
+		fmt.Fprintf(w, "      <data key=\"type\">%s</data>\n", e.Type)
+		fmt.Fprintf(w, "      <data key=\"weight\">%d</data>\n", e.Weight)
+		fmt.Fprintln(w, "    </edge>")
+	}
+
+	fmt.Fprintln(w, "  </graph>")
+	_, err := fmt.Fprintln(w, "</graphml>")
+	return err
+}
+
+// WriteNeo4jCSV writes the graph to nodes.csv and relationships.csv in dir, in the format
+// 'neo4j-admin database import full --nodes=nodes.csv --relationships=relationships.csv' reads
+func (g *Graph) WriteNeo4jCSV(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("error creating output directory: %w", err)
+	}
+
+	keys := g.propertyKeys()
+	header := append([]string{"id:ID", "name", ":LABEL"}, keys...)
+	var nodeRows [][]string
+	for _, n := range g.Nodes {
+		row := []string{n.ID, n.Name, n.Label}
+		for _, key := range keys {
+			row = append(row, n.Properties[key])
+		}
+		nodeRows = append(nodeRows, row)
+	}
+	if err := writeCSV(filepath.Join(dir, "nodes.csv"), header, nodeRows); err != nil {
+		return err
+	}
+
+	var edgeRows [][]string
+	for _, e := range g.Edges {
+		edgeRows = append(edgeRows, []string{e.From, e.To, e.Type, strconv.Itoa(e.Weight)})
+	}
+	return writeCSV(filepath.Join(dir, "relationships.csv"), []string{":START_ID", ":END_ID", ":TYPE", "weight:int"}, edgeRows)
+}
+
+// writeCSV writes a header and rows to a CSV file
+func writeCSV(path string, header []string, rows [][]string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating %s: %w", path, err)
+	}
+
+	writer := csv.NewWriter(file)
+	writer.Write(header)
+	writer.WriteAll(rows)
+	err = writer.Error()
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("error writing %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package notes
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildGraph(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	nm, err := NewNotesManager()
+	if err != nil {
+		t.Fatalf("Failed to create notes manager: %v", err)
+	}
+
+	progress := &ProjectProgressNote{ProjectName: "app", Title: "Switch to JWT"}
+	progress.Changes.FilesModified = []string{"auth/login.go"}
+	if err := nm.SaveProjectProgress(progress); err != nil {
+		t.Fatalf("SaveProjectProgress failed: %v", err)
+	}
+	for i, port := range []string{"8080", "9090"} {
+		interaction := &Interaction{Timestamp: time.Now().Add(time.Duration(i) * time.Second), ProjectName: "app"}
+		interaction.Context.CurrentState = "connection error: dial tcp :" + port
+		interaction.Context.FilesChanged = []string{"auth/login.go"}
+		if err := nm.SaveInteraction(interaction); err != nil {
+			t.Fatalf("SaveInteraction failed: %v", err)
+		}
+	}
+	if err := nm.SaveUserNote("alice", &RememberNote{Timestamp: time.Now(), Content: "Keep cmd/main.go thin", Metadata: map[string]interface{}{"project": "app"}}); err != nil {
+		t.Fatalf("SaveUserNote failed: %v", err)
+	}
+
+	g, err := nm.BuildGraph("app")
+	if err != nil {
+		t.Fatalf("BuildGraph failed: %v", err)
+	}
+
+	labels := make(map[string]int)
+	for _, n := range g.Nodes {
+		labels[n.Label]++
+	}
+	if labels[LabelDecision] != 1 || labels[LabelPerson] != 1 || labels[LabelFile] != 2 {
+		t.Errorf("Unexpected nodes: %v", labels)
+	}
+	if labels[LabelError] != 1 {
+		t.Errorf("Expected errors differing only in the port to be grouped, got %d error nodes", labels[LabelError])
+	}
+
+	var affects *GraphEdge
+	for _, e := range g.Edges {
+		if e.Type == "AFFECTS" {
+			affects = e
+		}
+	}
+	if affects == nil || affects.To != "file:auth/login.go" || affects.Weight != 2 {
+		t.Errorf("Expected the error to affect auth/login.go twice, got %+v", affects)
+	}
+}
+
+func TestBuildGraphFollowsRenames(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	nm, err := NewNotesManager()
+	if err != nil {
+		t.Fatalf("Failed to create notes manager: %v", err)
+	}
+
+	before := &ProjectProgressNote{ProjectName: "app", Title: "Add login", Timestamp: time.Now().Add(-time.Hour)}
+	before.Changes.FilesAdded = []string{"auth/login.go"}
+	if err := nm.SaveProjectProgress(before); err != nil {
+		t.Fatalf("SaveProjectProgress failed: %v", err)
+	}
+	moved := &ProjectProgressNote{ProjectName: "app", Title: "Move auth into internal"}
+	moved.Changes.FilesRenamed = []FileRename{{From: "auth", To: "internal/auth", At: time.Now()}}
+	if err := nm.SaveProjectProgress(moved); err != nil {
+		t.Fatalf("SaveProjectProgress failed: %v", err)
+	}
+
+	g, err := nm.BuildGraph("app")
+	if err != nil {
+		t.Fatalf("BuildGraph failed: %v", err)
+	}
+	for _, n := range g.Nodes {
+		if n.Label == LabelFile && n.ID != "file:internal/auth/login.go" {
+			t.Errorf("Expected the file to be linked under its latest path, got %s", n.ID)
+		}
+		if n.ID == "file:internal/auth/login.go" && n.Properties["previous_paths"] != "auth/login.go" {
+			t.Errorf("Expected the old path to be kept, got %q", n.Properties["previous_paths"])
+		}
+	}
+
+	// A file moved and moved back is at its original path again
+	renames := make(RenameMap)
+	renames.Add(FileRename{From: "a.go", To: "b.go", At: time.Now()}, FileRename{From: "b.go", To: "a.go", At: time.Now().Add(time.Second)})
+	if renames.Resolve("a.go") != "a.go" || renames.Resolve("b.go") != "a.go" {
+		t.Errorf("Expected both paths to resolve to a.go, got %s and %s", renames.Resolve("a.go"), renames.Resolve("b.go"))
+	}
+}
@@ -0,0 +1,92 @@
+package notes
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// noteFileTimeLayout is the local-time prefix of interaction and monitor note file names
+const noteFileTimeLayout = "2006-01-02-15-04-05"
+
+// TimeRange limits the notes read; a zero Since or Until leaves that end open
+type TimeRange struct {
+	Since time.Time
+	Until time.Time
+}
+
+// contains reports whether t falls within the range
+func (r TimeRange) contains(t time.Time) bool {
+	return (r.Since.IsZero() || !t.Before(r.Since)) && (r.Until.IsZero() || !t.After(r.Until))
+}
+
+// fileNameTime parses the timestamp a note file name starts with
+func fileNameTime(name string) (time.Time, bool) {
+	if len(name) < len(noteFileTimeLayout) {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation(noteFileTimeLayout, name[:len(noteFileTimeLayout)], time.Local)
+	return t, err == nil
+}
+
+// Interactions streams a project's interactions in the time range, oldest first. Files are chosen by the
+// timestamp in their names, so only files that can fall within the range are read and decoded.
+// A file that cannot be read yields a nil interaction and an error, and iteration continues.
+func (nm *NotesManager) Interactions(projectName string, r TimeRange) iter.Seq2[*Interaction, error] {
+	return func(yield func(*Interaction, error) bool) {
+		notesDir := filepath.Join(nm.baseDir, "projects", projectName, "notes")
+		entries, err := os.ReadDir(notesDir)
+		if os.IsNotExist(err) {
+			return
+		}
+		if err != nil {
+			yield(nil, fmt.Errorf("error reading notes directory: %w", err))
+			return
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+				continue
+			}
+
+			// Names have second resolution, so a file from the second before Since may still qualify
+			if named, ok := fileNameTime(entry.Name()); ok {
+				if !r.Since.IsZero() && named.Before(r.Since.Truncate(time.Second)) {
+					continue
+				}
+				// Entries are sorted by name, so every later file is newer still
+				if !r.Until.IsZero() && named.After(r.Until) {
+					return
+				}
+			}
+
+			interaction, err := nm.readInteraction(filepath.Join(notesDir, entry.Name()))
+			if err != nil {
+				if !yield(nil, fmt.Errorf("%s: %w", entry.Name(), err)) {
+					return
+				}
+				continue
+			}
+			if r.contains(interaction.Timestamp) && !yield(interaction, nil) {
+				return
+			}
+		}
+	}
+}
+
+// readInteraction reads and decodes one interaction file
+func (nm *NotesManager) readInteraction(path string) (*Interaction, error) {
+	data, err := nm.readNoteFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read file: %w", err)
+	}
+
+	var interaction Interaction
+	if err := json.Unmarshal(data, &interaction); err != nil {
+		return nil, fmt.Errorf("could not parse JSON: %w", err)
+	}
+	return &interaction, nil
+}
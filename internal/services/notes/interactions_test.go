@@ -0,0 +1,52 @@
+package notes
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestInteractionsTimeRange(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	nm, err := NewNotesManager()
+	if err != nil {
+		t.Fatalf("Failed to create notes manager: %v", err)
+	}
+
+	now := time.Now()
+	for _, age := range []time.Duration{48 * time.Hour, time.Hour, 2 * time.Minute, time.Minute} {
+		if err := nm.SaveInteraction(&Interaction{ProjectName: "app", Timestamp: now.Add(-age)}); err != nil {
+			t.Fatalf("SaveInteraction failed: %v", err)
+		}
+	}
+
+	// An old unreadable file is never opened when the range excludes it by name
+	old := now.Add(-72*time.Hour).Format(noteFileTimeLayout) + ".json"
+	if err := os.WriteFile(filepath.Join(nm.BaseDir(), "projects", "app", "notes", old), []byte("not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var recent []*Interaction
+	for interaction, err := range nm.Interactions("app", TimeRange{Since: now.Add(-5 * time.Minute)}) {
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		recent = append(recent, interaction)
+	}
+	if len(recent) != 2 || !recent[0].Timestamp.Before(recent[1].Timestamp) {
+		t.Errorf("Expected the 2 most recent interactions oldest first, got %d", len(recent))
+	}
+
+	count, failed := 0, 0
+	for _, err := range nm.Interactions("app", TimeRange{Until: now.Add(-30 * time.Minute)}) {
+		if err != nil {
+			failed++
+			continue
+		}
+		count++
+	}
+	if count != 2 || failed != 1 {
+		t.Errorf("Expected 2 old interactions and 1 unreadable file, got %d and %d", count, failed)
+	}
+}
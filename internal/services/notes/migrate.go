@@ -0,0 +1,213 @@
+package notes
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CurrentSchemaVersion is the schema version written with every new note
+const CurrentSchemaVersion = 1
+
+// NoteKind identifies which note schema a file uses
+type NoteKind string
+
+const (
+	KindInteraction NoteKind = "interaction"
+	KindMonitor     NoteKind = "monitor"
+	KindProgress    NoteKind = "progress"
+	KindRemember    NoteKind = "remember"
+)
+
+// migration upgrades a decoded note of one kind from version From to From+1
+type migration struct {
+	Kind  NoteKind
+	From  int
+	Apply func(note map[string]interface{}, path string) error
+}
+
+// migrations lists every schema upgrade in order. Add new steps here and bump CurrentSchemaVersion.
+var migrations = []migration{
+	{Kind: KindInteraction, From: 0, Apply: fillProjectNameFromDir},
+	{Kind: KindMonitor, From: 0, Apply: fillProjectNameFromDir},
+	{Kind: KindProgress, From: 0, Apply: fillProgressIDFromFile},
+	{Kind: KindRemember, From: 0, Apply: ensureRememberMetadata},
+}
+
+// fillProjectNameFromDir sets a missing project name from the note's project directory
+func fillProjectNameFromDir(note map[string]interface{}, path string) error {
+	if name, _ := note["project_name"].(string); name != "" {
+		return nil
+	}
+
+	// Interactions live in projects/<name>/notes, monitor notes in monitor_notes/<name>
+	dir := filepath.Dir(path)
+	if filepath.Base(dir) == archiveDir {
+		dir = filepath.Dir(dir)
+	}
+	if filepath.Base(dir) == "notes" {
+		dir = filepath.Dir(dir)
+	}
+	note["project_name"] = filepath.Base(dir)
+	return nil
+}
+
+// fillProgressIDFromFile sets a missing progress note ID from its <project>_<id>.json filename
+func fillProgressIDFromFile(note map[string]interface{}, path string) error {
+	if id, _ := note["id"].(string); id != "" {
+		return nil
+	}
+
+	name := strings.TrimSuffix(filepath.Base(path), ".json")
+	i := strings.LastIndex(name, "_")
+	if i < 0 {
+		return fmt.Errorf("cannot derive note ID from %s", filepath.Base(path))
+	}
+	note["id"] = name[i+1:]
+	return nil
+}
+
+// ensureRememberMetadata replaces missing remember note metadata with an empty object
+func ensureRememberMetadata(note map[string]interface{}, path string) error {
+	if _, ok := note["metadata"].(map[string]interface{}); !ok {
+		note["metadata"] = map[string]interface{}{}
+	}
+	return nil
+}
+
+// MigrationReport summarizes a migration run
+type MigrationReport struct {
+	Scanned  int
+	Migrated int
+	Skipped  []string // Files written by a newer version of wash
+	Failed   map[string]error
+}
+
+// migrateNote upgrades a decoded note to the current version, reporting whether anything changed
+func migrateNote(kind NoteKind, note map[string]interface{}, path string) (bool, error) {
+	version := schemaVersionOf(note)
+	if version > CurrentSchemaVersion {
+		return false, fmt.Errorf("schema version %d is newer than supported version %d", version, CurrentSchemaVersion)
+	}
+	if version == CurrentSchemaVersion {
+		return false, nil
+	}
+
+	for ; version < CurrentSchemaVersion; version++ {
+		for _, m := range migrations {
+			if m.Kind == kind && m.From == version {
+				if err := m.Apply(note, path); err != nil {
+					return false, fmt.Errorf("error migrating from version %d: %w", version, err)
+				}
+			}
+		}
+	}
+
+	note["schema_version"] = CurrentSchemaVersion
+	return true, nil
+}
+
+// schemaVersionOf returns a decoded note's schema version; notes written before versioning are version 0
+func schemaVersionOf(note map[string]interface{}) int {
+	switch version := note["schema_version"].(type) {
+	case float64:
+		return int(version)
+	case int:
+		return version
+	default:
+		return 0
+	}
+}
+
+// noteFiles lists the JSON files holding notes of each kind
+func (nm *NotesManager) noteFiles() (map[NoteKind][]string, error) {
+	globs := map[NoteKind]string{
+		KindInteraction: filepath.Join(nm.baseDir, "projects", "*", "notes", "*.json"),
+		KindMonitor:     filepath.Join(nm.baseDir, "monitor_notes", "*", "*.json"),
+		KindProgress:    filepath.Join(nm.baseDir, "progress", "*.json"),
+		KindRemember:    filepath.Join(nm.baseDir, "remember", "*", "*.json"),
+	}
+
+	files := make(map[NoteKind][]string)
+	for kind, pattern := range globs {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("error listing %s notes: %w", kind, err)
+		}
+		// Archived notes sit in an archive directory beside the live ones
+		archived, err := filepath.Glob(filepath.Join(filepath.Dir(pattern), archiveDir, filepath.Base(pattern)))
+		if err != nil {
+			return nil, fmt.Errorf("error listing archived %s notes: %w", kind, err)
+		}
+		files[kind] = append(matches, archived...)
+	}
+	return files, nil
+}
+
+// Migrate upgrades every note file under ~/.wash to the current schema version in place.
+// With dryRun set, it reports what would change without writing.
+func (nm *NotesManager) Migrate(dryRun bool) (*MigrationReport, error) {
+	files, err := nm.noteFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &MigrationReport{Failed: make(map[string]error)}
+	progressChanged := false
+
+	for _, kind := range []NoteKind{KindInteraction, KindMonitor, KindProgress, KindRemember} {
+		for _, path := range files[kind] {
+			report.Scanned++
+
+			data, err := nm.readNoteFile(path)
+			if err != nil {
+				report.Failed[path] = err
+				continue
+			}
+
+			var note map[string]interface{}
+			if err := json.Unmarshal(data, &note); err != nil {
+				report.Failed[path] = fmt.Errorf("error parsing note: %w", err)
+				continue
+			}
+
+			if schemaVersionOf(note) > CurrentSchemaVersion {
+				report.Skipped = append(report.Skipped, path)
+				continue
+			}
+
+			changed, err := migrateNote(kind, note, path)
+			if err != nil {
+				report.Failed[path] = err
+				continue
+			}
+			if !changed {
+				continue
+			}
+
+			report.Migrated++
+			if dryRun {
+				continue
+			}
+
+			if err := nm.writeNoteFile(path, note); err != nil {
+				report.Failed[path] = err
+				continue
+			}
+			if kind == KindProgress {
+				progressChanged = true
+			}
+		}
+	}
+
+	// Progress note IDs may have changed, so let the indexes rebuild on next use
+	if progressChanged {
+		if err := os.RemoveAll(filepath.Join(nm.baseDir, "progress", "index")); err != nil {
+			return report, fmt.Errorf("error resetting progress index: %w", err)
+		}
+	}
+
+	return report, nil
+}
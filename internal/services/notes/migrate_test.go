@@ -0,0 +1,57 @@
+package notes
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrateNote(t *testing.T) {
+	note := map[string]interface{}{"title": "old note"}
+	path := filepath.Join("progress", "demo_1234.json")
+
+	changed, err := migrateNote(KindProgress, note, path)
+	if err != nil {
+		t.Fatalf("Migration failed: %v", err)
+	}
+	if !changed {
+		t.Fatal("Expected an unversioned note to be migrated")
+	}
+	if note["id"] != "1234" {
+		t.Errorf("Expected ID from filename, got %v", note["id"])
+	}
+	if schemaVersionOf(note) != CurrentSchemaVersion {
+		t.Errorf("Expected schema version %d, got %d", CurrentSchemaVersion, schemaVersionOf(note))
+	}
+
+	if changed, err := migrateNote(KindProgress, note, path); err != nil || changed {
+		t.Errorf("Expected a current note to be left alone, got changed=%v err=%v", changed, err)
+	}
+}
+
+func TestMigrateSkipsNewerNotes(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	nm, err := NewNotesManager()
+	if err != nil {
+		t.Fatalf("Failed to create notes manager: %v", err)
+	}
+
+	dir := filepath.Join(nm.baseDir, "monitor_notes", "demo")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"schema_version": 99}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "b.json"), []byte(`{"interaction": {}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := nm.Migrate(false)
+	if err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if report.Migrated != 1 || len(report.Skipped) != 1 {
+		t.Errorf("Expected 1 migrated and 1 skipped, got %d and %d", report.Migrated, len(report.Skipped))
+	}
+}
@@ -0,0 +1,185 @@
+package notes
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// DefaultMonitorBatchSize is how many monitor notes are buffered before a flush when no size is configured
+const DefaultMonitorBatchSize = 10
+
+// monitorWALName is the write-ahead log of buffered monitor notes, kept in the monitor_notes directory
+const monitorWALName = "pending.wal"
+
+// walRecord is one buffered monitor note as written to the write-ahead log
+type walRecord struct {
+	Project string       `json:"project"`
+	Note    *MonitorNote `json:"note"`
+}
+
+// MonitorNoteBuffer holds monitor notes in memory and writes them to their note files in batches.
+// Every buffered note is first appended to a write-ahead log without fsync, so a crashed wash loses
+// nothing: the log is replayed by the next buffer. A power loss can drop the unflushed batch; a batch
+// size of 1 writes each note straight to its file instead.
+type MonitorNoteBuffer struct {
+	notesManager *NotesManager
+	batchSize    int
+	walPath      string
+
+	mu      sync.Mutex
+	pending []walRecord
+	wal     *os.File
+}
+
+// NewMonitorNoteBuffer creates a buffer that flushes every batchSize notes; zero uses the default.
+// Notes left in the write-ahead log by a previous run are written to their files first.
+func NewMonitorNoteBuffer(nm *NotesManager, batchSize int) (*MonitorNoteBuffer, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultMonitorBatchSize
+	}
+
+	b := &MonitorNoteBuffer{
+		notesManager: nm,
+		batchSize:    batchSize,
+		walPath:      filepath.Join(nm.baseDir, "monitor_notes", monitorWALName),
+	}
+	if err := b.recover(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// recover replays the write-ahead log left by a run that did not flush, then removes it
+func (b *MonitorNoteBuffer) recover() error {
+	file, err := os.Open(b.walPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error opening monitor note log: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+	for scanner.Scan() {
+		record, err := b.decode(scanner.Bytes())
+		if err != nil {
+			// A record cut short by the crash is the only one that can be damaged
+			fmt.Printf("Warning: skipping damaged monitor note log entry: %v\n", err)
+			continue
+		}
+		b.pending = append(b.pending, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading monitor note log: %w", err)
+	}
+
+	return b.flushLocked()
+}
+
+// encode turns a record into one log line, encrypted when notes are encrypted at rest
+func (b *MonitorNoteBuffer) encode(record walRecord) ([]byte, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding monitor note: %w", err)
+	}
+	data, err = b.notesManager.cipher.Encrypt(data)
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting monitor note: %w", err)
+	}
+	return append([]byte(base64.StdEncoding.EncodeToString(data)), '\n'), nil
+}
+
+// decode reverses encode for one log line
+func (b *MonitorNoteBuffer) decode(line []byte) (walRecord, error) {
+	var record walRecord
+	data, err := base64.StdEncoding.DecodeString(string(line))
+	if err != nil {
+		return record, err
+	}
+	data, err = b.notesManager.cipher.Decrypt(data)
+	if err != nil {
+		return record, err
+	}
+	if err := json.Unmarshal(data, &record); err != nil {
+		return record, err
+	}
+	if record.Note == nil {
+		return record, fmt.Errorf("entry has no note")
+	}
+	return record, nil
+}
+
+// Add buffers a monitor note, flushing once a full batch is waiting
+func (b *MonitorNoteBuffer) Add(projectName string, note *MonitorNote) error {
+	if b.batchSize == 1 {
+		return b.notesManager.SaveMonitorNote(projectName, note)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	record := walRecord{Project: projectName, Note: note}
+	line, err := b.encode(record)
+	if err != nil {
+		return err
+	}
+
+	if b.wal == nil {
+		b.wal, err = os.OpenFile(b.walPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return fmt.Errorf("error opening monitor note log: %w", err)
+		}
+	}
+	if _, err := b.wal.Write(line); err != nil {
+		return fmt.Errorf("error writing monitor note log: %w", err)
+	}
+
+	b.pending = append(b.pending, record)
+	if len(b.pending) < b.batchSize {
+		return nil
+	}
+	return b.flushLocked()
+}
+
+// Pending returns the number of notes waiting to be flushed
+func (b *MonitorNoteBuffer) Pending() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.pending)
+}
+
+// Flush writes every buffered note to its file and clears the write-ahead log
+func (b *MonitorNoteBuffer) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.flushLocked()
+}
+
+// flushLocked writes the buffered notes; the log is cleared only once all of them are saved
+func (b *MonitorNoteBuffer) flushLocked() error {
+	for len(b.pending) > 0 {
+		record := b.pending[0]
+		if err := b.notesManager.SaveMonitorNote(record.Project, record.Note); err != nil {
+			return err
+		}
+		b.pending = b.pending[1:]
+	}
+
+	if b.wal != nil {
+		if err := b.wal.Close(); err != nil {
+			return fmt.Errorf("error closing monitor note log: %w", err)
+		}
+		b.wal = nil
+	}
+	if err := os.Remove(b.walPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error clearing monitor note log: %w", err)
+	}
+	return nil
+}
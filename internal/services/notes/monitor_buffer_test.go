@@ -0,0 +1,54 @@
+package notes
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMonitorNoteBufferRecoversLog(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	nm, err := NewNotesManager()
+	if err != nil {
+		t.Fatalf("Failed to create notes manager: %v", err)
+	}
+
+	buffer, err := NewMonitorNoteBuffer(nm, 3)
+	if err != nil {
+		t.Fatalf("Failed to create buffer: %v", err)
+	}
+	start := time.Now().Add(-time.Hour)
+	for i := 0; i < 2; i++ {
+		note := &MonitorNote{Timestamp: start.Add(time.Duration(i) * time.Minute)}
+		if err := buffer.Add("demo", note); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+
+	noteFiles := func() []string {
+		files, _ := filepath.Glob(filepath.Join(nm.GetMonitorNotesDir("demo"), "*.json"))
+		return files
+	}
+	if files := noteFiles(); len(files) != 0 || buffer.Pending() != 2 {
+		t.Fatalf("Notes written before the batch filled: %v", files)
+	}
+
+	// A new buffer, as after a crash, saves what the log holds
+	if _, err := NewMonitorNoteBuffer(nm, 3); err != nil {
+		t.Fatalf("Failed to recover buffer: %v", err)
+	}
+	if files := noteFiles(); len(files) != 2 {
+		t.Fatalf("Recovered %d notes, want 2", len(files))
+	}
+
+	immediate, err := NewMonitorNoteBuffer(nm, 1)
+	if err != nil {
+		t.Fatalf("Failed to create buffer: %v", err)
+	}
+	if err := immediate.Add("demo", &MonitorNote{Timestamp: start.Add(10 * time.Minute)}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if files := noteFiles(); len(files) != 3 {
+		t.Errorf("Batch size 1 should write immediately, have %d notes", len(files))
+	}
+}
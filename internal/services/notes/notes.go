@@ -9,7 +9,12 @@ import (
 	"strings"
 	"time"
 
+	"github.com/bkidd1/wash-cli/internal/services/attachments"
+	"github.com/bkidd1/wash-cli/internal/services/journal"
+	"github.com/bkidd1/wash-cli/internal/services/usage"
 	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/crypt"
+	"github.com/bkidd1/wash-cli/internal/utils/fsutil"
 	"github.com/google/uuid"
 	"github.com/sashabaranov/go-openai"
 )
@@ -60,10 +65,11 @@ type MonitorAnalysis struct {
 
 // Interaction represents a single interaction between user and AI
 type Interaction struct {
-	Timestamp   time.Time `json:"timestamp"`
-	ProjectName string    `json:"project_name"`
-	ProjectGoal string    `json:"project_goal"`
-	Context     struct {
+	SchemaVersion int       `json:"schema_version"`
+	Timestamp     time.Time `json:"timestamp"`
+	ProjectName   string    `json:"project_name"`
+	ProjectGoal   string    `json:"project_goal"`
+	Context       struct {
 		CurrentState string   `json:"current_state"`
 		FilesChanged []string `json:"files_changed,omitempty"`
 	} `json:"context"`
@@ -80,28 +86,42 @@ type Interaction struct {
 
 // MonitorNote represents a note from wash monitor
 type MonitorNote struct {
-	Timestamp   time.Time `json:"timestamp"`
-	ProjectName string    `json:"project_name"`
+	SchemaVersion int       `json:"schema_version"`
+	Timestamp     time.Time `json:"timestamp"`
+	ProjectName   string    `json:"project_name"`
+	// Application is the editor or terminal multiplexer the interaction was observed in
+	Application string `json:"application,omitempty"`
 	Interaction struct {
 		UserRequest string   `json:"user_request"`
 		AIAction    string   `json:"ai_action"`
 		Context     string   `json:"context"`
 		CodeChanges []string `json:"code_changes"`
+		// AffectedFiles are files that import the changed code and may need changes too
+		AffectedFiles []string `json:"affected_files,omitempty"`
 	} `json:"interaction"`
+	// Renames are the files moved in this activity, in the order they were moved
+	Renames  []FileRename `json:"renames,omitempty"`
+	Metadata struct {
+		Tags []string `json:"tags,omitempty"`
+	} `json:"metadata"`
+	Attachments []attachments.Attachment `json:"attachments,omitempty"`
 }
 
 // ProjectProgressNote represents significant project progress and milestones
 type ProjectProgressNote struct {
-	Timestamp   time.Time `json:"timestamp"`
-	ID          string    `json:"id"`
-	ProjectName string    `json:"project_name"`
-	Type        string    `json:"type"` // e.g., "milestone", "architecture", "feature", "refactor"
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Changes     struct {
+	SchemaVersion int       `json:"schema_version"`
+	Timestamp     time.Time `json:"timestamp"`
+	ID            string    `json:"id"`
+	ProjectName   string    `json:"project_name"`
+	Type          string    `json:"type"` // e.g., "milestone", "architecture", "feature", "refactor"
+	Title         string    `json:"title"`
+	Description   string    `json:"description"`
+	Changes       struct {
 		FilesModified []string `json:"files_modified,omitempty"`
 		FilesAdded    []string `json:"files_added,omitempty"`
 		FilesDeleted  []string `json:"files_deleted,omitempty"`
+		// FilesRenamed are files moved rather than added and deleted, in the order they were moved
+		FilesRenamed []FileRename `json:"files_renamed,omitempty"`
 	} `json:"changes"`
 	Impact struct {
 		Scope         string   `json:"scope"` // e.g., "local", "module", "project-wide"
@@ -113,28 +133,32 @@ type ProjectProgressNote struct {
 		Priority Priority `json:"priority,omitempty"`
 		Status   Status   `json:"status,omitempty"`
 	} `json:"metadata"`
+	Attachments []attachments.Attachment `json:"attachments,omitempty"`
 }
 
 // RememberNote represents a user-created note from wash remember
 type RememberNote struct {
-	Timestamp time.Time              `json:"timestamp"`
-	Content   string                 `json:"content"`
-	Metadata  map[string]interface{} `json:"metadata"`
+	ID            string                 `json:"-"` // Taken from the file name
+	SchemaVersion int                    `json:"schema_version"`
+	Timestamp     time.Time              `json:"timestamp"`
+	Content       string                 `json:"content"`
+	Metadata      map[string]interface{} `json:"metadata"`
 }
 
 // NotesManager handles all Wash notes operations
 type NotesManager struct {
 	baseDir string
+	cipher  *crypt.Cipher // nil when encryption at rest is off
 }
 
 // NewNotesManager creates a new NotesManager instance
 func NewNotesManager() (*NotesManager, error) {
-	homeDir, err := os.UserHomeDir()
+	dataDir, err := config.DataDir()
 	if err != nil {
-		return nil, fmt.Errorf("error getting home directory: %w", err)
+		return nil, err
 	}
 
-	baseDir := filepath.Join(homeDir, ".wash")
+	baseDir := dataDir
 	if err := os.MkdirAll(baseDir, 0755); err != nil {
 		return nil, fmt.Errorf("error creating .wash directory: %w", err)
 	}
@@ -155,7 +179,48 @@ func NewNotesManager() (*NotesManager, error) {
 		}
 	}
 
-	return &NotesManager{baseDir: baseDir}, nil
+	// Notes are encrypted at rest when the config names a key source
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	cipher, err := crypt.Load(cfg.Encryption, baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unlock encrypted notes: %w", err)
+	}
+
+	return &NotesManager{baseDir: baseDir, cipher: cipher}, nil
+}
+
+// writeNoteFile encodes v as indented JSON and writes it atomically while holding the directory lock,
+// so concurrent wash processes never leave or read a partially written note.
+func (nm *NotesManager) writeNoteFile(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding note: %w", err)
+	}
+
+	data, err = nm.cipher.Encrypt(append(data, '\n'))
+	if err != nil {
+		return fmt.Errorf("error encrypting note: %w", err)
+	}
+
+	unlock, err := fsutil.LockDir(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return fsutil.WriteFileAtomic(path, data, 0644)
+}
+
+// readNoteFile reads a note file, decrypting it if it was written encrypted
+func (nm *NotesManager) readNoteFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return nm.cipher.Decrypt(data)
 }
 
 // SaveInteraction saves a new interaction
@@ -167,7 +232,7 @@ func (nm *NotesManager) SaveInteraction(interaction *Interaction) error {
 	}
 
 	// Generate filename with timestamp
-	filename := fmt.Sprintf("%s.json", interaction.Timestamp.Format("2006-01-02-15-04-05"))
+	filename := fmt.Sprintf("%s.json", interaction.Timestamp.Local().Format(noteFileTimeLayout))
 	notesDir := filepath.Join(projectDir, "notes")
 	filepath := filepath.Join(notesDir, filename)
 
@@ -177,17 +242,11 @@ func (nm *NotesManager) SaveInteraction(interaction *Interaction) error {
 	}
 
 	// Save interaction to file
-	file, err := os.Create(filepath)
-	if err != nil {
-		return fmt.Errorf("error creating interaction file: %w", err)
-	}
-	defer file.Close()
-
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(interaction); err != nil {
-		return fmt.Errorf("error encoding interaction: %w", err)
+	interaction.SchemaVersion = CurrentSchemaVersion
+	if err := nm.writeNoteFile(filepath, interaction); err != nil {
+		return fmt.Errorf("error saving interaction: %w", err)
 	}
+	nm.record(journal.ActionSaved, interaction.ProjectName, filepath, "interaction")
 
 	return nil
 }
@@ -200,74 +259,45 @@ func (nm *NotesManager) SaveUserNote(username string, note *RememberNote) error
 	}
 
 	// Generate filename with timestamp
-	filename := fmt.Sprintf("%s_%s.json", note.Timestamp.Format("2006-01-02-15-04-05"), uuid.New().String())
+	note.ID = uuid.New().String()
+	filename := fmt.Sprintf("%s_%s.json", note.Timestamp.Format("2006-01-02-15-04-05"), note.ID)
 	filepath := filepath.Join(userDir, filename)
 
-	file, err := os.Create(filepath)
-	if err != nil {
-		return fmt.Errorf("error creating note file: %w", err)
-	}
-	defer file.Close()
-
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(note); err != nil {
-		return fmt.Errorf("error encoding note: %w", err)
+	note.SchemaVersion = CurrentSchemaVersion
+	if err := nm.writeNoteFile(filepath, note); err != nil {
+		return fmt.Errorf("error saving note: %w", err)
 	}
+	nm.record(journal.ActionSaved, note.Project(), filepath, "remember note")
 
 	return nil
 }
 
 // LoadInteractions loads all interactions for a project
 func (nm *NotesManager) LoadInteractions(projectName string) ([]*Interaction, error) {
-	projectDir := filepath.Join(nm.baseDir, "projects", projectName, "notes")
+	return nm.QueryInteractions(projectName, nil)
+}
 
-	if _, err := os.Stat(projectDir); os.IsNotExist(err) {
-		return nil, nil
+// criteriaRange returns the time range named by the since and until criteria
+func criteriaRange(criteria map[string]interface{}) TimeRange {
+	var r TimeRange
+	if since, ok := criteria["since"].(time.Time); ok {
+		r.Since = since
 	}
-
-	files, err := os.ReadDir(projectDir)
-	if err != nil {
-		return nil, fmt.Errorf("error reading notes directory: %w", err)
-	}
-
-	var interactions []*Interaction
-	for _, file := range files {
-		// Skip non-JSON files
-		if filepath.Ext(file.Name()) != ".json" {
-			continue
-		}
-
-		filepath := filepath.Join(projectDir, file.Name())
-		data, err := os.ReadFile(filepath)
-		if err != nil {
-			// Log error but continue with other files
-			fmt.Printf("Warning: Could not read file %s: %v\n", file.Name(), err)
-			continue
-		}
-
-		var interaction Interaction
-		if err := json.Unmarshal(data, &interaction); err != nil {
-			// Log error but continue with other files
-			fmt.Printf("Warning: Could not parse JSON in file %s: %v\n", file.Name(), err)
-			continue
-		}
-
-		interactions = append(interactions, &interaction)
+	if until, ok := criteria["until"].(time.Time); ok {
+		r.Until = until
 	}
-
-	return interactions, nil
+	return r
 }
 
-// QueryInteractions queries interactions based on criteria
+// QueryInteractions queries interactions based on criteria: priority, status, tag, and since and until times
 func (nm *NotesManager) QueryInteractions(projectName string, criteria map[string]interface{}) ([]*Interaction, error) {
-	interactions, err := nm.LoadInteractions(projectName)
-	if err != nil {
-		return nil, err
-	}
-
 	var filtered []*Interaction
-	for _, interaction := range interactions {
+	for interaction, err := range nm.Interactions(projectName, criteriaRange(criteria)) {
+		if err != nil {
+			// Warn on stderr, where it cannot corrupt JSON output, and continue with other files
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			continue
+		}
 		if matchesCriteria(interaction, criteria) {
 			filtered = append(filtered, interaction)
 		}
@@ -308,6 +338,12 @@ func matchesCriteria(interaction *Interaction, criteria map[string]interface{})
 // SaveProjectProgress saves a project progress note
 func (nm *NotesManager) SaveProjectProgress(note *ProjectProgressNote) error {
 	note.Timestamp = time.Now()
+	return nm.saveProgress(note)
+}
+
+// saveProgress saves a progress note under a new ID, keeping its timestamp
+func (nm *NotesManager) saveProgress(note *ProjectProgressNote) error {
+	note.SchemaVersion = CurrentSchemaVersion
 	note.ID = uuid.New().String()
 
 	// Create the progress directory if it doesn't exist
@@ -317,14 +353,15 @@ func (nm *NotesManager) SaveProjectProgress(note *ProjectProgressNote) error {
 	}
 
 	// Create a file for the note
-	noteFile := filepath.Join(progressDir, fmt.Sprintf("%s_%s.json", note.ProjectName, note.ID))
-	data, err := json.MarshalIndent(note, "", "  ")
-	if err != nil {
-		return fmt.Errorf("error marshaling note: %w", err)
+	fileName := fmt.Sprintf("%s_%s.json", note.ProjectName, note.ID)
+	if err := nm.writeNoteFile(filepath.Join(progressDir, fileName), note); err != nil {
+		return fmt.Errorf("error saving note: %w", err)
 	}
+	nm.record(journal.ActionSaved, note.ProjectName, filepath.Join(progressDir, fileName), "progress note: "+note.Title)
 
-	if err := os.WriteFile(noteFile, data, 0644); err != nil {
-		return fmt.Errorf("error writing note file: %w", err)
+	// Keep the project's index current so listing doesn't scan the directory
+	if err := nm.addToProgressIndex(note, fileName); err != nil {
+		return fmt.Errorf("error updating progress index: %w", err)
 	}
 
 	return nil
@@ -332,37 +369,14 @@ func (nm *NotesManager) SaveProjectProgress(note *ProjectProgressNote) error {
 
 // LoadProjectProgress loads all project progress notes for a given project
 func (nm *NotesManager) LoadProjectProgress(projectName string) ([]*ProjectProgressNote, error) {
-	progressDir := filepath.Join(nm.baseDir, "progress")
-	files, err := os.ReadDir(progressDir)
-	if err != nil {
-		return nil, fmt.Errorf("error reading progress directory: %w", err)
-	}
-
-	var notes []*ProjectProgressNote
-	for _, file := range files {
-		if !strings.HasPrefix(file.Name(), projectName+"_") {
-			continue
-		}
-
-		data, err := os.ReadFile(filepath.Join(progressDir, file.Name()))
-		if err != nil {
-			return nil, fmt.Errorf("error reading note file: %w", err)
-		}
-
-		var note ProjectProgressNote
-		if err := json.Unmarshal(data, &note); err != nil {
-			return nil, fmt.Errorf("error unmarshaling note: %w", err)
-		}
-
-		notes = append(notes, &note)
-	}
-
-	return notes, nil
+	return nm.GetProgressNotesBetween(projectName, time.Time{}, time.Time{})
 }
 
-// QueryProjectProgress queries project progress notes based on criteria
+// QueryProjectProgress queries project progress notes based on criteria: type, priority, status, tag,
+// and since and until times
 func (nm *NotesManager) QueryProjectProgress(projectName string, criteria map[string]interface{}) ([]*ProjectProgressNote, error) {
-	notes, err := nm.LoadProjectProgress(projectName)
+	r := criteriaRange(criteria)
+	notes, err := nm.GetProgressNotesBetween(projectName, r.Since, r.Until)
 	if err != nil {
 		return nil, err
 	}
@@ -428,7 +442,7 @@ func (nm *NotesManager) GenerateProgressFromMonitor(projectName string, duration
 			continue
 		}
 
-		data, err := os.ReadFile(filepath.Join(monitorDir, file.Name()))
+		data, err := nm.readNoteFile(filepath.Join(monitorDir, file.Name()))
 		if err != nil {
 			continue
 		}
@@ -466,6 +480,9 @@ func (nm *NotesManager) GenerateProgressFromMonitor(projectName string, duration
 		if len(note.Interaction.CodeChanges) > 0 {
 			monitorData.WriteString(fmt.Sprintf("Code Changes: %s\n", strings.Join(note.Interaction.CodeChanges, ", ")))
 		}
+		if len(note.Interaction.AffectedFiles) > 0 {
+			monitorData.WriteString(fmt.Sprintf("Likely Affected: %s\n", strings.Join(note.Interaction.AffectedFiles, ", ")))
+		}
 		monitorData.WriteString("\n")
 	}
 
@@ -476,7 +493,7 @@ func (nm *NotesManager) GenerateProgressFromMonitor(projectName string, duration
 	}
 
 	// Create API client with config key
-	client := openai.NewClient(cfg.OpenAIKey)
+	client := usage.NewClient(cfg.OpenAIKey)
 
 	// Create the analysis prompt
 	prompt := `You are an expert software architect and project manager analyzing a series of development interactions between a user and an AI coding assistant.
@@ -541,6 +558,9 @@ Format your response as a JSON object with the following structure:
 		strings.Join(analysis.FilesChanged, "\n"),
 	)
 
+	// Record changed files so plan tracking can match them
+	progressNote.Changes.FilesModified = analysis.FilesChanged
+
 	// Set impact assessment
 	progressNote.Impact.Scope = "project-wide"
 	if len(analysis.PotentialIssues) > 0 {
@@ -562,6 +582,24 @@ Format your response as a JSON object with the following structure:
 	return progressNote, nil
 }
 
+// record adds a change to a note file to the activity journal, with the path relative to the data directory
+func (nm *NotesManager) record(action, projectName, path, detail string) {
+	if rel, err := filepath.Rel(nm.baseDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		path = rel
+	}
+	journal.Record(action, projectName, filepath.ToSlash(path), detail)
+}
+
+// BaseDir returns the root of the wash data directory
+func (nm *NotesManager) BaseDir() string {
+	return nm.baseDir
+}
+
+// Cipher returns the cipher notes are encrypted with, or nil when encryption is off
+func (nm *NotesManager) Cipher() *crypt.Cipher {
+	return nm.cipher
+}
+
 // GetMonitorNotesDir returns the path to the monitor notes directory for a project
 func (nm *NotesManager) GetMonitorNotesDir(projectName string) string {
 	return filepath.Join(nm.baseDir, "monitor_notes", projectName)
@@ -569,39 +607,7 @@ func (nm *NotesManager) GetMonitorNotesDir(projectName string) string {
 
 // GetUserNotes retrieves all remember notes for a specific user and project
 func (nm *NotesManager) GetUserNotes(username string, projectName string) ([]*RememberNote, error) {
-	userDir := filepath.Join(nm.baseDir, "remember", username)
-	if _, err := os.Stat(userDir); os.IsNotExist(err) {
-		return nil, nil
-	}
-
-	files, err := os.ReadDir(userDir)
-	if err != nil {
-		return nil, fmt.Errorf("error reading user directory: %w", err)
-	}
-
-	var notes []*RememberNote
-	for _, file := range files {
-		if filepath.Ext(file.Name()) != ".json" {
-			continue
-		}
-
-		data, err := os.ReadFile(filepath.Join(userDir, file.Name()))
-		if err != nil {
-			continue
-		}
-
-		var note RememberNote
-		if err := json.Unmarshal(data, &note); err != nil {
-			continue
-		}
-
-		// Check if the note belongs to the specified project
-		if project, ok := note.Metadata["project"].(string); ok && project == projectName {
-			notes = append(notes, &note)
-		}
-	}
-
-	return notes, nil
+	return nm.ListUserNotes(username, RememberFilter{Project: projectName})
 }
 
 // SaveMonitorNote saves a monitor note for a project
@@ -617,57 +623,38 @@ func (nm *NotesManager) SaveMonitorNote(projectName string, note *MonitorNote) e
 	filepath := filepath.Join(projectDir, filename)
 
 	// Save note to file
-	file, err := os.Create(filepath)
-	if err != nil {
-		return fmt.Errorf("error creating note file: %w", err)
-	}
-	defer file.Close()
-
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(note); err != nil {
-		return fmt.Errorf("error encoding note: %w", err)
+	note.SchemaVersion = CurrentSchemaVersion
+	if err := nm.writeNoteFile(filepath, note); err != nil {
+		return fmt.Errorf("error saving note: %w", err)
 	}
 
 	return nil
 }
 
-// GetProgressNotes retrieves all progress notes for a specific project
-func (nm *NotesManager) GetProgressNotes(projectName string) ([]*ProjectProgressNote, error) {
-	progressDir := filepath.Join(nm.baseDir, "progress")
-	if err := os.MkdirAll(progressDir, 0755); err != nil {
-		return nil, fmt.Errorf("error creating progress directory: %w", err)
+// MonitorNoteTimes returns when a project's monitor notes between start and end were taken, read
+// from their file names so notes are not decrypted
+func (nm *NotesManager) MonitorNoteTimes(projectName string, start, end time.Time) ([]time.Time, error) {
+	entries, err := os.ReadDir(nm.GetMonitorNotesDir(projectName))
+	if os.IsNotExist(err) {
+		return nil, nil
 	}
-
-	entries, err := os.ReadDir(progressDir)
 	if err != nil {
-		return nil, fmt.Errorf("error reading progress directory: %w", err)
+		return nil, fmt.Errorf("error reading monitor directory: %w", err)
 	}
 
-	var notes []*ProjectProgressNote
+	var times []time.Time
 	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+		name := strings.TrimSuffix(entry.Name(), ".json")
+		t, err := time.ParseInLocation("2006-01-02-15-04-05", name, time.Local)
+		if err != nil || t.Before(start) || !t.Before(end) {
 			continue
 		}
-
-		// Check if the file belongs to the specified project
-		if !strings.HasPrefix(entry.Name(), projectName+"_") {
-			continue
-		}
-
-		filePath := filepath.Join(progressDir, entry.Name())
-		data, err := os.ReadFile(filePath)
-		if err != nil {
-			return nil, fmt.Errorf("error reading progress note file %s: %w", entry.Name(), err)
-		}
-
-		var note ProjectProgressNote
-		if err := json.Unmarshal(data, &note); err != nil {
-			return nil, fmt.Errorf("error unmarshaling progress note from %s: %w", entry.Name(), err)
-		}
-
-		notes = append(notes, &note)
+		times = append(times, t)
 	}
+	return times, nil
+}
 
-	return notes, nil
+// GetProgressNotes retrieves all progress notes for a specific project
+func (nm *NotesManager) GetProgressNotes(projectName string) ([]*ProjectProgressNote, error) {
+	return nm.GetProgressNotesBetween(projectName, time.Time{}, time.Time{})
 }
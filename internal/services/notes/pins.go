@@ -0,0 +1,301 @@
+package notes
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/utils/fsutil"
+)
+
+const (
+	// pinsFileName lists the notes pinned into analyzer prompts
+	pinsFileName = "pins.json"
+
+	// maxPinnedNoteLength caps how much of each pinned note goes into a prompt
+	maxPinnedNoteLength = 600
+)
+
+// Pin is a note included in the system prompt of every analysis of its project
+type Pin struct {
+	Kind NoteKind `json:"kind"`
+	ID   string   `json:"id"`
+	// Path is the note file, relative to the data directory
+	Path     string    `json:"path"`
+	Project  string    `json:"project"`
+	PinnedAt time.Time `json:"pinned_at"`
+}
+
+// matchNoteID returns the item whose ID is id or, failing that, the only one starting with it
+func matchNoteID[T any](items []T, id string, idOf func(T) string) (T, error) {
+	var match T
+	found := false
+	for _, item := range items {
+		itemID := idOf(item)
+		if itemID == id {
+			return item, nil
+		}
+		if id != "" && strings.HasPrefix(itemID, id) {
+			if found {
+				return match, fmt.Errorf("ID %s matches more than one note; use more characters", id)
+			}
+			match, found = item, true
+		}
+	}
+	if !found {
+		return match, fmt.Errorf("no note with ID %s", id)
+	}
+	return match, nil
+}
+
+// FindNote returns the note with this ID, or a unique prefix of it. Besides the IDs tags use,
+// bug reports are identified as <project>/<file name without .md>.
+func (nm *NotesManager) FindNote(id string) (*TaggedNote, error) {
+	if id == "" {
+		return nil, fmt.Errorf("note ID cannot be empty")
+	}
+
+	loaded, err := nm.loadTaggedNotes()
+	if err != nil {
+		return nil, err
+	}
+	candidates := make([]*TaggedNote, 0, len(loaded))
+	for _, note := range loaded {
+		candidates = append(candidates, &note.TaggedNote)
+	}
+
+	bugs, err := nm.bugNotes()
+	if err != nil {
+		return nil, err
+	}
+	candidates = append(candidates, bugs...)
+
+	return matchNoteID(candidates, id, func(n *TaggedNote) string { return n.ID })
+}
+
+// bugNotes lists every bug report, archived ones included
+func (nm *NotesManager) bugNotes() ([]*TaggedNote, error) {
+	var bugs []*TaggedNote
+	for _, pattern := range []string{
+		filepath.Join(nm.baseDir, "projects", "*", "bugs", "*.md"),
+		filepath.Join(nm.baseDir, "projects", "*", "bugs", archiveDir, "*.md"),
+	} {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("error listing bug reports: %w", err)
+		}
+		for _, path := range matches {
+			dir := filepath.Dir(path)
+			if filepath.Base(dir) == archiveDir {
+				dir = filepath.Dir(dir)
+			}
+			project := filepath.Base(filepath.Dir(dir))
+			bugs = append(bugs, &TaggedNote{
+				Kind:    KindBug,
+				ID:      project + "/" + strings.TrimSuffix(filepath.Base(path), ".md"),
+				Path:    path,
+				Project: project,
+			})
+		}
+	}
+	return bugs, nil
+}
+
+// Pin adds the note with this ID, or a unique prefix of it, to the pinned notes.
+// Pinning a note twice keeps the first pin.
+func (nm *NotesManager) Pin(id string) (*Pin, error) {
+	note, err := nm.FindNote(id)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(nm.baseDir, note.Path)
+	if err != nil {
+		return nil, fmt.Errorf("error locating note: %w", err)
+	}
+
+	var pinned *Pin
+	err = nm.updatePins(func(pins []*Pin) []*Pin {
+		for _, pin := range pins {
+			if pin.Kind == note.Kind && pin.ID == note.ID {
+				pinned = pin
+				return pins
+			}
+		}
+		pinned = &Pin{Kind: note.Kind, ID: note.ID, Path: rel, Project: note.Project, PinnedAt: time.Now()}
+		return append(pins, pinned)
+	})
+	return pinned, err
+}
+
+// Unpin removes the pin whose note ID is id, or a unique prefix of it
+func (nm *NotesManager) Unpin(id string) (*Pin, error) {
+	var unpinned *Pin
+	var matchErr error
+	err := nm.updatePins(func(pins []*Pin) []*Pin {
+		pin, err := matchNoteID(pins, id, func(p *Pin) string { return p.ID })
+		if err != nil {
+			matchErr = err
+			return pins
+		}
+		unpinned = pin
+		kept := pins[:0]
+		for _, p := range pins {
+			if p != pin {
+				kept = append(kept, p)
+			}
+		}
+		return kept
+	})
+	if err != nil {
+		return nil, err
+	}
+	if matchErr != nil {
+		return nil, matchErr
+	}
+	return unpinned, nil
+}
+
+// ListPins returns the pinned notes, oldest pin first
+func (nm *NotesManager) ListPins() ([]*Pin, error) {
+	data, err := os.ReadFile(filepath.Join(nm.baseDir, pinsFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading pins: %w", err)
+	}
+	var pins []*Pin
+	if err := json.Unmarshal(data, &pins); err != nil {
+		return nil, fmt.Errorf("error parsing pins: %w", err)
+	}
+	sort.SliceStable(pins, func(i, j int) bool { return pins[i].PinnedAt.Before(pins[j].PinnedAt) })
+	return pins, nil
+}
+
+// updatePins applies change to the pins under a lock and saves the result
+func (nm *NotesManager) updatePins(change func([]*Pin) []*Pin) error {
+	unlock, err := fsutil.LockDir(nm.baseDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	pins, err := nm.ListPins()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(change(pins), "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding pins: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(filepath.Join(nm.baseDir, pinsFileName), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("error saving pins: %w", err)
+	}
+	return nil
+}
+
+// PinnedNotes returns the text of the notes pinned for a project, global remember notes included.
+// Pinned notes that have been deleted are skipped; archived ones are still found.
+func (nm *NotesManager) PinnedNotes(projectName string) ([]string, error) {
+	pins, err := nm.ListPins()
+	if err != nil {
+		return nil, err
+	}
+
+	var texts []string
+	for _, pin := range pins {
+		if pin.Project != projectName && pin.Project != globalProject {
+			continue
+		}
+		text, err := nm.pinnedText(pin)
+		if err != nil || text == "" {
+			continue
+		}
+		if runes := []rune(text); len(runes) > maxPinnedNoteLength {
+			text = string(runes[:maxPinnedNoteLength]) + "..."
+		}
+		texts = append(texts, text)
+	}
+	return texts, nil
+}
+
+// pinnedText reads a pinned note and summarizes it for a prompt
+func (nm *NotesManager) pinnedText(pin *Pin) (string, error) {
+	path := filepath.Join(nm.baseDir, pin.Path)
+	dir, name := filepath.Dir(path), filepath.Base(path)
+	if filepath.Base(dir) == archiveDir {
+		dir = filepath.Dir(dir)
+	}
+	// The note may have been archived or restored since it was pinned
+	for _, candidate := range []string{path, filepath.Join(dir, name), filepath.Join(dir, archiveDir, name)} {
+		if _, err := os.Stat(candidate); err == nil {
+			path = candidate
+			break
+		}
+	}
+
+	if pin.Kind == KindBug {
+		data, err := nm.readNoteFile(path)
+		if err != nil {
+			return "", err
+		}
+		text := "Bug: " + bugTitle(data)
+		if solutions := strings.TrimSpace(markdownSection(data, "## Suggested Solutions")); solutions != "" {
+			text += "\nSolutions: " + strings.Join(strings.Fields(solutions), " ")
+		}
+		return text, nil
+	}
+
+	data, err := nm.readNoteFile(path)
+	if err != nil {
+		return "", err
+	}
+	switch pin.Kind {
+	case KindRemember:
+		var note RememberNote
+		if err := json.Unmarshal(data, &note); err != nil {
+			return "", err
+		}
+		return note.Content, nil
+	case KindProgress:
+		var note ProjectProgressNote
+		if err := json.Unmarshal(data, &note); err != nil {
+			return "", err
+		}
+		if note.Type == "" {
+			note.Type = "progress"
+		}
+		return fmt.Sprintf("Decision (%s): %s. %s", note.Type, note.Title, note.Description), nil
+	case KindMonitor:
+		var note MonitorNote
+		if err := json.Unmarshal(data, &note); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Observed: %s %s %s", note.Interaction.UserRequest, note.Interaction.AIAction, note.Interaction.Context), nil
+	case KindInteraction:
+		var note Interaction
+		if err := json.Unmarshal(data, &note); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Earlier analysis: %s %s", note.Context.CurrentState, note.Analysis.CurrentApproach), nil
+	}
+	return "", fmt.Errorf("cannot pin %s notes", pin.Kind)
+}
+
+// markdownSection returns the text under a heading of a markdown document, up to the next heading of any level
+func markdownSection(data []byte, heading string) string {
+	text := string(data)
+	start := strings.Index(text, heading+"\n")
+	if start < 0 {
+		return ""
+	}
+	text = text[start+len(heading)+1:]
+	if end := strings.Index(text, "\n#"); end >= 0 {
+		text = text[:end]
+	}
+	return text
+}
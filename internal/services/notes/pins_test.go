@@ -0,0 +1,65 @@
+package notes
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPinnedNotes(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	nm, err := NewNotesManager()
+	if err != nil {
+		t.Fatalf("Failed to create notes manager: %v", err)
+	}
+
+	remember := &RememberNote{Timestamp: time.Now(), Content: "Retry webhooks with backoff", Metadata: map[string]interface{}{"project": "app"}}
+	if err := nm.SaveUserNote("alice", remember); err != nil {
+		t.Fatalf("SaveUserNote failed: %v", err)
+	}
+	bugDir := filepath.Join(nm.baseDir, "projects", "app", "bugs")
+	if err := os.MkdirAll(bugDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	report := "# Bug Report\n\n## Description\nLogin fails after upgrade\n\n## Suggested Solutions\n- Clear the session cache\n\n## Priority\nhigh\n"
+	if err := os.WriteFile(filepath.Join(bugDir, "bug_2024-05-01-10-30-00.md"), []byte(report), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := nm.Pin(remember.ID[:8]); err != nil {
+		t.Fatalf("Pin failed: %v", err)
+	}
+	bugPin, err := nm.Pin("app/bug_2024")
+	if err != nil {
+		t.Fatalf("Pin failed: %v", err)
+	}
+
+	// Archiving a pinned bug keeps it pinned
+	entries, _ := nm.ListEntries("app", KindBug, false)
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 bug, got %d", len(entries))
+	}
+	if err := nm.ArchiveEntry(entries[0]); err != nil {
+		t.Fatalf("ArchiveEntry failed: %v", err)
+	}
+
+	pinned, err := nm.PinnedNotes("app")
+	if err != nil {
+		t.Fatalf("PinnedNotes failed: %v", err)
+	}
+	if len(pinned) != 2 || pinned[0] != remember.Content || !strings.Contains(pinned[1], "Clear the session cache") {
+		t.Errorf("Unexpected pinned notes: %q", pinned)
+	}
+	if other, _ := nm.PinnedNotes("other"); len(other) != 0 {
+		t.Errorf("Expected no pinned notes for another project, got %q", other)
+	}
+
+	if _, err := nm.Unpin(bugPin.ID); err != nil {
+		t.Fatalf("Unpin failed: %v", err)
+	}
+	if pins, _ := nm.ListPins(); len(pins) != 1 {
+		t.Errorf("Expected 1 pin left, got %d", len(pins))
+	}
+}
@@ -0,0 +1,264 @@
+package notes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/utils/fsutil"
+)
+
+// ProgressIndexEntry describes one progress note in a project's index
+type ProgressIndexEntry struct {
+	ID        string    `json:"id"`
+	File      string    `json:"file"`
+	Timestamp time.Time `json:"timestamp"`
+	Type      string    `json:"type"`
+	Title     string    `json:"title"`
+}
+
+// progressIndex is the per-project manifest of progress notes, sorted by timestamp
+type progressIndex struct {
+	ProjectName string               `json:"project_name"`
+	Entries     []ProgressIndexEntry `json:"entries"`
+}
+
+// progressIndexPath returns the index file for a project
+func (nm *NotesManager) progressIndexPath(projectName string) string {
+	return filepath.Join(nm.baseDir, "progress", "index", projectName+".json")
+}
+
+// readProgressIndex reads a project's index, returning nil if it is missing or unreadable
+func (nm *NotesManager) readProgressIndex(projectName string) (*progressIndex, error) {
+	data, err := nm.readNoteFile(nm.progressIndexPath(projectName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading progress index: %w", err)
+	}
+
+	var index progressIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		// A corrupt index is rebuilt from the notes themselves
+		return nil, nil
+	}
+	return &index, nil
+}
+
+// loadProgressIndex reads a project's index, rebuilding it from the progress directory if it is missing or unreadable
+func (nm *NotesManager) loadProgressIndex(projectName string) (*progressIndex, error) {
+	index, err := nm.readProgressIndex(projectName)
+	if err != nil || index != nil {
+		return index, err
+	}
+
+	unlock, err := fsutil.LockDir(filepath.Dir(nm.progressIndexPath(projectName)))
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	// Another process may have rebuilt the index while we waited for the lock
+	if index, err := nm.readProgressIndex(projectName); err != nil || index != nil {
+		return index, err
+	}
+	return nm.rebuildProgressIndex(projectName)
+}
+
+// saveProgressIndex writes a project's index; callers hold the index directory lock
+func (nm *NotesManager) saveProgressIndex(index *progressIndex) error {
+	indexPath := nm.progressIndexPath(index.ProjectName)
+	if err := os.MkdirAll(filepath.Dir(indexPath), 0755); err != nil {
+		return fmt.Errorf("error creating progress index directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling progress index: %w", err)
+	}
+
+	// Titles are note content, so the index is encrypted like the notes
+	data, err = nm.cipher.Encrypt(data)
+	if err != nil {
+		return fmt.Errorf("error encrypting progress index: %w", err)
+	}
+
+	if err := fsutil.WriteFileAtomic(indexPath, data, 0644); err != nil {
+		return fmt.Errorf("error writing progress index: %w", err)
+	}
+	return nil
+}
+
+// rebuildProgressIndex scans the progress directory once to build a project's index; callers hold the index directory lock
+func (nm *NotesManager) rebuildProgressIndex(projectName string) (*progressIndex, error) {
+	progressDir := filepath.Join(nm.baseDir, "progress")
+	if err := os.MkdirAll(progressDir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating progress directory: %w", err)
+	}
+
+	entries, err := os.ReadDir(progressDir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading progress directory: %w", err)
+	}
+
+	index := &progressIndex{ProjectName: projectName}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		// Check if the file belongs to the specified project
+		if !isProgressFile(entry.Name(), projectName) {
+			continue
+		}
+
+		note, err := nm.readProgressNote(filepath.Join(progressDir, entry.Name()))
+		if err != nil {
+			// One corrupt note must not hide the rest of the project's progress
+			fmt.Fprintf(os.Stderr, "Warning: skipping progress note %s: %v\n", entry.Name(), err)
+			continue
+		}
+
+		// Skip notes of other projects whose names share this prefix
+		if note.ProjectName != "" && note.ProjectName != projectName {
+			continue
+		}
+
+		index.Entries = append(index.Entries, indexEntryFor(note, entry.Name()))
+	}
+
+	sortIndexEntries(index.Entries)
+
+	if err := nm.saveProgressIndex(index); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// addToProgressIndex records a newly saved note in its project's index
+func (nm *NotesManager) addToProgressIndex(note *ProjectProgressNote, file string) error {
+	unlock, err := fsutil.LockDir(filepath.Dir(nm.progressIndexPath(note.ProjectName)))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	index, err := nm.readProgressIndex(note.ProjectName)
+	if err != nil {
+		return err
+	}
+	if index == nil {
+		// The rebuild picks up the note we just wrote
+		_, err := nm.rebuildProgressIndex(note.ProjectName)
+		return err
+	}
+
+	index.Entries = append(index.Entries, indexEntryFor(note, file))
+	sortIndexEntries(index.Entries)
+	return nm.saveProgressIndex(index)
+}
+
+// RebuildProgressIndex rescans the progress directory for a project, picking up notes written outside wash
+func (nm *NotesManager) RebuildProgressIndex(projectName string) error {
+	unlock, err := fsutil.LockDir(filepath.Dir(nm.progressIndexPath(projectName)))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	_, err = nm.rebuildProgressIndex(projectName)
+	return err
+}
+
+// indexEntryFor builds the index entry for a note stored in file
+func indexEntryFor(note *ProjectProgressNote, file string) ProgressIndexEntry {
+	return ProgressIndexEntry{
+		ID:        note.ID,
+		File:      file,
+		Timestamp: note.Timestamp,
+		Type:      note.Type,
+		Title:     note.Title,
+	}
+}
+
+// sortIndexEntries orders entries oldest first
+func sortIndexEntries(entries []ProgressIndexEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.Before(entries[j].Timestamp)
+	})
+}
+
+// entriesBetween returns the entries with timestamps in [start, end); a zero bound is open
+func entriesBetween(entries []ProgressIndexEntry, start time.Time, end time.Time) []ProgressIndexEntry {
+	lo := 0
+	if !start.IsZero() {
+		lo = sort.Search(len(entries), func(i int) bool {
+			return !entries[i].Timestamp.Before(start)
+		})
+	}
+	hi := len(entries)
+	if !end.IsZero() {
+		hi = sort.Search(len(entries), func(i int) bool {
+			return !entries[i].Timestamp.Before(end)
+		})
+	}
+	if lo >= hi {
+		return nil
+	}
+	return entries[lo:hi]
+}
+
+// readProgressNote reads a single progress note file
+func (nm *NotesManager) readProgressNote(path string) (*ProjectProgressNote, error) {
+	data, err := nm.readNoteFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading progress note file %s: %w", filepath.Base(path), err)
+	}
+
+	var note ProjectProgressNote
+	if err := json.Unmarshal(data, &note); err != nil {
+		return nil, fmt.Errorf("error unmarshaling progress note from %s: %w", filepath.Base(path), err)
+	}
+	return &note, nil
+}
+
+// ListProgressIndex returns the index entries for a project without reading the notes, oldest first
+func (nm *NotesManager) ListProgressIndex(projectName string) ([]ProgressIndexEntry, error) {
+	index, err := nm.loadProgressIndex(projectName)
+	if err != nil {
+		return nil, err
+	}
+	return index.Entries, nil
+}
+
+// GetProgressNotesBetween retrieves a project's progress notes with timestamps in [start, end).
+// A zero start or end leaves that side of the range open.
+func (nm *NotesManager) GetProgressNotesBetween(projectName string, start time.Time, end time.Time) ([]*ProjectProgressNote, error) {
+	index, err := nm.loadProgressIndex(projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	progressDir := filepath.Join(nm.baseDir, "progress")
+	var notes []*ProjectProgressNote
+	for _, entry := range entriesBetween(index.Entries, start, end) {
+		note, err := nm.readProgressNote(filepath.Join(progressDir, entry.File))
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				// The note was removed outside wash; skip the stale entry
+				continue
+			}
+			return nil, err
+		}
+		notes = append(notes, note)
+	}
+
+	return notes, nil
+}
@@ -0,0 +1,54 @@
+package notes
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEntriesBetween(t *testing.T) {
+	day := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+	entries := []ProgressIndexEntry{
+		{ID: "before", Timestamp: day.Add(-time.Hour)},
+		{ID: "start", Timestamp: day},
+		{ID: "during", Timestamp: day.Add(12 * time.Hour)},
+		{ID: "end", Timestamp: day.AddDate(0, 0, 1)},
+	}
+
+	got := entriesBetween(entries, day, day.AddDate(0, 0, 1))
+	if len(got) != 2 || got[0].ID != "start" || got[1].ID != "during" {
+		t.Errorf("Expected [start during], got %v", got)
+	}
+
+	if got := entriesBetween(entries, time.Time{}, time.Time{}); len(got) != len(entries) {
+		t.Errorf("Expected open range to return all %d entries, got %d", len(entries), len(got))
+	}
+
+	if got := entriesBetween(entries, day.AddDate(0, 0, 2), time.Time{}); len(got) != 0 {
+		t.Errorf("Expected no entries after the last note, got %d", len(got))
+	}
+}
+
+func TestRebuildProgressIndexSkipsCorruptNote(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	nm, err := NewNotesManager()
+	if err != nil {
+		t.Fatalf("Failed to create notes manager: %v", err)
+	}
+
+	if err := nm.SaveProjectProgress(&ProjectProgressNote{ProjectName: "demo", Title: "Ship login"}); err != nil {
+		t.Fatalf("Failed to save note: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(nm.baseDir, "progress", "demo_corrupt.json"), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := nm.RebuildProgressIndex("demo"); err != nil {
+		t.Fatalf("RebuildProgressIndex failed: %v", err)
+	}
+	entries, err := nm.ListProgressIndex("demo")
+	if err != nil || len(entries) != 1 || entries[0].Title != "Ship login" {
+		t.Errorf("ListProgressIndex = %+v, %v", entries, err)
+	}
+}
@@ -0,0 +1,282 @@
+package notes
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/attachments"
+	"github.com/bkidd1/wash-cli/internal/services/journal"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+)
+
+const (
+	// attachmentGracePeriod protects attachments stored moments ago whose note is not saved yet
+	attachmentGracePeriod = time.Hour
+
+	// DefaultContextLogRetention is how long context logs are kept when retention.context_logs is not set
+	DefaultContextLogRetention = 90 * 24 * time.Hour
+	// contextLogTimeLayout names the monthly context logs the analyzer writes to ~/.wash/context
+	contextLogTimeLayout = "2006-01"
+)
+
+// RetentionPolicy says how long each kind of note is kept; zero keeps notes forever
+type RetentionPolicy struct {
+	MonitorNotes  time.Duration
+	ProgressNotes time.Duration
+	Interactions  time.Duration
+	// Trash is how long deleted notes stay restorable
+	Trash time.Duration
+	// ContextLogs is how long the monthly logs of the notes analyses considered are kept
+	ContextLogs time.Duration
+}
+
+// IsZero reports whether the policy keeps everything
+func (p RetentionPolicy) IsZero() bool {
+	return p.MonitorNotes == 0 && p.ProgressNotes == 0 && p.Interactions == 0 && p.Trash == 0 && p.ContextLogs == 0
+}
+
+// maxAge returns how long notes of a kind are kept
+func (p RetentionPolicy) maxAge(kind NoteKind) time.Duration {
+	switch kind {
+	case KindMonitor:
+		return p.MonitorNotes
+	case KindProgress:
+		return p.ProgressNotes
+	case KindInteraction:
+		return p.Interactions
+	default:
+		return 0
+	}
+}
+
+// ParseRetentionPolicy builds a policy from the retention section of the config. The trash is
+// emptied after DefaultTrashRetention and context logs deleted after DefaultContextLogRetention,
+// unless retention.trash and retention.context_logs say otherwise.
+func ParseRetentionPolicy(settings map[string]string) (RetentionPolicy, error) {
+	policy := RetentionPolicy{Trash: DefaultTrashRetention, ContextLogs: DefaultContextLogRetention}
+	for key, value := range settings {
+		age, err := ParseRetention(value)
+		if err != nil {
+			return policy, fmt.Errorf("invalid retention.%s: %w", key, err)
+		}
+
+		switch key {
+		case "monitor_notes":
+			policy.MonitorNotes = age
+		case "progress_notes":
+			policy.ProgressNotes = age
+		case "interactions":
+			policy.Interactions = age
+		case "trash":
+			policy.Trash = age
+		case "context_logs":
+			policy.ContextLogs = age
+		case "screenshots":
+			// Screenshots are not notes; the monitor cleans them up
+		case "analysis_cache":
+			// Cached answers are not notes; the cache deletes its expired ones
+		default:
+			return policy, fmt.Errorf("unknown retention setting %q (expected monitor_notes, progress_notes, interactions, trash, screenshots, context_logs or analysis_cache)", key)
+		}
+	}
+	return policy, nil
+}
+
+// ParseRetention parses an age such as 30d, 2w or 12h; empty, 0 and "forever" keep notes forever
+func ParseRetention(value string) (time.Duration, error) {
+	return config.ParseAge(value)
+}
+
+// PruneReport summarizes a prune run
+type PruneReport struct {
+	Deleted          map[NoteKind]int
+	TrashEmptied     int   // Deleted notes removed from the trash for good
+	ContextLogs      int   // Monthly context logs deleted
+	AttachmentsFreed int64 // Bytes of attachments no longer referenced by any note
+	Failed           map[string]error
+}
+
+// Prune deletes notes older than the policy allows and empties the trash of notes deleted before its retention,
+// then removes attachments no remaining note references. With dryRun set, it reports what would be deleted without deleting anything.
+func (nm *NotesManager) Prune(policy RetentionPolicy, dryRun bool) (*PruneReport, error) {
+	files, err := nm.noteFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &PruneReport{Deleted: make(map[NoteKind]int), Failed: make(map[string]error)}
+	if policy.IsZero() {
+		return report, nil
+	}
+
+	now := time.Now()
+	progressChanged := false
+
+	for _, kind := range []NoteKind{KindInteraction, KindMonitor, KindProgress} {
+		maxAge := policy.maxAge(kind)
+		if maxAge == 0 {
+			continue
+		}
+		cutoff := now.Add(-maxAge)
+
+		for _, path := range files[kind] {
+			timestamp, err := nm.noteTimestamp(path)
+			if err != nil {
+				report.Failed[path] = err
+				continue
+			}
+			if !timestamp.Before(cutoff) {
+				continue
+			}
+
+			report.Deleted[kind]++
+			if dryRun {
+				continue
+			}
+
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				report.Failed[path] = err
+				report.Deleted[kind]--
+				continue
+			}
+			nm.record(journal.ActionDeleted, "", path, "pruned: older than the "+string(kind)+" retention")
+			if kind == KindProgress {
+				progressChanged = true
+			}
+		}
+	}
+
+	if policy.Trash > 0 {
+		emptied, err := nm.EmptyTrash(now.Add(-policy.Trash), dryRun)
+		report.TrashEmptied = emptied
+		if err != nil {
+			return report, err
+		}
+	}
+
+	if policy.ContextLogs > 0 {
+		deleted, err := nm.pruneContextLogs(now.Add(-policy.ContextLogs), dryRun)
+		report.ContextLogs = deleted
+		if err != nil {
+			return report, err
+		}
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	// Deleted progress notes must drop out of the indexes
+	if progressChanged {
+		if err := os.RemoveAll(filepath.Join(nm.baseDir, "progress", "index")); err != nil {
+			return report, fmt.Errorf("error resetting progress index: %w", err)
+		}
+	}
+
+	freed, err := nm.pruneAttachments()
+	if err != nil {
+		return report, err
+	}
+	report.AttachmentsFreed = freed
+
+	return report, nil
+}
+
+// pruneContextLogs deletes the monthly context logs whose month ended before cutoff and returns how
+// many there were. With dryRun set, it only counts them.
+func (nm *NotesManager) pruneContextLogs(cutoff time.Time, dryRun bool) (int, error) {
+	paths, err := filepath.Glob(filepath.Join(nm.baseDir, "context", "*.jsonl"))
+	if err != nil {
+		return 0, fmt.Errorf("error listing context logs: %w", err)
+	}
+
+	deleted := 0
+	for _, path := range paths {
+		month, err := time.ParseInLocation(contextLogTimeLayout, strings.TrimSuffix(filepath.Base(path), ".jsonl"), time.Local)
+		if err != nil || !month.AddDate(0, 1, 0).Before(cutoff) {
+			continue
+		}
+		if !dryRun {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return deleted, fmt.Errorf("error deleting context log: %w", err)
+			}
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// pruneAttachments removes stored attachments that no note or bug report references
+func (nm *NotesManager) pruneAttachments() (int64, error) {
+	attachmentsDir := filepath.Join(nm.baseDir, "attachments")
+	if _, err := os.Stat(attachmentsDir); os.IsNotExist(err) {
+		return 0, nil
+	}
+
+	files, err := nm.noteFiles()
+	if err != nil {
+		return 0, err
+	}
+	bugReports, err := filepath.Glob(filepath.Join(nm.baseDir, "projects", "*", "bugs", "*.md"))
+	if err != nil {
+		return 0, fmt.Errorf("error listing bug reports: %w", err)
+	}
+	archivedBugs, err := filepath.Glob(filepath.Join(nm.baseDir, "projects", "*", "bugs", archiveDir, "*.md"))
+	if err != nil {
+		return 0, fmt.Errorf("error listing bug reports: %w", err)
+	}
+	bugReports = append(bugReports, archivedBugs...)
+	// Trashed notes keep their attachments so a restore brings them back intact
+	trashed, err := nm.trashedFiles()
+	if err != nil {
+		return 0, err
+	}
+
+	paths := append(bugReports, trashed...)
+	for _, kindFiles := range files {
+		paths = append(paths, kindFiles...)
+	}
+
+	referenced := make(map[string]bool)
+	for _, path := range paths {
+		data, err := nm.readNoteFile(path)
+		if err != nil {
+			// Keep everything rather than risk deleting a referenced attachment
+			return 0, fmt.Errorf("error reading %s: %w", path, err)
+		}
+		for _, hash := range attachments.Referenced(data) {
+			referenced[hash] = true
+		}
+	}
+
+	am, err := attachments.NewAttachmentManagerAt(attachmentsDir, 0)
+	if err != nil {
+		return 0, err
+	}
+	return am.RemoveUnreferenced(referenced, time.Now().Add(-attachmentGracePeriod))
+}
+
+// noteTimestamp reads a note's timestamp, falling back to the file's modification time
+func (nm *NotesManager) noteTimestamp(path string) (time.Time, error) {
+	data, err := nm.readNoteFile(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var note struct {
+		Timestamp time.Time `json:"timestamp"`
+	}
+	if err := json.Unmarshal(data, &note); err == nil && !note.Timestamp.IsZero() {
+		return note.Timestamp, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
@@ -0,0 +1,105 @@
+package notes
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseRetention(t *testing.T) {
+	tests := map[string]time.Duration{
+		"30d":     30 * 24 * time.Hour,
+		"2w":      14 * 24 * time.Hour,
+		"12h":     12 * time.Hour,
+		"":        0,
+		"forever": 0,
+	}
+	for input, want := range tests {
+		got, err := ParseRetention(input)
+		if err != nil || got != want {
+			t.Errorf("ParseRetention(%q) = %v, %v; want %v", input, got, err, want)
+		}
+	}
+
+	for _, input := range []string{"1y", "-3d", "soon"} {
+		if _, err := ParseRetention(input); err == nil {
+			t.Errorf("ParseRetention(%q) should fail", input)
+		}
+	}
+}
+
+func TestPruneDeletesOldMonitorNotes(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	nm, err := NewNotesManager()
+	if err != nil {
+		t.Fatalf("Failed to create notes manager: %v", err)
+	}
+
+	old := &MonitorNote{Timestamp: time.Now().Add(-40 * 24 * time.Hour)}
+	recent := &MonitorNote{Timestamp: time.Now().Add(-time.Hour)}
+	for _, note := range []*MonitorNote{old, recent} {
+		if err := nm.SaveMonitorNote("demo", note); err != nil {
+			t.Fatalf("Failed to save note: %v", err)
+		}
+	}
+
+	policy := RetentionPolicy{MonitorNotes: 30 * 24 * time.Hour}
+
+	report, err := nm.Prune(policy, true)
+	if err != nil || report.Deleted[KindMonitor] != 1 {
+		t.Fatalf("Dry run: deleted=%v err=%v", report.Deleted, err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(nm.GetMonitorNotesDir("demo"), "*.json")); len(files) != 2 {
+		t.Fatalf("Dry run deleted notes: %v", files)
+	}
+
+	if _, err := nm.Prune(policy, false); err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	files, _ := filepath.Glob(filepath.Join(nm.GetMonitorNotesDir("demo"), "*.json"))
+	if len(files) != 1 {
+		t.Fatalf("Expected one remaining note, got %v", files)
+	}
+	if _, err := os.Stat(files[0]); err != nil || filepath.Base(files[0]) != recent.Timestamp.Format("2006-01-02-15-04-05")+".json" {
+		t.Errorf("Wrong note kept: %s", files[0])
+	}
+}
+
+func TestPruneDeletesOldContextLogs(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	nm, err := NewNotesManager()
+	if err != nil {
+		t.Fatalf("Failed to create notes manager: %v", err)
+	}
+
+	dir := filepath.Join(nm.baseDir, "context")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().AddDate(0, -6, 0).Format(contextLogTimeLayout) + ".jsonl"
+	current := time.Now().Format(contextLogTimeLayout) + ".jsonl"
+	for _, name := range []string{old, current} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("{}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	policy, err := ParseRetentionPolicy(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := nm.Prune(policy, false)
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if report.ContextLogs != 1 {
+		t.Errorf("Expected one context log deleted, got %d", report.ContextLogs)
+	}
+	if _, err := os.Stat(filepath.Join(dir, old)); !os.IsNotExist(err) {
+		t.Error("Expected the old context log to be deleted")
+	}
+	if _, err := os.Stat(filepath.Join(dir, current)); err != nil {
+		t.Errorf("Expected this month's context log to be kept: %v", err)
+	}
+}
@@ -0,0 +1,245 @@
+package notes
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/journal"
+)
+
+// Remember note scopes
+const (
+	// ScopeProject notes apply to the project they were saved for
+	ScopeProject = "project"
+	// ScopeGlobal notes apply to every project
+	ScopeGlobal = "global"
+)
+
+// RememberFilter selects remember notes; zero fields match everything
+type RememberFilter struct {
+	Scope         string   // ScopeProject or ScopeGlobal
+	Project       string   // Matches project scoped notes of this project
+	IncludeGlobal bool     // With Project set, also match global notes
+	Tags          []string // A note must carry every tag
+	Since         time.Time
+	Until         time.Time
+}
+
+// matches reports whether a note passes the filter
+func (f RememberFilter) matches(note *RememberNote) bool {
+	if f.Scope != "" && note.Scope() != f.Scope {
+		return false
+	}
+	if f.Project != "" && note.Project() != f.Project && !(f.IncludeGlobal && note.Scope() == ScopeGlobal) {
+		return false
+	}
+	if !f.Since.IsZero() && note.Timestamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && note.Timestamp.After(f.Until) {
+		return false
+	}
+	for _, tag := range f.Tags {
+		found := false
+		for _, t := range note.Tags() {
+			if strings.EqualFold(t, tag) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Project returns the project a remember note belongs to
+func (n *RememberNote) Project() string {
+	project, _ := n.Metadata["project"].(string)
+	return project
+}
+
+// Scope returns ScopeGlobal for notes that apply to every project, otherwise ScopeProject
+func (n *RememberNote) Scope() string {
+	if scope, _ := n.Metadata["scope"].(string); scope == ScopeGlobal {
+		return ScopeGlobal
+	}
+	return ScopeProject
+}
+
+// Tags returns the tags of a remember note
+func (n *RememberNote) Tags() []string {
+	switch tags := n.Metadata["tags"].(type) {
+	case []string:
+		return tags
+	case []interface{}:
+		result := make([]string, 0, len(tags))
+		for _, tag := range tags {
+			if s, ok := tag.(string); ok && s != "" {
+				result = append(result, s)
+			}
+		}
+		return result
+	default:
+		return nil
+	}
+}
+
+// SetTags replaces the tags of a remember note
+func (n *RememberNote) SetTags(tags []string) {
+	if n.Metadata == nil {
+		n.Metadata = make(map[string]interface{})
+	}
+	n.Metadata["tags"] = tags
+}
+
+// CurrentUser returns the user whose remember notes are read and written
+func CurrentUser() string {
+	username := os.Getenv("USER")
+	if username == "" {
+		username = "default"
+	}
+	return username
+}
+
+// rememberNoteID derives a note ID from its file name, which is a timestamp followed by a UUID
+func rememberNoteID(filename string) string {
+	name := strings.TrimSuffix(filename, filepath.Ext(filename))
+	if i := strings.LastIndex(name, "_"); i >= 0 && i+1 < len(name) {
+		return name[i+1:]
+	}
+	return name
+}
+
+// ListUserNotes returns a user's remember notes that pass the filter, oldest first
+func (nm *NotesManager) ListUserNotes(username string, filter RememberFilter) ([]*RememberNote, error) {
+	userDir := filepath.Join(nm.baseDir, "remember", username)
+	files, err := os.ReadDir(userDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading user directory: %w", err)
+	}
+
+	var notes []*RememberNote
+	for _, file := range files {
+		if filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+
+		note, err := nm.readRememberNote(filepath.Join(userDir, file.Name()))
+		if err != nil {
+			continue
+		}
+		if filter.matches(note) {
+			notes = append(notes, note)
+		}
+	}
+
+	sort.Slice(notes, func(i, j int) bool {
+		if !notes[i].Timestamp.Equal(notes[j].Timestamp) {
+			return notes[i].Timestamp.Before(notes[j].Timestamp)
+		}
+		return notes[i].ID < notes[j].ID
+	})
+	return notes, nil
+}
+
+// GetUserNote returns the remember note whose ID starts with id, which must match exactly one note
+func (nm *NotesManager) GetUserNote(username, id string) (*RememberNote, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil, fmt.Errorf("note ID cannot be empty")
+	}
+
+	all, err := nm.ListUserNotes(username, RememberFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []*RememberNote
+	for _, note := range all {
+		if note.ID == id {
+			return note, nil
+		}
+		if strings.HasPrefix(note.ID, id) {
+			matches = append(matches, note)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no remember note with ID %s", id)
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, fmt.Errorf("note ID %s is ambiguous: it matches %d notes", id, len(matches))
+	}
+}
+
+// UpdateUserNote rewrites an existing remember note in place
+func (nm *NotesManager) UpdateUserNote(username string, note *RememberNote) error {
+	path, err := nm.rememberNotePath(username, note.ID)
+	if err != nil {
+		return err
+	}
+
+	if note.Metadata == nil {
+		note.Metadata = make(map[string]interface{})
+	}
+	note.Metadata["updated_at"] = time.Now().Format(time.RFC3339)
+	note.SchemaVersion = CurrentSchemaVersion
+	if err := nm.writeNoteFile(path, note); err != nil {
+		return fmt.Errorf("error saving note: %w", err)
+	}
+	nm.record(journal.ActionSaved, note.Project(), path, "remember note updated")
+	return nil
+}
+
+// DeleteUserNote moves a remember note to the trash
+func (nm *NotesManager) DeleteUserNote(username, id string) (*TrashEntry, error) {
+	path, err := nm.rememberNotePath(username, id)
+	if err != nil {
+		return nil, err
+	}
+	return nm.Trash(KindRemember, path, "")
+}
+
+// rememberNotePath returns the file holding the remember note with exactly this ID
+func (nm *NotesManager) rememberNotePath(username, id string) (string, error) {
+	if id == "" {
+		return "", fmt.Errorf("note ID cannot be empty")
+	}
+	matches, err := filepath.Glob(filepath.Join(nm.baseDir, "remember", username, "*.json"))
+	if err != nil {
+		return "", fmt.Errorf("error finding note: %w", err)
+	}
+	for _, path := range matches {
+		if rememberNoteID(filepath.Base(path)) == id {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no remember note with ID %s", id)
+}
+
+// readRememberNote reads a remember note file, setting its ID from the file name
+func (nm *NotesManager) readRememberNote(path string) (*RememberNote, error) {
+	data, err := nm.readNoteFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var note RememberNote
+	if err := json.Unmarshal(data, &note); err != nil {
+		return nil, err
+	}
+	note.ID = rememberNoteID(filepath.Base(path))
+	return &note, nil
+}
@@ -0,0 +1,127 @@
+package notes
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+)
+
+// RememberStore is the single source of the remember notes analyzers see. It combines the notes
+// saved with wash remember and the remember_notes list from the config, which count as global notes.
+type RememberStore struct {
+	notesManager *NotesManager
+	username     string
+	configured   []string
+}
+
+// NewRememberStore creates a RememberStore for the current user
+func NewRememberStore(cfg *config.Config) (*RememberStore, error) {
+	notesManager, err := NewNotesManager()
+	if err != nil {
+		return nil, err
+	}
+	return NewRememberStoreFor(notesManager, CurrentUser(), cfg.RememberNotes), nil
+}
+
+// NewRememberStoreFor creates a RememberStore over an existing notes manager
+func NewRememberStoreFor(notesManager *NotesManager, username string, configured []string) *RememberStore {
+	return &RememberStore{notesManager: notesManager, username: username, configured: configured}
+}
+
+// ForProject returns the global notes, config list first, and the project's notes, each oldest first
+func (s *RememberStore) ForProject(projectName string) (global, project []string, err error) {
+	global = append(global, s.configured...)
+
+	notes, err := s.notesManager.ListUserNotes(s.username, RememberFilter{Project: projectName, IncludeGlobal: true})
+	if err != nil {
+		return global, nil, err
+	}
+	for _, note := range notes {
+		if note.Scope() == ScopeGlobal {
+			global = append(global, note.Content)
+		} else {
+			project = append(project, note.Content)
+		}
+	}
+	return global, project, nil
+}
+
+// SavedTimes returns when each of the notes ForProject returns was saved, keyed by content.
+// Notes from the config list have no time and are left out.
+func (s *RememberStore) SavedTimes(projectName string) (map[string]time.Time, error) {
+	notes, err := s.notesManager.ListUserNotes(s.username, RememberFilter{Project: projectName, IncludeGlobal: true})
+	if err != nil {
+		return nil, err
+	}
+	times := make(map[string]time.Time, len(notes))
+	for _, note := range notes {
+		times[strings.TrimSpace(note.Content)] = note.Timestamp
+	}
+	return times, nil
+}
+
+// Add saves a note for a project, or with an empty project name a global note
+func (s *RememberStore) Add(projectName, content string, tags []string) (*RememberNote, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil, fmt.Errorf("content cannot be empty")
+	}
+
+	note := &RememberNote{
+		Timestamp: time.Now(),
+		Content:   content,
+		Metadata: map[string]interface{}{
+			"type": "remember",
+			"tags": tags,
+		},
+	}
+	if projectName == "" {
+		note.Metadata["scope"] = ScopeGlobal
+	} else {
+		note.Metadata["scope"] = ScopeProject
+		note.Metadata["project"] = projectName
+	}
+
+	if err := s.notesManager.SaveUserNote(s.username, note); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+// ImportConfigNotes moves the config's remember_notes into the store as global notes, skipping notes
+// already saved, and removes them from the config file. It returns how many notes were added.
+func (s *RememberStore) ImportConfigNotes() (int, error) {
+	existing, err := s.notesManager.ListUserNotes(s.username, RememberFilter{Scope: ScopeGlobal})
+	if err != nil {
+		return 0, err
+	}
+	saved := make(map[string]bool, len(existing))
+	for _, note := range existing {
+		saved[strings.ToLower(note.Content)] = true
+	}
+
+	var imported []string
+	err = config.UpdateConfig(func(cfg *config.Config) error {
+		for _, content := range cfg.RememberNotes {
+			key := strings.ToLower(strings.TrimSpace(content))
+			if key == "" || saved[key] {
+				continue
+			}
+			if _, err := s.Add("", content, []string{"config"}); err != nil {
+				return fmt.Errorf("failed to save note %q: %w", content, err)
+			}
+			saved[key] = true
+			imported = append(imported, content)
+		}
+		cfg.RememberNotes = nil
+		return nil
+	})
+	if err != nil {
+		return len(imported), err
+	}
+
+	s.configured = nil
+	return len(imported), nil
+}
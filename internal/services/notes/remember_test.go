@@ -0,0 +1,80 @@
+package notes
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRememberNoteLifecycle(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	nm, err := NewNotesManager()
+	if err != nil {
+		t.Fatalf("Failed to create notes manager: %v", err)
+	}
+
+	saved := []*RememberNote{
+		{Timestamp: time.Now().Add(-10 * 24 * time.Hour), Content: "old", Metadata: map[string]interface{}{"project": "app", "tags": []string{"api"}}},
+		{Timestamp: time.Now(), Content: "new", Metadata: map[string]interface{}{"project": "app", "tags": []string{"api", "security"}}},
+		{Timestamp: time.Now(), Content: "elsewhere", Metadata: map[string]interface{}{"project": "other"}},
+	}
+	for _, note := range saved {
+		if err := nm.SaveUserNote("alice", note); err != nil {
+			t.Fatalf("SaveUserNote failed: %v", err)
+		}
+	}
+
+	list, err := nm.ListUserNotes("alice", RememberFilter{Project: "app", Tags: []string{"API"}, Since: time.Now().Add(-24 * time.Hour)})
+	if err != nil {
+		t.Fatalf("ListUserNotes failed: %v", err)
+	}
+	if len(list) != 1 || list[0].Content != "new" || list[0].ID != saved[1].ID {
+		t.Fatalf("Expected only the new note, got %+v", list)
+	}
+
+	note, err := nm.GetUserNote("alice", saved[1].ID[:8])
+	if err != nil {
+		t.Fatalf("GetUserNote failed: %v", err)
+	}
+	note.Content = "edited"
+	note.SetTags([]string{"docs"})
+	if err := nm.UpdateUserNote("alice", note); err != nil {
+		t.Fatalf("UpdateUserNote failed: %v", err)
+	}
+	if got, _ := nm.GetUserNote("alice", saved[1].ID); got.Content != "edited" || len(got.Tags()) != 1 || got.Tags()[0] != "docs" {
+		t.Errorf("Edit was not saved: %+v", got)
+	}
+
+	if _, err := nm.DeleteUserNote("alice", saved[0].ID); err != nil {
+		t.Fatalf("DeleteUserNote failed: %v", err)
+	}
+	if _, err := nm.GetUserNote("alice", saved[0].ID); err == nil {
+		t.Error("Deleted note is still readable")
+	}
+	if remaining, _ := nm.ListUserNotes("alice", RememberFilter{}); len(remaining) != 2 {
+		t.Errorf("Expected 2 notes left, got %d", len(remaining))
+	}
+}
+
+func TestRememberStoreForProject(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	nm, err := NewNotesManager()
+	if err != nil {
+		t.Fatalf("Failed to create notes manager: %v", err)
+	}
+	store := NewRememberStoreFor(nm, "alice", []string{"config note"})
+
+	for _, add := range []struct{ project, content string }{{"app", "app note"}, {"", "global note"}, {"other", "other note"}} {
+		if _, err := store.Add(add.project, add.content, nil); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+
+	global, project, err := store.ForProject("app")
+	if err != nil {
+		t.Fatalf("ForProject failed: %v", err)
+	}
+	if strings.Join(global, "|") != "config note|global note" || strings.Join(project, "|") != "app note" {
+		t.Errorf("Unexpected scopes: global=%v project=%v", global, project)
+	}
+}
@@ -0,0 +1,66 @@
+package notes
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FileRename records a file or directory moved from one path to another
+type FileRename struct {
+	From string    `json:"from"`
+	To   string    `json:"to"`
+	At   time.Time `json:"at"`
+}
+
+// RenameMap follows files across renames to their latest paths
+type RenameMap map[string]string
+
+// Add records renames, applied in the order of their times so later moves win
+func (r RenameMap) Add(renames ...FileRename) {
+	renames = append([]FileRename(nil), renames...)
+	sort.SliceStable(renames, func(i, j int) bool { return renames[i].At.Before(renames[j].At) })
+	for _, rename := range renames {
+		from, to := cleanPath(rename.From), cleanPath(rename.To)
+		if from == to || from == "." || to == "." {
+			continue
+		}
+		// A file now exists at to, so an earlier move away from to no longer applies
+		delete(r, to)
+		r[from] = to
+	}
+}
+
+// Resolve returns the latest path of a file, following renames of the file and of the
+// directories containing it
+func (r RenameMap) Resolve(path string) string {
+	path = cleanPath(path)
+	// Add keeps exact renames free of cycles; the bound covers loops through directory renames
+	for i := 0; i <= len(r); i++ {
+		next, ok := r[path]
+		if !ok {
+			next, ok = r.resolveDir(path)
+		}
+		if !ok || next == path {
+			return path
+		}
+		path = next
+	}
+	return path
+}
+
+// resolveDir maps a path inside a renamed directory, preferring the deepest renamed directory
+func (r RenameMap) resolveDir(path string) (string, bool) {
+	for dir := filepath.ToSlash(filepath.Dir(path)); dir != "." && dir != "/"; dir = filepath.ToSlash(filepath.Dir(dir)) {
+		if to, ok := r[dir]; ok {
+			return to + strings.TrimPrefix(path, dir), true
+		}
+	}
+	return "", false
+}
+
+// cleanPath puts a path in the form renames are keyed by
+func cleanPath(path string) string {
+	return filepath.ToSlash(filepath.Clean(path))
+}
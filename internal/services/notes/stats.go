@@ -0,0 +1,159 @@
+package notes
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// globalProject is the project name remember notes without a project are counted under
+const globalProject = "(global)"
+
+// Stats counts the notes wash has stored
+type Stats struct {
+	ByKind    map[NoteKind]int
+	ByProject map[string]map[NoteKind]int
+	// ByDay counts notes per local day, keyed 2006-01-02
+	ByDay map[string]int
+	// Storage is the size in bytes of each top-level directory of the data directory
+	Storage map[string]int64
+	// Unreadable counts note files that could not be read or decoded
+	Unreadable int
+}
+
+// Total returns the number of notes counted
+func (s *Stats) Total() int {
+	total := 0
+	for _, count := range s.ByKind {
+		total += count
+	}
+	return total
+}
+
+// Stats counts every stored note, archived ones included, by kind, project and day.
+// With projectName set, only that project's notes are counted; storage always covers the whole data directory.
+func (nm *NotesManager) Stats(projectName string) (*Stats, error) {
+	files, err := nm.noteFiles()
+	if err != nil {
+		return nil, err
+	}
+	for _, pattern := range []string{
+		filepath.Join(nm.baseDir, "projects", "*", "bugs", "*.md"),
+		filepath.Join(nm.baseDir, "projects", "*", "bugs", archiveDir, "*.md"),
+	} {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("error listing bug reports: %w", err)
+		}
+		files[KindBug] = append(files[KindBug], matches...)
+	}
+
+	stats := &Stats{
+		ByKind:    make(map[NoteKind]int),
+		ByProject: make(map[string]map[NoteKind]int),
+		ByDay:     make(map[string]int),
+	}
+	for kind, paths := range files {
+		for _, path := range paths {
+			project, timestamp, err := nm.noteOrigin(kind, path)
+			if err != nil {
+				stats.Unreadable++
+				continue
+			}
+			if projectName != "" && project != projectName {
+				continue
+			}
+
+			stats.ByKind[kind]++
+			if stats.ByProject[project] == nil {
+				stats.ByProject[project] = make(map[NoteKind]int)
+			}
+			stats.ByProject[project][kind]++
+			stats.ByDay[timestamp.Local().Format("2006-01-02")]++
+		}
+	}
+
+	if stats.Storage, err = directorySizes(nm.baseDir); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// noteOrigin returns the project and timestamp of a note file
+func (nm *NotesManager) noteOrigin(kind NoteKind, path string) (string, time.Time, error) {
+	if kind == KindBug {
+		// Bug reports live in projects/<name>/bugs, possibly in its archive directory
+		dir := filepath.Dir(path)
+		if filepath.Base(dir) == archiveDir {
+			dir = filepath.Dir(dir)
+		}
+		entry := Entry{Kind: kind, Path: path}
+		if err := nm.describeEntry(&entry); err != nil {
+			return "", time.Time{}, err
+		}
+		return filepath.Base(filepath.Dir(dir)), entry.Timestamp, nil
+	}
+
+	data, err := nm.readNoteFile(path)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	var note struct {
+		Timestamp   time.Time              `json:"timestamp"`
+		ProjectName string                 `json:"project_name"`
+		Metadata    map[string]interface{} `json:"metadata"`
+	}
+	if err := json.Unmarshal(data, &note); err != nil {
+		return "", time.Time{}, err
+	}
+
+	project := note.ProjectName
+	if kind == KindRemember {
+		project, _ = note.Metadata["project"].(string)
+		if project == "" {
+			project = globalProject
+		}
+	}
+	return project, note.Timestamp, nil
+}
+
+// directorySizes sums the size of the files under each top-level directory of root;
+// files directly in root are counted under "."
+func directorySizes(root string) (map[string]int64, error) {
+	sizes := make(map[string]int64)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Skip what cannot be read rather than fail the whole count
+			if d != nil && d.IsDir() && path != root {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+		top := "."
+		if i := strings.IndexRune(rel, os.PathSeparator); i >= 0 {
+			top = rel[:i]
+		}
+		sizes[top] += info.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error measuring storage: %w", err)
+	}
+	return sizes, nil
+}
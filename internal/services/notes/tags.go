@@ -0,0 +1,232 @@
+package notes
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// TagKinds lists the note kinds that carry tags, in the order they are reported
+var TagKinds = []NoteKind{KindRemember, KindProgress, KindMonitor, KindInteraction}
+
+// TaggedNote is one note file as seen by tag management
+type TaggedNote struct {
+	Kind NoteKind
+	// ID is the remember or progress note ID; monitor notes and interactions, which have none,
+	// are identified as <project>/<file name without .json>
+	ID      string
+	Path    string
+	Project string
+	Tags    []string
+}
+
+// TagCount is how many notes carry a tag
+type TagCount struct {
+	Tag    string
+	Total  int
+	ByKind map[NoteKind]int
+}
+
+// loadedNote is a tagged note with its decoded contents, ready to be rewritten
+type loadedNote struct {
+	TaggedNote
+	data map[string]interface{}
+}
+
+// loadTaggedNotes decodes every note that can carry tags; files that cannot be read are skipped
+func (nm *NotesManager) loadTaggedNotes() ([]*loadedNote, error) {
+	files, err := nm.noteFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var loaded []*loadedNote
+	for _, kind := range TagKinds {
+		for _, path := range files[kind] {
+			raw, err := nm.readNoteFile(path)
+			if err != nil {
+				continue
+			}
+			var data map[string]interface{}
+			if err := json.Unmarshal(raw, &data); err != nil {
+				continue
+			}
+
+			note := &loadedNote{TaggedNote: TaggedNote{Kind: kind, Path: path}, data: data}
+			note.Project, _ = data["project_name"].(string)
+			metadata, _ := data["metadata"].(map[string]interface{})
+			switch kind {
+			case KindRemember:
+				note.ID = rememberNoteID(filepath.Base(path))
+				note.Project, _ = metadata["project"].(string)
+				if note.Project == "" {
+					note.Project = globalProject
+				}
+			case KindProgress:
+				note.ID, _ = data["id"].(string)
+			default:
+				note.ID = note.Project + "/" + strings.TrimSuffix(filepath.Base(path), ".json")
+			}
+			note.Tags = tagsOf(metadata)
+			loaded = append(loaded, note)
+		}
+	}
+	return loaded, nil
+}
+
+// tagsOf returns the tags in a decoded metadata object
+func tagsOf(metadata map[string]interface{}) []string {
+	values, _ := metadata["tags"].([]interface{})
+	tags := make([]string, 0, len(values))
+	for _, value := range values {
+		if tag, ok := value.(string); ok && tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// hasTag reports whether tags contain tag, ignoring case
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// setTags writes a note's tags back into its metadata and saves the file
+func (nm *NotesManager) setTags(note *loadedNote, tags []string) error {
+	metadata, _ := note.data["metadata"].(map[string]interface{})
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+		note.data["metadata"] = metadata
+	}
+	metadata["tags"] = tags
+	if err := nm.writeNoteFile(note.Path, note.data); err != nil {
+		return fmt.Errorf("error saving %s: %w", note.Path, err)
+	}
+	note.Tags = tags
+	return nil
+}
+
+// ListTags counts the notes carrying each tag, most used first. With projectName set,
+// only that project's notes are counted. Tags differing only in case are counted together.
+func (nm *NotesManager) ListTags(projectName string) ([]TagCount, error) {
+	loaded, err := nm.loadTaggedNotes()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]*TagCount)
+	for _, note := range loaded {
+		if projectName != "" && note.Project != projectName {
+			continue
+		}
+		for _, tag := range note.Tags {
+			key := strings.ToLower(tag)
+			count := counts[key]
+			if count == nil {
+				count = &TagCount{Tag: tag, ByKind: make(map[NoteKind]int)}
+				counts[key] = count
+			}
+			count.Total++
+			count.ByKind[note.Kind]++
+		}
+	}
+
+	result := make([]TagCount, 0, len(counts))
+	for _, count := range counts {
+		result = append(result, *count)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Total != result[j].Total {
+			return result[i].Total > result[j].Total
+		}
+		return result[i].Tag < result[j].Tag
+	})
+	return result, nil
+}
+
+// NotesWithTag returns the notes carrying a tag, ignoring case
+func (nm *NotesManager) NotesWithTag(projectName, tag string) ([]TaggedNote, error) {
+	loaded, err := nm.loadTaggedNotes()
+	if err != nil {
+		return nil, err
+	}
+
+	var tagged []TaggedNote
+	for _, note := range loaded {
+		if (projectName == "" || note.Project == projectName) && hasTag(note.Tags, tag) {
+			tagged = append(tagged, note.TaggedNote)
+		}
+	}
+	return tagged, nil
+}
+
+// RenameTag renames a tag, ignoring case, on every note carrying it and returns how many notes changed.
+// Notes that already carry the new tag keep a single copy of it.
+func (nm *NotesManager) RenameTag(oldTag, newTag string) (int, error) {
+	newTag = strings.TrimSpace(newTag)
+	if oldTag == "" || newTag == "" {
+		return 0, fmt.Errorf("tags cannot be empty")
+	}
+
+	loaded, err := nm.loadTaggedNotes()
+	if err != nil {
+		return 0, err
+	}
+
+	renamed := 0
+	for _, note := range loaded {
+		if !hasTag(note.Tags, oldTag) {
+			continue
+		}
+		tags := []string{}
+		for _, tag := range note.Tags {
+			if strings.EqualFold(tag, oldTag) {
+				tag = newTag
+			}
+			if !hasTag(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+		if err := nm.setTags(note, tags); err != nil {
+			return renamed, err
+		}
+		renamed++
+	}
+	return renamed, nil
+}
+
+// ApplyTag adds a tag to the note with this ID, or a unique prefix of it, and returns the note.
+// A note that already carries the tag is left as is.
+func (nm *NotesManager) ApplyTag(tag, id string) (*TaggedNote, error) {
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return nil, fmt.Errorf("tag cannot be empty")
+	}
+	if id == "" {
+		return nil, fmt.Errorf("note ID cannot be empty")
+	}
+
+	loaded, err := nm.loadTaggedNotes()
+	if err != nil {
+		return nil, err
+	}
+
+	match, err := matchNoteID(loaded, id, func(n *loadedNote) string { return n.ID })
+	if err != nil {
+		return nil, err
+	}
+
+	if !hasTag(match.Tags, tag) {
+		if err := nm.setTags(match, append(match.Tags, tag)); err != nil {
+			return nil, err
+		}
+	}
+	return &match.TaggedNote, nil
+}
@@ -0,0 +1,55 @@
+package notes
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRenameAndApplyTags(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	nm, err := NewNotesManager()
+	if err != nil {
+		t.Fatalf("Failed to create notes manager: %v", err)
+	}
+
+	remember := &RememberNote{Timestamp: time.Now(), Content: "check tokens", Metadata: map[string]interface{}{"project": "app", "tags": []string{"authn", "Auth"}}}
+	if err := nm.SaveUserNote("alice", remember); err != nil {
+		t.Fatalf("SaveUserNote failed: %v", err)
+	}
+	progress := &ProjectProgressNote{ProjectName: "app", Title: "Login"}
+	progress.Metadata.Tags = []string{"authn"}
+	if err := nm.SaveProjectProgress(progress); err != nil {
+		t.Fatalf("SaveProjectProgress failed: %v", err)
+	}
+	monitor := &MonitorNote{Timestamp: time.Now(), ProjectName: "app"}
+	if err := nm.SaveMonitorNote("app", monitor); err != nil {
+		t.Fatalf("SaveMonitorNote failed: %v", err)
+	}
+
+	renamed, err := nm.RenameTag("AUTHN", "auth")
+	if err != nil {
+		t.Fatalf("RenameTag failed: %v", err)
+	}
+	if renamed != 2 {
+		t.Errorf("Expected 2 notes renamed, got %d", renamed)
+	}
+	if note, _ := nm.GetUserNote("alice", remember.ID); len(note.Tags()) != 1 {
+		t.Errorf("Expected the merged tags to be deduplicated, got %v", note.Tags())
+	}
+
+	tagged, err := nm.ApplyTag("auth", "app/"+monitor.Timestamp.Format("2006-01-02-15-04-05"))
+	if err != nil {
+		t.Fatalf("ApplyTag failed: %v", err)
+	}
+	if tagged.Kind != KindMonitor {
+		t.Errorf("Expected the monitor note to be tagged, got %s", tagged.Kind)
+	}
+
+	counts, err := nm.ListTags("app")
+	if err != nil {
+		t.Fatalf("ListTags failed: %v", err)
+	}
+	if len(counts) != 1 || counts[0].Total != 3 {
+		t.Errorf("Expected auth on 3 notes, got %+v", counts)
+	}
+}
@@ -0,0 +1,194 @@
+package notes
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/journal"
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultTrashRetention is how long deleted notes stay restorable when retention.trash is not set
+	DefaultTrashRetention = 30 * 24 * time.Hour
+
+	// trashEntryFile describes a trashed note; the leading dot keeps encryption conversion away from it
+	trashEntryFile = ".trash.json"
+)
+
+// TrashEntry is a deleted note kept in ~/.wash/trash/<id> until it is restored or the trash is emptied
+type TrashEntry struct {
+	ID   string   `json:"id"`
+	Kind NoteKind `json:"kind"`
+	// Path is where the note lived, relative to the data directory
+	Path      string    `json:"path"`
+	Project   string    `json:"project,omitempty"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// trashDir returns the directory holding trashed notes
+func (nm *NotesManager) trashDir() string {
+	return filepath.Join(nm.baseDir, "trash")
+}
+
+// Trash moves a note file into the trash instead of deleting it, so it can be restored
+func (nm *NotesManager) Trash(kind NoteKind, path string, projectName string) (*TrashEntry, error) {
+	rel, err := filepath.Rel(nm.baseDir, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return nil, fmt.Errorf("%s is not in the data directory", path)
+	}
+
+	entry := &TrashEntry{
+		ID:        uuid.New().String()[:8],
+		Kind:      kind,
+		Path:      rel,
+		Project:   projectName,
+		DeletedAt: time.Now(),
+	}
+
+	dir := filepath.Join(nm.trashDir(), entry.ID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating trash directory: %w", err)
+	}
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error encoding trash entry: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, trashEntryFile), append(data, '\n'), 0644); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("error writing trash entry: %w", err)
+	}
+	if err := os.Rename(path, filepath.Join(dir, filepath.Base(path))); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("error moving note to trash: %w", err)
+	}
+	nm.record(journal.ActionDeleted, projectName, path, "moved to trash as "+entry.ID)
+
+	if kind == KindProgress && projectName != "" {
+		if err := nm.RebuildProgressIndex(projectName); err != nil {
+			return entry, err
+		}
+	}
+	return entry, nil
+}
+
+// ListTrash returns the trashed notes, most recently deleted first
+func (nm *NotesManager) ListTrash() ([]*TrashEntry, error) {
+	paths, err := filepath.Glob(filepath.Join(nm.trashDir(), "*", trashEntryFile))
+	if err != nil {
+		return nil, fmt.Errorf("error listing trash: %w", err)
+	}
+
+	var entries []*TrashEntry
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading trash entry: %w", err)
+		}
+		var entry TrashEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, fmt.Errorf("error parsing %s: %w", path, err)
+		}
+		// The entry's directory names it, whatever its file says, so restoring or emptying it
+		// cannot reach outside the trash
+		entry.ID = filepath.Base(filepath.Dir(path))
+		entries = append(entries, &entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].DeletedAt.After(entries[j].DeletedAt) })
+	return entries, nil
+}
+
+// RestoreTrash moves a trashed note, named by its ID or a unique prefix of it, back where it was
+func (nm *NotesManager) RestoreTrash(id string) (*TrashEntry, error) {
+	entries, err := nm.ListTrash()
+	if err != nil {
+		return nil, err
+	}
+
+	var entry *TrashEntry
+	for _, candidate := range entries {
+		if id != "" && strings.HasPrefix(candidate.ID, id) {
+			if entry != nil {
+				return nil, fmt.Errorf("ID %s is ambiguous; use more characters", id)
+			}
+			entry = candidate
+		}
+	}
+	if entry == nil {
+		return nil, fmt.Errorf("no trashed note with ID %s", id)
+	}
+
+	// A tampered entry must not restore over a file outside the data directory, or into the trash
+	if !filepath.IsLocal(entry.Path) || strings.HasPrefix(filepath.ToSlash(filepath.Clean(entry.Path)), "trash/") {
+		return nil, fmt.Errorf("cannot restore %s: invalid path %q", entry.ID, entry.Path)
+	}
+	target := filepath.Join(nm.baseDir, entry.Path)
+	if _, err := os.Stat(target); err == nil {
+		return nil, fmt.Errorf("cannot restore %s: a note already exists at %s", entry.ID, target)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return nil, fmt.Errorf("error creating note directory: %w", err)
+	}
+
+	dir := filepath.Join(nm.trashDir(), entry.ID)
+	if err := os.Rename(filepath.Join(dir, filepath.Base(entry.Path)), target); err != nil {
+		return nil, fmt.Errorf("error restoring note: %w", err)
+	}
+	nm.record(journal.ActionRestored, entry.Project, target, "from trash "+entry.ID)
+	if err := os.RemoveAll(dir); err != nil {
+		return entry, fmt.Errorf("error removing trash entry: %w", err)
+	}
+
+	if entry.Kind == KindProgress && entry.Project != "" {
+		if err := nm.RebuildProgressIndex(entry.Project); err != nil {
+			return entry, err
+		}
+	}
+	return entry, nil
+}
+
+// EmptyTrash permanently deletes the notes trashed before cutoff and returns how many there were.
+// With dryRun set, it only counts them.
+func (nm *NotesManager) EmptyTrash(cutoff time.Time, dryRun bool) (int, error) {
+	entries, err := nm.ListTrash()
+	if err != nil {
+		return 0, err
+	}
+
+	emptied := 0
+	for _, entry := range entries {
+		if !entry.DeletedAt.Before(cutoff) {
+			continue
+		}
+		if !dryRun {
+			if err := os.RemoveAll(filepath.Join(nm.trashDir(), entry.ID)); err != nil {
+				return emptied, fmt.Errorf("error emptying trash: %w", err)
+			}
+			nm.record(journal.ActionDeleted, entry.Project, entry.Path, "permanently deleted from trash "+entry.ID)
+		}
+		emptied++
+	}
+	return emptied, nil
+}
+
+// trashedFiles returns the note files in the trash
+func (nm *NotesManager) trashedFiles() ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(nm.trashDir(), "*", "*"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing trash: %w", err)
+	}
+
+	var files []string
+	for _, path := range matches {
+		if filepath.Base(path) != trashEntryFile {
+			files = append(files, path)
+		}
+	}
+	return files, nil
+}
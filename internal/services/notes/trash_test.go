@@ -0,0 +1,92 @@
+package notes
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTrashRestoreAndEmpty(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	nm, err := NewNotesManager()
+	if err != nil {
+		t.Fatalf("Failed to create notes manager: %v", err)
+	}
+
+	for _, content := range []string{"keep", "drop"} {
+		if err := nm.SaveUserNote("alice", &RememberNote{Timestamp: time.Now(), Content: content}); err != nil {
+			t.Fatalf("SaveUserNote failed: %v", err)
+		}
+	}
+	saved, err := nm.ListUserNotes("alice", RememberFilter{})
+	if err != nil || len(saved) != 2 {
+		t.Fatalf("Expected 2 notes, got %d (%v)", len(saved), err)
+	}
+
+	first, err := nm.DeleteUserNote("alice", saved[0].ID)
+	if err != nil {
+		t.Fatalf("DeleteUserNote failed: %v", err)
+	}
+	second, err := nm.DeleteUserNote("alice", saved[1].ID)
+	if err != nil {
+		t.Fatalf("DeleteUserNote failed: %v", err)
+	}
+	if trashed, _ := nm.ListTrash(); len(trashed) != 2 {
+		t.Fatalf("Expected 2 notes in the trash, got %d", len(trashed))
+	}
+
+	restored, err := nm.RestoreTrash(first.ID[:4])
+	if err != nil {
+		t.Fatalf("RestoreTrash failed: %v", err)
+	}
+	if restored.ID != first.ID {
+		t.Errorf("Restored %s, expected %s", restored.ID, first.ID)
+	}
+	if note, err := nm.GetUserNote("alice", saved[0].ID); err != nil || note.Content != saved[0].Content {
+		t.Errorf("Restored note is not readable: %v", err)
+	}
+
+	if emptied, err := nm.EmptyTrash(second.DeletedAt, false); err != nil || emptied != 0 {
+		t.Errorf("Expected nothing deleted before the cutoff, got %d (%v)", emptied, err)
+	}
+	if emptied, err := nm.EmptyTrash(time.Now().Add(time.Second), false); err != nil || emptied != 1 {
+		t.Errorf("Expected 1 note emptied, got %d (%v)", emptied, err)
+	}
+	if _, err := nm.RestoreTrash(second.ID); err == nil {
+		t.Error("Emptied note could still be restored")
+	}
+}
+
+func TestRestoreTrashRejectsPathsOutsideDataDir(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	nm, err := NewNotesManager()
+	if err != nil {
+		t.Fatalf("Failed to create notes manager: %v", err)
+	}
+
+	for id, path := range map[string]string{
+		"aaaa1111": filepath.Join("..", "outside.json"),
+		"bbbb2222": filepath.Join(t.TempDir(), "absolute.json"),
+		"cccc3333": filepath.Join("trash", "dddd4444", "entry.json"),
+	} {
+		dir := filepath.Join(nm.trashDir(), id)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		data, _ := json.Marshal(TrashEntry{ID: id, Kind: KindRemember, Path: path, DeletedAt: time.Now()})
+		if err := os.WriteFile(filepath.Join(dir, trashEntryFile), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, filepath.Base(path)), []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := nm.RestoreTrash(id); err == nil {
+			t.Errorf("Expected restoring %s to %s to fail", id, path)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(nm.baseDir), "outside.json")); !os.IsNotExist(err) {
+		t.Error("Expected nothing restored outside the data directory")
+	}
+}
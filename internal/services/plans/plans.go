@@ -0,0 +1,246 @@
+package plans
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/crypt"
+	"github.com/bkidd1/wash-cli/internal/utils/fsutil"
+	"github.com/google/uuid"
+)
+
+// Kind represents the kind of plan
+type Kind string
+
+const (
+	KindRefactor Kind = "refactor"
+	KindFeature  Kind = "feature"
+)
+
+// Status represents the status of a plan or step
+type Status string
+
+const (
+	StatusPending    Status = "pending"
+	StatusInProgress Status = "in_progress"
+	StatusDone       Status = "done"
+)
+
+// Step represents a single file-level step of a plan
+type Step struct {
+	Number      int        `json:"number"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Files       []string   `json:"files,omitempty"`
+	Risk        string     `json:"risk"` // e.g., "low", "medium", "high"
+	RiskNotes   string     `json:"risk_notes,omitempty"`
+	Status      Status     `json:"status"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// Plan represents an ordered multi-step plan toward a goal
+type Plan struct {
+	ID          string     `json:"id"`
+	ProjectName string     `json:"project_name"`
+	Kind        Kind       `json:"kind"`
+	Goal        string     `json:"goal"`
+	Summary     string     `json:"summary,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Status      Status     `json:"status"`
+	Steps       []Step     `json:"steps"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// CompletedSteps returns the number of completed steps
+func (p *Plan) CompletedSteps() int {
+	count := 0
+	for _, step := range p.Steps {
+		if step.Status == StatusDone {
+			count++
+		}
+	}
+	return count
+}
+
+// NextStep returns the first step that is not done, or nil if all are done
+func (p *Plan) NextStep() *Step {
+	for i := range p.Steps {
+		if p.Steps[i].Status != StatusDone {
+			return &p.Steps[i]
+		}
+	}
+	return nil
+}
+
+// CompleteStep marks a step as done and updates the plan status
+func (p *Plan) CompleteStep(number int) (*Step, error) {
+	for i := range p.Steps {
+		if p.Steps[i].Number != number {
+			continue
+		}
+		if p.Steps[i].Status == StatusDone {
+			return nil, fmt.Errorf("step %d is already done", number)
+		}
+
+		now := time.Now()
+		p.Steps[i].Status = StatusDone
+		p.Steps[i].CompletedAt = &now
+		p.UpdatedAt = now
+
+		if p.CompletedSteps() == len(p.Steps) {
+			p.Status = StatusDone
+			p.CompletedAt = &now
+		} else {
+			p.Status = StatusInProgress
+		}
+
+		return &p.Steps[i], nil
+	}
+	return nil, fmt.Errorf("plan has no step %d", number)
+}
+
+// PlanManager handles storage of plans
+type PlanManager struct {
+	baseDir string
+	cipher  *crypt.Cipher // nil when encryption at rest is off
+}
+
+// NewPlanManager creates a new PlanManager instance
+func NewPlanManager() (*PlanManager, error) {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+
+	// Plans name the files and changes they cover, so they are encrypted like the notes
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	cipher, err := crypt.Load(cfg.Encryption, dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unlock encrypted plans: %w", err)
+	}
+
+	return &PlanManager{baseDir: filepath.Join(dataDir, "projects"), cipher: cipher}, nil
+}
+
+// plansDir returns the plans directory for a project
+func (pm *PlanManager) plansDir(projectName string) string {
+	return filepath.Join(pm.baseDir, projectName, "plans")
+}
+
+// NewPlan creates a plan with fresh identifiers and pending steps
+func NewPlan(projectName string, kind Kind, goal string, steps []Step) *Plan {
+	now := time.Now()
+	for i := range steps {
+		steps[i].Number = i + 1
+		steps[i].Status = StatusPending
+	}
+
+	return &Plan{
+		ID:          uuid.New().String()[:8],
+		ProjectName: projectName,
+		Kind:        kind,
+		Goal:        goal,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Status:      StatusPending,
+		Steps:       steps,
+	}
+}
+
+// SavePlan saves a plan to the project's plans directory
+func (pm *PlanManager) SavePlan(plan *Plan) error {
+	dir := pm.plansDir(plan.ProjectName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("error creating plans directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling plan: %w", err)
+	}
+	if data, err = pm.cipher.Encrypt(data); err != nil {
+		return fmt.Errorf("error encrypting plan: %w", err)
+	}
+
+	if err := fsutil.WriteFileAtomic(filepath.Join(dir, plan.ID+".json"), data, 0644); err != nil {
+		return fmt.Errorf("error writing plan file: %w", err)
+	}
+
+	return nil
+}
+
+// LoadPlan loads a plan by ID or unique ID prefix
+func (pm *PlanManager) LoadPlan(projectName string, id string) (*Plan, error) {
+	plans, err := pm.ListPlans(projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	var match *Plan
+	for _, plan := range plans {
+		if strings.HasPrefix(plan.ID, id) {
+			if match != nil {
+				return nil, fmt.Errorf("plan ID %s is ambiguous", id)
+			}
+			match = plan
+		}
+	}
+
+	if match == nil {
+		return nil, fmt.Errorf("no plan found with ID %s", id)
+	}
+	return match, nil
+}
+
+// ListPlans returns all plans for a project, newest first
+func (pm *PlanManager) ListPlans(projectName string) ([]*Plan, error) {
+	dir := pm.plansDir(projectName)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading plans directory: %w", err)
+	}
+
+	var plans []*Plan
+	for _, file := range files {
+		if filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		if err == nil {
+			data, err = pm.cipher.Decrypt(data)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Could not read file %s: %v\n", file.Name(), err)
+			continue
+		}
+
+		var plan Plan
+		if err := json.Unmarshal(data, &plan); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Could not parse JSON in file %s: %v\n", file.Name(), err)
+			continue
+		}
+
+		plans = append(plans, &plan)
+	}
+
+	sort.Slice(plans, func(i, j int) bool {
+		return plans[i].CreatedAt.After(plans[j].CreatedAt)
+	})
+
+	return plans, nil
+}
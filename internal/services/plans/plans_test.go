@@ -0,0 +1,119 @@
+package plans
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+)
+
+func TestSaveAndLoadPlan(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(config.DataDirEnv, t.TempDir())
+	pm, err := NewPlanManager()
+	if err != nil {
+		t.Fatalf("Failed to create plan manager: %v", err)
+	}
+
+	older := NewPlan("app", KindRefactor, "Split the parser", []Step{{Title: "Extract lexer"}, {Title: "Add tests"}})
+	older.ID = "abc12345"
+	older.CreatedAt = time.Now().Add(-time.Hour)
+	newer := NewPlan("app", KindRefactor, "Rename config", []Step{{Title: "Rename"}})
+	newer.ID = "abd67890"
+	other := NewPlan("other", KindRefactor, "Elsewhere", nil)
+	for _, plan := range []*Plan{older, newer, other} {
+		if err := pm.SavePlan(plan); err != nil {
+			t.Fatalf("SavePlan failed: %v", err)
+		}
+	}
+	// Files that are not plans are skipped
+	if err := os.WriteFile(filepath.Join(pm.plansDir("app"), "broken.json"), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pm.plansDir("app"), "notes.txt"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	plans, err := pm.ListPlans("app")
+	if err != nil {
+		t.Fatalf("ListPlans failed: %v", err)
+	}
+	if len(plans) != 2 || plans[0].ID != newer.ID || plans[1].ID != older.ID {
+		t.Fatalf("Expected the app plans newest first, got %v", plans)
+	}
+	if steps := plans[1].Steps; len(steps) != 2 || steps[1].Number != 2 || steps[1].Status != StatusPending {
+		t.Errorf("Unexpected steps %+v", steps)
+	}
+
+	tests := []struct {
+		id      string
+		want    string
+		wantErr bool
+	}{
+		{"abc12345", older.ID, false},
+		{"abd", newer.ID, false},
+		{"ab", "", true},
+		{"zzz", "", true},
+	}
+	for _, tt := range tests {
+		plan, err := pm.LoadPlan("app", tt.id)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("LoadPlan(%q) = %s, want an error", tt.id, plan.ID)
+			}
+			continue
+		}
+		if err != nil || plan.ID != tt.want {
+			t.Errorf("LoadPlan(%q) = %v, %v, want %s", tt.id, plan, err, tt.want)
+		}
+	}
+
+	if plans, err := pm.ListPlans("missing"); err != nil || len(plans) != 0 {
+		t.Errorf("Expected no plans for an unknown project, got %v, %v", plans, err)
+	}
+}
+
+func TestCompleteStep(t *testing.T) {
+	tests := []struct {
+		name       string
+		done       []int
+		complete   int
+		wantErr    bool
+		wantStatus Status
+		wantNext   int
+	}{
+		{"first step", nil, 1, false, StatusInProgress, 2},
+		{"out of order", nil, 3, false, StatusInProgress, 1},
+		{"last step", []int{1, 2}, 3, false, StatusDone, 0},
+		{"already done", []int{1}, 1, true, StatusInProgress, 2},
+		{"unknown step", nil, 4, true, StatusPending, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := NewPlan("app", KindRefactor, "Tidy up", []Step{{Title: "a"}, {Title: "b"}, {Title: "c"}})
+			for _, number := range tt.done {
+				if _, err := plan.CompleteStep(number); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err := plan.CompleteStep(tt.complete)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CompleteStep(%d) error = %v, wantErr %v", tt.complete, err, tt.wantErr)
+			}
+			if plan.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s", plan.Status, tt.wantStatus)
+			}
+			next := plan.NextStep()
+			if tt.wantNext == 0 {
+				if next != nil || plan.CompletedAt == nil {
+					t.Errorf("Expected a completed plan, next step %v", next)
+				}
+			} else if next == nil || next.Number != tt.wantNext {
+				t.Errorf("NextStep() = %v, want step %d", next, tt.wantNext)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package plans
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/notes"
+	"github.com/bkidd1/wash-cli/internal/utils/git"
+)
+
+// StallThreshold is how long a plan can go without progress before it is considered stalled
+const StallThreshold = 7 * 24 * time.Hour
+
+// StepUpdate describes a step that was automatically marked done
+type StepUpdate struct {
+	Plan *Plan
+	Step *Step
+}
+
+// IsStalled reports whether an unfinished plan has seen no progress within the threshold
+func (p *Plan) IsStalled(threshold time.Duration) bool {
+	return p.Status != StatusDone && time.Since(p.UpdatedAt) > threshold
+}
+
+// ProgressNoteForStep builds the progress note recorded when a plan step is completed
+func ProgressNoteForStep(plan *Plan, step *Step) *notes.ProjectProgressNote {
+	note := &notes.ProjectProgressNote{
+		ProjectName: plan.ProjectName,
+		Type:        string(plan.Kind),
+		Title:       fmt.Sprintf("Plan %s step %d/%d: %s", plan.ID, step.Number, len(plan.Steps), step.Title),
+		Description: fmt.Sprintf("Goal: %s\n\n%s", plan.Goal, step.Description),
+	}
+	note.Changes.FilesModified = step.Files
+	note.Impact.Scope = "module"
+	note.Impact.AffectedAreas = step.Files
+	note.Impact.RiskLevel = step.Risk
+	note.Metadata.Priority = notes.PriorityMedium
+	note.Metadata.Status = notes.StatusResolved
+	note.Metadata.Tags = []string{planTag, plan.ID}
+	return note
+}
+
+// planTag tags the progress notes the tracker writes for completed steps
+const planTag = "plan"
+
+// ChangedFiles returns the files, relative to the repository root, whose contents changed since
+// the given time. In a git repository they come from git's diff against the last commit before
+// then; elsewhere from the files wash progress notes recorded, leaving out the notes the tracker
+// writes for completed steps, which only repeat the steps' files.
+func ChangedFiles(nm *notes.NotesManager, projectName string, projectPath string, since time.Time) ([]string, error) {
+	if projectPath != "" && git.IsRepo(projectPath) {
+		files, err := git.DiffFilesSince(projectPath, since)
+		if err != nil {
+			return nil, fmt.Errorf("error listing files changed since %s: %w", since.Format(time.DateOnly), err)
+		}
+		return files, nil
+	}
+
+	var files []string
+	progressNotes, err := nm.GetProgressNotesBetween(projectName, since, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	for _, note := range progressNotes {
+		if slices.Contains(note.Metadata.Tags, planTag) {
+			continue
+		}
+		files = append(files, note.Changes.FilesModified...)
+		files = append(files, note.Changes.FilesAdded...)
+		files = append(files, note.Changes.FilesDeleted...)
+		for _, rename := range note.Changes.FilesRenamed {
+			files = append(files, rename.From, rename.To)
+		}
+	}
+	for i, file := range files {
+		files[i] = repoPath(projectPath, "", file)
+	}
+	return files, nil
+}
+
+// SyncProgress marks plan steps done when all of their files have changed. A step's changes are
+// counted from the plan's creation or, when an earlier step has files in common with it, from
+// that step's completion, so the changes made for one step do not also complete the next; such a
+// step waits until the earlier ones are done. Completed steps are recorded as progress notes and
+// the updated plans are saved.
+func (pm *PlanManager) SyncProgress(nm *notes.NotesManager, projectName string, projectPath string) ([]StepUpdate, error) {
+	allPlans, err := pm.ListPlans(projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Step files are relative to the project directory, which may be below the repository root
+	root, prefix := projectPath, ""
+	if projectPath != "" && git.IsRepo(projectPath) {
+		if top, err := git.Root(projectPath); err == nil {
+			root = top
+		}
+		prefix, _ = git.Prefix(projectPath)
+	}
+
+	var updates []StepUpdate
+	for _, plan := range allPlans {
+		if plan.Status == StatusDone {
+			continue
+		}
+
+		changes := make(map[time.Time][]string)
+		var completed []int
+		for i, step := range plan.Steps {
+			if step.Status == StatusDone || len(step.Files) == 0 {
+				continue
+			}
+			since, ok := stepBaseline(plan, i)
+			if !ok {
+				continue
+			}
+			if _, ok := changes[since]; !ok {
+				// A failed diff would leave every step waiting without saying why
+				if changes[since], err = ChangedFiles(nm, projectName, projectPath, since); err != nil {
+					return updates, err
+				}
+			}
+			files := make([]string, len(step.Files))
+			for j, file := range step.Files {
+				files[j] = repoPath(root, prefix, file)
+			}
+			if allFilesChanged(files, changes[since]) {
+				completed = append(completed, step.Number)
+			}
+		}
+		if len(completed) == 0 {
+			continue
+		}
+
+		for _, number := range completed {
+			step, err := plan.CompleteStep(number)
+			if err != nil {
+				continue
+			}
+			if err := nm.SaveProjectProgress(ProgressNoteForStep(plan, step)); err != nil {
+				return updates, fmt.Errorf("error saving progress note: %w", err)
+			}
+			updates = append(updates, StepUpdate{Plan: plan, Step: step})
+		}
+
+		if err := pm.SavePlan(plan); err != nil {
+			return updates, err
+		}
+	}
+
+	return updates, nil
+}
+
+// stepBaseline returns the time the changes for a step are counted from: the latest completion of
+// an earlier step with files in common with it, or the plan's creation. It reports false while
+// such an earlier step is not done.
+func stepBaseline(plan *Plan, index int) (time.Time, bool) {
+	since := plan.CreatedAt
+	step := plan.Steps[index]
+	for _, earlier := range plan.Steps[:index] {
+		if !shareFiles(earlier.Files, step.Files) {
+			continue
+		}
+		if earlier.Status != StatusDone || earlier.CompletedAt == nil {
+			return time.Time{}, false
+		}
+		if earlier.CompletedAt.After(since) {
+			since = *earlier.CompletedAt
+		}
+	}
+	return since, true
+}
+
+// shareFiles reports whether two steps have a file in common
+func shareFiles(a []string, b []string) bool {
+	for _, file := range a {
+		for _, other := range b {
+			if filepath.Clean(file) == filepath.Clean(other) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// StalledPlans returns the unfinished plans that have seen no progress within the threshold
+func (pm *PlanManager) StalledPlans(projectName string, threshold time.Duration) ([]*Plan, error) {
+	allPlans, err := pm.ListPlans(projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	var stalled []*Plan
+	for _, plan := range allPlans {
+		if plan.IsStalled(threshold) {
+			stalled = append(stalled, plan)
+		}
+	}
+	return stalled, nil
+}
+
+// allFilesChanged reports whether every file in files appears in changed
+func allFilesChanged(files []string, changed []string) bool {
+	for _, file := range files {
+		if !slices.Contains(changed, file) {
+			return false
+		}
+	}
+	return true
+}
+
+// repoPath makes a file named relative to the project directory, whose path from the repository
+// root is prefix, or absolute, relative to the repository root
+func repoPath(root string, prefix string, file string) string {
+	if filepath.IsAbs(file) {
+		if rel, err := filepath.Rel(root, file); err == nil {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.ToSlash(filepath.Join(prefix, file))
+}
@@ -0,0 +1,126 @@
+package plans
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/notes"
+)
+
+// newTestPlan saves a plan created an hour ago with steps touching the given files
+func newTestPlan(t *testing.T, pm *PlanManager, projectName string, stepFiles ...[]string) *Plan {
+	t.Helper()
+	var steps []Step
+	for _, files := range stepFiles {
+		steps = append(steps, Step{Title: "step", Files: files})
+	}
+	plan := NewPlan(projectName, KindRefactor, "Tidy up", steps)
+	plan.CreatedAt = time.Now().Add(-time.Hour)
+	if err := pm.SavePlan(plan); err != nil {
+		t.Fatal(err)
+	}
+	return plan
+}
+
+// doneSteps returns the numbers of a saved plan's completed steps
+func doneSteps(t *testing.T, pm *PlanManager, plan *Plan) []int {
+	t.Helper()
+	saved, err := pm.LoadPlan(plan.ProjectName, plan.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var done []int
+	for _, step := range saved.Steps {
+		if step.Status == StatusDone {
+			done = append(done, step.Number)
+		}
+	}
+	return done
+}
+
+func TestSyncProgressFromNotes(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	nm, err := notes.NewNotesManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pm, err := NewPlanManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	projectPath := t.TempDir()
+	plan := newTestPlan(t, pm, "app", []string{"main.go"}, []string{"cmd/main.go", "util.go"}, []string{"main.go"})
+
+	saveChange := func(files ...string) {
+		note := &notes.ProjectProgressNote{ProjectName: "app", Title: "Work"}
+		note.Changes.FilesModified = files
+		if err := nm.SaveProjectProgress(note); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sync := func() []int {
+		if _, err := pm.SyncProgress(nm, "app", projectPath); err != nil {
+			t.Fatal(err)
+		}
+		return doneSteps(t, pm, plan)
+	}
+
+	// cmd/main.go did not change, and step 3 shares main.go with step 1
+	saveChange("main.go", "util.go")
+	if done := sync(); len(done) != 1 || done[0] != 1 {
+		t.Fatalf("Expected only step 1 done, got %v", done)
+	}
+
+	// The note recorded for step 1 lists main.go, but only new changes count for step 3
+	if done := sync(); len(done) != 1 {
+		t.Fatalf("Expected step 3 to wait for new changes, got %v", done)
+	}
+	saveChange("main.go")
+	if done := sync(); len(done) != 2 || done[1] != 3 {
+		t.Errorf("Expected step 3 done after main.go changed again, got %v", done)
+	}
+}
+
+func TestSyncProgressFromGit(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	nm, err := notes.NewNotesManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pm, err := NewPlanManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	past := time.Now().Add(-2 * time.Hour).Format(time.RFC3339)
+	for _, name := range []string{"main.go", "cmd/main.go", "util.go"} {
+		os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755)
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("package main\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{{"init", "-q", "-b", "main"}, {"add", "."}, {"commit", "-q", "-m", "Initial commit"}} {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+past, "GIT_COMMITTER_DATE="+past)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Paths are matched from the repository root, so main.go is not cmd/main.go
+	plan := newTestPlan(t, pm, "app", []string{"cmd/main.go"}, []string{"./main.go"}, []string{filepath.Join(dir, "util.go")})
+	if _, err := pm.SyncProgress(nm, "app", dir); err != nil {
+		t.Fatal(err)
+	}
+	if done := doneSteps(t, pm, plan); len(done) != 1 || done[0] != 2 {
+		t.Errorf("Expected only step 2 done, got %v", done)
+	}
+}
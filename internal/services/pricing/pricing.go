@@ -0,0 +1,250 @@
+package pricing
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/fsutil"
+	"github.com/bkidd1/wash-cli/internal/utils/tokens"
+)
+
+const (
+	// RefreshInterval is how often the pricing table is checked for updates
+	RefreshInterval = 7 * 24 * time.Hour
+
+	// fetchTimeout bounds a background refresh
+	fetchTimeout = 30 * time.Second
+	// maxTableSize is the largest pricing table accepted
+	maxTableSize = 1 << 20
+
+	tableFileName   = "pricing.json"
+	checkedFileName = "checked"
+)
+
+// PublicKey is the base64 ed25519 key pricing tables are signed with, set at build time with
+// -ldflags "-X github.com/bkidd1/wash-cli/internal/services/pricing.PublicKey=...".
+// Builds without a key keep the bundled prices.
+var PublicKey = ""
+
+// URL serves the signed pricing table, with its signature at the same URL plus .sig. It is set at
+// build time with -ldflags "-X github.com/bkidd1/wash-cli/internal/services/pricing.URL=...", once
+// the maintainers publish a table; builds without one only update from an explicit URL.
+var URL = ""
+
+// ErrNoPublicKey is returned when updates are requested from a build without a signing key
+var ErrNoPublicKey = errors.New("this build of wash has no pricing signing key; the bundled prices are used")
+
+// ErrNoURL is returned when updates are requested from a build without a pricing table URL
+var ErrNoURL = errors.New("this build of wash has no pricing table URL; pass one with --url")
+
+// Table is a signed pricing table
+type Table struct {
+	UpdatedAt time.Time                   `json:"updated_at"`
+	Models    map[string]tokens.ModelInfo `json:"models"`
+}
+
+// dir returns the directory caching the downloaded table
+func dir() (string, error) {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "pricing"), nil
+}
+
+// bundledDate returns when the bundled prices were checked
+func bundledDate() time.Time {
+	date, _ := time.Parse("2006-01-02", tokens.BundledPricesDate)
+	return date
+}
+
+// Verify checks a table's signature and decodes it
+func Verify(data, signature []byte) (*Table, error) {
+	if PublicKey == "" {
+		return nil, ErrNoPublicKey
+	}
+	key, err := base64.StdEncoding.DecodeString(PublicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid pricing public key")
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil || !ed25519.Verify(ed25519.PublicKey(key), data, sig) {
+		return nil, fmt.Errorf("pricing table signature is invalid")
+	}
+
+	var table Table
+	if err := json.Unmarshal(data, &table); err != nil {
+		return nil, fmt.Errorf("error parsing pricing table: %w", err)
+	}
+	if len(table.Models) == 0 {
+		return nil, fmt.Errorf("pricing table lists no models")
+	}
+	return &table, nil
+}
+
+// Cached returns the downloaded table, verified again, or nil when there is none
+func Cached() (*Table, error) {
+	d, err := dir()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(d, tableFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading pricing table: %w", err)
+	}
+	signature, err := os.ReadFile(filepath.Join(d, tableFileName+".sig"))
+	if err != nil {
+		return nil, fmt.Errorf("error reading pricing signature: %w", err)
+	}
+	return Verify(data, signature)
+}
+
+// Apply loads the downloaded table, when there is one newer than the bundled prices, into the
+// prices used for estimates and the spend cap. It returns the table applied, or nil.
+func Apply() (*Table, error) {
+	if PublicKey == "" {
+		return nil, nil
+	}
+	table, err := Cached()
+	if err != nil || table == nil {
+		return nil, err
+	}
+	if !table.UpdatedAt.After(bundledDate()) {
+		return nil, nil
+	}
+	tokens.UpdateModels(table.Models)
+	return table, nil
+}
+
+// Update downloads and verifies the table at url, or at URL when url is empty, and its signature,
+// caches it and applies it. Tables older than the ones already in use are rejected, so an old
+// signed table cannot be replayed.
+func Update(ctx context.Context, url string) (*Table, error) {
+	table, err := download(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	tokens.UpdateModels(table.Models)
+	return table, nil
+}
+
+// download fetches, verifies and caches the table at url without applying it
+func download(ctx context.Context, url string) (*Table, error) {
+	if PublicKey == "" {
+		return nil, ErrNoPublicKey
+	}
+	if url == "" {
+		url = URL
+	}
+	if url == "" {
+		return nil, ErrNoURL
+	}
+
+	d, err := dir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(d, 0755); err != nil {
+		return nil, fmt.Errorf("error creating pricing directory: %w", err)
+	}
+	// Record the attempt whatever its outcome, so a failing endpoint is not retried by every command
+	defer os.WriteFile(filepath.Join(d, checkedFileName), []byte(time.Now().Format(time.RFC3339)+"\n"), 0644)
+
+	data, err := fetch(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := fetch(ctx, url+".sig")
+	if err != nil {
+		return nil, err
+	}
+	table, err := Verify(data, signature)
+	if err != nil {
+		return nil, err
+	}
+
+	current := bundledDate()
+	if cached, err := Cached(); err == nil && cached != nil && cached.UpdatedAt.After(current) {
+		current = cached.UpdatedAt
+	}
+	if table.UpdatedAt.Before(current) {
+		return nil, fmt.Errorf("downloaded pricing table from %s is older than the prices in use (%s)", table.UpdatedAt.Format("2006-01-02"), current.Format("2006-01-02"))
+	}
+
+	if err := fsutil.WriteFileAtomic(filepath.Join(d, tableFileName+".sig"), signature, 0644); err != nil {
+		return nil, fmt.Errorf("error saving pricing signature: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(filepath.Join(d, tableFileName), data, 0644); err != nil {
+		return nil, fmt.Errorf("error saving pricing table: %w", err)
+	}
+	return table, nil
+}
+
+// Refresh applies the downloaded table and, when the last check is older than RefreshInterval,
+// looks for a new one in the background, so the network never holds up a command. A new table is
+// only cached, and applied from the next command on, since estimates may already be running.
+// Failures leave the current prices in place.
+func Refresh(url string) error {
+	if PublicKey == "" {
+		return nil
+	}
+	if _, err := Apply(); err != nil {
+		return err
+	}
+	if url == "" && URL == "" {
+		return nil
+	}
+
+	d, err := dir()
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(filepath.Join(d, checkedFileName)); err == nil && time.Since(info.ModTime()) < RefreshInterval {
+		return nil
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+		defer cancel()
+		download(ctx, url)
+	}()
+	return nil
+}
+
+// fetch downloads a URL of at most maxTableSize bytes
+func fetch(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error downloading %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxTableSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("error downloading %s: %w", url, err)
+	}
+	if len(data) > maxTableSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", url, maxTableSize)
+	}
+	return data, nil
+}
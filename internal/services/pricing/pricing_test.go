@@ -0,0 +1,118 @@
+package pricing
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/utils/tokens"
+)
+
+func TestUpdate(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(key string) { PublicKey = key }(PublicKey)
+	PublicKey = base64.StdEncoding.EncodeToString(public)
+
+	var table, signature []byte
+	serve := func(updatedAt time.Time, price float64) {
+		table, _ = json.Marshal(Table{
+			UpdatedAt: updatedAt,
+			Models:    map[string]tokens.ModelInfo{"test-model": {ContextWindow: 1000, InputPer1K: price, OutputPer1K: price}},
+		})
+		signature = []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(private, table)))
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/pricing.json.sig" {
+			w.Write(signature)
+			return
+		}
+		w.Write(table)
+	}))
+	defer server.Close()
+	url := server.URL + "/pricing.json"
+	defer delete(tokens.Models, "test-model")
+
+	if _, err := Update(context.Background(), ""); !errors.Is(err, ErrNoURL) {
+		t.Errorf("Expected updates without a URL to be refused, got %v", err)
+	}
+
+	serve(time.Now(), 0.5)
+	if _, err := Update(context.Background(), url); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if tokens.Models["test-model"].InputPer1K != 0.5 {
+		t.Errorf("Expected the downloaded price to be applied, got %+v", tokens.Models["test-model"])
+	}
+	if cached, err := Cached(); err != nil || cached == nil {
+		t.Errorf("Expected the table to be cached, got %v, %v", cached, err)
+	}
+
+	serve(time.Now(), 0.9)
+	signature = []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(private, []byte("other"))))
+	if _, err := Update(context.Background(), url); err == nil {
+		t.Error("Expected a bad signature to be rejected")
+	}
+
+	serve(time.Now().AddDate(0, 0, -1), 0.1)
+	if _, err := Update(context.Background(), url); err == nil {
+		t.Error("Expected an older table to be rejected")
+	}
+	if tokens.Models["test-model"].InputPer1K != 0.5 {
+		t.Errorf("Expected rejected tables to leave prices alone, got %+v", tokens.Models["test-model"])
+	}
+}
+
+func TestRefreshDownloadsInBackground(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(key string) { PublicKey = key }(PublicKey)
+	PublicKey = base64.StdEncoding.EncodeToString(public)
+
+	table, _ := json.Marshal(Table{
+		UpdatedAt: time.Now(),
+		Models:    map[string]tokens.ModelInfo{"refresh-model": {ContextWindow: 1000, InputPer1K: 0.5, OutputPer1K: 0.5}},
+	})
+	signature := []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(private, table)))
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		if r.URL.Path == "/pricing.json.sig" {
+			w.Write(signature)
+			return
+		}
+		w.Write(table)
+	}))
+	defer server.Close()
+
+	// The server holds the download, so Refresh returning shows it did not wait
+	if err := Refresh(server.URL + "/pricing.json"); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	close(release)
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if cached, err := Cached(); err == nil && cached != nil {
+			if _, ok := tokens.Models["refresh-model"]; ok {
+				t.Error("Expected the new table to wait for the next command")
+			}
+			return
+		}
+	}
+	t.Error("Expected the table to be downloaded and cached")
+}
@@ -0,0 +1,283 @@
+package relevance
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/utils/analyzable"
+	"github.com/bkidd1/wash-cli/internal/utils/git"
+	"github.com/bkidd1/wash-cli/internal/utils/ignore"
+	"github.com/bkidd1/wash-cli/internal/utils/secrets"
+)
+
+const (
+	// DefaultLimit is how many source files are included with a bug analysis
+	DefaultLimit = 3
+	// maxScannedFiles bounds the walk in very large projects
+	maxScannedFiles = 5000
+	// maxSnippetLines is the longest excerpt taken from a file
+	maxSnippetLines = 80
+	// linesBeforeHit is how much of the code before a mention an excerpt keeps
+	linesBeforeHit = 20
+	// recentWindow is how far back changed files count as recent
+	recentWindow = 7 * 24 * time.Hour
+)
+
+// Scores of the signals that make a file relevant
+const (
+	scoreNamed    = 10
+	scoreDefines  = 6
+	scoreUses     = 2
+	scoreRecent   = 3
+	scoreKeyword  = 1
+	maxUsesScore  = 6
+	maxKeywordHit = 3
+)
+
+var (
+	// fileMentionPattern finds file names such as session.go or internal/auth/session.go
+	fileMentionPattern = regexp.MustCompile(`[\w./-]*\w\.[A-Za-z][A-Za-z0-9]{0,5}\b`)
+	// wordPattern finds identifiers and dotted names such as auth.Refresh
+	wordPattern = regexp.MustCompile("`?[A-Za-z_][A-Za-z0-9_]*(?:\\.[A-Za-z_][A-Za-z0-9_]*)*(?:\\(\\))?`?")
+	// camelCase marks identifiers with an uppercase letter after a lowercase one
+	camelCase = regexp.MustCompile(`[a-z0-9][A-Z]`)
+)
+
+// secretFiles are files that hold credentials rather than code; they are never excerpted, whatever
+// the description names
+var secretFiles = map[string]bool{
+	"credentials": true, "credentials.json": true, "id_rsa": true, "id_ecdsa": true, "id_ed25519": true,
+	"secrets.json": true, "secrets.yaml": true, "secrets.yml": true, "service-account.json": true,
+}
+
+// secretExtensions are the extensions of key and certificate stores
+var secretExtensions = map[string]bool{".pem": true, ".key": true, ".p12": true, ".pfx": true, ".jks": true, ".keystore": true}
+
+// stopwords are common words of bug descriptions that say nothing about the code
+var stopwords = map[string]bool{
+	"about": true, "after": true, "again": true, "always": true, "before": true, "being": true,
+	"could": true, "doesn": true, "error": true, "every": true, "fails": true, "never": true,
+	"should": true, "there": true, "these": true, "those": true, "throws": true, "using": true,
+	"where": true, "which": true, "while": true, "would": true, "working": true, "instead": true,
+}
+
+// Snippet is an excerpt of a project file that looks relevant to a bug
+type Snippet struct {
+	// Path is relative to the project root, with forward slashes
+	Path string
+	// StartLine and EndLine are the 1-based lines of the excerpt
+	StartLine int
+	EndLine   int
+	Content   string
+	// Reasons says why the file was chosen
+	Reasons []string
+	score   int
+	recent  bool
+}
+
+// Name identifies the excerpt for a prompt, with its lines and why it was chosen
+func (s Snippet) Name() string {
+	return fmt.Sprintf("%s:%d-%d (%s)", s.Path, s.StartLine, s.EndLine, strings.Join(s.Reasons, ", "))
+}
+
+// Find returns up to limit excerpts of the files in the project at root that the bug description
+// names, that define or use the symbols it mentions, or that changed recently and share its words.
+// Files ignored by .gitignore, dotfiles such as .env and .npmrc, key files, binary and generated
+// files are skipped, and any secrets left in an excerpt are redacted.
+func Find(root, description string, limit int) ([]Snippet, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+	named, symbols, keywords := mentions(description)
+	recent := recentFiles(root)
+	if len(named) == 0 && len(symbols) == 0 && len(recent) == 0 {
+		return nil, nil
+	}
+
+	patterns, err := ignore.LoadGitignorePatterns(root)
+	if err != nil {
+		patterns = ignore.DefaultIgnorePatterns
+	}
+	definitions := make(map[string]*regexp.Regexp, len(symbols))
+	uses := make(map[string]*regexp.Regexp, len(symbols))
+	for _, symbol := range symbols {
+		quoted := regexp.QuoteMeta(symbol)
+		definitions[symbol] = regexp.MustCompile(`\b(func|def|class|type|interface|struct|enum|trait|fn|function|const|var|let|module)\s+(\([^)]*\)\s*)?` + quoted + `\b`)
+		uses[symbol] = regexp.MustCompile(`\b` + quoted + `\b`)
+	}
+
+	var found []Snippet
+	scanned := 0
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if info.IsDir() {
+			if strings.HasPrefix(info.Name(), ".") || ignore.ShouldIgnore(rel, patterns) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ignore.ShouldIgnore(rel, patterns) || isSecretFile(info.Name()) {
+			return nil
+		}
+		if scanned++; scanned > maxScannedFiles {
+			return filepath.SkipAll
+		}
+
+		snippet := Snippet{Path: rel, recent: recent[rel]}
+		for _, name := range named {
+			if rel == name || strings.HasSuffix(rel, "/"+name) || info.Name() == filepath.Base(name) {
+				snippet.score += scoreNamed
+				snippet.Reasons = append(snippet.Reasons, "named in the description")
+				break
+			}
+		}
+		if len(symbols) == 0 && !snippet.recent && snippet.score == 0 {
+			return nil
+		}
+		if analyzable.Check(path, 0) != nil {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		lines := strings.Split(string(data), "\n")
+
+		hit := -1
+		usesScore := 0
+		for _, symbol := range symbols {
+			if loc := definitions[symbol].FindIndex(data); loc != nil {
+				snippet.score += scoreDefines
+				snippet.Reasons = append(snippet.Reasons, "defines "+symbol)
+				hit = lineOf(data, loc[0])
+			} else if loc := uses[symbol].FindIndex(data); loc != nil && usesScore < maxUsesScore {
+				usesScore += scoreUses
+				snippet.Reasons = append(snippet.Reasons, "uses "+symbol)
+				if hit < 0 {
+					hit = lineOf(data, loc[0])
+				}
+			}
+		}
+		snippet.score += usesScore
+
+		if snippet.recent {
+			lower := strings.ToLower(string(data))
+			hits := 0
+			for _, keyword := range keywords {
+				if hits < maxKeywordHit && strings.Contains(lower, keyword) {
+					hits++
+				}
+			}
+			// A recent change only counts when the file has something to do with the bug
+			if hits > 0 || snippet.score > 0 {
+				snippet.score += scoreRecent + hits*scoreKeyword
+				snippet.Reasons = append(snippet.Reasons, "changed recently")
+			}
+		}
+		if snippet.score == 0 {
+			return nil
+		}
+
+		snippet.StartLine, snippet.EndLine = excerpt(len(lines), hit)
+		snippet.Content, _ = secrets.Redact(strings.Join(lines[snippet.StartLine-1:snippet.EndLine], "\n"))
+		found = append(found, snippet)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error searching project files: %w", err)
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].score != found[j].score {
+			return found[i].score > found[j].score
+		}
+		return found[i].Path < found[j].Path
+	})
+	if len(found) > limit {
+		found = found[:limit]
+	}
+	return found, nil
+}
+
+// isSecretFile reports whether a file name is a dotfile, such as .env or .npmrc, or a known
+// credential or key file
+func isSecretFile(name string) bool {
+	return strings.HasPrefix(name, ".") || secretFiles[strings.ToLower(name)] || secretExtensions[strings.ToLower(filepath.Ext(name))]
+}
+
+// mentions extracts the file names, code symbols and other words of a description. Symbols are
+// words that look like code: camelCase, snake_case, dotted, called or quoted in backticks.
+func mentions(description string) (files, symbols, keywords []string) {
+	seenFiles := make(map[string]bool)
+	for _, file := range fileMentionPattern.FindAllString(description, -1) {
+		file = strings.TrimPrefix(file, "./")
+		if !seenFiles[file] {
+			seenFiles[file] = true
+			files = append(files, file)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, word := range wordPattern.FindAllString(description, -1) {
+		quoted := strings.HasPrefix(word, "`")
+		called := strings.HasSuffix(strings.Trim(word, "`"), "()")
+		word = strings.TrimSuffix(strings.Trim(word, "`"), "()")
+		if seen[word] || seenFiles[word] {
+			continue
+		}
+		seen[word] = true
+
+		parts := strings.Split(word, ".")
+		last := parts[len(parts)-1]
+		codeLike := quoted || called || len(parts) > 1 || strings.Contains(word, "_") || camelCase.MatchString(word)
+		switch {
+		case codeLike && len(last) >= 3 && !seen["symbol:"+last]:
+			seen["symbol:"+last] = true
+			symbols = append(symbols, last)
+		case !codeLike && len(word) >= 5 && !stopwords[strings.ToLower(word)]:
+			keywords = append(keywords, strings.ToLower(word))
+		}
+	}
+	return files, symbols, keywords
+}
+
+// recentFiles returns the project files changed in git within the recent window
+func recentFiles(root string) map[string]bool {
+	recent := make(map[string]bool)
+	files, err := git.ChangedFilesSince(root, time.Now().Add(-recentWindow))
+	if err != nil {
+		return recent
+	}
+	for _, file := range files {
+		recent[filepath.ToSlash(file)] = true
+	}
+	return recent
+}
+
+// lineOf returns the 0-based line of a byte offset
+func lineOf(data []byte, offset int) int {
+	return strings.Count(string(data[:offset]), "\n")
+}
+
+// excerpt returns the 1-based lines kept of a file: from a little before the hit, or from the top
+// without one, up to maxSnippetLines
+func excerpt(total, hit int) (start, end int) {
+	start = 1
+	if hit >= 0 {
+		start = max(hit-linesBeforeHit, 0) + 1
+	}
+	end = min(start+maxSnippetLines-1, total)
+	return start, end
+}
@@ -0,0 +1,265 @@
+package retrieval
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bkidd1/wash-cli/internal/services/usage"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/crypt"
+	"github.com/bkidd1/wash-cli/internal/utils/fsutil"
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	// embeddingModel is the model used to embed notes and queries
+	embeddingModel = openai.SmallEmbedding3
+	// maxQueryChars limits how much of a query is embedded
+	maxQueryChars = 8000
+)
+
+// Entry is a stored embedding for a piece of text
+type Entry struct {
+	Text   string    `json:"text"`
+	Model  string    `json:"model"`
+	Vector []float32 `json:"vector"`
+}
+
+// Result is a retrieved text with its similarity to the query
+type Result struct {
+	Text  string
+	Score float64
+}
+
+// Retriever embeds texts and finds the ones most similar to a query
+type Retriever struct {
+	client    *openai.Client
+	storePath string
+	cipher    *crypt.Cipher // nil when encryption at rest is off
+	mu        sync.Mutex
+	entries   map[string]Entry
+}
+
+// NewRetriever creates a retriever backed by the embedding store in ~/.wash/retrieval
+func NewRetriever(apiKey string) (*Retriever, error) {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+
+	// The store keeps the text of every embedded note, so it is encrypted like the notes
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	cipher, err := crypt.Load(cfg.Encryption, dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unlock encrypted embedding store: %w", err)
+	}
+
+	r := &Retriever{
+		client:    usage.NewClient(apiKey),
+		storePath: filepath.Join(dataDir, "retrieval", "embeddings.json"),
+		cipher:    cipher,
+		entries:   make(map[string]Entry),
+	}
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// load reads the embedding store from disk
+func (r *Retriever) load() error {
+	data, err := os.ReadFile(r.storePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("error reading embedding store: %w", err)
+	}
+	if data, err = r.cipher.Decrypt(data); err != nil {
+		return fmt.Errorf("error decrypting embedding store: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &r.entries); err != nil {
+		// A corrupt store only costs re-embedding, so start fresh
+		r.entries = make(map[string]Entry)
+	}
+	return nil
+}
+
+// save writes the embedding store to disk, readable only by the user
+func (r *Retriever) save() error {
+	if err := os.MkdirAll(filepath.Dir(r.storePath), 0755); err != nil {
+		return fmt.Errorf("error creating retrieval directory: %w", err)
+	}
+
+	data, err := json.Marshal(r.entries)
+	if err != nil {
+		return fmt.Errorf("error marshaling embedding store: %w", err)
+	}
+	if data, err = r.cipher.Encrypt(data); err != nil {
+		return fmt.Errorf("error encrypting embedding store: %w", err)
+	}
+
+	if err := fsutil.WriteFileAtomic(r.storePath, data, 0600); err != nil {
+		return fmt.Errorf("error writing embedding store: %w", err)
+	}
+	return nil
+}
+
+// key returns the store key for a text
+func key(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// Index embeds any texts not yet in the store and saves it
+func (r *Retriever) Index(ctx context.Context, texts []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var missing []string
+	seen := make(map[string]bool)
+	for _, text := range texts {
+		k := key(text)
+		if entry, ok := r.entries[k]; (ok && entry.Model == string(embeddingModel)) || seen[k] {
+			continue
+		}
+		seen[k] = true
+		missing = append(missing, text)
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	vectors, err := r.embed(ctx, missing)
+	if err != nil {
+		return err
+	}
+
+	for i, text := range missing {
+		r.entries[key(text)] = Entry{Text: text, Model: string(embeddingModel), Vector: vectors[i]}
+	}
+
+	return r.save()
+}
+
+// Forget removes the stored embeddings of texts, so a deleted note's text does not stay on disk
+func (r *Retriever) Forget(texts ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	removed := false
+	for _, text := range texts {
+		k := key(text)
+		if _, ok := r.entries[k]; ok {
+			delete(r.entries, k)
+			removed = true
+		}
+	}
+
+	if !removed {
+		return nil
+	}
+	return r.save()
+}
+
+// embed returns the embedding vectors for texts in input order
+func (r *Retriever) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	resp, err := r.client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+		Input: texts,
+		Model: embeddingModel,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating embeddings: %w", err)
+	}
+
+	if len(resp.Data) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(resp.Data))
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, data := range resp.Data {
+		vectors[data.Index] = data.Embedding
+	}
+	return vectors, nil
+}
+
+// TopK returns the k candidates most similar to the query, best first
+func (r *Retriever) TopK(ctx context.Context, query string, candidates []string, k int) ([]Result, error) {
+	if err := r.Index(ctx, candidates); err != nil {
+		return nil, err
+	}
+
+	if len(query) > maxQueryChars {
+		// Cutting inside a multi-byte character would send invalid UTF-8
+		query = strings.ToValidUTF8(query[:maxQueryChars], "")
+	}
+
+	queryVectors, err := r.embed(ctx, []string{query})
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var entries []Entry
+	for _, text := range candidates {
+		if entry, ok := r.entries[key(text)]; ok {
+			entries = append(entries, entry)
+		}
+	}
+
+	return Rank(queryVectors[0], entries, k), nil
+}
+
+// Rank scores entries against the query vector and returns the top k, best first
+func Rank(query []float32, entries []Entry, k int) []Result {
+	results := make([]Result, 0, len(entries))
+	for _, entry := range entries {
+		results = append(results, Result{Text: entry.Text, Score: Cosine(query, entry.Vector)})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+
+	if k > 0 && len(results) > k {
+		results = results[:k]
+	}
+	return results
+}
+
+// Cosine returns the cosine similarity of two vectors
+func Cosine(a []float32, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
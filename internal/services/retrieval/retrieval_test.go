@@ -0,0 +1,63 @@
+package retrieval
+
+import (
+	"testing"
+
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+)
+
+func TestRank(t *testing.T) {
+	entries := []Entry{
+		{Text: "unrelated", Vector: []float32{0, 1}},
+		{Text: "close", Vector: []float32{1, 0.1}},
+		{Text: "exact", Vector: []float32{1, 0}},
+	}
+
+	results := Rank([]float32{1, 0}, entries, 2)
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+
+	if results[0].Text != "exact" || results[1].Text != "close" {
+		t.Errorf("Expected [exact close], got [%s %s]", results[0].Text, results[1].Text)
+	}
+}
+
+func TestCosineMismatchedVectors(t *testing.T) {
+	if score := Cosine([]float32{1, 0}, []float32{1}); score != 0 {
+		t.Errorf("Expected 0 for mismatched vectors, got %f", score)
+	}
+}
+
+func TestForgetRemovesStoredText(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(config.DataDirEnv, t.TempDir())
+
+	r, err := NewRetriever("")
+	if err != nil {
+		t.Fatalf("NewRetriever failed: %v", err)
+	}
+	for _, text := range []string{"keep me", "delete me"} {
+		r.entries[key(text)] = Entry{Text: text, Model: string(embeddingModel), Vector: []float32{1, 0}}
+	}
+	if err := r.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Forget("delete me", "never stored"); err != nil {
+		t.Fatalf("Forget failed: %v", err)
+	}
+
+	// The store on disk no longer holds the text
+	reloaded, err := NewRetriever("")
+	if err != nil {
+		t.Fatalf("NewRetriever failed: %v", err)
+	}
+	if _, ok := reloaded.entries[key("delete me")]; ok {
+		t.Error("Forgotten text is still stored")
+	}
+	if _, ok := reloaded.entries[key("keep me")]; !ok {
+		t.Error("Other text was removed")
+	}
+}
@@ -0,0 +1,296 @@
+package retro
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/bugs"
+	"github.com/bkidd1/wash-cli/internal/services/findings"
+	"github.com/bkidd1/wash-cli/internal/services/journal"
+	"github.com/bkidd1/wash-cli/internal/services/notes"
+)
+
+const (
+	// NoteType is the progress note type retros are stored as, so weekly summaries include them
+	NoteType = "retro"
+
+	// sessionGap is the longest pause between two activities of the same work session
+	sessionGap = 30 * time.Minute
+	// activityTime is counted for every activity, so a session of one activity is not zero
+	activityTime = 5 * time.Minute
+	// digestType is the progress note type of the daily digests written by wash notes compact
+	digestType = "digest"
+)
+
+// Week is the data a retro reflects on
+type Week struct {
+	Project string
+	Start   time.Time
+	End     time.Time
+
+	// Digests are the daily digests of the week and Progress the other progress notes
+	Digests  []*notes.ProjectProgressNote
+	Progress []*notes.ProjectProgressNote
+	// Previous is the latest retro before this week, if any
+	Previous *notes.ProjectProgressNote
+
+	// Active is the time spent per day, estimated from monitor notes and the activity journal
+	Active map[string]time.Duration
+
+	BugsReported int
+	BugsResolved int
+	Analyses     int
+	// Critical and ShouldFix count the findings of the files analyzed this week
+	Critical  int
+	ShouldFix int
+}
+
+// Gather collects a project's week ending at end from its notes, bugs, findings and activity
+func Gather(nm *notes.NotesManager, bm *bugs.BugManager, fm *findings.FindingsManager, project string, end time.Time) (*Week, error) {
+	week := &Week{Project: project, Start: end.AddDate(0, 0, -7), End: end}
+
+	progress, err := nm.GetProgressNotesBetween(project, week.Start, week.End)
+	if err != nil {
+		return nil, fmt.Errorf("error loading progress notes: %w", err)
+	}
+	for _, note := range progress {
+		switch note.Type {
+		case digestType:
+			week.Digests = append(week.Digests, note)
+		case NoteType:
+		default:
+			week.Progress = append(week.Progress, note)
+		}
+	}
+	week.Previous, err = Latest(nm, project, week.End)
+	if err != nil {
+		return nil, err
+	}
+
+	reports, err := bm.List(project, true)
+	if err != nil {
+		return nil, err
+	}
+	for _, report := range reports {
+		if report.ReportedAt.Before(week.Start) || !report.ReportedAt.Before(week.End) {
+			continue
+		}
+		week.BugsReported++
+		if report.IsResolved() {
+			week.BugsResolved++
+		}
+	}
+
+	if state, err := fm.Load(project); err == nil {
+		for _, file := range state.Files {
+			if !file.AnalyzedAt.Before(week.Start) && file.AnalyzedAt.Before(week.End) {
+				week.Critical += len(file.CriticalIssues)
+				week.ShouldFix += len(file.ShouldFix)
+			}
+		}
+	}
+
+	// Work sessions are read from when monitor notes were taken and wash was used
+	times, err := nm.MonitorNoteTimes(project, week.Start, week.End)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := journal.Load(journal.Filter{Since: week.Start, Project: project})
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !entry.Timestamp.Before(week.End) {
+			continue
+		}
+		times = append(times, entry.Timestamp)
+		if entry.Action == journal.ActionAnalysis {
+			week.Analyses++
+		}
+	}
+	week.Active = ActiveTime(times)
+
+	return week, nil
+}
+
+// Latest returns the project's most recent retro before a time, or nil if there is none
+func Latest(nm *notes.NotesManager, project string, before time.Time) (*notes.ProjectProgressNote, error) {
+	progress, err := nm.GetProgressNotesBetween(project, time.Time{}, before)
+	if err != nil {
+		return nil, fmt.Errorf("error loading progress notes: %w", err)
+	}
+	var latest *notes.ProjectProgressNote
+	for _, note := range progress {
+		if note.Type == NoteType && (latest == nil || note.Timestamp.After(latest.Timestamp)) {
+			latest = note
+		}
+	}
+	return latest, nil
+}
+
+// ActiveTime estimates the time spent per local day from activity times: activities less than
+// sessionGap apart belong to one session, which lasts from its first activity to activityTime
+// after its last
+func ActiveTime(times []time.Time) map[string]time.Duration {
+	sorted := append([]time.Time(nil), times...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Before(sorted[j]) })
+
+	active := make(map[string]time.Duration)
+	for i := 0; i < len(sorted); {
+		start, last := sorted[i], sorted[i]
+		for i++; i < len(sorted) && sorted[i].Sub(last) <= sessionGap; i++ {
+			last = sorted[i]
+		}
+		active[start.Local().Format("2006-01-02")] += last.Sub(start) + activityTime
+	}
+	return active
+}
+
+// TotalActive returns the time spent over the week
+func (w *Week) TotalActive() time.Duration {
+	var total time.Duration
+	for _, d := range w.Active {
+		total += d
+	}
+	return total
+}
+
+// String formats the week as a markdown overview, for the terminal and for the model
+func (w *Week) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Week of %s to %s\n\n", w.Start.Format("2006-01-02"), w.End.AddDate(0, 0, -1).Format("2006-01-02"))
+
+	b.WriteString("## Time\n")
+	if len(w.Active) == 0 {
+		b.WriteString("No recorded activity\n")
+	} else {
+		days := make([]string, 0, len(w.Active))
+		for day := range w.Active {
+			days = append(days, day)
+		}
+		sort.Strings(days)
+		for _, day := range days {
+			fmt.Fprintf(&b, "- %s: %s\n", day, formatDuration(w.Active[day]))
+		}
+		fmt.Fprintf(&b, "- Total: %s over %d day(s)\n", formatDuration(w.TotalActive()), len(days))
+	}
+
+	b.WriteString("\n## Feedback\n")
+	fmt.Fprintf(&b, "- %d analyses, with %d critical and %d should-fix findings in the files analyzed\n", w.Analyses, w.Critical, w.ShouldFix)
+	fmt.Fprintf(&b, "- %d bugs reported, %d of them resolved\n", w.BugsReported, w.BugsResolved)
+
+	if len(w.Digests) > 0 {
+		b.WriteString("\n## Daily digests\n")
+		for _, note := range w.Digests {
+			fmt.Fprintf(&b, "- %s: %s\n", note.Timestamp.Local().Format("Mon 2006-01-02"), firstLine(note.Description))
+		}
+	}
+	if len(w.Progress) > 0 {
+		b.WriteString("\n## Progress\n")
+		for _, note := range w.Progress {
+			fmt.Fprintf(&b, "- %s\n", note.Title)
+		}
+	}
+	if w.Previous != nil {
+		fmt.Fprintf(&b, "\n## Last retro (%s)\n%s\n", w.Previous.Timestamp.Local().Format("2006-01-02"), Commitments(w.Previous))
+	}
+	return b.String()
+}
+
+// Entry is what the user takes away from a week
+type Entry struct {
+	Keep  []string
+	Stop  []string
+	Start []string
+	// Observations is the model's reading of the week, if one was generated
+	Observations string
+}
+
+// Empty reports whether no keep, stop or start item was given
+func (e *Entry) Empty() bool {
+	return len(e.Keep) == 0 && len(e.Stop) == 0 && len(e.Start) == 0
+}
+
+// NewNote turns a retro into a progress note of type retro
+func NewNote(week *Week, entry *Entry) *notes.ProjectProgressNote {
+	var b strings.Builder
+	writeItems(&b, "Keep", entry.Keep)
+	writeItems(&b, "Stop", entry.Stop)
+	writeItems(&b, "Start", entry.Start)
+	if entry.Observations != "" {
+		fmt.Fprintf(&b, "## Observations\n%s\n\n", strings.TrimSpace(entry.Observations))
+	}
+	fmt.Fprintf(&b, "## Week in numbers\n%s active, %d analyses, %d critical findings, %d bugs reported, %d resolved\n",
+		formatDuration(week.TotalActive()), week.Analyses, week.Critical, week.BugsReported, week.BugsResolved)
+
+	note := &notes.ProjectProgressNote{
+		ProjectName: week.Project,
+		Type:        NoteType,
+		Title:       fmt.Sprintf("Weekly retro %s to %s", week.Start.Format("2006-01-02"), week.End.AddDate(0, 0, -1).Format("2006-01-02")),
+		Description: b.String(),
+	}
+	note.Impact.Scope = "project-wide"
+	note.Impact.RiskLevel = "low"
+	note.Metadata.Priority = notes.PriorityMedium
+	note.Metadata.Status = notes.StatusResolved
+	note.Metadata.Tags = []string{NoteType}
+	return note
+}
+
+// Commitments returns the Stop and Start sections of a retro note, which the next retro checks
+func Commitments(note *notes.ProjectProgressNote) string {
+	var parts []string
+	for _, heading := range []string{"Stop", "Start"} {
+		if section := section(note.Description, heading); section != "" {
+			parts = append(parts, fmt.Sprintf("%s:\n%s", heading, section))
+		}
+	}
+	if len(parts) == 0 {
+		return "No stop or start items"
+	}
+	return strings.Join(parts, "\n")
+}
+
+// writeItems writes one "## Heading" list of a retro
+func writeItems(b *strings.Builder, heading string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "## %s\n", heading)
+	for _, item := range items {
+		fmt.Fprintf(b, "- %s\n", item)
+	}
+	b.WriteString("\n")
+}
+
+// section returns the trimmed body of a "## Heading" section of markdown
+func section(markdown, heading string) string {
+	_, rest, found := strings.Cut(markdown, "## "+heading+"\n")
+	if !found {
+		return ""
+	}
+	if end := strings.Index(rest, "\n## "); end >= 0 {
+		rest = rest[:end]
+	}
+	return strings.TrimSpace(rest)
+}
+
+// formatDuration rounds a duration to minutes, such as 1h20m
+func formatDuration(d time.Duration) string {
+	if d < time.Minute {
+		return "0m"
+	}
+	return strings.TrimSuffix(d.Round(time.Minute).String(), "0s")
+}
+
+// firstLine returns the first non-empty line of text
+func firstLine(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
@@ -0,0 +1,20 @@
+package retro
+
+import (
+	"testing"
+	"time"
+)
+
+func TestActiveTime(t *testing.T) {
+	day := time.Date(2024, 5, 1, 9, 0, 0, 0, time.Local)
+	active := ActiveTime([]time.Time{
+		day.Add(20 * time.Minute),
+		day,
+		day.Add(40 * time.Minute),
+		// A new session after a long pause
+		day.Add(3 * time.Hour),
+	})
+	if got := active["2024-05-01"]; got != 50*time.Minute {
+		t.Errorf("Expected 50m of activity, got %s", got)
+	}
+}
@@ -0,0 +1,184 @@
+package screenshot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+
+	"github.com/bkidd1/wash-cli/internal/utils/platform"
+	"github.com/kbinani/screenshot"
+)
+
+// minWindowSide is the smallest width and height of a window worth capturing; smaller ones are
+// palettes, tooltips and status items
+const minWindowSide = 200
+
+// errWindowNotFound is returned when no window of the application is open
+var errWindowNotFound = errors.New("window not found")
+
+// macWindowsScript lists the windows of every space with CoreGraphics, through the JavaScript
+// bridge of osascript, as JSON. Option 0 is kCGWindowListOptionAll, and layer 0 holds the
+// applications' normal windows.
+const macWindowsScript = `ObjC.import('CoreGraphics');
+const windows = ObjC.deepUnwrap(ObjC.castRefToObject($.CGWindowListCopyWindowInfo(0, 0))) || [];
+JSON.stringify(windows.filter(w => w.kCGWindowLayer === 0).map(w => ({
+	id: w.kCGWindowNumber,
+	application: w.kCGWindowOwnerName || "",
+	title: w.kCGWindowName || "",
+	x: w.kCGWindowBounds.X,
+	y: w.kCGWindowBounds.Y,
+	width: w.kCGWindowBounds.Width,
+	height: w.kCGWindowBounds.Height,
+	on_screen: !!w.kCGWindowIsOnscreen
+})));`
+
+// windowInfo is an open window that can be captured
+type windowInfo struct {
+	ID          int64   `json:"id"`
+	Application string  `json:"application"`
+	Title       string  `json:"title"`
+	X           float64 `json:"x"`
+	Y           float64 `json:"y"`
+	Width       float64 `json:"width"`
+	Height      float64 `json:"height"`
+	OnScreen    bool    `json:"on_screen"`
+}
+
+// pickWindow returns the window of the target applications to capture: the largest window on
+// screen, or the largest one anywhere when none is on screen
+func pickWindow(windows []windowInfo, targets []string) (windowInfo, bool) {
+	var best windowInfo
+	found := false
+	for _, w := range windows {
+		window := Window{Application: w.Application, Title: w.Title}
+		if window.Match(targets) == "" || w.Width < minWindowSide || w.Height < minWindowSide {
+			continue
+		}
+		if !found || w.OnScreen && !best.OnScreen ||
+			w.OnScreen == best.OnScreen && w.Width*w.Height > best.Width*best.Height {
+			best, found = w, true
+		}
+	}
+	return best, found
+}
+
+// listWindows lists the open windows that may belong to the applications; where the window
+// system can search for them, as X11 can, only theirs are listed. Wayland compositors other
+// than sway and Hyprland return errWindowNotFound, as they do not list windows.
+func listWindows(applications []string) ([]windowInfo, error) {
+	switch platform.CurrentOS() {
+	case platform.Darwin:
+		return macWindows()
+	case platform.Linux:
+		if onWayland() {
+			return waylandWindows()
+		}
+		return x11Windows(applications)
+	case platform.Windows:
+		return win32Windows()
+	default:
+		return nil, fmt.Errorf("window capture is not supported on %s", platform.GetOSName())
+	}
+}
+
+// findWindow returns the window of the applications to capture, chosen as pickWindow does, or
+// errWindowNotFound when none of them has a window open
+func findWindow(applications []string) (windowInfo, error) {
+	windows, err := listWindows(applications)
+	if err != nil {
+		return windowInfo{}, err
+	}
+	window, ok := pickWindow(windows, applications)
+	if !ok {
+		return windowInfo{}, errWindowNotFound
+	}
+	return window, nil
+}
+
+// captureWindow captures the window of an application to a PNG file, returning errWindowNotFound
+// when it has none open
+func captureWindow(application, outputPath string) error {
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	window, err := findWindow([]string{application})
+	if err != nil {
+		return err
+	}
+	switch platform.CurrentOS() {
+	case platform.Darwin:
+		return captureMacWindow(window, outputPath)
+	case platform.Linux:
+		if onWayland() {
+			return captureWaylandWindow(window, outputPath)
+		}
+		// X11 has no capture of a window alone, so it is cut out of the screen. Covered parts
+		// show what covers them, which the monitor avoids by capturing only the focused window.
+		return captureRect(window.bounds(), outputPath)
+	default:
+		return captureWin32Window(window, outputPath)
+	}
+}
+
+// bounds returns the window's rectangle in screen coordinates
+func (w windowInfo) bounds() image.Rectangle {
+	return image.Rect(int(w.X), int(w.Y), int(w.X+w.Width), int(w.Y+w.Height))
+}
+
+// captureRect captures a rectangle of the screen, clipped to the displays, to a PNG file
+func captureRect(bounds image.Rectangle, outputPath string) error {
+	var screen image.Rectangle
+	for i := range screenshot.NumActiveDisplays() {
+		screen = screen.Union(screenshot.GetDisplayBounds(i))
+	}
+	if !screen.Empty() {
+		bounds = bounds.Intersect(screen)
+	}
+	img, err := screenshot.CaptureRect(bounds)
+	if err != nil {
+		return fmt.Errorf("failed to capture screenshot: %w", err)
+	}
+
+	// Create parent directory if it doesn't exist
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create screenshot file: %w", err)
+	}
+	defer file.Close()
+	if err := png.Encode(file, img); err != nil {
+		return fmt.Errorf("failed to encode screenshot: %w", err)
+	}
+	return nil
+}
+
+// macWindows lists the windows of every space from the CoreGraphics window list
+func macWindows() ([]windowInfo, error) {
+	out, err := exec.Command("osascript", "-l", "JavaScript", "-e", macWindowsScript).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list windows: %w", err)
+	}
+	var windows []windowInfo
+	if err := json.Unmarshal(out, &windows); err != nil {
+		return nil, fmt.Errorf("failed to parse window list: %w", err)
+	}
+	return windows, nil
+}
+
+// captureMacWindow captures a window from the CoreGraphics window list alone with screencapture,
+// which works when it is covered or on another space
+func captureMacWindow(window windowInfo, outputPath string) error {
+	// -l captures one window, -o leaves out its shadow and -x the shutter sound
+	if out, err := exec.Command("screencapture", "-x", "-o", "-l", strconv.FormatInt(window.ID, 10), outputPath).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to capture window: %v: %s", err, out)
+	}
+	return nil
+}
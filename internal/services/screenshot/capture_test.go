@@ -0,0 +1,71 @@
+package screenshot
+
+import (
+	"encoding/json"
+	"image"
+	"testing"
+)
+
+func TestPickWindow(t *testing.T) {
+	windows := []windowInfo{
+		{ID: 1, Application: "Cursor", Title: "Settings", Width: 600, Height: 400},
+		{ID: 2, Application: "Cursor", Title: "main.go — app", Width: 1400, Height: 900},
+		{ID: 3, Application: "Cursor", Title: "", Width: 800, Height: 600, OnScreen: true},
+		{ID: 4, Application: "Cursor", Title: "", Width: 40, Height: 20, OnScreen: true},
+		{ID: 5, Application: "Firefox", Title: "Cursor pricing", Width: 1600, Height: 1000, OnScreen: true},
+	}
+
+	if w, ok := pickWindow(windows, []string{"Cursor"}); !ok || w.ID != 3 {
+		t.Errorf("Expected the window on screen, got %+v", w)
+	}
+	windows[2].OnScreen = false
+	if w, ok := pickWindow(windows, []string{"Cursor"}); !ok || w.ID != 2 {
+		t.Errorf("Expected the largest window on another space, got %+v", w)
+	}
+	if _, ok := pickWindow(windows, []string{"Code"}); ok {
+		t.Error("Expected no window for an application that is not open")
+	}
+}
+
+func TestParseX11Geometry(t *testing.T) {
+	window := parseX11Geometry("WINDOW=4194311\nX=1920\nY=28\nWIDTH=1280\nHEIGHT=1024\nSCREEN=0\n")
+	if window.ID != 4194311 || window.bounds().String() != "(1920,28)-(3200,1052)" {
+		t.Errorf("Unexpected window: %+v", window)
+	}
+}
+
+func TestPortalResponse(t *testing.T) {
+	line := "/org/freedesktop/portal/desktop/request/1_42/wash1: org.freedesktop.portal.Request.Response (uint32 0, {'uri': <'file:///home/me/Pictures/Screenshot.png'>})"
+	match := portalResponse.FindStringSubmatch(line)
+	if match == nil || match[2] != "0" || match[3] != "file:///home/me/Pictures/Screenshot.png" {
+		t.Errorf("Unexpected match: %q", match)
+	}
+}
+
+func TestWin32WindowList(t *testing.T) {
+	out := `[{"id":132456,"application":"Code","title":"main.go - app - Visual Studio Code","x":-8,"y":-8,"width":1936,"height":1048,"on_screen":true},` +
+		`{"id":65812,"application":"explorer","title":"","x":0,"y":0,"width":1920,"height":1080,"on_screen":true}]`
+	var windows []windowInfo
+	if err := json.Unmarshal([]byte(out), &windows); err != nil {
+		t.Fatal(err)
+	}
+	if w, ok := pickWindow(windows, []string{"Code"}); !ok || w.ID != 132456 {
+		t.Errorf("Expected the VS Code window, got %+v", w)
+	}
+}
+
+func TestDisplayOf(t *testing.T) {
+	displays := []image.Rectangle{
+		image.Rect(0, 0, 1920, 1080),
+		image.Rect(1920, 0, 4480, 1440),
+	}
+	if got := displayOf(displays, image.Rect(1800, 100, 3000, 900)); got != 1 {
+		t.Errorf("Expected the display showing most of the window, got %d", got)
+	}
+	if got := displayOf(displays, image.Rect(100, 100, 900, 700)); got != 0 {
+		t.Errorf("Expected the primary display, got %d", got)
+	}
+	if got := displayOf(displays, image.Rect(-2000, 0, -100, 900)); got != -1 {
+		t.Errorf("Expected no display for an offscreen window, got %d", got)
+	}
+}
@@ -0,0 +1,85 @@
+package screenshot
+
+import (
+	"fmt"
+	"image"
+	"strings"
+
+	"github.com/bkidd1/wash-cli/internal/utils/platform"
+	"github.com/kbinani/screenshot"
+)
+
+// Displays returns the bounds of the connected displays, the primary display first
+func Displays() []image.Rectangle {
+	if !platform.IsSupported() {
+		return nil
+	}
+	displays := make([]image.Rectangle, screenshot.NumActiveDisplays())
+	for i := range displays {
+		displays[i] = screenshot.GetDisplayBounds(i)
+	}
+	return displays
+}
+
+// DescribeDisplays lists the connected displays, numbered from 1 as --display numbers them
+func DescribeDisplays() string {
+	displays := Displays()
+	if len(displays) == 0 {
+		return "no displays found"
+	}
+	described := make([]string, len(displays))
+	for i, d := range displays {
+		described[i] = fmt.Sprintf("%d: %dx%d at %d,%d", i+1, d.Dx(), d.Dy(), d.Min.X, d.Min.Y)
+	}
+	return strings.Join(described, ", ")
+}
+
+// CheckDisplay reports whether a display, numbered from 1, can be captured
+func CheckDisplay(display int) error {
+	if platform.CurrentOS() == platform.Linux && onWayland() {
+		return fmt.Errorf("choosing a display is not supported on Wayland")
+	}
+	if display < 1 || display > len(Displays()) {
+		return fmt.Errorf("display %d not found (displays: %s)", display, DescribeDisplays())
+	}
+	return nil
+}
+
+// CaptureDisplay captures a whole display, numbered from 1 as in Displays, to a PNG file
+func CaptureDisplay(display int, outputPath string) error {
+	if err := CheckDisplay(display); err != nil {
+		return err
+	}
+	return captureRect(Displays()[display-1], outputPath)
+}
+
+// CaptureScreen captures the display showing the window of the applications that pickWindow
+// chooses, so the editor is captured on whichever display it is on. The primary display is
+// captured when none of them has a window open or windows cannot be listed.
+func CaptureScreen(applications []string, outputPath string) error {
+	if platform.CurrentOS() == platform.Linux && onWayland() {
+		return CaptureFullScreen(outputPath)
+	}
+	window, err := findWindow(applications)
+	if err != nil {
+		return CaptureFullScreen(outputPath)
+	}
+	displays := Displays()
+	if display := displayOf(displays, window.bounds()); display >= 0 {
+		return captureRect(displays[display], outputPath)
+	}
+	return CaptureFullScreen(outputPath)
+}
+
+// displayOf returns the index of the display that shows most of a window, or -1 when it is on
+// none of them
+func displayOf(displays []image.Rectangle, window image.Rectangle) int {
+	best, bestArea := -1, 0
+	for i, display := range displays {
+		overlap := display.Intersect(window)
+		if area := overlap.Dx() * overlap.Dy(); area > bestArea {
+			best, bestArea = i, area
+		}
+	}
+	return best
+}
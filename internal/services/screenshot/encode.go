@@ -0,0 +1,108 @@
+package screenshot
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+)
+
+const (
+	// DefaultFormat, DefaultQuality and DefaultMaxSize apply when the screenshot settings are not set.
+	// The model reduces images to fit 2048 pixels and 768 on the short side anyway, so larger
+	// screenshots only cost upload time.
+	DefaultFormat  = "jpeg"
+	DefaultQuality = 80
+	DefaultMaxSize = 1600
+)
+
+// Encoding is how screenshots are prepared for upload
+type Encoding struct {
+	// Format is jpeg or png
+	Format string
+	// Quality is the JPEG quality, from 1 to 100
+	Quality int
+	// MaxSize is the longest side, in pixels, larger screenshots are scaled down to
+	MaxSize int
+}
+
+// withDefaults fills the unset settings with the defaults
+func (e Encoding) withDefaults() Encoding {
+	if e.Format == "" {
+		e.Format = DefaultFormat
+	}
+	if e.Quality <= 0 {
+		e.Quality = DefaultQuality
+	}
+	if e.MaxSize <= 0 {
+		e.MaxSize = DefaultMaxSize
+	}
+	return e
+}
+
+// Extension returns the file extension of encoded screenshots
+func (e Encoding) Extension() string {
+	if e.withDefaults().Format == "png" {
+		return ".png"
+	}
+	return ".jpg"
+}
+
+// Encode scales a PNG screenshot down to fit MaxSize and encodes it in Format, returning the data
+// and its MIME type
+func (e Encoding) Encode(data []byte) ([]byte, string, error) {
+	e = e.withDefaults()
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode screenshot: %w", err)
+	}
+	img = downscale(img, e.MaxSize)
+
+	var buf bytes.Buffer
+	switch e.Format {
+	case "png":
+		err = png.Encode(&buf, img)
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: e.Quality})
+	default:
+		return nil, "", fmt.Errorf("unsupported screenshot format %q (expected jpeg or png)", e.Format)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode screenshot: %w", err)
+	}
+	return buf.Bytes(), "image/" + e.Format, nil
+}
+
+// downscale shrinks an image so its longest side is at most maxSize, averaging the source pixels
+// each target pixel covers, which keeps small text readable. Smaller images are returned as is.
+func downscale(img image.Image, maxSize int) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w <= maxSize && h <= maxSize {
+		return img
+	}
+	tw, th := maxSize, h*maxSize/w
+	if h > w {
+		tw, th = w*maxSize/h, maxSize
+	}
+	tw, th = max(tw, 1), max(th, 1)
+
+	out := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for ty := range th {
+		y0, y1 := bounds.Min.Y+ty*h/th, bounds.Min.Y+(ty+1)*h/th
+		for tx := range tw {
+			x0, x1 := bounds.Min.X+tx*w/tw, bounds.Min.X+(tx+1)*w/tw
+			var r, g, b, n uint64
+			for y := y0; y < max(y1, y0+1); y++ {
+				for x := x0; x < max(x1, x0+1); x++ {
+					pr, pg, pb, _ := img.At(x, y).RGBA()
+					r, g, b, n = r+uint64(pr), g+uint64(pg), b+uint64(pb), n+1
+				}
+			}
+			out.SetRGBA(tx, ty, color.RGBA{R: uint8(r / n >> 8), G: uint8(g / n >> 8), B: uint8(b / n >> 8), A: 0xff})
+		}
+	}
+	return out
+}
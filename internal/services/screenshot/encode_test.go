@@ -0,0 +1,38 @@
+package screenshot
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	data := encode(t, image.Rect(20, 20, 300, 40))
+
+	out, mimeType, err := Encoding{MaxSize: 320}.Encode(data)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if mimeType != "image/jpeg" {
+		t.Errorf("Expected JPEG by default, got %s", mimeType)
+	}
+	img, err := jpeg.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("Expected a JPEG: %v", err)
+	}
+	if size := img.Bounds().Size(); size != (image.Point{320, 200}) {
+		t.Errorf("Expected 320x200, got %v", size)
+	}
+	// The dark bar averages to dark pixels at half the size
+	if r, _, _, _ := img.At(80, 15).RGBA(); r>>8 > 64 {
+		t.Errorf("Expected the bar to stay dark, got %d", r>>8)
+	}
+
+	if _, mimeType, _ := (Encoding{Format: "png"}).Encode(data); mimeType != "image/png" {
+		t.Errorf("Expected PNG, got %s", mimeType)
+	}
+	if _, _, err := (Encoding{Format: "webp"}).Encode(data); err == nil {
+		t.Error("Expected an unsupported format to fail")
+	}
+}
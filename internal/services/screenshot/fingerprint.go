@@ -0,0 +1,65 @@
+package screenshot
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+)
+
+const (
+	// fingerprintSize is the width and height of the grid a screenshot is reduced to
+	fingerprintSize = 64
+	// cellTolerance is how much a cell's brightness may change, out of 255, before it counts as
+	// changed, so compression noise and antialiasing do not
+	cellTolerance = 8
+)
+
+// Fingerprint is a screenshot reduced to the average brightness of each cell of a grid, to tell
+// whether the screen has meaningfully changed without keeping the image
+type Fingerprint []uint8
+
+// NewFingerprint reduces a PNG screenshot to its fingerprint
+func NewFingerprint(data []byte) (Fingerprint, error) {
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode screenshot: %w", err)
+	}
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return nil, fmt.Errorf("screenshot is empty")
+	}
+
+	var sums, counts [fingerprintSize * fingerprintSize]uint64
+	// Sampling every other pixel is plenty for cells of hundreds of pixels
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += 2 {
+		row := (y - bounds.Min.Y) * fingerprintSize / bounds.Dy()
+		for x := bounds.Min.X; x < bounds.Max.X; x += 2 {
+			col := (x - bounds.Min.X) * fingerprintSize / bounds.Dx()
+			sums[row*fingerprintSize+col] += uint64(luma(img, x, y))
+			counts[row*fingerprintSize+col]++
+		}
+	}
+
+	f := make(Fingerprint, len(sums))
+	for i := range sums {
+		if counts[i] > 0 {
+			f[i] = uint8(sums[i] / counts[i])
+		}
+	}
+	return f, nil
+}
+
+// Difference returns the share of the screen, from 0 to 1, that changed between two
+// fingerprints. A missing fingerprint differs completely.
+func (f Fingerprint) Difference(other Fingerprint) float64 {
+	if len(f) == 0 || len(f) != len(other) {
+		return 1
+	}
+	changed := 0
+	for i := range f {
+		if diff := int(f[i]) - int(other[i]); diff > cellTolerance || diff < -cellTolerance {
+			changed++
+		}
+	}
+	return float64(changed) / float64(len(f))
+}
@@ -0,0 +1,53 @@
+package screenshot
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+// encode renders a white screen with dark rectangles and returns it as PNG
+func encode(t *testing.T, rects ...image.Rectangle) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 640, 400))
+	for y := 0; y < 400; y++ {
+		for x := 0; x < 640; x++ {
+			img.Set(x, y, color.White)
+			for _, r := range rects {
+				if (image.Point{x, y}).In(r) {
+					img.Set(x, y, color.Black)
+				}
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestFingerprintDifference(t *testing.T) {
+	base, err := NewFingerprint(encode(t, image.Rect(20, 20, 300, 40)))
+	if err != nil {
+		t.Fatalf("NewFingerprint failed: %v", err)
+	}
+	same, _ := NewFingerprint(encode(t, image.Rect(20, 20, 300, 40)))
+	cursor, _ := NewFingerprint(encode(t, image.Rect(20, 20, 300, 40), image.Rect(400, 300, 402, 304)))
+	edited, _ := NewFingerprint(encode(t, image.Rect(20, 20, 300, 40), image.Rect(20, 60, 600, 200)))
+
+	if d := base.Difference(same); d != 0 {
+		t.Errorf("Expected identical screens not to differ, got %.4f", d)
+	}
+	if d := base.Difference(cursor); d > 0.001 {
+		t.Errorf("Expected a blinking cursor to be a negligible change, got %.4f", d)
+	}
+	if d := base.Difference(edited); d < 0.1 {
+		t.Errorf("Expected new content to change the screen, got %.4f", d)
+	}
+	if d := base.Difference(nil); d != 1 {
+		t.Errorf("Expected no previous fingerprint to differ completely, got %.4f", d)
+	}
+}
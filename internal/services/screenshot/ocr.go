@@ -0,0 +1,104 @@
+package screenshot
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// ErrNoOCR is returned when tesseract is not installed
+var ErrNoOCR = errors.New("tesseract is not installed")
+
+// Word is a word read from a screenshot, with its box in pixels
+type Word struct {
+	Text string
+	Box  image.Rectangle
+	// Line numbers the line the word is on, counting from 0 down the screenshot
+	Line int
+}
+
+// OCRAvailable reports whether tesseract is installed
+func OCRAvailable() bool {
+	_, err := exec.LookPath("tesseract")
+	return err == nil
+}
+
+// OCR reads the words of a PNG screenshot with tesseract
+func OCR(data []byte) ([]Word, error) {
+	if !OCRAvailable() {
+		return nil, ErrNoOCR
+	}
+	tmp, err := os.CreateTemp("", "wash-ocr-*.png")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return nil, fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	tmp.Close()
+
+	out, err := exec.Command("tesseract", tmp.Name(), "stdout", "tsv").Output()
+	if err != nil {
+		return nil, fmt.Errorf("tesseract failed: %w", err)
+	}
+	return parseTSV(string(out)), nil
+}
+
+// parseTSV reads the words of tesseract's TSV output, whose columns are level, page, block,
+// paragraph, line and word numbers, left, top, width, height, confidence and text
+func parseTSV(out string) []Word {
+	var words []Word
+	lines := make(map[[3]string]int)
+	for _, row := range strings.Split(out, "\n") {
+		fields := strings.Split(strings.TrimRight(row, "\r"), "\t")
+		if len(fields) < 12 || fields[0] != "5" || strings.TrimSpace(fields[11]) == "" {
+			continue
+		}
+		var box [4]int
+		valid := true
+		for i := range box {
+			n, err := strconv.Atoi(fields[6+i])
+			if err != nil {
+				valid = false
+				break
+			}
+			box[i] = n
+		}
+		if !valid {
+			continue
+		}
+		key := [3]string{fields[2], fields[3], fields[4]}
+		line, ok := lines[key]
+		if !ok {
+			line = len(lines)
+			lines[key] = line
+		}
+		words = append(words, Word{
+			Text: fields[11],
+			Box:  image.Rect(box[0], box[1], box[0]+box[2], box[1]+box[3]),
+			Line: line,
+		})
+	}
+	return words
+}
+
+// Lines joins words into the lines of text they were read on, from the top
+func Lines(words []Word) []string {
+	var lines []string
+	for _, word := range words {
+		for len(lines) <= word.Line {
+			lines = append(lines, "")
+		}
+		if lines[word.Line] != "" {
+			lines[word.Line] += " "
+		}
+		lines[word.Line] += word.Text
+	}
+	return lines
+}
@@ -0,0 +1,44 @@
+package screenshot
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+
+	"github.com/bkidd1/wash-cli/internal/utils/platform"
+)
+
+// ErrNoScreenRecording is returned when macOS has not granted the Screen Recording permission,
+// without which captures show only the desktop and the menu bar, or come out black
+var ErrNoScreenRecording = errors.New("macOS has not granted wash the Screen Recording permission")
+
+// ScreenRecordingSteps tells how to grant the Screen Recording permission
+const ScreenRecordingSteps = `To let wash capture the screen:
+  1. Open System Settings > Privacy & Security > Screen & System Audio Recording
+     (Screen Recording on macOS 14 and earlier).
+  2. Turn on the app you run wash in, such as Terminal, iTerm2 or your editor; click +
+     to add it if it is not listed. For 'wash monitor install', add the wash binary itself.
+  3. Quit and reopen that app: macOS applies the permission to newly started apps only.
+  4. Run 'wash doctor' to check.`
+
+// screenRecordingScript asks CoreGraphics, through the JavaScript bridge of osascript, whether
+// the app wash runs in may capture the screen, without prompting
+const screenRecordingScript = `ObjC.import('CoreGraphics'); $.CGPreflightScreenCaptureAccess()`
+
+// CheckScreenRecording returns ErrNoScreenRecording when the screen cannot be captured for want of
+// the Screen Recording permission. Only macOS asks for it, from macOS 10.15; elsewhere, and when
+// the permission cannot be checked, it returns nil.
+func CheckScreenRecording() error {
+	if platform.CurrentOS() != platform.Darwin {
+		return nil
+	}
+	out, err := exec.Command("osascript", "-l", "JavaScript", "-e", screenRecordingScript).Output()
+	if err != nil {
+		// macOS before 10.15 has no such permission, nor the function to check it
+		return nil
+	}
+	if strings.TrimSpace(string(out)) == "false" {
+		return ErrNoScreenRecording
+	}
+	return nil
+}
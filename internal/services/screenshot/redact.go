@@ -0,0 +1,76 @@
+package screenshot
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+
+	"github.com/bkidd1/wash-cli/internal/utils/secrets"
+)
+
+// redactPadding widens the boxes blacked out around secrets, in pixels, so no edge of a glyph is left
+const redactPadding = 2
+
+// Redact blacks out the secrets read in a PNG screenshot, such as API keys, tokens and the values
+// of .env files, returning the redacted PNG and how many words were covered. It returns ErrNoOCR
+// when tesseract is not installed.
+func Redact(data []byte) ([]byte, int, error) {
+	words, err := OCR(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	boxes := secretBoxes(words)
+	if len(boxes) == 0 {
+		return data, 0, nil
+	}
+
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to decode screenshot: %w", err)
+	}
+	canvas := image.NewRGBA(img.Bounds())
+	draw.Draw(canvas, canvas.Bounds(), img, img.Bounds().Min, draw.Src)
+	black := image.NewUniform(color.Black)
+	for _, box := range boxes {
+		draw.Draw(canvas, box.Inset(-redactPadding).Intersect(canvas.Bounds()), black, image.Point{}, draw.Src)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, canvas); err != nil {
+		return nil, 0, fmt.Errorf("failed to encode screenshot: %w", err)
+	}
+	return buf.Bytes(), len(boxes), nil
+}
+
+// secretBoxes returns the boxes of the words that are part of a secret, found line by line
+func secretBoxes(words []Word) []image.Rectangle {
+	byLine := make(map[int][]Word)
+	for _, word := range words {
+		byLine[word.Line] = append(byLine[word.Line], word)
+	}
+
+	var boxes []image.Rectangle
+	for _, line := range byLine {
+		// Join the line, remembering where each word starts
+		var text string
+		starts := make([]int, len(line))
+		for i, word := range line {
+			if i > 0 {
+				text += " "
+			}
+			starts[i] = len(text)
+			text += word.Text
+		}
+		for _, span := range secrets.Find(text) {
+			for i, word := range line {
+				if starts[i] < span.End && starts[i]+len(word.Text) > span.Start {
+					boxes = append(boxes, word.Box)
+				}
+			}
+		}
+	}
+	return boxes
+}
@@ -0,0 +1,27 @@
+package screenshot
+
+import (
+	"image"
+	"testing"
+)
+
+func TestSecretBoxes(t *testing.T) {
+	tsv := "level\tpage_num\tblock_num\tpar_num\tline_num\tword_num\tleft\ttop\twidth\theight\tconf\ttext\n" +
+		"4\t1\t1\t1\t1\t0\t10\t10\t400\t12\t-1\t\n" +
+		"5\t1\t1\t1\t1\t1\t10\t10\t60\t12\t96\texport\n" +
+		"5\t1\t1\t1\t1\t2\t80\t10\t330\t12\t91\tOPENAI_API_KEY=sk-abcdefghijklmnopqrstuvwxyz\n" +
+		"5\t1\t1\t1\t2\t1\t10\t30\t60\t12\t95\tfunc\n" +
+		"5\t1\t1\t1\t2\t2\t80\t30\t90\t12\t95\tparseKey()\n"
+	words := parseTSV(tsv)
+	if len(words) != 4 || words[2].Line != 1 {
+		t.Fatalf("Expected 4 words on 2 lines, got %+v", words)
+	}
+	if lines := Lines(words); len(lines) != 2 || lines[1] != "func parseKey()" {
+		t.Errorf("Unexpected lines: %q", lines)
+	}
+
+	boxes := secretBoxes(words)
+	if len(boxes) != 1 || boxes[0] != image.Rect(80, 10, 410, 22) {
+		t.Errorf("Expected the key's box, got %v", boxes)
+	}
+}
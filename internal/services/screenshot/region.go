@@ -0,0 +1,99 @@
+package screenshot
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+)
+
+const (
+	// panelMinX and panelMaxX bound where the divider of a chat panel docked on the right may be,
+	// in fractions of the width: panels are narrower than half the window and wider than a
+	// minimap or scrollbar
+	panelMinX = 0.5
+	panelMaxX = 0.8
+	// dividerEdge is the brightness step, out of 255, between neighbouring columns at a divider
+	dividerEdge = 12
+	// dividerRows is the share of rows a column must step on to be a divider
+	dividerRows = 0.7
+	// dividerSamples is how many rows are sampled to find a divider
+	dividerSamples = 400
+)
+
+// Region is a part of a screenshot, in fractions of its width and height
+type Region struct {
+	X, Y, Width, Height float64
+}
+
+// rect returns the region's pixels in an image's bounds
+func (r Region) rect(bounds image.Rectangle) image.Rectangle {
+	w, h := float64(bounds.Dx()), float64(bounds.Dy())
+	return image.Rect(
+		bounds.Min.X+int(r.X*w), bounds.Min.Y+int(r.Y*h),
+		bounds.Min.X+int((r.X+r.Width)*w), bounds.Min.Y+int((r.Y+r.Height)*h),
+	).Intersect(bounds)
+}
+
+// Crop returns a region of a PNG screenshot as a PNG
+func Crop(data []byte, r Region) ([]byte, error) {
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode screenshot: %w", err)
+	}
+	sub, ok := img.(interface {
+		SubImage(image.Rectangle) image.Image
+	})
+	if !ok {
+		return nil, fmt.Errorf("screenshot cannot be cropped")
+	}
+	rect := r.rect(img.Bounds())
+	if rect.Empty() {
+		return nil, fmt.Errorf("region is outside the screenshot")
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, sub.SubImage(rect)); err != nil {
+		return nil, fmt.Errorf("failed to encode screenshot: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// DetectChatPanel finds a chat panel docked on the right of an editor window by its divider: the
+// rightmost column, between panelMinX and panelMaxX, where the brightness steps on most rows. Text
+// and code change from row to row, so only panel borders step along the whole height.
+func DetectChatPanel(data []byte) (Region, bool) {
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Region{}, false
+	}
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width < 2 || height == 0 {
+		return Region{}, false
+	}
+
+	step := max(1, height/dividerSamples)
+	rows := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		rows++
+	}
+	for x := int(panelMaxX * float64(width)); x >= int(panelMinX*float64(width)) && x > 0; x-- {
+		edges := 0
+		for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+			if diff := luma(img, bounds.Min.X+x, y) - luma(img, bounds.Min.X+x-1, y); diff >= dividerEdge || -diff >= dividerEdge {
+				edges++
+			}
+		}
+		if float64(edges) >= dividerRows*float64(rows) {
+			left := float64(x) / float64(width)
+			return Region{X: left, Y: 0, Width: 1 - left, Height: 1}, true
+		}
+	}
+	return Region{}, false
+}
+
+// luma returns the brightness of a pixel, from 0 to 255: Rec. 601 luma, from 16-bit channels to 8 bits
+func luma(img image.Image, x, y int) int {
+	r, g, b, _ := img.At(x, y).RGBA()
+	return int((299*r + 587*g + 114*b) / 1000 >> 8)
+}
@@ -0,0 +1,39 @@
+package screenshot
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"math"
+	"testing"
+)
+
+func TestDetectChatPanel(t *testing.T) {
+	// Lines of code on the left, a minimap edge at x=340 and the panel divider at x=448
+	code := []image.Rectangle{image.Rect(340, 0, 400, 400), image.Rect(448, 0, 449, 400)}
+	for y := 10; y < 400; y += 20 {
+		code = append(code, image.Rect(20, y, 20+(y*7)%300, y+8))
+	}
+	data := encode(t, code...)
+
+	region, ok := DetectChatPanel(data)
+	if !ok || math.Abs(region.X-448.0/640) > 0.01 || region.Width+region.X != 1 {
+		t.Fatalf("Expected the panel right of x=448, got %+v, %v", region, ok)
+	}
+
+	cropped, err := Crop(data, region)
+	if err != nil {
+		t.Fatalf("Crop failed: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(cropped))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := img.Bounds().Size(); size.X < 190 || size.X > 193 || size.Y != 400 {
+		t.Errorf("Expected a 192x400 panel, got %v", size)
+	}
+
+	if _, ok := DetectChatPanel(encode(t, code[2:]...)); ok {
+		t.Error("Expected no panel in a window without a divider")
+	}
+}
@@ -1,6 +1,7 @@
 package screenshot
 
 import (
+	"errors"
 	"fmt"
 	"image/png"
 	"os"
@@ -32,7 +33,10 @@ func Capture(displayIndex int) (*Screenshot, error) {
 	}
 
 	// Create screenshots directory if it doesn't exist
-	dir := filepath.Join(os.Getenv("HOME"), ".wash-screenshots")
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create screenshots directory: %w", err)
 	}
@@ -65,11 +69,16 @@ func GetDisplayCount() int {
 	return screenshot.NumActiveDisplays()
 }
 
-// CaptureWindow takes a screenshot of a specific window by title
-func CaptureWindow(windowTitle string, outputPath string) error {
+// CaptureWindow takes a screenshot of an application's window, matched by name like focused
+// windows are. The primary display is captured instead when no application is given, when it has
+// no window open or when window capture is not supported.
+func CaptureWindow(application string, outputPath string) error {
 	if !platform.IsSupported() {
 		return fmt.Errorf("screenshot capture is not supported on %s", platform.GetOSName())
 	}
+	if application == "" {
+		return CaptureFullScreen(outputPath)
+	}
 
 	// If window capture is not supported, fall back to full screen capture
 	if !platform.SupportsWindowCapture() {
@@ -77,58 +86,21 @@ func CaptureWindow(windowTitle string, outputPath string) error {
 		return CaptureFullScreen(outputPath)
 	}
 
-	// For now, we'll just capture the entire primary display
-	// In the future, we can add window-specific capture using platform-specific APIs
-	bounds := screenshot.GetDisplayBounds(0)
-
-	// Capture the screenshot
-	img, err := screenshot.CaptureRect(bounds)
-	if err != nil {
-		return fmt.Errorf("failed to capture screenshot: %w", err)
-	}
-
-	// Create parent directory if it doesn't exist
-	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
-		return fmt.Errorf("failed to create output directory: %w", err)
-	}
-
-	// Save the screenshot
-	file, err := os.Create(outputPath)
-	if err != nil {
-		return fmt.Errorf("failed to create screenshot file: %w", err)
-	}
-	defer file.Close()
-
-	if err := png.Encode(file, img); err != nil {
-		return fmt.Errorf("failed to encode screenshot: %w", err)
+	err := captureWindow(application, outputPath)
+	if errors.Is(err, errWindowNotFound) {
+		return CaptureFullScreen(outputPath)
 	}
-
-	return nil
+	return err
 }
 
-// CaptureFullScreen captures the entire primary display
+// CaptureFullScreen captures the entire primary display; on Wayland, where X11 capture sees only
+// a blank screen, the compositor or the desktop portal captures it instead
 func CaptureFullScreen(outputPath string) error {
-	bounds := screenshot.GetDisplayBounds(0)
-	img, err := screenshot.CaptureRect(bounds)
-	if err != nil {
-		return fmt.Errorf("failed to capture full screen: %w", err)
-	}
-
-	// Create parent directory if it doesn't exist
-	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
-		return fmt.Errorf("failed to create output directory: %w", err)
+	if platform.CurrentOS() == platform.Linux && onWayland() {
+		return captureWaylandScreen(outputPath)
 	}
-
-	// Save the screenshot
-	file, err := os.Create(outputPath)
-	if err != nil {
-		return fmt.Errorf("failed to create screenshot file: %w", err)
-	}
-	defer file.Close()
-
-	if err := png.Encode(file, img); err != nil {
-		return fmt.Errorf("failed to encode screenshot: %w", err)
+	if err := captureRect(screenshot.GetDisplayBounds(0), outputPath); err != nil {
+		return fmt.Errorf("failed to capture full screen: %w", err)
 	}
-
 	return nil
 }
@@ -0,0 +1,88 @@
+package screenshot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/crypt"
+)
+
+// dirName is the directory in the data directory screenshots are written to
+const dirName = "screenshots"
+
+// Dir returns where screenshots are written, the screenshots directory in the data directory
+func Dir() (string, error) {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, dirName), nil
+}
+
+// Keep stores an analyzed screenshot in Dir under a new name ending in ext, encrypted when cipher
+// is set, and returns its path
+func Keep(data []byte, ext string, cipher *crypt.Cipher) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create screenshots directory: %w", err)
+	}
+	if data, err = cipher.Encrypt(data); err != nil {
+		return "", fmt.Errorf("failed to encrypt screenshot: %w", err)
+	}
+	file, err := os.CreateTemp(dir, "screenshot-*"+ext)
+	if err != nil {
+		return "", fmt.Errorf("failed to create screenshot file: %w", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to write screenshot: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to write screenshot: %w", err)
+	}
+	return file.Name(), nil
+}
+
+// Clean deletes the screenshots in Dir last modified before cutoff, returning how many there were
+// and their size. With dryRun set, it only counts them.
+func Clean(cutoff time.Time, dryRun bool) (int, int64, error) {
+	dir, err := Dir()
+	if err != nil {
+		return 0, 0, err
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read screenshots directory: %w", err)
+	}
+
+	deleted, size := 0, int64(0)
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !strings.HasPrefix(entry.Name(), "screenshot-") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if !dryRun {
+			if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil && !os.IsNotExist(err) {
+				return deleted, size, fmt.Errorf("failed to delete screenshot: %w", err)
+			}
+		}
+		deleted++
+		size += info.Size()
+	}
+	return deleted, size, nil
+}
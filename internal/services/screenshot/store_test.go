@@ -0,0 +1,70 @@
+package screenshot
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/crypt"
+)
+
+func TestClean(t *testing.T) {
+	dataDir := t.TempDir()
+	t.Setenv(config.DataDirEnv, dataDir)
+	dir := filepath.Join(dataDir, dirName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-48 * time.Hour)
+	for _, name := range []string{"screenshot-old.png", "screenshot-new.png", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("png"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.Chtimes(filepath.Join(dir, "screenshot-old.png"), old, old)
+	os.Chtimes(filepath.Join(dir, "notes.txt"), old, old)
+
+	cutoff := time.Now().Add(-24 * time.Hour)
+	if deleted, size, err := Clean(cutoff, true); err != nil || deleted != 1 || size != 3 {
+		t.Fatalf("Dry run: got %d, %d, %v", deleted, size, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "screenshot-old.png")); err != nil {
+		t.Fatal("Expected a dry run to keep the screenshot")
+	}
+
+	if deleted, _, err := Clean(cutoff, false); err != nil || deleted != 1 {
+		t.Fatalf("Clean: got %d, %v", deleted, err)
+	}
+	remaining, _ := os.ReadDir(dir)
+	if len(remaining) != 2 {
+		t.Errorf("Expected the new screenshot and other files to be kept, got %d files", len(remaining))
+	}
+}
+
+func TestKeepEncrypts(t *testing.T) {
+	dataDir := t.TempDir()
+	t.Setenv(config.DataDirEnv, dataDir)
+	t.Setenv(crypt.PassphraseEnv, "correct horse")
+	cipher, err := crypt.Load(crypt.SourcePassphrase, dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := Keep([]byte("jpeg"), ".jpg", cipher)
+	if err != nil {
+		t.Fatalf("Keep failed: %v", err)
+	}
+	if filepath.Dir(path) != filepath.Join(dataDir, dirName) || !strings.HasPrefix(filepath.Base(path), "screenshot-") || filepath.Ext(path) != ".jpg" {
+		t.Errorf("Expected a screenshot in the data directory, got %s", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !crypt.IsEncrypted(data) {
+		t.Fatalf("Expected the kept screenshot encrypted, got %q, %v", data, err)
+	}
+	if plain, err := cipher.Decrypt(data); err != nil || string(plain) != "jpeg" {
+		t.Errorf("Expected the screenshot back, got %q, %v", plain, err)
+	}
+}
@@ -0,0 +1,256 @@
+package screenshot
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// portalTimeout is how long the desktop portal may take to answer, including the permission
+// prompt some desktops show for the first screenshot
+const portalTimeout = 30 * time.Second
+
+// portalResponse matches the Response signal of a portal request, with its result code and URI
+var portalResponse = regexp.MustCompile(`^(\S+): org\.freedesktop\.portal\.Request\.Response \(uint32 (\d+), .*'uri': <'([^']*)'>`)
+
+// onWayland reports whether the session is a Wayland one, where X11 tools only see XWayland windows
+func onWayland() bool {
+	return os.Getenv("WAYLAND_DISPLAY") != ""
+}
+
+// waylandWindows lists the windows of the compositors that tell where windows are: sway and
+// Hyprland. Other compositors, such as GNOME's and KDE's, do not, so the screen is captured
+// instead.
+func waylandWindows() ([]windowInfo, error) {
+	switch {
+	case os.Getenv("SWAYSOCK") != "":
+		return swayWindows()
+	case os.Getenv("HYPRLAND_INSTANCE_SIGNATURE") != "":
+		return hyprlandWindows()
+	default:
+		return nil, errWindowNotFound
+	}
+}
+
+// captureWaylandWindow captures a window's part of the screen with grim
+func captureWaylandWindow(window windowInfo, outputPath string) error {
+	geometry := fmt.Sprintf("%d,%d %dx%d", int(window.X), int(window.Y), int(window.Width), int(window.Height))
+	if out, err := exec.Command("grim", "-g", geometry, outputPath).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to capture window (install grim): %v: %s", err, out)
+	}
+	return nil
+}
+
+// swayNode is a node of sway's layout tree; windows are the leaves
+type swayNode struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	AppID string `json:"app_id"`
+	Rect  struct {
+		X      float64 `json:"x"`
+		Y      float64 `json:"y"`
+		Width  float64 `json:"width"`
+		Height float64 `json:"height"`
+	} `json:"rect"`
+	Visible          bool `json:"visible"`
+	Focused          bool `json:"focused"`
+	WindowProperties struct {
+		Class string `json:"class"`
+	} `json:"window_properties"`
+	Nodes         []swayNode `json:"nodes"`
+	FloatingNodes []swayNode `json:"floating_nodes"`
+}
+
+// swayWindows lists the windows of sway's layout tree
+func swayWindows() ([]windowInfo, error) {
+	out, err := exec.Command("swaymsg", "-t", "get_tree", "-r").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sway windows: %w", err)
+	}
+	var root swayNode
+	if err := json.Unmarshal(out, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse sway tree: %w", err)
+	}
+	var windows []windowInfo
+	var walk func(node swayNode)
+	walk = func(node swayNode) {
+		application := node.AppID
+		if application == "" {
+			application = node.WindowProperties.Class
+		}
+		if application != "" {
+			windows = append(windows, windowInfo{
+				ID: node.ID, Application: application, Title: node.Name, OnScreen: node.Visible,
+				X: node.Rect.X, Y: node.Rect.Y, Width: node.Rect.Width, Height: node.Rect.Height,
+			})
+		}
+		for _, child := range append(node.Nodes, node.FloatingNodes...) {
+			walk(child)
+		}
+	}
+	walk(root)
+	return windows, nil
+}
+
+// waylandActiveWindow returns the focused window on sway and Hyprland; other compositors do not
+// tell other applications
+func waylandActiveWindow() (*Window, error) {
+	switch {
+	case os.Getenv("SWAYSOCK") != "":
+		out, err := exec.Command("swaymsg", "-t", "get_tree", "-r").Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the focused window: %w", err)
+		}
+		var root swayNode
+		if err := json.Unmarshal(out, &root); err != nil {
+			return nil, fmt.Errorf("failed to parse sway tree: %w", err)
+		}
+		if window := focusedSwayWindow(root); window != nil {
+			return window, nil
+		}
+		return &Window{}, nil
+	case os.Getenv("HYPRLAND_INSTANCE_SIGNATURE") != "":
+		out, err := exec.Command("hyprctl", "activewindow", "-j").Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the focused window: %w", err)
+		}
+		var active struct {
+			Class string `json:"class"`
+			Title string `json:"title"`
+		}
+		if err := json.Unmarshal(out, &active); err != nil {
+			return nil, fmt.Errorf("failed to parse Hyprland window: %w", err)
+		}
+		return &Window{Application: active.Class, Title: active.Title}, nil
+	default:
+		return nil, fmt.Errorf("finding the focused window is supported on sway and Hyprland only among Wayland compositors")
+	}
+}
+
+// focusedSwayWindow returns the focused window of a sway tree, or nil
+func focusedSwayWindow(node swayNode) *Window {
+	if node.Focused && (node.AppID != "" || node.WindowProperties.Class != "") {
+		application := node.AppID
+		if application == "" {
+			application = node.WindowProperties.Class
+		}
+		return &Window{Application: application, Title: node.Name}
+	}
+	for _, child := range append(node.Nodes, node.FloatingNodes...) {
+		if window := focusedSwayWindow(child); window != nil {
+			return window
+		}
+	}
+	return nil
+}
+
+// hyprlandWindows lists Hyprland's windows
+func hyprlandWindows() ([]windowInfo, error) {
+	out, err := exec.Command("hyprctl", "clients", "-j").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Hyprland windows: %w", err)
+	}
+	var clients []struct {
+		Class  string    `json:"class"`
+		Title  string    `json:"title"`
+		At     []float64 `json:"at"`
+		Size   []float64 `json:"size"`
+		Mapped bool      `json:"mapped"`
+		Hidden bool      `json:"hidden"`
+	}
+	if err := json.Unmarshal(out, &clients); err != nil {
+		return nil, fmt.Errorf("failed to parse Hyprland windows: %w", err)
+	}
+	var windows []windowInfo
+	for i, client := range clients {
+		if len(client.At) != 2 || len(client.Size) != 2 {
+			continue
+		}
+		windows = append(windows, windowInfo{
+			ID: int64(i), Application: client.Class, Title: client.Title, OnScreen: client.Mapped && !client.Hidden,
+			X: client.At[0], Y: client.At[1], Width: client.Size[0], Height: client.Size[1],
+		})
+	}
+	return windows, nil
+}
+
+// captureWaylandScreen captures the screen with grim where the compositor supports it, and
+// through the desktop portal's Screenshot interface elsewhere
+func captureWaylandScreen(outputPath string) error {
+	if _, err := exec.LookPath("grim"); err == nil {
+		if err := exec.Command("grim", outputPath).Run(); err == nil {
+			return nil
+		}
+	}
+	return capturePortal(outputPath)
+}
+
+// capturePortal asks the desktop portal for a screenshot with gdbus, waits for the answer signal
+// and moves the file it wrote to outputPath
+func capturePortal(outputPath string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), portalTimeout)
+	defer cancel()
+
+	// Watch for the answer before asking, so it cannot be missed
+	monitor := exec.CommandContext(ctx, "gdbus", "monitor", "--session", "--dest", "org.freedesktop.portal.Desktop")
+	stdout, err := monitor.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to watch the desktop portal: %w", err)
+	}
+	if err := monitor.Start(); err != nil {
+		return fmt.Errorf("failed to watch the desktop portal (install gdbus): %w", err)
+	}
+	defer monitor.Wait()
+	defer cancel()
+	lines := bufio.NewScanner(stdout)
+	lines.Scan()
+
+	token := "wash" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	if out, err := exec.CommandContext(ctx, "gdbus", "call", "--session",
+		"--dest", "org.freedesktop.portal.Desktop",
+		"--object-path", "/org/freedesktop/portal/desktop",
+		"--method", "org.freedesktop.portal.Screenshot.Screenshot",
+		"", "{'interactive': <false>, 'handle_token': <'"+token+"'>}").CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to ask the desktop portal for a screenshot: %v: %s", err, out)
+	}
+
+	for lines.Scan() {
+		match := portalResponse.FindStringSubmatch(lines.Text())
+		if match == nil || !strings.HasSuffix(match[1], "/"+token) {
+			continue
+		}
+		if match[2] != "0" {
+			return fmt.Errorf("the desktop portal refused the screenshot; allow wash to take screenshots in your desktop's privacy settings")
+		}
+		uri, err := url.Parse(match[3])
+		if err != nil || uri.Scheme != "file" {
+			return fmt.Errorf("the desktop portal returned an unexpected screenshot location: %s", match[3])
+		}
+		return moveFile(uri.Path, outputPath)
+	}
+	return fmt.Errorf("the desktop portal did not answer within %s", portalTimeout)
+}
+
+// moveFile moves a file, copying it when it is on another file system
+func moveFile(from, to string) error {
+	if os.Rename(from, to) == nil {
+		return nil
+	}
+	data, err := os.ReadFile(from)
+	if err != nil {
+		return fmt.Errorf("failed to read portal screenshot: %w", err)
+	}
+	if err := os.WriteFile(to, data, 0644); err != nil {
+		return fmt.Errorf("failed to write screenshot: %w", err)
+	}
+	os.Remove(from)
+	return nil
+}
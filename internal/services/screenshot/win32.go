@@ -0,0 +1,104 @@
+package screenshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// win32Types declares the user32 functions the PowerShell scripts call
+const win32Types = `Add-Type -AssemblyName System.Drawing
+Add-Type @"
+using System;
+using System.Runtime.InteropServices;
+public static class WashWin32 {
+	[StructLayout(LayoutKind.Sequential)] public struct RECT { public int Left, Top, Right, Bottom; }
+	[DllImport("user32.dll")] public static extern bool GetWindowRect(IntPtr hwnd, out RECT rect);
+	[DllImport("user32.dll")] public static extern bool IsIconic(IntPtr hwnd);
+	[DllImport("user32.dll")] public static extern IntPtr GetForegroundWindow();
+	[DllImport("user32.dll")] public static extern uint GetWindowThreadProcessId(IntPtr hwnd, out uint pid);
+	[DllImport("user32.dll")] public static extern bool PrintWindow(IntPtr hwnd, IntPtr hdc, uint flags);
+}
+"@
+`
+
+// win32WindowsScript lists the main windows of running processes as JSON
+const win32WindowsScript = win32Types + `$windows = Get-Process | Where-Object { $_.MainWindowHandle -ne 0 } | ForEach-Object {
+	$rect = New-Object WashWin32+RECT
+	[void][WashWin32]::GetWindowRect($_.MainWindowHandle, [ref]$rect)
+	[pscustomobject]@{
+		id = [int64]$_.MainWindowHandle; application = $_.ProcessName; title = $_.MainWindowTitle
+		x = $rect.Left; y = $rect.Top; width = $rect.Right - $rect.Left; height = $rect.Bottom - $rect.Top
+		on_screen = -not [WashWin32]::IsIconic($_.MainWindowHandle)
+	}
+}
+ConvertTo-Json -Compress -InputObject @($windows)`
+
+// win32ActiveWindowScript prints the process name and title of the foreground window
+const win32ActiveWindowScript = win32Types + `$hwnd = [WashWin32]::GetForegroundWindow()
+$id = 0
+[void][WashWin32]::GetWindowThreadProcessId($hwnd, [ref]$id)
+$process = Get-Process -Id $id
+$process.ProcessName
+$process.MainWindowTitle`
+
+// win32CaptureScript renders a window into a PNG with PrintWindow. Flag 2, PW_RENDERFULLCONTENT,
+// renders windows drawn with DirectX, such as Electron editors', even when they are covered.
+const win32CaptureScript = win32Types + `$hwnd = [IntPtr][int64]$env:WASH_WINDOW_HANDLE
+$rect = New-Object WashWin32+RECT
+[void][WashWin32]::GetWindowRect($hwnd, [ref]$rect)
+$bitmap = New-Object System.Drawing.Bitmap ($rect.Right - $rect.Left), ($rect.Bottom - $rect.Top)
+$graphics = [System.Drawing.Graphics]::FromImage($bitmap)
+$hdc = $graphics.GetHdc()
+$ok = [WashWin32]::PrintWindow($hwnd, $hdc, 2)
+$graphics.ReleaseHdc($hdc)
+$graphics.Dispose()
+if (-not $ok) { $bitmap.Dispose(); throw "PrintWindow failed" }
+$bitmap.Save($env:WASH_SCREENSHOT_PATH, [System.Drawing.Imaging.ImageFormat]::Png)
+$bitmap.Dispose()`
+
+// powershell runs a PowerShell script and returns its output
+func powershell(script string, env ...string) ([]byte, error) {
+	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	if len(env) > 0 {
+		cmd.Env = append(cmd.Environ(), env...)
+	}
+	out, err := cmd.Output()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return out, fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return out, err
+}
+
+// win32ActiveWindow returns the foreground window's process name and title
+func win32ActiveWindow() (*Window, error) {
+	out, err := powershell(win32ActiveWindowScript)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the focused window: %w", err)
+	}
+	app, title, _ := strings.Cut(strings.TrimSpace(strings.ReplaceAll(string(out), "\r\n", "\n")), "\n")
+	return &Window{Application: app, Title: title}, nil
+}
+
+// win32Windows lists the main windows of running processes
+func win32Windows() ([]windowInfo, error) {
+	out, err := powershell(win32WindowsScript)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list windows: %w", err)
+	}
+	var windows []windowInfo
+	if err := json.Unmarshal(out, &windows); err != nil {
+		return nil, fmt.Errorf("failed to parse window list: %w", err)
+	}
+	return windows, nil
+}
+
+// captureWin32Window renders a main window with PrintWindow
+func captureWin32Window(window windowInfo, outputPath string) error {
+	if _, err := powershell(win32CaptureScript, "WASH_WINDOW_HANDLE="+strconv.FormatInt(window.ID, 10), "WASH_SCREENSHOT_PATH="+outputPath); err != nil {
+		return fmt.Errorf("failed to capture window: %w", err)
+	}
+	return nil
+}
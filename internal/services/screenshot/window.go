@@ -0,0 +1,103 @@
+package screenshot
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/bkidd1/wash-cli/internal/utils/platform"
+)
+
+// DefaultWindows are the applications captured when monitor_windows is not set
+var DefaultWindows = []string{"Cursor", "Code", "Windsurf"}
+
+// frontmostScript asks System Events for the frontmost application and its front window's title
+const frontmostScript = `tell application "System Events"
+	set frontApp to first application process whose frontmost is true
+	set windowTitle to ""
+	try
+		set windowTitle to name of front window of frontApp
+	end try
+	return (name of frontApp) & linefeed & windowTitle
+end tell`
+
+// Window is the focused window
+type Window struct {
+	Application string
+	Title       string
+}
+
+// ActiveWindow returns the focused window, using System Events on macOS, user32 on Windows,
+// xdotool on X11 and the compositor on Wayland
+func ActiveWindow() (*Window, error) {
+	switch platform.CurrentOS() {
+	case platform.Darwin:
+		out, err := exec.Command("osascript", "-e", frontmostScript).Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the focused window (allow your terminal to control System Events): %w", err)
+		}
+		app, title, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+		return &Window{Application: app, Title: title}, nil
+	case platform.Linux:
+		if onWayland() {
+			return waylandActiveWindow()
+		}
+		title, err := exec.Command("xdotool", "getactivewindow", "getwindowname").Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the focused window (install xdotool): %w", err)
+		}
+		// Older xdotool releases have no getwindowclassname; the title is enough to match then
+		class, _ := exec.Command("xdotool", "getactivewindow", "getwindowclassname").Output()
+		return &Window{Application: strings.TrimSpace(string(class)), Title: strings.TrimSpace(string(title))}, nil
+	case platform.Windows:
+		return win32ActiveWindow()
+	default:
+		return nil, fmt.Errorf("finding the focused window is not supported on %s", platform.GetOSName())
+	}
+}
+
+// Match returns the target a window belongs to, or "" when it is none of them. Targets are
+// matched case-insensitively against the words of the application name, or of the title when the
+// application is unknown.
+func (w *Window) Match(targets []string) string {
+	name := w.Application
+	if name == "" {
+		name = w.Title
+	}
+	for _, target := range targets {
+		if containsWord(name, target) {
+			return target
+		}
+	}
+	return ""
+}
+
+// Name names the window's application for notes, falling back to its title
+func (w *Window) Name() string {
+	if w.Application != "" {
+		return w.Application
+	}
+	return w.Title
+}
+
+// containsWord reports whether s contains phrase as whole words, ignoring case, so a target of
+// Code matches "main.go - app - Visual Studio Code" but not "Encoder.java"
+func containsWord(s, phrase string) bool {
+	s, phrase = strings.ToLower(s), strings.ToLower(phrase)
+	for i := 0; ; {
+		j := strings.Index(s[i:], phrase)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(phrase)
+		if (start == 0 || !isWordByte(s[start-1])) && (end == len(s) || !isWordByte(s[end])) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+// isWordByte reports whether b is part of a word
+func isWordByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 0x80
+}
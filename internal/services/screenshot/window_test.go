@@ -0,0 +1,23 @@
+package screenshot
+
+import "testing"
+
+func TestWindowMatch(t *testing.T) {
+	targets := []string{"Cursor", "Code", "GoLand"}
+	tests := []struct {
+		window Window
+		want   string
+	}{
+		{Window{Application: "Cursor", Title: "main.go — app"}, "Cursor"},
+		{Window{Application: "Code", Title: "main.go - app - Visual Studio Code"}, "Code"},
+		{Window{Title: "main.go - app - Visual Studio Code"}, "Code"},
+		{Window{Application: "jetbrains-goland", Title: "app – main.go"}, "GoLand"},
+		{Window{Application: "Xcode", Title: "Encoder.swift"}, ""},
+		{Window{Application: "Firefox", Title: "Cursor pricing"}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.window.Match(targets); got != tt.want {
+			t.Errorf("Match(%+v) = %q, want %q", tt.window, got, tt.want)
+		}
+	}
+}
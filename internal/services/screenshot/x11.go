@@ -0,0 +1,75 @@
+package screenshot
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// x11Windows finds the applications' visible windows with xdotool, by their class
+func x11Windows(applications []string) ([]windowInfo, error) {
+	var windows []windowInfo
+	for _, application := range applications {
+		out, err := exec.Command("xdotool", "search", "--onlyvisible", "--class", regexp.QuoteMeta(application)).Output()
+		if err != nil {
+			// xdotool exits with 1 when no window matches
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("failed to list windows (install xdotool): %w", err)
+		}
+		for _, id := range strings.Fields(string(out)) {
+			if window, err := x11Window(id); err == nil {
+				windows = append(windows, window)
+			}
+		}
+	}
+	return windows, nil
+}
+
+// x11Window describes an X11 window from its class, title and geometry
+func x11Window(id string) (windowInfo, error) {
+	geometry, err := exec.Command("xdotool", "getwindowgeometry", "--shell", id).Output()
+	if err != nil {
+		return windowInfo{}, err
+	}
+	window := parseX11Geometry(string(geometry))
+	window.OnScreen = true
+	class, _ := exec.Command("xdotool", "getwindowclassname", id).Output()
+	title, _ := exec.Command("xdotool", "getwindowname", id).Output()
+	window.Application = strings.TrimSpace(string(class))
+	window.Title = strings.TrimSpace(string(title))
+	return window, nil
+}
+
+// parseX11Geometry reads the output of xdotool getwindowgeometry --shell, lines such as WIDTH=1280
+func parseX11Geometry(out string) windowInfo {
+	var window windowInfo
+	for _, line := range strings.Split(out, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			continue
+		}
+		switch key {
+		case "WINDOW":
+			window.ID = int64(n)
+		case "X":
+			window.X = n
+		case "Y":
+			window.Y = n
+		case "WIDTH":
+			window.Width = n
+		case "HEIGHT":
+			window.Height = n
+		}
+	}
+	return window
+}
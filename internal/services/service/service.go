@@ -0,0 +1,235 @@
+package service
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+)
+
+const (
+	// Label names the launchd agent
+	Label = "com.wash.monitor"
+	// UnitName names the systemd user unit
+	UnitName = "wash-monitor.service"
+	// LogName is the log of the service's output, in the data directory
+	LogName = "monitor.log"
+)
+
+// Service is the monitor command the service runs
+type Service struct {
+	// Executable is the absolute path of wash
+	Executable string
+	Args       []string
+	// WorkDir is the project directory the monitor runs in
+	WorkDir string
+	// Env holds the variables the monitor needs from the installing shell, such as DISPLAY
+	Env map[string]string
+	// LogPath receives the output of a launchd agent; systemd keeps it in the journal
+	LogPath string
+}
+
+// run executes a service manager command, replaced in tests
+var run = func(name string, args ...string) error {
+	if out, err := exec.Command(name, args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("%s %s failed: %v: %s", name, strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Path returns where the service definition is installed for the current OS
+func Path() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return filepath.Join(home, "Library", "LaunchAgents", Label+".plist"), nil
+	case "linux":
+		configHome := os.Getenv("XDG_CONFIG_HOME")
+		if configHome == "" {
+			configHome = filepath.Join(home, ".config")
+		}
+		return filepath.Join(configHome, "systemd", "user", UnitName), nil
+	default:
+		return "", fmt.Errorf("running the monitor as a service is supported on macOS and Linux only")
+	}
+}
+
+// LogPath returns the log of a launchd agent's output, in the data directory
+func LogPath() (string, error) {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, LogName), nil
+}
+
+// Install writes the service definition and starts it, replacing an installed one, and returns
+// the definition's path
+func Install(s Service) (string, error) {
+	path, err := Path()
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); err == nil {
+		// Stop the installed service so the new definition is the one running
+		stop(path)
+	}
+
+	definition := s.Unit()
+	if runtime.GOOS == "darwin" {
+		definition = s.Plist()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(definition), 0644); err != nil {
+		return "", fmt.Errorf("failed to write service definition: %w", err)
+	}
+
+	if runtime.GOOS == "darwin" {
+		err = run("launchctl", "bootstrap", launchdDomain(), path)
+	} else if err = run("systemctl", "--user", "daemon-reload"); err == nil {
+		err = run("systemctl", "--user", "enable", "--now", UnitName)
+	}
+	if err != nil {
+		return path, fmt.Errorf("wrote %s but failed to start it: %w", path, err)
+	}
+	return path, nil
+}
+
+// Uninstall stops the service and removes its definition, returning its path. It returns "" when
+// no service is installed.
+func Uninstall() (string, error) {
+	path, err := Path()
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return "", nil
+	}
+	stop(path)
+	if err := os.Remove(path); err != nil {
+		return "", fmt.Errorf("failed to remove service definition: %w", err)
+	}
+	if runtime.GOOS == "linux" {
+		if err := run("systemctl", "--user", "daemon-reload"); err != nil {
+			return path, err
+		}
+	}
+	return path, nil
+}
+
+// stop stops and unloads an installed service; failures are ignored, as it may not be running
+func stop(path string) {
+	if runtime.GOOS == "darwin" {
+		run("launchctl", "bootout", launchdDomain(), path)
+		return
+	}
+	run("systemctl", "--user", "disable", "--now", UnitName)
+}
+
+// launchdDomain is the launchd domain of the user's login session
+func launchdDomain() string {
+	return "gui/" + strconv.Itoa(os.Getuid())
+}
+
+// Plist renders the service as a launchd agent that starts at login and restarts after a crash
+func (s Service) Plist() string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+`)
+	fmt.Fprintf(&b, "\t<key>Label</key>\n\t<string>%s</string>\n", Label)
+	b.WriteString("\t<key>ProgramArguments</key>\n\t<array>\n")
+	for _, arg := range append([]string{s.Executable}, s.Args...) {
+		fmt.Fprintf(&b, "\t\t<string>%s</string>\n", html.EscapeString(arg))
+	}
+	b.WriteString("\t</array>\n")
+	fmt.Fprintf(&b, "\t<key>WorkingDirectory</key>\n\t<string>%s</string>\n", html.EscapeString(s.WorkDir))
+	if len(s.Env) > 0 {
+		b.WriteString("\t<key>EnvironmentVariables</key>\n\t<dict>\n")
+		for _, name := range sortedKeys(s.Env) {
+			fmt.Fprintf(&b, "\t\t<key>%s</key>\n\t\t<string>%s</string>\n", name, html.EscapeString(s.Env[name]))
+		}
+		b.WriteString("\t</dict>\n")
+	}
+	b.WriteString("\t<key>RunAtLoad</key>\n\t<true/>\n")
+	b.WriteString("\t<key>KeepAlive</key>\n\t<dict>\n\t\t<key>SuccessfulExit</key>\n\t\t<false/>\n\t</dict>\n")
+	if s.LogPath != "" {
+		fmt.Fprintf(&b, "\t<key>StandardOutPath</key>\n\t<string>%s</string>\n", html.EscapeString(s.LogPath))
+		fmt.Fprintf(&b, "\t<key>StandardErrorPath</key>\n\t<string>%s</string>\n", html.EscapeString(s.LogPath))
+	}
+	b.WriteString("</dict>\n</plist>\n")
+	return b.String()
+}
+
+// Unit renders the service as a systemd user unit that starts with the user's session and
+// restarts after a crash
+func (s Service) Unit() string {
+	var b strings.Builder
+	b.WriteString("[Unit]\nDescription=wash development monitor\nAfter=graphical-session.target\n\n[Service]\n")
+	fmt.Fprintf(&b, "ExecStart=%s\n", systemdCommand(append([]string{s.Executable}, s.Args...)))
+	// WorkingDirectory takes the path as is, without quotes, and expands only specifiers
+	fmt.Fprintf(&b, "WorkingDirectory=%s\n", strings.ReplaceAll(s.WorkDir, "%", "%%"))
+	for _, name := range sortedKeys(s.Env) {
+		fmt.Fprintf(&b, "Environment=%s\n", systemdEnvQuote(name+"="+s.Env[name]))
+	}
+	b.WriteString("Restart=on-failure\nRestartSec=30\n\n[Install]\nWantedBy=default.target\n")
+	return b.String()
+}
+
+// systemdCommand quotes the words of a command for ExecStart
+func systemdCommand(words []string) string {
+	quoted := make([]string, len(words))
+	for i, word := range words {
+		quoted[i] = systemdQuote(word)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// systemdQuote quotes a word of a command line that contains spaces, quotes, backslashes,
+// specifiers or variables, which systemd expands in ExecStart
+func systemdQuote(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\"'\\%$") {
+		return value
+	}
+	return `"` + strings.ReplaceAll(escapeQuoted(value), "$", "$$") + `"`
+}
+
+// systemdEnvQuote quotes a NAME=value assignment for Environment, which expands specifiers but not
+// variables, so a $ is kept as is
+func systemdEnvQuote(assignment string) string {
+	if !strings.ContainsAny(assignment, " \t\"'\\%") {
+		return assignment
+	}
+	return `"` + escapeQuoted(assignment) + `"`
+}
+
+// escapeQuoted escapes backslashes, double quotes and specifiers inside a double-quoted value
+func escapeQuoted(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return strings.ReplaceAll(value, "%", "%%")
+}
+
+// sortedKeys returns the keys of a map in order, so definitions are stable
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefinitions(t *testing.T) {
+	s := Service{
+		Executable: "/usr/local/bin/wash",
+		Args:       []string{"monitor", "--yes", "--project", "my app"},
+		WorkDir:    "/home/dev/my app",
+		Env:        map[string]string{"WASH_DATA_DIR": "/home/dev/.wash", "DISPLAY": ":0"},
+		LogPath:    "/home/dev/.wash/monitor.log",
+	}
+
+	unit := s.Unit()
+	for _, want := range []string{
+		`ExecStart=/usr/local/bin/wash monitor --yes --project "my app"`,
+		"WorkingDirectory=/home/dev/my app\n",
+		"Environment=DISPLAY=:0\nEnvironment=WASH_DATA_DIR=/home/dev/.wash\n",
+		"Restart=on-failure",
+		"WantedBy=default.target",
+	} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("Expected unit to contain %q:\n%s", want, unit)
+		}
+	}
+
+	s.WorkDir = "/home/dev/100%"
+	if unit := s.Unit(); !strings.Contains(unit, "WorkingDirectory=/home/dev/100%%\n") {
+		t.Errorf("Expected the specifier in WorkingDirectory escaped:\n%s", unit)
+	}
+	s.WorkDir = "/home/dev/my app"
+
+	plist := s.Plist()
+	for _, want := range []string{
+		"<string>" + Label + "</string>",
+		"\t\t<string>/usr/local/bin/wash</string>\n\t\t<string>monitor</string>",
+		"<key>WorkingDirectory</key>\n\t<string>/home/dev/my app</string>",
+		"<key>DISPLAY</key>\n\t\t<string>:0</string>",
+		"<key>StandardOutPath</key>",
+	} {
+		if !strings.Contains(plist, want) {
+			t.Errorf("Expected plist to contain %q:\n%s", want, plist)
+		}
+	}
+
+	tests := []struct {
+		quote func(string) string
+		value string
+		want  string
+	}{
+		{systemdQuote, `50% "done"`, `"50%% \"done\""`},
+		{systemdQuote, "$HOME", `"$$HOME"`},
+		{systemdQuote, "", `""`},
+		{systemdEnvQuote, "DISPLAY=:0", "DISPLAY=:0"},
+		// Environment does not expand variables, so a $ needs no escaping
+		{systemdEnvQuote, "TOKEN=a$b", "TOKEN=a$b"},
+		{systemdEnvQuote, `PS1=$ \w 100%`, `"PS1=$ \\w 100%%"`},
+	}
+	for _, tt := range tests {
+		if got := tt.quote(tt.value); got != tt.want {
+			t.Errorf("Quoting %q = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
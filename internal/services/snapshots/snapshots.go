@@ -0,0 +1,214 @@
+package snapshots
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/findings"
+	"github.com/bkidd1/wash-cli/internal/services/journal"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/crypt"
+)
+
+// namePattern limits snapshot names to characters that are safe in file names
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Snapshot is a project's findings, health score and summary frozen under a name
+type Snapshot struct {
+	Name        string          `json:"name"`
+	Project     string          `json:"project"`
+	CreatedAt   time.Time       `json:"created_at"`
+	HealthScore int             `json:"health_score"`
+	State       *findings.State `json:"state"`
+}
+
+// SnapshotManager handles storage of the snapshots of each project
+type SnapshotManager struct {
+	baseDir string
+	cipher  *crypt.Cipher // nil when encryption at rest is off
+}
+
+// NewSnapshotManager creates a new SnapshotManager instance
+func NewSnapshotManager() (*SnapshotManager, error) {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+
+	// Snapshots freeze the findings, which quote the analyzed code, so they are encrypted like them
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	cipher, err := crypt.Load(cfg.Encryption, dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unlock encrypted snapshots: %w", err)
+	}
+
+	return &SnapshotManager{baseDir: filepath.Join(dataDir, "projects"), cipher: cipher}, nil
+}
+
+// Dir returns the directory holding a project's snapshots
+func (sm *SnapshotManager) Dir(projectName string) string {
+	return filepath.Join(sm.baseDir, projectName, "snapshots")
+}
+
+// Create freezes the current state under a new name. Snapshots are immutable: a name can only be
+// used once, and the file is written read-only.
+func (sm *SnapshotManager) Create(name string, state *findings.State) (*Snapshot, error) {
+	if !namePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid snapshot name %q: use letters, digits, dots, dashes and underscores", name)
+	}
+
+	snapshot := &Snapshot{
+		Name:        name,
+		Project:     state.Project,
+		CreatedAt:   time.Now(),
+		HealthScore: state.HealthScore(),
+		State:       state,
+	}
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling snapshot: %w", err)
+	}
+	if data, err = sm.cipher.Encrypt(data); err != nil {
+		return nil, fmt.Errorf("error encrypting snapshot: %w", err)
+	}
+
+	dir := sm.Dir(state.Project)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating snapshot directory: %w", err)
+	}
+	path := filepath.Join(dir, name+".json")
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0444)
+	if errors.Is(err, os.ErrExist) {
+		return nil, fmt.Errorf("snapshot %q already exists", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error creating snapshot: %w", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		os.Remove(path)
+		return nil, fmt.Errorf("error writing snapshot: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("error writing snapshot: %w", err)
+	}
+
+	journal.Record(journal.ActionSaved, state.Project, path, "snapshot "+name)
+	return snapshot, nil
+}
+
+// Get loads a snapshot by name
+func (sm *SnapshotManager) Get(projectName, name string) (*Snapshot, error) {
+	if !namePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid snapshot name %q", name)
+	}
+	data, err := os.ReadFile(filepath.Join(sm.Dir(projectName), name+".json"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("snapshot %q not found", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading snapshot: %w", err)
+	}
+	if data, err = sm.cipher.Decrypt(data); err != nil {
+		return nil, fmt.Errorf("error decrypting snapshot %s: %w", name, err)
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("error parsing snapshot %s: %w", name, err)
+	}
+	if snapshot.State == nil {
+		snapshot.State = &findings.State{Project: projectName}
+	}
+	if snapshot.State.Files == nil {
+		snapshot.State.Files = map[string]*findings.File{}
+	}
+	return &snapshot, nil
+}
+
+// List returns a project's snapshots, oldest first
+func (sm *SnapshotManager) List(projectName string) ([]*Snapshot, error) {
+	paths, err := filepath.Glob(filepath.Join(sm.Dir(projectName), "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing snapshots: %w", err)
+	}
+
+	var list []*Snapshot
+	for _, path := range paths {
+		snapshot, err := sm.Get(projectName, strings.TrimSuffix(filepath.Base(path), ".json"))
+		if err != nil {
+			fmt.Printf("Warning: %v\n", err)
+			continue
+		}
+		list = append(list, snapshot)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return list, nil
+}
+
+// Change is how one file's findings differ between two states
+type Change struct {
+	Path     string
+	Added    []string
+	Resolved []string
+}
+
+// Compare lists the findings added and resolved between two states, by file
+func Compare(from, to *findings.State) []Change {
+	paths := from.Paths()
+	for _, path := range to.Paths() {
+		if _, ok := from.Files[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var changes []Change
+	for _, path := range paths {
+		before, after := issues(from.Files[path]), issues(to.Files[path])
+		change := Change{Path: path}
+		for _, issue := range after {
+			if !slices.Contains(before, issue) {
+				change.Added = append(change.Added, issue)
+			}
+		}
+		for _, issue := range before {
+			if !slices.Contains(after, issue) {
+				change.Resolved = append(change.Resolved, issue)
+			}
+		}
+		if len(change.Added) > 0 || len(change.Resolved) > 0 {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// issues lists a file's findings with their severity, so a finding that changed severity counts as changed
+func issues(file *findings.File) []string {
+	if file == nil {
+		return nil
+	}
+	var list []string
+	for _, issue := range file.CriticalIssues {
+		list = append(list, "[critical] "+issue)
+	}
+	for _, issue := range file.ShouldFix {
+		list = append(list, "[should fix] "+issue)
+	}
+	for _, issue := range file.CouldFix {
+		list = append(list, "[could fix] "+issue)
+	}
+	return list
+}
@@ -0,0 +1,48 @@
+package snapshots
+
+import (
+	"testing"
+
+	"github.com/bkidd1/wash-cli/internal/services/findings"
+)
+
+func TestCreateAndCompare(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	sm, err := NewSnapshotManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := &findings.State{Project: "demo", Files: map[string]*findings.File{
+		"main.go": {Path: "main.go", CriticalIssues: []string{"Unchecked error"}, CouldFix: []string{"Long function"}},
+	}}
+	created, err := sm.Create("pre-release-1.4", before)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if created.HealthScore != 89 {
+		t.Errorf("Expected health score 89, got %d", created.HealthScore)
+	}
+	if _, err := sm.Create("pre-release-1.4", before); err == nil {
+		t.Error("Expected an existing snapshot to be immutable")
+	}
+	if _, err := sm.Create("../escape", before); err == nil {
+		t.Error("Expected a name with a path separator to be rejected")
+	}
+
+	loaded, err := sm.Get("demo", "pre-release-1.4")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	after := &findings.State{Project: "demo", Files: map[string]*findings.File{
+		"main.go": {Path: "main.go", CouldFix: []string{"Long function"}},
+		"util.go": {Path: "util.go", ShouldFix: []string{"Missing test"}},
+	}}
+	changes := Compare(loaded.State, after)
+	if len(changes) != 2 {
+		t.Fatalf("Expected changes in 2 files, got %+v", changes)
+	}
+	if len(changes[0].Resolved) != 1 || len(changes[0].Added) != 0 || len(changes[1].Added) != 1 {
+		t.Errorf("Unexpected changes: %+v", changes)
+	}
+}
@@ -0,0 +1,101 @@
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Source identifies where terminal text is captured from
+type Source string
+
+const (
+	// SourceTmux captures a tmux pane with capture-pane
+	SourceTmux Source = "tmux"
+	// SourceScreen captures a GNU screen window with hardcopy
+	SourceScreen Source = "screen"
+)
+
+// DefaultScrollbackLines is how many lines of scrollback are captured
+const DefaultScrollbackLines = 200
+
+// ParseSource validates a source name
+func ParseSource(name string) (Source, error) {
+	switch Source(name) {
+	case SourceTmux, SourceScreen:
+		return Source(name), nil
+	default:
+		return "", fmt.Errorf("unknown terminal source %q (expected tmux or screen)", name)
+	}
+}
+
+// Capture returns the last lines of scrollback from the terminal source.
+// The target is a tmux target pane (e.g. "dev:1.0") or a screen session name; empty uses the current one.
+func Capture(source Source, target string, lines int) (string, error) {
+	if lines <= 0 {
+		lines = DefaultScrollbackLines
+	}
+
+	switch source {
+	case SourceTmux:
+		return captureTmux(target, lines)
+	case SourceScreen:
+		return captureScreen(target, lines)
+	default:
+		return "", fmt.Errorf("unknown terminal source %q", source)
+	}
+}
+
+// captureTmux captures a tmux pane, joining wrapped lines
+func captureTmux(target string, lines int) (string, error) {
+	args := []string{"capture-pane", "-p", "-J", "-S", "-" + strconv.Itoa(lines)}
+	if target != "" {
+		args = append(args, "-t", target)
+	}
+
+	out, err := exec.Command("tmux", args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to capture tmux pane: %w", err)
+	}
+	return lastLines(string(out), lines), nil
+}
+
+// captureScreen writes a GNU screen hardcopy with scrollback to a temporary file and reads it
+func captureScreen(target string, lines int) (string, error) {
+	tmp, err := os.CreateTemp("", "wash-screen-*.txt")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	var args []string
+	if target != "" {
+		args = append(args, "-S", target)
+	}
+	args = append(args, "-X", "hardcopy", "-h", tmp.Name())
+
+	if err := exec.Command("screen", args...).Run(); err != nil {
+		return "", fmt.Errorf("failed to capture screen window: %w", err)
+	}
+
+	data, err := os.ReadFile(tmp.Name())
+	if err != nil {
+		return "", fmt.Errorf("failed to read screen hardcopy: %w", err)
+	}
+	return lastLines(string(data), lines), nil
+}
+
+// lastLines returns the last n lines of text with trailing whitespace trimmed
+func lastLines(text string, n int) string {
+	all := strings.Split(strings.TrimRight(text, " \n"), "\n")
+	if len(all) > n {
+		all = all[len(all)-n:]
+	}
+	for i := range all {
+		all[i] = strings.TrimRight(all[i], " ")
+	}
+	return strings.Join(all, "\n")
+}
@@ -0,0 +1,15 @@
+package terminal
+
+import "testing"
+
+func TestLastLines(t *testing.T) {
+	text := "one\ntwo  \nthree\n\n\n"
+
+	if got := lastLines(text, 2); got != "two\nthree" {
+		t.Errorf("Expected %q, got %q", "two\nthree", got)
+	}
+
+	if got := lastLines(text, 10); got != "one\ntwo\nthree" {
+		t.Errorf("Expected %q, got %q", "one\ntwo\nthree", got)
+	}
+}
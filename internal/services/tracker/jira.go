@@ -0,0 +1,167 @@
+package tracker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/bugs"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+)
+
+const (
+	// defaultJiraIssueType is the type of created issues when issue_type is not set
+	defaultJiraIssueType = "Bug"
+	// jiraSummaryLimit is the longest summary Jira accepts
+	jiraSummaryLimit = 255
+)
+
+// JiraTracker creates Jira issues through the REST API
+type JiraTracker struct {
+	cfg        config.JiraConfig
+	httpClient *http.Client
+}
+
+// NewJiraTracker creates a tracker for the configured Jira project
+func NewJiraTracker(cfg config.JiraConfig) (*JiraTracker, error) {
+	var missing []string
+	if cfg.BaseURL == "" {
+		missing = append(missing, "base_url")
+	}
+	if cfg.ProjectKey == "" {
+		missing = append(missing, "project_key")
+	}
+	if cfg.Token == "" {
+		missing = append(missing, "token")
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("jira is missing %s in wash.yaml", strings.Join(missing, ", "))
+	}
+	if cfg.IssueType == "" {
+		cfg.IssueType = defaultJiraIssueType
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+	// The token is sent with every request, so it only travels encrypted
+	if u, err := url.Parse(cfg.BaseURL); err != nil || u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("jira base_url must be an https URL such as https://example.atlassian.net, got %q", cfg.BaseURL)
+	}
+
+	return &JiraTracker{
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// Name returns the tracker's name
+func (j *JiraTracker) Name() string {
+	return "Jira"
+}
+
+// Publish creates an issue from the report, labelled wash, and returns its key and URL
+func (j *JiraTracker) Publish(ctx context.Context, report *bugs.Report) (*Issue, error) {
+	summary := report.Title()
+	if runes := []rune(summary); len(runes) > jiraSummaryLimit {
+		summary = string(runes[:jiraSummaryLimit-3]) + "..."
+	}
+
+	payload := map[string]interface{}{
+		"fields": map[string]interface{}{
+			"project":     map[string]string{"key": j.cfg.ProjectKey},
+			"issuetype":   map[string]string{"name": j.cfg.IssueType},
+			"summary":     summary,
+			"description": jiraMarkup(report),
+			"labels":      []string{"wash"},
+		},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding issue: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, j.cfg.BaseURL+"/rest/api/2/issue", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if j.cfg.Email != "" {
+		req.SetBasicAuth(j.cfg.Email, j.cfg.Token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+j.cfg.Token)
+	}
+
+	resp, err := j.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error reaching jira at %s: %w", j.cfg.BaseURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("jira rejected the issue: %s%s", resp.Status, jiraErrors(resp.Body))
+	}
+
+	var created struct {
+		Key string `json:"key"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil || created.Key == "" {
+		return nil, fmt.Errorf("error decoding jira response: %v", err)
+	}
+	return &Issue{Key: created.Key, URL: j.cfg.BaseURL + "/browse/" + created.Key}, nil
+}
+
+// jiraErrors extracts the messages of a Jira error response, prefixed with ": "
+func jiraErrors(body io.Reader) string {
+	var decoded struct {
+		ErrorMessages []string          `json:"errorMessages"`
+		Errors        map[string]string `json:"errors"`
+	}
+	if json.NewDecoder(io.LimitReader(body, 64*1024)).Decode(&decoded) != nil {
+		return ""
+	}
+	messages := decoded.ErrorMessages
+	for field, message := range decoded.Errors {
+		messages = append(messages, field+": "+message)
+	}
+	if len(messages) == 0 {
+		return ""
+	}
+	return ": " + strings.Join(messages, "; ")
+}
+
+// jiraMarkup converts a report's markdown to Jira wiki markup: headings, bullet lists and code blocks
+func jiraMarkup(report *bugs.Report) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "_Published from wash bug report %s of %s_\n\n", report.ID, report.Project)
+
+	inCode := false
+	for _, section := range report.Sections {
+		fmt.Fprintf(&b, "h2. %s\n", section.Heading)
+		for _, line := range strings.Split(strings.TrimRight(section.Body, "\n"), "\n") {
+			trimmed := strings.TrimSpace(line)
+			switch {
+			case strings.HasPrefix(trimmed, "```"):
+				inCode = !inCode
+				b.WriteString("{code}\n")
+			case inCode:
+				b.WriteString(line + "\n")
+			case strings.HasPrefix(line, "- "):
+				b.WriteString("* " + strings.TrimPrefix(line, "- ") + "\n")
+			case strings.HasPrefix(line, "### "):
+				b.WriteString("h3. " + strings.TrimPrefix(line, "### ") + "\n")
+			default:
+				b.WriteString(line + "\n")
+			}
+		}
+		b.WriteString("\n")
+	}
+	if inCode {
+		b.WriteString("{code}\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,63 @@
+package tracker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bkidd1/wash-cli/internal/services/bugs"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+)
+
+func TestJiraPublish(t *testing.T) {
+	var fields map[string]interface{}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, token, ok := r.BasicAuth(); !ok || user != "dev@example.com" || token != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var payload struct {
+			Fields map[string]interface{} `json:"fields"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		fields = payload.Fields
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"10001","key":"APP-7"}`))
+	}))
+	defer server.Close()
+
+	jira, err := NewJiraTracker(config.JiraConfig{BaseURL: server.URL + "/", ProjectKey: "APP", Email: "dev@example.com", Token: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jira.httpClient = server.Client()
+	report := bugs.Parse([]byte("# Bug Report\n\n## Description\nLogin fails after upgrade\n\n## Suggested Solutions\n- Clear stale sessions\n\n## Status\nOpen\n"))
+	issue, err := jira.Publish(context.Background(), report)
+	if err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+
+	if issue.Key != "APP-7" || issue.URL != server.URL+"/browse/APP-7" {
+		t.Errorf("Unexpected issue: %+v", issue)
+	}
+	if fields["summary"] != "Login fails after upgrade" {
+		t.Errorf("Expected the title as summary, got %v", fields["summary"])
+	}
+	if issueType := fields["issuetype"].(map[string]interface{})["name"]; issueType != "Bug" {
+		t.Errorf("Expected the default issue type, got %v", issueType)
+	}
+	if description := fields["description"].(string); !strings.Contains(description, "h2. Suggested Solutions\n* Clear stale sessions") {
+		t.Errorf("Expected the report in Jira markup, got %q", description)
+	}
+
+	if _, err := NewJiraTracker(config.JiraConfig{BaseURL: server.URL}); err == nil || !strings.Contains(err.Error(), "project_key, token") {
+		t.Errorf("Expected the missing settings to be named, got %v", err)
+	}
+	insecure := config.JiraConfig{BaseURL: "http://jira.example.com", ProjectKey: "APP", Token: "secret"}
+	if _, err := NewJiraTracker(insecure); err == nil || !strings.Contains(err.Error(), "https") {
+		t.Errorf("Expected a plain http base_url to be rejected, got %v", err)
+	}
+}
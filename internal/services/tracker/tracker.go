@@ -0,0 +1,39 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bkidd1/wash-cli/internal/services/bugs"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+)
+
+// Trackers lists the issue trackers bug reports can be published to
+var Trackers = []string{"jira"}
+
+// Issue is a bug report published to an issue tracker
+type Issue struct {
+	Key string
+	URL string
+}
+
+// Tracker publishes bug reports to an issue tracker
+type Tracker interface {
+	// Name is how the tracker is recorded in published reports
+	Name() string
+	Publish(ctx context.Context, report *bugs.Report) (*Issue, error)
+}
+
+// New returns the tracker called name, configured from cfg
+func New(name string, cfg *config.Config) (Tracker, error) {
+	switch strings.ToLower(name) {
+	case "jira":
+		if cfg.Jira == nil {
+			return nil, fmt.Errorf("jira is not configured: add a jira section with base_url, project_key and token to wash.yaml")
+		}
+		return NewJiraTracker(*cfg.Jira)
+	default:
+		return nil, fmt.Errorf("unsupported tracker %q (use %s)", name, strings.Join(Trackers, ", "))
+	}
+}
@@ -0,0 +1,364 @@
+package transcript
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Format identifies the terminal agent whose transcript is followed
+type Format string
+
+const (
+	// FormatClaude follows a Claude Code session log, JSON lines under ~/.claude/projects
+	FormatClaude Format = "claude"
+	// FormatAider follows aider's .aider.chat.history.md
+	FormatAider Format = "aider"
+)
+
+// aiderHistoryName is the chat history aider writes to the root of the repository
+const aiderHistoryName = ".aider.chat.history.md"
+
+const (
+	// maxTurnChars caps the text kept of one turn, so a long answer or pasted file does not fill the prompt
+	maxTurnChars = 2000
+	// maxTurns caps the turns returned at once, keeping the most recent, so a long session resumed
+	// or found in a new transcript is not analyzed whole
+	maxTurns = 40
+	// maxReadBytes caps how much of a transcript is read at once; the older part of a longer
+	// backlog is skipped
+	maxReadBytes = 1 << 20
+)
+
+// ParseFormat validates a transcript format name
+func ParseFormat(name string) (Format, error) {
+	switch Format(name) {
+	case FormatClaude, FormatAider:
+		return Format(name), nil
+	default:
+		return "", fmt.Errorf("unknown transcript format %q (expected claude or aider)", name)
+	}
+}
+
+// Application names the agent that writes transcripts in a format
+func (f Format) Application() string {
+	if f == FormatClaude {
+		return "Claude Code"
+	}
+	return "aider"
+}
+
+// Turn is one message of the conversation with the agent
+type Turn struct {
+	// Role is user or assistant
+	Role string
+	Text string
+	// Files are the files the assistant edited in this turn, when the transcript records them
+	Files []string
+}
+
+// Tailer follows a transcript, returning the turns added since it last read it
+type Tailer struct {
+	format Format
+	// target is a transcript file, or a directory whose newest transcript is followed
+	target string
+	file   string
+	offset int64
+}
+
+// NewTailer follows the transcript at target, or the default one of the agent for workDir when
+// target is empty. Only turns added after it starts are returned.
+func NewTailer(format Format, target, workDir string) (*Tailer, error) {
+	if target == "" {
+		var err error
+		if target, err = DefaultTarget(format, workDir); err != nil {
+			return nil, err
+		}
+	}
+	target, err := filepath.Abs(target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve transcript path: %w", err)
+	}
+	t := &Tailer{format: format, target: target}
+
+	// Start at the end of the current transcript; a new one is read from its start
+	if file, err := t.current(); err == nil && file != "" {
+		if info, err := os.Stat(file); err == nil {
+			t.file, t.offset = file, info.Size()
+		}
+	}
+	return t, nil
+}
+
+// DefaultTarget returns where an agent keeps its transcripts for a project directory: Claude
+// Code's session directory for it, or aider's chat history in it
+func DefaultTarget(format Format, workDir string) (string, error) {
+	if format == FormatAider {
+		return filepath.Join(workDir, aiderHistoryName), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	return filepath.Join(home, ".claude", "projects", claudeProjectName(workDir)), nil
+}
+
+// claudeProjectName is the directory Claude Code names after a project path, with every character
+// other than a letter or digit replaced by a dash
+func claudeProjectName(workDir string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '-'
+	}, workDir)
+}
+
+// Format returns the format of the transcript being followed
+func (t *Tailer) Format() Format {
+	return t.format
+}
+
+// Target returns the file or directory being followed
+func (t *Tailer) Target() string {
+	return t.target
+}
+
+// current returns the transcript to read: the target itself, or the most recently modified
+// transcript in it. It returns "" when there is none yet.
+func (t *Tailer) current() (string, error) {
+	info, err := os.Stat(t.target)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read transcript: %w", err)
+	}
+	if !info.IsDir() {
+		return t.target, nil
+	}
+
+	pattern := "*.jsonl"
+	if t.format == FormatAider {
+		pattern = aiderHistoryName
+	}
+	matches, err := filepath.Glob(filepath.Join(t.target, pattern))
+	if err != nil {
+		return "", err
+	}
+	newest, newestTime := "", int64(0)
+	for _, match := range matches {
+		if info, err := os.Stat(match); err == nil && info.ModTime().UnixNano() > newestTime {
+			newest, newestTime = match, info.ModTime().UnixNano()
+		}
+	}
+	return newest, nil
+}
+
+// Next returns the turns written since the last call, at most the last maxTurns of them. A line
+// still being written is left for the next call, and a transcript that was truncated or replaced
+// by a newer session is read from its start.
+func (t *Tailer) Next() ([]Turn, error) {
+	file, err := t.current()
+	if err != nil || file == "" {
+		return nil, err
+	}
+	if file != t.file {
+		t.file, t.offset = file, 0
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open transcript: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transcript: %w", err)
+	}
+	if info.Size() < t.offset {
+		t.offset = 0
+	}
+	start := t.offset
+	skipped := info.Size()-start > maxReadBytes
+	if skipped {
+		start = info.Size() - maxReadBytes
+	}
+	if _, err := f.Seek(start, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to read transcript: %w", err)
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transcript: %w", err)
+	}
+
+	// Only whole lines are parsed
+	end := bytes.LastIndexByte(data, '\n')
+	if end < 0 {
+		return nil, nil
+	}
+	data = data[:end+1]
+	t.offset = start + int64(len(data))
+	if skipped {
+		// The first line read was cut by skipping the older part
+		data = data[bytes.IndexByte(data, '\n')+1:]
+	}
+
+	var turns []Turn
+	if t.format == FormatAider {
+		turns = parseAider(data)
+	} else {
+		turns = parseClaude(data)
+	}
+	if len(turns) > maxTurns {
+		turns = turns[len(turns)-maxTurns:]
+	}
+	return turns, nil
+}
+
+// claudeEntry is the part of a Claude Code log line that holds a message
+type claudeEntry struct {
+	Type    string `json:"type"`
+	IsMeta  bool   `json:"isMeta"`
+	Message struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	} `json:"message"`
+}
+
+// claudeBlock is a block of a message's content: text, a tool call or a tool result
+type claudeBlock struct {
+	Type  string `json:"type"`
+	Text  string `json:"text"`
+	Name  string `json:"name"`
+	Input struct {
+		FilePath     string `json:"file_path"`
+		NotebookPath string `json:"notebook_path"`
+		Command      string `json:"command"`
+	} `json:"input"`
+}
+
+// parseClaude converts the messages of Claude Code log lines into turns. Tool results, which the
+// log records as user messages, are left out; tool calls are summarized in the assistant's turn.
+func parseClaude(data []byte) []Turn {
+	var turns []Turn
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		var entry claudeEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.IsMeta {
+			continue
+		}
+		if entry.Type != "user" && entry.Type != "assistant" {
+			continue
+		}
+
+		turn := Turn{Role: entry.Type}
+		var text string
+		var parts []string
+		var blocks []claudeBlock
+		if json.Unmarshal(entry.Message.Content, &text) == nil {
+			parts = append(parts, text)
+		} else if json.Unmarshal(entry.Message.Content, &blocks) == nil {
+			for _, block := range blocks {
+				switch block.Type {
+				case "text":
+					parts = append(parts, block.Text)
+				case "tool_use":
+					target := block.Input.FilePath
+					if target == "" {
+						target = block.Input.NotebookPath
+					}
+					if target != "" && (strings.Contains(block.Name, "Edit") || block.Name == "Write") {
+						turn.Files = append(turn.Files, target)
+					}
+					if target == "" {
+						target = firstLine(block.Input.Command)
+					}
+					parts = append(parts, strings.TrimSpace("["+block.Name+" "+target)+"]")
+				}
+			}
+		}
+		turn.Text = truncate(strings.TrimSpace(strings.Join(parts, "\n")))
+		if turn.Text == "" {
+			continue
+		}
+		turns = appendTurn(turns, turn)
+	}
+	return turns
+}
+
+// parseAider converts aider chat history into turns: "#### " lines are the user's, "> " lines are
+// aider's own output, of which only applied edits are kept, and the rest is the model's answer
+func parseAider(data []byte) []Turn {
+	var turns []Turn
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "# aider chat started"):
+			continue
+		case strings.HasPrefix(line, "#### "):
+			turns = appendTurn(turns, Turn{Role: "user", Text: strings.TrimPrefix(line, "#### ")})
+		case strings.HasPrefix(line, ">"):
+			if file, ok := strings.CutPrefix(strings.TrimSpace(strings.TrimPrefix(line, ">")), "Applied edit to "); ok {
+				turns = appendTurn(turns, Turn{Role: "assistant", Text: "[edited " + file + "]", Files: []string{file}})
+			}
+		default:
+			turns = appendTurn(turns, Turn{Role: "assistant", Text: line})
+		}
+	}
+
+	// Blank lines separate paragraphs within a turn
+	kept := turns[:0]
+	for _, turn := range turns {
+		if turn.Text = truncate(strings.TrimSpace(turn.Text)); turn.Text != "" {
+			kept = append(kept, turn)
+		}
+	}
+	return kept
+}
+
+// appendTurn adds a turn, joining it to the previous one when they have the same role
+func appendTurn(turns []Turn, turn Turn) []Turn {
+	if n := len(turns); n > 0 && turns[n-1].Role == turn.Role {
+		turns[n-1].Text = truncate(turns[n-1].Text + "\n" + turn.Text)
+		turns[n-1].Files = append(turns[n-1].Files, turn.Files...)
+		return turns
+	}
+	return append(turns, turn)
+}
+
+// truncate keeps the first maxTurnChars bytes of a turn's text
+func truncate(text string) string {
+	if len(text) <= maxTurnChars {
+		return text
+	}
+	return strings.ToValidUTF8(text[:maxTurnChars], "") + " [...]"
+}
+
+// firstLine returns the first line of a command
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
+
+// FormatTurns renders turns as the conversation sent for analysis
+func FormatTurns(turns []Turn) string {
+	var b strings.Builder
+	for _, turn := range turns {
+		role := "User"
+		if turn.Role == "assistant" {
+			role = "Assistant"
+		}
+		fmt.Fprintf(&b, "%s: %s\n", role, turn.Text)
+		if len(turn.Files) > 0 {
+			fmt.Fprintf(&b, "Files edited: %s\n", strings.Join(turn.Files, ", "))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,121 @@
+package transcript
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTailClaude(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "session.jsonl")
+	if err := os.WriteFile(path, []byte(`{"type":"user","message":{"role":"user","content":"old request"}}`+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tailer, err := NewTailer(FormatClaude, dir, "")
+	if err != nil {
+		t.Fatalf("NewTailer failed: %v", err)
+	}
+	if turns, err := tailer.Next(); err != nil || len(turns) != 0 {
+		t.Fatalf("Expected no turns before new activity, got %v, %v", turns, err)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"type":"user","message":{"role":"user","content":"Fix the login bug"}}` + "\n")
+	f.WriteString(`{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"Fixing it."},{"type":"tool_use","name":"Edit","input":{"file_path":"auth/login.go"}}]}}` + "\n")
+	f.WriteString(`{"type":"user","message":{"role":"user","content":[{"type":"tool_result","content":"ok"}]}}` + "\n")
+	f.WriteString(`{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"Done."}]}}` + "\n")
+	f.WriteString(`{"type":"user","message":`)
+	f.Close()
+
+	turns, err := tailer.Next()
+	if err != nil {
+		t.Fatalf("Next failed: %v", err)
+	}
+	if len(turns) != 2 {
+		t.Fatalf("Expected 2 turns, got %d: %v", len(turns), turns)
+	}
+	if turns[0].Role != "user" || turns[0].Text != "Fix the login bug" {
+		t.Errorf("Unexpected user turn: %+v", turns[0])
+	}
+	if turns[1].Text != "Fixing it.\n[Edit auth/login.go]\nDone." || len(turns[1].Files) != 1 || turns[1].Files[0] != "auth/login.go" {
+		t.Errorf("Unexpected assistant turn: %+v", turns[1])
+	}
+
+	// The partial line is read once it is finished
+	f, _ = os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString(`{"role":"user","content":"Now add a test"}}` + "\n")
+	f.Close()
+	if turns, _ := tailer.Next(); len(turns) != 1 || turns[0].Text != "Now add a test" {
+		t.Errorf("Expected the finished line, got %v", turns)
+	}
+}
+
+func TestTailCapsTurns(t *testing.T) {
+	dir := t.TempDir()
+	tailer, err := NewTailer(FormatClaude, dir, "")
+	if err != nil {
+		t.Fatalf("NewTailer failed: %v", err)
+	}
+
+	// A session found after the tailer started is read from its start, but only its latest turns
+	var b strings.Builder
+	for i := 0; i < 3*maxTurns; i++ {
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		b.WriteString(`{"type":"` + role + `","message":{"role":"` + role + `","content":"turn ` + strconv.Itoa(i) + `"}}` + "\n")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "resumed.jsonl"), []byte(b.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	turns, err := tailer.Next()
+	if err != nil {
+		t.Fatalf("Next failed: %v", err)
+	}
+	if len(turns) != maxTurns || turns[len(turns)-1].Text != "turn "+strconv.Itoa(3*maxTurns-1) {
+		t.Errorf("Expected the last %d turns, got %d ending in %+v", maxTurns, len(turns), turns[len(turns)-1])
+	}
+	if turns, _ := tailer.Next(); len(turns) != 0 {
+		t.Errorf("Expected no turns without new activity, got %d", len(turns))
+	}
+}
+
+func TestParseAider(t *testing.T) {
+	history := `
+# aider chat started at 2025-01-01 10:00:00
+
+> Added main.go to the chat.
+
+#### Add a --verbose flag
+
+I'll add the flag.
+
+main.go
+> Applied edit to main.go
+`
+	turns := parseAider([]byte(history))
+	if len(turns) != 2 {
+		t.Fatalf("Expected 2 turns, got %d: %v", len(turns), turns)
+	}
+	if turns[0].Role != "user" || turns[0].Text != "Add a --verbose flag" {
+		t.Errorf("Unexpected user turn: %+v", turns[0])
+	}
+	if !strings.Contains(turns[1].Text, "I'll add the flag.") || len(turns[1].Files) != 1 || turns[1].Files[0] != "main.go" {
+		t.Errorf("Unexpected assistant turn: %+v", turns[1])
+	}
+}
+
+func TestClaudeProjectName(t *testing.T) {
+	if got := claudeProjectName("/Users/me/my_app.v2"); got != "-Users-me-my-app-v2" {
+		t.Errorf("Expected -Users-me-my-app-v2, got %s", got)
+	}
+}
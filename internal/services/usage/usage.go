@@ -0,0 +1,449 @@
+package usage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/fsutil"
+	"github.com/bkidd1/wash-cli/internal/utils/tokens"
+	"github.com/sashabaranov/go-openai"
+)
+
+// ledgerTimeLayout names the monthly ledger files in ~/.wash/usage
+const ledgerTimeLayout = "2006-01"
+
+// ErrSpendCapReached is returned instead of making an API call once the month's spend reaches the cap
+var ErrSpendCapReached = errors.New("monthly spend cap reached")
+
+const (
+	// DefaultRequestsPerMinute is the API call rate when requests_per_minute is not set
+	DefaultRequestsPerMinute = 60
+	// rateBurst is how many calls can start at once before the rate applies
+	rateBurst = 5
+)
+
+var (
+	// command is the wash command recorded with each API call, set once at startup
+	command string
+
+	// spendCap is the monthly spend in USD at which API calls stop; zero means no cap
+	spendCap float64
+
+	// limiter spaces API calls of every client, so parallel analyses share one rate; nil means no limit
+	limiter *rateLimiter
+
+	// spendMu guards reserved, so parallel calls cannot all pass the cap check before any is recorded
+	spendMu sync.Mutex
+	// reserved is the estimated cost of the calls sent but not yet recorded in the ledger
+	reserved float64
+)
+
+// Record is one OpenAI API call in the usage ledger
+type Record struct {
+	Timestamp        time.Time `json:"timestamp"`
+	Command          string    `json:"command,omitempty"`
+	Endpoint         string    `json:"endpoint"`
+	Model            string    `json:"model,omitempty"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	TotalTokens      int       `json:"total_tokens"`
+	// Estimated is set when the API did not report usage, such as for a cancelled stream,
+	// and the tokens were counted locally
+	Estimated bool `json:"estimated,omitempty"`
+}
+
+// SetCommand names the wash command that API calls made from now on are recorded against
+func SetCommand(name string) {
+	command = name
+}
+
+// SetSpendCap stops API calls made from now on once this month's recorded spend reaches capUSD;
+// zero removes the cap
+func SetSpendCap(capUSD float64) {
+	spendCap = capUSD
+}
+
+// SetRateLimit spaces the API calls made from now on, across all clients, to requestsPerMinute
+// after a short burst; zero or less uses DefaultRequestsPerMinute
+func SetRateLimit(requestsPerMinute int) {
+	if requestsPerMinute <= 0 {
+		requestsPerMinute = DefaultRequestsPerMinute
+	}
+	limiter = &rateLimiter{interval: time.Minute / time.Duration(requestsPerMinute)}
+}
+
+// rateLimiter lets rateBurst calls start at once and then one per interval
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	// next is when the calls made so far would have started at exactly one per interval
+	next time.Time
+}
+
+// wait blocks until a call may start, or ctx is done
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	start := l.next.Add(-time.Duration(rateBurst-1) * l.interval)
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := start.Sub(now)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Cost returns the USD cost of a recorded call at the prices in the token pricing table;
+// calls to models missing from the table cost nothing
+func (r Record) Cost() float64 {
+	info := tokens.Lookup(r.Model)
+	return float64(r.PromptTokens)/1000*info.InputPer1K + float64(r.CompletionTokens)/1000*info.OutputPer1K
+}
+
+// MonthSpend returns the USD spent on API calls since the start of the current month
+func MonthSpend() (float64, error) {
+	now := time.Now()
+	records, err := Load(time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local))
+	if err != nil {
+		return 0, err
+	}
+	total := 0.0
+	for _, record := range records {
+		total += record.Cost()
+	}
+	return total, nil
+}
+
+// NewClient creates an OpenAI client that records every API call in the usage ledger
+func NewClient(apiKey string) *openai.Client {
+	cfg := openai.DefaultConfig(apiKey)
+	cfg.HTTPClient = &http.Client{Transport: &transport{base: http.DefaultTransport}}
+	return openai.NewClientWithConfig(cfg)
+}
+
+// transport records the model and token usage of successful API responses
+type transport struct {
+	base http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	model, promptTokens, completionTokens := requestEstimate(req)
+	release, err := reserve(model, promptTokens, completionTokens)
+	if err != nil {
+		return nil, err
+	}
+	if limiter != nil {
+		if err := limiter.wait(req.Context()); err != nil {
+			release()
+			return nil, err
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode >= 300 {
+		release()
+		return resp, err
+	}
+	endpoint := strings.TrimPrefix(req.URL.Path, "/v1")
+
+	// Streamed responses report usage in their last event, which is recorded as the body is read
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		resp.Body = &streamBody{ReadCloser: resp.Body, endpoint: endpoint, model: model, promptTokens: promptTokens, release: release}
+		return resp, nil
+	}
+	defer release()
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	recordResponse(endpoint, body)
+	return resp, nil
+}
+
+// reserve checks the spend cap against this month's spend plus the calls in flight, and holds
+// the estimated cost of this call until it is recorded; the returned func releases it
+func reserve(model string, promptTokens, completionTokens int) (func(), error) {
+	if spendCap <= 0 {
+		return func() {}, nil
+	}
+	info := tokens.Lookup(model)
+	cost := float64(promptTokens)/1000*info.InputPer1K + float64(completionTokens)/1000*info.OutputPer1K
+
+	spendMu.Lock()
+	defer spendMu.Unlock()
+	// An unreadable ledger must not block calls, so only a known spend is checked against the cap
+	if spent, err := MonthSpend(); err == nil && (spent >= spendCap || spent+reserved+cost > spendCap) {
+		return nil, fmt.Errorf("%w: $%.2f of $%.2f spent this month, $%.2f more held by calls in progress and about $%.2f needed for this one (raise monthly_spend_cap or run 'wash config upgrade')", ErrSpendCapReached, spent, spendCap, reserved, cost)
+	}
+	reserved += cost
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			spendMu.Lock()
+			reserved -= cost
+			spendMu.Unlock()
+		})
+	}, nil
+}
+
+// tokenUsage is the usage an API response reports
+type tokenUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// recordResponse records the usage reported by a response body
+func recordResponse(endpoint string, body []byte) {
+	var decoded struct {
+		Model string     `json:"model"`
+		Usage tokenUsage `json:"usage"`
+	}
+	if json.Unmarshal(body, &decoded) != nil {
+		return
+	}
+	record(endpoint, decoded.Model, decoded.Usage, false)
+}
+
+// record appends one API call to the ledger
+func record(endpoint, model string, used tokenUsage, estimated bool) {
+	// Usage tracking must never fail the call it records
+	_ = Append(Record{
+		Timestamp:        time.Now(),
+		Command:          command,
+		Endpoint:         endpoint,
+		Model:            model,
+		PromptTokens:     used.PromptTokens,
+		CompletionTokens: used.CompletionTokens,
+		TotalTokens:      used.TotalTokens,
+		Estimated:        estimated,
+	})
+}
+
+// requestEstimate returns the model of a chat request, the tokens of its messages counted locally,
+// and the most completion tokens it allows; requests without messages count as no tokens
+func requestEstimate(req *http.Request) (model string, promptTokens, completionTokens int) {
+	if req.GetBody == nil {
+		return "", 0, 0
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return "", 0, 0
+	}
+	data, err := io.ReadAll(body)
+	body.Close()
+	if err != nil {
+		return "", 0, 0
+	}
+
+	var decoded struct {
+		Model     string `json:"model"`
+		MaxTokens int    `json:"max_tokens"`
+		Messages  []struct {
+			Content json.RawMessage `json:"content"`
+		} `json:"messages"`
+	}
+	if json.Unmarshal(data, &decoded) != nil {
+		return "", 0, 0
+	}
+	var contents []string
+	for _, message := range decoded.Messages {
+		// Only text content is counted; image parts have no local token count
+		var text string
+		if json.Unmarshal(message.Content, &text) == nil {
+			contents = append(contents, text)
+		}
+	}
+	if len(contents) == 0 {
+		return decoded.Model, 0, 0
+	}
+	completionTokens = decoded.MaxTokens
+	if completionTokens <= 0 {
+		completionTokens = tokens.DefaultCompletionTokens
+	}
+	return decoded.Model, tokens.CountMessages(decoded.Model, contents...), completionTokens
+}
+
+// streamBody passes a streamed response through, recording the usage in its last "data:" event.
+// A stream closed before that event, such as a cancelled one, is recorded with estimated usage.
+type streamBody struct {
+	io.ReadCloser
+	endpoint string
+	// model and promptTokens come from the request until an event names the model
+	model        string
+	promptTokens int
+	// completion collects the streamed text, so usage can be estimated if it is never reported
+	completion strings.Builder
+	recorded   bool
+	// release frees the spend reserved for the call once its usage is recorded
+	release func()
+	// pending holds the start of a line that has not been read to its end yet
+	pending []byte
+}
+
+func (b *streamBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.pending = append(b.pending, p[:n]...)
+	for {
+		i := bytes.IndexByte(b.pending, '\n')
+		if i < 0 {
+			break
+		}
+		if data, found := bytes.CutPrefix(bytes.TrimSpace(b.pending[:i]), []byte("data:")); found {
+			b.event(bytes.TrimSpace(data))
+		}
+		b.pending = b.pending[i+1:]
+	}
+	return n, err
+}
+
+// event records the usage of the final event, and collects the text of the others
+func (b *streamBody) event(data []byte) {
+	var decoded struct {
+		Model   string      `json:"model"`
+		Usage   *tokenUsage `json:"usage"`
+		Choices []struct {
+			Delta struct {
+				Content   string `json:"content"`
+				ToolCalls []struct {
+					Function struct {
+						Arguments string `json:"arguments"`
+					} `json:"function"`
+				} `json:"tool_calls"`
+			} `json:"delta"`
+		} `json:"choices"`
+	}
+	if json.Unmarshal(data, &decoded) != nil {
+		return
+	}
+	if decoded.Model != "" {
+		b.model = decoded.Model
+	}
+	for _, choice := range decoded.Choices {
+		b.completion.WriteString(choice.Delta.Content)
+		for _, call := range choice.Delta.ToolCalls {
+			b.completion.WriteString(call.Function.Arguments)
+		}
+	}
+	if decoded.Usage != nil && !b.recorded {
+		b.recorded = true
+		record(b.endpoint, b.model, *decoded.Usage, false)
+		b.release()
+	}
+}
+
+// Close records estimated usage if the stream ended before reporting it, since the prompt
+// and the text streamed so far are billed even when the call is cancelled
+func (b *streamBody) Close() error {
+	if !b.recorded && (b.promptTokens > 0 || b.completion.Len() > 0) {
+		b.recorded = true
+		used := tokenUsage{PromptTokens: b.promptTokens, CompletionTokens: tokens.Count(b.model, b.completion.String())}
+		used.TotalTokens = used.PromptTokens + used.CompletionTokens
+		record(b.endpoint, b.model, used, true)
+	}
+	b.release()
+	return b.ReadCloser.Close()
+}
+
+// ledgerDir returns the directory holding the monthly usage ledgers
+func ledgerDir() (string, error) {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "usage"), nil
+}
+
+// Append adds a record to the ledger of its month
+func Append(record Record) error {
+	dir, err := ledgerDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("error creating usage directory: %w", err)
+	}
+
+	path := filepath.Join(dir, record.Timestamp.Format(ledgerTimeLayout)+".jsonl")
+	if err := fsutil.AppendJSONLine(path, record); err != nil {
+		return fmt.Errorf("error writing usage ledger: %w", err)
+	}
+	return nil
+}
+
+// Load returns the recorded API calls made at or after since, oldest first; a zero since loads all of them
+func Load(since time.Time) ([]Record, error) {
+	dir, err := ledgerDir()
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing usage ledgers: %w", err)
+	}
+	sort.Strings(paths)
+
+	var records []Record
+	for _, path := range paths {
+		// Skip whole months before since
+		month, err := time.ParseInLocation(ledgerTimeLayout, strings.TrimSuffix(filepath.Base(path), ".jsonl"), time.Local)
+		if err == nil && !since.IsZero() && month.AddDate(0, 1, 0).Before(since) {
+			continue
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("error opening usage ledger: %w", err)
+		}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			var record Record
+			if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+				// A line cut short by a crash is skipped
+				continue
+			}
+			if since.IsZero() || !record.Timestamp.Before(since) {
+				records = append(records, record)
+			}
+		}
+		err = scanner.Err()
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error reading usage ledger: %w", err)
+		}
+	}
+	return records, nil
+}
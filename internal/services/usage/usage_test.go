@@ -0,0 +1,229 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestClientRecordsUsage(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"model":"gpt-4-0613","choices":[{"message":{"role":"assistant","content":"ok"}}],"usage":{"prompt_tokens":12,"completion_tokens":3,"total_tokens":15}}`))
+	}))
+	defer server.Close()
+
+	// Same client as NewClient, pointed at the test server
+	cfg := openai.DefaultConfig("test-key")
+	cfg.BaseURL = server.URL + "/v1"
+	cfg.HTTPClient = &http.Client{Transport: &transport{base: http.DefaultTransport}}
+	client := openai.NewClientWithConfig(cfg)
+
+	SetCommand("file")
+	resp, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{Model: openai.GPT4})
+	if err != nil || resp.Choices[0].Message.Content != "ok" {
+		t.Fatalf("Completion = %+v, %v", resp, err)
+	}
+
+	records, err := Load(time.Now().Add(-time.Minute))
+	if err != nil || len(records) != 1 {
+		t.Fatalf("Load = %+v, %v", records, err)
+	}
+	got := records[0]
+	if got.Command != "file" || got.Endpoint != "/chat/completions" || got.Model != "gpt-4-0613" || got.TotalTokens != 15 {
+		t.Errorf("Recorded %+v", got)
+	}
+}
+
+func TestSpendCapStopsCalls(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"model":"gpt-4","choices":[{"message":{"role":"assistant","content":"ok"}}],"usage":{"prompt_tokens":100000,"completion_tokens":0,"total_tokens":100000}}`))
+	}))
+	defer server.Close()
+
+	cfg := openai.DefaultConfig("test-key")
+	cfg.BaseURL = server.URL + "/v1"
+	cfg.HTTPClient = &http.Client{Transport: &transport{base: http.DefaultTransport}}
+	client := openai.NewClientWithConfig(cfg)
+
+	// The first call costs $3 at gpt-4 prices, so the second reaches the $2 cap
+	SetSpendCap(2)
+	defer SetSpendCap(0)
+	if _, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{Model: openai.GPT4}); err != nil {
+		t.Fatalf("First call failed: %v", err)
+	}
+	_, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{Model: openai.GPT4})
+	if !errors.Is(err, ErrSpendCapReached) || calls != 1 {
+		t.Errorf("Expected the cap to stop the second call, got %v after %d calls", err, calls)
+	}
+	if spent, err := MonthSpend(); err != nil || spent < 2.99 || spent > 3.01 {
+		t.Errorf("MonthSpend = %v, %v", spent, err)
+	}
+}
+
+func TestSpendCapHoldsCostOfParallelCalls(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	var started atomic.Int32
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started.Add(1)
+		<-unblock
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"model":"gpt-4","choices":[{"message":{"role":"assistant","content":"ok"}}],"usage":{"prompt_tokens":10,"completion_tokens":10000,"total_tokens":10010}}`))
+	}))
+	defer server.Close()
+
+	cfg := openai.DefaultConfig("test-key")
+	cfg.BaseURL = server.URL + "/v1"
+	cfg.HTTPClient = &http.Client{Transport: &transport{base: http.DefaultTransport}}
+	client := openai.NewClientWithConfig(cfg)
+
+	// Each call may cost about $0.60 at gpt-4 prices, so only three fit under a $2 cap
+	SetSpendCap(2)
+	defer SetSpendCap(0)
+	request := openai.ChatCompletionRequest{
+		Model:     openai.GPT4,
+		MaxTokens: 10000,
+		Messages:  []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "hi"}},
+	}
+	var refused atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.CreateChatCompletion(context.Background(), request); errors.Is(err, ErrSpendCapReached) {
+				refused.Add(1)
+			} else if err != nil {
+				t.Errorf("Call failed: %v", err)
+			}
+		}()
+	}
+
+	// Hold the calls in flight until every one has been sent or refused
+	deadline := time.Now().Add(5 * time.Second)
+	for started.Load()+refused.Load() < 5 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	close(unblock)
+	wg.Wait()
+
+	if started.Load() != 3 || refused.Load() != 2 {
+		t.Errorf("Expected 3 calls sent and 2 refused, got %d sent and %d refused", started.Load(), refused.Load())
+	}
+	if math.Abs(reserved) > 1e-9 {
+		t.Errorf("Expected every reservation released, $%.2f still held", reserved)
+	}
+}
+
+func TestClientRecordsStreamedUsage(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {\"model\":\"gpt-4o\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"ok\"}}]}\n\n"))
+		w.Write([]byte("data: {\"model\":\"gpt-4o\",\"choices\":[],\"usage\":{\"prompt_tokens\":20,\"completion_tokens\":5,\"total_tokens\":25}}\n\n"))
+		w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	cfg := openai.DefaultConfig("test-key")
+	cfg.BaseURL = server.URL + "/v1"
+	cfg.HTTPClient = &http.Client{Transport: &transport{base: http.DefaultTransport}}
+	client := openai.NewClientWithConfig(cfg)
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), openai.ChatCompletionRequest{Model: openai.GPT4o})
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	for {
+		if _, err := stream.Recv(); err != nil {
+			break
+		}
+	}
+	stream.Close()
+
+	records, err := Load(time.Now().Add(-time.Minute))
+	if err != nil || len(records) != 1 || records[0].TotalTokens != 25 || records[0].Model != "gpt-4o" {
+		t.Errorf("Expected one record of the final usage event, got %+v, %v", records, err)
+	}
+}
+
+func TestClientEstimatesUsageOfCancelledStream(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {\"model\":\"gpt-4o\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"The first finding is\"}}]}\n\n"))
+		w.(http.Flusher).Flush()
+		// Hold the stream open until the client gives up, before any usage is reported
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	cfg := openai.DefaultConfig("test-key")
+	cfg.BaseURL = server.URL + "/v1"
+	cfg.HTTPClient = &http.Client{Transport: &transport{base: http.DefaultTransport}}
+	client := openai.NewClientWithConfig(cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.CreateChatCompletionStream(ctx, openai.ChatCompletionRequest{
+		Model:    openai.GPT4o,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Review this file for bugs"}},
+	})
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("Recv failed: %v", err)
+	}
+	cancel()
+	stream.Close()
+
+	records, err := Load(time.Now().Add(-time.Minute))
+	if err != nil || len(records) != 1 {
+		t.Fatalf("Expected one record of the cancelled stream, got %+v, %v", records, err)
+	}
+	got := records[0]
+	if !got.Estimated || got.Model != "gpt-4o" || got.PromptTokens == 0 || got.CompletionTokens == 0 || got.TotalTokens != got.PromptTokens+got.CompletionTokens {
+		t.Errorf("Recorded %+v", got)
+	}
+}
+
+func TestRateLimiterSpacesCallsAfterBurst(t *testing.T) {
+	l := &rateLimiter{interval: 20 * time.Millisecond}
+	start := time.Now()
+	for i := 0; i < rateBurst; i++ {
+		if err := l.wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 15*time.Millisecond {
+		t.Errorf("Expected the burst to start at once, took %v", elapsed)
+	}
+	for i := 0; i < 3; i++ {
+		if err := l.wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected calls after the burst to be spaced, took %v", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.wait(ctx); err == nil {
+		t.Error("Expected a canceled wait to fail")
+	}
+}
@@ -0,0 +1,213 @@
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Repo is a repository that belongs to a workspace
+type Repo struct {
+	Name string `json:"name"` // Project name used for notes, defaults to the directory name
+	Path string `json:"path"`
+}
+
+// Workspace groups related repositories that are developed together
+type Workspace struct {
+	Name      string    `json:"name"`
+	Repos     []Repo    `json:"repos"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ProjectNames returns the project names of the workspace repos
+func (w *Workspace) ProjectNames() []string {
+	names := make([]string, 0, len(w.Repos))
+	for _, repo := range w.Repos {
+		names = append(names, repo.Name)
+	}
+	return names
+}
+
+// Repo returns the repo with the given project name
+func (w *Workspace) Repo(name string) (*Repo, bool) {
+	for i := range w.Repos {
+		if w.Repos[i].Name == name {
+			return &w.Repos[i], true
+		}
+	}
+	return nil, false
+}
+
+// RepoForPath returns the repo containing the given path, the deepest one when repos are nested
+func (w *Workspace) RepoForPath(path string) (*Repo, bool) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, false
+	}
+	var found *Repo
+	for i := range w.Repos {
+		rel, err := filepath.Rel(w.Repos[i].Path, absPath)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			if found == nil || len(w.Repos[i].Path) > len(found.Path) {
+				found = &w.Repos[i]
+			}
+		}
+	}
+	return found, found != nil
+}
+
+// AddRepo adds a repository by path, using the directory name as its project name
+func (w *Workspace) AddRepo(path string) (*Repo, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving path %s: %w", path, err)
+	}
+
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading repository %s: %w", path, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", path)
+	}
+
+	name := filepath.Base(absPath)
+	for _, repo := range w.Repos {
+		if repo.Name == name {
+			return nil, fmt.Errorf("workspace %s already has a repository named %s", w.Name, name)
+		}
+	}
+
+	w.Repos = append(w.Repos, Repo{Name: name, Path: absPath})
+	return &w.Repos[len(w.Repos)-1], nil
+}
+
+// RemoveRepo removes the repo with the given project name
+func (w *Workspace) RemoveRepo(name string) error {
+	for i, repo := range w.Repos {
+		if repo.Name == name {
+			w.Repos = append(w.Repos[:i], w.Repos[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("workspace %s has no repository named %s", w.Name, name)
+}
+
+// WorkspaceManager handles storage of workspaces
+type WorkspaceManager struct {
+	baseDir string
+}
+
+// NewWorkspaceManager creates a new WorkspaceManager instance
+func NewWorkspaceManager() (*WorkspaceManager, error) {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+
+	baseDir := filepath.Join(dataDir, "workspaces")
+	if err := os.MkdirAll(baseDir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating workspaces directory: %w", err)
+	}
+
+	return &WorkspaceManager{baseDir: baseDir}, nil
+}
+
+// validName rejects workspace names that would escape the workspaces directory
+func validName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) || filepath.Base(name) != name {
+		return fmt.Errorf("invalid workspace name %q", name)
+	}
+	return nil
+}
+
+// SaveWorkspace saves a workspace
+func (wm *WorkspaceManager) SaveWorkspace(ws *Workspace) error {
+	if err := validName(ws.Name); err != nil {
+		return err
+	}
+	now := time.Now()
+	if ws.CreatedAt.IsZero() {
+		ws.CreatedAt = now
+	}
+	ws.UpdatedAt = now
+
+	data, err := json.MarshalIndent(ws, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling workspace: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(wm.baseDir, ws.Name+".json"), data, 0644); err != nil {
+		return fmt.Errorf("error writing workspace file: %w", err)
+	}
+
+	return nil
+}
+
+// LoadWorkspace loads a workspace by name
+func (wm *WorkspaceManager) LoadWorkspace(name string) (*Workspace, error) {
+	if err := validName(name); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(wm.baseDir, name+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("workspace %s does not exist", name)
+		}
+		return nil, fmt.Errorf("error reading workspace file: %w", err)
+	}
+
+	var ws Workspace
+	if err := json.Unmarshal(data, &ws); err != nil {
+		return nil, fmt.Errorf("error unmarshaling workspace: %w", err)
+	}
+
+	return &ws, nil
+}
+
+// ListWorkspaces returns all workspaces sorted by name
+func (wm *WorkspaceManager) ListWorkspaces() ([]*Workspace, error) {
+	files, err := os.ReadDir(wm.baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading workspaces directory: %w", err)
+	}
+
+	var workspaces []*Workspace
+	for _, file := range files {
+		if filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+
+		ws, err := wm.LoadWorkspace(strings.TrimSuffix(file.Name(), ".json"))
+		if err != nil {
+			continue
+		}
+		workspaces = append(workspaces, ws)
+	}
+
+	sort.Slice(workspaces, func(i, j int) bool {
+		return workspaces[i].Name < workspaces[j].Name
+	})
+
+	return workspaces, nil
+}
+
+// DeleteWorkspace deletes a workspace definition; notes for its repos are kept
+func (wm *WorkspaceManager) DeleteWorkspace(name string) error {
+	if err := validName(name); err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(wm.baseDir, name+".json")); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("workspace %s does not exist", name)
+		}
+		return fmt.Errorf("error deleting workspace: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+)
+
+func TestRepoForPath(t *testing.T) {
+	root := t.TempDir()
+	ws := &Workspace{Name: "shop"}
+	for _, name := range []string{"api", "web", "api-client"} {
+		dir := filepath.Join(root, name)
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ws.AddRepo(dir); err != nil {
+			t.Fatalf("AddRepo failed: %v", err)
+		}
+	}
+	// A repo nested in another, added after it
+	nested := filepath.Join(root, "api", "plugins")
+	if err := os.Mkdir(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ws.AddRepo(nested); err != nil {
+		t.Fatalf("AddRepo failed: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{filepath.Join(root, "api"), "api"},
+		{filepath.Join(root, "api", "internal", "server.go"), "api"},
+		{filepath.Join(root, "api", "plugins", "auth.go"), "plugins"},
+		// A sibling sharing the prefix is a different repo
+		{filepath.Join(root, "api-client", "client.go"), "api-client"},
+		{filepath.Join(root, "web", "..", "api"), "api"},
+		{filepath.Join(root, "..dotted"), ""},
+		{root, ""},
+		{filepath.Dir(root), ""},
+	}
+	for _, tt := range tests {
+		repo, ok := ws.RepoForPath(tt.path)
+		if tt.want == "" {
+			if ok {
+				t.Errorf("RepoForPath(%q) = %s, want no repo", tt.path, repo.Name)
+			}
+			continue
+		}
+		if !ok || repo.Name != tt.want {
+			t.Errorf("RepoForPath(%q) = %v, %v, want %s", tt.path, repo, ok, tt.want)
+		}
+	}
+}
+
+func TestAddAndRemoveRepo(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "notes.txt")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{filepath.Join(root, "api"), filepath.Join(root, "other", "api")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ws := &Workspace{Name: "shop"}
+	tests := []struct {
+		path    string
+		wantErr bool
+	}{
+		{filepath.Join(root, "api"), false},
+		// Project names come from the directory name, so they must be unique
+		{filepath.Join(root, "other", "api"), true},
+		{filepath.Join(root, "missing"), true},
+		{file, true},
+	}
+	for _, tt := range tests {
+		if _, err := ws.AddRepo(tt.path); (err != nil) != tt.wantErr {
+			t.Errorf("AddRepo(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+		}
+	}
+	if names := ws.ProjectNames(); len(names) != 1 || names[0] != "api" {
+		t.Fatalf("ProjectNames() = %v, want [api]", names)
+	}
+	if repo, ok := ws.Repo("api"); !ok || repo.Path != filepath.Join(root, "api") {
+		t.Errorf("Repo(api) = %v, %v", repo, ok)
+	}
+
+	if err := ws.RemoveRepo("web"); err == nil {
+		t.Error("Expected an error removing an unknown repo")
+	}
+	if err := ws.RemoveRepo("api"); err != nil {
+		t.Errorf("RemoveRepo failed: %v", err)
+	}
+	if _, ok := ws.Repo("api"); ok {
+		t.Error("Expected the repo to be removed")
+	}
+}
+
+func TestWorkspaceStorage(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(config.DataDirEnv, t.TempDir())
+	wm, err := NewWorkspaceManager()
+	if err != nil {
+		t.Fatalf("Failed to create workspace manager: %v", err)
+	}
+
+	for _, name := range []string{"web", "backend"} {
+		ws := &Workspace{Name: name, Repos: []Repo{{Name: name + "-app", Path: "/src/" + name}}}
+		if err := wm.SaveWorkspace(ws); err != nil {
+			t.Fatalf("SaveWorkspace failed: %v", err)
+		}
+		if ws.CreatedAt.IsZero() || ws.UpdatedAt.IsZero() {
+			t.Errorf("Expected timestamps to be set on %s", name)
+		}
+	}
+
+	workspaces, err := wm.ListWorkspaces()
+	if err != nil {
+		t.Fatalf("ListWorkspaces failed: %v", err)
+	}
+	if len(workspaces) != 2 || workspaces[0].Name != "backend" || workspaces[1].Name != "web" {
+		t.Fatalf("Expected workspaces sorted by name, got %v", workspaces)
+	}
+
+	tests := []struct {
+		name     string
+		wantRepo string
+		wantErr  bool
+	}{
+		{"web", "web-app", false},
+		{"backend", "backend-app", false},
+		{"missing", "", true},
+		{"../web", "", true},
+		{"..", "", true},
+	}
+	for _, tt := range tests {
+		ws, err := wm.LoadWorkspace(tt.name)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("LoadWorkspace(%q) succeeded, want an error", tt.name)
+			}
+			continue
+		}
+		if err != nil || len(ws.Repos) != 1 || ws.Repos[0].Name != tt.wantRepo {
+			t.Errorf("LoadWorkspace(%q) = %v, %v", tt.name, ws, err)
+		}
+	}
+
+	for _, name := range []string{"../escape", "a/b", `a\b`, ""} {
+		if err := wm.SaveWorkspace(&Workspace{Name: name}); err == nil {
+			t.Errorf("SaveWorkspace(%q) succeeded, want an error", name)
+		}
+	}
+
+	if err := wm.DeleteWorkspace("web"); err != nil {
+		t.Errorf("DeleteWorkspace failed: %v", err)
+	}
+	if err := wm.DeleteWorkspace("web"); err == nil {
+		t.Error("Expected an error deleting a missing workspace")
+	}
+}
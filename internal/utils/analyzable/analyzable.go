@@ -0,0 +1,166 @@
+package analyzable
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultMaxSize is the largest file analyzed when no limit is configured
+	DefaultMaxSize = 1 << 20
+	// sniffSize is how much of a file is read to detect binary, generated and minified content
+	sniffSize = 8 << 10
+	// minifiedLineLength is the line length above which web assets are treated as minified
+	minifiedLineLength = 500
+	// NoLimit disables the size check
+	NoLimit = -1
+)
+
+// Reasons a file is skipped
+const (
+	ReasonBinary    = "binary file"
+	ReasonTooLarge  = "file too large"
+	ReasonGenerated = "generated file"
+	ReasonMinified  = "minified file"
+	ReasonLockfile  = "dependency lockfile"
+)
+
+// generatedSuffixes mark files produced by code generators
+var generatedSuffixes = []string{
+	"_gen.go", "_generated.go", ".pb.go", ".pb.gw.go", ".gen.ts", ".generated.ts",
+	"_pb2.py", "_pb2_grpc.py", ".g.dart", ".freezed.dart", ".designer.cs", ".g.cs",
+}
+
+// minifiedSuffixes mark minified web assets and their source maps
+var minifiedSuffixes = []string{".min.js", ".min.mjs", ".min.css", ".js.map", ".css.map", ".bundle.js"}
+
+// lockfiles are dependency lockfiles, which are large and machine written
+var lockfiles = map[string]bool{
+	"package-lock.json": true, "yarn.lock": true, "pnpm-lock.yaml": true, "go.sum": true,
+	"Cargo.lock": true, "poetry.lock": true, "Pipfile.lock": true, "composer.lock": true,
+	"Gemfile.lock": true, "bun.lockb": true, "flake.lock": true,
+}
+
+// webAssetExts are the extensions checked for minified content
+var webAssetExts = map[string]bool{".js": true, ".mjs": true, ".cjs": true, ".css": true}
+
+// generatedMarker matches the headers code generators write, including Go's "Code generated ... DO NOT EDIT."
+var generatedMarker = regexp.MustCompile(`(?m)^\s*(//|#|/\*|\*|<!--)?\s*(Code generated .* DO NOT EDIT|@generated|<auto-generated|This file was automatically generated|AUTO-GENERATED FILE)`)
+
+// SkipError explains why a file is not worth analyzing
+type SkipError struct {
+	Path   string
+	Reason string
+	Detail string
+}
+
+func (e *SkipError) Error() string {
+	if e.Detail != "" {
+		return fmt.Sprintf("skipping %s: %s (%s)", e.Path, e.Reason, e.Detail)
+	}
+	return fmt.Sprintf("skipping %s: %s", e.Path, e.Reason)
+}
+
+// Check reports whether a file is worth sending to a model, returning a *SkipError for binary,
+// generated and minified files and for files larger than maxSize. A maxSize of zero uses DefaultMaxSize.
+func Check(path string, maxSize int64) error {
+	if maxSize == 0 {
+		maxSize = DefaultMaxSize
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", path)
+	}
+	if err := checkName(path); err != nil {
+		return err
+	}
+	if maxSize > 0 && info.Size() > maxSize {
+		return &SkipError{Path: path, Reason: ReasonTooLarge, Detail: fmt.Sprintf("%d KB, limit is %d KB", info.Size()>>10, maxSize>>10)}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	head := make([]byte, sniffSize)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	return checkContent(path, head[:n], int64(n) < info.Size())
+}
+
+// CheckContent applies the name and content checks to data that is already in memory
+func CheckContent(path string, data []byte) error {
+	if err := checkName(path); err != nil {
+		return err
+	}
+	if len(data) > sniffSize {
+		return checkContent(path, data[:sniffSize], true)
+	}
+	return checkContent(path, data, false)
+}
+
+// checkName detects generated, minified and lock files from their names
+func checkName(path string) error {
+	base := filepath.Base(path)
+	lower := strings.ToLower(base)
+
+	if lockfiles[base] {
+		return &SkipError{Path: path, Reason: ReasonLockfile}
+	}
+	for _, suffix := range generatedSuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return &SkipError{Path: path, Reason: ReasonGenerated, Detail: "*" + suffix}
+		}
+	}
+	for _, suffix := range minifiedSuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return &SkipError{Path: path, Reason: ReasonMinified, Detail: "*" + suffix}
+		}
+	}
+	return nil
+}
+
+// checkContent sniffs the start of a file; truncated is set when head is only part of it
+func checkContent(path string, head []byte, truncated bool) error {
+	if bytes.IndexByte(head, 0) >= 0 {
+		return &SkipError{Path: path, Reason: ReasonBinary}
+	}
+
+	// A multi-byte character may be cut off at the end of the sample
+	valid := head
+	if truncated {
+		for i := 0; i < utf8.UTFMax && len(valid) > 0 && !utf8.Valid(valid); i++ {
+			valid = valid[:len(valid)-1]
+		}
+	}
+	if !utf8.Valid(valid) {
+		return &SkipError{Path: path, Reason: ReasonBinary, Detail: "not UTF-8 text"}
+	}
+
+	if generatedMarker.Match(head) {
+		return &SkipError{Path: path, Reason: ReasonGenerated, Detail: "generated-code header"}
+	}
+
+	if webAssetExts[strings.ToLower(filepath.Ext(path))] {
+		for _, line := range bytes.Split(head, []byte("\n")) {
+			if len(line) > minifiedLineLength {
+				return &SkipError{Path: path, Reason: ReasonMinified, Detail: fmt.Sprintf("lines over %d characters", minifiedLineLength)}
+			}
+		}
+	}
+	return nil
+}
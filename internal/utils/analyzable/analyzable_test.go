@@ -0,0 +1,59 @@
+package analyzable
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.go":           "package main\n\nfunc main() {}\n",
+		"app.js":            "export const x = 1;\n",
+		"api.pb.go":         "package api\n",
+		"stringer.go":       "// Code generated by \"stringer -type=Kind\"; DO NOT EDIT.\n\npackage kinds\n",
+		"bundle.js":         "!function(){" + strings.Repeat("var a=1;", 100) + "}();\n",
+		"vendor.min.css":    "body{margin:0}\n",
+		"logo.png":          "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR",
+		"latin1.txt":        "caf\xe9\n",
+		"package-lock.json": "{}\n",
+		"big.txt":           strings.Repeat("a line of text\n", 200),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := map[string]string{
+		"main.go":           "",
+		"app.js":            "",
+		"api.pb.go":         ReasonGenerated,
+		"stringer.go":       ReasonGenerated,
+		"bundle.js":         ReasonMinified,
+		"vendor.min.css":    ReasonMinified,
+		"logo.png":          ReasonBinary,
+		"latin1.txt":        ReasonBinary,
+		"package-lock.json": ReasonLockfile,
+		"big.txt":           ReasonTooLarge,
+	}
+	for name, reason := range want {
+		err := Check(filepath.Join(dir, name), 2048)
+		var skip *SkipError
+		switch {
+		case reason == "" && err != nil:
+			t.Errorf("%s: expected analyzable, got %v", name, err)
+		case reason != "" && !errors.As(err, &skip):
+			t.Errorf("%s: expected %s, got %v", name, reason, err)
+		case reason != "" && skip.Reason != reason:
+			t.Errorf("%s: expected %s, got %s", name, reason, skip.Reason)
+		}
+	}
+
+	if err := Check(filepath.Join(dir, "big.txt"), NoLimit); err != nil {
+		t.Errorf("Expected no size limit with NoLimit, got %v", err)
+	}
+}
@@ -0,0 +1,264 @@
+package chunk
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// maxHeaderLines limits the file header repeated for context in follow-up requests
+const maxHeaderLines = 60
+
+// scriptDeclPattern matches the first line of a top-level JavaScript or TypeScript declaration
+var scriptDeclPattern = regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:declare\s+)?(?:async\s+)?(?:abstract\s+)?` +
+	`(import|function\*?|class|interface|type|enum|const|let|var|namespace|module)\b\s*([\w$]*)`)
+
+// scriptExts are the extensions of files split with the JavaScript/TypeScript scanner
+var scriptExts = map[string]bool{
+	".js": true, ".jsx": true, ".mjs": true, ".cjs": true,
+	".ts": true, ".tsx": true, ".mts": true, ".cts": true,
+}
+
+// Declaration is a top-level declaration of a source file as a range of zero-based lines, End
+// excluded. The range starts at its doc comment or decorators.
+type Declaration struct {
+	Name  string
+	Start int
+	End   int
+}
+
+// Declarations returns the top-level declarations of a Go, JavaScript or TypeScript file in order,
+// or nil for other languages. Go files are parsed; a file with syntax errors yields the
+// declarations before the error.
+func Declarations(path string, content []byte) []Declaration {
+	ext := strings.ToLower(filepath.Ext(path))
+	switch {
+	case ext == ".go":
+		return goDeclarations(path, content)
+	case scriptExts[ext]:
+		return scriptDeclarations(string(content))
+	}
+	return nil
+}
+
+// Header returns how many leading lines hold the package clause, file comments and imports, capped
+// so that it can be repeated as context for later chunks
+func Header(decls []Declaration) int {
+	header := 0
+	for _, decl := range decls {
+		if decl.Name != "import" {
+			header = decl.Start
+			break
+		}
+		header = decl.End
+	}
+	return min(header, maxHeaderLines)
+}
+
+// Cut moves the end of a chunk running from start to limit back to the start of the last
+// declaration it reaches, so that no declaration is split. When one declaration alone exceeds the
+// limit there is no better place, and limit is returned.
+func Cut(decls []Declaration, start, limit int) int {
+	cut := limit
+	for _, decl := range decls {
+		if decl.Start > limit {
+			break
+		}
+		if decl.Start > start {
+			cut = decl.Start
+		}
+		// The limit falls after a declaration ends, before the next starts
+		if decl.End <= limit && decl.End > start {
+			cut = decl.End
+		}
+	}
+	return cut
+}
+
+// Names lists the declarations between the lines start and end, for describing a chunk
+func Names(decls []Declaration, start, end int) []string {
+	var names []string
+	for _, decl := range decls {
+		if decl.Start >= start && decl.Start < end && decl.Name != "import" {
+			names = append(names, decl.Name)
+		}
+	}
+	return names
+}
+
+// Find returns the declarations named name, such as HandleRequest, Server.HandleRequest for a Go
+// method, or Box for a class or type
+func Find(decls []Declaration, name string) []Declaration {
+	var found []Declaration
+	for _, decl := range decls {
+		for _, candidate := range declNames(decl.Name) {
+			if candidate == name {
+				found = append(found, decl)
+				break
+			}
+		}
+	}
+	return found
+}
+
+// declNames returns the names a declaration can be looked up by: "func (*Server) Handle" is Handle
+// and Server.Handle
+func declNames(name string) []string {
+	fields := strings.Fields(name)
+	if len(fields) < 2 {
+		return nil
+	}
+	last := fields[len(fields)-1]
+	if len(fields) == 3 && strings.HasPrefix(fields[1], "(") {
+		receiver := strings.Trim(fields[1], "(*)")
+		return []string{last, receiver + "." + last}
+	}
+	return []string{last}
+}
+
+// goDeclarations reads the declarations of a Go file from its syntax tree
+func goDeclarations(path string, content []byte) []Declaration {
+	fset := token.NewFileSet()
+	file, _ := parser.ParseFile(fset, path, content, parser.ParseComments|parser.SkipObjectResolution)
+	if file == nil {
+		return nil
+	}
+
+	line := func(pos token.Pos) int { return fset.Position(pos).Line - 1 }
+	decls := make([]Declaration, 0, len(file.Decls))
+	for _, d := range file.Decls {
+		if _, bad := d.(*ast.BadDecl); bad {
+			break
+		}
+		decl := Declaration{Start: line(d.Pos()), End: line(d.End()) + 1}
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			decl.Name = "func " + d.Name.Name
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				decl.Name = fmt.Sprintf("func (%s) %s", receiverName(d.Recv.List[0].Type), d.Name.Name)
+			}
+			if d.Doc != nil {
+				decl.Start = line(d.Doc.Pos())
+			}
+		case *ast.GenDecl:
+			decl.Name = d.Tok.String()
+			if d.Tok != token.IMPORT && len(d.Specs) > 0 {
+				decl.Name += " " + specName(d.Specs[0])
+			}
+			if d.Doc != nil {
+				decl.Start = line(d.Doc.Pos())
+			}
+		}
+		decls = append(decls, decl)
+	}
+	return decls
+}
+
+// receiverName returns the type name of a method receiver, such as *Config
+func receiverName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return "*" + receiverName(t.X)
+	case *ast.IndexExpr:
+		return receiverName(t.X)
+	case *ast.IndexListExpr:
+		return receiverName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return "?"
+}
+
+// specName returns the first name a type, var or const spec declares
+func specName(spec ast.Spec) string {
+	switch s := spec.(type) {
+	case *ast.TypeSpec:
+		return s.Name.Name
+	case *ast.ValueSpec:
+		if len(s.Names) > 0 {
+			return s.Names[0].Name
+		}
+	}
+	return ""
+}
+
+// scriptDeclarations finds the top-level declarations of JavaScript or TypeScript source by
+// tracking the nesting of braces, brackets and parentheses outside strings and comments. A
+// declaration runs until the next one starts.
+func scriptDeclarations(content string) []Declaration {
+	lines := strings.Split(content, "\n")
+	var decls []Declaration
+	depth := 0
+	// quote is the string delimiter the scanner is inside, or '*' inside a block comment
+	var quote byte
+
+	for i, text := range lines {
+		trimmed := strings.TrimSpace(text)
+		if depth == 0 && quote == 0 {
+			if match := scriptDeclPattern.FindStringSubmatch(trimmed); match != nil {
+				name := match[1]
+				if name != "import" && match[2] != "" {
+					name += " " + match[2]
+				}
+				decls = append(decls, Declaration{Name: name, Start: leadingComments(lines, i)})
+			}
+		}
+
+		for j := 0; j < len(text); j++ {
+			c := text[j]
+			switch {
+			case quote == '*':
+				if c == '*' && j+1 < len(text) && text[j+1] == '/' {
+					quote = 0
+					j++
+				}
+			case quote != 0:
+				if c == '\\' {
+					j++
+				} else if c == quote {
+					quote = 0
+				}
+			case c == '/' && j+1 < len(text) && text[j+1] == '/':
+				j = len(text)
+			case c == '/' && j+1 < len(text) && text[j+1] == '*':
+				quote = '*'
+				j++
+			case c == '"' || c == '\'' || c == '`':
+				quote = c
+			case c == '{' || c == '(' || c == '[':
+				depth++
+			case c == '}' || c == ')' || c == ']':
+				depth = max(depth-1, 0)
+			}
+		}
+		// Only template literals and block comments continue on the next line
+		if quote == '"' || quote == '\'' {
+			quote = 0
+		}
+	}
+
+	for i := range decls {
+		if i+1 < len(decls) {
+			decls[i].End = decls[i+1].Start
+		} else {
+			decls[i].End = len(lines)
+		}
+	}
+	return decls
+}
+
+// leadingComments moves the start of a declaration up over the comments and decorators right above it
+func leadingComments(lines []string, start int) int {
+	for start > 0 {
+		prev := strings.TrimSpace(lines[start-1])
+		if !strings.HasPrefix(prev, "//") && !strings.HasPrefix(prev, "/*") && !strings.HasPrefix(prev, "*") && !strings.HasPrefix(prev, "@") {
+			break
+		}
+		start--
+	}
+	return start
+}
@@ -0,0 +1,81 @@
+package chunk
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDeclarationsGo(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+// Config holds settings
+type Config struct {
+	Name string
+}
+
+// Print prints the name
+func (c *Config) Print() {
+	fmt.Println(c.Name)
+}
+
+func main() {
+	(&Config{}).Print()
+}
+`
+	decls := Declarations("main.go", []byte(src))
+	var names []string
+	for _, d := range decls {
+		names = append(names, d.Name)
+	}
+	if got := strings.Join(names, ","); got != "import,type Config,func (*Config) Print,func main" {
+		t.Fatalf("Unexpected declarations: %s", got)
+	}
+	if decls[2].Start != 9 || decls[2].End != 13 {
+		t.Errorf("Expected Print to span lines 9-13 with its doc comment, got %d-%d", decls[2].Start, decls[2].End)
+	}
+	if found := Find(decls, "Config.Print"); len(found) != 1 || found[0].Start != 9 {
+		t.Errorf("Expected to find the Print method, got %+v", found)
+	}
+	if Header(decls) != 4 {
+		t.Errorf("Expected a 4-line header, got %d", Header(decls))
+	}
+
+	// A limit inside Print cuts before its doc comment; one after it cuts at its end
+	if cut := Cut(decls, 0, 11); cut != 9 {
+		t.Errorf("Expected cut at line 9, got %d", cut)
+	}
+	if cut := Cut(decls, 0, 13); cut != 13 {
+		t.Errorf("Expected cut at line 13, got %d", cut)
+	}
+	if cut := Cut(decls, 9, 11); cut != 11 {
+		t.Errorf("Expected the limit when one declaration exceeds it, got %d", cut)
+	}
+}
+
+func TestDeclarationsTypeScript(t *testing.T) {
+	src := `import { a } from "./a";
+
+/** Greets */
+export function greet(name: string) {
+	const s = "}";
+	return ` + "`hi ${name}\n}`" + `;
+}
+
+export class Box {
+	value = { a: 1 };
+}
+`
+	decls := Declarations("box.ts", []byte(src))
+	var names []string
+	for _, d := range decls {
+		names = append(names, d.Name)
+	}
+	if got := strings.Join(names, ","); got != "import,function greet,class Box" {
+		t.Fatalf("Unexpected declarations: %s", got)
+	}
+	if decls[1].Start != 2 || decls[2].Start != 9 {
+		t.Errorf("Expected greet at line 2 and Box at line 9, got %d and %d", decls[1].Start, decls[2].Start)
+	}
+}
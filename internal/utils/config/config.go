@@ -1,11 +1,18 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
 
+	"github.com/bkidd1/wash-cli/internal/utils/fsutil"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
 )
 
 const (
@@ -13,88 +20,554 @@ const (
 	DefaultConfigName = ".wash"
 	// DefaultConfigType is the default type of the config file
 	DefaultConfigType = "yaml"
+	// EnvPrefix prefixes the environment variables that override config settings
+	EnvPrefix = "WASH"
+	// DataDirEnv relocates the wash data directory
+	DataDirEnv = "WASH_DATA_DIR"
 )
 
+// RetentionKinds are the note kinds a retention age can be set for, plus the trash of deleted notes,
+// the monitor's screenshots, the context logs of analyses and the cache of analysis answers
+var RetentionKinds = []string{"monitor_notes", "progress_notes", "interactions", "trash", "screenshots", "context_logs", "analysis_cache"}
+
+// HookEvents are the wash events a hook command can be configured for
+var HookEvents = []string{"on-critical-finding", "on-summary-generated", "on-bug-created"}
+
+// Profiles are the supported values of the profile setting. New configs start in the safe profile,
+// which uses a small model, keeps wash monitor off and caps spend, until 'wash config upgrade'.
+const (
+	ProfileSafe     = "safe"
+	ProfileStandard = "standard"
+)
+
+// Profiles lists the supported profiles
+var Profiles = []string{ProfileSafe, ProfileStandard}
+
+const (
+	// SafeModel is the analysis model of the safe profile when no model is set
+	SafeModel = "gpt-4o-mini"
+	// SafeSpendCapUSD is the monthly spend cap of the safe profile when no cap is set
+	SafeSpendCapUSD = 5.0
+)
+
+// defaultConfig is written when no config file exists
+const defaultConfig = `openai_key: ""
+project_goal: ""
+remember_notes: []
+profile: safe
+`
+
 // Config holds the application configuration
 type Config struct {
 	OpenAIKey     string   `yaml:"openai_key"`
 	ProjectGoal   string   `yaml:"project_goal,omitempty"`
 	RememberNotes []string `yaml:"remember_notes,omitempty"`
+	// Model overrides the model used for code, bug and project analysis
+	Model string `yaml:"model,omitempty"`
+	// FallbackModels are tried in order when the model is over quota or unavailable
+	FallbackModels []string `yaml:"fallback_models,omitempty"`
+	// Provider names the LLM provider; only openai is supported
+	Provider string `yaml:"provider,omitempty"`
+	// AttachmentQuotaMB caps attachment storage; zero uses the default
+	AttachmentQuotaMB int `yaml:"attachment_quota_mb,omitempty"`
+	// MaxFileSizeKB is the largest file sent for analysis; zero uses the default
+	MaxFileSizeKB int `yaml:"max_file_size_kb,omitempty"`
+	// Retention maps note kinds (monitor_notes, progress_notes, interactions), trash, screenshots,
+	// context_logs and analysis_cache to a maximum age such as 30d
+	Retention map[string]string `yaml:"retention,omitempty"`
+	// CompactAfter is the age, such as 7d, after which wash monitor rolls monitor notes into daily digests
+	CompactAfter string `yaml:"compact_monitor_notes_after,omitempty"`
+	// MonitorBatchSize is how many monitor notes wash monitor buffers before writing them; 1 writes each
+	// note immediately and zero uses the default
+	MonitorBatchSize int `yaml:"monitor_batch_size,omitempty"`
+	// MonitorInterval is how often wash monitor analyzes activity, such as 30s or 2m; empty uses the default
+	MonitorInterval string `yaml:"monitor_interval,omitempty"`
+	// MonitorWindows are the applications wash monitor captures when their window is focused, matched
+	// against the application name and window title; empty uses the default list
+	MonitorWindows []string `yaml:"monitor_windows,omitempty"`
+	// MonitorRegion crops screenshots to the assistant's chat panel: "auto" to find it, or the
+	// panel's x,y,width,height in percent of the window; empty sends the whole window
+	MonitorRegion string `yaml:"monitor_region,omitempty"`
+	// ScreenshotFormat, ScreenshotQuality and ScreenshotMaxSize set how monitor screenshots are
+	// encoded for upload: jpeg or png, the JPEG quality and the longest side in pixels; zero values
+	// use the defaults
+	ScreenshotFormat  string `yaml:"screenshot_format,omitempty"`
+	ScreenshotQuality int    `yaml:"screenshot_quality,omitempty"`
+	ScreenshotMaxSize int    `yaml:"screenshot_max_size,omitempty"`
+	// RedactSecrets is on or off; when on, the default, secrets are removed from what wash monitor
+	// sends, and blacked out of screenshots with tesseract; screenshots are not sent without it
+	RedactSecrets string `yaml:"redact_secrets,omitempty"`
+	// Encryption enables encryption at rest of notes and attachments with a key source: passphrase or keychain
+	Encryption string `yaml:"encryption,omitempty"`
+	// Profile is safe or standard; configs without one are standard
+	Profile string `yaml:"profile,omitempty"`
+	// MonthlySpendCap stops API calls once this many USD have been spent in a month; zero means no cap
+	MonthlySpendCap float64 `yaml:"monthly_spend_cap,omitempty"`
+	// Workers is how many analyses of a multi-file run or large project are sent at once; zero uses
+	// the default
+	Workers int `yaml:"workers,omitempty"`
+	// RequestsPerMinute caps the API calls wash makes per minute, shared by parallel analyses; zero
+	// uses the default
+	RequestsPerMinute int `yaml:"requests_per_minute,omitempty"`
+	// Jira configures publishing bug reports to Jira with wash bug publish
+	Jira *JiraConfig `yaml:"jira,omitempty"`
+	// Hooks maps wash events (on-critical-finding, on-summary-generated, on-bug-created) to shell
+	// commands run with a JSON payload on stdin
+	Hooks map[string]string `yaml:"hooks,omitempty"`
+	// ProjectHooks are the hooks set by the project .wash.yaml, which only run once trusted
+	ProjectHooks map[string]string `yaml:"-"`
 }
 
-// LoadConfig loads the configuration from file and environment variables
+// JiraConfig holds the Jira project bug reports are published to. Jira Cloud authenticates with
+// the account email and an API token; Jira Server and Data Center with a personal access token alone.
+type JiraConfig struct {
+	BaseURL    string `yaml:"base_url,omitempty"`
+	ProjectKey string `yaml:"project_key,omitempty"`
+	Email      string `yaml:"email,omitempty"`
+	Token      string `yaml:"token,omitempty"`
+	// IssueType is the type of the created issues; empty uses Bug
+	IssueType string `yaml:"issue_type,omitempty"`
+}
+
+// JiraKeys lists the settings of the jira section
+var JiraKeys = []string{"base_url", "project_key", "email", "token", "issue_type"}
+
+// CredentialKeys are the settings that hold credentials or say where they are sent. They are read
+// from the global config and the environment only: a cloned repository's .wash.yaml could
+// otherwise send the user's tokens to a host of its choosing.
+var CredentialKeys = []string{"openai_key", "jira.base_url", "jira.email", "jira.token"}
+
+// GlobalKeys are the settings read from the global config and the environment only: the
+// credentials, and the settings that protect the user's data and spending, which a cloned
+// repository's .wash.yaml must not weaken. Retention prunes the history of every project, so it is
+// global too.
+var GlobalKeys = globalKeys()
+
+// globalKeys lists GlobalKeys
+func globalKeys() []string {
+	keys := append([]string{}, CredentialKeys...)
+	keys = append(keys, "encryption", "redact_secrets", "profile", "monthly_spend_cap")
+	for _, kind := range RetentionKinds {
+		keys = append(keys, "retention."+kind)
+	}
+	return keys
+}
+
+// Safe reports whether the safe profile is active
+func (c *Config) Safe() bool {
+	return c.Profile == ProfileSafe
+}
+
+// flags holds the command line flags that override config settings, registered with BindFlags
+var flags *pflag.FlagSet
+
+// BindFlags registers the persistent flags that take precedence over every other config source
+func BindFlags(fs *pflag.FlagSet) {
+	fs.String("model", "", "Model for analysis (overrides $"+EnvPrefix+"_MODEL and config files)")
+	fs.String("provider", "", "LLM provider (overrides $"+EnvPrefix+"_PROVIDER and config files)")
+	fs.String("data-dir", "", "Directory for wash data and the global config (overrides $"+DataDirEnv+")")
+	flags = fs
+}
+
+// DataDir returns the directory holding wash data and the global config: the --data-dir flag,
+// then WASH_DATA_DIR, then ~/.wash
+func DataDir() (string, error) {
+	if flags != nil {
+		if f := flags.Lookup("data-dir"); f != nil && f.Changed && f.Value.String() != "" {
+			return filepath.Abs(f.Value.String())
+		}
+	}
+	if dir := os.Getenv(DataDirEnv); dir != "" {
+		return filepath.Abs(dir)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".wash"), nil
+}
+
+// LoadConfig loads the configuration. Sources take precedence in this order:
+// command line flags, WASH_* environment variables, the nearest project .wash.yaml, and the global wash.yaml.
 func LoadConfig() (*Config, error) {
-	// Set up Viper
-	viper.SetConfigName("wash")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath("$HOME/.wash")
+	configFile, err := Path()
+	if err != nil {
+		return nil, err
+	}
 
 	// Create config directory if it doesn't exist
-	configDir := filepath.Join(os.Getenv("HOME"), ".wash")
-	if err := os.MkdirAll(configDir, 0755); err != nil {
+	if err := os.MkdirAll(filepath.Dir(configFile), 0755); err != nil {
 		return nil, fmt.Errorf("error creating config directory: %w", err)
 	}
 
-	// Try to read the config file
-	if err := viper.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			// Config file not found, create it with default values
-			viper.Set("openai_key", "")
-			viper.Set("project_goal", "")
-			viper.Set("remember_notes", []string{})
-			if err := viper.SafeWriteConfig(); err != nil {
-				return nil, fmt.Errorf("error creating config file: %w", err)
+	// Config file not found, create it with default values
+	if _, err := os.Stat(configFile); os.IsNotExist(err) {
+		if err := fsutil.WriteFileAtomic(configFile, []byte(defaultConfig), 0600); err != nil {
+			return nil, fmt.Errorf("error creating config file: %w", err)
+		}
+	}
+
+	// A fresh Viper per load keeps settings from leaking between loads
+	v := viper.New()
+	v.SetConfigType("yaml")
+	v.SetConfigFile(configFile)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("error reading config file (run 'wash config validate' for details): %w", err)
+	}
+
+	globalHooks := make(map[string]string)
+	for _, event := range HookEvents {
+		globalHooks[event] = v.GetString("hooks." + event)
+	}
+
+	globalSettings := make(map[string]string)
+	for _, key := range GlobalKeys {
+		if v.IsSet(key) {
+			globalSettings[key] = v.GetString(key)
+		}
+	}
+
+	if projectFile := ProjectPath(); projectFile != "" {
+		v.SetConfigFile(projectFile)
+		if err := v.MergeInConfig(); err != nil {
+			return nil, fmt.Errorf("error reading project config %s (run 'wash config validate' for details): %w", projectFile, err)
+		}
+	}
+
+	// Hooks from a project config run commands from the repository, so they are tracked separately
+	projectHooks := make(map[string]string)
+	for _, event := range HookEvents {
+		if command := v.GetString("hooks." + event); command != "" && command != globalHooks[event] {
+			projectHooks[event] = command
+		}
+	}
+
+	// WASH_PROJECT_GOAL overrides project_goal, WASH_RETENTION_MONITOR_NOTES overrides retention.monitor_notes
+	// and WASH_HOOKS_ON_BUG_CREATED overrides hooks.on-bug-created
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_"))
+	v.AutomaticEnv()
+
+	if flags != nil {
+		for _, key := range []string{"model", "provider"} {
+			if f := flags.Lookup(key); f != nil {
+				if err := v.BindPFlag(key, f); err != nil {
+					return nil, fmt.Errorf("error binding --%s: %w", key, err)
+				}
 			}
-		} else {
-			return nil, fmt.Errorf("error reading config file: %w", err)
 		}
 	}
 
-	// Get OpenAI key from environment variable or config file
-	openAIKey := os.Getenv("OPENAI_API_KEY")
-	if openAIKey == "" {
-		openAIKey = viper.GetString("openai_key")
+	// Global settings come from the environment or, ignoring the project config, the global config
+	global := func(key string) string {
+		if os.Getenv(EnvPrefix+"_"+strings.ToUpper(strings.ReplaceAll(key, ".", "_"))) != "" {
+			return v.GetString(key)
+		}
+		return globalSettings[key]
+	}
+
+	// OPENAI_API_KEY is honored below WASH_OPENAI_KEY but above config files
+	openAIKey := global("openai_key")
+	if os.Getenv(EnvPrefix+"_OPENAI_KEY") == "" && os.Getenv("OPENAI_API_KEY") != "" {
+		openAIKey = os.Getenv("OPENAI_API_KEY")
+	}
+
+	// Environment lists are separated by semicolons, since notes contain spaces
+	rememberNotes := v.GetStringSlice("remember_notes")
+	if env := os.Getenv(EnvPrefix + "_REMEMBER_NOTES"); env != "" {
+		rememberNotes = nil
+		for _, note := range strings.Split(env, ";") {
+			if note = strings.TrimSpace(note); note != "" {
+				rememberNotes = append(rememberNotes, note)
+			}
+		}
+	}
+
+	fallbackModels := v.GetStringSlice("fallback_models")
+	if env := os.Getenv(EnvPrefix + "_FALLBACK_MODELS"); env != "" {
+		fallbackModels = nil
+		for _, model := range strings.Split(env, ",") {
+			if model = strings.TrimSpace(model); model != "" {
+				fallbackModels = append(fallbackModels, model)
+			}
+		}
+	}
+
+	monitorWindows := v.GetStringSlice("monitor_windows")
+	if env := os.Getenv(EnvPrefix + "_MONITOR_WINDOWS"); env != "" {
+		monitorWindows = nil
+		for _, window := range strings.Split(env, ",") {
+			if window = strings.TrimSpace(window); window != "" {
+				monitorWindows = append(monitorWindows, window)
+			}
+		}
+	}
+
+	var retention map[string]string
+	for _, kind := range RetentionKinds {
+		if age := global("retention." + kind); age != "" {
+			if retention == nil {
+				retention = make(map[string]string)
+			}
+			retention[kind] = age
+		}
+	}
+
+	var hooks map[string]string
+	for _, event := range HookEvents {
+		command := v.GetString("hooks." + event)
+		if command != projectHooks[event] {
+			delete(projectHooks, event)
+		}
+		if command == "" {
+			continue
+		}
+		if hooks == nil {
+			hooks = make(map[string]string)
+		}
+		hooks[event] = command
+	}
+
+	cfg := &Config{
+		OpenAIKey:         openAIKey,
+		ProjectGoal:       v.GetString("project_goal"),
+		RememberNotes:     rememberNotes,
+		Model:             v.GetString("model"),
+		FallbackModels:    fallbackModels,
+		Provider:          v.GetString("provider"),
+		AttachmentQuotaMB: v.GetInt("attachment_quota_mb"),
+		MaxFileSizeKB:     v.GetInt("max_file_size_kb"),
+		Retention:         retention,
+		Encryption:        global("encryption"),
+		CompactAfter:      v.GetString("compact_monitor_notes_after"),
+		MonitorBatchSize:  v.GetInt("monitor_batch_size"),
+		MonitorInterval:   v.GetString("monitor_interval"),
+		MonitorWindows:    monitorWindows,
+		MonitorRegion:     v.GetString("monitor_region"),
+		ScreenshotFormat:  v.GetString("screenshot_format"),
+		ScreenshotQuality: v.GetInt("screenshot_quality"),
+		ScreenshotMaxSize: v.GetInt("screenshot_max_size"),
+		RedactSecrets:     global("redact_secrets"),
+		Profile:           global("profile"),
+		Workers:           v.GetInt("workers"),
+		RequestsPerMinute: v.GetInt("requests_per_minute"),
+		Hooks:             hooks,
+		ProjectHooks:      projectHooks,
+	}
+
+	jira := &JiraConfig{
+		BaseURL:    global("jira.base_url"),
+		ProjectKey: v.GetString("jira.project_key"),
+		Email:      global("jira.email"),
+		Token:      global("jira.token"),
+		IssueType:  v.GetString("jira.issue_type"),
+	}
+	if *jira != (JiraConfig{}) {
+		cfg.Jira = jira
 	}
 
-	// Get project goal and remember notes
-	projectGoal := viper.GetString("project_goal")
-	rememberNotes := viper.GetStringSlice("remember_notes")
+	spendCap := global("monthly_spend_cap")
+	if spendCap != "" {
+		if cfg.MonthlySpendCap, err = strconv.ParseFloat(spendCap, 64); err != nil {
+			return nil, fmt.Errorf("invalid monthly_spend_cap %q (run 'wash config validate' for details)", spendCap)
+		}
+	}
 
-	return &Config{
-		OpenAIKey:     openAIKey,
-		ProjectGoal:   projectGoal,
-		RememberNotes: rememberNotes,
-	}, nil
+	// The safe profile fills in a small model and a spend cap, unless they are set explicitly
+	if cfg.Safe() {
+		if cfg.Model == "" {
+			cfg.Model = SafeModel
+		}
+		if spendCap == "" {
+			cfg.MonthlySpendCap = SafeSpendCapUSD
+		}
+	}
+
+	if cfg.Profile != "" && !contains(Profiles, cfg.Profile) {
+		return nil, fmt.Errorf("unsupported profile %q: use safe or standard", cfg.Profile)
+	}
+	if cfg.Provider != "" && !contains(Providers, cfg.Provider) {
+		return nil, fmt.Errorf("unsupported provider %q: only openai is supported", cfg.Provider)
+	}
+	return cfg, nil
+}
+
+// ProjectPath returns the nearest .wash.yaml in the current directory or its parents, stopping below the home directory
+func ProjectPath() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	home, _ := os.UserHomeDir()
+
+	for dir != home {
+		candidate := filepath.Join(dir, DefaultConfigName+"."+DefaultConfigType)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return ""
 }
 
 // SaveConfig saves the configuration to file
 func SaveConfig(config *Config) error {
-	// Reset Viper configuration
-	viper.Reset()
+	return UpdateConfig(func(c *Config) error {
+		*c = *config
+		return nil
+	})
+}
+
+// UpdateConfig applies fn to the configuration stored on disk and writes it back atomically.
+// Keys wash does not know about, such as settings from newer versions, and comments are preserved.
+func UpdateConfig(fn func(*Config) error) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error creating config directory: %w", err)
+	}
 
-	// Set up Viper again
-	viper.SetConfigName("wash")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath("$HOME/.wash")
+	unlock, err := fsutil.LockDir(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
-	// Set the values
-	viper.Set("openai_key", config.OpenAIKey)
-	viper.Set("project_goal", config.ProjectGoal)
-	viper.Set("remember_notes", config.RememberNotes)
+	doc, err := readConfigDocument(path)
+	if err != nil {
+		return err
+	}
 
-	// Get the config file path
-	home, err := os.UserHomeDir()
+	var cfg Config
+	if err := doc.Decode(&cfg); err != nil {
+		return fmt.Errorf("error decoding config file: %w", err)
+	}
+	if err := fn(&cfg); err != nil {
+		return err
+	}
+
+	var updated yaml.Node
+	if err := updated.Encode(&cfg); err != nil {
+		return fmt.Errorf("error encoding config: %w", err)
+	}
+	mergeKnownKeys(doc.Content[0], &updated)
+
+	data, err := yaml.Marshal(doc)
 	if err != nil {
-		return fmt.Errorf("failed to get home directory: %w", err)
+		return fmt.Errorf("error encoding config: %w", err)
 	}
 
-	configPath := filepath.Join(home, ".wash", "wash.yaml")
+	// Make sure what we are about to write reads back as the same configuration
+	if err := verifyConfig(data, &cfg); err != nil {
+		return err
+	}
 
-	// Write the config file
-	if err := viper.WriteConfigAs(configPath); err != nil {
+	if err := fsutil.WriteFileAtomic(path, data, 0600); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
+	return nil
+}
+
+// Path returns the path of the global config file
+func Path() (string, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "wash.yaml"), nil
+}
+
+// readConfigDocument parses the config file, returning an empty mapping document if it does not exist
+func readConfigDocument(path string) (*yaml.Node, error) {
+	doc := &yaml.Node{Kind: yaml.DocumentNode}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("error reading config file: %w", err)
+	}
+	if len(data) > 0 {
+		if err := yaml.Unmarshal(data, doc); err != nil {
+			return nil, fmt.Errorf("error parsing config file: %w", err)
+		}
+	}
+
+	if len(doc.Content) == 0 {
+		doc.Kind = yaml.DocumentNode
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode}}
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("config file %s is not a YAML mapping", path)
+	}
+	return doc, nil
+}
+
+// mergeKnownKeys copies the keys wash manages from updated into mapping, leaving other keys untouched
+func mergeKnownKeys(mapping *yaml.Node, updated *yaml.Node) {
+	values := make(map[string]*yaml.Node)
+	for i := 0; i+1 < len(updated.Content); i += 2 {
+		values[updated.Content[i].Value] = updated.Content[i+1]
+	}
 
+	for _, key := range knownKeys() {
+		value, ok := values[key]
+		index := -1
+		for i := 0; i+1 < len(mapping.Content); i += 2 {
+			if mapping.Content[i].Value == key {
+				index = i
+				break
+			}
+		}
+
+		switch {
+		case ok && index >= 0:
+			mapping.Content[index+1] = value
+		case ok:
+			mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+		case index >= 0:
+			// An empty setting is omitted rather than left at its old value
+			mapping.Content = append(mapping.Content[:index], mapping.Content[index+2:]...)
+		}
+	}
+}
+
+// knownKeys returns the config file keys backed by Config fields
+func knownKeys() []string {
+	t := reflect.TypeOf(Config{})
+	keys := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		if key := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]; key != "" && key != "-" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// verifyConfig checks that encoded config data decodes back to cfg
+func verifyConfig(data []byte, cfg *Config) error {
+	var decoded Config
+	if err := yaml.Unmarshal(data, &decoded); err != nil {
+		return fmt.Errorf("config failed verification: %w", err)
+	}
+
+	want, err := yaml.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("error encoding config: %w", err)
+	}
+	got, err := yaml.Marshal(&decoded)
+	if err != nil {
+		return fmt.Errorf("error encoding config: %w", err)
+	}
+	if !bytes.Equal(want, got) {
+		return fmt.Errorf("config failed verification: written settings do not match")
+	}
 	return nil
 }
 
@@ -0,0 +1,279 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUpdateConfigPreservesUnknownKeys(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	path := filepath.Join(home, ".wash", "wash.yaml")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	original := `# my settings
+openai_key: old-key
+future_setting:
+  enabled: true
+remember_notes:
+  - keep tests fast
+`
+	if err := os.WriteFile(path, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := UpdateConfig(func(cfg *Config) error {
+		if cfg.OpenAIKey != "old-key" || len(cfg.RememberNotes) != 1 {
+			t.Errorf("Unexpected config read from disk: %+v", cfg)
+		}
+		cfg.OpenAIKey = "new-key"
+		cfg.RememberNotes = nil
+		cfg.ProjectGoal = "ship it"
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateConfig failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	written := string(data)
+
+	for _, want := range []string{"# my settings", "openai_key: new-key", "future_setting:", "enabled: true", "project_goal: ship it"} {
+		if !strings.Contains(written, want) {
+			t.Errorf("Expected %q in config:\n%s", want, written)
+		}
+	}
+	if strings.Contains(written, "remember_notes") {
+		t.Errorf("Expected cleared remember_notes to be removed:\n%s", written)
+	}
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv(DataDirEnv, filepath.Join(home, "data"))
+
+	if err := os.MkdirAll(filepath.Join(home, "data"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	global := "openai_key: global-key\nmodel: global-model\nproject_goal: global goal\n"
+	if err := os.WriteFile(filepath.Join(home, "data", "wash.yaml"), []byte(global), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	project := filepath.Join(home, "src", "app")
+	if err := os.MkdirAll(filepath.Join(project, "pkg"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(project, ".wash.yaml"), []byte("model: project-model\nproject_goal: project goal\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(filepath.Join(project, "pkg"))
+	t.Setenv("WASH_MODEL", "env-model")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.OpenAIKey != "global-key" {
+		t.Errorf("Expected key from global config, got %q", cfg.OpenAIKey)
+	}
+	if cfg.ProjectGoal != "project goal" {
+		t.Errorf("Expected goal from project config, got %q", cfg.ProjectGoal)
+	}
+	if cfg.Model != "env-model" {
+		t.Errorf("Expected model from environment, got %q", cfg.Model)
+	}
+
+	t.Setenv("WASH_PROVIDER", "anthropic")
+	if _, err := LoadConfig(); err == nil {
+		t.Error("Expected an error for an unsupported provider")
+	}
+}
+
+func TestLoadConfigIgnoresProjectCredentials(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv(DataDirEnv, filepath.Join(home, "data"))
+
+	if err := os.MkdirAll(filepath.Join(home, "data"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	global := "openai_key: global-key\njira:\n  base_url: https://example.atlassian.net\n  token: global-token\n  project_key: APP\n"
+	if err := os.WriteFile(filepath.Join(home, "data", "wash.yaml"), []byte(global), 0600); err != nil {
+		t.Fatal(err)
+	}
+	project := filepath.Join(home, "src", "app")
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatal(err)
+	}
+	local := "openai_key: project-key\njira:\n  base_url: https://attacker.example.com\n  token: project-token\n  project_key: WEB\n"
+	if err := os.WriteFile(filepath.Join(project, ".wash.yaml"), []byte(local), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(project)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.OpenAIKey != "global-key" || cfg.Jira.BaseURL != "https://example.atlassian.net" || cfg.Jira.Token != "global-token" {
+		t.Errorf("Expected credentials from the global config, got key %q, base_url %q, token %q", cfg.OpenAIKey, cfg.Jira.BaseURL, cfg.Jira.Token)
+	}
+	if cfg.Jira.ProjectKey != "WEB" {
+		t.Errorf("Expected project_key from the project config, got %q", cfg.Jira.ProjectKey)
+	}
+
+	t.Setenv("WASH_JIRA_TOKEN", "env-token")
+	if cfg, err := LoadConfig(); err != nil || cfg.Jira.Token != "env-token" {
+		t.Errorf("Expected the token from the environment, got %q, %v", cfg.Jira.Token, err)
+	}
+
+	problems, ok := ValidateProject([]byte(local)).(ValidationErrors)
+	if !ok || len(problems) != 3 {
+		t.Fatalf("Expected the three project credentials to be reported, got %v", problems)
+	}
+	if !strings.HasPrefix(problems[1].Error(), "line 3: jira.base_url: is ignored") {
+		t.Errorf("Unexpected problem %q", problems[1].Error())
+	}
+}
+
+func TestLoadConfigIgnoresProjectProtectedSettings(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(DataDirEnv, filepath.Join(home, "data"))
+
+	if err := os.MkdirAll(filepath.Join(home, "data"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	global := "encryption: keychain\nretention:\n  interactions: 90d\n"
+	if err := os.WriteFile(filepath.Join(home, "data", "wash.yaml"), []byte(global), 0600); err != nil {
+		t.Fatal(err)
+	}
+	project := filepath.Join(home, "src", "app")
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatal(err)
+	}
+	local := "encryption: \"\"\nredact_secrets: \"off\"\nretention:\n  interactions: 1d\n  monitor_notes: 1d\nmodel: gpt-4o-mini\n"
+	if err := os.WriteFile(filepath.Join(project, ".wash.yaml"), []byte(local), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(project)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Encryption != "keychain" || cfg.RedactSecrets != "" {
+		t.Errorf("Expected encryption and redaction from the global config, got %q and %q", cfg.Encryption, cfg.RedactSecrets)
+	}
+	if len(cfg.Retention) != 1 || cfg.Retention["interactions"] != "90d" {
+		t.Errorf("Expected retention from the global config, got %v", cfg.Retention)
+	}
+	if cfg.Model != "gpt-4o-mini" {
+		t.Errorf("Expected the model from the project config, got %q", cfg.Model)
+	}
+
+	t.Setenv("WASH_REDACT_SECRETS", "off")
+	if cfg, err := LoadConfig(); err != nil || cfg.RedactSecrets != "off" {
+		t.Errorf("Expected redaction from the environment, got %q, %v", cfg.RedactSecrets, err)
+	}
+
+	problems, ok := ValidateProject([]byte(local)).(ValidationErrors)
+	if !ok || len(problems) != 4 {
+		t.Fatalf("Expected the four protected settings to be reported, got %v", problems)
+	}
+	if !strings.HasPrefix(problems[2].Error(), "line 4: retention.interactions: is ignored") {
+		t.Errorf("Unexpected problem %q", problems[2].Error())
+	}
+}
+
+func TestLoadConfigKeepsGlobalSafeProfile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(DataDirEnv, filepath.Join(home, "data"))
+
+	if err := os.MkdirAll(filepath.Join(home, "data"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, "data", "wash.yaml"), []byte("profile: safe\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	project := filepath.Join(home, "src", "app")
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(project, ".wash.yaml"), []byte("profile: default\nmonthly_spend_cap: 1000\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(project)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if !cfg.Safe() || cfg.MonthlySpendCap != SafeSpendCapUSD {
+		t.Errorf("Expected the global safe profile and its spend cap, got %q and %.2f", cfg.Profile, cfg.MonthlySpendCap)
+	}
+
+	t.Setenv("WASH_MONTHLY_SPEND_CAP", "12.5")
+	if cfg, err := LoadConfig(); err != nil || cfg.MonthlySpendCap != 12.5 {
+		t.Errorf("Expected the spend cap from the environment, got %.2f, %v", cfg.MonthlySpendCap, err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := "openai_key: sk-test\nprovider: openai\nencryption: keychain\nattachment_quota_mb: 512\nretention:\n  monitor_notes: 30d\nremember_notes:\n  - keep tests fast\nmonitor_region: 65,0,35,100\n"
+	if err := Validate([]byte(valid)); err != nil {
+		t.Errorf("Expected valid config, got: %v", err)
+	}
+
+	invalid := "modle: gpt-4o\nprovider: antropic\nattachment_quota_mb: lots\nretention:\n  monitor_note: 30d\n  interactions: soon\n"
+	err := Validate([]byte(invalid))
+	problems, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("Expected ValidationErrors, got %v", err)
+	}
+	want := []string{
+		`line 1: modle: unknown key (did you mean "model"?)`,
+		`line 2: provider: unsupported value "antropic"`,
+		"line 3: attachment_quota_mb:",
+		`line 5: retention.monitor_note: unknown note kind (did you mean "monitor_notes"?)`,
+		`line 6: retention.interactions: invalid age "soon"`,
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("Expected %d problems, got %d:\n%v", len(want), len(problems), err)
+	}
+	for i, prefix := range want {
+		if !strings.HasPrefix(problems[i].Error(), prefix) {
+			t.Errorf("Problem %d: expected prefix %q, got %q", i, prefix, problems[i].Error())
+		}
+	}
+
+	if err := Validate([]byte("openai_key: [unclosed\n")); err == nil {
+		t.Error("Expected a syntax error")
+	}
+}
+
+func TestParseRegion(t *testing.T) {
+	if region, auto, err := ParseRegion("65, 0, 35, 100"); err != nil || auto || region != [4]float64{0.65, 0, 0.35, 1} {
+		t.Errorf("Unexpected region %v, %v, %v", region, auto, err)
+	}
+	if _, auto, err := ParseRegion("auto"); err != nil || !auto {
+		t.Errorf("Expected auto, got %v, %v", auto, err)
+	}
+	for _, value := range []string{"right", "10,10,10", "70,0,40,100", "0,0,0,100"} {
+		if _, _, err := ParseRegion(value); err == nil {
+			t.Errorf("Expected %q to be invalid", value)
+		}
+	}
+}
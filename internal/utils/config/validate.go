@@ -0,0 +1,487 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/utils/crypt"
+	"github.com/bkidd1/wash-cli/internal/utils/fsutil"
+	"gopkg.in/yaml.v3"
+)
+
+// Providers are the supported values of the provider setting
+var Providers = []string{"openai"}
+
+// ScreenshotFormats are the supported values of the screenshot_format setting
+var ScreenshotFormats = []string{"jpeg", "png"}
+
+// RedactModes are the supported values of the redact_secrets setting
+var RedactModes = []string{"on", "off"}
+
+// EncryptionSources are the supported values of the encryption setting
+var EncryptionSources = []string{crypt.SourcePassphrase, crypt.SourceKeychain}
+
+// ValidationError describes one problem found in a config file
+type ValidationError struct {
+	Line    int
+	Key     string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	switch {
+	case e.Line > 0 && e.Key != "":
+		return fmt.Sprintf("line %d: %s: %s", e.Line, e.Key, e.Message)
+	case e.Line > 0:
+		return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+	case e.Key != "":
+		return fmt.Sprintf("%s: %s", e.Key, e.Message)
+	default:
+		return e.Message
+	}
+}
+
+// ValidationErrors lists every problem found in a config file
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	lines := make([]string, len(e))
+	for i, err := range e {
+		lines[i] = err.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Validate checks config file contents against the config schema, returning ValidationErrors for
+// syntax errors, unknown keys, values of the wrong type and unsupported enum values
+func Validate(data []byte) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return ValidationErrors{{Message: strings.TrimPrefix(err.Error(), "yaml: ")}}
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+
+	mapping := doc.Content[0]
+	if mapping.Kind != yaml.MappingNode {
+		return ValidationErrors{{Line: mapping.Line, Message: "config must be a mapping of key: value settings"}}
+	}
+
+	var errs ValidationErrors
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i], mapping.Content[i+1]
+		errs = append(errs, validateKey(key, value)...)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateProject checks a project .wash.yaml as Validate does, and also reports the global
+// settings, which are ignored there
+func ValidateProject(data []byte) error {
+	err := Validate(data)
+	var errs ValidationErrors
+	if problems, ok := err.(ValidationErrors); ok {
+		errs = problems
+	} else if err != nil {
+		return err
+	}
+
+	var doc yaml.Node
+	if yaml.Unmarshal(data, &doc) == nil && len(doc.Content) > 0 && doc.Content[0].Kind == yaml.MappingNode {
+		mapping := doc.Content[0]
+		for i := 0; i+1 < len(mapping.Content); i += 2 {
+			key, value := mapping.Content[i], mapping.Content[i+1]
+			settings := []*yaml.Node{key}
+			if value.Kind == yaml.MappingNode {
+				settings = nil
+				for j := 0; j+1 < len(value.Content); j += 2 {
+					settings = append(settings, value.Content[j])
+				}
+			}
+			for _, setting := range settings {
+				name := setting.Value
+				if setting != key {
+					name = key.Value + "." + name
+				}
+				if contains(GlobalKeys, name) {
+					errs = append(errs, ValidationError{Line: setting.Line, Key: name,
+						Message: "is ignored in a project config, which a cloned repository controls; set it in the global wash.yaml or the environment"})
+				}
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateKey checks a single top-level setting
+func validateKey(key, value *yaml.Node) ValidationErrors {
+	at := func(node *yaml.Node, name, format string, args ...interface{}) ValidationErrors {
+		return ValidationErrors{{Line: node.Line, Key: name, Message: fmt.Sprintf(format, args...)}}
+	}
+
+	switch key.Value {
+	case "openai_key", "project_goal", "model":
+		if !isString(value) {
+			return at(value, key.Value, "must be a string")
+		}
+	case "provider":
+		return validateEnum(value, key.Value, Providers)
+	case "encryption":
+		return validateEnum(value, key.Value, EncryptionSources)
+	case "profile":
+		return validateEnum(value, key.Value, Profiles)
+	case "screenshot_format":
+		return validateEnum(value, key.Value, ScreenshotFormats)
+	case "redact_secrets":
+		return validateEnum(value, key.Value, RedactModes)
+	case "screenshot_quality":
+		if n, err := strconv.Atoi(value.Value); !isCount(value) || err != nil || n > 100 {
+			return at(value, key.Value, "must be a JPEG quality from 1 to 100, such as 80 (0 uses the default)")
+		}
+	case "screenshot_max_size":
+		if !isCount(value) {
+			return at(value, key.Value, "must be a size in pixels, such as 1600 (0 uses the default)")
+		}
+	case "monthly_spend_cap":
+		if !isAmount(value) {
+			return at(value, key.Value, "must be an amount in USD, such as 5 or 20.50 (0 means no cap)")
+		}
+	case "compact_monitor_notes_after":
+		return validateAge(value, key.Value)
+	case "monitor_interval":
+		if isNull(value) {
+			return nil
+		}
+		if _, err := ParseInterval(value.Value); err != nil || value.Kind != yaml.ScalarNode {
+			return at(value, key.Value, "invalid interval %q (use e.g. 30s or 2m, at least %s)", value.Value, MinInterval)
+		}
+	case "monitor_region":
+		if isNull(value) {
+			return nil
+		}
+		if _, _, err := ParseRegion(value.Value); err != nil || value.Kind != yaml.ScalarNode {
+			return at(value, key.Value, "must be auto, or x,y,width,height in percent of the window, such as 65,0,35,100")
+		}
+	case "attachment_quota_mb":
+		if !isCount(value) {
+			return at(value, key.Value, "must be a whole number of megabytes, such as 1024")
+		}
+	case "max_file_size_kb":
+		if !isCount(value) {
+			return at(value, key.Value, "must be a whole number of kilobytes, such as 1024")
+		}
+	case "workers":
+		if !isCount(value) {
+			return at(value, key.Value, "must be a whole number of parallel analyses, such as 4 (1 analyzes one file at a time)")
+		}
+	case "requests_per_minute":
+		if !isCount(value) {
+			return at(value, key.Value, "must be a whole number of API calls per minute, such as 60")
+		}
+	case "monitor_batch_size":
+		if !isCount(value) {
+			return at(value, key.Value, "must be a whole number of notes, such as 10 (1 writes every note immediately)")
+		}
+	case "remember_notes":
+		if isNull(value) {
+			return nil
+		}
+		if value.Kind != yaml.SequenceNode {
+			return at(value, key.Value, "must be a list of notes, one per line starting with \"- \"")
+		}
+		for _, note := range value.Content {
+			if !isString(note) {
+				return at(note, key.Value, "each note must be a string")
+			}
+		}
+	case "fallback_models":
+		if isNull(value) {
+			return nil
+		}
+		if value.Kind != yaml.SequenceNode {
+			return at(value, key.Value, "must be a list of models, such as [gpt-4o-mini]")
+		}
+		for _, model := range value.Content {
+			if !isString(model) || model.Value == "" {
+				return at(model, key.Value, "each model must be a model name")
+			}
+		}
+	case "monitor_windows":
+		if isNull(value) {
+			return nil
+		}
+		if value.Kind != yaml.SequenceNode {
+			return at(value, key.Value, "must be a list of applications, such as [Cursor, Code, Windsurf]")
+		}
+		for _, window := range value.Content {
+			if !isString(window) || window.Value == "" {
+				return at(window, key.Value, "each entry must be an application name or window title")
+			}
+		}
+	case "jira":
+		if isNull(value) {
+			return nil
+		}
+		if value.Kind != yaml.MappingNode {
+			return at(value, key.Value, "must be a mapping with base_url, project_key and token")
+		}
+		var errs ValidationErrors
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			setting, setValue := value.Content[i], value.Content[i+1]
+			name := key.Value + "." + setting.Value
+			switch {
+			case !contains(JiraKeys, setting.Value):
+				errs = append(errs, at(setting, name, "unknown setting%s (expected %s)", suggest(setting.Value, JiraKeys), strings.Join(JiraKeys, ", "))...)
+			case !isString(setValue):
+				errs = append(errs, at(setValue, name, "must be a string")...)
+			case setting.Value == "base_url" && setValue.Value != "" && !strings.HasPrefix(setValue.Value, "https://"):
+				errs = append(errs, at(setValue, name, "must be an https URL such as https://example.atlassian.net")...)
+			}
+		}
+		return errs
+	case "hooks":
+		if isNull(value) {
+			return nil
+		}
+		if value.Kind != yaml.MappingNode {
+			return at(value, key.Value, "must be a mapping of events to commands, such as on-bug-created: ./notify.sh")
+		}
+		var errs ValidationErrors
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			event, command := value.Content[i], value.Content[i+1]
+			name := key.Value + "." + event.Value
+			switch {
+			case !contains(HookEvents, event.Value):
+				errs = append(errs, at(event, name, "unknown event%s (expected %s)", suggest(event.Value, HookEvents), strings.Join(HookEvents, ", "))...)
+			case !isString(command):
+				errs = append(errs, at(command, name, "must be a shell command")...)
+			}
+		}
+		return errs
+	case "retention":
+		if isNull(value) {
+			return nil
+		}
+		if value.Kind != yaml.MappingNode {
+			return at(value, key.Value, "must be a mapping of note kinds to ages, such as monitor_notes: 30d")
+		}
+		var errs ValidationErrors
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			kind := value.Content[i]
+			name := key.Value + "." + kind.Value
+			if !contains(RetentionKinds, kind.Value) {
+				errs = append(errs, at(kind, name, "unknown note kind%s (expected %s)", suggest(kind.Value, RetentionKinds), strings.Join(RetentionKinds, ", "))...)
+				continue
+			}
+			errs = append(errs, validateAge(value.Content[i+1], name)...)
+		}
+		return errs
+	default:
+		return at(key, key.Value, "unknown key%s", suggest(key.Value, knownKeys()))
+	}
+	return nil
+}
+
+// validateEnum checks that a setting is empty or one of the allowed values
+func validateEnum(value *yaml.Node, name string, allowed []string) ValidationErrors {
+	if isNull(value) || (isString(value) && (value.Value == "" || contains(allowed, value.Value))) {
+		return nil
+	}
+	return ValidationErrors{{
+		Line:    value.Line,
+		Key:     name,
+		Message: fmt.Sprintf("unsupported value %q%s (expected %s)", value.Value, suggest(value.Value, allowed), strings.Join(allowed, " or ")),
+	}}
+}
+
+// validateAge checks that a setting parses as an age
+func validateAge(value *yaml.Node, name string) ValidationErrors {
+	if isNull(value) {
+		return nil
+	}
+	if _, err := ParseAge(value.Value); err != nil || value.Kind != yaml.ScalarNode {
+		return ValidationErrors{{Line: value.Line, Key: name, Message: fmt.Sprintf("invalid age %q (use e.g. 30d, 2w, 12h or forever)", value.Value)}}
+	}
+	return nil
+}
+
+// ParseAge parses an age such as 30d, 2w or 12h; empty, 0 and "forever" return zero
+func ParseAge(value string) (time.Duration, error) {
+	value = strings.TrimSpace(strings.ToLower(value))
+	if value == "" || value == "0" || value == "forever" {
+		return 0, nil
+	}
+
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+	if unit, ok := units[value[len(value)-1:]]; ok {
+		n, err := strconv.Atoi(value[:len(value)-1])
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q", value)
+		}
+		return time.Duration(n) * unit, nil
+	}
+
+	age, err := time.ParseDuration(value)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("invalid age %q (use e.g. 30d, 2w or 12h)", value)
+	}
+	return age, nil
+}
+
+// MinInterval is the shortest monitor interval allowed
+const MinInterval = 10 * time.Second
+
+// ParseInterval parses an interval such as 30s or 2m of at least MinInterval
+func ParseInterval(value string) (time.Duration, error) {
+	interval, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("invalid interval %q (use e.g. 30s or 2m)", value)
+	}
+	if interval < MinInterval {
+		return 0, fmt.Errorf("interval %s is shorter than the minimum of %s", interval, MinInterval)
+	}
+	return interval, nil
+}
+
+// RegionAuto is the monitor_region that has the chat panel found in each screenshot
+const RegionAuto = "auto"
+
+// ParseRegion parses a monitor_region: auto, or x,y,width,height in percent of the window, into
+// fractions of the window
+func ParseRegion(value string) (region [4]float64, auto bool, err error) {
+	value = strings.TrimSpace(value)
+	if value == RegionAuto {
+		return region, true, nil
+	}
+	fields := strings.Split(value, ",")
+	if len(fields) != 4 {
+		return region, false, fmt.Errorf("invalid region %q (use auto or x,y,width,height in percent)", value)
+	}
+	for i, field := range fields {
+		percent, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil || percent < 0 || percent > 100 {
+			return region, false, fmt.Errorf("invalid region %q: %q is not a percentage", value, field)
+		}
+		region[i] = percent / 100
+	}
+	if region[2] == 0 || region[3] == 0 || region[0]+region[2] > 1 || region[1]+region[3] > 1 {
+		return region, false, fmt.Errorf("invalid region %q: it must be inside the window and not empty", value)
+	}
+	return region, false, nil
+}
+
+// ParseTime parses a --since or --until value: a date, an RFC 3339 time or an age such as 7d
+func ParseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range []string{"2006-01-02", time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	age, err := ParseAge(value)
+	if err != nil || age == 0 {
+		return time.Time{}, fmt.Errorf("invalid time %q (use a date such as 2025-01-31 or an age such as 7d)", value)
+	}
+	return time.Now().Add(-age), nil
+}
+
+// WriteConfigFile validates data and atomically replaces the config file at path with it
+func WriteConfigFile(path string, data []byte) error {
+	if err := Validate(data); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error creating config directory: %w", err)
+	}
+
+	unlock, err := fsutil.LockDir(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := fsutil.WriteFileAtomic(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// isNull reports whether a node is an empty value
+func isNull(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag == "!!null"
+}
+
+// isString reports whether a node is a scalar that decodes into a string
+func isString(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag != "!!null"
+}
+
+// isCount reports whether a node is a non-negative integer
+func isCount(node *yaml.Node) bool {
+	n, err := strconv.Atoi(node.Value)
+	return node.Kind == yaml.ScalarNode && node.Tag == "!!int" && err == nil && n >= 0
+}
+
+// isAmount reports whether a node is a non-negative number
+func isAmount(node *yaml.Node) bool {
+	n, err := strconv.ParseFloat(node.Value, 64)
+	return node.Kind == yaml.ScalarNode && (node.Tag == "!!int" || node.Tag == "!!float") && err == nil && n >= 0
+}
+
+// contains reports whether values includes value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// suggest returns a "did you mean" hint for the closest candidate to value, or an empty string
+func suggest(value string, candidates []string) string {
+	best, bestDistance := "", 3
+	for _, candidate := range candidates {
+		if d := editDistance(strings.ToLower(value), candidate); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean %q?)", best)
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
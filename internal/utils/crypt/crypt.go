@@ -0,0 +1,269 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/bkidd1/wash-cli/internal/utils/fsutil"
+)
+
+const (
+	// SourcePassphrase derives the key from the WASH_PASSPHRASE environment variable
+	SourcePassphrase = "passphrase"
+	// SourceKeychain keeps a random key in the OS keychain
+	SourceKeychain = "keychain"
+	// PassphraseEnv holds the passphrase for SourcePassphrase
+	PassphraseEnv = "WASH_PASSPHRASE"
+
+	// keyFile records the salt and a check value so a wrong key is caught before any data is read
+	keyFile = "encryption.json"
+	// kdfIterations is the PBKDF2-SHA256 work factor for passphrases
+	kdfIterations = 600000
+	// checkPlaintext is encrypted into the key file to verify the key
+	checkPlaintext = "wash"
+)
+
+// magic prefixes every encrypted file, so plaintext files written before encryption was enabled still read
+var magic = []byte("WASHENC1")
+
+// ErrLocked is returned when reading encrypted data without a key
+var ErrLocked = errors.New("data is encrypted; set 'encryption' in ~/.wash/wash.yaml and provide the key")
+
+// Cipher encrypts and decrypts stored data with AES-256-GCM. A nil Cipher passes plaintext through.
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher creates a cipher from a 32-byte key
+func NewCipher(key []byte) (*Cipher, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("error creating cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("error creating cipher: %w", err)
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// Encrypt seals data behind the magic prefix and a random nonce
+func (c *Cipher) Encrypt(data []byte) ([]byte, error) {
+	if c == nil {
+		return data, nil
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("error generating nonce: %w", err)
+	}
+
+	out := make([]byte, 0, len(magic)+len(nonce)+len(data)+c.aead.Overhead())
+	out = append(out, magic...)
+	out = append(out, nonce...)
+	return c.aead.Seal(out, nonce, data, magic), nil
+}
+
+// Decrypt opens data written by Encrypt; data without the magic prefix is returned unchanged
+func (c *Cipher) Decrypt(data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+	if c == nil {
+		return nil, ErrLocked
+	}
+
+	rest := data[len(magic):]
+	if len(rest) < c.aead.NonceSize() {
+		return nil, fmt.Errorf("encrypted data is truncated")
+	}
+	nonce, sealed := rest[:c.aead.NonceSize()], rest[c.aead.NonceSize():]
+
+	plain, err := c.aead.Open(nil, nonce, sealed, magic)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting data (wrong key or corrupt file): %w", err)
+	}
+	return plain, nil
+}
+
+// IsEncrypted reports whether data was written by Encrypt
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, magic)
+}
+
+// keyInfo is stored in ~/.wash/encryption.json
+type keyInfo struct {
+	Source string `json:"source"`
+	Salt   string `json:"salt,omitempty"`
+	Check  string `json:"check"`
+}
+
+// loaded caches ciphers by source and directory, since deriving a passphrase key is deliberately slow
+var (
+	loadedMu sync.Mutex
+	loaded   = make(map[string]*Cipher)
+)
+
+// Load returns the cipher for a key source, creating the key on first use.
+// An empty source disables encryption and returns a nil cipher.
+func Load(source string, dir string) (*Cipher, error) {
+	if source == "" {
+		return nil, nil
+	}
+
+	loadedMu.Lock()
+	defer loadedMu.Unlock()
+
+	cacheKey := source + "\x00" + dir
+	if c, ok := loaded[cacheKey]; ok {
+		return c, nil
+	}
+
+	c, err := load(source, dir)
+	if err != nil {
+		return nil, err
+	}
+	loaded[cacheKey] = c
+	return c, nil
+}
+
+// load unlocks the key for a source without caching
+func load(source string, dir string) (*Cipher, error) {
+	info, err := readKeyInfo(dir)
+	if err != nil {
+		return nil, err
+	}
+	firstUse := info == nil
+	if firstUse {
+		info = &keyInfo{Source: source}
+	} else if info.Source != source {
+		return nil, fmt.Errorf("notes are encrypted with a %s key, but the config asks for %s", info.Source, source)
+	}
+
+	var key []byte
+	switch source {
+	case SourcePassphrase:
+		key, err = passphraseKey(info)
+	case SourceKeychain:
+		key, err = keychainKey(firstUse)
+	default:
+		return nil, fmt.Errorf("unknown encryption key source %q (expected %s or %s)", source, SourcePassphrase, SourceKeychain)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if firstUse {
+		check, err := c.Encrypt([]byte(checkPlaintext))
+		if err != nil {
+			return nil, err
+		}
+		info.Check = base64.StdEncoding.EncodeToString(check)
+		if err := writeKeyInfo(dir, info); err != nil {
+			return nil, err
+		}
+		return c, nil
+	}
+
+	check, err := base64.StdEncoding.DecodeString(info.Check)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", keyFile, err)
+	}
+	if plain, err := c.Decrypt(check); err != nil || string(plain) != checkPlaintext {
+		return nil, fmt.Errorf("wrong encryption key for the notes in %s", dir)
+	}
+	return c, nil
+}
+
+// passphraseKey derives a key from the passphrase, generating a salt on first use
+func passphraseKey(info *keyInfo) ([]byte, error) {
+	passphrase := os.Getenv(PassphraseEnv)
+	if passphrase == "" {
+		return nil, fmt.Errorf("encryption uses a passphrase; set %s", PassphraseEnv)
+	}
+
+	if info.Salt == "" {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, fmt.Errorf("error generating salt: %w", err)
+		}
+		info.Salt = base64.StdEncoding.EncodeToString(salt)
+	}
+	salt, err := base64.StdEncoding.DecodeString(info.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("error reading salt: %w", err)
+	}
+
+	return pbkdf2.Key(sha256.New, passphrase, salt, kdfIterations, 32)
+}
+
+// keychainKey reads the key from the OS keychain, storing a new random key on first use
+func keychainKey(firstUse bool) ([]byte, error) {
+	if firstUse {
+		// Reuse a key left in the keychain by an earlier setup, so data it encrypted stays readable
+		if encoded, err := keychainGet(); err == nil && encoded != "" {
+			return base64.StdEncoding.DecodeString(encoded)
+		}
+
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("error generating key: %w", err)
+		}
+		if err := keychainSet(base64.StdEncoding.EncodeToString(key)); err != nil {
+			return nil, fmt.Errorf("error storing key in keychain: %w", err)
+		}
+		return key, nil
+	}
+
+	encoded, err := keychainGet()
+	if err != nil {
+		return nil, fmt.Errorf("error reading key from keychain: %w", err)
+	}
+	return base64.StdEncoding.DecodeString(encoded)
+}
+
+func readKeyInfo(dir string) (*keyInfo, error) {
+	data, err := os.ReadFile(filepath.Join(dir, keyFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading %s: %w", keyFile, err)
+	}
+
+	var info keyInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", keyFile, err)
+	}
+	return &info, nil
+}
+
+func writeKeyInfo(dir string, info *keyInfo) error {
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling %s: %w", keyFile, err)
+	}
+	if err := fsutil.WriteFileAtomic(filepath.Join(dir, keyFile), data, 0600); err != nil {
+		return fmt.Errorf("error writing %s: %w", keyFile, err)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package crypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	c, err := NewCipher(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sealed, err := c.Encrypt([]byte(`{"note":"secret"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncrypted(sealed) || bytes.Contains(sealed, []byte("secret")) {
+		t.Fatalf("Data not encrypted: %q", sealed)
+	}
+
+	plain, err := c.Decrypt(sealed)
+	if err != nil || string(plain) != `{"note":"secret"}` {
+		t.Fatalf("Decrypt = %q, %v", plain, err)
+	}
+
+	// Plaintext written before encryption was enabled still reads
+	if plain, err := c.Decrypt([]byte("{}")); err != nil || string(plain) != "{}" {
+		t.Errorf("Plaintext passthrough = %q, %v", plain, err)
+	}
+
+	var locked *Cipher
+	if _, err := locked.Decrypt(sealed); err != ErrLocked {
+		t.Errorf("Expected ErrLocked without a key, got %v", err)
+	}
+}
+
+func TestLoadRejectsWrongPassphrase(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Setenv(PassphraseEnv, "correct horse")
+	if _, err := load(SourcePassphrase, dir); err != nil {
+		t.Fatalf("First load failed: %v", err)
+	}
+	if _, err := load(SourcePassphrase, dir); err != nil {
+		t.Fatalf("Reload with the same passphrase failed: %v", err)
+	}
+
+	t.Setenv(PassphraseEnv, "battery staple")
+	if _, err := load(SourcePassphrase, dir); err == nil {
+		t.Error("Expected a wrong passphrase to be rejected")
+	}
+}
@@ -0,0 +1,54 @@
+package crypt
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+const (
+	// keychainService and keychainAccount name the keychain entry holding the key
+	keychainService = "wash-cli"
+	keychainAccount = "notes-encryption-key"
+)
+
+// keychainGet reads the key from the macOS keychain or the Secret Service on Linux
+func keychainGet() (string, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", keychainService, "-a", keychainAccount, "-w")
+	case "linux":
+		cmd = exec.Command("secret-tool", "lookup", "service", keychainService, "account", keychainAccount)
+	default:
+		return "", fmt.Errorf("keychain is not supported on %s; use the passphrase key source", runtime.GOOS)
+	}
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s failed: %w", cmd.Path, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// keychainSet stores the key in the macOS keychain or the Secret Service on Linux
+func keychainSet(secret string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		// security reads the command from stdin in interactive mode, so the key never shows up in ps
+		cmd = exec.Command("security", "-i")
+		cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -w %q\n", keychainService, keychainAccount, secret))
+	case "linux":
+		cmd = exec.Command("secret-tool", "store", "--label", "wash notes encryption key", "service", keychainService, "account", keychainAccount)
+		cmd.Stdin = strings.NewReader(secret)
+	default:
+		return fmt.Errorf("keychain is not supported on %s; use the passphrase key source", runtime.GOOS)
+	}
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %v: %s", cmd.Path, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package editor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// defaultEditor is used when neither $VISUAL nor $EDITOR is set
+const defaultEditor = "vi"
+
+// Command returns the user's editor command line, split into arguments
+func Command() []string {
+	for _, envVar := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(envVar)); len(fields) > 0 {
+			return fields
+		}
+	}
+	return []string{defaultEditor}
+}
+
+// Edit opens path in the user's editor and waits for it to exit
+func Edit(path string) error {
+	args := Command()
+	cmd := exec.Command(args[0], append(args[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %s failed: %w", args[0], err)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package fsutil
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "note.json")
+
+	if err := WriteFileAtomic(path, []byte("first"), 0644); err != nil {
+		t.Fatalf("First write failed: %v", err)
+	}
+	if err := WriteFileAtomic(path, []byte("second"), 0644); err != nil {
+		t.Fatalf("Second write failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if string(data) != "second" {
+		t.Errorf("Expected %q, got %q", "second", string(data))
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read directory: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected only the written file, found %d entries", len(entries))
+	}
+}
+
+func TestAppendJSONLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.jsonl")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := AppendJSONLine(path, map[string]int{"n": i}); err != nil {
+				t.Errorf("Append failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 20 {
+		t.Fatalf("Expected 20 lines, got %d", len(lines))
+	}
+	for _, line := range lines {
+		var v map[string]int
+		if err := json.Unmarshal([]byte(line), &v); err != nil {
+			t.Errorf("Expected whole JSON lines, got %q", line)
+		}
+	}
+}
+
+func TestGlob(t *testing.T) {
+	dir := t.TempDir()
+	for _, file := range []string{"main.go", "internal/a/a.go", "internal/a/b/b.go", "internal/a/a.txt", "internal/skip/c.go"} {
+		path := filepath.Join(dir, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	skip := func(path string) bool { return filepath.Base(path) == "skip" }
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"internal/**/*.go", []string{"internal/a/a.go", "internal/a/b/b.go"}},
+		{"internal/*/*.go", []string{"internal/a/a.go"}},
+		{"*.go", []string{"main.go"}},
+		{"**/b.go", []string{"internal/a/b/b.go"}},
+	}
+	for _, tt := range tests {
+		matches, err := Glob(filepath.Join(dir, tt.pattern), skip)
+		if err != nil {
+			t.Fatalf("Glob(%q) failed: %v", tt.pattern, err)
+		}
+		var got []string
+		for _, match := range matches {
+			rel, _ := filepath.Rel(dir, match)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("Glob(%q) = %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package fsutil
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// HasGlob reports whether a path contains glob metacharacters
+func HasGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// Glob returns the files matching pattern, sorted. Besides the filepath.Match syntax, a ** segment
+// matches any number of directories. Directories for which skip returns true are not searched.
+func Glob(pattern string, skip func(dir string) bool) ([]string, error) {
+	pattern = filepath.ToSlash(filepath.Clean(pattern))
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	segments := strings.Split(pattern, "/")
+
+	// Walk from the longest directory prefix without metacharacters
+	static := 0
+	for static < len(segments)-1 && !HasGlob(segments[static]) {
+		static++
+	}
+	root := strings.Join(segments[:static], "/")
+	switch {
+	case root == "" && static > 0:
+		root = "/"
+	case root == "":
+		root = "."
+	}
+	rest := segments[static:]
+	recursive := slices.Contains(rest, "**")
+
+	var matches []string
+	err := filepath.WalkDir(filepath.FromSlash(root), func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(filepath.FromSlash(root), file)
+		if err != nil || rel == "." {
+			return nil
+		}
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		if d.IsDir() {
+			// Without ** nothing deeper than the pattern can match
+			if (!recursive && len(parts) >= len(rest)) || (skip != nil && skip(file)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if matchSegments(rest, parts) {
+			matches = append(matches, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// matchSegments matches path segments against pattern segments, where ** matches any number of them
+func matchSegments(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchSegments(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], parts[1:])
+}
@@ -0,0 +1,88 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxContextDiffBytes caps the diff kept in a Context
+const MaxContextDiffBytes = 4000
+
+// Context describes the state of a repository when a bug was reported
+type Context struct {
+	Branch string
+	// Head is the short hash and subject of the HEAD commit
+	Head   string
+	Status string
+	// DiffSource says what Diff shows: the uncommitted changes or, in a clean tree, the last commit
+	DiffSource string
+	DiffStat   string
+	Diff       string
+	Truncated  bool
+}
+
+// CaptureContext records the branch, HEAD commit, status and most recent changes of the repository
+// containing dir; it returns nil when dir is not in a git work tree
+func CaptureContext(dir string) (*Context, error) {
+	if !IsRepo(dir) {
+		return nil, nil
+	}
+
+	c := &Context{}
+	c.Branch, _ = run(dir, "branch", "--show-current")
+	if c.Branch == "" {
+		c.Branch = "(detached HEAD)"
+	}
+	if head, err := run(dir, "log", "-1", "--format=%h %s"); err == nil {
+		c.Head = head
+	} else {
+		c.Head = "(no commits)"
+	}
+
+	// The leading column of the first line is significant, so only the end is trimmed
+	status, err := output(dir, "status", "--short")
+	if err != nil {
+		return nil, err
+	}
+	c.Status = strings.TrimRight(status, "\n")
+
+	// Uncommitted changes are the likeliest cause; in a clean tree, show what the last commit changed
+	c.DiffSource = "uncommitted changes"
+	c.DiffStat, _ = run(dir, "diff", "--stat", "HEAD")
+	c.Diff, _ = run(dir, "diff", "HEAD")
+	if c.Diff == "" && c.Head != "(no commits)" {
+		c.DiffSource = "last commit"
+		c.DiffStat, _ = run(dir, "show", "--stat", "--format=", "HEAD")
+		c.Diff, _ = run(dir, "show", "--format=", "HEAD")
+	}
+	if len(c.Diff) > MaxContextDiffBytes {
+		// Cut at the start of a character, not inside one
+		cut := MaxContextDiffBytes
+		for cut > 0 && !utf8.RuneStart(c.Diff[cut]) {
+			cut--
+		}
+		c.Diff = c.Diff[:cut]
+		c.Truncated = true
+	}
+	return c, nil
+}
+
+// String renders the context as markdown for bug reports and prompts
+func (c *Context) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Branch: %s\nHEAD: %s\n", c.Branch, c.Head)
+	if c.Status == "" {
+		b.WriteString("Status: clean\n")
+	} else {
+		fmt.Fprintf(&b, "Status:\n```\n%s\n```\n", c.Status)
+	}
+	if c.Diff != "" {
+		fmt.Fprintf(&b, "Diff of the %s:\n```\n%s\n```\n", c.DiffSource, c.DiffStat)
+		fmt.Fprintf(&b, "```diff\n%s\n```\n", c.Diff)
+		if c.Truncated {
+			fmt.Fprintf(&b, "(diff cut at %d bytes)\n", MaxContextDiffBytes)
+		}
+	}
+	return b.String()
+}
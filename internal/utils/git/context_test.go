@@ -0,0 +1,62 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestCaptureContext(t *testing.T) {
+	dir := t.TempDir()
+	if c, err := CaptureContext(dir); c != nil || err != nil {
+		t.Fatalf("Expected no context outside a repository, got %+v, %v", c, err)
+	}
+
+	gitCmd := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(content string) {
+		if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gitCmd("init", "-q", "-b", "main")
+	write("package main\n")
+	gitCmd("add", ".")
+	gitCmd("commit", "-q", "-m", "Initial commit")
+
+	c, err := CaptureContext(dir)
+	if err != nil {
+		t.Fatalf("CaptureContext failed: %v", err)
+	}
+	if c.Branch != "main" || !strings.HasSuffix(c.Head, " Initial commit") || c.Status != "" || c.DiffSource != "last commit" {
+		t.Errorf("Unexpected context of a clean tree: %+v", c)
+	}
+
+	write("package main\n\nfunc main() {}\n")
+	if c, err = CaptureContext(dir); err != nil {
+		t.Fatalf("CaptureContext failed: %v", err)
+	}
+	if c.Status != " M main.go" || c.DiffSource != "uncommitted changes" || !strings.Contains(c.Diff, "+func main() {}") {
+		t.Errorf("Unexpected context of a modified tree: %+v", c)
+	}
+	if text := c.String(); !strings.Contains(text, "Branch: main\n") || !strings.Contains(text, "```diff\n") {
+		t.Errorf("Unexpected rendering:\n%s", text)
+	}
+
+	// A long diff is cut between characters
+	write("package main\n\n// " + strings.Repeat("é", MaxContextDiffBytes) + "\n")
+	if c, err = CaptureContext(dir); err != nil {
+		t.Fatalf("CaptureContext failed: %v", err)
+	}
+	if !c.Truncated || len(c.Diff) > MaxContextDiffBytes || !utf8.ValidString(c.Diff) {
+		t.Errorf("Expected the diff cut to valid UTF-8 within %d bytes, got %d bytes, truncated %v", MaxContextDiffBytes, len(c.Diff), c.Truncated)
+	}
+}
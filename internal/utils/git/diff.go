@@ -0,0 +1,170 @@
+package git
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// hunkHeaderPattern reads the new-file start line of a hunk header such as @@ -10,4 +12,6 @@
+var hunkHeaderPattern = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// DiffOptions selects the changes Diff returns
+type DiffOptions struct {
+	// Staged limits the diff to the changes in the index
+	Staged bool
+	// Ref compares the working tree with the point where it branched off this ref, such as main
+	Ref string
+	// Context is how many unchanged lines surround each hunk
+	Context int
+}
+
+// DiffLine is one line of a hunk
+type DiffLine struct {
+	// Kind is '+' for an added line, '-' for a removed line and ' ' for context
+	Kind byte
+	// Line is the line number in the new file, or zero for removed lines
+	Line int
+	Text string
+}
+
+// FileDiff is the changes to one file
+type FileDiff struct {
+	// Path is the file's path in the new tree, relative to the repository root
+	Path    string
+	OldPath string
+	Deleted bool
+	Binary  bool
+	Hunks   [][]DiffLine
+}
+
+// Added reports whether a line of the new file was added or changed
+func (f FileDiff) Added(line int) bool {
+	for _, hunk := range f.Hunks {
+		for _, l := range hunk {
+			if l.Kind == '+' && l.Line == line {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Text returns the new-file lines of the hunks, one per line
+func (f FileDiff) Text() string {
+	var b strings.Builder
+	for _, hunk := range f.Hunks {
+		for _, l := range hunk {
+			if l.Kind != '-' {
+				b.WriteString(l.Text)
+				b.WriteByte('\n')
+			}
+		}
+	}
+	return b.String()
+}
+
+// Counts returns how many lines the diff adds and removes
+func (f FileDiff) Counts() (added, removed int) {
+	for _, hunk := range f.Hunks {
+		for _, l := range hunk {
+			switch l.Kind {
+			case '+':
+				added++
+			case '-':
+				removed++
+			}
+		}
+	}
+	return added, removed
+}
+
+// Diff returns the uncommitted changes of the repository containing dir, or the staged ones, or
+// the changes since the working tree branched off a ref. It returns nil outside a git work tree.
+func Diff(dir string, opts DiffOptions) ([]FileDiff, error) {
+	if !IsRepo(dir) {
+		return nil, nil
+	}
+
+	args := []string{"diff", "--no-color", "--no-ext-diff", "-M", fmt.Sprintf("-U%d", max(opts.Context, 0))}
+	switch {
+	case opts.Staged:
+		args = append(args, "--cached")
+	case opts.Ref != "":
+		base, err := run(dir, "merge-base", opts.Ref, "HEAD")
+		if err != nil {
+			return nil, fmt.Errorf("cannot compare with %s: %w", opts.Ref, err)
+		}
+		args = append(args, base)
+	default:
+		// Staged and unstaged changes together; a repository without commits has nothing to compare with
+		if _, err := run(dir, "rev-parse", "--verify", "HEAD"); err == nil {
+			args = append(args, "HEAD")
+		}
+	}
+
+	out, err := output(dir, args...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDiff(out), nil
+}
+
+// ParseDiff parses unified diff output of git diff into per-file changes
+func ParseDiff(out string) []FileDiff {
+	var files []FileDiff
+	var file *FileDiff
+	var hunk *[]DiffLine
+	next := 0
+
+	for _, line := range strings.Split(out, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			files = append(files, FileDiff{})
+			file = &files[len(files)-1]
+			hunk = nil
+			// Paths are read from the ---/+++ lines; this one only covers binary files and renames
+			if _, b, ok := strings.Cut(line, " b/"); ok {
+				file.Path = b
+			}
+		case file == nil:
+			continue
+		case hunk == nil && strings.HasPrefix(line, "--- "):
+			if path := strings.TrimPrefix(line, "--- "); path != "/dev/null" {
+				file.OldPath = strings.TrimPrefix(path, "a/")
+			}
+		case hunk == nil && strings.HasPrefix(line, "+++ "):
+			if path := strings.TrimPrefix(line, "+++ "); path == "/dev/null" {
+				file.Deleted = true
+				file.Path = file.OldPath
+			} else {
+				file.Path = strings.TrimPrefix(path, "b/")
+			}
+		case hunk == nil && strings.HasPrefix(line, "rename from "):
+			file.OldPath = strings.TrimPrefix(line, "rename from ")
+		case hunk == nil && strings.HasPrefix(line, "Binary files "):
+			file.Binary = true
+		case strings.HasPrefix(line, "@@"):
+			match := hunkHeaderPattern.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			next, _ = strconv.Atoi(match[1])
+			file.Hunks = append(file.Hunks, nil)
+			hunk = &file.Hunks[len(file.Hunks)-1]
+		case hunk == nil || line == "" || strings.HasPrefix(line, `\`):
+			// Headers, the trailing newline and "\ No newline at end of file"
+			continue
+		case line[0] == '+':
+			*hunk = append(*hunk, DiffLine{Kind: '+', Line: next, Text: line[1:]})
+			next++
+		case line[0] == '-':
+			*hunk = append(*hunk, DiffLine{Kind: '-', Text: line[1:]})
+		default:
+			*hunk = append(*hunk, DiffLine{Kind: ' ', Line: next, Text: line[1:]})
+			next++
+		}
+	}
+	return files
+}
@@ -0,0 +1,48 @@
+package git
+
+import "testing"
+
+func TestParseDiff(t *testing.T) {
+	out := `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -10,4 +10,5 @@ func main() {
+ 	cfg := load()
+-	run(cfg)
++	if err := run(cfg); err != nil {
++		log.Fatal(err)
++	}
+ }
+diff --git a/old.txt b/old.txt
+deleted file mode 100644
+--- a/old.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-gone
+diff --git a/logo.png b/logo.png
+Binary files a/logo.png and b/logo.png differ
+`
+	files := ParseDiff(out)
+	if len(files) != 3 {
+		t.Fatalf("Expected 3 files, got %d", len(files))
+	}
+
+	main := files[0]
+	if main.Path != "main.go" || len(main.Hunks) != 1 {
+		t.Fatalf("Unexpected main.go diff: %+v", main)
+	}
+	if added, removed := main.Counts(); added != 3 || removed != 1 {
+		t.Errorf("Expected +3 -1, got +%d -%d", added, removed)
+	}
+	if main.Added(10) || !main.Added(11) || !main.Added(13) || main.Added(14) {
+		t.Errorf("Expected lines 11-13 to be added, got %+v", main.Hunks[0])
+	}
+
+	if !files[1].Deleted || files[1].Path != "old.txt" {
+		t.Errorf("Expected old.txt to be deleted, got %+v", files[1])
+	}
+	if !files[2].Binary || files[2].Path != "logo.png" {
+		t.Errorf("Expected logo.png to be binary, got %+v", files[2])
+	}
+}
@@ -0,0 +1,160 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+)
+
+// run executes a git command in dir and returns its trimmed output
+func run(dir string, args ...string) (string, error) {
+	out, err := output(dir, args...)
+	return strings.TrimSpace(out), err
+}
+
+// output executes a git command in dir and returns its output as is
+func output(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %w", strings.Join(args, " "), err)
+	}
+	return string(out), nil
+}
+
+// IsRepo reports whether dir is inside a git work tree
+func IsRepo(dir string) bool {
+	out, err := run(dir, "rev-parse", "--is-inside-work-tree")
+	return err == nil && out == "true"
+}
+
+// ChangedFilesSince returns files changed in commits since the given time plus uncommitted changes
+func ChangedFilesSince(dir string, since time.Time) ([]string, error) {
+	if !IsRepo(dir) {
+		return nil, nil
+	}
+
+	seen := make(map[string]bool)
+
+	committed, err := run(dir, "log", "--since="+since.Format(time.RFC3339), "--name-only", "--pretty=format:")
+	if err != nil {
+		return nil, err
+	}
+	addLines(seen, committed)
+
+	uncommitted, err := run(dir, "diff", "--name-only", "HEAD")
+	if err == nil {
+		addLines(seen, uncommitted)
+	}
+
+	untracked, err := run(dir, "ls-files", "--others", "--exclude-standard")
+	if err == nil {
+		addLines(seen, untracked)
+	}
+
+	// The log names a renamed file by its new path only; the old path changed too
+	if renames, err := RenamesSince(dir, since); err == nil {
+		for _, rename := range renames {
+			seen[rename.From] = true
+			seen[rename.To] = true
+		}
+	}
+
+	files := make([]string, 0, len(seen))
+	for file := range seen {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// emptyTree is the hash of git's empty tree, the base of a repository with no earlier commit
+const emptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// Root returns the top directory of the repository dir is in
+func Root(dir string) (string, error) {
+	return run(dir, "rev-parse", "--show-toplevel")
+}
+
+// Prefix returns the path of dir relative to the repository root, with a trailing slash, or ""
+// at the root
+func Prefix(dir string) (string, error) {
+	return run(dir, "rev-parse", "--show-prefix")
+}
+
+// DiffFilesSince returns the files, relative to the repository root, whose contents differ from
+// the last commit made before the given time: committed, uncommitted and untracked changes. Files
+// changed and then changed back are left out, and both sides of a rename are included.
+func DiffFilesSince(dir string, since time.Time) ([]string, error) {
+	base, err := run(dir, "rev-list", "-1", "--before="+since.Format(time.RFC3339), "HEAD")
+	if err != nil || base == "" {
+		base = emptyTree
+	}
+
+	seen := make(map[string]bool)
+	changed, err := run(dir, "diff", "--name-only", "--no-renames", base)
+	if err != nil {
+		return nil, err
+	}
+	addLines(seen, changed)
+	if untracked, err := run(dir, "ls-files", "--others", "--exclude-standard", "--full-name", "--", ":/"); err == nil {
+		addLines(seen, untracked)
+	}
+
+	files := make([]string, 0, len(seen))
+	for file := range seen {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Rename is a file git detected as moved, by content similarity
+type Rename struct {
+	From string
+	To   string
+}
+
+// RenamesSince returns the files renamed in commits since the given time and in uncommitted
+// changes, oldest first
+func RenamesSince(dir string, since time.Time) ([]Rename, error) {
+	committed, err := run(dir, "log", "--since="+since.Format(time.RFC3339), "-M", "--diff-filter=R", "--name-status", "--pretty=format:")
+	if err != nil {
+		return nil, err
+	}
+	renames := parseRenames(committed)
+	// The log lists the newest commit first
+	for i, j := 0, len(renames)-1; i < j; i, j = i+1, j-1 {
+		renames[i], renames[j] = renames[j], renames[i]
+	}
+
+	if uncommitted, err := run(dir, "diff", "-M", "--diff-filter=R", "--name-status", "HEAD"); err == nil {
+		renames = append(renames, parseRenames(uncommitted)...)
+	}
+	return renames, nil
+}
+
+// parseRenames reads the "R<score>\told\tnew" lines of git --name-status output
+func parseRenames(output string) []Rename {
+	var renames []Rename
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "\t")
+		if len(fields) == 3 && strings.HasPrefix(fields[0], "R") {
+			renames = append(renames, Rename{From: fields[1], To: fields[2]})
+		}
+	}
+	return renames
+}
+
+// addLines adds each non-empty line of output to the set
+func addLines(set map[string]bool, output string) {
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			set[line] = true
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenamesSince(t *testing.T) {
+	dir := t.TempDir()
+	gitCmd := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "old.go"), []byte("package main\n\nfunc main() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitCmd("init", "-q", "-b", "main")
+	gitCmd("add", ".")
+	gitCmd("commit", "-q", "-m", "Initial commit")
+	gitCmd("mv", "old.go", "new.go")
+	gitCmd("commit", "-q", "-m", "Rename")
+
+	files, err := ChangedFilesSince(dir, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("ChangedFilesSince failed: %v", err)
+	}
+	if strings.Join(files, ",") != "new.go,old.go" {
+		t.Errorf("Expected both sides of the rename, got %v", files)
+	}
+}
+
+func TestDiffFilesSince(t *testing.T) {
+	dir := t.TempDir()
+	gitCmd := func(date string, args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	past := time.Now().Add(-2 * time.Hour).Format(time.RFC3339)
+	now := time.Now().Format(time.RFC3339)
+
+	write("main.go", "package main\n")
+	write("cmd/main.go", "package main\n")
+	write("util.go", "package main\n")
+	gitCmd(past, "init", "-q", "-b", "main")
+	gitCmd(past, "add", ".")
+	gitCmd(past, "commit", "-q", "-m", "Initial commit")
+
+	// util.go is changed and changed back, main.go changed and new.go left untracked
+	write("util.go", "package util\n")
+	gitCmd(now, "commit", "-q", "-am", "Change util")
+	write("util.go", "package main\n")
+	gitCmd(now, "commit", "-q", "-am", "Revert util")
+	write("main.go", "package main\n\nfunc main() {}\n")
+	write("cmd/new.go", "package main\n")
+
+	files, err := DiffFilesSince(filepath.Join(dir, "cmd"), time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("DiffFilesSince failed: %v", err)
+	}
+	if strings.Join(files, ",") != "cmd/new.go,main.go" {
+		t.Errorf("Expected the files that differ, relative to the root, got %v", files)
+	}
+	if prefix, err := Prefix(filepath.Join(dir, "cmd")); err != nil || prefix != "cmd/" {
+		t.Errorf("Expected prefix cmd/, got %q, %v", prefix, err)
+	}
+}
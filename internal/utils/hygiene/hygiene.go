@@ -0,0 +1,350 @@
+package hygiene
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bkidd1/wash-cli/internal/utils/analyzable"
+	"github.com/bkidd1/wash-cli/internal/utils/impact"
+	"github.com/bkidd1/wash-cli/internal/utils/language"
+)
+
+const (
+	// MinDuplicateLines is the fewest significant lines a copied block spans
+	MinDuplicateLines = 8
+	// listedExports and listedDuplicates cap how many unused exports and copied blocks are listed
+	listedExports    = 30
+	listedDuplicates = 10
+)
+
+// importLine matches the lines of import blocks, which are alike in many files without being copied
+var importLine = regexp.MustCompile(`^(?:import\b|from\s+\S+\s+import\b|(?:[\w.]+\s+)?"[^"]*"$)`)
+
+// Export is an exported Go function, type, variable or constant no other package of the module uses
+type Export struct {
+	Package string
+	Name    string
+	Path    string
+	Line    int
+	// Unused is set when the package does not use it either
+	Unused bool
+}
+
+// Duplicate is a block of code found in two places
+type Duplicate struct {
+	Path      string
+	Line      int
+	OtherPath string
+	OtherLine int
+	// Lines counts the lines of the first copy
+	Lines int
+}
+
+// Report is the dead and duplicated code found in a project without the model
+type Report struct {
+	// Exports are the unused exports, those unused anywhere first
+	Exports []Export
+	// Unimported are the Go packages nothing in the module imports
+	Unimported []string
+	// SameName groups the Go packages sharing a directory name, which are often a leftover copy
+	// of each other; commands are left out, as they are usually named after what they run
+	SameName [][]string
+	// Duplicates are the largest copied blocks, largest first
+	Duplicates []Duplicate
+}
+
+// Find looks for dead and duplicated code in the files of a project, given relative to root with
+// forward slashes. Exports and packages are checked when root is a Go module.
+func Find(root string, files []string) *Report {
+	r := &Report{}
+	if graph, err := impact.Load(root); err == nil && graph != nil {
+		r.Exports = unusedExports(root, graph.Module(), files)
+		r.Unimported = graph.Unimported()
+		r.SameName = sameName(graph.Packages())
+	}
+	r.Duplicates = duplicates(root, files)
+	return r
+}
+
+// Empty reports whether nothing was found
+func (r *Report) Empty() bool {
+	return len(r.Exports) == 0 && len(r.Unimported) == 0 && len(r.SameName) == 0 && len(r.Duplicates) == 0
+}
+
+// String formats the report as the list shown in the hygiene report and sent with the prompt
+func (r *Report) String() string {
+	if r.Empty() {
+		return "- No unused exports, unimported packages or copied blocks found\n"
+	}
+	var b strings.Builder
+	if len(r.Exports) > 0 {
+		var listed []string
+		for _, export := range r.Exports[:min(len(r.Exports), listedExports)] {
+			note := ""
+			if export.Unused {
+				note = ", unused"
+			}
+			listed = append(listed, fmt.Sprintf("%s.%s (%s:%d%s)", export.Package, export.Name, export.Path, export.Line, note))
+		}
+		if more := len(r.Exports) - len(listed); more > 0 {
+			listed = append(listed, fmt.Sprintf("and %d more", more))
+		}
+		fmt.Fprintf(&b, "- Exports no other package uses: %s\n", strings.Join(listed, ", "))
+	}
+	if len(r.Unimported) > 0 {
+		fmt.Fprintf(&b, "- Packages nothing in the module imports: %s\n", strings.Join(r.Unimported, ", "))
+	}
+	if len(r.SameName) > 0 {
+		var listed []string
+		for _, group := range r.SameName {
+			listed = append(listed, fmt.Sprintf("%s (%s)", path.Base(group[0]), strings.Join(group, ", ")))
+		}
+		fmt.Fprintf(&b, "- Packages sharing a name: %s\n", strings.Join(listed, ", "))
+	}
+	if len(r.Duplicates) > 0 {
+		var listed []string
+		for _, dup := range r.Duplicates {
+			listed = append(listed, fmt.Sprintf("%s:%d and %s:%d (%d lines)", dup.Path, dup.Line, dup.OtherPath, dup.OtherLine, dup.Lines))
+		}
+		fmt.Fprintf(&b, "- Copied blocks: %s\n", strings.Join(listed, ", "))
+	}
+	return b.String()
+}
+
+// unusedExports returns the exported top-level declarations of the module's packages that no other
+// package uses. Tests are not counted as uses, and methods, which may satisfy interfaces, and main
+// packages are not checked.
+func unusedExports(root, module string, files []string) []Export {
+	type key struct{ pkg, name string }
+	var exports []Export
+	usedOutside := make(map[key]bool)
+	usedInside := make(map[key]int)
+	fset := token.NewFileSet()
+
+	for _, file := range files {
+		if !strings.HasSuffix(file, ".go") || strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		parsed, err := parser.ParseFile(fset, filepath.Join(root, filepath.FromSlash(file)), nil, parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		pkg := path.Dir(file)
+
+		// Uses in other packages go through the names their imports are known by
+		imports := make(map[string]string)
+		for _, spec := range parsed.Imports {
+			imported, err := strconv.Unquote(spec.Path.Value)
+			if err != nil || (imported != module && !strings.HasPrefix(imported, module+"/")) {
+				continue
+			}
+			rel := "."
+			if imported != module {
+				rel = strings.TrimPrefix(imported, module+"/")
+			}
+			name := path.Base(imported)
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+			imports[name] = rel
+		}
+
+		declared := make(map[*ast.Ident]bool)
+		if parsed.Name.Name != "main" {
+			for _, decl := range parsed.Decls {
+				for _, ident := range exportedNames(decl) {
+					declared[ident] = true
+					exports = append(exports, Export{Package: pkg, Name: ident.Name, Path: file, Line: fset.Position(ident.Pos()).Line})
+				}
+			}
+		}
+
+		ast.Inspect(parsed, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.SelectorExpr:
+				if x, ok := n.X.(*ast.Ident); ok {
+					if rel, ok := imports[x.Name]; ok {
+						usedOutside[key{rel, n.Sel.Name}] = true
+					}
+				}
+			case *ast.Ident:
+				if !declared[n] {
+					usedInside[key{pkg, n.Name}]++
+				}
+			}
+			return true
+		})
+	}
+
+	var unused []Export
+	for _, export := range exports {
+		k := key{export.Package, export.Name}
+		if !usedOutside[k] {
+			export.Unused = usedInside[k] == 0
+			unused = append(unused, export)
+		}
+	}
+	sort.SliceStable(unused, func(i, j int) bool { return unused[i].Unused && !unused[j].Unused })
+	return unused
+}
+
+// exportedNames returns the exported names a top-level declaration declares, leaving out methods
+func exportedNames(decl ast.Decl) []*ast.Ident {
+	var names []*ast.Ident
+	switch decl := decl.(type) {
+	case *ast.FuncDecl:
+		if decl.Recv == nil && decl.Name.IsExported() {
+			names = append(names, decl.Name)
+		}
+	case *ast.GenDecl:
+		for _, spec := range decl.Specs {
+			switch spec := spec.(type) {
+			case *ast.TypeSpec:
+				if spec.Name.IsExported() {
+					names = append(names, spec.Name)
+				}
+			case *ast.ValueSpec:
+				for _, name := range spec.Names {
+					if name.IsExported() {
+						names = append(names, name)
+					}
+				}
+			}
+		}
+	}
+	return names
+}
+
+// sameName groups the packages that share a directory name, leaving out those under cmd
+func sameName(packages []string) [][]string {
+	byName := make(map[string][]string)
+	var names []string
+	for _, pkg := range packages {
+		if pkg == "." || pkg == "cmd" || strings.HasPrefix(pkg, "cmd/") || strings.Contains(pkg, "/cmd/") {
+			continue
+		}
+		name := path.Base(pkg)
+		if byName[name] == nil {
+			names = append(names, name)
+		}
+		byName[name] = append(byName[name], pkg)
+	}
+	sort.Strings(names)
+	var groups [][]string
+	for _, name := range names {
+		if len(byName[name]) > 1 {
+			groups = append(groups, byName[name])
+		}
+	}
+	return groups
+}
+
+// line is a significant line of a file: code that is not blank, a comment, a lone bracket or an import
+type line struct {
+	text   string
+	number int
+}
+
+// location is the start of a window of significant lines in a file
+type location struct {
+	file  int
+	index int
+}
+
+// duplicates finds the blocks of at least MinDuplicateLines significant lines that appear twice,
+// by hashing every window of that many lines and joining the windows that follow each other
+func duplicates(root string, files []string) []Duplicate {
+	var sources []string
+	var lines [][]line
+	for _, file := range files {
+		if language.ForFile(file) == "" {
+			continue
+		}
+		fullPath := filepath.Join(root, filepath.FromSlash(file))
+		if analyzable.Check(fullPath, 0) != nil {
+			continue
+		}
+		content, err := os.ReadFile(fullPath)
+		if err != nil {
+			continue
+		}
+		sources = append(sources, file)
+		lines = append(lines, significant(string(content)))
+	}
+
+	seen := make(map[string][]location)
+	var found []Duplicate
+	for fi := range sources {
+		// current is the index in found of the block the previous window extended, or -1
+		current := -1
+		var last location
+		for i := 0; i+MinDuplicateLines <= len(lines[fi]); i++ {
+			var window []string
+			for _, l := range lines[fi][i : i+MinDuplicateLines] {
+				window = append(window, l.text)
+			}
+			key := strings.Join(window, "\n")
+			end := lines[fi][i+MinDuplicateLines-1].number
+
+			// Continue the block the previous window belongs to, or start one at the first earlier copy
+			match, matched, extends := location{}, false, false
+			for _, loc := range seen[key] {
+				if loc.file == fi && i-loc.index < MinDuplicateLines {
+					continue
+				}
+				if current >= 0 && loc.file == last.file && loc.index == last.index+1 {
+					match, matched, extends = loc, true, true
+					break
+				}
+				if !matched {
+					match, matched = loc, true
+				}
+			}
+			seen[key] = append(seen[key], location{fi, i})
+
+			if !matched {
+				current = -1
+				continue
+			}
+			if extends {
+				found[current].Lines = end - found[current].Line + 1
+			} else {
+				found = append(found, Duplicate{
+					Path:      sources[fi],
+					Line:      lines[fi][i].number,
+					OtherPath: sources[match.file],
+					OtherLine: lines[match.file][match.index].number,
+					Lines:     end - lines[fi][i].number + 1,
+				})
+				current = len(found) - 1
+			}
+			last = match
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool { return found[i].Lines > found[j].Lines })
+	return found[:min(len(found), listedDuplicates)]
+}
+
+// significant returns the significant lines of a file, trimmed, with their line numbers
+func significant(content string) []line {
+	var kept []line
+	for i, text := range strings.Split(content, "\n") {
+		text = strings.TrimSpace(text)
+		if text == "" || strings.Trim(text, "{}()[],;") == "" || importLine.MatchString(text) ||
+			strings.HasPrefix(text, "//") || strings.HasPrefix(text, "#") || strings.HasPrefix(text, "/*") || strings.HasPrefix(text, "*") {
+			continue
+		}
+		kept = append(kept, line{text: text, number: i + 1})
+	}
+	return kept
+}
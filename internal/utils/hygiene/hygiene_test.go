@@ -0,0 +1,62 @@
+package hygiene
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	root := t.TempDir()
+	copied := "\tfor _, item := range items {\n\t\tif item.Done {\n\t\t\tcontinue\n\t\t}\n\t\ttotal += item.Cost\n\t\tcount++\n\t\tlog.Println(item.Name)\n\t\tlast = item\n\t\tseen[item.Name] = true\n\t}\n"
+	files := map[string]string{
+		"go.mod":                "module example.com/app\n",
+		"cmd/app/main.go":       "package main\n\nimport \"example.com/app/store\"\n\nfunc main() { store.Open() }\n",
+		"store/store.go":        "package store\n\nfunc Open() {}\n\nfunc Close() {}\n\nconst Version = 1\n\nvar current = Version\n",
+		"store/store_test.go":   "package store\n\nfunc TestClose() { Close() }\n",
+		"legacy/store/store.go": "package store\n\nfunc sum() {\n" + copied + "}\n",
+		"report/report.go":      "package report\n\nfunc total() {\n\tstart()\n" + copied + "}\n",
+	}
+	var paths []string
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, name)
+	}
+
+	sort.Strings(paths)
+
+	report := Find(root, paths)
+	var exports []string
+	for _, export := range report.Exports {
+		exports = append(exports, export.Name)
+		if export.Name == "Close" && !export.Unused {
+			t.Errorf("Expected uses in tests not to count")
+		}
+		if export.Name == "Version" && export.Unused {
+			t.Errorf("Expected Version to be used in its package")
+		}
+	}
+	if got := strings.Join(exports, " "); got != "Close Version" {
+		t.Errorf("Unexpected unused exports: %s", got)
+	}
+	if got := strings.Join(report.Unimported, " "); got != "legacy/store report" {
+		t.Errorf("Unexpected unimported packages: %s", got)
+	}
+	if len(report.SameName) != 1 || strings.Join(report.SameName[0], " ") != "legacy/store store" {
+		t.Errorf("Unexpected packages sharing a name: %v", report.SameName)
+	}
+	if len(report.Duplicates) != 1 {
+		t.Fatalf("Expected one copied block, got %v", report.Duplicates)
+	}
+	if dup := report.Duplicates[0]; dup.Lines != 9 || dup.Line != 5 || dup.OtherLine != 4 {
+		t.Errorf("Unexpected copied block: %+v", dup)
+	}
+}
@@ -3,6 +3,7 @@ package ignore
 import (
 	"bufio"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 )
@@ -48,50 +49,107 @@ var DefaultIgnorePatterns = []string{
 	"temp",
 }
 
-// ShouldIgnore checks if a path should be ignored based on patterns
-func ShouldIgnore(path string, patterns []string) bool {
-	// Convert path to forward slashes for consistent matching
-	path = filepath.ToSlash(path)
+// PatternFiles are the files in a project's root that LoadGitignorePatterns reads patterns from.
+// .washignore uses the .gitignore syntax for what only wash should skip.
+var PatternFiles = []string{".gitignore", ".washignore"}
+
+// ShouldIgnore checks if a path, relative to the project root, should be ignored based on patterns
+// in the .gitignore syntax: a pattern without a slash matches a name at any depth, one with a
+// leading or inner slash matches from the root, ** matches any number of directories, and a
+// later !pattern re-includes what an earlier one ignored. As in git, a file cannot be re-included
+// once a directory above it is ignored. Whether a path is a directory is not known here, so a
+// pattern ending in a slash matches files of that name too.
+func ShouldIgnore(rel string, patterns []string) bool {
+	rel = path.Clean(filepath.ToSlash(rel))
+	if rel == "." || rel == "/" {
+		return false
+	}
+	parts := strings.Split(strings.TrimPrefix(rel, "/"), "/")
 
-	// Check each pattern
-	for _, pattern := range patterns {
-		// Handle exact matches
-		if path == pattern {
+	// Every directory above the path is checked first, so an ignored one hides its contents
+	for i := 1; i <= len(parts); i++ {
+		if ignoredBy(parts[:i], patterns) {
 			return true
 		}
+	}
+	return false
+}
 
-		// Handle directory matches
-		if strings.HasSuffix(pattern, "/") {
-			if strings.HasPrefix(path, pattern) {
-				return true
-			}
+// ignoredBy reports whether the last of patterns that matches a path ignores it
+func ignoredBy(parts []string, patterns []string) bool {
+	ignored := false
+	for _, pattern := range patterns {
+		negate := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+		// A backslash escapes a leading ! or #
+		pattern = strings.TrimPrefix(pattern, `\`)
+		pattern = strings.TrimSuffix(pattern, "/")
+		if pattern == "" || ignored != negate {
+			// Only a negation can change an ignored path, and only an ignore a kept one
+			continue
+		}
+
+		matched := false
+		if strings.Contains(pattern, "/") {
+			matched = matchSegments(strings.Split(strings.TrimPrefix(pattern, "/"), "/"), parts)
+		} else {
+			matched, _ = path.Match(pattern, parts[len(parts)-1])
+		}
+		if matched {
+			ignored = !negate
 		}
+	}
+	return ignored
+}
 
-		// Handle wildcard matches
-		if strings.Contains(pattern, "*") {
-			matched, err := filepath.Match(pattern, filepath.Base(path))
-			if err == nil && matched {
+// matchSegments matches the slash-separated segments of an anchored pattern against those of a
+// path, where a ** segment matches any number of path segments
+func matchSegments(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchSegments(pattern[1:], parts[i:]) {
 				return true
 			}
 		}
+		return false
 	}
-
-	return false
+	if len(parts) == 0 {
+		return false
+	}
+	if matched, _ := path.Match(pattern[0], parts[0]); !matched {
+		return false
+	}
+	return matchSegments(pattern[1:], parts[1:])
 }
 
-// LoadGitignorePatterns loads patterns from .gitignore file
+// LoadGitignorePatterns loads the default patterns and those of the project's .gitignore and
+// .washignore files
 func LoadGitignorePatterns(rootPath string) ([]string, error) {
-	gitignorePath := filepath.Join(rootPath, ".gitignore")
 	patterns := make([]string, 0)
 
 	// Add default patterns
 	patterns = append(patterns, DefaultIgnorePatterns...)
 
-	// Try to read .gitignore file
-	file, err := os.Open(gitignorePath)
+	for _, name := range PatternFiles {
+		filePatterns, err := loadPatternFile(filepath.Join(rootPath, name))
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, filePatterns...)
+	}
+	return patterns, nil
+}
+
+// loadPatternFile reads the patterns of one ignore file, which may not exist
+func loadPatternFile(path string) ([]string, error) {
+	var patterns []string
+	file, err := os.Open(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return patterns, nil
+			return nil, nil
 		}
 		return nil, err
 	}
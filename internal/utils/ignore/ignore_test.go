@@ -0,0 +1,84 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadGitignorePatternsReadsWashignore(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, ".gitignore"), []byte("# build output\n/generated/\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".washignore"), []byte("fixtures/\n*.pb.go\n!keep.pb.go\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	patterns, err := LoadGitignorePatterns(root)
+	if err != nil {
+		t.Fatalf("LoadGitignorePatterns failed: %v", err)
+	}
+
+	tests := map[string]bool{
+		"generated":           true,
+		"generated/api.go":    true,
+		"fixtures":            true,
+		"fixtures/input.json": true,
+		"api/service.pb.go":   true,
+		"api/keep.pb.go":      false,
+		"node_modules":        true,
+		"api/service.go":      false,
+		"cmd/main.go":         false,
+	}
+	for path, want := range tests {
+		if got := ShouldIgnore(path, patterns); got != want {
+			t.Errorf("ShouldIgnore(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestShouldIgnoreFollowsGitignoreSyntax(t *testing.T) {
+	patterns := []string{
+		"*.log",
+		"!keep.log",
+		"/build",
+		"docs/*.tmp",
+		"fixtures/",
+		"!fixtures/golden.json",
+		"**/generated/**",
+		`\#notes`,
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"debug.log", true},
+		{"logs/app/debug.log", true},
+		// A later negation re-includes
+		{"keep.log", false},
+		{"logs/keep.log", false},
+		// A leading slash anchors to the root
+		{"build", true},
+		{"build/main.o", true},
+		{"src/build", false},
+		{"src/build/main.o", false},
+		// An inner slash anchors too
+		{"docs/draft.tmp", true},
+		{"api/docs/draft.tmp", false},
+		// Nothing under an ignored directory can be re-included
+		{"fixtures/golden.json", true},
+		{"test/fixtures/input.json", true},
+		{"api/generated/types.go", true},
+		{"generated/types.go", true},
+		{"#notes", true},
+		{"src/main.go", false},
+		{".", false},
+	}
+	for _, tt := range tests {
+		if got := ShouldIgnore(tt.path, patterns); got != tt.want {
+			t.Errorf("ShouldIgnore(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
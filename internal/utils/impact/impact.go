@@ -0,0 +1,187 @@
+package impact
+
+import (
+	"bufio"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bkidd1/wash-cli/internal/utils/ignore"
+)
+
+// DefaultLimit is how many likely affected files are listed for a change
+const DefaultLimit = 20
+
+// Graph is the import graph of the Go packages in a module
+type Graph struct {
+	module string
+	// packageOf maps each Go file, relative to the root, to its package's import path
+	packageOf map[string]string
+	// importers maps a package's import path to the files that import it
+	importers map[string][]string
+	// imports maps each package's import path to the module packages its non-test files import
+	imports map[string]map[string]bool
+	// commands are the main packages
+	commands map[string]bool
+}
+
+// Load parses the imports of every Go file in the module at root. It returns nil without an error
+// when root has no go.mod, so callers can skip impact analysis for other projects.
+func Load(root string) (*Graph, error) {
+	module, err := modulePath(filepath.Join(root, "go.mod"))
+	if err != nil || module == "" {
+		return nil, nil
+	}
+
+	patterns, err := ignore.LoadGitignorePatterns(root)
+	if err != nil {
+		patterns = ignore.DefaultIgnorePatterns
+	}
+
+	g := &Graph{
+		module:    module,
+		packageOf: make(map[string]string),
+		importers: make(map[string][]string),
+		imports:   make(map[string]map[string]bool),
+		commands:  make(map[string]bool),
+	}
+	fset := token.NewFileSet()
+	err = filepath.Walk(root, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(root, file)
+		if err != nil || rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if info.IsDir() {
+			name := info.Name()
+			if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata" || ignore.ShouldIgnore(rel, patterns) {
+				return filepath.SkipDir
+			}
+			// Nested modules have their own graph
+			if _, err := os.Stat(filepath.Join(file, "go.mod")); err == nil {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(rel, ".go") {
+			return nil
+		}
+
+		parsed, err := parser.ParseFile(fset, file, nil, parser.ImportsOnly)
+		if err != nil {
+			return nil
+		}
+		pkg := g.packageFor(rel)
+		g.packageOf[rel] = pkg
+		test := strings.HasSuffix(rel, "_test.go")
+		if !test {
+			if g.imports[pkg] == nil {
+				g.imports[pkg] = make(map[string]bool)
+			}
+			if parsed.Name.Name == "main" {
+				g.commands[pkg] = true
+			}
+		}
+		for _, spec := range parsed.Imports {
+			imported, err := strconv.Unquote(spec.Path.Value)
+			if err == nil && (imported == module || strings.HasPrefix(imported, module+"/")) {
+				g.importers[imported] = append(g.importers[imported], rel)
+				if !test && imported != pkg {
+					g.imports[pkg][imported] = true
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// packageFor returns the import path of the package holding a file
+func (g *Graph) packageFor(rel string) string {
+	dir := path.Dir(rel)
+	if dir == "." {
+		return g.module
+	}
+	return g.module + "/" + dir
+}
+
+// Affected returns up to limit files that depend on the changed Go files through imports, nearest
+// first: the files importing their packages, then the files importing those files' packages, and
+// so on. Tests are listed but not followed. Changed files are never listed.
+func (g *Graph) Affected(changed []string, limit int) []string {
+	if g == nil || limit <= 0 {
+		return nil
+	}
+
+	skip := make(map[string]bool, len(changed))
+	var queue []string
+	queued := make(map[string]bool)
+	for _, file := range changed {
+		file = filepath.ToSlash(file)
+		skip[file] = true
+		if pkg, ok := g.packageOf[file]; ok && !queued[pkg] {
+			queued[pkg] = true
+			queue = append(queue, pkg)
+		}
+	}
+
+	var affected []string
+	seen := make(map[string]bool)
+	for len(queue) > 0 && len(affected) < limit {
+		// Each level is sorted, so the result does not depend on walk order
+		var level []string
+		for _, pkg := range queue {
+			for _, file := range g.importers[pkg] {
+				if !skip[file] && !seen[file] {
+					seen[file] = true
+					level = append(level, file)
+				}
+			}
+		}
+		sort.Strings(level)
+
+		queue = nil
+		for _, file := range level {
+			if len(affected) < limit {
+				affected = append(affected, file)
+			}
+			// Nothing imports a test, so its package's dependents are not affected through it
+			if strings.HasSuffix(file, "_test.go") {
+				continue
+			}
+			if pkg := g.packageOf[file]; !queued[pkg] {
+				queued[pkg] = true
+				queue = append(queue, pkg)
+			}
+		}
+	}
+	return affected
+}
+
+// modulePath reads the module path from a go.mod file
+func modulePath(goMod string) (string, error) {
+	file, err := os.Open(goMod)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if module, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "module "); ok {
+			return strings.Trim(strings.TrimSpace(module), `"`), nil
+		}
+	}
+	return "", scanner.Err()
+}
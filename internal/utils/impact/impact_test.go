@@ -0,0 +1,100 @@
+package impact
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAffected(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":                   "module example.com/app\n\ngo 1.22\n",
+		"store/store.go":           "package store\n",
+		"store/cache.go":           "package store\n",
+		"api/api.go":               "package api\n\nimport \"example.com/app/store\"\n",
+		"api/api_test.go":          "package api\n\nimport \"example.com/app/store\"\n",
+		"cmd/app/main.go":          "package main\n\nimport (\n\t\"fmt\"\n\t\"example.com/app/api\"\n)\n",
+		"tools/lint/lint.go":       "package lint\n\nimport \"example.com/app/api\"\n",
+		"tools/lint/lint_test.go":  "package lint\n",
+		"plugins/go.mod":           "module example.com/plugins\n",
+		"plugins/plugin/plugin.go": "package plugin\n\nimport \"example.com/app/store\"\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	graph, err := Load(root)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	affected := graph.Affected([]string{"store/cache.go"}, DefaultLimit)
+	if got := strings.Join(affected, " "); got != "api/api.go api/api_test.go cmd/app/main.go tools/lint/lint.go" {
+		t.Errorf("Unexpected affected files: %s", got)
+	}
+	if affected := graph.Affected([]string{"store/cache.go"}, 1); len(affected) != 1 || affected[0] != "api/api.go" {
+		t.Errorf("Expected the nearest file only, got %v", affected)
+	}
+
+	if graph, err := Load(t.TempDir()); graph != nil || err != nil {
+		t.Errorf("Expected no graph outside a Go module, got %v %v", graph, err)
+	}
+}
+
+func TestPackageGraph(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":                                "module example.com/app\n",
+		"internal/utils/log/log.go":             "package log\n\nimport \"example.com/app/internal/services/audit\"\n",
+		"internal/services/audit/audit.go":      "package audit\n\nimport \"example.com/app/internal/services/users\"\n",
+		"internal/services/users/users.go":      "package users\n\nimport \"example.com/app/internal/services/audit\"\n",
+		"internal/services/users/users_test.go": "package users\n\nimport \"example.com/app/cmd/app\"\n",
+		"cmd/app/main.go":                       "package main\n\nimport \"example.com/app/internal/services/users\"\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	graph, err := Load(root)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if got := strings.Join(graph.Packages(), " "); got != "cmd/app internal/services/audit internal/services/users internal/utils/log" {
+		t.Errorf("Unexpected packages: %s", got)
+	}
+	if got := graph.Imports("internal/services/users"); len(got) != 1 || got[0] != "internal/services/audit" {
+		t.Errorf("Expected tests not to add imports, got %v", got)
+	}
+	cycles := graph.Cycles()
+	if len(cycles) != 1 || strings.Join(cycles[0], " ") != "internal/services/audit internal/services/users" {
+		t.Errorf("Unexpected cycles: %v", cycles)
+	}
+	violations := graph.LayerViolations()
+	if len(violations) != 1 || violations[0].From != "internal/utils/log" || violations[0].ToLayer != "services" {
+		t.Errorf("Unexpected layering violations: %v", violations)
+	}
+	if gods := graph.GodPackages(); len(gods) != 0 {
+		t.Errorf("Expected no god packages in a small module, got %v", gods)
+	}
+
+	mermaid := graph.Mermaid()
+	if !strings.Contains(mermaid, "p0 --> p2") || !strings.Contains(mermaid, "linkStyle 1,2,3 stroke:red") {
+		t.Errorf("Unexpected Mermaid graph:\n%s", mermaid)
+	}
+	if dot := graph.DOT(); !strings.Contains(dot, "\"internal/utils/log\" -> \"internal/services/audit\" [color=red];") {
+		t.Errorf("Unexpected DOT graph:\n%s", dot)
+	}
+}
@@ -0,0 +1,326 @@
+package impact
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	// godMinImports is the fewest module packages a god package imports, however small the module
+	godMinImports = 8
+	// mostImported is how many of the most imported packages are named
+	mostImported = 3
+)
+
+// layers ranks the usual directory names of a Go module from the lowest layer to the highest.
+// A package takes the rank of the first of its directories that has one.
+var layers = map[string]int{
+	"util": 0, "utils": 0, "common": 0, "lib": 0, "helpers": 0, "shared": 0,
+	"model": 1, "models": 1, "store": 1, "storage": 1, "repository": 1, "db": 1,
+	"service": 2, "services": 2, "core": 2, "domain": 2, "usecase": 2, "usecases": 2,
+	"api": 3, "handler": 3, "handlers": 3, "server": 3, "web": 3, "http": 3, "ui": 3,
+	"cmd": 4,
+}
+
+// Violation is an import from a lower layer of the module into a higher one
+type Violation struct {
+	From, To string
+	// FromLayer and ToLayer are the directories that place the packages in their layers
+	FromLayer, ToLayer string
+}
+
+// GodPackage is a package that imports a large share of the module
+type GodPackage struct {
+	Package string
+	Imports int
+}
+
+// Module returns the module path
+func (g *Graph) Module() string {
+	return g.module
+}
+
+// Packages returns the module's packages, relative to the module root, sorted
+func (g *Graph) Packages() []string {
+	var packages []string
+	for pkg := range g.imports {
+		packages = append(packages, g.rel(pkg))
+	}
+	sort.Strings(packages)
+	return packages
+}
+
+// Imports returns the module packages a package imports, relative to the module root, sorted.
+// Tests are left out, as they do not make a package depend on another.
+func (g *Graph) Imports(pkg string) []string {
+	var imports []string
+	for imported := range g.imports[g.abs(pkg)] {
+		if _, ok := g.imports[imported]; ok {
+			imports = append(imports, g.rel(imported))
+		}
+	}
+	sort.Strings(imports)
+	return imports
+}
+
+// Unimported returns the packages no other package of the module imports, sorted, leaving out main
+// packages and the root package, which are entry points
+func (g *Graph) Unimported() []string {
+	imported := make(map[string]bool)
+	for _, pkg := range g.Packages() {
+		for _, dep := range g.Imports(pkg) {
+			imported[dep] = true
+		}
+	}
+	var unimported []string
+	for _, pkg := range g.Packages() {
+		if pkg != "." && !imported[pkg] && !g.commands[g.abs(pkg)] {
+			unimported = append(unimported, pkg)
+		}
+	}
+	return unimported
+}
+
+// Cycles returns the groups of packages that import each other, directly or not, each sorted.
+// Go does not build such packages, so they are left by unfinished changes.
+func (g *Graph) Cycles() [][]string {
+	// Tarjan's strongly connected components
+	index := make(map[string]int)
+	low := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var cycles [][]string
+
+	var visit func(pkg string)
+	visit = func(pkg string) {
+		index[pkg] = len(index)
+		low[pkg] = index[pkg]
+		stack = append(stack, pkg)
+		onStack[pkg] = true
+		for _, imported := range g.Imports(pkg) {
+			if _, seen := index[imported]; !seen {
+				visit(imported)
+				low[pkg] = min(low[pkg], low[imported])
+			} else if onStack[imported] {
+				low[pkg] = min(low[pkg], index[imported])
+			}
+		}
+		if low[pkg] != index[pkg] {
+			return
+		}
+		var component []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == pkg {
+				break
+			}
+		}
+		if len(component) > 1 {
+			sort.Strings(component)
+			cycles = append(cycles, component)
+		}
+	}
+	for _, pkg := range g.Packages() {
+		if _, seen := index[pkg]; !seen {
+			visit(pkg)
+		}
+	}
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i][0] < cycles[j][0] })
+	return cycles
+}
+
+// GodPackages returns the packages that import at least a third of the module's other packages,
+// and never fewer than godMinImports, most imports first. Main packages, which wire the others
+// together, are left out.
+func (g *Graph) GodPackages() []GodPackage {
+	threshold := max(godMinImports, (len(g.imports)-1+2)/3)
+	var gods []GodPackage
+	for _, pkg := range g.Packages() {
+		if g.commands[g.abs(pkg)] {
+			continue
+		}
+		if imports := len(g.Imports(pkg)); imports >= threshold {
+			gods = append(gods, GodPackage{Package: pkg, Imports: imports})
+		}
+	}
+	sort.SliceStable(gods, func(i, j int) bool { return gods[i].Imports > gods[j].Imports })
+	return gods
+}
+
+// LayerViolations returns the imports from a lower layer into a higher one, such as utils
+// importing services or anything importing cmd, with layers taken from directory names
+func (g *Graph) LayerViolations() []Violation {
+	var violations []Violation
+	for _, pkg := range g.Packages() {
+		fromLayer, fromRank := layerOf(pkg)
+		if fromLayer == "" {
+			continue
+		}
+		for _, imported := range g.Imports(pkg) {
+			if toLayer, toRank := layerOf(imported); toLayer != "" && toRank > fromRank {
+				violations = append(violations, Violation{From: pkg, To: imported, FromLayer: fromLayer, ToLayer: toLayer})
+			}
+		}
+	}
+	return violations
+}
+
+// layerOf returns the directory that places a package in a layer, and the layer's rank
+func layerOf(pkg string) (string, int) {
+	for _, dir := range strings.Split(pkg, "/") {
+		if rank, ok := layers[dir]; ok {
+			return dir, rank
+		}
+	}
+	return "", 0
+}
+
+// Summary lists the dependency findings of the module, in the format of the project metrics
+func (g *Graph) Summary() string {
+	var b strings.Builder
+	packages := g.Packages()
+	edges := 0
+	importers := make(map[string]int)
+	for _, pkg := range packages {
+		for _, imported := range g.Imports(pkg) {
+			edges++
+			importers[imported]++
+		}
+	}
+	fmt.Fprintf(&b, "- Go packages: %d in %s, with %d imports between them\n", len(packages), g.module, edges)
+
+	popular := append([]string(nil), packages...)
+	sort.SliceStable(popular, func(i, j int) bool { return importers[popular[i]] > importers[popular[j]] })
+	var named []string
+	for _, pkg := range popular[:min(len(popular), mostImported)] {
+		if importers[pkg] > 0 {
+			named = append(named, fmt.Sprintf("%s (%d)", pkg, importers[pkg]))
+		}
+	}
+	if len(named) > 0 {
+		fmt.Fprintf(&b, "- Most imported packages: %s\n", strings.Join(named, ", "))
+	}
+
+	if cycles := g.Cycles(); len(cycles) > 0 {
+		var listed []string
+		for _, cycle := range cycles {
+			listed = append(listed, strings.Join(cycle, ", "))
+		}
+		fmt.Fprintf(&b, "- Import cycles: %s\n", strings.Join(listed, "; "))
+	} else {
+		b.WriteString("- Import cycles: none\n")
+	}
+	if gods := g.GodPackages(); len(gods) > 0 {
+		var listed []string
+		for _, god := range gods {
+			listed = append(listed, fmt.Sprintf("%s (imports %d of %d packages)", god.Package, god.Imports, len(packages)-1))
+		}
+		fmt.Fprintf(&b, "- God packages: %s\n", strings.Join(listed, ", "))
+	}
+	if violations := g.LayerViolations(); len(violations) > 0 {
+		var listed []string
+		for _, v := range violations {
+			listed = append(listed, fmt.Sprintf("%s imports %s (%s above %s)", v.From, v.To, v.ToLayer, v.FromLayer))
+		}
+		fmt.Fprintf(&b, "- Layering violations: %s\n", strings.Join(listed, ", "))
+	}
+	return b.String()
+}
+
+// flagged returns the imports in cycles or across layers, keyed by importer then imported package
+func (g *Graph) flagged() map[[2]string]bool {
+	flagged := make(map[[2]string]bool)
+	for _, cycle := range g.Cycles() {
+		for _, pkg := range cycle {
+			for _, imported := range g.Imports(pkg) {
+				for _, other := range cycle {
+					if other == imported {
+						flagged[[2]string{pkg, imported}] = true
+					}
+				}
+			}
+		}
+	}
+	for _, v := range g.LayerViolations() {
+		flagged[[2]string{v.From, v.To}] = true
+	}
+	return flagged
+}
+
+// DOT renders the package graph in Graphviz DOT, with cycles and layering violations in red
+func (g *Graph) DOT() string {
+	var b strings.Builder
+	flagged := g.flagged()
+	fmt.Fprintf(&b, "digraph %q {\n\trankdir=LR;\n\tnode [shape=box];\n", g.module)
+	for _, pkg := range g.Packages() {
+		fmt.Fprintf(&b, "\t%q;\n", g.label(pkg))
+	}
+	for _, pkg := range g.Packages() {
+		for _, imported := range g.Imports(pkg) {
+			attrs := ""
+			if flagged[[2]string{pkg, imported}] {
+				attrs = " [color=red]"
+			}
+			fmt.Fprintf(&b, "\t%q -> %q%s;\n", g.label(pkg), g.label(imported), attrs)
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Mermaid renders the package graph as a Mermaid flowchart, with cycles and layering violations
+// in red
+func (g *Graph) Mermaid() string {
+	var b strings.Builder
+	flagged := g.flagged()
+	b.WriteString("flowchart LR\n")
+	ids := make(map[string]string)
+	for i, pkg := range g.Packages() {
+		ids[pkg] = fmt.Sprintf("p%d", i)
+		fmt.Fprintf(&b, "    %s[\"%s\"]\n", ids[pkg], g.label(pkg))
+	}
+	var red []string
+	link := 0
+	for _, pkg := range g.Packages() {
+		for _, imported := range g.Imports(pkg) {
+			fmt.Fprintf(&b, "    %s --> %s\n", ids[pkg], ids[imported])
+			if flagged[[2]string{pkg, imported}] {
+				red = append(red, fmt.Sprint(link))
+			}
+			link++
+		}
+	}
+	if len(red) > 0 {
+		fmt.Fprintf(&b, "    linkStyle %s stroke:red\n", strings.Join(red, ","))
+	}
+	return b.String()
+}
+
+// label names a package in a rendered graph; the root package takes the module's last element
+func (g *Graph) label(pkg string) string {
+	if pkg == "." {
+		return path.Base(g.module)
+	}
+	return pkg
+}
+
+// rel returns a package's path relative to the module root, "." for the root package
+func (g *Graph) rel(pkg string) string {
+	if pkg == g.module {
+		return "."
+	}
+	return strings.TrimPrefix(pkg, g.module+"/")
+}
+
+// abs returns the import path of a package relative to the module root
+func (g *Graph) abs(pkg string) string {
+	if pkg == "." {
+		return g.module
+	}
+	return g.module + "/" + pkg
+}
@@ -0,0 +1,281 @@
+package language
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/ignore"
+)
+
+const (
+	// profileFileName is the name of the cached profile in the project directory
+	profileFileName = "language.json"
+	// profileTTL is how long a cached profile is trusted before re-detection
+	profileTTL = 24 * time.Hour
+	// maxScannedFiles limits the number of files inspected during detection
+	maxScannedFiles = 5000
+)
+
+// extensionLanguages maps file extensions to language names
+var extensionLanguages = map[string]string{
+	".go":    "Go",
+	".py":    "Python",
+	".js":    "JavaScript",
+	".jsx":   "JavaScript",
+	".mjs":   "JavaScript",
+	".ts":    "TypeScript",
+	".tsx":   "TypeScript",
+	".rs":    "Rust",
+	".java":  "Java",
+	".kt":    "Kotlin",
+	".rb":    "Ruby",
+	".php":   "PHP",
+	".cs":    "C#",
+	".c":     "C",
+	".h":     "C",
+	".cpp":   "C++",
+	".cc":    "C++",
+	".hpp":   "C++",
+	".swift": "Swift",
+	".scala": "Scala",
+	".ex":    "Elixir",
+	".exs":   "Elixir",
+	".dart":  "Dart",
+	".lua":   "Lua",
+	".sh":    "Shell",
+}
+
+// manifestLanguages maps manifest files to the language they imply
+var manifestLanguages = map[string]string{
+	"go.mod":           "Go",
+	"package.json":     "JavaScript",
+	"tsconfig.json":    "TypeScript",
+	"requirements.txt": "Python",
+	"pyproject.toml":   "Python",
+	"setup.py":         "Python",
+	"Pipfile":          "Python",
+	"Cargo.toml":       "Rust",
+	"pom.xml":          "Java",
+	"build.gradle":     "Java",
+	"Gemfile":          "Ruby",
+	"composer.json":    "PHP",
+	"mix.exs":          "Elixir",
+	"pubspec.yaml":     "Dart",
+	"Package.swift":    "Swift",
+}
+
+// manifestFrameworks maps manifest files to dependency names and the framework they indicate
+var manifestFrameworks = map[string]map[string]string{
+	"package.json": {
+		"\"react\"":         "React",
+		"\"next\"":          "Next.js",
+		"\"vue\"":           "Vue",
+		"\"svelte\"":        "Svelte",
+		"\"express\"":       "Express",
+		"\"@angular/core\"": "Angular",
+	},
+	"requirements.txt": {
+		"django":  "Django",
+		"flask":   "Flask",
+		"fastapi": "FastAPI",
+	},
+	"pyproject.toml": {
+		"django":  "Django",
+		"flask":   "Flask",
+		"fastapi": "FastAPI",
+	},
+	"go.mod": {
+		"github.com/spf13/cobra":   "Cobra",
+		"github.com/gin-gonic/gin": "Gin",
+		"github.com/labstack/echo": "Echo",
+		"github.com/gofiber/fiber": "Fiber",
+	},
+	"Gemfile": {
+		"rails": "Rails",
+	},
+	"Cargo.toml": {
+		"tokio": "Tokio",
+		"actix": "Actix",
+	},
+}
+
+// Profile describes the languages and frameworks used by a project
+type Profile struct {
+	Languages  []string  `json:"languages"`
+	Frameworks []string  `json:"frameworks,omitempty"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// String returns a human-readable description of the profile for use in prompts
+func (p *Profile) String() string {
+	if p == nil || len(p.Languages) == 0 {
+		return ""
+	}
+
+	desc := fmt.Sprintf("Primary languages: %s", strings.Join(p.Languages, ", "))
+	if len(p.Frameworks) > 0 {
+		desc += fmt.Sprintf("\nFrameworks: %s", strings.Join(p.Frameworks, ", "))
+	}
+	return desc
+}
+
+// ForFile returns the language of a source file judged by its extension, or "" when it is not source code
+func ForFile(path string) string {
+	return extensionLanguages[strings.ToLower(filepath.Ext(path))]
+}
+
+// Detect inspects file extensions and manifests under rootPath to build a profile
+func Detect(rootPath string) (*Profile, error) {
+	ignorePatterns, err := ignore.LoadGitignorePatterns(rootPath)
+	if err != nil {
+		return nil, fmt.Errorf("error loading ignore patterns: %w", err)
+	}
+
+	counts := make(map[string]int)
+	frameworks := make(map[string]bool)
+	scanned := 0
+
+	err = filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(rootPath, path)
+		if err != nil {
+			return err
+		}
+
+		if relPath != "." && ignore.ShouldIgnore(relPath, ignorePatterns) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		if lang, ok := extensionLanguages[strings.ToLower(filepath.Ext(path))]; ok {
+			counts[lang]++
+		}
+
+		// Manifests weigh more than a single source file
+		if lang, ok := manifestLanguages[info.Name()]; ok {
+			counts[lang] += 10
+			for _, fw := range detectFrameworks(path, info.Name()) {
+				frameworks[fw] = true
+			}
+		}
+
+		scanned++
+		if scanned >= maxScannedFiles {
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error walking project directory: %w", err)
+	}
+
+	profile := &Profile{
+		Languages:  rankLanguages(counts),
+		DetectedAt: time.Now(),
+	}
+	for fw := range frameworks {
+		profile.Frameworks = append(profile.Frameworks, fw)
+	}
+	sort.Strings(profile.Frameworks)
+
+	return profile, nil
+}
+
+// Load returns the cached profile for a project, detecting it again if missing or stale
+func Load(projectName string, rootPath string) (*Profile, error) {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+	cachePath := filepath.Join(dataDir, "projects", projectName, profileFileName)
+
+	if data, err := os.ReadFile(cachePath); err == nil {
+		var profile Profile
+		if err := json.Unmarshal(data, &profile); err == nil && time.Since(profile.DetectedAt) < profileTTL {
+			return &profile, nil
+		}
+	}
+
+	profile, err := Detect(rootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
+		return nil, fmt.Errorf("error creating project directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(profile, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling language profile: %w", err)
+	}
+
+	if err := os.WriteFile(cachePath, data, 0644); err != nil {
+		return nil, fmt.Errorf("error writing language profile: %w", err)
+	}
+
+	return profile, nil
+}
+
+// detectFrameworks scans a manifest file for known framework dependencies
+func detectFrameworks(path string, name string) []string {
+	markers, ok := manifestFrameworks[name]
+	if !ok {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	content := strings.ToLower(string(data))
+
+	var found []string
+	for marker, fw := range markers {
+		if strings.Contains(content, strings.ToLower(marker)) {
+			found = append(found, fw)
+		}
+	}
+	return found
+}
+
+// rankLanguages returns languages ordered by weight, dropping negligible ones
+func rankLanguages(counts map[string]int) []string {
+	total := 0
+	for _, c := range counts {
+		total += c
+	}
+
+	var langs []string
+	for lang, c := range counts {
+		// Ignore languages that make up less than 5% of the project
+		if total > 0 && c*20 < total {
+			continue
+		}
+		langs = append(langs, lang)
+	}
+
+	sort.Slice(langs, func(i, j int) bool {
+		if counts[langs[i]] != counts[langs[j]] {
+			return counts[langs[i]] > counts[langs[j]]
+		}
+		return langs[i] < langs[j]
+	})
+
+	return langs
+}
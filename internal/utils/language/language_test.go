@@ -0,0 +1,168 @@
+package language
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+)
+
+func TestForFile(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"main.go", "Go"},
+		{"web/App.TSX", "TypeScript"},
+		{"lib/util.mjs", "JavaScript"},
+		{"include/vec.hpp", "C++"},
+		{"README.md", ""},
+		{"Makefile", ""},
+	}
+	for _, tt := range tests {
+		if got := ForFile(tt.path); got != tt.want {
+			t.Errorf("ForFile(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name           string
+		files          map[string]string
+		wantLanguages  []string
+		wantFrameworks []string
+	}{
+		{
+			name:          "empty project",
+			files:         nil,
+			wantLanguages: nil,
+		},
+		{
+			name: "manifest outweighs source files",
+			files: map[string]string{
+				"go.mod":     "module example.com/app\n\nrequire github.com/spf13/cobra v1.8.0\n",
+				"main.go":    "package main",
+				"tool.py":    "print()",
+				"helper.py":  "print()",
+				"scripts.py": "print()",
+			},
+			wantLanguages:  []string{"Go", "Python"},
+			wantFrameworks: []string{"Cobra"},
+		},
+		{
+			name: "frameworks from package.json",
+			files: map[string]string{
+				"package.json": `{"dependencies": {"react": "^18.0.0", "next": "^14.0.0"}}`,
+				"src/app.jsx":  "",
+			},
+			wantLanguages:  []string{"JavaScript"},
+			wantFrameworks: []string{"Next.js", "React"},
+		},
+		{
+			name: "negligible languages are dropped",
+			files: map[string]string{
+				"Cargo.toml":     "[dependencies]\ntokio = \"1\"\n",
+				"src/a.rs":       "",
+				"src/b.rs":       "",
+				"src/c.rs":       "",
+				"src/d.rs":       "",
+				"src/e.rs":       "",
+				"src/f.rs":       "",
+				"src/g.rs":       "",
+				"src/h.rs":       "",
+				"src/i.rs":       "",
+				"src/j.rs":       "",
+				"scripts/run.sh": "",
+			},
+			wantLanguages:  []string{"Rust"},
+			wantFrameworks: []string{"Tokio"},
+		},
+		{
+			name: "ignored directories are skipped",
+			files: map[string]string{
+				".gitignore":       "vendor/\n",
+				"main.go":          "",
+				"vendor/a/a.rb":    "",
+				"vendor/a/b.rb":    "",
+				"vendor/a/c.rb":    "",
+				"vendor/a/Gemfile": "gem 'rails'",
+			},
+			wantLanguages: []string{"Go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for name, content := range tt.files {
+				path := filepath.Join(root, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			profile, err := Detect(root)
+			if err != nil {
+				t.Fatalf("Detect failed: %v", err)
+			}
+			if !reflect.DeepEqual(profile.Languages, tt.wantLanguages) {
+				t.Errorf("Languages = %v, want %v", profile.Languages, tt.wantLanguages)
+			}
+			if !reflect.DeepEqual(profile.Frameworks, tt.wantFrameworks) {
+				t.Errorf("Frameworks = %v, want %v", profile.Frameworks, tt.wantFrameworks)
+			}
+		})
+	}
+}
+
+func TestProfileString(t *testing.T) {
+	tests := []struct {
+		profile *Profile
+		want    string
+	}{
+		{nil, ""},
+		{&Profile{}, ""},
+		{&Profile{Languages: []string{"Go"}}, "Primary languages: Go"},
+		{&Profile{Languages: []string{"TypeScript", "Go"}, Frameworks: []string{"React"}}, "Primary languages: TypeScript, Go\nFrameworks: React"},
+	}
+	for _, tt := range tests {
+		if got := tt.profile.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestLoadCachesProfile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(config.DataDirEnv, t.TempDir())
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "main.go"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	profile, err := Load("app", root)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !reflect.DeepEqual(profile.Languages, []string{"Go"}) {
+		t.Fatalf("Languages = %v, want [Go]", profile.Languages)
+	}
+
+	// A fresh cached profile is used even after the project changes
+	if err := os.WriteFile(filepath.Join(root, "pyproject.toml"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	cached, err := Load("app", root)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !reflect.DeepEqual(cached.Languages, []string{"Go"}) {
+		t.Errorf("Expected the cached profile, got %v", cached.Languages)
+	}
+}
@@ -0,0 +1,246 @@
+package metrics
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bkidd1/wash-cli/internal/utils/chunk"
+	"github.com/bkidd1/wash-cli/internal/utils/language"
+)
+
+// listed is how many of the largest files and most complex functions are named
+const listed = 5
+
+// branchPattern finds the branches of JavaScript and TypeScript code, which is not parsed
+var branchPattern = regexp.MustCompile(`\b(?:if|for|while|case|catch)\b|&&|\|\||\?\?`)
+
+// Language is the size of the code in one language
+type Language struct {
+	Name  string
+	Files int
+	Lines int
+}
+
+// File is the size of one source file
+type File struct {
+	Path  string
+	Lines int
+}
+
+// Function is the cyclomatic complexity of a function, or of a top-level declaration in languages
+// that are not parsed
+type Function struct {
+	Path       string
+	Line       int
+	Name       string
+	Complexity int
+}
+
+// Metrics measure a project's code locally, without the model
+type Metrics struct {
+	// Files counts every file; Lines counts the non-blank lines of source files
+	Files     int
+	Lines     int
+	Languages []Language
+	// Largest are the source files with the most lines, largest first
+	Largest []File
+	// Functions and Complexity count the measured functions and their total complexity
+	Functions  int
+	Complexity int
+	// MostComplex are the functions of highest complexity, highest first
+	MostComplex []Function
+}
+
+// Compute measures the files of a project, given relative to root with forward slashes.
+// Complexity is measured for Go functions and JavaScript and TypeScript declarations.
+func Compute(root string, files []string) *Metrics {
+	m := &Metrics{Files: len(files)}
+	languages := make(map[string]*Language)
+	var sizes []File
+	var functions []Function
+
+	for _, file := range files {
+		lang := language.ForFile(file)
+		if lang == "" {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(file)))
+		if err != nil {
+			continue
+		}
+		lines := 0
+		for _, line := range strings.Split(string(content), "\n") {
+			if strings.TrimSpace(line) != "" {
+				lines++
+			}
+		}
+		if languages[lang] == nil {
+			languages[lang] = &Language{Name: lang}
+		}
+		languages[lang].Files++
+		languages[lang].Lines += lines
+		m.Lines += lines
+		sizes = append(sizes, File{Path: file, Lines: lines})
+		functions = append(functions, complexity(file, content)...)
+	}
+
+	for _, lang := range languages {
+		m.Languages = append(m.Languages, *lang)
+	}
+	sort.Slice(m.Languages, func(i, j int) bool {
+		if m.Languages[i].Lines != m.Languages[j].Lines {
+			return m.Languages[i].Lines > m.Languages[j].Lines
+		}
+		return m.Languages[i].Name < m.Languages[j].Name
+	})
+	sort.SliceStable(sizes, func(i, j int) bool { return sizes[i].Lines > sizes[j].Lines })
+	m.Largest = sizes[:min(len(sizes), listed)]
+
+	m.Functions = len(functions)
+	for _, fn := range functions {
+		m.Complexity += fn.Complexity
+	}
+	sort.SliceStable(functions, func(i, j int) bool { return functions[i].Complexity > functions[j].Complexity })
+	m.MostComplex = functions[:min(len(functions), listed)]
+	return m
+}
+
+// AverageComplexity is the mean complexity of the measured functions, or 0 when there are none
+func (m *Metrics) AverageComplexity() float64 {
+	if m.Functions == 0 {
+		return 0
+	}
+	return float64(m.Complexity) / float64(m.Functions)
+}
+
+// String formats the metrics as the list shown in the project report and sent with the prompt
+func (m *Metrics) String() string {
+	var b strings.Builder
+	var langs []string
+	for _, lang := range m.Languages {
+		langs = append(langs, fmt.Sprintf("%s %s (%d lines)", lang.Name, count(lang.Files, "file"), lang.Lines))
+	}
+	fmt.Fprintf(&b, "- %s, %d lines of code", count(m.Files, "file"), m.Lines)
+	if len(langs) > 0 {
+		fmt.Fprintf(&b, ": %s", strings.Join(langs, ", "))
+	}
+	b.WriteString("\n")
+
+	if len(m.Largest) > 0 {
+		var largest []string
+		for _, file := range m.Largest {
+			largest = append(largest, fmt.Sprintf("%s (%d lines)", file.Path, file.Lines))
+		}
+		fmt.Fprintf(&b, "- Largest files: %s\n", strings.Join(largest, ", "))
+	}
+	if m.Functions > 0 {
+		var complex []string
+		for _, fn := range m.MostComplex {
+			complex = append(complex, fmt.Sprintf("%s in %s:%d (%d)", fn.Name, fn.Path, fn.Line, fn.Complexity))
+		}
+		fmt.Fprintf(&b, "- Cyclomatic complexity: %.1f on average over %s; highest: %s\n",
+			m.AverageComplexity(), count(m.Functions, "function"), strings.Join(complex, ", "))
+	}
+	return b.String()
+}
+
+// count formats n of a noun, pluralized with an s
+func count(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// complexity measures the functions of a Go file, or the top-level declarations of a JavaScript or
+// TypeScript file; other languages are not measured
+func complexity(file string, content []byte) []Function {
+	if strings.ToLower(path.Ext(file)) == ".go" {
+		return goComplexity(file, content)
+	}
+
+	var functions []Function
+	lines := strings.Split(string(content), "\n")
+	for _, decl := range chunk.Declarations(file, content) {
+		if decl.Name == "import" || !strings.ContainsAny(strings.Join(lines[decl.Start:min(decl.End, len(lines))], "\n"), "{") {
+			continue
+		}
+		branches := 0
+		for _, line := range lines[decl.Start:min(decl.End, len(lines))] {
+			if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "*") {
+				continue
+			}
+			branches += len(branchPattern.FindAllString(line, -1))
+		}
+		functions = append(functions, Function{Path: file, Line: decl.Start + 1, Name: decl.Name, Complexity: 1 + branches})
+	}
+	return functions
+}
+
+// goComplexity measures each function and method of a Go file: one plus a point for every if, for,
+// case, select case and && or || condition
+func goComplexity(file string, content []byte) []Function {
+	fset := token.NewFileSet()
+	parsed, err := parser.ParseFile(fset, file, content, parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+
+	var functions []Function
+	for _, decl := range parsed.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		score := 1
+		ast.Inspect(fn.Body, func(node ast.Node) bool {
+			switch n := node.(type) {
+			case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+				score++
+			case *ast.CaseClause:
+				if n.List != nil {
+					score++
+				}
+			case *ast.CommClause:
+				if n.Comm != nil {
+					score++
+				}
+			case *ast.BinaryExpr:
+				if n.Op == token.LAND || n.Op == token.LOR {
+					score++
+				}
+			}
+			return true
+		})
+
+		name := fn.Name.Name
+		if fn.Recv != nil && len(fn.Recv.List) > 0 {
+			name = receiverType(fn.Recv.List[0].Type) + "." + name
+		}
+		functions = append(functions, Function{Path: file, Line: fset.Position(fn.Pos()).Line, Name: name, Complexity: score})
+	}
+	return functions
+}
+
+// receiverType returns the type name of a method receiver without its pointer or type parameters
+func receiverType(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverType(t.X)
+	case *ast.IndexExpr:
+		return receiverType(t.X)
+	case *ast.IndexListExpr:
+		return receiverType(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return "?"
+}
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompute(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"main.go": `package main
+
+// run has a complexity of 5
+func run(args []string) error {
+	for _, arg := range args {
+		if arg == "" || arg == "-" {
+			continue
+		}
+		switch arg {
+		case "help":
+			return nil
+		default:
+		}
+	}
+	return nil
+}
+
+func (s *server) Close() {}
+`,
+		"web/app.js": "import x from 'x'\n\nfunction handle(req) {\n  if (req && req.ok) {\n    return 1\n  }\n}\n",
+		"README.md":  "# Demo\n",
+	}
+	var paths []string
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, name)
+	}
+
+	m := Compute(root, paths)
+	if m.Files != 3 || len(m.Languages) != 2 || m.Languages[0].Name != "Go" || m.Languages[0].Lines != 16 {
+		t.Errorf("Unexpected sizes: %+v", m)
+	}
+	if m.Largest[0].Path != "main.go" {
+		t.Errorf("Expected main.go to be the largest file, got %+v", m.Largest)
+	}
+	if m.Functions != 3 || m.MostComplex[0] != (Function{Path: "main.go", Line: 4, Name: "run", Complexity: 5}) {
+		t.Errorf("Unexpected complexity: %+v", m.MostComplex)
+	}
+	if m.MostComplex[1].Name != "function handle" || m.MostComplex[1].Complexity != 3 {
+		t.Errorf("Unexpected JavaScript complexity: %+v", m.MostComplex[1])
+	}
+	if got := m.String(); !strings.Contains(got, "- 3 files, 22 lines of code: Go 1 file (16 lines), JavaScript 1 file (6 lines)") ||
+		!strings.Contains(got, "run in main.go:4 (5)") {
+		t.Errorf("Unexpected summary:\n%s", got)
+	}
+}
@@ -0,0 +1,133 @@
+package patch
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// hunkHeaderPattern reads the old-file start line of a hunk header such as @@ -10,4 +12,6 @@
+var hunkHeaderPattern = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+\d+(?:,\d+)? @@`)
+
+// Hunk is one change of a unified diff: the lines it replaces, with their context, and the lines
+// that replace them
+type Hunk struct {
+	// OldStart is the 1-based line the hunk claims to start at; it is only a hint for matching
+	OldStart int
+	Old      []string
+	New      []string
+}
+
+// Parse reads the hunks of a unified diff of one file. File headers are skipped, and an empty line
+// inside a hunk is read as an empty context line, which models often write without the space.
+func Parse(diff string) ([]Hunk, error) {
+	var hunks []Hunk
+	var hunk *Hunk
+	lines := strings.Split(strings.TrimRight(diff, "\n"), "\n")
+
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			match := hunkHeaderPattern.FindStringSubmatch(line)
+			start := 0
+			if match != nil {
+				start, _ = strconv.Atoi(match[1])
+			}
+			hunks = append(hunks, Hunk{OldStart: start})
+			hunk = &hunks[len(hunks)-1]
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "),
+			strings.HasPrefix(line, "+++ ") && i > 0 && strings.HasPrefix(lines[i-1], "--- "),
+			strings.HasPrefix(line, "diff "), strings.HasPrefix(line, "index "), strings.HasPrefix(line, `\`):
+			continue
+		case hunk == nil:
+			continue
+		case line == "":
+			hunk.Old = append(hunk.Old, "")
+			hunk.New = append(hunk.New, "")
+		case line[0] == ' ':
+			hunk.Old = append(hunk.Old, line[1:])
+			hunk.New = append(hunk.New, line[1:])
+		case line[0] == '-':
+			hunk.Old = append(hunk.Old, line[1:])
+		case line[0] == '+':
+			hunk.New = append(hunk.New, line[1:])
+		default:
+			return nil, fmt.Errorf("unexpected line in hunk %d: %q", len(hunks), line)
+		}
+	}
+
+	if len(hunks) == 0 {
+		return nil, fmt.Errorf("no hunks in diff")
+	}
+	for i, h := range hunks {
+		if len(h.Old) == 0 {
+			return nil, fmt.Errorf("hunk %d has no context lines to place it", i+1)
+		}
+	}
+	return hunks, nil
+}
+
+// Apply applies hunks to content in order. Each hunk is placed where its context and removed lines
+// match the content, ignoring trailing whitespace, nearest to the line its header claims; the
+// line numbers of model-written diffs are often off.
+func Apply(content string, hunks []Hunk) (string, error) {
+	return ApplyWithin(content, hunks, 0, strings.Count(content, "\n")+1)
+}
+
+// ApplyWithin applies hunks written against lines start to end (zero-based, end exclusive) of
+// content, as when only part of a file was shown: header line numbers count from start, and hunks
+// only match inside the range. A hunk of a single line that matches more than one place is
+// rejected, since there is no context to tell which was meant.
+func ApplyWithin(content string, hunks []Hunk, start, end int) (string, error) {
+	lines := strings.Split(content, "\n")
+	end = min(end, len(lines))
+	from, delta := start, 0
+
+	for i, hunk := range hunks {
+		want := start + hunk.OldStart - 1 + delta
+		at, found := -1, 0
+		for j := from; j+len(hunk.Old) <= end+delta; j++ {
+			if !matches(lines[j:j+len(hunk.Old)], hunk.Old) {
+				continue
+			}
+			found++
+			if at < 0 || abs(j-want) < abs(at-want) {
+				at = j
+			}
+		}
+		if at < 0 {
+			return "", fmt.Errorf("hunk %d does not match the file", i+1)
+		}
+		if found > 1 && len(hunk.Old) == 1 {
+			return "", fmt.Errorf("hunk %d matches %d lines and has no context to tell which", i+1, found)
+		}
+
+		replaced := make([]string, 0, len(lines)-len(hunk.Old)+len(hunk.New))
+		replaced = append(replaced, lines[:at]...)
+		replaced = append(replaced, hunk.New...)
+		replaced = append(replaced, lines[at+len(hunk.Old):]...)
+		lines = replaced
+
+		from = at + len(hunk.New)
+		delta += len(hunk.New) - len(hunk.Old)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// matches compares lines ignoring trailing whitespace
+func matches(lines, want []string) bool {
+	for i := range want {
+		if strings.TrimRight(lines[i], " \t\r") != strings.TrimRight(want[i], " \t\r") {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,69 @@
+package patch
+
+import "testing"
+
+func TestApplyWithWrongLineNumbers(t *testing.T) {
+	content := "package main\n\nfunc main() {\n\tf, _ := os.Open(name)\n\tuse(f)\n}\n"
+	// The header claims line 40, but the hunk is at line 3
+	diff := `--- a/main.go
++++ b/main.go
+@@ -40,4 +40,8 @@
+ func main() {
+-	f, _ := os.Open(name)
++	f, err := os.Open(name)
++	if err != nil {
++		log.Fatal(err)
++	}
+ 	use(f)
+ }
+`
+	hunks, err := Parse(diff)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	got, err := Apply(content, hunks)
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	want := "package main\n\nfunc main() {\n\tf, err := os.Open(name)\n\tif err != nil {\n\t\tlog.Fatal(err)\n\t}\n\tuse(f)\n}\n"
+	if got != want {
+		t.Errorf("Unexpected result:\n%s", got)
+	}
+
+	if _, err := Apply("package other\n", hunks); err == nil {
+		t.Error("Expected a hunk that does not match to fail")
+	}
+}
+
+func TestApplyWithinRange(t *testing.T) {
+	// The same line appears in both functions; only the second was shown to the model, from line 4
+	content := "func a() {\n\treturn nil\n}\nfunc b() {\n\tx := 1\n\treturn nil\n}\n"
+	hunks, err := Parse("@@ -2,2 +2,2 @@\n \tx := 1\n-\treturn nil\n+\treturn err\n")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	got, err := ApplyWithin(content, hunks, 3, 7)
+	if err != nil {
+		t.Fatalf("ApplyWithin failed: %v", err)
+	}
+	if want := "func a() {\n\treturn nil\n}\nfunc b() {\n\tx := 1\n\treturn err\n}\n"; got != want {
+		t.Errorf("Unexpected result:\n%s", got)
+	}
+
+	// A hunk can not reach outside the range, even where it would match
+	if _, err := ApplyWithin(content, hunks, 0, 3); err == nil {
+		t.Error("Expected a hunk outside the range to fail")
+	}
+
+	// A single changed line with no context that occurs twice cannot be placed
+	bare, err := Parse("@@ -6 +6 @@\n-\treturn nil\n+\treturn err\n")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if _, err := Apply(content, bare); err == nil {
+		t.Error("Expected an ambiguous single-line hunk to fail")
+	}
+	if _, err := ApplyWithin(content, bare, 3, 7); err != nil {
+		t.Errorf("Expected the single-line hunk to apply where it is unique: %v", err)
+	}
+}
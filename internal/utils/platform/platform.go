@@ -30,9 +30,7 @@ func IsSupported() bool {
 
 // SupportsWindowCapture returns whether the current OS supports window-specific screenshot capture
 func SupportsWindowCapture() bool {
-	// Currently, only macOS has reliable window capture support
-	// Linux and Windows implementations might be less reliable or require additional setup
-	return CurrentOS() == Darwin
+	return IsSupported()
 }
 
 // GetOSName returns a human-readable name for the current OS
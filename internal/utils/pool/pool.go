@@ -0,0 +1,36 @@
+package pool
+
+import "sync"
+
+// DefaultWorkers is how many tasks run at once when no worker count is configured
+const DefaultWorkers = 4
+
+// Workers returns the configured worker count, or DefaultWorkers when it is zero or less
+func Workers(configured int) int {
+	if configured <= 0 {
+		return DefaultWorkers
+	}
+	return configured
+}
+
+// Run calls task for every index below count, with at most workers calls running at once, and
+// returns when all have returned. Tasks start in index order.
+func Run(workers, count int, task func(i int)) {
+	workers = min(max(workers, 1), count)
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				task(i)
+			}
+		}()
+	}
+	for i := 0; i < count; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
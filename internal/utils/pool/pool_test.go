@@ -0,0 +1,37 @@
+package pool
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRunLimitsConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	running, peak := 0, 0
+	done := make([]bool, 20)
+
+	Run(3, len(done), func(i int) {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		done[i] = true
+		mu.Unlock()
+	})
+
+	if peak != 3 {
+		t.Errorf("Expected 3 tasks at once, got %d", peak)
+	}
+	for i, ok := range done {
+		if !ok {
+			t.Errorf("Task %d did not run", i)
+		}
+	}
+	Run(4, 0, func(int) { t.Error("Expected no tasks") })
+}
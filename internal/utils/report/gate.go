@@ -0,0 +1,65 @@
+package report
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// FailOnLevels are the severities --fail-on accepts, most severe first
+var FailOnLevels = []string{"critical", "should", "could"}
+
+// levelNames name each --fail-on level as the analysis does
+var levelNames = map[string]string{
+	"critical": "Critical! Must Fix",
+	"should":   "Should Fix",
+	"could":    "Could Fix",
+}
+
+// GateError reports findings at or above the --fail-on level. Commands exit with its ExitCode,
+// which differs from the 1 of other errors so CI can tell findings from failures.
+type GateError struct {
+	Level string
+	Count int
+}
+
+func (e *GateError) Error() string {
+	noun := "findings"
+	if e.Count == 1 {
+		noun = "finding"
+	}
+	return fmt.Sprintf("%d %s at or above %s (--fail-on %s)", e.Count, noun, levelNames[e.Level], e.Level)
+}
+
+// ExitCode is the exit status of a run that failed the gate
+func (e *GateError) ExitCode() int {
+	return 2
+}
+
+// CheckFailOn rejects an unknown --fail-on level; an empty level turns the gate off
+func CheckFailOn(level string) error {
+	if level != "" && !slices.Contains(FailOnLevels, level) {
+		return fmt.Errorf("unknown --fail-on level %q: use one of %s", level, strings.Join(FailOnLevels, ", "))
+	}
+	return nil
+}
+
+// FailOn returns a *GateError when there are findings at or above level, or nil when there are
+// none or level is empty
+func FailOn(level string, critical, shouldFix, couldFix int) error {
+	count := 0
+	switch level {
+	case "could":
+		count += couldFix
+		fallthrough
+	case "should":
+		count += shouldFix
+		fallthrough
+	case "critical":
+		count += critical
+	}
+	if count == 0 {
+		return nil
+	}
+	return &GateError{Level: level, Count: count}
+}
@@ -0,0 +1,215 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formats are the output formats of an analysis report; text is the terminal output
+var Formats = []string{"text", "json", "sarif", "markdown"}
+
+// Severities of findings, as named in the analysis
+const (
+	Critical  = "critical"
+	ShouldFix = "should_fix"
+	CouldFix  = "could_fix"
+)
+
+var (
+	// linePattern finds explicit line references such as "line 42" or "lines 42-48"
+	linePattern = regexp.MustCompile(`(?i)\blines?\s+(\d+)`)
+	// codePattern finds code quoted in backticks
+	codePattern = regexp.MustCompile("`([^`\n]+)`")
+	// identifierPattern finds words that look like code, such as f.Close() or parse_args
+	identifierPattern = regexp.MustCompile(`[A-Za-z_][\w]*(?:\.[A-Za-z_]\w*)*\(?`)
+)
+
+// Finding is one issue of an analysis, with the line it refers to when that can be told
+type Finding struct {
+	Message string `json:"message"`
+	// Line is 1-based, or 0 when the finding could not be attributed to a line
+	Line int `json:"line,omitempty"`
+}
+
+// File is the analysis of one file, or the error that stopped it
+type File struct {
+	Path string `json:"path"`
+	// StartLine and EndLine are the 1-based, inclusive lines that were analyzed
+	StartLine      int       `json:"start_line,omitempty"`
+	EndLine        int       `json:"end_line,omitempty"`
+	CriticalIssues []Finding `json:"critical_issues"`
+	ShouldFix      []Finding `json:"should_fix"`
+	CouldFix       []Finding `json:"could_fix"`
+	// Suppressed is the number of known findings left out, such as those in a baseline
+	Suppressed int    `json:"suppressed,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Report is the analyses of one run
+type Report struct {
+	Tool        string    `json:"tool"`
+	Version     string    `json:"version"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Files       []*File   `json:"files"`
+}
+
+// New creates an empty report
+func New(version string) *Report {
+	return &Report{Tool: "wash", Version: version, GeneratedAt: time.Now(), Files: []*File{}}
+}
+
+// NewFile attributes the findings of an analysis of lines[start:end] (zero-based, end exclusive)
+// to the lines they refer to
+func NewFile(path string, lines []string, start, end int, critical, shouldFix, couldFix []string) *File {
+	end = min(end, len(lines))
+	attribute := func(messages []string) []Finding {
+		findings := make([]Finding, 0, len(messages))
+		for _, message := range messages {
+			findings = append(findings, Finding{Message: message, Line: Locate(message, lines, start, end)})
+		}
+		return findings
+	}
+	return &File{
+		Path:           filepath.ToSlash(path),
+		StartLine:      start + 1,
+		EndLine:        end,
+		CriticalIssues: attribute(critical),
+		ShouldFix:      attribute(shouldFix),
+		CouldFix:       attribute(couldFix),
+	}
+}
+
+// Failed records a file whose analysis failed
+func Failed(path string, err error) *File {
+	return &File{Path: filepath.ToSlash(path), CriticalIssues: []Finding{}, ShouldFix: []Finding{}, CouldFix: []Finding{}, Error: err.Error()}
+}
+
+// Locate returns the 1-based line of lines[start:end] a finding refers to, or 0 if it cannot be
+// told. Findings do not carry lines, so this looks for an explicit "line N" first, then for the
+// code the finding quotes in backticks, then for words that look like code, taking the first line
+// that contains it.
+func Locate(finding string, lines []string, start, end int) int {
+	end = min(end, len(lines))
+	if start < 0 || start >= end {
+		return 0
+	}
+
+	for _, match := range linePattern.FindAllStringSubmatch(finding, -1) {
+		if line, err := strconv.Atoi(match[1]); err == nil && line > start && line <= end {
+			return line
+		}
+	}
+
+	var candidates []string
+	for _, match := range codePattern.FindAllStringSubmatch(finding, -1) {
+		candidates = append(candidates, strings.TrimSpace(match[1]))
+	}
+	var words []string
+	for _, word := range identifierPattern.FindAllString(codePattern.ReplaceAllString(finding, ""), -1) {
+		if looksLikeCode(word) {
+			words = append(words, word)
+		}
+	}
+	// Longer words are more specific
+	sort.SliceStable(words, func(i, j int) bool { return len(words[i]) > len(words[j]) })
+	candidates = append(candidates, words...)
+
+	for _, candidate := range candidates {
+		if len(candidate) < 3 {
+			continue
+		}
+		for i := start; i < end; i++ {
+			if strings.Contains(lines[i], candidate) {
+				return i + 1
+			}
+		}
+	}
+	return 0
+}
+
+// looksLikeCode reports whether a word of prose is likely an identifier: a call, a selector,
+// snake_case or an inner capital letter
+func looksLikeCode(word string) bool {
+	if strings.ContainsAny(word, "(._") {
+		return true
+	}
+	for i := 1; i < len(word); i++ {
+		if word[i] >= 'A' && word[i] <= 'Z' && word[i-1] >= 'a' && word[i-1] <= 'z' {
+			return true
+		}
+	}
+	return false
+}
+
+// Render formats the report as json, sarif or markdown
+func (r *Report) Render(format string) (string, error) {
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("error marshaling report: %w", err)
+		}
+		return string(data) + "\n", nil
+	case "sarif":
+		data, err := json.MarshalIndent(r.sarif(), "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("error marshaling report: %w", err)
+		}
+		return string(data) + "\n", nil
+	case "markdown":
+		return r.Markdown(), nil
+	}
+	return "", fmt.Errorf("unknown output format %q: use one of %s", format, strings.Join(Formats, ", "))
+}
+
+// Markdown formats the report with a section per file and the line of each finding
+func (r *Report) Markdown() string {
+	var b strings.Builder
+	if len(r.Files) == 1 {
+		fmt.Fprintf(&b, "# Code Analysis: %s\n", r.Files[0].Path)
+	} else {
+		fmt.Fprintf(&b, "# Code Analysis: %d Files\n", len(r.Files))
+	}
+	fmt.Fprintf(&b, "*Generated on %s*\n", r.GeneratedAt.Format("2006-01-02 15:04:05"))
+
+	for _, file := range r.Files {
+		if len(r.Files) > 1 {
+			fmt.Fprintf(&b, "\n## %s\n", file.Path)
+		}
+		if file.Error != "" {
+			fmt.Fprintf(&b, "\nAnalysis failed: %s\n", file.Error)
+			continue
+		}
+		fmt.Fprintf(&b, "\nLines %d-%d\n", file.StartLine, file.EndLine)
+		writeSection(&b, "Critical! Must Fix", file.CriticalIssues, len(r.Files) > 1)
+		writeSection(&b, "Should Fix", file.ShouldFix, len(r.Files) > 1)
+		writeSection(&b, "Could Fix", file.CouldFix, len(r.Files) > 1)
+	}
+	return b.String()
+}
+
+// writeSection writes the findings of one severity, one level below the file heading
+func writeSection(b *strings.Builder, heading string, findings []Finding, nested bool) {
+	level := "##"
+	if nested {
+		level = "###"
+	}
+	fmt.Fprintf(b, "\n%s %s\n", level, heading)
+	if len(findings) == 0 {
+		b.WriteString("No issues found\n")
+		return
+	}
+	for _, finding := range findings {
+		if finding.Line > 0 {
+			fmt.Fprintf(b, "- Line %d: %s\n", finding.Line, finding.Message)
+		} else {
+			fmt.Fprintf(b, "- %s\n", finding.Message)
+		}
+	}
+}
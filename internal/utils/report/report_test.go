@@ -0,0 +1,98 @@
+package report
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestNewFileAttributesFindings(t *testing.T) {
+	lines := strings.Split(`package main
+
+func main() {
+	f, _ := os.Open("data.txt")
+	defer f.Close()
+	parseArgs(os.Args)
+}`, "\n")
+
+	file := NewFile("cmd/main.go", lines, 0, len(lines),
+		[]string{"Is the error from `os.Open` ignored?", "What happens on line 6 when no arguments are given?"},
+		[]string{"Should the error from f.Close() be checked?"},
+		[]string{"Could the whole program be simpler?"})
+
+	if file.Path != "cmd/main.go" || file.StartLine != 1 || file.EndLine != 7 {
+		t.Errorf("Unexpected file attribution: %+v", file)
+	}
+	for _, c := range []struct {
+		finding Finding
+		want    int
+	}{
+		{file.CriticalIssues[0], 4},
+		{file.CriticalIssues[1], 6},
+		{file.ShouldFix[0], 5},
+		{file.CouldFix[0], 0},
+	} {
+		if c.finding.Line != c.want {
+			t.Errorf("Expected %q on line %d, got %d", c.finding.Message, c.want, c.finding.Line)
+		}
+	}
+
+	// Only the analyzed lines are searched
+	if line := Locate("Is `f.Close()` deferred?", lines, 0, 3); line != 0 {
+		t.Errorf("Expected no line outside the analyzed range, got %d", line)
+	}
+}
+
+func TestRenderSARIF(t *testing.T) {
+	r := New("1.2.3")
+	r.Files = append(r.Files,
+		NewFile("main.go", []string{"package main", "", "func run() {}"}, 0, 3, []string{"Does `run` need a context?"}, nil, []string{"Could it be simpler?"}))
+
+	out, err := r.Render("sarif")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal([]byte(out), &log); err != nil {
+		t.Fatalf("Invalid SARIF: %v", err)
+	}
+	results := log.Runs[0].Results
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	if results[0].RuleID != Critical || results[0].Level != "error" || results[0].Locations[0].PhysicalLocation.Region.StartLine != 3 {
+		t.Errorf("Unexpected critical result: %+v", results[0])
+	}
+	// Unattributed findings point at the first analyzed line
+	if results[1].Level != "note" || results[1].Locations[0].PhysicalLocation.Region.StartLine != 1 {
+		t.Errorf("Unexpected could fix result: %+v", results[1])
+	}
+
+	if _, err := r.Render("xml"); err == nil {
+		t.Error("Expected an unknown format to be rejected")
+	}
+}
+
+func TestFailOn(t *testing.T) {
+	for _, c := range []struct {
+		level string
+		want  int
+	}{
+		{"", 0},
+		{"critical", 0},
+		{"should", 2},
+		{"could", 5},
+	} {
+		err := FailOn(c.level, 0, 2, 3)
+		var count int
+		if gate, ok := err.(*GateError); ok {
+			count = gate.Count
+		}
+		if count != c.want {
+			t.Errorf("FailOn(%q) = %v, want %d findings", c.level, err, c.want)
+		}
+	}
+	if err := CheckFailOn("major"); err == nil {
+		t.Error("Expected an unknown level to be rejected")
+	}
+}
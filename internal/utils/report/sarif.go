@@ -0,0 +1,106 @@
+package report
+
+// sarifSchema is the SARIF 2.1.0 schema, the version code scanning tools read
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// rules describe each severity as a SARIF rule, with the level its results are reported at
+var rules = []struct {
+	id, name, level string
+}{
+	{Critical, "Critical! Must Fix", "error"},
+	{ShouldFix, "Should Fix", "warning"},
+	{CouldFix, "Could Fix", "note"},
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string       `json:"id"`
+	Name                 string       `json:"name"`
+	ShortDescription     sarifMessage `json:"shortDescription"`
+	DefaultConfiguration struct {
+		Level string `json:"level"`
+	} `json:"defaultConfiguration"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+		Region struct {
+			StartLine int `json:"startLine"`
+		} `json:"region"`
+	} `json:"physicalLocation"`
+}
+
+// sarif converts the report to a SARIF log. Findings that could not be attributed to a line are
+// reported at the first analyzed line, since code scanning tools need a location for each result.
+func (r *Report) sarif() sarifLog {
+	driver := sarifDriver{Name: r.Tool, Version: r.Version, InformationURI: "https://github.com/bkidd1/wash-cli"}
+	for _, rule := range rules {
+		sr := sarifRule{ID: rule.id, Name: rule.name, ShortDescription: sarifMessage{Text: rule.name}}
+		sr.DefaultConfiguration.Level = rule.level
+		driver.Rules = append(driver.Rules, sr)
+	}
+
+	results := []sarifResult{}
+	for _, file := range r.Files {
+		if file.Error != "" {
+			continue
+		}
+		for i, findings := range [][]Finding{file.CriticalIssues, file.ShouldFix, file.CouldFix} {
+			for _, finding := range findings {
+				var location sarifLocation
+				location.PhysicalLocation.ArtifactLocation.URI = file.Path
+				location.PhysicalLocation.Region.StartLine = finding.Line
+				if finding.Line == 0 {
+					location.PhysicalLocation.Region.StartLine = max(file.StartLine, 1)
+				}
+				results = append(results, sarifResult{
+					RuleID:    rules[i].id,
+					Level:     rules[i].level,
+					Message:   sarifMessage{Text: finding.Message},
+					Locations: []sarifLocation{location},
+				})
+			}
+		}
+	}
+
+	return sarifLog{
+		Schema:  sarifSchema,
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	}
+}
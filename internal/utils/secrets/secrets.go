@@ -0,0 +1,85 @@
+package secrets
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Mask replaces each secret in redacted text
+const Mask = "[REDACTED]"
+
+// patterns match well-known credential formats; the whole match is the secret
+var patterns = []*regexp.Regexp{
+	// OpenAI, Anthropic and other sk- keys
+	regexp.MustCompile(`\bsk-(?:ant-|proj-)?[A-Za-z0-9_-]{20,}`),
+	// AWS access key IDs
+	regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+	// GitHub tokens
+	regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{30,}|github_pat_[A-Za-z0-9_]{40,})`),
+	// Slack tokens
+	regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`),
+	// Google API keys
+	regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`),
+	// Stripe secret and restricted keys
+	regexp.MustCompile(`\b[rs]k_live_[0-9A-Za-z]{20,}`),
+	// JSON Web Tokens
+	regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`),
+	// PEM private key headers
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`),
+}
+
+// assignment matches a value assigned to a name that says it is secret, as in .env files and
+// config: API_KEY=..., "password": "...", token: ...; the value is the secret
+var assignment = regexp.MustCompile(`(?i)\b[a-z0-9_.-]*(?:key|token|secret|passw(?:or)?d|pwd|credentials?|auth)[a-z0-9_.-]*["']?\s*[:=]\s*["']?([^\s"',;]{6,})`)
+
+// Span is the byte range of a secret in a text
+type Span struct {
+	Start, End int
+}
+
+// Find returns the spans of the secrets in a text, in order and without overlaps
+func Find(text string) []Span {
+	var spans []Span
+	for _, pattern := range patterns {
+		for _, loc := range pattern.FindAllStringIndex(text, -1) {
+			spans = append(spans, Span{loc[0], loc[1]})
+		}
+	}
+	for _, loc := range assignment.FindAllStringSubmatchIndex(text, -1) {
+		value := text[loc[2]:loc[3]]
+		// Leave out references rather than values, such as os.Getenv("API_KEY") or ${TOKEN}
+		if strings.ContainsAny(value, "$({") || value == Mask {
+			continue
+		}
+		spans = append(spans, Span{loc[2], loc[3]})
+	}
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i].Start < spans[j].Start })
+	var merged []Span
+	for _, span := range spans {
+		if n := len(merged); n > 0 && span.Start <= merged[n-1].End {
+			merged[n-1].End = max(merged[n-1].End, span.End)
+			continue
+		}
+		merged = append(merged, span)
+	}
+	return merged
+}
+
+// Redact replaces the secrets in a text with Mask, returning the text and how many were replaced
+func Redact(text string) (string, int) {
+	spans := Find(text)
+	if len(spans) == 0 {
+		return text, 0
+	}
+	var b strings.Builder
+	last := 0
+	for _, span := range spans {
+		b.WriteString(text[last:span.Start])
+		b.WriteString(Mask)
+		last = span.End
+	}
+	b.WriteString(text[last:])
+	return b.String(), len(spans)
+}
@@ -0,0 +1,200 @@
+package style
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// glob is a compiled editorconfig section pattern
+type glob struct {
+	re *regexp.Regexp
+	// ranges holds the bounds of each {num1..num2} in the pattern, in the order of their groups
+	ranges [][2]int
+}
+
+// numericRange matches the body of a {num1..num2} pattern
+var numericRange = regexp.MustCompile(`^([+-]?\d+)\.\.([+-]?\d+)$`)
+
+// compileGlob compiles an editorconfig section pattern. Patterns without a slash match a file
+// name in any directory; patterns with one are relative to the .editorconfig's directory.
+func compileGlob(pattern string) (*glob, error) {
+	g := &glob{}
+	anchored := strings.Contains(pattern, "/")
+	expr := g.translate(strings.TrimPrefix(pattern, "/"))
+	if anchored {
+		expr = "^" + expr + "$"
+	} else {
+		expr = "^(?:.*/)?" + expr + "$"
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	g.re = re
+	return g, nil
+}
+
+// match reports whether the pattern applies to path, a slash-separated path relative to the
+// .editorconfig's directory
+func (g *glob) match(path string) bool {
+	groups := g.re.FindStringSubmatchIndex(path)
+	if groups == nil {
+		return false
+	}
+	for i, bounds := range g.ranges {
+		// A range in an alternative that did not match is not checked
+		start, end := groups[2*i+2], groups[2*i+3]
+		if start < 0 {
+			continue
+		}
+		n, err := strconv.Atoi(path[start:end])
+		if err != nil || n < bounds[0] || n > bounds[1] {
+			return false
+		}
+	}
+	return true
+}
+
+// translate turns a pattern into a regular expression: * and ? stop at slashes, ** crosses them,
+// [...] and [!...] are character classes, {a,b} lists alternatives and {num1..num2} matches the
+// integers between them. A backslash escapes the next character.
+func (g *glob) translate(pattern string) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		case c == '/' && strings.HasPrefix(pattern[i:], "/**/"):
+			// a/**/b also matches a/b
+			b.WriteString("(?:/|/.*/)")
+			i += 3
+		case c == '*' && strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := classEnd(pattern, i)
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : end]
+			negate := strings.HasPrefix(class, "!")
+			class = strings.TrimPrefix(class, "!")
+			b.WriteString("[")
+			if negate {
+				b.WriteString("^")
+			}
+			b.WriteString(strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`).Replace(class))
+			b.WriteString("]")
+			i = end
+		case c == '{':
+			end := braceEnd(pattern, i)
+			if end < 0 {
+				b.WriteString(`\{`)
+				continue
+			}
+			b.WriteString(g.braces(pattern[i+1 : end]))
+			i = end
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	return b.String()
+}
+
+// braces translates the body of a {...} pattern. A body without a comma or a numeric range is
+// matched literally, braces included.
+func (g *glob) braces(body string) string {
+	if m := numericRange.FindStringSubmatch(body); m != nil {
+		low, _ := strconv.Atoi(m[1])
+		high, _ := strconv.Atoi(m[2])
+		if low > high {
+			low, high = high, low
+		}
+		g.ranges = append(g.ranges, [2]int{low, high})
+		return `([+-]?\d+)`
+	}
+
+	alternatives := splitAlternatives(body)
+	if len(alternatives) < 2 {
+		return regexp.QuoteMeta("{") + g.translate(body) + regexp.QuoteMeta("}")
+	}
+	translated := make([]string, len(alternatives))
+	for i, alternative := range alternatives {
+		translated[i] = g.translate(alternative)
+	}
+	return "(?:" + strings.Join(translated, "|") + ")"
+}
+
+// classEnd returns the index of the ] closing the character class at start, or -1 when there is
+// none. A class never spans a slash.
+func classEnd(pattern string, start int) int {
+	i := start + 1
+	if i < len(pattern) && pattern[i] == '!' {
+		i++
+	}
+	// A ] right after the opening bracket is part of the class
+	if i < len(pattern) && pattern[i] == ']' {
+		i++
+	}
+	for ; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case '/':
+			return -1
+		case ']':
+			return i
+		}
+	}
+	return -1
+}
+
+// braceEnd returns the index of the } closing the brace at start, or -1 when there is none
+func braceEnd(pattern string, start int) int {
+	depth := 0
+	for i := start; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitAlternatives splits the body of a brace pattern at its top-level commas
+func splitAlternatives(body string) []string {
+	var alternatives []string
+	depth, last := 0, 0
+	for i := 0; i < len(body); i++ {
+		switch body[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				alternatives = append(alternatives, body[last:i])
+				last = i + 1
+			}
+		}
+	}
+	return append(alternatives, body[last:])
+}
@@ -0,0 +1,295 @@
+package style
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Settings holds the formatting settings that apply to a set of files
+type Settings struct {
+	IndentStyle        string `json:"indent_style,omitempty"` // "tab" or "space"
+	IndentSize         int    `json:"indent_size,omitempty"`
+	MaxLineLength      int    `json:"max_line_length,omitempty"`
+	EndOfLine          string `json:"end_of_line,omitempty"`
+	InsertFinalNewline *bool  `json:"insert_final_newline,omitempty"`
+}
+
+// section is a glob section of an .editorconfig file; a pattern that does not compile matches nothing
+type section struct {
+	pattern  string
+	glob     *glob
+	settings Settings
+}
+
+// Conventions describes the formatting conventions detected for a project
+type Conventions struct {
+	root       string
+	sections   []section
+	Formatters []string
+}
+
+// Detect reads .editorconfig and formatter configuration files under rootPath
+func Detect(rootPath string) (*Conventions, error) {
+	root, err := filepath.Abs(rootPath)
+	if err != nil {
+		return nil, err
+	}
+	conventions := &Conventions{root: root}
+
+	sections, err := parseEditorConfig(filepath.Join(rootPath, ".editorconfig"))
+	if err != nil {
+		return nil, fmt.Errorf("error reading .editorconfig: %w", err)
+	}
+	conventions.sections = sections
+
+	conventions.Formatters = detectFormatters(rootPath)
+
+	return conventions, nil
+}
+
+// ForFile returns the merged settings that apply to the given file, given by an absolute path or
+// one relative to the project root. Files outside the project get no .editorconfig settings.
+func (c *Conventions) ForFile(filePath string) Settings {
+	var merged Settings
+	name := filepath.Base(filePath)
+	rel := filepath.ToSlash(filePath)
+	if filepath.IsAbs(filePath) {
+		r, err := filepath.Rel(c.root, filePath)
+		if err != nil || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) {
+			r = ""
+		}
+		rel = filepath.ToSlash(r)
+	}
+
+	// Later sections override earlier ones, as in the editorconfig spec
+	for _, s := range c.sections {
+		if rel == "" || s.glob == nil || !s.glob.match(rel) {
+			continue
+		}
+		if s.settings.IndentStyle != "" {
+			merged.IndentStyle = s.settings.IndentStyle
+		}
+		if s.settings.IndentSize != 0 {
+			merged.IndentSize = s.settings.IndentSize
+		}
+		if s.settings.MaxLineLength != 0 {
+			merged.MaxLineLength = s.settings.MaxLineLength
+		}
+		if s.settings.EndOfLine != "" {
+			merged.EndOfLine = s.settings.EndOfLine
+		}
+		if s.settings.InsertFinalNewline != nil {
+			merged.InsertFinalNewline = s.settings.InsertFinalNewline
+		}
+	}
+
+	// Go files are always tab-indented by gofmt
+	if strings.HasSuffix(name, ".go") {
+		merged.IndentStyle = "tab"
+	}
+
+	return merged
+}
+
+// Describe returns a prompt-ready description of the conventions for a file.
+// An empty filePath describes the project-wide defaults.
+func (c *Conventions) Describe(filePath string) string {
+	if c == nil {
+		return ""
+	}
+
+	var lines []string
+	settings := c.ForFile(filePath)
+	if filePath == "" {
+		settings = c.ForFile("*")
+	}
+
+	if settings.IndentStyle == "tab" {
+		lines = append(lines, "Indent with tabs")
+	} else if settings.IndentStyle == "space" {
+		size := settings.IndentSize
+		if size == 0 {
+			size = 4
+		}
+		lines = append(lines, fmt.Sprintf("Indent with %d spaces", size))
+	}
+	if settings.MaxLineLength > 0 {
+		lines = append(lines, fmt.Sprintf("Keep lines under %d characters", settings.MaxLineLength))
+	}
+	if settings.EndOfLine != "" {
+		lines = append(lines, fmt.Sprintf("Use %s line endings", strings.ToUpper(settings.EndOfLine)))
+	}
+	if settings.InsertFinalNewline != nil && *settings.InsertFinalNewline {
+		lines = append(lines, "End files with a newline")
+	}
+	if len(c.Formatters) > 0 {
+		lines = append(lines, fmt.Sprintf("Code must pass: %s", strings.Join(c.Formatters, ", ")))
+	}
+
+	if len(lines) == 0 {
+		return ""
+	}
+	return "- " + strings.Join(lines, "\n- ")
+}
+
+// parseEditorConfig parses the sections of an .editorconfig file
+func parseEditorConfig(path string) ([]section, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var sections []section
+	var current *section
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		// Skip empty lines and comments
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			pattern := line[1 : len(line)-1]
+			g, _ := compileGlob(pattern)
+			sections = append(sections, section{pattern: pattern, glob: g})
+			current = &sections[len(sections)-1]
+			continue
+		}
+
+		// Properties before the first section (like root = true) are ignored
+		if current == nil {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.ToLower(strings.TrimSpace(value))
+
+		switch key {
+		case "indent_style":
+			current.settings.IndentStyle = value
+		case "indent_size", "tab_width":
+			if n, err := strconv.Atoi(value); err == nil && current.settings.IndentSize == 0 {
+				current.settings.IndentSize = n
+			}
+		case "max_line_length":
+			if n, err := strconv.Atoi(value); err == nil {
+				current.settings.MaxLineLength = n
+			}
+		case "end_of_line":
+			current.settings.EndOfLine = value
+		case "insert_final_newline":
+			b := value == "true"
+			current.settings.InsertFinalNewline = &b
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return sections, nil
+}
+
+// detectFormatters returns the formatters and linters configured in the project
+func detectFormatters(rootPath string) []string {
+	var formatters []string
+
+	if fileContains(filepath.Join(rootPath, "go.mod"), "") {
+		if fileContains(filepath.Join(rootPath, ".golangci.yml"), "gofumpt") ||
+			fileContains(filepath.Join(rootPath, ".golangci.yaml"), "gofumpt") ||
+			fileContains(filepath.Join(rootPath, "go.mod"), "mvdan.cc/gofumpt") {
+			formatters = append(formatters, "gofumpt")
+		} else {
+			formatters = append(formatters, "gofmt")
+		}
+		if fileContains(filepath.Join(rootPath, ".golangci.yml"), "goimports") ||
+			fileContains(filepath.Join(rootPath, ".golangci.yaml"), "goimports") {
+			formatters = append(formatters, "goimports")
+		}
+	}
+
+	if prettier := prettierSettings(rootPath); prettier != "" {
+		formatters = append(formatters, prettier)
+	}
+
+	pyproject := filepath.Join(rootPath, "pyproject.toml")
+	if fileContains(pyproject, "[tool.black]") {
+		formatters = append(formatters, "black")
+	}
+	if fileContains(pyproject, "[tool.ruff") || fileContains(filepath.Join(rootPath, "ruff.toml"), "") {
+		formatters = append(formatters, "ruff")
+	}
+
+	if fileContains(filepath.Join(rootPath, "rustfmt.toml"), "") || fileContains(filepath.Join(rootPath, ".rustfmt.toml"), "") {
+		formatters = append(formatters, "rustfmt")
+	}
+
+	return formatters
+}
+
+// prettierSettings describes the prettier configuration if one is present
+func prettierSettings(rootPath string) string {
+	for _, name := range []string{".prettierrc", ".prettierrc.json"} {
+		data, err := os.ReadFile(filepath.Join(rootPath, name))
+		if err != nil {
+			continue
+		}
+
+		var cfg struct {
+			PrintWidth int   `json:"printWidth"`
+			TabWidth   int   `json:"tabWidth"`
+			UseTabs    bool  `json:"useTabs"`
+			Semi       *bool `json:"semi"`
+		}
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return "prettier"
+		}
+
+		var opts []string
+		if cfg.PrintWidth > 0 {
+			opts = append(opts, fmt.Sprintf("printWidth %d", cfg.PrintWidth))
+		}
+		if cfg.UseTabs {
+			opts = append(opts, "tabs")
+		} else if cfg.TabWidth > 0 {
+			opts = append(opts, fmt.Sprintf("tabWidth %d", cfg.TabWidth))
+		}
+		if cfg.Semi != nil && !*cfg.Semi {
+			opts = append(opts, "no semicolons")
+		}
+		if len(opts) == 0 {
+			return "prettier"
+		}
+		return fmt.Sprintf("prettier (%s)", strings.Join(opts, ", "))
+	}
+
+	if fileContains(filepath.Join(rootPath, "package.json"), "\"prettier\"") {
+		return "prettier"
+	}
+	return ""
+}
+
+// fileContains reports whether the file exists and contains substr
+func fileContains(path string, substr string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(data), substr)
+}
@@ -0,0 +1,123 @@
+package style
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*", "main.go", true},
+		{"*", "cmd/wash/main.go", true},
+		{"*.go", "cmd/wash/main.go", true},
+		{"*.go", "main.go.txt", false},
+		{"Makefile", "build/Makefile", true},
+		{"*.{js,ts}", "web/app.ts", true},
+		{"*.{js,ts}", "web/app.tsx", false},
+		{"{package.json,*.yml}", ".github/ci.yml", true},
+		{"lib/*.js", "lib/index.js", true},
+		{"lib/*.js", "lib/sub/index.js", false},
+		{"lib/*.js", "src/lib/index.js", false},
+		{"/lib/*.js", "lib/index.js", true},
+		{"lib/**.js", "lib/sub/index.js", true},
+		{"a/**/b.txt", "a/b.txt", true},
+		{"a/**/b.txt", "a/x/y/b.txt", true},
+		{"file?.md", "file1.md", true},
+		{"file?.md", "file10.md", false},
+		{"[abc].c", "b.c", true},
+		{"[!abc].c", "b.c", false},
+		{"[!abc].c", "d.c", true},
+		{"test{1..10}.py", "test7.py", true},
+		{"test{1..10}.py", "test11.py", false},
+		{"{single}.txt", "{single}.txt", true},
+		{"{a,{b,c}}.txt", "c.txt", true},
+		{`\*.txt`, "*.txt", true},
+		{`\*.txt`, "a.txt", false},
+	}
+
+	for _, tt := range tests {
+		g, err := compileGlob(tt.pattern)
+		if err != nil {
+			t.Errorf("compileGlob(%q) failed: %v", tt.pattern, err)
+			continue
+		}
+		if got := g.match(tt.path); got != tt.want {
+			t.Errorf("%q matching %q = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestDetectAndDescribe(t *testing.T) {
+	root := t.TempDir()
+	editorconfig := `root = true
+
+[*]
+indent_style = space
+indent_size = 2
+insert_final_newline = true
+
+[*.go]
+indent_style = tab
+
+[docs/**.md]
+max_line_length = 80
+
+[Makefile]
+indent_style = tab
+`
+	if err := os.WriteFile(filepath.Join(root, ".editorconfig"), []byte(editorconfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".prettierrc"), []byte(`{"printWidth": 100, "semi": false}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	conventions, err := Detect(root)
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+	if len(conventions.Formatters) != 1 || conventions.Formatters[0] != "prettier (printWidth 100, no semicolons)" {
+		t.Errorf("Unexpected formatters %v", conventions.Formatters)
+	}
+
+	tests := []struct {
+		path    string
+		want    []string
+		notWant []string
+	}{
+		{"", []string{"Indent with 2 spaces", "End files with a newline", "Code must pass: prettier"}, []string{"Keep lines"}},
+		{filepath.Join(root, "web", "app.js"), []string{"Indent with 2 spaces"}, []string{"tabs"}},
+		{filepath.Join(root, "cmd", "main.go"), []string{"Indent with tabs"}, nil},
+		{filepath.Join(root, "docs", "guide", "intro.md"), []string{"Keep lines under 80 characters"}, nil},
+		{"docs/intro.md", []string{"Keep lines under 80 characters"}, nil},
+		// The section is relative to the .editorconfig, so a docs directory elsewhere does not match
+		{filepath.Join(root, "web", "docs", "intro.md"), nil, []string{"Keep lines"}},
+		{filepath.Join(root, "build", "Makefile"), []string{"Indent with tabs"}, nil},
+		// Files outside the project only get the formatters
+		{filepath.Join(filepath.Dir(root), "other.js"), []string{"Code must pass"}, []string{"Indent"}},
+	}
+	for _, tt := range tests {
+		description := conventions.Describe(tt.path)
+		for _, want := range tt.want {
+			if !strings.Contains(description, want) {
+				t.Errorf("Describe(%q) = %q, missing %q", tt.path, description, want)
+			}
+		}
+		for _, notWant := range tt.notWant {
+			if strings.Contains(description, notWant) {
+				t.Errorf("Describe(%q) = %q, should not contain %q", tt.path, description, notWant)
+			}
+		}
+	}
+
+	var none *Conventions
+	if none.Describe("main.go") != "" {
+		t.Error("Expected no description without conventions")
+	}
+}
@@ -0,0 +1,195 @@
+package templates
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/attachments"
+	"github.com/bkidd1/wash-cli/internal/utils/config"
+	"github.com/bkidd1/wash-cli/internal/utils/git"
+)
+
+const (
+	// BugReport renders bug reports saved by wash bug
+	BugReport = "bug_report"
+	// ProgressNote renders progress notes as markdown in the wash notes browse preview
+	ProgressNote = "progress_note"
+
+	// fileExt is the extension of user template files
+	fileExt = ".md.tmpl"
+)
+
+// BugReportData is the data available to the bug report template
+type BugReportData struct {
+	ReportedAt         time.Time
+	Project            string
+	Description        string
+	PotentialCauses    string
+	SuggestedSolutions string
+	Priority           string
+	Status             string
+	Attachments        []attachments.Attachment
+	// Git is the state of the repository the bug was reported in, or nil outside one
+	Git *git.Context
+	// Fallback names the model that analyzed the bug when the configured one was unavailable
+	Fallback string
+	// SourceFiles names the project files sent with the analysis as relevant to the bug
+	SourceFiles []string
+}
+
+// defaults holds the built-in templates, used when no user template exists
+var defaults = map[string]string{
+	BugReport: `# Bug Report
+*Reported on {{ .ReportedAt | datetime }}*
+{{- with .Fallback }}
+*Analyzed with {{ . }}*
+{{- end }}
+
+## Description
+{{ .Description }}
+
+## Suggested Solutions
+{{ .SuggestedSolutions }}
+
+## Priority
+{{ .Priority }}
+
+## Status
+{{ .Status }}
+{{- if .Git }}
+
+## Git Context
+{{ .Git }}
+{{- end }}
+{{- if .SourceFiles }}
+
+## Related Files
+{{ range .SourceFiles }}- {{ . }}
+{{ end }}
+{{- end }}
+{{- if .Attachments }}
+
+## Attachments
+{{ range .Attachments }}- {{ .Name }} ({{ .MediaType }}, {{ .Size }} bytes) {{ .Ref }}
+{{ end }}
+{{- end }}
+
+## Notes
+`,
+	ProgressNote: `# {{ .Title }}
+*{{ .Type }} note for {{ .ProjectName }} on {{ .Timestamp | datetime }}*
+
+{{ .Description }}
+{{- if .Changes.FilesModified }}
+
+## Files Modified
+{{ range .Changes.FilesModified }}- {{ . }}
+{{ end }}
+{{- end }}
+{{- if .Attachments }}
+
+## Attachments
+{{ range .Attachments }}- {{ .Name }} {{ .Ref }}
+{{ end }}
+{{- end }}
+{{- if .Metadata.Tags }}
+
+Tags: {{ join .Metadata.Tags ", " }}
+{{- end }}
+`,
+}
+
+// funcs are the helper functions available in templates
+var funcs = template.FuncMap{
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"datetime": func(t time.Time) string {
+		return t.Format("2006-01-02 15:04:05")
+	},
+	"date": func(t time.Time) string {
+		return t.Format("2006-01-02")
+	},
+	"env": os.Getenv,
+}
+
+// Dir returns the directory holding user templates
+func Dir() (string, error) {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "templates"), nil
+}
+
+// Names returns the names of the built-in templates
+func Names() []string {
+	names := make([]string, 0, len(defaults))
+	for name := range defaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Default returns the built-in text of a template
+func Default(name string) (string, error) {
+	text, ok := defaults[name]
+	if !ok {
+		return "", fmt.Errorf("unknown template %s", name)
+	}
+	return text, nil
+}
+
+// Path returns where the user's copy of a template lives
+func Path(name string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name+fileExt), nil
+}
+
+// Load returns the user's template if one exists in ~/.wash/templates, otherwise the built-in one
+func Load(name string) (*template.Template, error) {
+	text, err := Default(name)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := Path(name)
+	if err != nil {
+		return nil, err
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		text = string(data)
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("error reading template %s: %w", path, err)
+	}
+
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing template %s: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// Render renders the named template with data
+func Render(name string, data interface{}) (string, error) {
+	tmpl, err := Load(name)
+	if err != nil {
+		return "", err
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("error rendering template %s: %w", name, err)
+	}
+	return out.String(), nil
+}
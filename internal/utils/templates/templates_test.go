@@ -0,0 +1,60 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bkidd1/wash-cli/internal/services/notes"
+)
+
+func TestRenderDefaults(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	report, err := Render(BugReport, BugReportData{
+		ReportedAt:         time.Date(2025, 3, 1, 9, 30, 0, 0, time.UTC),
+		Description:        "Crash on save",
+		SuggestedSolutions: "- Check for nil",
+		Priority:           "high",
+		Status:             "Open",
+	})
+	if err != nil {
+		t.Fatalf("Failed to render bug report: %v", err)
+	}
+	if !strings.Contains(report, "*Reported on 2025-03-01 09:30:00*") || !strings.Contains(report, "## Priority\nhigh") {
+		t.Errorf("Unexpected bug report:\n%s", report)
+	}
+
+	note := &notes.ProjectProgressNote{Title: "Refactor", ProjectName: "demo", Type: "refactor"}
+	note.Changes.FilesModified = []string{"main.go"}
+	rendered, err := Render(ProgressNote, note)
+	if err != nil {
+		t.Fatalf("Failed to render progress note: %v", err)
+	}
+	if !strings.Contains(rendered, "- main.go") {
+		t.Errorf("Expected modified files in progress note:\n%s", rendered)
+	}
+}
+
+func TestUserTemplateOverridesDefault(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	dir := filepath.Join(home, ".wash", "templates")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, BugReport+fileExt), []byte("TICKET: {{ .Description | upper }}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := Render(BugReport, BugReportData{Description: "crash"})
+	if err != nil {
+		t.Fatalf("Failed to render bug report: %v", err)
+	}
+	if report != "TICKET: CRASH" {
+		t.Errorf("Expected user template output, got %q", report)
+	}
+}
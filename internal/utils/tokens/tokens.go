@@ -0,0 +1,172 @@
+package tokens
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+const (
+	// defaultContextWindow is used for models missing from the pricing table
+	defaultContextWindow = 8192
+	// DefaultCompletionTokens is the completion size assumed when a request sets no MaxTokens
+	DefaultCompletionTokens = 1000
+	// LargeRequestTokens is the prompt size above which commands print a cost estimate
+	LargeRequestTokens = 2000
+	// messageOverhead approximates the tokens added per chat message by the API
+	messageOverhead = 4
+)
+
+// ModelInfo holds the context window and pricing of a model
+type ModelInfo struct {
+	ContextWindow int `json:"context_window"`
+	// InputPer1K and OutputPer1K are USD prices per 1,000 tokens
+	InputPer1K  float64 `json:"input_per_1k"`
+	OutputPer1K float64 `json:"output_per_1k"`
+}
+
+// BundledPricesDate is when the prices in Models were last checked
+const BundledPricesDate = "2025-04-14"
+
+// Models contains the known models and their pricing; it starts as the bundled table and
+// is updated by UpdateModels with downloaded prices
+var Models = map[string]ModelInfo{
+	"gpt-4":         {ContextWindow: 8192, InputPer1K: 0.03, OutputPer1K: 0.06},
+	"gpt-4-turbo":   {ContextWindow: 128000, InputPer1K: 0.01, OutputPer1K: 0.03},
+	"gpt-4o":        {ContextWindow: 128000, InputPer1K: 0.0025, OutputPer1K: 0.01},
+	"gpt-4o-mini":   {ContextWindow: 128000, InputPer1K: 0.00015, OutputPer1K: 0.0006},
+	"gpt-4.1":       {ContextWindow: 1047576, InputPer1K: 0.002, OutputPer1K: 0.008},
+	"gpt-4.1-mini":  {ContextWindow: 1047576, InputPer1K: 0.0004, OutputPer1K: 0.0016},
+	"gpt-3.5-turbo": {ContextWindow: 16385, InputPer1K: 0.0005, OutputPer1K: 0.0015},
+}
+
+var (
+	loaderOnce sync.Once
+	encoders   = make(map[string]*tiktoken.Tiktoken)
+	encodersMu sync.Mutex
+)
+
+// encoderFor returns the cached tokenizer for a model
+func encoderFor(model string) (*tiktoken.Tiktoken, error) {
+	// Use the embedded BPE files so counting works offline
+	loaderOnce.Do(func() {
+		tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader())
+	})
+
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+
+	if enc, ok := encoders[model]; ok {
+		return enc, nil
+	}
+
+	enc, err := tiktoken.EncodingForModel(model)
+	if err != nil {
+		// Fall back to the encoding shared by current chat models
+		enc, err = tiktoken.GetEncoding("cl100k_base")
+		if err != nil {
+			return nil, fmt.Errorf("error loading tokenizer: %w", err)
+		}
+	}
+
+	encoders[model] = enc
+	return enc, nil
+}
+
+// Count returns the number of tokens in text for the given model
+func Count(model string, text string) int {
+	enc, err := encoderFor(model)
+	if err != nil {
+		// Roughly four characters per token for English text and code
+		return len(text)/4 + 1
+	}
+	return len(enc.EncodeOrdinary(text))
+}
+
+// CountMessages returns the prompt size of a list of message contents
+func CountMessages(model string, contents ...string) int {
+	total := 3 // every reply is primed with the assistant role
+	for _, content := range contents {
+		total += Count(model, content) + messageOverhead
+	}
+	return total
+}
+
+// UpdateModels replaces the info of the given models and adds the ones that are new.
+// It must be called before estimates are made concurrently.
+func UpdateModels(models map[string]ModelInfo) {
+	for name, info := range models {
+		Models[name] = info
+	}
+}
+
+// Lookup returns the model info, falling back to the closest known model
+func Lookup(model string) ModelInfo {
+	if info, ok := Models[model]; ok {
+		return info
+	}
+
+	// Match dated variants like gpt-4o-2024-05-13 to their base model
+	best := ""
+	for name := range Models {
+		if strings.HasPrefix(model, name+"-") && len(name) > len(best) {
+			best = name
+		}
+	}
+	if best != "" {
+		return Models[best]
+	}
+
+	return ModelInfo{ContextWindow: defaultContextWindow}
+}
+
+// ContextWindow returns the context window size of a model
+func ContextWindow(model string) int {
+	return Lookup(model).ContextWindow
+}
+
+// Estimate describes the expected size and cost of a request
+type Estimate struct {
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	Cost             float64
+}
+
+// NewEstimate builds an estimate for a request with the given prompt and completion sizes
+func NewEstimate(model string, promptTokens int, completionTokens int) Estimate {
+	if completionTokens <= 0 {
+		completionTokens = DefaultCompletionTokens
+	}
+	info := Lookup(model)
+	return Estimate{
+		Model:            model,
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		Cost:             float64(promptTokens)/1000*info.InputPer1K + float64(completionTokens)/1000*info.OutputPer1K,
+	}
+}
+
+// String returns a one-line summary of the estimate
+func (e Estimate) String() string {
+	return fmt.Sprintf("~%d prompt tokens + up to %d completion tokens on %s (estimated cost: $%.4f)",
+		e.PromptTokens, e.CompletionTokens, e.Model, e.Cost)
+}
+
+// SplitLines returns how many of the leading lines fit within the token budget. It returns at least
+// one when there are lines, so a first line over the budget makes a part of its own and callers
+// splitting text into parts always make progress.
+func SplitLines(model string, lines []string, budget int) int {
+	used := 0
+	for i, line := range lines {
+		// Account for the newline joining each line
+		used += Count(model, line+"\n")
+		if used > budget {
+			return max(i, 1)
+		}
+	}
+	return len(lines)
+}
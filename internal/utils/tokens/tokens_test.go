@@ -0,0 +1,72 @@
+package tokens
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"hello", 1},
+		{"hello world", 2},
+		{strings.Repeat("word ", 100), 101},
+	}
+	for _, tt := range tests {
+		if got := Count("gpt-4o", tt.text); got != tt.want {
+			t.Errorf("Count(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+
+	// Unknown models use the encoding shared by current chat models
+	if Count("some-future-model", "hello world") == 0 {
+		t.Error("Expected tokens for an unknown model")
+	}
+}
+
+func TestSplitLines(t *testing.T) {
+	// Each line and its newline are two tokens
+	lines := []string{"alpha", "beta", "gamma", "delta"}
+	tests := []struct {
+		name   string
+		lines  []string
+		budget int
+		want   int
+	}{
+		{"everything fits", lines, 100, 4},
+		{"exact fit", lines, 4, 2},
+		{"partial", lines, 7, 3},
+		{"first line over budget", lines, 1, 1},
+		{"no budget", lines, 0, 1},
+		{"no lines", nil, 10, 0},
+	}
+	for _, tt := range tests {
+		if got := SplitLines("gpt-4o", tt.lines, tt.budget); got != tt.want {
+			t.Errorf("%s: SplitLines = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		model  string
+		window int
+		input  float64
+	}{
+		{"gpt-4o", 128000, 0.0025},
+		{"gpt-4o-2024-05-13", 128000, 0.0025},
+		// The longest matching base wins over gpt-4o
+		{"gpt-4o-mini-2024-07-18", 128000, 0.00015},
+		{"gpt-4-0613", 8192, 0.03},
+		{"unknown-model", defaultContextWindow, 0},
+	}
+	for _, tt := range tests {
+		info := Lookup(tt.model)
+		if info.ContextWindow != tt.window || info.InputPer1K != tt.input {
+			t.Errorf("Lookup(%q) = %+v, want window %d and input %v", tt.model, info, tt.window, tt.input)
+		}
+	}
+}